@@ -41,18 +41,19 @@ func Abs(value int) int {
 	return value
 }
 
-// ProcessData demonstrates moderate cognitive complexity
-// Contains: for loop, multiple if statements, logical operators
+// ProcessData demonstrates moderate cognitive complexity (complexity: 13)
+// Contains: for loop, if/else-if/else chains, logical operators
+// Each else if / else adds a flat +1, whatever the nesting depth
 func ProcessData(numbers []int, includeNegatives bool) []string {
 	result := make([]string, 0)
 
 	for _, number := range numbers { // +1
-		if number > 0 { // +1
+		if number > 0 { // +2 (nesting = 1)
 			result = append(result, fmt.Sprintf("%d", number))
-		} else if includeNegatives && number < 0 { // +1 for if, +1 for &&
+		} else if includeNegatives && number < 0 { // +1 for else if, +3 for && (nesting = 2)
 			result = append(result, fmt.Sprintf("(%d)", -number))
-		} else {
-			continue // +1 (nested)
+		} else { // +1
+			continue // +3 (nesting = 2)
 		}
 	}
 
@@ -121,10 +122,10 @@ func SelectExample(ch1, ch2 chan int, done chan bool) int {
 	}
 }
 
-// ConcurrentWorker demonstrates goroutines and select in a loop (complexity: 2)
+// ConcurrentWorker demonstrates select in a loop; the select counts once, whatever its cases (complexity: 3)
 func ConcurrentWorker(jobs <-chan int, results chan<- int, done chan bool) {
 	for { // +1
-		select { // +1
+		select { // +2 (nesting = 1)
 		case job := <-jobs:
 			results <- job * 2
 		case <-done:
@@ -223,6 +224,32 @@ func ClosureExample(items []int) []int {
 	return result
 }
 
+// Filter is a generic higher-order function (complexity: 2)
+func Filter[T any](items []T, keep func(T) bool) []T {
+	result := make([]T, 0, len(items))
+	for _, item := range items { // +1
+		if keep(item) { // +2 (nesting = 1)
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// PositiveEvens passes an inline predicate with branches to a generic function.
+// Inline (default) complexity: 4, as the predicate body is nested in the literal.
+// With closureMode "separate" this is 0 and the predicate is reported on its own
+// as PositiveEvens.func1 (complexity: 3)
+func PositiveEvens(numbers []int) []int {
+	return Filter(numbers, func(n int) bool {
+		if n <= 0 { // +1 (+1 nesting when inline)
+			return false
+		} else if n%2 != 0 { // +1
+			return false
+		}
+		return n < 1000 || n%10 == 0 // +1 for ||
+	})
+}
+
 // LogicalOperatorChain demonstrates multiple logical operators
 func LogicalOperatorChain(a, b, c, d bool) bool {
 	return a && b || c && d // +1 for &&, +1 for ||, +1 for &&
@@ -0,0 +1,14 @@
+// Package asm mixes Go and assembly sources, like packages with hand-tuned
+// architecture-specific code. Only the Go files are analyzed.
+package asm
+
+// Sum adds the values, using the assembly implementation where one exists.
+func Sum(values []int64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	if hasAsm {
+		return sumAsm(values)
+	}
+	return sumGeneric(values)
+}
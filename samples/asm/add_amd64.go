@@ -0,0 +1,6 @@
+package asm
+
+const hasAsm = true
+
+//go:noescape
+func sumAsm(values []int64) int64
@@ -0,0 +1,17 @@
+#include "textflag.h"
+
+// func sumAsm(values []int64) int64
+TEXT ·sumAsm(SB), NOSPLIT, $0-32
+	MOVQ values_base+0(FP), SI
+	MOVQ values_len+8(FP), CX
+	XORQ AX, AX
+loop:
+	TESTQ CX, CX
+	JZ done
+	ADDQ (SI), AX
+	ADDQ $8, SI
+	DECQ CX
+	JMP loop
+done:
+	MOVQ AX, ret+24(FP)
+	RET
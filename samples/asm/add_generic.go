@@ -0,0 +1,7 @@
+//go:build !amd64
+
+package asm
+
+const hasAsm = false
+
+func sumAsm(values []int64) int64 { return sumGeneric(values) }
@@ -0,0 +1,9 @@
+package asm
+
+func sumGeneric(values []int64) int64 {
+	var total int64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
@@ -0,0 +1,85 @@
+// Package callbacks passes closures with their own conditions to standard-library
+// callback APIs, to check how inline callbacks are measured.
+package callbacks
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Person struct {
+	Name string
+	Age  int
+}
+
+// SortPeople orders people by age, then by name.
+func SortPeople(people []Person) {
+	sort.Slice(people, func(i, j int) bool {
+		if people[i].Age != people[j].Age {
+			return people[i].Age < people[j].Age
+		}
+		return people[i].Name < people[j].Name
+	})
+}
+
+// SortGroups orders the members of every group by name.
+func SortGroups(groups map[string][]Person) {
+	for _, members := range groups {
+		sort.SliceStable(members, func(i, j int) bool {
+			return members[i].Name < members[j].Name
+		})
+	}
+}
+
+// Normalize lowercases letters, keeps digits, and drops everything else.
+func Normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r + ('a' - 'A')
+		}
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// ListGoFiles collects the Go files under root, skipping hidden directories.
+func ListGoFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if strings.HasSuffix(path, ".go") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// Registry loads its entries on first use.
+type Registry struct {
+	once    sync.Once
+	entries map[string]int
+}
+
+// Entries returns the index of every non-empty name, built once.
+func (r *Registry) Entries(names []string) map[string]int {
+	r.once.Do(func() {
+		r.entries = make(map[string]int)
+		for i, name := range names {
+			if name != "" {
+				r.entries[name] = i
+			}
+		}
+	})
+	return r.entries
+}
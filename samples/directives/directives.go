@@ -0,0 +1,48 @@
+//go:build linux || darwin
+// +build linux darwin
+
+// Package directives mixes compiler directives and tool comments with code; its
+// metrics must match the directive-free copy in plain/.
+package directives
+
+//go:generate stringer -type=Level
+//go:generate go run gen.go -output tables_gen.go
+
+type Level int
+
+//go:noinline
+//go:nosplit
+func Clamp(v, lo, hi int) int {
+	//line clamp.go:1
+	if v < lo {
+		return lo
+	}
+	//nolint:gomnd
+	if v > hi && hi != 0 {
+		return hi
+	}
+	return v
+}
+
+//export Process
+//go:norace
+func Process(items []int) (total int) {
+	for _, item := range items {
+		//go:nocheckptr
+		switch {
+		case item < 0:
+			continue
+		case item > 100:
+			//lint:ignore SA4006 capped on purpose
+			total += 100
+		default:
+			total += item
+		}
+	}
+	return
+}
+
+//go:generate echo done
+func (l Level) Valid() bool {
+	return l >= 0 && l < 3 //nolint:gomnd
+}
@@ -0,0 +1,51 @@
+// Package directives embeds files with //go:embed; its metrics must match the
+// directive-free copy in plain/. The embedded files themselves are not part of the sample.
+package directives
+
+import (
+	"embed"
+	"strings"
+)
+
+//go:embed version.txt
+var version string
+
+//go:embed templates/*.tmpl static
+//go:embed "assets/logo one.png"
+var assets embed.FS
+
+var (
+	//go:embed banner.txt
+	banner []byte
+
+	//go:embed LICENSE
+	license string
+)
+
+// Version returns the embedded version without surrounding whitespace.
+func Version() string {
+	v := strings.TrimSpace(version)
+	if v == "" {
+		return "dev"
+	}
+	return v
+}
+
+// Banner returns the embedded banner, or the license when the banner is empty.
+//
+//go:noinline
+func Banner(short bool) string {
+	if len(banner) == 0 || short {
+		return license
+	}
+	return string(banner)
+}
+
+// Template reads one embedded template.
+func Template(name string) (string, error) {
+	data, err := assets.ReadFile("templates/" + name + ".tmpl")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,32 @@
+// Package directives is the directive-free copy of ../directives.go.
+package directives
+
+type Level int
+
+func Clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi && hi != 0 {
+		return hi
+	}
+	return v
+}
+
+func Process(items []int) (total int) {
+	for _, item := range items {
+		switch {
+		case item < 0:
+			continue
+		case item > 100:
+			total += 100
+		default:
+			total += item
+		}
+	}
+	return
+}
+
+func (l Level) Valid() bool {
+	return l >= 0 && l < 3
+}
@@ -0,0 +1,40 @@
+// Package directives is the directive-free copy of ../embed.go.
+package directives
+
+import (
+	"embed"
+	"strings"
+)
+
+var version string
+
+var assets embed.FS
+
+var (
+	banner []byte
+
+	license string
+)
+
+func Version() string {
+	v := strings.TrimSpace(version)
+	if v == "" {
+		return "dev"
+	}
+	return v
+}
+
+func Banner(short bool) string {
+	if len(banner) == 0 || short {
+		return license
+	}
+	return string(banner)
+}
+
+func Template(name string) (string, error) {
+	data, err := assets.ReadFile("templates/" + name + ".tmpl")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,51 @@
+// Package dispatch routes commands through tables of handler funcs, as command-line
+// tools and routers do, to check that each func value of a map or slice literal is
+// measured as its own entry.
+package dispatch
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Handler runs one command.
+type Handler func(args []string) error
+
+// NewRouter builds the dispatch table of the tool's commands.
+func NewRouter(verbose bool) map[string]Handler {
+	if verbose {
+		fmt.Println("building router")
+	}
+	return map[string]Handler{
+		"add": func(args []string) error {
+			if len(args) != 2 {
+				return errors.New("add needs two arguments")
+			}
+			return nil
+		},
+		"list": func(args []string) error {
+			for _, arg := range args {
+				if strings.HasPrefix(arg, "-") {
+					return fmt.Errorf("unknown flag %s", arg)
+				}
+			}
+			return nil
+		},
+		"help": func(args []string) error {
+			return nil
+		},
+	}
+}
+
+// Middleware returns the checks run before every command, in order.
+func Middleware() []Handler {
+	return []Handler{
+		func(args []string) error {
+			if len(args) == 0 {
+				return errors.New("no arguments")
+			}
+			return nil
+		},
+	}
+}
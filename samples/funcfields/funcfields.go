@@ -0,0 +1,77 @@
+// Package funcfields assigns func literals to struct fields and map entries, as HTTP
+// routers and test doubles do, to check that each literal is measured as its own entry.
+package funcfields
+
+import (
+	"errors"
+	"net/http"
+)
+
+type Route struct {
+	Path    string
+	Handler http.HandlerFunc
+}
+
+// routes is initialized at package level, outside any function.
+var routes = []Route{
+	{
+		Path: "/health",
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	},
+	{
+		Path: "/users",
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		},
+	},
+}
+
+type User struct {
+	ID   string
+	Name string
+}
+
+// FakeStore is a test double whose behavior is set per test.
+type FakeStore struct {
+	GetFn    func(id string) (*User, error)
+	DeleteFn func(id string) error
+}
+
+// NewFakeStore returns a store that knows one user.
+func NewFakeStore(known *User) *FakeStore {
+	if known == nil {
+		return &FakeStore{}
+	}
+	return &FakeStore{
+		GetFn: func(id string) (*User, error) {
+			if id == known.ID {
+				return known, nil
+			}
+			return nil, errors.New("not found")
+		},
+		DeleteFn: func(id string) error {
+			for _, ch := range id {
+				if ch == '/' {
+					return errors.New("invalid id")
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// commands dispatches by name through a map of funcs.
+var commands = map[string]func(args []string) error{
+	"echo": func(args []string) error {
+		if len(args) == 0 {
+			return errors.New("nothing to echo")
+		}
+		return nil
+	},
+}
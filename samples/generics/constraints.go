@@ -0,0 +1,66 @@
+// Package constraints mixes constraint interfaces (type sets with union and ~ elements)
+// with generic and regular functions, to check that type sets add no entries or
+// complexity of their own.
+package constraints
+
+import "fmt"
+
+// Integer is a type set of integer kinds.
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+// Number embeds Integer in a larger union.
+type Number interface {
+	Integer | ~float32 | ~float64
+}
+
+// Label is a type set with a method: any string type that can describe itself.
+type Label interface {
+	~string
+	Describe() string
+}
+
+// Sum adds up the values of a slice.
+func Sum[T Number](values []T) T {
+	var total T
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// Clamp limits v to the range [lo, hi], with an inline constraint.
+func Clamp[T interface{ ~int | ~float64 }](v, lo, hi T) T {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// Set is a generic set.
+type Set[T comparable] map[T]struct{}
+
+// Add inserts a value.
+func (s Set[T]) Add(v T) {
+	s[v] = struct{}{}
+}
+
+// Flags combines bit flags; its | is an operator, unlike the ones in type sets.
+func Flags(read, write bool) int {
+	flags := 0
+	if read && write {
+		flags = 1 | 2
+	}
+	return flags
+}
+
+// Print writes every label.
+func Print[L Label](labels []L) {
+	for _, l := range labels {
+		fmt.Println(l.Describe())
+	}
+}
@@ -0,0 +1,62 @@
+// Package containers defines generic container types whose methods name their type
+// parameters differently, to check that they are named and grouped under one type.
+package containers
+
+// Stack is a last-in, first-out stack.
+type Stack[T any] struct {
+	items []T
+}
+
+// Push adds a value on top.
+func (s *Stack[T]) Push(v T) {
+	s.items = append(s.items, v)
+}
+
+// Pop removes the top value, reporting whether there was one.
+func (s *Stack[T]) Pop() (T, bool) {
+	var zero T
+	if len(s.items) == 0 {
+		return zero, false
+	}
+	v := s.items[len(s.items)-1]
+	s.items = s.items[:len(s.items)-1]
+	return v, true
+}
+
+// Peek returns the top value without removing it.
+func (s Stack[E]) Peek() (E, bool) {
+	if s.Len() == 0 {
+		var zero E
+		return zero, false
+	}
+	return s.items[s.Len()-1], true
+}
+
+// Len returns the number of values.
+func (s Stack[_]) Len() int {
+	return len(s.items)
+}
+
+// Drain pops every value, visiting each on the way.
+func (s *Stack[V]) Drain(visit func(V)) {
+	if v, ok := s.Pop(); ok {
+		visit(v)
+		s.Drain(visit)
+	}
+}
+
+// Pair holds two values of different types.
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+// Swap returns the pair with its values exchanged.
+func (p Pair[K,V]) Swap() Pair[V, K] {
+	return Pair[V, K]{Key: p.Value, Value: p.Key}
+}
+
+// Matches reports whether the pair has the given key and a non-nil value.
+func (p *Pair[ K, V ]) Matches(key K, present func(V) bool) bool {
+	return p.Key == key && present(p.Value)
+}
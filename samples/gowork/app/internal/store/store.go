@@ -0,0 +1,9 @@
+package store
+
+// Get returns the value stored under key, or def when there is none.
+func Get(values map[string]string, key, def string) string {
+	if v, ok := values[key]; ok {
+		return v
+	}
+	return def
+}
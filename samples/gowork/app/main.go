@@ -0,0 +1,11 @@
+package main
+
+import "fmt"
+
+func main() {
+	for _, arg := range []string{"a", "b"} {
+		if arg != "" {
+			fmt.Println(arg)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package lib
+
+// Clamp limits v to the range [lo, hi].
+func Clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	} else if v > hi {
+		return hi
+	}
+	return v
+}
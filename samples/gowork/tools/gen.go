@@ -0,0 +1,7 @@
+package main
+
+func main() {
+	if len("generate") > 0 {
+		println("generate")
+	}
+}
@@ -0,0 +1,4 @@
+// The tools module is not listed in go.work.
+module example.com/tools
+
+go 1.22
@@ -0,0 +1,219 @@
+/**
+ * @fileoverview Complexity Annotations
+ *
+ * Computes the edits that keep `metrics: cc=N` comments above complex functions, e.g.
+ * `//metrics: cc=14`, so the numbers are committed with the code and show up in diffs
+ * for readers without the extension. Edits are idempotent: an existing annotation
+ * directly above a function is updated in place, annotations of functions that fell
+ * below the threshold or no longer exist are removed, and nothing else is touched.
+ *
+ * Only outermost functions are annotated; closures and nested functions would put
+ * comments inside another function's body.
+ *
+ * The same comment block above a function can carry a `metrics:ignore` directive
+ * (`//metrics:ignore`), which mutes the function's diagnostics.
+ */
+
+import { UnifiedFunctionMetrics } from "../metricsAnalyzer/metricsAnalyzerFactory";
+
+/** Line comment prefix of each language that can carry annotations. */
+const COMMENT_PREFIXES: Record<string, string> = {
+  csharp: "//",
+  dart: "//",
+  elixir: "#",
+  go: "//",
+  java: "//",
+  javascript: "//",
+  javascriptreact: "//",
+  python: "#",
+  rust: "//",
+  sql: "--",
+  typescript: "//",
+  typescriptreact: "//",
+};
+
+/** Prefix of the lines that attach to the declaration below them (decorators, attributes). */
+const ATTRIBUTE_PREFIXES: Record<string, string> = {
+  dart: "@",
+  elixir: "@",
+  python: "@",
+  rust: "#[",
+};
+
+/** A change to one line of the document (0-based lines of the unedited text). */
+export type AnnotationEdit =
+  | { kind: "insert"; line: number; text: string }
+  | { kind: "replace"; line: number; text: string }
+  | { kind: "delete"; line: number };
+
+/**
+ * Returns the line comment prefix used for annotations in a language.
+ *
+ * @param languageId - VS Code language identifier
+ * @returns The prefix, or undefined when the language has no supported line comment
+ */
+export function getCommentPrefix(languageId: string): string | undefined {
+  return COMMENT_PREFIXES[languageId];
+}
+
+/** Matches an annotation line of the given comment prefix (`//` and `#` need no escaping). */
+function getAnnotationPattern(prefix: string): RegExp {
+  return new RegExp(`^\\s*${prefix}metrics: cc=\\d+\\s*$`);
+}
+
+/**
+ * Computes the edits that bring the annotations of a file up to date.
+ *
+ * @param lines - The file's source lines
+ * @param functions - The file's functions (0-based lines)
+ * @param languageId - VS Code language identifier, which selects the comment prefix
+ * @param threshold - Minimum complexity a function needs to be annotated
+ * @returns The edits in line order; empty when the annotations are already current
+ */
+export function getAnnotationEdits(
+  lines: readonly string[],
+  functions: readonly UnifiedFunctionMetrics[],
+  languageId: string,
+  threshold: number
+): AnnotationEdit[] {
+  const prefix = getCommentPrefix(languageId);
+  if (!prefix) {
+    return [];
+  }
+
+  // Complexity to annotate, keyed by the first line of each qualifying function.
+  const wanted = new Map<number, number>();
+  for (const func of functions) {
+    const nested = functions.some(
+      (outer) =>
+        outer !== func &&
+        outer.startLine <= func.startLine &&
+        func.endLine <= outer.endLine &&
+        (outer.startLine < func.startLine || func.endLine < outer.endLine)
+    );
+    if (!nested && func.complexity >= threshold) {
+      wanted.set(func.startLine, Math.max(func.complexity, wanted.get(func.startLine) ?? 0));
+    }
+  }
+
+  const pattern = getAnnotationPattern(prefix);
+  const annotate = (line: number) =>
+    `${/^\s*/.exec(lines[line])![0]}${prefix}metrics: cc=${wanted.get(line)}`;
+  const edits: AnnotationEdit[] = [];
+  for (let i = 0; i < lines.length; i++) {
+    if (!pattern.test(lines[i])) {
+      continue;
+    }
+    if (!wanted.has(i + 1)) {
+      edits.push({ kind: "delete", line: i });
+      continue;
+    }
+    const text = annotate(i + 1);
+    if (lines[i] !== text) {
+      edits.push({ kind: "replace", line: i, text });
+    }
+  }
+  for (const line of wanted.keys()) {
+    if (line === 0 || !pattern.test(lines[line - 1])) {
+      edits.push({ kind: "insert", line, text: annotate(line) });
+    }
+  }
+  return edits.sort((a, b) => a.line - b.line);
+}
+
+/**
+ * Computes the edits that remove every annotation from a file.
+ *
+ * @param lines - The file's source lines
+ * @param languageId - VS Code language identifier, which selects the comment prefix
+ * @returns One delete per annotation line
+ */
+export function getStripEdits(lines: readonly string[], languageId: string): AnnotationEdit[] {
+  const prefix = getCommentPrefix(languageId);
+  if (!prefix) {
+    return [];
+  }
+  const pattern = getAnnotationPattern(prefix);
+  const edits: AnnotationEdit[] = [];
+  lines.forEach((line, i) => {
+    if (pattern.test(line)) {
+      edits.push({ kind: "delete", line: i });
+    }
+  });
+  return edits;
+}
+
+/**
+ * Returns the first line of the block directly above a function that belongs to it:
+ * consecutive comment lines and, for Python, Rust, Elixir, and Dart, decorator and attribute lines.
+ */
+function getLeadingBlockStart(
+  lines: readonly string[],
+  startLine: number,
+  prefix: string,
+  attributePrefix: string | undefined
+): number {
+  let line = startLine;
+  while (line > 0) {
+    const text = lines[line - 1].trim();
+    if (!text.startsWith(prefix) && !(attributePrefix && text.startsWith(attributePrefix))) {
+      break;
+    }
+    line--;
+  }
+  return line;
+}
+
+/**
+ * Returns whether the comments directly above a function carry a `metrics:ignore` directive.
+ *
+ * @param lines - The file's source lines
+ * @param func - The function (0-based lines)
+ * @param languageId - VS Code language identifier, which selects the comment prefix
+ * @returns true if the function's diagnostics are muted
+ */
+export function isIgnoredFunction(
+  lines: readonly string[],
+  func: UnifiedFunctionMetrics,
+  languageId: string
+): boolean {
+  const prefix = getCommentPrefix(languageId);
+  if (!prefix) {
+    return false;
+  }
+  const directive = new RegExp(`^\\s*${prefix}metrics:ignore(\\s|$)`);
+  const start = getLeadingBlockStart(lines, func.startLine, prefix, ATTRIBUTE_PREFIXES[languageId]);
+  return lines.slice(start, func.startLine).some((line) => directive.test(line));
+}
+
+/**
+ * Computes the edit that mutes a function's diagnostics with a `metrics:ignore` comment.
+ * The comment goes above the function's decorators or attributes, so it stays attached
+ * to the declaration, and below its doc comment, at the declaration's indentation.
+ *
+ * @param lines - The file's source lines
+ * @param func - The function (0-based lines)
+ * @param languageId - VS Code language identifier, which selects the comment prefix
+ * @returns The insert, or undefined when the language has no line comments or the
+ *   function is already ignored
+ */
+export function getIgnoreEdit(
+  lines: readonly string[],
+  func: UnifiedFunctionMetrics,
+  languageId: string
+): AnnotationEdit | undefined {
+  const prefix = getCommentPrefix(languageId);
+  if (!prefix || isIgnoredFunction(lines, func, languageId)) {
+    return undefined;
+  }
+  const attributePrefix = ATTRIBUTE_PREFIXES[languageId];
+  let line = func.startLine;
+  while (attributePrefix && line > 0 && lines[line - 1].trim().startsWith(attributePrefix)) {
+    line--;
+  }
+  return {
+    kind: "insert",
+    line,
+    text: `${/^\s*/.exec(lines[func.startLine])![0]}${prefix}metrics:ignore`,
+  };
+}
@@ -0,0 +1,186 @@
+/**
+ * @fileoverview Public Extension API
+ *
+ * The object returned from `activate`, for other extensions that want to contribute an
+ * analyzer for a language Code Metrics does not support:
+ *
+ * ```typescript
+ * const codeMetrics = vscode.extensions.getExtension<CodeMetricsApi>("dev-asilva.code-metrics");
+ * const api = await codeMetrics?.activate();
+ * context.subscriptions.push(api.registerAnalyzer("cobol", new CobolAnalyzer(), {
+ *   fileExtensions: ["cbl", "cob"],
+ * }));
+ * ```
+ *
+ * Once registered, the language gets CodeLens, diagnostics, function details, workspace
+ * reports, and exports like a built-in one. Disposing the returned registration removes
+ * the analyzer; registrations are also dropped when Code Metrics deactivates.
+ *
+ * Tools that work on one function at a time can look up its metrics by qualified name
+ * with `getFunctionMetrics(uri, "Service.Save")`; only that file is analyzed. Text that is
+ * not in a file is analyzed with `analyzeSource(text, "go")`.
+ */
+
+import * as vscode from "vscode";
+import {
+  LanguageAnalyzer,
+  MetricsAnalyzerFactory,
+  RawFunctionMetrics,
+  RawMetricsDetail,
+  SourceAnalysis,
+  UnifiedFunctionMetrics,
+} from "./metricsAnalyzer/metricsAnalyzerFactory";
+import { ConfigurationManager } from "./configuration";
+import { registerCodeLensLanguage } from "./providers/codeLensProvider";
+
+export {
+  LanguageAnalyzer,
+  RawFunctionMetrics,
+  RawMetricsDetail,
+  SourceAnalysis,
+  UnifiedFunctionMetrics,
+};
+
+/** Options for {@link CodeMetricsApi.registerAnalyzer}. */
+export interface AnalyzerRegistrationOptions {
+  /** File extensions (without the dot) of the language, so workspace scans pick its files up */
+  fileExtensions?: string[];
+}
+
+/** The API other extensions receive from `activate()`. */
+export interface CodeMetricsApi {
+  /**
+   * Registers an analyzer for a language without a built-in one.
+   *
+   * @param languageId - VS Code language identifier, e.g. 'cobol'
+   * @param analyzer - Object whose synchronous `analyzeFile(text)` returns one entry per
+   *   function: name, complexity, increments (`details`), and 0-based positions
+   * @param options - Registration options
+   * @returns A disposable that unregisters the analyzer
+   * @throws {Error} If the language or one of its extensions already has an analyzer
+   */
+  registerAnalyzer(
+    languageId: string,
+    analyzer: LanguageAnalyzer,
+    options?: AnalyzerRegistrationOptions
+  ): vscode.Disposable;
+
+  /**
+   * Returns the metrics of one function of a file. Open documents are analyzed as shown
+   * in the editor, unsaved changes included; other files are read from disk. Results are
+   * cached by content, so repeated lookups in an unchanged file do not analyze it again.
+   *
+   * @param uri - The file declaring the function
+   * @param qualifiedName - The name reported by the analyzer, e.g. `Service.Save` for a Go
+   *   method; a name declared more than once in the file takes an `@<line>` suffix (1-based),
+   *   as in exported ids
+   * @returns The function's metrics, or null when the file cannot be read, its language is
+   *   not supported, or it has no such function
+   */
+  getFunctionMetrics(
+    uri: vscode.Uri,
+    qualifiedName: string
+  ): Promise<UnifiedFunctionMetrics | null>;
+
+  /**
+   * Analyzes source text synchronously, without a document or file, with the analyzer
+   * options of the user's settings for the language. Results are cached by content.
+   *
+   * @param sourceText - The complete source code
+   * @param languageId - VS Code language identifier, e.g. 'go'
+   * @returns The functions and their metrics as shown in function details; no functions
+   *   and an `error` when the language has no analyzer or its analyzer failed. Syntax
+   *   errors are not failures: the text is analyzed as far as the parser recovers
+   */
+  analyzeSource(sourceText: string, languageId: string): SourceAnalysis;
+}
+
+/**
+ * Finds a function by qualified name, with an optional `@<line>` suffix (1-based) to pick
+ * one of several functions of the same name.
+ *
+ * @param functions - The analyzed functions of a file
+ * @param qualifiedName - The name to look up
+ * @returns The first matching function, or undefined when there is none
+ */
+export function findFunctionByName(
+  functions: readonly UnifiedFunctionMetrics[],
+  qualifiedName: string
+): UnifiedFunctionMetrics | undefined {
+  const exact = functions.find((func) => func.name === qualifiedName);
+  if (exact) {
+    return exact;
+  }
+  const match = /^(.*)@(\d+)$/.exec(qualifiedName);
+  return match
+    ? functions.find((func) => func.name === match[1] && func.startLine + 1 === Number(match[2]))
+    : undefined;
+}
+
+/** Returns the open document for a URI, or loads the file without showing it. */
+async function getDocument(uri: vscode.Uri): Promise<vscode.TextDocument | undefined> {
+  const open = vscode.workspace.textDocuments.find(
+    (doc) => doc.uri.toString() === uri.toString()
+  );
+  if (open) {
+    return open;
+  }
+  try {
+    return await vscode.workspace.openTextDocument(uri);
+  } catch {
+    return undefined; // missing or unreadable file
+  }
+}
+
+/**
+ * Creates the API object returned from `activate`. Registrations are tracked so they can
+ * be removed together on deactivation.
+ *
+ * @param registrations - Collection receiving each registration's disposable
+ * @returns The API
+ */
+export function createApi(registrations: vscode.Disposable[]): CodeMetricsApi {
+  return {
+    registerAnalyzer(languageId, analyzer, options = {}) {
+      const unregister = MetricsAnalyzerFactory.registerAnalyzer(
+        languageId,
+        analyzer,
+        options.fileExtensions
+      );
+      const codeLens = registerCodeLensLanguage(languageId);
+      const registration = new vscode.Disposable(() => {
+        codeLens.dispose();
+        unregister();
+        const index = registrations.indexOf(registration);
+        if (index !== -1) {
+          registrations.splice(index, 1);
+        }
+      });
+      registrations.push(registration);
+      return registration;
+    },
+
+    async getFunctionMetrics(uri, qualifiedName) {
+      const document = await getDocument(uri);
+      if (!document || !MetricsAnalyzerFactory.isSupportedLanguage(document.languageId)) {
+        return null;
+      }
+      const config = ConfigurationManager.getConfiguration(document.uri, document.languageId);
+      const functions = MetricsAnalyzerFactory.analyzeFile(
+        document.getText(),
+        document.languageId,
+        ConfigurationManager.getAnalyzerOptions(config)
+      );
+      return findFunctionByName(functions, qualifiedName) ?? null;
+    },
+
+    analyzeSource(sourceText, languageId) {
+      const config = ConfigurationManager.getConfiguration(undefined, languageId);
+      return MetricsAnalyzerFactory.analyzeSource(
+        sourceText,
+        languageId,
+        ConfigurationManager.getAnalyzerOptions(config)
+      );
+    },
+  };
+}
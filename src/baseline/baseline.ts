@@ -0,0 +1,529 @@
+/**
+ * @fileoverview Baseline Comparison
+ *
+ * Compares the complexity of changed files against a base branch, for pull request
+ * checks: the files changed since the merge base with the base ref (e.g. `origin/main`)
+ * are analyzed in both versions, and functions whose complexity went up are reported.
+ * The same comparison also yields every change, up or down, for a pull request's
+ * description, and the net change to check against a complexity budget. The base version of each file is read with `git show`, so nothing is
+ * checked out.
+ *
+ * This module does not depend on the VS Code API and also backs the command-line entry
+ * point in `cli/compareBaseline.ts`.
+ */
+
+import { execFile } from "child_process";
+import * as fs from "fs/promises";
+import * as path from "path";
+import {
+  AnalyzerOptions,
+  MetricsAnalyzerFactory,
+  UnifiedFunctionMetrics,
+} from "../metricsAnalyzer/metricsAnalyzerFactory";
+import { IdentityStrategy } from "../metricsAnalyzer/fingerprint";
+
+/** A function whose complexity increased, or that is new with non-zero complexity. */
+export interface ComplexityIncrease {
+  name: string;
+  /** Line of the function in the current version (1-based) */
+  line: number;
+  /** Complexity on the base branch; undefined for new functions */
+  before?: number;
+  after: number;
+}
+
+/** The increases found in one changed file. */
+export interface FileComparison {
+  /** Path relative to the compared directory (forward slashes) */
+  path: string;
+  increases: ComplexityIncrease[];
+}
+
+/** Result of comparing the working tree against a base ref. */
+export interface BaselineComparison {
+  /** The base ref as given, e.g. `origin/main` */
+  baseRef: string;
+  /** Merge base of the base ref and HEAD that files were compared against */
+  baseCommit: string;
+  /** Number of changed files that were analyzed */
+  analyzedFiles: number;
+  /** Files with at least one increase, in path order */
+  files: FileComparison[];
+}
+
+/** A function whose complexity changed, or that was added or removed. */
+export interface ComplexityChange {
+  name: string;
+  /** Line of the function in the current version, or in the base version if removed (1-based) */
+  line: number;
+  /** Complexity on the base branch; undefined for new functions */
+  before?: number;
+  /** Current complexity; undefined for removed functions */
+  after?: number;
+}
+
+/** The changes found in one changed file. */
+export interface FileChanges {
+  /** Path relative to the compared directory (forward slashes) */
+  path: string;
+  changes: ComplexityChange[];
+}
+
+/** Every complexity change since a base ref, for {@link formatChangeReport}. */
+export interface ChangeReport {
+  /** The base ref as given, e.g. `origin/main` */
+  baseRef: string;
+  /** Merge base of the base ref and HEAD that files were compared against */
+  baseCommit: string;
+  /** Number of changed files that were analyzed */
+  analyzedFiles: number;
+  /** Files with at least one change, in path order */
+  files: FileChanges[];
+}
+
+/** The net complexity change of a set of changes, measured against a budget. */
+export interface BudgetCheck {
+  /** Sum of the changes in complexity, removed functions counting negative */
+  net: number;
+  /** Largest allowed net change, e.g. `20`, or `0` for net-neutral changes */
+  budget: number;
+  /** Whether the net change is larger than the budget */
+  exceeded: boolean;
+}
+
+/** Both analyzed versions of a changed file. */
+interface ChangedFile {
+  path: string;
+  before: UnifiedFunctionMetrics[];
+  after: UnifiedFunctionMetrics[];
+}
+
+/** Options for {@link compareWithBaseline}. */
+export interface BaselineOptions {
+  /** Returns whether a changed file (relative path) should be compared; all files by default */
+  include?: (relativePath: string) => boolean;
+  /** How functions are matched between versions (default `name`) */
+  identity?: IdentityStrategy;
+  /**
+   * How both versions are analyzed, e.g. from `ConfigurationManager.getAnalyzerOptions`, so
+   * the compared values are the ones shown in the editor; fingerprints follow `identity`
+   */
+  analyzerOptions?: AnalyzerOptions;
+}
+
+/** Runs git in a directory and resolves with its standard output. */
+export function runGit(cwd: string, args: string[]): Promise<string> {
+  return new Promise((resolve, reject) => {
+    execFile("git", args, { cwd, maxBuffer: 64 * 1024 * 1024 }, (error, stdout, stderr) => {
+      if (error) {
+        reject(new Error(stderr.trim() || error.message));
+      } else {
+        resolve(stdout);
+      }
+    });
+  });
+}
+
+/**
+ * Splits a remote-tracking ref like `origin/main` into the remote and branch to fetch.
+ *
+ * @param baseRef - The base ref
+ * @returns The remote and branch, or undefined for refs without a remote part
+ */
+export function parseRemoteRef(baseRef: string): { remote: string; branch: string } | undefined {
+  const match = /^([^/]+)\/(.+)$/.exec(baseRef);
+  return match ? { remote: match[1], branch: match[2] } : undefined;
+}
+
+/**
+ * Fetches the branch behind a remote-tracking base ref, e.g. `git fetch origin main`.
+ *
+ * @param cwd - A directory inside the repository
+ * @param baseRef - The remote-tracking ref
+ * @throws {Error} If the ref has no remote part or the fetch fails
+ */
+export async function fetchBaseRef(cwd: string, baseRef: string): Promise<void> {
+  const remote = parseRemoteRef(baseRef);
+  if (!remote) {
+    throw new Error(`"${baseRef}" is not a remote branch (expected e.g. origin/main)`);
+  }
+  await runGit(cwd, ["fetch", remote.remote, remote.branch]);
+}
+
+/**
+ * Finds the commit that changes are measured against: the merge base of the ref and HEAD.
+ *
+ * @param cwd - A directory inside the repository
+ * @param baseRef - The base ref
+ * @returns The merge base commit hash
+ * @throws {Error} With a message suggesting `git fetch` when the ref is not available
+ */
+export async function resolveBaseCommit(cwd: string, baseRef: string): Promise<string> {
+  try {
+    await runGit(cwd, ["rev-parse", "--verify", "--quiet", `${baseRef}^{commit}`]);
+  } catch {
+    const remote = parseRemoteRef(baseRef);
+    const hint = remote ? ` Fetch it first: git fetch ${remote.remote} ${remote.branch}` : "";
+    throw new Error(`Base ref "${baseRef}" is not available in this repository.${hint}`);
+  }
+  return (await runGit(cwd, ["merge-base", baseRef, "HEAD"])).trim();
+}
+
+/**
+ * Matches functions of the base and current version of a file and reports those whose
+ * complexity increased. Functions are matched by name, repeated names in source order.
+ * With `fingerprint` identity, functions with identical normalized content are paired
+ * first, so a renamed function is not mistaken for a new one. New functions are reported
+ * when they have any complexity; removed functions are ignored.
+ *
+ * @param before - Functions of the base version
+ * @param after - Functions of the current version
+ * @param identity - How functions are matched (default `name`)
+ * @returns The increases, largest first
+ */
+export function compareFunctionMetrics(
+  before: readonly UnifiedFunctionMetrics[],
+  after: readonly UnifiedFunctionMetrics[],
+  identity: IdentityStrategy = "name"
+): ComplexityIncrease[] {
+  const { matches } = matchFunctions(before, after, identity);
+  const increases: ComplexityIncrease[] = [];
+  for (const func of after) {
+    const base = matches.get(func);
+    if (base ? func.complexity > base.complexity : func.complexity > 0) {
+      increases.push({
+        name: func.name,
+        line: func.startLine + 1,
+        before: base?.complexity,
+        after: func.complexity,
+      });
+    }
+  }
+  return increases.sort((a, b) => b.after - (b.before ?? 0) - (a.after - (a.before ?? 0)));
+}
+
+/**
+ * Matches functions of the base and current version of a file and reports every change
+ * in complexity: functions that got more or less complex, and added or removed functions
+ * with any complexity. Functions are matched as by {@link compareFunctionMetrics}.
+ *
+ * @param before - Functions of the base version
+ * @param after - Functions of the current version
+ * @param identity - How functions are matched (default `name`)
+ * @returns The changes in source order, removed functions last
+ */
+export function diffFunctionMetrics(
+  before: readonly UnifiedFunctionMetrics[],
+  after: readonly UnifiedFunctionMetrics[],
+  identity: IdentityStrategy = "name"
+): ComplexityChange[] {
+  const { matches, unmatched } = matchFunctions(before, after, identity);
+  const changes: ComplexityChange[] = [];
+  for (const func of after) {
+    const base = matches.get(func);
+    if (base ? func.complexity !== base.complexity : func.complexity > 0) {
+      changes.push({
+        name: func.name,
+        line: func.startLine + 1,
+        before: base?.complexity,
+        after: func.complexity,
+      });
+    }
+  }
+  for (const base of unmatched) {
+    if (base.complexity > 0) {
+      changes.push({ name: base.name, line: base.startLine + 1, before: base.complexity });
+    }
+  }
+  return changes;
+}
+
+/**
+ * Pairs each current function with its base version: by fingerprint first with
+ * `fingerprint` identity, then by name, repeated names in source order.
+ *
+ * @returns The base version of each matched current function, and the base functions left over
+ */
+function matchFunctions(
+  before: readonly UnifiedFunctionMetrics[],
+  after: readonly UnifiedFunctionMetrics[],
+  identity: IdentityStrategy
+): {
+  matches: Map<UnifiedFunctionMetrics, UnifiedFunctionMetrics>;
+  unmatched: UnifiedFunctionMetrics[];
+} {
+  const unmatched = [...before];
+  const matches = new Map<UnifiedFunctionMetrics, UnifiedFunctionMetrics>();
+  if (identity === "fingerprint") {
+    for (const func of after) {
+      const index = unmatched.findIndex(
+        (base) => base.fingerprint !== undefined && base.fingerprint === func.fingerprint
+      );
+      if (index !== -1) {
+        matches.set(func, unmatched.splice(index, 1)[0]);
+      }
+    }
+  }
+  for (const func of after) {
+    const index = matches.has(func) ? -1 : unmatched.findIndex((base) => base.name === func.name);
+    if (index !== -1) {
+      matches.set(func, unmatched.splice(index, 1)[0]);
+    }
+  }
+  return { matches, unmatched };
+}
+
+/**
+ * Compares every supported file changed since the merge base with a base ref, working
+ * tree changes to tracked files included, against its base version.
+ *
+ * @param cwd - The repository root, or a directory inside it to limit the comparison to
+ * @param baseRef - The base ref, e.g. `origin/main`
+ * @param options - Comparison options
+ * @returns The comparison
+ * @throws {Error} If git fails or the base ref is not available
+ */
+export async function compareWithBaseline(
+  cwd: string,
+  baseRef: string,
+  options: BaselineOptions = {}
+): Promise<BaselineComparison> {
+  const baseCommit = await resolveBaseCommit(cwd, baseRef);
+  const changed = await analyzeChangedFiles(cwd, baseCommit, options);
+  const comparison: BaselineComparison = {
+    baseRef,
+    baseCommit,
+    analyzedFiles: changed.length,
+    files: [],
+  };
+  for (const file of changed) {
+    const increases = compareFunctionMetrics(file.before, file.after, options.identity);
+    if (increases.length > 0) {
+      comparison.files.push({ path: file.path, increases });
+    }
+  }
+  return comparison;
+}
+
+/**
+ * Collects every complexity change in the supported files changed since the merge base
+ * with a base ref, as {@link compareWithBaseline} compares them.
+ *
+ * @param cwd - The repository root, or a directory inside it to limit the comparison to
+ * @param baseRef - The base ref, e.g. `origin/main`
+ * @param options - Comparison options
+ * @returns The changes
+ * @throws {Error} If git fails or the base ref is not available
+ */
+export async function collectComplexityChanges(
+  cwd: string,
+  baseRef: string,
+  options: BaselineOptions = {}
+): Promise<ChangeReport> {
+  const baseCommit = await resolveBaseCommit(cwd, baseRef);
+  const changed = await analyzeChangedFiles(cwd, baseCommit, options);
+  const report: ChangeReport = { baseRef, baseCommit, analyzedFiles: changed.length, files: [] };
+  for (const file of changed) {
+    const changes = diffFunctionMetrics(file.before, file.after, options.identity);
+    if (changes.length > 0) {
+      report.files.push({ path: file.path, changes });
+    }
+  }
+  return report;
+}
+
+/**
+ * Analyzes both versions of every supported file changed since a commit, working tree
+ * changes to tracked files included. Deleted files are analyzed with no current
+ * functions, so the complexity they removed is credited.
+ *
+ * @param cwd - The repository root, or a directory inside it to limit the comparison to
+ * @param baseCommit - The commit to compare against
+ * @param options - Comparison options
+ * @returns The analyzed files, in path order
+ */
+async function analyzeChangedFiles(
+  cwd: string,
+  baseCommit: string,
+  options: BaselineOptions
+): Promise<ChangedFile[]> {
+  // --relative limits the diff to `cwd` and keeps paths relative to it, for monorepo folders.
+  const diff = await runGit(
+    cwd,
+    ["diff", "--name-status", "--relative", "-M", "--diff-filter=AMRD", baseCommit]
+  );
+  const analyzerOptions: AnalyzerOptions = {
+    ...options.analyzerOptions,
+    fingerprints: options.identity === "fingerprint",
+  };
+
+  const files: ChangedFile[] = [];
+  for (const line of diff.split("\n")) {
+    const [status, ...paths] = line.split("\t");
+    if (!status || paths.length === 0) {
+      continue;
+    }
+    const currentPath = paths[paths.length - 1];
+    const languageId = MetricsAnalyzerFactory.getLanguageIdForFile(currentPath);
+    if (!languageId || (options.include && !options.include(currentPath))) {
+      continue;
+    }
+
+    // Added files have no base version, deleted files no current one; renamed files are
+    // read under their old path.
+    const base = status === "A" ? "" : await runGit(cwd, ["show", `${baseCommit}:./${paths[0]}`]);
+    const current =
+      status === "D" ? undefined : await fs.readFile(path.join(cwd, currentPath), "utf-8");
+    files.push({
+      path: currentPath,
+      before: MetricsAnalyzerFactory.analyzeFile(base, languageId, analyzerOptions),
+      after:
+        current === undefined
+          ? []
+          : MetricsAnalyzerFactory.analyzeFile(current, languageId, analyzerOptions),
+    });
+  }
+  return files.sort((a, b) => a.path.localeCompare(b.path));
+}
+
+/**
+ * Renders a comparison as Markdown for a pull request comment.
+ *
+ * @param comparison - The comparison to render
+ * @returns The comment body
+ */
+export function formatBaselineComment(comparison: BaselineComparison): string {
+  const lines = [
+    `### Cognitive complexity compared with \`${comparison.baseRef}\``,
+    "",
+  ];
+  const increases = comparison.files.flatMap((file) =>
+    file.increases.map((increase) => ({ file: file.path, ...increase }))
+  );
+  if (increases.length === 0) {
+    lines.push(`No function got more complex in ${comparison.analyzedFiles} changed files. ✅`);
+    return `${lines.join("\n")}\n`;
+  }
+
+  lines.push(
+    `${increases.length} functions got more complex in ${comparison.analyzedFiles} changed files.`,
+    "",
+    "| File | Function | Base | Now | Change |",
+    "| --- | --- | ---: | ---: | ---: |"
+  );
+  for (const increase of increases) {
+    const change = increase.before === undefined ? "new" : `+${increase.after - increase.before}`;
+    lines.push(
+      `| \`${increase.file}:${increase.line}\` | \`${increase.name}\` | ` +
+        `${increase.before ?? "–"} | ${increase.after} | ${change} |`
+    );
+  }
+  return `${lines.join("\n")}\n`;
+}
+
+/**
+ * Renders every complexity change as Markdown for a pull request description: a table of
+ * the changed functions with their complexity before and after, and a one-line verdict.
+ *
+ * @param report - The changes to render
+ * @param budget - Largest allowed net change; when given, the check is rendered below the verdict
+ * @returns The Markdown
+ */
+export function formatChangeReport(report: ChangeReport, budget?: number): string {
+  const changes = report.files.flatMap((file) =>
+    file.changes.map((change) => ({ file: file.path, ...change }))
+  );
+  const lines = [
+    `### Complexity changes since \`${report.baseRef}\``,
+    "",
+    formatChangeVerdict(changes, report.analyzedFiles),
+  ];
+  if (budget !== undefined) {
+    lines.push("", formatBudgetCheck(checkComplexityBudget(report, budget)));
+  }
+  if (changes.length === 0) {
+    return `${lines.join("\n")}\n`;
+  }
+
+  lines.push(
+    "",
+    "| Function | File | Before | After | Change |",
+    "| --- | --- | ---: | ---: | ---: |"
+  );
+  for (const change of changes) {
+    const delta =
+      change.before === undefined
+        ? "new"
+        : change.after === undefined
+          ? "removed"
+          : formatDelta(change.after - change.before);
+    lines.push(
+      `| \`${change.name}\` | \`${change.file}:${change.line}\` | ` +
+        `${change.before ?? "–"} | ${change.after ?? "–"} | ${delta} |`
+    );
+  }
+  return `${lines.join("\n")}\n`;
+}
+
+/** Sums the changes in complexity; added and removed functions count in full. */
+function getNetChange(changes: readonly ComplexityChange[]): number {
+  return changes.reduce((sum, change) => sum + (change.after ?? 0) - (change.before ?? 0), 0);
+}
+
+/**
+ * Checks the net complexity change of a report against a budget, for teams that keep
+ * each change net-neutral or better.
+ *
+ * @param report - The changes, from {@link collectComplexityChanges}
+ * @param budget - Largest allowed net change; negative budgets require a reduction
+ * @returns The net change and whether it exceeds the budget
+ */
+export function checkComplexityBudget(report: ChangeReport, budget: number): BudgetCheck {
+  const net = getNetChange(report.files.flatMap((file) => file.changes));
+  return { net, budget, exceeded: net > budget };
+}
+
+/**
+ * Renders a budget check as one line, e.g.
+ * "❌ Net complexity +24 exceeds the budget of +20."
+ *
+ * @param check - The budget check
+ * @returns The line, without a trailing newline
+ */
+export function formatBudgetCheck(check: BudgetCheck): string {
+  return check.exceeded
+    ? `❌ Net complexity ${formatDelta(check.net)} exceeds the budget of ${formatDelta(check.budget)}.`
+    : `✅ Net complexity ${formatDelta(check.net)} is within the budget of ${formatDelta(check.budget)}.`;
+}
+
+/** Writes a change in complexity with its sign, e.g. `+3` or `−2`. */
+function formatDelta(delta: number): string {
+  return delta > 0 ? `+${delta}` : delta < 0 ? `−${-delta}` : "0";
+}
+
+/** Sums up the changes in one line, e.g. "⚠️ Complexity +4 overall: 2 more complex, 1 new." */
+function formatChangeVerdict(changes: readonly ComplexityChange[], analyzedFiles: number): string {
+  if (changes.length === 0) {
+    return `✅ No function changed in complexity across ${analyzedFiles} changed files.`;
+  }
+  const net = getNetChange(changes);
+  const counts = new Map<string, number>();
+  for (const change of changes) {
+    const kind =
+      change.before === undefined
+        ? "new"
+        : change.after === undefined
+          ? "removed"
+          : change.after > change.before
+            ? "more complex"
+            : "simpler";
+    counts.set(kind, (counts.get(kind) ?? 0) + 1);
+  }
+  const breakdown = ["more complex", "simpler", "new", "removed"]
+    .filter((kind) => counts.has(kind))
+    .map((kind) => `${counts.get(kind)} ${kind}`)
+    .join(", ");
+  const icon = net > 0 ? "⚠️" : "✅";
+  const overall = net === 0 ? "unchanged overall" : `${formatDelta(net)} overall`;
+  return `${icon} Complexity ${overall}: ${breakdown}.`;
+}
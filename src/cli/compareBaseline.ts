@@ -0,0 +1,125 @@
+/**
+ * @fileoverview Baseline Comparison CLI
+ *
+ * Headless entry point for CI: compares the repository in the current directory (or
+ * `--cwd`) against a base ref and prints the Markdown comment to standard output. Files
+ * are analyzed and excluded as the `.codemetrics.json` of that directory configures. With
+ * `--budget N`, or a `complexityBudget` in `.codemetrics.json`, the net complexity change
+ * is also checked against N (e.g. `--budget 20` allows +20 in total; `--base HEAD` limits
+ * the check to uncommitted changes).
+ *
+ * Usage: `node out/cli/compareBaseline.js [--base origin/main] [--cwd dir] [--fail-on-increase]
+ *   [--identity name|fingerprint] [--budget N]`
+ *
+ * Exit codes: 0 on success, 1 when `--fail-on-increase` is set and a function got more
+ * complex or when the net change exceeds the budget, 2 when the comparison could not run
+ * (e.g. the base ref was not fetched).
+ */
+
+import * as path from "path";
+import {
+  BaselineOptions,
+  checkComplexityBudget,
+  collectComplexityChanges,
+  compareWithBaseline,
+  formatBaselineComment,
+  formatBudgetCheck,
+} from "../baseline/baseline";
+import { IdentityStrategy } from "../metricsAnalyzer/fingerprint";
+import { CodeMetricsConfig, loadProjectConfig, toAnalyzerOptions } from "../projectConfig";
+import { isExcludedFile } from "../workspace/fileFilters";
+
+/** Parsed command-line options. */
+interface CliOptions {
+  base: string;
+  cwd: string;
+  failOnIncrease: boolean;
+  identity: IdentityStrategy;
+  /** Largest allowed net complexity change; unchecked when not given */
+  budget?: number;
+}
+
+/**
+ * Parses command-line arguments.
+ *
+ * @param args - Arguments after the script name
+ * @returns The options
+ * @throws {Error} On unknown arguments or a missing value
+ */
+export function parseArguments(args: readonly string[]): CliOptions {
+  const options: CliOptions = {
+    base: "origin/main",
+    cwd: process.cwd(),
+    failOnIncrease: false,
+    identity: "name",
+  };
+  for (let i = 0; i < args.length; i++) {
+    const arg = args[i];
+    if (arg === "--fail-on-increase") {
+      options.failOnIncrease = true;
+    } else if (arg === "--base" || arg === "--cwd") {
+      const value = args[++i];
+      if (value === undefined) {
+        throw new Error(`${arg} needs a value`);
+      }
+      options[arg === "--base" ? "base" : "cwd"] = value;
+    } else if (arg === "--identity") {
+      const value = args[++i];
+      if (value !== "name" && value !== "fingerprint") {
+        throw new Error("--identity must be name or fingerprint");
+      }
+      options.identity = value;
+    } else if (arg === "--budget") {
+      const value = args[++i];
+      if (value === undefined || !/^[+-]?\d+$/.test(value)) {
+        throw new Error("--budget must be a whole number, e.g. 20");
+      }
+      options.budget = Number(value);
+    } else {
+      throw new Error(`Unknown argument: ${arg}`);
+    }
+  }
+  return options;
+}
+
+/**
+ * Resolves the comparison options of a directory as the extension does for a workspace
+ * folder: its configuration decides how files are analyzed and which are excluded.
+ *
+ * @param options - The command-line options
+ * @param config - The directory's configuration, from {@link loadProjectConfig}
+ * @returns The options for the baseline comparison
+ */
+export function getBaselineOptions(options: CliOptions, config: CodeMetricsConfig): BaselineOptions {
+  return {
+    identity: options.identity,
+    analyzerOptions: toAnalyzerOptions(config),
+    include: (relativePath) => !isExcludedFile(path.join(options.cwd, relativePath), config),
+  };
+}
+
+async function main(): Promise<number> {
+  try {
+    const options = parseArguments(process.argv.slice(2));
+    const config = loadProjectConfig(options.cwd);
+    const baselineOptions = getBaselineOptions(options, config);
+    const comparison = await compareWithBaseline(options.cwd, options.base, baselineOptions);
+    process.stdout.write(formatBaselineComment(comparison));
+    let exceeded = false;
+    const budget = options.budget ?? config.complexityBudget ?? undefined;
+    if (budget !== undefined) {
+      const report = await collectComplexityChanges(options.cwd, options.base, baselineOptions);
+      const check = checkComplexityBudget(report, budget);
+      process.stdout.write(`\n${formatBudgetCheck(check)}\n`);
+      exceeded = check.exceeded;
+    }
+    return (options.failOnIncrease && comparison.files.length > 0) || exceeded ? 1 : 0;
+  } catch (error) {
+    process.stderr.write(`code-metrics: ${(error as Error).message}\n`);
+    return 2;
+  }
+}
+
+if (require.main === module) {
+  main().then((code) => process.exit(code));
+}
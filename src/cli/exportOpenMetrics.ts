@@ -0,0 +1,153 @@
+/**
+ * @fileoverview OpenMetrics Export CLI
+ *
+ * Headless entry point for CI: analyzes the supported files tracked by git in the current
+ * directory (or `--cwd`) and prints their metrics in the OpenMetrics / Prometheus text
+ * format, ready to pipe into a Pushgateway. Files are analyzed and excluded as the
+ * `.codemetrics.json` of that directory configures:
+ *
+ *   node out/cli/exportOpenMetrics.js | curl --data-binary @- \
+ *     http://pushgateway:9091/metrics/job/code_metrics/repo/my-service
+ *
+ * Usage: `node out/cli/exportOpenMetrics.js [--cwd dir] [--prefix code] [--min-complexity N]
+ *   [--exclude regex]...`
+ *
+ * `--min-complexity` and `--exclude` (matched against relative paths, repeatable) keep the
+ * number of series down in large repositories. Exit codes: 0 on success, 2 when the files
+ * could not be listed (e.g. the directory is not in a git repository).
+ */
+
+import * as fs from "fs/promises";
+import * as path from "path";
+import { runGit } from "../baseline/baseline";
+import { MetricsAnalyzerFactory } from "../metricsAnalyzer/metricsAnalyzerFactory";
+import { OpenMetricsSample, formatOpenMetrics, isValidMetricPrefix } from "../export/openMetrics";
+import { loadProjectConfig, toAnalyzerOptions } from "../projectConfig";
+import { isExcludedFile } from "../workspace/fileFilters";
+
+/** Parsed command-line options. */
+interface CliOptions {
+  cwd: string;
+  prefix: string;
+  /** Functions below this complexity are left out */
+  minComplexity: number;
+  /** Regular expressions matched against relative paths; matching files are skipped */
+  exclude: RegExp[];
+}
+
+/**
+ * Parses command-line arguments.
+ *
+ * @param args - Arguments after the script name
+ * @returns The options
+ * @throws {Error} On unknown arguments or a missing or invalid value
+ */
+export function parseArguments(args: readonly string[]): CliOptions {
+  const options: CliOptions = {
+    cwd: process.cwd(),
+    prefix: "code",
+    minComplexity: 0,
+    exclude: [],
+  };
+  for (let i = 0; i < args.length; i++) {
+    const arg = args[i];
+    const value = args[i + 1];
+    if (arg === "--cwd" || arg === "--prefix" || arg === "--exclude" || arg === "--min-complexity") {
+      if (value === undefined) {
+        throw new Error(`${arg} needs a value`);
+      }
+      i++;
+    }
+    if (arg === "--cwd") {
+      options.cwd = value;
+    } else if (arg === "--prefix") {
+      if (!isValidMetricPrefix(value)) {
+        throw new Error("--prefix must be letters, digits, and underscores, e.g. code");
+      }
+      options.prefix = value;
+    } else if (arg === "--min-complexity") {
+      if (!/^\d+$/.test(value)) {
+        throw new Error("--min-complexity must be a whole number, e.g. 10");
+      }
+      options.minComplexity = Number(value);
+    } else if (arg === "--exclude") {
+      try {
+        options.exclude.push(new RegExp(value));
+      } catch {
+        throw new Error(`--exclude pattern "${value}" is not a valid regular expression`);
+      }
+    } else {
+      throw new Error(`Unknown argument: ${arg}`);
+    }
+  }
+  return options;
+}
+
+/**
+ * Analyzes the supported files tracked by git under a directory, with the settings of its
+ * `.codemetrics.json` as the extension uses for a workspace folder.
+ *
+ * @param options - Where to look and what to keep
+ * @returns One sample per kept function, in path and line order
+ * @throws {Error} If git fails
+ */
+export async function collectSamples(options: CliOptions): Promise<OpenMetricsSample[]> {
+  const config = loadProjectConfig(options.cwd);
+  if (!config.enabled) {
+    return [];
+  }
+  const analyzerOptions = toAnalyzerOptions(config);
+  const listed = await runGit(options.cwd, ["ls-files", "-z"]);
+  const files = listed
+    .split("\0")
+    .filter(
+      (file) =>
+        file &&
+        !options.exclude.some((pattern) => pattern.test(file)) &&
+        !isExcludedFile(path.join(options.cwd, file), config)
+    )
+    .sort();
+  const samples: OpenMetricsSample[] = [];
+  for (const file of files) {
+    const language = MetricsAnalyzerFactory.getLanguageIdForFile(file);
+    if (!language) {
+      continue;
+    }
+    let sourceText: string;
+    try {
+      sourceText = await fs.readFile(path.join(options.cwd, file), "utf-8");
+    } catch {
+      // Tracked but deleted in the working tree.
+      continue;
+    }
+    for (const func of MetricsAnalyzerFactory.analyzeFile(sourceText, language, analyzerOptions)) {
+      if (func.complexity >= options.minComplexity) {
+        samples.push({
+          file,
+          function: func.name,
+          language,
+          startLine: func.startLine + 1,
+          complexity: func.complexity,
+          logicalLines: func.logicalLines,
+        });
+      }
+    }
+  }
+  return samples;
+}
+
+async function main(): Promise<number> {
+  try {
+    const options = parseArguments(process.argv.slice(2));
+    const samples = await collectSamples(options);
+    process.stdout.write(formatOpenMetrics(samples, { prefix: options.prefix }));
+    return 0;
+  } catch (error) {
+    process.stderr.write(`code-metrics: ${(error as Error).message}\n`);
+    return 2;
+  }
+}
+
+if (require.main === module) {
+  main().then((code) => process.exit(code));
+}
@@ -3,46 +3,47 @@
  *
  * This module provides a centralized way to access VS Code configuration settings
  * for the code metrics extension. It ensures type safety and provides
- * default values for all configuration options.
+ * default values for all configuration options. The options themselves, their defaults,
+ * and `.codemetrics.json` parsing live in `projectConfig.ts`, which does not depend on
+ * the VS Code API, and are re-exported here.
  */
 
+import * as fs from "fs";
+import * as path from "path";
 import * as vscode from "vscode";
+import {
+  AnalysisEngine,
+  AnalyzerOptions,
+  ClosureMode,
+  ComplexityScoring,
+  LiteralFuncMode,
+  OPTIONAL_METRICS,
+  PanicMode,
+  SwitchSize,
+  UnifiedFunctionMetrics,
+} from "./metricsAnalyzer/metricsAnalyzerFactory";
+import { IdentityStrategy } from "./metricsAnalyzer/fingerprint";
+import {
+  AnalysisTrigger,
+  COMPLEXITY_PRESETS,
+  CodeLensAction,
+  CodeMetricsConfig,
+  ComplexityPreset,
+  DEFAULT_CONFIG,
+  DisplayStyle,
+  PRIMARY_METRICS,
+  PRIMARY_METRIC_SOURCES,
+  UNSUPPORTED_PRIMARY_METRICS,
+  PROJECT_CONFIG_FILE,
+  PrimaryMetric,
+  ReportSortBy,
+  applyComplexityPreset,
+  parseProjectConfig,
+  toAnalyzerOptions,
+  toConfigOverrides,
+} from "./projectConfig";
 
-/**
- * Interface defining all configuration options for the code metrics extension.
- * This interface ensures type safety when accessing configuration values.
- */
-export interface CodeMetricsConfig {
-  /** Whether the extension is enabled */
-  enabled: boolean;
-  /** Whether to show CodeLens above functions */
-  showCodeLens: boolean;
-  /** Complexity threshold for warning status (yellow indicator) */
-  warningThreshold: number;
-  /** Complexity threshold for error status (red indicator) */
-  errorThreshold: number;
-  /** Glob patterns for files to exclude from analysis */
-  excludePatterns: string[];
-}
-
-/**
- * Default configuration values used when user hasn't specified custom values.
- */
-export const DEFAULT_CONFIG: CodeMetricsConfig = {
-  enabled: true,
-  showCodeLens: true,
-  warningThreshold: 10,
-  errorThreshold: 15,
-  excludePatterns: [
-    "**/node_modules/**",
-    "**/dist/**",
-    "**/build/**",
-    "**/out/**",
-    "**/*.min.js",
-    "**/*.spec.*",
-    "**/*.test.*",
-  ],
-};
+export * from "./projectConfig";
 
 /**
  * Configuration manager class that provides typed access to extension settings.
@@ -52,19 +53,39 @@ export const DEFAULT_CONFIG: CodeMetricsConfig = {
 export class ConfigurationManager {
   private static readonly CONFIG_SECTION = "codeMetrics";
 
+  /**
+   * Cache of parsed `.codemetrics.json` overrides keyed by workspace folder path.
+   * Cleared by the project config watcher whenever one of the files changes.
+   */
+  private static readonly projectConfigCache = new Map<
+    string,
+    Partial<CodeMetricsConfig>
+  >();
+
+  /** Name of the active threshold profile from `codeMetrics.profiles`, if any. */
+  private static activeProfile: string | undefined;
+
+  /** Primary metrics already reported as unsupported, so each is reported once. */
+  private static readonly reportedPrimaryMetrics = new Set<string>();
+
+  /** Fires with the new profile name whenever the active profile changes. */
+  private static readonly profileEmitter = new vscode.EventEmitter<string | undefined>();
+
   /**
    * Gets the current configuration with all values resolved to their actual or default values.
    *
    * @param resource - Optional URI for workspace-specific configuration
+   * @param languageId - Optional language whose `[language]` settings apply, e.g. `"[go]":
+   *   { "codeMetrics.warningThreshold": 10 }`
    * @returns Complete configuration object with all values
    */
-  public static getConfiguration(resource?: vscode.Uri): CodeMetricsConfig {
+  public static getConfiguration(resource?: vscode.Uri, languageId?: string): CodeMetricsConfig {
     const config = vscode.workspace.getConfiguration(
       this.CONFIG_SECTION,
-      resource
+      languageId ? { uri: resource, languageId } : resource
     );
 
-    return {
+    const settings: CodeMetricsConfig = {
       enabled: config.get<boolean>("enabled", DEFAULT_CONFIG.enabled),
       showCodeLens: config.get<boolean>(
         "showCodeLens",
@@ -78,11 +99,385 @@ export class ConfigurationManager {
         "errorThreshold",
         DEFAULT_CONFIG.errorThreshold
       ),
+      nestingWarningThreshold: config.get<number>(
+        "nestingWarningThreshold",
+        DEFAULT_CONFIG.nestingWarningThreshold
+      ),
+      nestingErrorThreshold: config.get<number>(
+        "nestingErrorThreshold",
+        DEFAULT_CONFIG.nestingErrorThreshold
+      ),
       excludePatterns: config.get<string[]>(
         "excludePatterns",
         DEFAULT_CONFIG.excludePatterns
       ),
+      fieldAccessThreshold: config.get<number>(
+        "fieldAccessThreshold",
+        DEFAULT_CONFIG.fieldAccessThreshold
+      ),
+      conditionOperandThreshold: config.get<number>(
+        "conditionOperandThreshold",
+        DEFAULT_CONFIG.conditionOperandThreshold
+      ),
+      expressionNestingThreshold: config.get<number>(
+        "expressionNestingThreshold",
+        DEFAULT_CONFIG.expressionNestingThreshold
+      ),
+      closureDepthThreshold: config.get<number>(
+        "closureDepthThreshold",
+        DEFAULT_CONFIG.closureDepthThreshold
+      ),
+      caseClauseThreshold: config.get<number>(
+        "caseClauseThreshold",
+        DEFAULT_CONFIG.caseClauseThreshold
+      ),
+      branchDensityThreshold: config.get<number>(
+        "branchDensityThreshold",
+        DEFAULT_CONFIG.branchDensityThreshold
+      ),
+      enabledMetrics: config.get<string[]>("metrics.enabled", DEFAULT_CONFIG.enabledMetrics),
+      dominantFunctionShare: config.get<number>(
+        "dominantFunctionShare",
+        DEFAULT_CONFIG.dominantFunctionShare
+      ),
+      includeTests: config.get<boolean>(
+        "includeTests",
+        DEFAULT_CONFIG.includeTests
+      ),
+      closureMode: config.get<ClosureMode>(
+        "closureMode",
+        DEFAULT_CONFIG.closureMode
+      ),
+      showFileSummary: config.get<boolean>(
+        "showFileSummary",
+        DEFAULT_CONFIG.showFileSummary
+      ),
+      coverageFile: config.get<string>(
+        "coverageFile",
+        DEFAULT_CONFIG.coverageFile
+      ),
+      coverageThreshold: config.get<number>(
+        "coverageThreshold",
+        DEFAULT_CONFIG.coverageThreshold
+      ),
+      excludeGenerated: config.get<boolean>(
+        "excludeGenerated",
+        DEFAULT_CONFIG.excludeGenerated
+      ),
+      groupPlatformVariants: config.get<boolean>(
+        "groupPlatformVariants",
+        DEFAULT_CONFIG.groupPlatformVariants
+      ),
+      groupPlatformFunctions: config.get<boolean>(
+        "groupPlatformFunctions",
+        DEFAULT_CONFIG.groupPlatformFunctions
+      ),
+      displayLocale: config.get<string>(
+        "display.locale",
+        DEFAULT_CONFIG.displayLocale
+      ),
+      displayStyle: config.get<DisplayStyle>(
+        "display.style",
+        DEFAULT_CONFIG.displayStyle
+      ),
+      codeLensAction: config.get<CodeLensAction>(
+        "display.codeLensAction",
+        DEFAULT_CONFIG.codeLensAction
+      ),
+      codeLensTemplate: config.get<string>(
+        "display.codeLensTemplate",
+        DEFAULT_CONFIG.codeLensTemplate
+      ),
+      overviewRuler: config.get<boolean>(
+        "display.overviewRuler",
+        DEFAULT_CONFIG.overviewRuler
+      ),
+      statusBar: config.get<boolean>(
+        "display.statusBar",
+        DEFAULT_CONFIG.statusBar
+      ),
+      statusBarHideWhenZero: config.get<boolean>(
+        "display.statusBarHideWhenZero",
+        DEFAULT_CONFIG.statusBarHideWhenZero
+      ),
+      symbolComplexity: config.get<boolean>(
+        "display.symbolComplexity",
+        DEFAULT_CONFIG.symbolComplexity
+      ),
+      codeLensLimit: config.get<number>(
+        "display.codeLensLimit",
+        DEFAULT_CONFIG.codeLensLimit
+      ),
+      primaryMetric: config.get<PrimaryMetric>(
+        "display.primaryMetric",
+        DEFAULT_CONFIG.primaryMetric
+      ),
+      thresholdDiagnostics: config.get<boolean>(
+        "display.thresholdDiagnostics",
+        DEFAULT_CONFIG.thresholdDiagnostics
+      ),
+      analysisTrigger: config.get<AnalysisTrigger>(
+        "analysis.trigger",
+        DEFAULT_CONFIG.analysisTrigger
+      ),
+      saveGuardrail: config.get<boolean>(
+        "analysis.saveGuardrail",
+        DEFAULT_CONFIG.saveGuardrail
+      ),
+      includeSubmodules: config.get<boolean>(
+        "analysis.includeSubmodules",
+        DEFAULT_CONFIG.includeSubmodules
+      ),
+      largeFileThreshold: config.get<number>(
+        "largeFileThreshold",
+        DEFAULT_CONFIG.largeFileThreshold
+      ),
+      skipLargeFiles: config.get<boolean>(
+        "skipLargeFiles",
+        DEFAULT_CONFIG.skipLargeFiles
+      ),
+      lazyAnalysis: config.get<boolean>(
+        "analysis.lazy",
+        DEFAULT_CONFIG.lazyAnalysis
+      ),
+      showNodeCounts: config.get<boolean>(
+        "advanced.showNodeCounts",
+        DEFAULT_CONFIG.showNodeCounts
+      ),
+      exportQualifiedNames: config.get<boolean>(
+        "export.qualifiedNames",
+        DEFAULT_CONFIG.exportQualifiedNames
+      ),
+      reportFluentChains: config.get<boolean>(
+        "reportFluentChains",
+        DEFAULT_CONFIG.reportFluentChains
+      ),
+      debtBaseMinutes: config.get<number>("debt.baseMinutes", DEFAULT_CONFIG.debtBaseMinutes),
+      debtMinutesPerPoint: config.get<number>(
+        "debt.minutesPerPoint",
+        DEFAULT_CONFIG.debtMinutesPerPoint
+      ),
+      reportUnusedFunctions: config.get<boolean>(
+        "reportUnusedFunctions",
+        DEFAULT_CONFIG.reportUnusedFunctions
+      ),
+      unusedFunctionsIncludeExported: config.get<boolean>(
+        "unusedFunctions.includeExported",
+        DEFAULT_CONFIG.unusedFunctionsIncludeExported
+      ),
+      reportTypeMetrics: config.get<boolean>(
+        "reportTypeMetrics",
+        DEFAULT_CONFIG.reportTypeMetrics
+      ),
+      reportMethodCandidates: config.get<boolean>(
+        "reportMethodCandidates",
+        DEFAULT_CONFIG.reportMethodCandidates
+      ),
+      reportSortBy: config.get<ReportSortBy>(
+        "reportSortBy",
+        DEFAULT_CONFIG.reportSortBy
+      ),
+      typeMetricsMergeReceivers: config.get<boolean>(
+        "typeMetrics.mergeReceivers",
+        DEFAULT_CONFIG.typeMetricsMergeReceivers
+      ),
+      showCompletionSummary: config.get<boolean>(
+        "showCompletionSummary",
+        DEFAULT_CONFIG.showCompletionSummary
+      ),
+      baselineRef: config.get<string>("baseline.ref", DEFAULT_CONFIG.baselineRef),
+      complexityBudget: config.get<number | null>("baseline.budget", DEFAULT_CONFIG.complexityBudget),
+      identityStrategy: config.get<IdentityStrategy>(
+        "identity.strategy",
+        DEFAULT_CONFIG.identityStrategy
+      ),
+      annotationThreshold: config.get<number>(
+        "annotations.threshold",
+        DEFAULT_CONFIG.annotationThreshold
+      ),
+      analysisEngine: config.get<AnalysisEngine>(
+        "analysis.engine",
+        DEFAULT_CONFIG.analysisEngine
+      ),
+      historyHover: config.get<boolean>("history.hover", DEFAULT_CONFIG.historyHover),
+      excludeFunctionPatterns: config.get<string[]>(
+        "analysis.excludeFunctionPatterns",
+        DEFAULT_CONFIG.excludeFunctionPatterns
+      ),
+      resolverPatterns: config.get<string[]>(
+        "analysis.resolverPatterns",
+        DEFAULT_CONFIG.resolverPatterns
+      ),
+      complexityPreset: config.get<ComplexityPreset>(
+        "complexity.preset",
+        DEFAULT_CONFIG.complexityPreset
+      ),
+      complexityScoring: config.get<ComplexityScoring>(
+        "complexity.scoring",
+        DEFAULT_CONFIG.complexityScoring
+      ),
+      complexityBase: config.get<number>("complexity.base", DEFAULT_CONFIG.complexityBase),
+      streamingThreshold: config.get<number>(
+        "analysis.streamingThreshold",
+        DEFAULT_CONFIG.streamingThreshold
+      ),
+      featureFlagPatterns: config.get<string[]>(
+        "complexity.featureFlagPatterns",
+        DEFAULT_CONFIG.featureFlagPatterns
+      ),
+      panicMode: config.get<PanicMode>("complexity.panic", DEFAULT_CONFIG.panicMode),
+      literalFuncMode: config.get<LiteralFuncMode>("literalFuncs", DEFAULT_CONFIG.literalFuncMode),
     };
+
+    // A workspace folder's `.codemetrics.json` takes precedence over VS Code settings,
+    // so each root of a multi-root workspace can carry its own thresholds and excludes.
+    // The active profile is an explicit choice for the session and wins over both.
+    const resolved = applyComplexityPreset({ ...settings, ...this.getOverrides(resource) });
+    if (!PRIMARY_METRICS.includes(resolved.primaryMetric)) {
+      this.warnUnsupportedPrimaryMetric(resolved.primaryMetric);
+      resolved.primaryMetric = DEFAULT_CONFIG.primaryMetric;
+    }
+    return resolved;
+  }
+
+  /**
+   * Tells the user once that a configured primary metric is not computed, for instance
+   * a metric name from another tool, and that cognitive complexity is used instead.
+   */
+  private static warnUnsupportedPrimaryMetric(metric: string): void {
+    if (this.reportedPrimaryMetrics.has(metric)) {
+      return;
+    }
+    this.reportedPrimaryMetrics.add(metric);
+    void vscode.window.showWarningMessage(
+      `Code Metrics: ${this.describeUnsupportedPrimaryMetric(metric)}. ` +
+        "Falling back to cognitive complexity."
+    );
+  }
+
+  /** Explains why a primary metric is rejected, e.g. `primary metric "cyclomatic" is not implemented; ...`. */
+  private static describeUnsupportedPrimaryMetric(metric: string): string {
+    const advice = UNSUPPORTED_PRIMARY_METRICS[metric];
+    return advice
+      ? `primary metric "${metric}" is not implemented; ${advice}`
+      : `primary metric "${metric}" is not available; use one of ${PRIMARY_METRICS.join(", ")}`;
+  }
+
+  /**
+   * Returns the primary metric as configured, before an unsupported one falls back to
+   * `cognitive`: the active profile's, the `.codemetrics.json` one, or the setting.
+   */
+  private static getConfiguredPrimaryMetric(resource?: vscode.Uri): string {
+    const setting = vscode.workspace
+      .getConfiguration(this.CONFIG_SECTION, resource)
+      .get<string>("display.primaryMetric", DEFAULT_CONFIG.primaryMetric);
+    return this.getOverrides(resource).primaryMetric ?? setting;
+  }
+
+  /**
+   * Returns the threshold profiles defined in `codeMetrics.profiles`, with each profile
+   * reduced to its valid setting overrides.
+   *
+   * @param resource - Optional URI for workspace-specific configuration
+   * @returns Profiles keyed by name
+   */
+  public static getProfiles(
+    resource?: vscode.Uri
+  ): Record<string, Partial<CodeMetricsConfig>> {
+    const raw = vscode.workspace
+      .getConfiguration(this.CONFIG_SECTION, resource)
+      .get<Record<string, unknown>>("profiles", {});
+    const profiles: Record<string, Partial<CodeMetricsConfig>> = {};
+    for (const [name, value] of Object.entries(raw ?? {})) {
+      profiles[name] = toConfigOverrides(value);
+    }
+    return profiles;
+  }
+
+  /** Returns the name of the active threshold profile, or undefined when none is active. */
+  public static getActiveProfile(): string | undefined {
+    return this.activeProfile;
+  }
+
+  /**
+   * Activates a threshold profile by name, or clears it when `name` is undefined.
+   * A profile name that is not defined for a resource simply applies no overrides there.
+   *
+   * @param name - The profile to activate
+   */
+  public static setActiveProfile(name: string | undefined): void {
+    if (name === this.activeProfile) {
+      return;
+    }
+    this.activeProfile = name;
+    this.profileEmitter.fire(name);
+  }
+
+  /**
+   * Invokes the callback whenever the active threshold profile changes.
+   *
+   * @param callback - Function to call with the new profile name
+   * @returns Disposable that stops listening
+   */
+  public static onActiveProfileChanged(
+    callback: (name: string | undefined) => void
+  ): vscode.Disposable {
+    return this.profileEmitter.event(callback);
+  }
+
+  /** Combines project and active-profile overrides for a resource, highest precedence last. */
+  private static getOverrides(resource?: vscode.Uri): Partial<CodeMetricsConfig> {
+    const profile = this.activeProfile
+      ? this.getProfiles(resource)[this.activeProfile]
+      : undefined;
+    return { ...this.getProjectConfig(resource), ...profile };
+  }
+
+  /**
+   * Returns the `.codemetrics.json` overrides for the workspace folder containing a resource.
+   *
+   * @param resource - Optional URI used to locate the workspace folder
+   * @returns The overrides, or an empty object when there is no folder or no project file
+   */
+  public static getProjectConfig(resource?: vscode.Uri): Partial<CodeMetricsConfig> {
+    const folder = resource ? vscode.workspace.getWorkspaceFolder(resource) : undefined;
+    if (!folder || folder.uri.scheme !== "file") {
+      return {};
+    }
+
+    const folderPath = folder.uri.fsPath;
+    let overrides = this.projectConfigCache.get(folderPath);
+    if (!overrides) {
+      const filePath = path.join(folderPath, PROJECT_CONFIG_FILE);
+      overrides = fs.existsSync(filePath)
+        ? parseProjectConfig(fs.readFileSync(filePath, "utf8"))
+        : {};
+      this.projectConfigCache.set(folderPath, overrides);
+    }
+    return overrides;
+  }
+
+  /**
+   * Watches `.codemetrics.json` files in all workspace folders, dropping cached overrides
+   * and invoking the callback whenever one is created, changed, or deleted.
+   *
+   * @param callback - Function to call after the cache is invalidated
+   * @returns Disposable that stops watching
+   */
+  public static onProjectConfigChanged(callback: () => void): vscode.Disposable {
+    const watcher = vscode.workspace.createFileSystemWatcher(
+      `**/${PROJECT_CONFIG_FILE}`
+    );
+    const invalidate = () => {
+      this.projectConfigCache.clear();
+      callback();
+    };
+    return vscode.Disposable.from(
+      watcher,
+      watcher.onDidCreate(invalidate),
+      watcher.onDidChange(invalidate),
+      watcher.onDidDelete(invalidate)
+    );
   }
 
   /**
@@ -96,6 +491,10 @@ export class ConfigurationManager {
     key: K,
     resource?: vscode.Uri
   ): CodeMetricsConfig[K] {
+    const override = this.getOverrides(resource)[key];
+    if (override !== undefined) {
+      return override as CodeMetricsConfig[K];
+    }
     const config = vscode.workspace.getConfiguration(
       this.CONFIG_SECTION,
       resource
@@ -113,6 +512,16 @@ export class ConfigurationManager {
     return this.get("enabled", resource);
   }
 
+  /**
+   * Derives the analyzer options from a resolved configuration.
+   *
+   * @param config - The resolved configuration
+   * @returns Options to pass to `MetricsAnalyzerFactory.analyzeFile`
+   */
+  public static getAnalyzerOptions(config: CodeMetricsConfig): AnalyzerOptions {
+    return toAnalyzerOptions(config);
+  }
+
   /**
    * Gets the complexity status for a given complexity score.
    *
@@ -137,23 +546,201 @@ export class ConfigurationManager {
       return {
         level: "error",
         icon: "🔴",
-        text: "High Complexity",
+        text: vscode.l10n.t("High Complexity"),
       };
     } else if (complexity >= config.warningThreshold) {
       return {
         level: "warning",
         icon: "🟡",
-        text: "Moderate Complexity",
+        text: vscode.l10n.t("Moderate Complexity"),
       };
     } else {
       return {
         level: "low",
         icon: "🟢",
-        text: "Low Complexity",
+        text: vscode.l10n.t("Low Complexity"),
       };
     }
   }
 
+  /**
+   * Returns the value of a function's primary metric (`display.primaryMetric`).
+   *
+   * @param func - The analyzed function
+   * @param config - The resolved configuration
+   * @returns The cognitive complexity, or the deepest nesting level of its increments
+   */
+  public static getPrimaryMetricValue(
+    func: UnifiedFunctionMetrics,
+    config: CodeMetricsConfig
+  ): number {
+    if (config.primaryMetric === "nesting") {
+      return func.details.reduce((max, detail) => Math.max(max, detail.nesting), 0);
+    }
+    return func.complexity;
+  }
+
+  /**
+   * Gets a function's band from its primary metric, compared against the thresholds of
+   * that metric.
+   *
+   * @param func - The analyzed function
+   * @param config - The resolved configuration
+   * @returns The primary metric's value and the status of its band
+   */
+  public static getPrimaryMetricStatus(
+    func: UnifiedFunctionMetrics,
+    config: CodeMetricsConfig
+  ): { value: number; status: ReturnType<typeof ConfigurationManager.getComplexityStatus> } {
+    const value = this.getPrimaryMetricValue(func, config);
+    const thresholds =
+      config.primaryMetric === "nesting"
+        ? {
+            ...config,
+            warningThreshold: config.nestingWarningThreshold,
+            errorThreshold: config.nestingErrorThreshold,
+          }
+        : config;
+    return { value, status: this.getComplexityStatus(value, thresholds) };
+  }
+
+  /**
+   * Returns the locale used to display non-integer metrics: the `display.locale`
+   * override when set, otherwise VS Code's display language.
+   *
+   * @param config - The resolved configuration
+   * @returns A BCP 47 locale tag
+   */
+  public static getDisplayLocale(config: CodeMetricsConfig): string {
+    return config.displayLocale || vscode.env.language;
+  }
+
+  /**
+   * Collects warnings for auxiliary per-function metrics that exceed their configured thresholds.
+   * These are reported alongside — not folded into — the cognitive complexity status.
+   *
+   * @param func - The analyzed function
+   * @param config - The resolved configuration
+   * @returns Human-readable warning messages (empty when nothing is over threshold)
+   */
+  public static getMetricWarnings(
+    func: UnifiedFunctionMetrics,
+    config: CodeMetricsConfig
+  ): string[] {
+    const warnings: string[] = [];
+
+    if (
+      config.fieldAccessThreshold > 0 &&
+      func.fieldAccessCount !== undefined &&
+      func.fieldAccessCount > config.fieldAccessThreshold
+    ) {
+      warnings.push(
+        `Accesses ${func.fieldAccessCount} receiver fields (threshold ${config.fieldAccessThreshold})`
+      );
+    }
+
+    if (
+      config.conditionOperandThreshold > 0 &&
+      func.maxConditionOperands !== undefined &&
+      func.maxConditionOperands > config.conditionOperandThreshold
+    ) {
+      warnings.push(
+        `Condition with ${func.maxConditionOperands} boolean operands (threshold ${config.conditionOperandThreshold})`
+      );
+    }
+
+    if (
+      config.expressionNestingThreshold > 0 &&
+      func.maxExpressionNesting !== undefined &&
+      func.maxExpressionNesting > config.expressionNestingThreshold
+    ) {
+      warnings.push(
+        `Conditional expressions nested ${func.maxExpressionNesting} deep (threshold ${config.expressionNestingThreshold})`
+      );
+    }
+
+    if (
+      config.closureDepthThreshold > 0 &&
+      func.maxClosureDepth !== undefined &&
+      func.maxClosureDepth > config.closureDepthThreshold
+    ) {
+      warnings.push(
+        `Closures nested ${func.maxClosureDepth} deep (threshold ${config.closureDepthThreshold})`
+      );
+    }
+
+    const longest = ConfigurationManager.getLongSwitches(func, config)[0];
+    if (longest) {
+      warnings.push(
+        `${longest.kind[0].toUpperCase()}${longest.kind.slice(1)} with ${longest.caseCount} cases ` +
+          `(threshold ${config.caseClauseThreshold})`
+      );
+    }
+
+    if (
+      config.branchDensityThreshold > 0 &&
+      func.branchDensity !== undefined &&
+      func.branchDensity > config.branchDensityThreshold
+    ) {
+      warnings.push(
+        `${func.branchCount} branches in ${func.statementCount} statements, ` +
+          `density ${func.branchDensity} (threshold ${config.branchDensityThreshold})`
+      );
+    }
+
+    return warnings;
+  }
+
+  /**
+   * Returns a function's switch and select statements with more clauses than
+   * `caseClauseThreshold`, longest first.
+   *
+   * @param func - The analyzed function
+   * @param config - The resolved configuration
+   * @returns The long statements, or none when the threshold is 0
+   */
+  public static getLongSwitches(
+    func: UnifiedFunctionMetrics,
+    config: CodeMetricsConfig
+  ): SwitchSize[] {
+    if (config.caseClauseThreshold <= 0 || !func.switches) {
+      return [];
+    }
+    return func.switches
+      .filter((statement) => statement.caseCount > config.caseClauseThreshold)
+      .sort((a, b) => b.caseCount - a.caseCount || a.line - b.line);
+  }
+
+  /**
+   * Finds the function that dominates its file: the one holding at least
+   * `dominantFunctionShare` percent of the file's total complexity. Files with a single
+   * function, or whose total stays below the warning threshold, are never flagged, so
+   * small files do not trip the check.
+   *
+   * @param functions - All analyzed functions of one file
+   * @param config - The file's resolved configuration
+   * @returns The dominant function and its share (0–100, rounded), or undefined
+   */
+  public static getDominantFunction(
+    functions: UnifiedFunctionMetrics[],
+    config: CodeMetricsConfig
+  ): { func: UnifiedFunctionMetrics; share: number } | undefined {
+    const total = functions.reduce((sum, func) => sum + func.complexity, 0);
+    if (
+      config.dominantFunctionShare <= 0 ||
+      functions.length < 2 ||
+      total < config.warningThreshold ||
+      total === 0
+    ) {
+      return undefined;
+    }
+    const top = functions.reduce((a, b) => (b.complexity > a.complexity ? b : a));
+    const share = (top.complexity / total) * 100;
+    return share >= config.dominantFunctionShare
+      ? { func: top, share: Math.round(share) }
+      : undefined;
+  }
+
   /**
    * Validates that thresholds are properly configured (warning < error).
    *
@@ -174,6 +761,67 @@ export class ConfigurationManager {
       );
     }
 
+    // Invalid function name patterns are skipped by the analyzer; point them out here.
+    for (const pattern of config.excludeFunctionPatterns) {
+      try {
+        new RegExp(pattern);
+      } catch {
+        warnings.push(`Function name pattern "${pattern}" is not a valid regular expression`);
+      }
+    }
+
+    for (const pattern of config.featureFlagPatterns) {
+      try {
+        new RegExp(pattern);
+      } catch {
+        warnings.push(`Feature flag pattern "${pattern}" is not a valid regular expression`);
+      }
+    }
+
+    for (const pattern of config.resolverPatterns) {
+      try {
+        new RegExp(pattern);
+      } catch {
+        warnings.push(`Resolver pattern "${pattern}" is not a valid regular expression`);
+      }
+    }
+
+    for (const metric of config.enabledMetrics) {
+      if (metric !== "complexity" && !(OPTIONAL_METRICS as readonly string[]).includes(metric)) {
+        warnings.push(
+          `Metric "${metric}" in metrics.enabled is not computed; use complexity, ${OPTIONAL_METRICS.join(", ")}`
+        );
+      }
+    }
+
+    const primaryMetric = this.getConfiguredPrimaryMetric(resource);
+    if (!(PRIMARY_METRICS as readonly string[]).includes(primaryMetric)) {
+      const reason = this.describeUnsupportedPrimaryMetric(primaryMetric);
+      warnings.push(`${reason.charAt(0).toUpperCase()}${reason.slice(1)}; using cognitive`);
+    } else if (!config.enabledMetrics.includes(PRIMARY_METRIC_SOURCES[config.primaryMetric])) {
+      warnings.push(
+        `Primary metric "${config.primaryMetric}" needs ` +
+          `"${PRIMARY_METRIC_SOURCES[config.primaryMetric]}" in metrics.enabled`
+      );
+    }
+
+    if (config.reportSortBy === "tokens" && !config.enabledMetrics.includes("tokens")) {
+      warnings.push(
+        'Report sort order "tokens" needs "tokens" in metrics.enabled; functions are not counted'
+      );
+    }
+
+    const presets = ["custom", ...Object.keys(COMPLEXITY_PRESETS)];
+    if (!presets.includes(config.complexityPreset)) {
+      warnings.push(
+        `Complexity preset "${config.complexityPreset}" is not known; use ${presets.join(", ")}`
+      );
+    }
+
+    if (config.complexityBase !== 0 && config.complexityBase !== 1) {
+      warnings.push(`Complexity base (${config.complexityBase}) should be 0 or 1; using 0`);
+    }
+
     return {
       valid: warnings.length === 0,
       warnings,
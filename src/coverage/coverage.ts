@@ -0,0 +1,151 @@
+/**
+ * @fileoverview Code Coverage Overlay
+ *
+ * This module parses line coverage from an lcov tracefile or a Go cover profile and joins
+ * it to per-function metrics. Functions that are both complex and poorly covered are the
+ * riskiest to change, so each function gets a risk score that grows with complexity and
+ * with the share of its lines that no test executes.
+ */
+
+import { UnifiedFunctionMetrics } from "../metricsAnalyzer/metricsAnalyzerFactory";
+
+/** Hit counts per instrumented line (1-based) of one file. */
+export type FileCoverage = Map<number, number>;
+
+/** Line coverage keyed by the file path as written in the coverage file (forward slashes). */
+export type CoverageReport = Map<string, FileCoverage>;
+
+/**
+ * Parses an lcov tracefile (`SF:` / `DA:` / `end_of_record`).
+ *
+ * @param text - Raw file contents
+ * @returns Line coverage per source file
+ */
+export function parseLcov(text: string): CoverageReport {
+  const report: CoverageReport = new Map();
+  let current: FileCoverage | undefined;
+  for (const rawLine of text.split(/\r?\n/)) {
+    const line = rawLine.trim();
+    if (line.startsWith("SF:")) {
+      current = getOrCreate(report, line.substring(3));
+    } else if (line.startsWith("DA:") && current) {
+      const [lineNumber, hits] = line.substring(3).split(",").map(Number);
+      if (Number.isInteger(lineNumber) && Number.isFinite(hits)) {
+        current.set(lineNumber, Math.max(current.get(lineNumber) ?? 0, hits));
+      }
+    } else if (line === "end_of_record") {
+      current = undefined;
+    }
+  }
+  return report;
+}
+
+/** Matches a Go cover profile block: `file.go:startLine.startCol,endLine.endCol numStmts count`. */
+const GO_COVER_BLOCK = /^(.+):(\d+)\.\d+,(\d+)\.\d+ \d+ (\d+)$/;
+
+/**
+ * Parses a Go cover profile (`go test -coverprofile`). Every line of a block is treated
+ * as instrumented with the block's hit count; overlapping blocks keep the highest count.
+ *
+ * @param text - Raw file contents
+ * @returns Line coverage per source file (keyed by import path, e.g. `example.com/m/pkg/a.go`)
+ */
+export function parseGoCoverProfile(text: string): CoverageReport {
+  const report: CoverageReport = new Map();
+  for (const rawLine of text.split(/\r?\n/)) {
+    const match = GO_COVER_BLOCK.exec(rawLine.trim());
+    if (!match) {
+      continue;
+    }
+    const file = getOrCreate(report, match[1]);
+    const hits = Number(match[4]);
+    for (let line = Number(match[2]); line <= Number(match[3]); line++) {
+      file.set(line, Math.max(file.get(line) ?? 0, hits));
+    }
+  }
+  return report;
+}
+
+/**
+ * Parses a coverage file, detecting Go cover profiles by their `mode:` header and
+ * treating anything else as lcov.
+ *
+ * @param text - Raw file contents
+ * @returns Line coverage per source file
+ */
+export function parseCoverage(text: string): CoverageReport {
+  return /^\s*mode:/.test(text) ? parseGoCoverProfile(text) : parseLcov(text);
+}
+
+/**
+ * Finds the coverage of a workspace file. Coverage paths may be absolute, relative to the
+ * root, or Go import paths, so a path matches when it equals or ends with the relative path.
+ *
+ * @param report - The parsed coverage
+ * @param relativePath - The file's path relative to its workspace root (forward slashes)
+ * @returns The file's coverage, or undefined if the coverage file does not mention it
+ */
+export function findFileCoverage(
+  report: CoverageReport,
+  relativePath: string
+): FileCoverage | undefined {
+  const exact = report.get(relativePath);
+  if (exact) {
+    return exact;
+  }
+  const suffix = `/${relativePath}`;
+  for (const [file, coverage] of report) {
+    if (file.replace(/\\/g, "/").endsWith(suffix)) {
+      return coverage;
+    }
+  }
+  return undefined;
+}
+
+/**
+ * Computes the share of a function's instrumented lines that were executed.
+ *
+ * @param func - The function (0-based `startLine`/`endLine`)
+ * @param coverage - The coverage of the function's file
+ * @returns A ratio between 0 and 1, or undefined when no line of the function is instrumented
+ */
+export function getFunctionCoverage(
+  func: UnifiedFunctionMetrics,
+  coverage: FileCoverage
+): number | undefined {
+  let instrumented = 0;
+  let covered = 0;
+  for (let line = func.startLine + 1; line <= func.endLine + 1; line++) {
+    const hits = coverage.get(line);
+    if (hits !== undefined) {
+      instrumented++;
+      if (hits > 0) {
+        covered++;
+      }
+    }
+  }
+  return instrumented === 0 ? undefined : covered / instrumented;
+}
+
+/**
+ * Combines complexity and coverage into a risk score: the complexity weighted by the
+ * uncovered share, so a fully covered function scores 0 and an untested one scores
+ * its full complexity.
+ *
+ * @param complexity - The function's cognitive complexity
+ * @param coverage - The function's coverage ratio (0–1)
+ * @returns The risk score, rounded to one decimal
+ */
+export function getRiskScore(complexity: number, coverage: number): number {
+  return Math.round(complexity * (1 - coverage) * 10) / 10;
+}
+
+function getOrCreate(report: CoverageReport, path: string): FileCoverage {
+  const key = path.replace(/\\/g, "/");
+  let file = report.get(key);
+  if (!file) {
+    file = new Map();
+    report.set(key, file);
+  }
+  return file;
+}
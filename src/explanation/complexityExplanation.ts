@@ -0,0 +1,192 @@
+/**
+ * @fileoverview Complexity Explanation
+ *
+ * Turns the breakdown of a function's cognitive complexity into plain sentences, one per
+ * counted decision ("+1 for `if` at line 42"), for a panel that teaches what the metric
+ * counts and why nesting costs more. Lines in the panel link back to the source. The same
+ * explanation, summarized, backs the quick check of the function at the cursor.
+ */
+
+import { UnifiedFunctionMetrics, UnifiedMetricsDetail } from "../metricsAnalyzer/metricsAnalyzerFactory";
+
+/** One counted decision of a function, explained. */
+export interface ExplainedDecision {
+  /** Line of the decision (1-based) */
+  line: number;
+  /** The increment it adds */
+  increment: number;
+  /** The construct, e.g. `if`, `&&`, or `catch` */
+  construct: string;
+  /** The summary, e.g. "+3 for `if` at line 42" */
+  summary: string;
+  /** Why the construct counts, and how its nesting adds to it */
+  explanation: string;
+  /** The trimmed source line */
+  source: string;
+}
+
+/** What each kind of construct costs a reader, matched against the detail reason in order. */
+const CONSTRUCT_NOTES: readonly [RegExp, string][] = [
+  [
+    /&&|\|\||\?\?|\b(and|or|AND|OR)\b|logical|boolean/,
+    "A sequence of logical operators is one more condition to hold in mind; switching operators starts a new sequence.",
+  ],
+  [
+    /^(else|elif|elsif)/i,
+    "An alternative branch is another path to follow, counted flat because it does not deepen the structure.",
+  ],
+  [/loop|^for\b|^while\b|^do\b|foreach|range/i, "A loop's body can run many times, in states that change between runs."],
+  [/catch|except|rescue|recover|exception/i, "A handler is a path taken only when something fails."],
+  [/break|continue|goto|jump|exit/i, "A jump breaks the top-to-bottom flow of the code."],
+  [/case|when|switch|match|select/i, "Each branch of a multi-way choice is a path to follow."],
+  [/recurs/i, "A recursive call makes the function loop through itself."],
+  [/lambda|closure|function literal|nested function/i, "A function defined inside another adds a context to keep track of."],
+  [/\bif\b|ternary|conditional|guard|unless/i, "A condition splits the flow into paths to follow."],
+];
+
+/**
+ * Reduces a detail reason to the construct it names: `binary && operator` → `&&`,
+ * `if statement` → `if`, `else if clause` → `else if`.
+ */
+export function getConstruct(reason: string): string {
+  const construct = reason
+    .replace(/\s*\(.*\)\s*$/, "")
+    .replace(/^(binary|logical|boolean)\s+/i, "")
+    .replace(/\s+(statement|clause|operator|expression|loop|call|sequence)$/i, "")
+    .trim();
+  return construct || reason;
+}
+
+/**
+ * Explains one detail: its increment, and how much of it comes from nesting.
+ *
+ * @param detail - The detail, with a 1-based position
+ * @param lines - The source lines of the function's file
+ */
+export function explainDecision(
+  detail: UnifiedMetricsDetail,
+  lines: readonly string[]
+): ExplainedDecision {
+  const construct = getConstruct(detail.reason);
+  const note = CONSTRUCT_NOTES.find(([pattern]) => pattern.test(detail.reason))?.[1];
+  const cost =
+    detail.nesting > 0 && detail.increment === 1 + detail.nesting
+      ? `1 for the \`${construct}\` itself, plus ${detail.nesting} because it is nested ` +
+        `${detail.nesting === 1 ? "one level" : `${detail.nesting} levels`} deep.`
+      : detail.nesting > 0 && detail.increment === 1
+        ? "Counted flat, whatever its nesting."
+        : "";
+  return {
+    line: detail.line,
+    increment: detail.increment,
+    construct,
+    summary: `+${detail.increment} for \`${construct}\` at line ${detail.line}`,
+    explanation: [note, cost].filter((part) => part).join(" "),
+    source: (lines[detail.line - 1] ?? "").trim(),
+  };
+}
+
+/**
+ * Explains every counted decision of a function, in source order.
+ *
+ * @param func - The function, with 1-based detail positions
+ * @param lines - The source lines of the function's file
+ */
+export function explainFunction(
+  func: UnifiedFunctionMetrics,
+  lines: readonly string[]
+): ExplainedDecision[] {
+  return [...func.details]
+    .sort((a, b) => a.line - b.line || a.column - b.column)
+    .map((detail) => explainDecision(detail, lines));
+}
+
+/**
+ * Summarizes an explained function in one line for a notification, e.g. "Check:
+ * complexity 6 from 5 decisions, 8 lines of code. Largest: +2 for `if` at line 3."
+ *
+ * @param func - The explained function
+ * @param decisions - Results from {@link explainFunction}
+ */
+export function formatExplanationSummary(
+  func: UnifiedFunctionMetrics,
+  decisions: readonly ExplainedDecision[]
+): string {
+  const size = func.logicalLines !== undefined ? `, ${func.logicalLines} lines of code` : "";
+  if (decisions.length === 0) {
+    return `${func.name}: complexity ${func.complexity}${size}. Nothing adds to it.`;
+  }
+  const largest = decisions.reduce((max, d) => (d.increment > max.increment ? d : max));
+  return (
+    `${func.name}: complexity ${func.complexity} from ${decisions.length} ` +
+    `${decisions.length === 1 ? "decision" : "decisions"}${size}. Largest: ${largest.summary}.`
+  );
+}
+
+/** Escapes text for HTML content and attributes. */
+function escapeHtml(text: string): string {
+  return text
+    .replace(/&/g, "&amp;")
+    .replace(/</g, "&lt;")
+    .replace(/>/g, "&gt;")
+    .replace(/"/g, "&quot;");
+}
+
+/** Renders `code` spans of a summary as HTML. */
+function renderSummary(summary: string): string {
+  return escapeHtml(summary).replace(/`([^`]+)`/g, "<code>$1</code>");
+}
+
+/**
+ * Renders the explanation of a function as a self-contained webview page. The page runs
+ * no scripts; each line number is a command link that reveals the line in the editor.
+ *
+ * @param func - The explained function
+ * @param decisions - Results from {@link explainFunction}
+ * @param revealLink - Returns the link target that reveals a 1-based line
+ * @returns The HTML document
+ */
+export function renderExplanationHtml(
+  func: UnifiedFunctionMetrics,
+  decisions: readonly ExplainedDecision[],
+  revealLink: (line: number) => string
+): string {
+  const rows = decisions
+    .map(
+      (d) =>
+        `<tr><td class="increment">+${d.increment}</td>` +
+        `<td><div>${renderSummary(d.summary)}</div>` +
+        `<a class="source" href="${escapeHtml(revealLink(d.line))}" title="Go to line ${d.line}">` +
+        `<span class="line">${d.line}</span> <code>${escapeHtml(d.source)}</code></a>` +
+        (d.explanation ? `<div class="why">${escapeHtml(d.explanation)}</div>` : "") +
+        `</td></tr>`
+    )
+    .join("\n");
+  const body =
+    decisions.length > 0
+      ? `<table>\n${rows}\n</table>`
+      : "<p>Nothing in this function adds to its complexity: it reads straight from top to bottom.</p>";
+
+  return `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta http-equiv="Content-Security-Policy" content="default-src 'none'; style-src 'unsafe-inline';">
+<title>Why is ${escapeHtml(func.name)} complex?</title>
+<style>
+  body { font-family: var(--vscode-font-family); color: var(--vscode-foreground); padding: 1em 2em; }
+  table { border-collapse: collapse; width: 100%; max-width: 56em; }
+  td { padding: 0.5em 0; vertical-align: top; border-bottom: 1px solid var(--vscode-panel-border); }
+  .increment { font-size: 1.4em; font-weight: bold; width: 3em; }
+  .source { display: block; margin: 0.25em 0; text-decoration: none; }
+  .line { opacity: 0.7; }
+  .why { opacity: 0.85; }
+</style>
+</head>
+<body>
+<h2>Why is <code>${escapeHtml(func.name)}</code> complex?</h2>
+<p>Cognitive complexity ${func.complexity}, from ${decisions.length} counted decisions. Branches and loops add 1, plus 1 for every level of nesting they sit in; alternatives such as <code>else</code> and logical operators add a flat 1.</p>
+${body}
+</body>
+</html>`;
+}
@@ -0,0 +1,74 @@
+/**
+ * @fileoverview Export Schema
+ *
+ * The JSON Schema of JSON exports, the contract for tools that read them. The schema is
+ * published as `schemas/metrics-export.schema.json` and versioned with the export's
+ * `schemaVersion`: a change that could break a reader, such as a removed or renamed
+ * field, a new status, or a new field (rows do not allow unknown fields), bumps both.
+ */
+
+/** Version of the JSON export format, written to every export as `schemaVersion`. */
+export const EXPORT_SCHEMA_VERSION = 1;
+
+/** Where the published schema can be found; written to every export as `$schema`. */
+export const EXPORT_SCHEMA_ID =
+  "https://raw.githubusercontent.com/askpt/code-metrics/main/schemas/metrics-export.schema.json";
+
+/** JSON Schema (draft 2020-12) of a JSON export. */
+export const EXPORT_SCHEMA = {
+  $schema: "https://json-schema.org/draft/2020-12/schema",
+  $id: EXPORT_SCHEMA_ID,
+  title: "Code Metrics export",
+  description: "Per-function complexity metrics exported by the Code Metrics extension.",
+  type: "object",
+  required: ["schemaVersion", "functions"],
+  additionalProperties: false,
+  properties: {
+    $schema: { type: "string" },
+    schemaVersion: { const: EXPORT_SCHEMA_VERSION },
+    functions: {
+      type: "array",
+      items: {
+        type: "object",
+        required: [
+          "root",
+          "file",
+          "language",
+          "function",
+          "startLine",
+          "endLine",
+          "complexity",
+          "status",
+        ],
+        additionalProperties: false,
+        properties: {
+          id: {
+            type: "string",
+            description:
+              "Unique key <root>/<file>#<name>, with @<startLine> appended when a name repeats within a file",
+          },
+          root: { type: "string", description: "Workspace root name" },
+          file: { type: "string", description: "Path relative to the root, with forward slashes" },
+          language: { type: "string", description: "VS Code language identifier, e.g. go" },
+          function: {
+            type: "string",
+            description: "Qualified function name, e.g. Service.Save",
+          },
+          startLine: { type: "integer", minimum: 1, description: "First line (1-based)" },
+          endLine: { type: "integer", minimum: 1, description: "Last line (1-based)" },
+          complexity: { type: "integer", minimum: 0, description: "Cognitive complexity" },
+          status: { enum: ["low", "warning", "error"], description: "Complexity band" },
+          logicalLines: {
+            type: "integer",
+            minimum: 0,
+            description: "Lines with code, blank and comment-only lines excluded",
+          },
+          fingerprint: {
+            type: "string",
+            description: "Content hash that survives renames, with the fingerprint identity strategy",
+          },
+        },
+      },
+    },
+  },
+} as const;
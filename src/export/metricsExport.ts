@@ -0,0 +1,167 @@
+/**
+ * @fileoverview Metrics Export
+ *
+ * This module flattens analysis results into one row per function and serializes them
+ * as JSON, CSV, or OpenMetrics text, so they can be attached to reviews, processed by
+ * other tools, or pushed to a monitoring system.
+ * Rows can be limited to violations: functions at or above the warning or error band
+ * of the configuration that applies to their root and language. JSON exports follow a published,
+ * versioned schema (see {@link EXPORT_SCHEMA}).
+ */
+
+import { ConfigurationManager } from "../configuration";
+import { WorkspaceMetrics, getLanguageConfig } from "../workspace/workspaceAnalyzer";
+import { EXPORT_SCHEMA, EXPORT_SCHEMA_ID, EXPORT_SCHEMA_VERSION } from "./exportSchema";
+import { formatOpenMetrics } from "./openMetrics";
+
+/** Serialization format of an export. */
+export type ExportFormat = "json" | "csv" | "openmetrics";
+
+/** Lowest complexity band kept when exporting only violations. */
+export type ViolationLevel = "warning" | "error";
+
+/** Options controlling which functions are exported and how. */
+export interface ExportOptions {
+  format: ExportFormat;
+  /** When set, only functions at or above this band are exported */
+  onlyViolations?: ViolationLevel;
+  /** Whether each row gets a unique `id` of root, file path, and qualified name */
+  qualifiedNames?: boolean;
+}
+
+/** One exported function. */
+export interface ExportRow {
+  /**
+   * Unique key `<root>/<file>#<name>` (names include the Go receiver, e.g. `Service.Sum`);
+   * `@<startLine>` is appended when a name repeats within a file, e.g. overloads
+   */
+  id?: string;
+  /** Workspace root name */
+  root: string;
+  /** Path relative to the root (forward slashes) */
+  file: string;
+  language: string;
+  function: string;
+  /** First line of the function (1-based) */
+  startLine: number;
+  /** Last line of the function (1-based) */
+  endLine: number;
+  complexity: number;
+  /** Complexity band: low, warning, or error */
+  status: "low" | "warning" | "error";
+  /** Logical lines of code, when measured */
+  logicalLines?: number;
+  /** Content fingerprint that survives renames, with the `fingerprint` identity strategy */
+  fingerprint?: string;
+}
+
+/** Column order of CSV exports. */
+const CSV_COLUMNS: (keyof ExportRow)[] = [
+  "root",
+  "file",
+  "language",
+  "function",
+  "startLine",
+  "endLine",
+  "complexity",
+  "status",
+  "logicalLines",
+];
+
+/**
+ * Flattens analysis results into export rows, applying the violation filter.
+ *
+ * @param metrics - Results for a file or the whole workspace
+ * @param options - Export options
+ * @returns Rows in root, file, and line order
+ */
+export function collectExportRows(
+  metrics: WorkspaceMetrics,
+  options: ExportOptions
+): ExportRow[] {
+  const rows: ExportRow[] = [];
+  for (const root of metrics.roots) {
+    for (const file of root.files) {
+      const nameCounts = new Map<string, number>();
+      for (const func of file.functions) {
+        nameCounts.set(func.name, (nameCounts.get(func.name) ?? 0) + 1);
+      }
+      const config = getLanguageConfig(root, file.languageId);
+      for (const func of file.functions) {
+        const status = ConfigurationManager.getComplexityStatus(func.complexity, config);
+        if (
+          (options.onlyViolations === "warning" && status.level === "low") ||
+          (options.onlyViolations === "error" && status.level !== "error")
+        ) {
+          continue;
+        }
+        const id = options.qualifiedNames
+          ? `${root.name}/${file.relativePath}#${func.name}` +
+            ((nameCounts.get(func.name) ?? 0) > 1 ? `@${func.startLine + 1}` : "")
+          : undefined;
+        rows.push({
+          ...(id !== undefined ? { id } : {}),
+          root: root.name,
+          file: file.relativePath,
+          language: file.languageId,
+          function: func.name,
+          startLine: func.startLine + 1,
+          endLine: func.endLine + 1,
+          complexity: func.complexity,
+          status: status.level,
+          logicalLines: func.logicalLines,
+          ...(func.fingerprint !== undefined ? { fingerprint: func.fingerprint } : {}),
+        });
+      }
+    }
+  }
+  return rows;
+}
+
+/**
+ * Serializes export rows.
+ *
+ * @param rows - The rows to write
+ * @param format - JSON (an object with the `schemaVersion` and the rows as `functions`),
+ *   CSV (with a header row), or OpenMetrics text (one gauge series per function)
+ * @returns The file contents
+ */
+export function formatExport(rows: ExportRow[], format: ExportFormat): string {
+  if (format === "openmetrics") {
+    return formatOpenMetrics(rows);
+  }
+  if (format === "json") {
+    const document = {
+      $schema: EXPORT_SCHEMA_ID,
+      schemaVersion: EXPORT_SCHEMA_VERSION,
+      functions: rows,
+    };
+    return `${JSON.stringify(document, null, 2)}\n`;
+  }
+  const measured = rows.length === 0 || rows.some((row) => row.logicalLines !== undefined);
+  const columns: (keyof ExportRow)[] = [
+    ...(rows.some((row) => row.id !== undefined) ? ["id" as const] : []),
+    ...CSV_COLUMNS.filter((column) => measured || column !== "logicalLines"),
+    ...(rows.some((row) => row.fingerprint !== undefined) ? ["fingerprint" as const] : []),
+  ];
+  const lines = [columns.join(",")];
+  for (const row of rows) {
+    lines.push(columns.map((column) => toCsvField(row[column])).join(","));
+  }
+  return `${lines.join("\n")}\n`;
+}
+
+/**
+ * Serializes the JSON Schema of JSON exports, e.g. to save it next to an export.
+ *
+ * @returns The schema as indented JSON
+ */
+export function formatExportSchema(): string {
+  return `${JSON.stringify(EXPORT_SCHEMA, null, 2)}\n`;
+}
+
+/** Quotes a CSV field when it contains a separator, quote, or line break. */
+function toCsvField(value: string | number | undefined): string {
+  const text = value === undefined ? "" : String(value);
+  return /[",\r\n]/.test(text) ? `"${text.replace(/"/g, '""')}"` : text;
+}
@@ -0,0 +1,106 @@
+/**
+ * @fileoverview OpenMetrics Export
+ *
+ * Serializes per-function metrics in the OpenMetrics / Prometheus text exposition
+ * format, so CI can push them to a Prometheus Pushgateway and complexity can be trended
+ * in Grafana, e.g. `code_complexity{file="api/orders.go",func="Checkout",language="go"} 7`.
+ *
+ * Every function is one series per metric, so a repository with 20,000 functions pushes
+ * 20,000 series on every job. Keep the label set to the file, function, and language,
+ * never add a commit or build number as a label, and narrow large repositories to their
+ * violations or a directory before pushing.
+ *
+ * This module does not depend on the VS Code API and also backs the command-line entry
+ * point in `cli/exportOpenMetrics.ts`.
+ */
+
+/** One function to expose. */
+export interface OpenMetricsSample {
+  /** Workspace root name; a `root` label is added when samples span several roots */
+  root?: string;
+  /** Path relative to the root (forward slashes) */
+  file: string;
+  /** Qualified function name, e.g. `Service.Save` */
+  function: string;
+  /** VS Code language identifier, e.g. `go` */
+  language: string;
+  /** First line of the function (1-based), used to tell apart names repeated in a file */
+  startLine: number;
+  complexity: number;
+  /** Logical lines of code, when measured */
+  logicalLines?: number;
+}
+
+/** Options of {@link formatOpenMetrics}. */
+export interface OpenMetricsOptions {
+  /** Prefix of the metric names (default `code`, giving `code_complexity`) */
+  prefix?: string;
+}
+
+/**
+ * Serializes samples in the text exposition format, ending with `# EOF`.
+ *
+ * Samples are written as gauges: `<prefix>_complexity` and, for measured functions,
+ * `<prefix>_logical_lines`. A name that repeats within a file, such as an overload, gets
+ * `@<startLine>` appended to its `func` label so that every series stays unique.
+ *
+ * @param samples - The functions to expose
+ * @param options - Metric naming
+ * @returns The exposition text
+ */
+export function formatOpenMetrics(
+  samples: readonly OpenMetricsSample[],
+  options: OpenMetricsOptions = {}
+): string {
+  const prefix = options.prefix ?? "code";
+  const withRoot = new Set(samples.map((sample) => sample.root ?? "")).size > 1;
+  const nameCounts = new Map<string, number>();
+  for (const sample of samples) {
+    const key = `${sample.root ?? ""}\0${sample.file}\0${sample.function}`;
+    nameCounts.set(key, (nameCounts.get(key) ?? 0) + 1);
+  }
+  const labels = samples.map((sample) => {
+    const repeated =
+      (nameCounts.get(`${sample.root ?? ""}\0${sample.file}\0${sample.function}`) ?? 0) > 1;
+    const pairs: [string, string][] = [
+      ...(withRoot ? [["root", sample.root ?? ""] as [string, string]] : []),
+      ["file", sample.file],
+      ["func", repeated ? `${sample.function}@${sample.startLine}` : sample.function],
+      ["language", sample.language],
+    ];
+    return `{${pairs.map(([name, value]) => `${name}="${escapeLabelValue(value)}"`).join(",")}}`;
+  });
+
+  const lines = [
+    `# HELP ${prefix}_complexity Complexity score of a function, as configured for the analysis.`,
+    `# TYPE ${prefix}_complexity gauge`,
+    ...samples.map((sample, i) => `${prefix}_complexity${labels[i]} ${sample.complexity}`),
+  ];
+  if (samples.some((sample) => sample.logicalLines !== undefined)) {
+    lines.push(
+      `# HELP ${prefix}_logical_lines Lines with code in a function, blank and comment-only lines excluded.`,
+      `# TYPE ${prefix}_logical_lines gauge`
+    );
+    samples.forEach((sample, i) => {
+      if (sample.logicalLines !== undefined) {
+        lines.push(`${prefix}_logical_lines${labels[i]} ${sample.logicalLines}`);
+      }
+    });
+  }
+  lines.push("# EOF");
+  return `${lines.join("\n")}\n`;
+}
+
+/**
+ * Checks a metric name prefix: letters, digits, and underscores, not starting with a digit.
+ *
+ * @param prefix - The prefix to check
+ */
+export function isValidMetricPrefix(prefix: string): boolean {
+  return /^[a-zA-Z_][a-zA-Z0-9_]*$/.test(prefix);
+}
+
+/** Escapes a label value: backslashes, double quotes, and line breaks. */
+function escapeLabelValue(value: string): string {
+  return value.replace(/\\/g, "\\\\").replace(/"/g, '\\"').replace(/\n/g, "\\n");
+}
@@ -1,11 +1,113 @@
 import * as vscode from "vscode";
 import { registerCodeLensProvider } from "./providers/codeLensProvider";
-import { UnifiedFunctionMetrics } from "./metricsAnalyzer/metricsAnalyzerFactory";
+import { registerDiagnosticsProvider } from "./providers/diagnosticsProvider";
+import { registerTestComplexityProvider } from "./providers/testComplexityProvider";
+import { registerHistoryHoverProvider } from "./providers/historyHoverProvider";
+import { registerOverviewRuler } from "./providers/overviewRulerProvider";
+import { registerComplexitySymbolProvider } from "./providers/complexitySymbolProvider";
+import { registerSaveGuardrail } from "./providers/saveGuardrail";
+import { findNextHotspot, registerStatusBar } from "./providers/statusBarProvider";
+import {
+  MetricsAnalyzerFactory,
+  UnifiedFunctionMetrics,
+} from "./metricsAnalyzer/metricsAnalyzerFactory";
 import { ConfigurationManager } from "./configuration";
+import {
+  WorkspaceAnalyzer,
+  WorkspaceMetrics,
+  findWorstFunction,
+  formatFileListing,
+  formatWorkspaceReport,
+  summarizeWorkspace,
+} from "./workspace/workspaceAnalyzer";
+import { WorkspaceMetricsWatcher } from "./workspace/workspaceWatcher";
+import { CodeMetricsApi, createApi } from "./api";
+import {
+  BaselineOptions,
+  checkComplexityBudget,
+  collectComplexityChanges,
+  compareWithBaseline,
+  fetchBaseRef,
+  formatBaselineComment,
+  formatBudgetCheck,
+  formatChangeReport,
+  parseRemoteRef,
+  resolveBaseCommit,
+} from "./baseline/baseline";
+import {
+  computeDistribution,
+  renderDistributionHtml,
+} from "./workspace/complexityDistribution";
+import {
+  ExportFormat,
+  ViolationLevel,
+  collectExportRows,
+  formatExport,
+  formatExportSchema,
+} from "./export/metricsExport";
+import {
+  fetchSnippet,
+  formatSnippetReport,
+  inferSnippetLanguage,
+} from "./snippet/snippetSource";
+import {
+  AnnotationEdit,
+  getAnnotationEdits,
+  getCommentPrefix,
+  getStripEdits,
+} from "./annotations/complexityAnnotations";
+import { analyzeNotebookCells, formatNotebookReport } from "./notebook/notebookCells";
+import {
+  AnalysisProfile,
+  formatAnalysisProfile,
+  profileSource,
+  profileWorkspace,
+} from "./workspace/analysisProfile";
+import { compareWithGocyclo, formatGocycloComparison } from "./workspace/gocycloComparison";
+import { analyzeFolderAtRef } from "./workspace/snapshotAnalyzer";
+import { compareFunctions, formatFunctionComparison } from "./workspace/functionComparison";
+import {
+  explainFunction,
+  formatExplanationSummary,
+  renderExplanationHtml,
+} from "./explanation/complexityExplanation";
 
 /** Shared output channel for function complexity details (created once, reused). */
 let detailsChannel: vscode.OutputChannel | undefined;
 
+/** Shared output channel for workspace reports (created once, reused). */
+let reportChannel: vscode.OutputChannel | undefined;
+
+/** Shared output channel for dry-run file listings (created once, reused). */
+let filesChannel: vscode.OutputChannel | undefined;
+
+/** Shared output channel for analysis timings (created once, reused). */
+let timingChannel: vscode.OutputChannel | undefined;
+
+/** Shared output channel for reports of the workspace at a git ref (created once, reused). */
+let snapshotChannel: vscode.OutputChannel | undefined;
+
+/** Keeps the last workspace report current as files change (replaced on every full scan). */
+let workspaceWatcher: WorkspaceMetricsWatcher | undefined;
+
+/** Webview showing the complexity distribution, while open. */
+let distributionPanel: vscode.WebviewPanel | undefined;
+
+/** Webview explaining the complexity of one function, while open. */
+let explanationPanel: vscode.WebviewPanel | undefined;
+
+/** Highlight of the decision points of the function at the cursor, while shown. */
+let decisionHighlight: vscode.TextEditorDecorationType | undefined;
+
+/** How long *Explain Current Function* keeps the decision points highlighted. */
+const DECISION_HIGHLIGHT_MS = 10000;
+
+/** Analyzers registered by other extensions through the API, removed on deactivation. */
+const analyzerRegistrations: vscode.Disposable[] = [];
+
+/** Workspace state key under which the active threshold profile is persisted. */
+const ACTIVE_PROFILE_STATE_KEY = "codeMetrics.activeProfile";
+
 /**
  * Formats a cognitive complexity breakdown for a function and writes it to the
  * shared output channel, then reveals the channel to the user.
@@ -30,9 +132,89 @@ function showFunctionDetails(
   detailsChannel.appendLine(
     `Cognitive Complexity: ${func.complexity}  ${status.icon} ${status.text}`
   );
+  if (config.complexityBase === 1) {
+    detailsChannel.appendLine("Base: +1 (codeMetrics.complexity.base), not listed below");
+  }
   detailsChannel.appendLine(
     `Location: lines ${func.startLine + 1}–${func.endLine + 1}`
   );
+  const size = [
+    ...(func.logicalLines !== undefined ? [`${func.logicalLines} logical lines`] : []),
+    ...(func.tokenCount !== undefined ? [`${func.tokenCount} tokens`] : []),
+  ];
+  if (size.length > 0) {
+    detailsChannel.appendLine(`Size: ${size.join(", ")}`);
+  }
+  if (func.approximate) {
+    detailsChannel.appendLine(
+      "≈ Approximate: the parser did not fully understand this function's syntax, so its metrics are an estimate"
+    );
+  }
+  if (func.expectedComplexity) {
+    detailsChannel.appendLine(
+      `Expected: cc${func.expectedComplexity.operator}${func.expectedComplexity.value}`
+    );
+  }
+  if (func.fieldAccessCount !== undefined) {
+    detailsChannel.appendLine(`Receiver fields accessed: ${func.fieldAccessCount}`);
+  }
+  if (func.maxConditionOperands !== undefined) {
+    detailsChannel.appendLine(`Max condition operands: ${func.maxConditionOperands}`);
+  }
+  if (func.maxExpressionNesting !== undefined) {
+    detailsChannel.appendLine(`Max expression nesting: ${func.maxExpressionNesting}`);
+  }
+  if (func.clauseCount && func.clauseCount > 1) {
+    detailsChannel.appendLine(`Function clauses: ${func.clauseCount}`);
+  }
+  if (func.returnCount !== undefined) {
+    detailsChannel.appendLine(`Return points: ${func.returnCount}`);
+  }
+  if (func.errorReturnCount !== undefined) {
+    detailsChannel.appendLine(`Error returns: ${func.errorReturnCount}`);
+  }
+  if (func.operatorKinds?.length) {
+    detailsChannel.appendLine(
+      `Operator kinds: ${func.operatorKinds.length} (${func.operatorKinds.join(", ")})`
+    );
+  }
+  if (func.branchDensity !== undefined) {
+    detailsChannel.appendLine(
+      `Branch density: ${func.branchDensity} (${func.branchCount} branches / ${func.statementCount} statements)`
+    );
+  }
+  if (func.maxClosureDepth) {
+    detailsChannel.appendLine(`Max closure depth: ${func.maxClosureDepth}`);
+  }
+  if (func.switches && func.switches.length > 0) {
+    const longest = func.switches.reduce((a, b) => (b.caseCount > a.caseCount ? b : a));
+    detailsChannel.appendLine(
+      `Longest ${longest.kind}: ${longest.caseCount} cases (line ${longest.line + 1})`
+    );
+  }
+  if (func.steadyStateComplexity !== undefined) {
+    detailsChannel.appendLine(
+      `Steady-state complexity: ${func.steadyStateComplexity} (without ${func.featureFlagChecks} feature flag checks)`
+    );
+  }
+  if (func.recursion === "direct") {
+    detailsChannel.appendLine("Recursion: calls itself");
+  } else if (func.recursion === "mutual") {
+    detailsChannel.appendLine(`Recursion: mutual (${func.recursionCycle?.join(", ")})`);
+  }
+  for (const warning of ConfigurationManager.getMetricWarnings(func, config)) {
+    detailsChannel.appendLine(`⚠️ ${warning}`);
+  }
+  if (func.nodeCounts) {
+    const entries = Object.entries(func.nodeCounts).sort(
+      (a, b) => b[1] - a[1] || a[0].localeCompare(b[0])
+    );
+    const total = entries.reduce((n, [, count]) => n + count, 0);
+    detailsChannel.appendLine(`\nAST nodes: ${total}`);
+    for (const [type, count] of entries) {
+      detailsChannel.appendLine(`  ${String(count).padStart(6)}  ${type}`);
+    }
+  }
 
   if (func.details.length === 0) {
     detailsChannel.appendLine("\nNo complexity contributors were reported.");
@@ -55,10 +237,1109 @@ function showFunctionDetails(
   detailsChannel.show(true /* preserveFocus */);
 }
 
+/**
+ * Replaces the report channel contents with a rendered workspace report, and redraws
+ * the complexity distribution when it is open.
+ */
+function writeWorkspaceReport(metrics: WorkspaceMetrics): void {
+  if (!reportChannel) {
+    reportChannel = vscode.window.createOutputChannel("Code Metrics Report");
+  }
+  reportChannel.clear();
+  for (const line of formatWorkspaceReport(metrics)) {
+    reportChannel.appendLine(line);
+  }
+  if (distributionPanel) {
+    distributionPanel.webview.html = renderDistributionHtml(computeDistribution(metrics));
+  }
+}
+
+/**
+ * Analyzes every workspace folder with its own configuration and writes a report,
+ * grouped by root, to the shared report channel. The report then stays current:
+ * changed files are re-analyzed incrementally instead of rescanning the workspace.
+ */
+async function analyzeWorkspace(): Promise<void> {
+  const metrics = await vscode.window.withProgress(
+    {
+      location: vscode.ProgressLocation.Notification,
+      title: "Code Metrics: Analyzing workspace",
+      cancellable: true,
+    },
+    (_progress, token) => WorkspaceAnalyzer.analyzeWorkspace(token)
+  );
+
+  writeWorkspaceReport(metrics);
+  reportChannel?.show(true /* preserveFocus */);
+  showCompletionSummary(metrics);
+
+  workspaceWatcher?.dispose();
+  workspaceWatcher = new WorkspaceMetricsWatcher(metrics);
+  workspaceWatcher.onDidUpdate(writeWorkspaceReport);
+}
+
+/**
+ * Shows the outcome of a completed analysis as a notification, unless turned off with
+ * `codeMetrics.showCompletionSummary`. "Open Report" reveals the report output channel.
+ */
+function showCompletionSummary(metrics: WorkspaceMetrics): void {
+  if (!ConfigurationManager.getConfiguration().showCompletionSummary) {
+    return;
+  }
+  const openReport = "Open Report";
+  vscode.window
+    .showInformationMessage(`Code Metrics: ${summarizeWorkspace(metrics)}`, openReport)
+    .then((choice) => {
+      if (choice === openReport) {
+        reportChannel?.show();
+      }
+    });
+}
+
+/**
+ * Analyzes one folder of the workspace, e.g. a module of a monorepo, and writes a scoped
+ * report. Invoked from the Explorer context menu with the folder, or from the command
+ * palette, where the folder is picked. The scoped report then stays current like a full one.
+ */
+async function analyzeFolder(folderUri?: vscode.Uri): Promise<void> {
+  if (!folderUri) {
+    const picked = await vscode.window.showOpenDialog({
+      canSelectFiles: false,
+      canSelectFolders: true,
+      canSelectMany: false,
+      defaultUri: vscode.workspace.workspaceFolders?.[0]?.uri,
+      openLabel: "Analyze",
+    });
+    folderUri = picked?.[0];
+    if (!folderUri) {
+      return;
+    }
+  }
+
+  const scope = folderUri;
+  const metrics = await vscode.window.withProgress(
+    {
+      location: vscode.ProgressLocation.Notification,
+      title: `Code Metrics: Analyzing ${vscode.workspace.asRelativePath(scope)}`,
+      cancellable: true,
+    },
+    (_progress, token) => WorkspaceAnalyzer.analyzeSubtree(scope, token)
+  );
+  if (!metrics) {
+    vscode.window.showWarningMessage("Only folders inside the workspace can be analyzed.");
+    return;
+  }
+
+  writeWorkspaceReport(metrics);
+  reportChannel?.show(true /* preserveFocus */);
+  showCompletionSummary(metrics);
+
+  workspaceWatcher?.dispose();
+  workspaceWatcher = new WorkspaceMetricsWatcher(metrics);
+  workspaceWatcher.onDidUpdate(writeWorkspaceReport);
+}
+
+/**
+ * Analyzes a workspace folder as it was at a git ref (commit, tag, branch, or stash)
+ * without checking it out, and writes the report to its own output channel so the live
+ * workspace report is left alone.
+ */
+async function analyzeAtRef(): Promise<void> {
+  const folder = (vscode.workspace.workspaceFolders?.length ?? 0) > 1
+    ? await vscode.window.showWorkspaceFolderPick()
+    : vscode.workspace.workspaceFolders?.[0];
+  if (!folder) {
+    return;
+  }
+  const ref = (
+    await vscode.window.showInputBox({
+      prompt: "Git ref to analyze the workspace at",
+      placeHolder: "e.g. v1.2.0, HEAD~10, a commit hash, or stash@{0}",
+    })
+  )?.trim();
+  if (!ref) {
+    return;
+  }
+
+  let metrics: WorkspaceMetrics;
+  try {
+    metrics = await vscode.window.withProgress(
+      {
+        location: vscode.ProgressLocation.Notification,
+        title: `Code Metrics: Analyzing ${folder.name} at ${ref}`,
+        cancellable: true,
+      },
+      async (_progress, token) => ({ roots: [await analyzeFolderAtRef(folder, ref, token)] })
+    );
+  } catch (error) {
+    vscode.window.showErrorMessage(`Code Metrics: ${(error as Error).message}`);
+    return;
+  }
+
+  if (!snapshotChannel) {
+    snapshotChannel = vscode.window.createOutputChannel("Code Metrics Snapshot");
+  }
+  snapshotChannel.clear();
+  for (const line of formatWorkspaceReport(metrics)) {
+    snapshotChannel.appendLine(line);
+  }
+  snapshotChannel.show(true /* preserveFocus */);
+}
+
+/**
+ * Jumps to the most complex function in the workspace, a starting point for refactoring.
+ * Uses the live results of the last workspace analysis, offering to run one if none exists.
+ */
+async function goToWorstFunction(): Promise<void> {
+  const metrics = await getWorkspaceResults();
+  const worst = metrics && findWorstFunction(metrics);
+  if (!worst) {
+    vscode.window.showInformationMessage("No functions were found in the workspace.");
+    return;
+  }
+
+  await revealFunction(worst.file.uri, worst.func.startLine);
+  vscode.window.showInformationMessage(
+    `${worst.func.name} in ${worst.file.relativePath} has the highest cognitive complexity: ${worst.func.complexity}`
+  );
+}
+
+/**
+ * Returns the live results of the last analysis, offering to analyze the workspace when
+ * there are none yet.
+ */
+async function getWorkspaceResults(): Promise<WorkspaceMetrics | undefined> {
+  if (!workspaceWatcher) {
+    const run = await vscode.window.showInformationMessage(
+      "The workspace has not been analyzed yet.",
+      "Analyze Workspace"
+    );
+    if (run !== "Analyze Workspace") {
+      return undefined;
+    }
+    await analyzeWorkspace();
+  }
+  return workspaceWatcher?.current;
+}
+
+/**
+ * Opens a webview with the histogram and percentiles of function complexity from the
+ * last analysis. The view follows the results as files change.
+ */
+async function showComplexityDistribution(): Promise<void> {
+  const metrics = await getWorkspaceResults();
+  if (!metrics) {
+    return;
+  }
+  if (!distributionPanel) {
+    distributionPanel = vscode.window.createWebviewPanel(
+      "codeMetrics.complexityDistribution",
+      "Complexity Distribution",
+      vscode.ViewColumn.Active,
+      { enableScripts: false }
+    );
+    distributionPanel.onDidDispose(() => {
+      distributionPanel = undefined;
+    });
+  }
+  distributionPanel.webview.html = renderDistributionHtml(computeDistribution(metrics));
+  distributionPanel.reveal();
+}
+
+/**
+ * Opens a webview that explains each counted decision of a function, with links to its
+ * lines. Opened from the CodeLens with `display.codeLensAction` set to `explain`, or from
+ * the command palette for the function at the cursor.
+ */
+async function explainFunctionComplexity(
+  func?: UnifiedFunctionMetrics,
+  uri?: vscode.Uri
+): Promise<void> {
+  const editor = vscode.window.activeTextEditor;
+  const document = uri ? await vscode.workspace.openTextDocument(uri) : editor?.document;
+  if (!document || !MetricsAnalyzerFactory.isSupportedLanguage(document.languageId)) {
+    vscode.window.showInformationMessage("Open a supported file to explain a function.");
+    return;
+  }
+  if (!func) {
+    const line = editor?.selection.active.line ?? 0;
+    const config = ConfigurationManager.getConfiguration(document.uri, document.languageId);
+    func = MetricsAnalyzerFactory.analyzeFile(
+      document.getText(),
+      document.languageId,
+      ConfigurationManager.getAnalyzerOptions(config)
+    )
+      .filter((f) => f.startLine <= line && line <= f.endLine)
+      // the innermost function holding the cursor
+      .sort((a, b) => b.startLine - a.startLine)[0];
+    if (!func) {
+      vscode.window.showInformationMessage("Place the cursor in a function to explain it.");
+      return;
+    }
+  }
+
+  if (!explanationPanel) {
+    explanationPanel = vscode.window.createWebviewPanel(
+      "codeMetrics.complexityExplanation",
+      "Why Is This Complex?",
+      vscode.ViewColumn.Beside,
+      { enableScripts: false, enableCommandUris: ["codeMetrics.revealFunction"] }
+    );
+    explanationPanel.onDidDispose(() => {
+      explanationPanel = undefined;
+    });
+  }
+  const target = document.uri;
+  const revealLink = (line: number) =>
+    `command:codeMetrics.revealFunction?${encodeURIComponent(JSON.stringify([target, line - 1]))}`;
+  explanationPanel.title = `Why Is ${func.name} Complex?`;
+  explanationPanel.webview.html = renderExplanationHtml(
+    func,
+    explainFunction(func, document.getText().split(/\r?\n/)),
+    revealLink
+  );
+  explanationPanel.reveal(vscode.ViewColumn.Beside, true /* preserveFocus */);
+}
+
+/**
+ * Analyzes only the function at the cursor and sums up its complexity in a notification,
+ * while the lines of its decision points are highlighted for a few seconds with their
+ * increments; hovering one explains it. For Go, the rest of the file is not parsed.
+ */
+async function explainCurrentFunction(): Promise<void> {
+  const editor = vscode.window.activeTextEditor;
+  if (!editor || !MetricsAnalyzerFactory.isSupportedLanguage(editor.document.languageId)) {
+    vscode.window.showInformationMessage("Open a supported file to explain a function.");
+    return;
+  }
+  const document = editor.document;
+  const line = editor.selection.active.line;
+  const config = ConfigurationManager.getConfiguration(document.uri, document.languageId);
+  const func = MetricsAnalyzerFactory.analyzeFile(document.getText(), document.languageId, {
+    ...ConfigurationManager.getAnalyzerOptions(config),
+    lineRanges: [{ start: line, end: line }],
+  })
+    .filter((f) => f.startLine <= line && line <= f.endLine)
+    // the innermost function holding the cursor
+    .sort((a, b) => b.startLine - a.startLine)[0];
+  if (!func) {
+    vscode.window.showInformationMessage("Place the cursor in a function to explain it.");
+    return;
+  }
+  const decisions = explainFunction(func, document.getText().split(/\r?\n/));
+
+  decisionHighlight?.dispose();
+  const highlight = vscode.window.createTextEditorDecorationType({
+    isWholeLine: true,
+    backgroundColor: new vscode.ThemeColor("editor.findMatchHighlightBackground"),
+    after: { margin: "0 0 0 2em", color: new vscode.ThemeColor("editorCodeLens.foreground") },
+  });
+  decisionHighlight = highlight;
+  const byLine = new Map<number, typeof decisions>();
+  for (const decision of decisions) {
+    byLine.set(decision.line, [...(byLine.get(decision.line) ?? []), decision]);
+  }
+  editor.setDecorations(
+    highlight,
+    [...byLine].map(([decisionLine, onLine]) => ({
+      range: document.lineAt(decisionLine - 1).range,
+      hoverMessage: new vscode.MarkdownString(
+        onLine.map((d) => `**${d.summary}**\n\n${d.explanation}`).join("\n\n---\n\n")
+      ),
+      renderOptions: {
+        after: { contentText: onLine.map((d) => `+${d.increment} ${d.construct}`).join(", ") },
+      },
+    }))
+  );
+  setTimeout(() => {
+    if (decisionHighlight === highlight) {
+      decisionHighlight = undefined;
+    }
+    highlight.dispose();
+  }, DECISION_HIGHLIGHT_MS);
+
+  const warnings = ConfigurationManager.getMetricWarnings(func, config);
+  const summary = formatExplanationSummary(func, decisions);
+  const choice = await vscode.window.showInformationMessage(
+    warnings.length > 0 ? `${summary} ${warnings.join("; ")}.` : summary,
+    "Open Explanation",
+    "Show Details"
+  );
+  if (choice === "Open Explanation") {
+    await explainFunctionComplexity(func, document.uri);
+  } else if (choice === "Show Details") {
+    showFunctionDetails(func, document.uri);
+  }
+}
+
+/**
+ * Lists the files a workspace scan would analyze, and why every other file is skipped,
+ * without analyzing anything. Helps tune `excludePatterns` and `includeTests`.
+ */
+async function listAnalyzableFiles(): Promise<void> {
+  const listings = await WorkspaceAnalyzer.listFiles();
+
+  if (!filesChannel) {
+    filesChannel = vscode.window.createOutputChannel("Code Metrics Files");
+  }
+  filesChannel.clear();
+  for (const line of formatFileListing(listings)) {
+    filesChannel.appendLine(line);
+  }
+  filesChannel.show(true /* preserveFocus */);
+}
+
+/** Asks for a workspace folder when there are several; returns the only one otherwise. */
+async function pickWorkspaceFolder(): Promise<vscode.WorkspaceFolder | undefined> {
+  return (vscode.workspace.workspaceFolders?.length ?? 0) > 1
+    ? await vscode.window.showWorkspaceFolderPick()
+    : vscode.workspace.workspaceFolders?.[0];
+}
+
+/**
+ * Asks for the workspace folder and base branch to compare, and makes sure the base
+ * branch is available, offering to fetch it when it has not been fetched.
+ *
+ * @param pickedFolder - The folder to compare, when already chosen
+ * @returns The folder and base ref with the comparison options, or undefined when cancelled
+ */
+async function pickBaseline(pickedFolder?: vscode.WorkspaceFolder): Promise<
+  { folder: vscode.WorkspaceFolder; baseRef: string; options: BaselineOptions } | undefined
+> {
+  const folder = pickedFolder ?? (await pickWorkspaceFolder());
+  if (!folder) {
+    return undefined;
+  }
+  const config = ConfigurationManager.getConfiguration(folder.uri);
+  const baseRef = await vscode.window.showInputBox({
+    prompt: "Base branch to compare complexity against",
+    value: config.baselineRef,
+  });
+  if (!baseRef) {
+    return undefined;
+  }
+
+  const cwd = folder.uri.fsPath;
+  try {
+    await resolveBaseCommit(cwd, baseRef);
+  } catch (error) {
+    const fetch = parseRemoteRef(baseRef) ? `Fetch ${baseRef}` : undefined;
+    const choice = await vscode.window.showErrorMessage(
+      (error as Error).message,
+      ...(fetch ? [fetch] : [])
+    );
+    if (!fetch || choice !== fetch) {
+      return undefined;
+    }
+    try {
+      await fetchBaseRef(cwd, baseRef);
+    } catch (fetchError) {
+      vscode.window.showErrorMessage(`Could not fetch ${baseRef}: ${(fetchError as Error).message}`);
+      return undefined;
+    }
+  }
+
+  return {
+    folder,
+    baseRef,
+    options: {
+      identity: config.identityStrategy,
+      analyzerOptions: ConfigurationManager.getAnalyzerOptions(config),
+      include: (relativePath) =>
+        WorkspaceAnalyzer.getSkipReason(vscode.Uri.joinPath(folder.uri, relativePath), config) ===
+        undefined,
+    },
+  };
+}
+
+/**
+ * Compares the changed files of a workspace folder against a base branch and opens the
+ * functions that got more complex as a Markdown document, ready to paste into a pull
+ * request. When the base branch has not been fetched, offers to fetch it.
+ */
+async function compareWithBaselineBranch(): Promise<void> {
+  const baseline = await pickBaseline();
+  if (!baseline) {
+    return;
+  }
+  const { folder, baseRef, options } = baseline;
+
+  try {
+    const comparison = await vscode.window.withProgress(
+      { location: vscode.ProgressLocation.Notification, title: `Code Metrics: Comparing with ${baseRef}` },
+      () => compareWithBaseline(folder.uri.fsPath, baseRef, options)
+    );
+    const document = await vscode.workspace.openTextDocument({
+      language: "markdown",
+      content: formatBaselineComment(comparison),
+    });
+    await vscode.window.showTextDocument(document);
+  } catch (error) {
+    vscode.window.showErrorMessage(`Could not compare with ${baseRef}: ${(error as Error).message}`);
+  }
+}
+
+/**
+ * Summarizes the complexity changes of a branch for its pull request description: every
+ * changed function with its complexity before and after, and a one-line verdict, copied
+ * to the clipboard or opened as an untitled Markdown document.
+ */
+async function generatePullRequestReport(): Promise<void> {
+  const baseline = await pickBaseline();
+  if (!baseline) {
+    return;
+  }
+  const { folder, baseRef, options } = baseline;
+
+  const budget = ConfigurationManager.getConfiguration(folder.uri).complexityBudget ?? undefined;
+  let markdown: string;
+  try {
+    const report = await vscode.window.withProgress(
+      { location: vscode.ProgressLocation.Notification, title: `Code Metrics: Comparing with ${baseRef}` },
+      () => collectComplexityChanges(folder.uri.fsPath, baseRef, options)
+    );
+    markdown = formatChangeReport(report, budget);
+  } catch (error) {
+    vscode.window.showErrorMessage(`Could not compare with ${baseRef}: ${(error as Error).message}`);
+    return;
+  }
+
+  const copy = "Copy to Clipboard";
+  const open = "Open as Markdown";
+  const choice = await vscode.window.showQuickPick([copy, open], {
+    placeHolder: "Where should the complexity report go?",
+  });
+  if (choice === copy) {
+    await vscode.env.clipboard.writeText(markdown);
+    vscode.window.showInformationMessage("Complexity report copied to the clipboard.");
+  } else if (choice === open) {
+    const document = await vscode.workspace.openTextDocument({ language: "markdown", content: markdown });
+    await vscode.window.showTextDocument(document);
+  }
+}
+
+/**
+ * Checks that the net complexity change since a base branch, working tree changes
+ * included, stays within `codeMetrics.baseline.budget`, and warns when it does not.
+ * Comparing with `HEAD` checks only the uncommitted changes.
+ */
+async function checkBudget(): Promise<void> {
+  const folder = await pickWorkspaceFolder();
+  if (!folder) {
+    return;
+  }
+  const budget = ConfigurationManager.getConfiguration(folder.uri).complexityBudget;
+  if (budget === null) {
+    const settings = "Open Settings";
+    const choice = await vscode.window.showInformationMessage(
+      "No complexity budget is set. Set codeMetrics.baseline.budget to the largest net change to allow, e.g. 20.",
+      settings
+    );
+    if (choice === settings) {
+      await vscode.commands.executeCommand("workbench.action.openSettings", "codeMetrics.baseline.budget");
+    }
+    return;
+  }
+
+  const baseline = await pickBaseline(folder);
+  if (!baseline) {
+    return;
+  }
+  const { baseRef, options } = baseline;
+  try {
+    const report = await vscode.window.withProgress(
+      { location: vscode.ProgressLocation.Notification, title: `Code Metrics: Comparing with ${baseRef}` },
+      () => collectComplexityChanges(folder.uri.fsPath, baseRef, options)
+    );
+    const check = checkComplexityBudget(report, budget);
+    const message = `${formatBudgetCheck(check)} (compared with ${baseRef})`;
+    if (check.exceeded) {
+      vscode.window.showWarningMessage(message);
+    } else {
+      vscode.window.showInformationMessage(message);
+    }
+  } catch (error) {
+    vscode.window.showErrorMessage(`Could not compare with ${baseRef}: ${(error as Error).message}`);
+  }
+}
+
+/**
+ * Analyzes throwaway code without creating a file: the clipboard contents or source
+ * downloaded from a URL are opened in an untitled document (so CodeLens applies) and
+ * a summary is written to the details channel.
+ */
+async function analyzeSnippet(): Promise<void> {
+  const choice = await vscode.window.showQuickPick(
+    [
+      { label: "Clipboard", description: "Analyze the code currently on the clipboard" },
+      { label: "URL", description: "Download and analyze a raw file, GitHub file, or gist" },
+    ],
+    { placeHolder: "Where is the code to analyze?" }
+  );
+  if (!choice) {
+    return;
+  }
+
+  let content: string;
+  let source: string;
+  let languageId: string | undefined;
+  if (choice.label === "Clipboard") {
+    content = await vscode.env.clipboard.readText();
+    source = "clipboard";
+    if (!content.trim()) {
+      vscode.window.showInformationMessage("The clipboard is empty.");
+      return;
+    }
+  } else {
+    const url = await vscode.window.showInputBox({
+      prompt: "URL of the source file to analyze",
+      placeHolder: "https://gist.github.com/…",
+      ignoreFocusOut: true,
+    });
+    if (!url) {
+      return;
+    }
+    try {
+      content = await vscode.window.withProgress(
+        { location: vscode.ProgressLocation.Notification, title: "Code Metrics: Downloading" },
+        () => fetchSnippet(url)
+      );
+    } catch (error) {
+      vscode.window.showErrorMessage((error as Error).message);
+      return;
+    }
+    source = url.trim();
+    languageId = inferSnippetLanguage(url);
+  }
+
+  if (!languageId) {
+    languageId = await vscode.window.showQuickPick(
+      MetricsAnalyzerFactory.getSupportedLanguages(),
+      { placeHolder: "Language of the snippet" }
+    );
+    if (!languageId) {
+      return;
+    }
+  }
+
+  const document = await vscode.workspace.openTextDocument({ language: languageId, content });
+  await vscode.window.showTextDocument(document, { preview: true });
+
+  const config = ConfigurationManager.getConfiguration();
+  const functions = MetricsAnalyzerFactory.analyzeFile(
+    content,
+    languageId,
+    ConfigurationManager.getAnalyzerOptions(config)
+  );
+  if (!detailsChannel) {
+    detailsChannel = vscode.window.createOutputChannel("Code Metrics Details");
+  }
+  detailsChannel.clear();
+  for (const line of formatSnippetReport(source, languageId, functions, config)) {
+    detailsChannel.appendLine(line);
+  }
+  detailsChannel.show(true /* preserveFocus */);
+}
+
+/**
+ * Analyzes each Python code cell of the active notebook and writes the per-cell and
+ * per-function results to the report channel. Markdown cells are skipped.
+ */
+function analyzeNotebook(): void {
+  const notebook = vscode.window.activeNotebookEditor?.notebook;
+  if (!notebook) {
+    vscode.window.showInformationMessage("Open a notebook to analyze its cells.");
+    return;
+  }
+
+  const config = ConfigurationManager.getConfiguration(notebook.uri);
+  const cells = analyzeNotebookCells(
+    notebook.getCells().map((cell) => ({
+      index: cell.index,
+      kind: cell.kind === vscode.NotebookCellKind.Code ? "code" : "markup",
+      languageId: cell.document.languageId,
+      text: cell.document.getText(),
+    })),
+    ConfigurationManager.getAnalyzerOptions(config)
+  );
+  if (!reportChannel) {
+    reportChannel = vscode.window.createOutputChannel("Code Metrics Report");
+  }
+  reportChannel.clear();
+  for (const line of formatNotebookReport(vscode.workspace.asRelativePath(notebook.uri), cells, config)) {
+    reportChannel.appendLine(line);
+  }
+  reportChannel.show(true /* preserveFocus */);
+}
+
+/**
+ * Re-analyzes the active file, or the whole workspace, without the cache and writes the
+ * time of each analysis phase and each file to the timing channel, for diagnosing slow
+ * analysis.
+ */
+async function profileAnalysis(): Promise<void> {
+  const editor = vscode.window.activeTextEditor;
+  const document =
+    editor && MetricsAnalyzerFactory.isSupportedLanguage(editor.document.languageId)
+      ? editor.document
+      : undefined;
+  const scope = await vscode.window.showQuickPick(
+    [
+      ...(document
+        ? [{ label: "Current file", description: vscode.workspace.asRelativePath(document.uri) }]
+        : []),
+      { label: "Workspace", description: "Every analyzable file in every workspace root" },
+    ],
+    { placeHolder: "What should be analyzed with timing?" }
+  );
+  if (!scope) {
+    return;
+  }
+
+  let profile: AnalysisProfile;
+  if (scope.label === "Workspace") {
+    profile = await vscode.window.withProgress(
+      {
+        location: vscode.ProgressLocation.Notification,
+        title: "Code Metrics: Timing workspace analysis",
+        cancellable: true,
+      },
+      (_progress, token) => profileWorkspace(token)
+    );
+  } else {
+    const relativePath = vscode.workspace.asRelativePath(document!.uri);
+    profile = {
+      target: relativePath,
+      files: [
+        profileSource(
+          relativePath,
+          document!.getText(),
+          document!.languageId,
+          ConfigurationManager.getConfiguration(document!.uri, document!.languageId)
+        ),
+      ],
+    };
+  }
+
+  if (!timingChannel) {
+    timingChannel = vscode.window.createOutputChannel("Code Metrics Timing");
+  }
+  timingChannel.clear();
+  for (const line of formatAnalysisProfile(profile)) {
+    timingChannel.appendLine(line);
+  }
+  timingChannel.show(true /* preserveFocus */);
+}
+
+/**
+ * Lists each function of the active Go file with its complexity next to the value
+ * gocyclo would report, followed by the counting rules in which the two differ.
+ */
+function showGocycloComparison(): void {
+  const document = vscode.window.activeTextEditor?.document;
+  if (document?.languageId !== "go") {
+    vscode.window.showInformationMessage("Open a Go file to compare it with gocyclo.");
+    return;
+  }
+
+  const rows = compareWithGocyclo(
+    document.getText(),
+    ConfigurationManager.getConfiguration(document.uri, document.languageId)
+  );
+  if (!detailsChannel) {
+    detailsChannel = vscode.window.createOutputChannel("Code Metrics Details");
+  }
+  detailsChannel.clear();
+  for (const line of formatGocycloComparison(vscode.workspace.asRelativePath(document.uri), rows)) {
+    detailsChannel.appendLine(line);
+  }
+  detailsChannel.show(true /* preserveFocus */);
+}
+
+/**
+ * Analyzes the active file, or the whole workspace, and saves the results as JSON, CSV, or
+ * OpenMetrics text for a Prometheus Pushgateway.
+ * Exports can be limited to violations so review artifacts stay small.
+ */
+async function exportMetrics(): Promise<void> {
+  const editor = vscode.window.activeTextEditor;
+  const scope = await vscode.window.showQuickPick(
+    [
+      ...(editor
+        ? [{ label: "Current file", description: vscode.workspace.asRelativePath(editor.document.uri) }]
+        : []),
+      { label: "Workspace", description: "Every analyzable file in every workspace root" },
+    ],
+    { placeHolder: "What should be exported?" }
+  );
+  if (!scope) {
+    return;
+  }
+
+  const filter = await vscode.window.showQuickPick(
+    [
+      { label: "All functions", level: undefined },
+      { label: "Only violations", description: "Warning band and above", level: "warning" as ViolationLevel },
+      { label: "Only errors", description: "Error band only", level: "error" as ViolationLevel },
+    ],
+    { placeHolder: "Which functions should be exported?" }
+  );
+  if (!filter) {
+    return;
+  }
+
+  const format = await vscode.window.showQuickPick(["json", "csv", "openmetrics"] as ExportFormat[], {
+    placeHolder: "Export format",
+  });
+  if (!format) {
+    return;
+  }
+
+  let metrics: WorkspaceMetrics;
+  if (scope.label === "Workspace") {
+    metrics = await vscode.window.withProgress(
+      {
+        location: vscode.ProgressLocation.Notification,
+        title: "Code Metrics: Analyzing workspace",
+        cancellable: true,
+      },
+      (_progress, token) => WorkspaceAnalyzer.analyzeWorkspace(token)
+    );
+  } else {
+    const document = editor!.document;
+    const folder = vscode.workspace.getWorkspaceFolder(document.uri);
+    const config = ConfigurationManager.getConfiguration(document.uri, document.languageId);
+    const functions = MetricsAnalyzerFactory.analyzeFile(
+      document.getText(),
+      document.languageId,
+      ConfigurationManager.getAnalyzerOptions(config)
+    );
+    metrics = {
+      roots: [
+        {
+          name: folder?.name ?? "",
+          folder,
+          config,
+          files: [
+            {
+              uri: document.uri,
+              relativePath: vscode.workspace.asRelativePath(document.uri, false),
+              languageId: document.languageId,
+              functions,
+            },
+          ],
+        },
+      ],
+    };
+  }
+
+  const extension = format === "openmetrics" ? "prom" : format;
+  const target = await vscode.window.showSaveDialog({
+    defaultUri: vscode.workspace.workspaceFolders?.[0]
+      ? vscode.Uri.joinPath(vscode.workspace.workspaceFolders[0].uri, `code-metrics.${extension}`)
+      : undefined,
+    filters: { [format === "openmetrics" ? "OpenMetrics" : format.toUpperCase()]: [extension] },
+  });
+  if (!target) {
+    return;
+  }
+
+  const rows = collectExportRows(metrics, {
+    format,
+    onlyViolations: filter.level,
+    qualifiedNames: ConfigurationManager.getConfiguration(editor?.document.uri)
+      .exportQualifiedNames,
+  });
+  await vscode.workspace.fs.writeFile(target, new TextEncoder().encode(formatExport(rows, format)));
+  vscode.window.showInformationMessage(
+    `Exported ${rows.length} functions to ${vscode.workspace.asRelativePath(target)}.`
+  );
+}
+
+/**
+ * Opens the JSON Schema of JSON exports in an untitled editor, to read or save it for
+ * tools that consume exports.
+ */
+async function showExportSchema(): Promise<void> {
+  const document = await vscode.workspace.openTextDocument({
+    language: "json",
+    content: formatExportSchema(),
+  });
+  await vscode.window.showTextDocument(document);
+}
+
+/**
+ * Returns the active editor's document when annotations can be written to it, and warns
+ * otherwise.
+ */
+function getAnnotatableDocument(): vscode.TextDocument | undefined {
+  const document = vscode.window.activeTextEditor?.document;
+  if (!document) {
+    return undefined;
+  }
+  if (
+    !MetricsAnalyzerFactory.isSupportedLanguage(document.languageId) ||
+    !getCommentPrefix(document.languageId)
+  ) {
+    vscode.window.showWarningMessage(
+      `Complexity comments are not supported for ${document.languageId} files.`
+    );
+    return undefined;
+  }
+  return document;
+}
+
+/** Applies annotation edits to a document as a single undoable change. */
+async function applyAnnotationEdits(
+  document: vscode.TextDocument,
+  edits: AnnotationEdit[]
+): Promise<void> {
+  const eol = document.eol === vscode.EndOfLine.CRLF ? "\r\n" : "\n";
+  const workspaceEdit = new vscode.WorkspaceEdit();
+  for (const edit of edits) {
+    const line = document.lineAt(edit.line);
+    if (edit.kind === "insert") {
+      workspaceEdit.insert(document.uri, line.range.start, `${edit.text}${eol}`);
+    } else if (edit.kind === "replace") {
+      workspaceEdit.replace(document.uri, line.range, edit.text);
+    } else {
+      workspaceEdit.delete(document.uri, line.rangeIncludingLineBreak);
+    }
+  }
+  await vscode.workspace.applyEdit(workspaceEdit);
+}
+
+/**
+ * Inserts or updates `metrics: cc=N` comments above the active file's functions whose
+ * complexity reaches `codeMetrics.annotations.threshold`, and removes stale ones.
+ */
+async function annotateComplexity(): Promise<void> {
+  const document = getAnnotatableDocument();
+  if (!document) {
+    return;
+  }
+
+  const config = ConfigurationManager.getConfiguration(document.uri, document.languageId);
+  const functions = MetricsAnalyzerFactory.analyzeFile(
+    document.getText(),
+    document.languageId,
+    ConfigurationManager.getAnalyzerOptions(config)
+  );
+  const edits = getAnnotationEdits(
+    document.getText().split(/\r?\n/),
+    functions,
+    document.languageId,
+    config.annotationThreshold
+  );
+  if (edits.length === 0) {
+    vscode.window.showInformationMessage("Complexity comments are up to date.");
+    return;
+  }
+
+  await applyAnnotationEdits(document, edits);
+  const count = (kind: AnnotationEdit["kind"]) => edits.filter((e) => e.kind === kind).length;
+  vscode.window.showInformationMessage(
+    `Complexity comments: ${count("insert")} added, ${count("replace")} updated, ` +
+      `${count("delete")} removed.`
+  );
+}
+
+/** Removes every `metrics: cc=N` comment from the active file. */
+async function stripComplexityAnnotations(): Promise<void> {
+  const document = getAnnotatableDocument();
+  if (!document) {
+    return;
+  }
+
+  const edits = getStripEdits(document.getText().split(/\r?\n/), document.languageId);
+  await applyAnnotationEdits(document, edits);
+  vscode.window.showInformationMessage(`Removed ${edits.length} complexity comments.`);
+}
+
+/**
+ * Opens a document and moves the cursor to a function's first line.
+ * Used by CodeLens entries that point at another function, such as the file summary.
+ */
+async function revealFunction(uri?: vscode.Uri, line?: number): Promise<void> {
+  if (!uri || line === undefined) {
+    return;
+  }
+  const position = new vscode.Position(line, 0);
+  await vscode.window.showTextDocument(uri, {
+    selection: new vscode.Range(position, position),
+  });
+}
+
+/**
+ * Lists every function of a file, most complex first, and jumps to the one picked.
+ * Opened by the lens of files with more functions than `display.codeLensLimit`.
+ */
+async function listFileFunctions(uri?: vscode.Uri): Promise<void> {
+  const document = uri
+    ? await vscode.workspace.openTextDocument(uri)
+    : vscode.window.activeTextEditor?.document;
+  if (!document || !MetricsAnalyzerFactory.isSupportedLanguage(document.languageId)) {
+    vscode.window.showInformationMessage("Open a supported file to list its functions.");
+    return;
+  }
+
+  const config = ConfigurationManager.getConfiguration(document.uri, document.languageId);
+  const functions = MetricsAnalyzerFactory.analyzeFile(
+    document.getText(),
+    document.languageId,
+    ConfigurationManager.getAnalyzerOptions(config)
+  );
+  const items = functions
+    .map((func) => {
+      const { value, status } = ConfigurationManager.getPrimaryMetricStatus(func, config);
+      return {
+        func,
+        value,
+        label: `${status.icon} ${func.name}`,
+        description: config.primaryMetric === "nesting" ? `nesting ${value}` : `${value}`,
+        detail: `Line ${func.startLine + 1}`,
+      };
+    })
+    .sort((a, b) => b.value - a.value || b.func.complexity - a.func.complexity);
+  const picked = await vscode.window.showQuickPick(items, {
+    placeHolder: `${functions.length} functions in ${vscode.workspace.asRelativePath(document.uri)}`,
+  });
+  if (picked) {
+    await revealFunction(document.uri, picked.func.startLine);
+  }
+}
+
+/**
+ * Moves the cursor to the next function of the active file over the warning threshold of
+ * the primary metric, wrapping around at the end. Opened by the status bar badge.
+ */
+async function goToNextHotspot(): Promise<void> {
+  const editor = vscode.window.activeTextEditor;
+  const document = editor?.document;
+  if (!editor || !document || !MetricsAnalyzerFactory.isSupportedLanguage(document.languageId)) {
+    vscode.window.showInformationMessage("Open a supported file to go to its complex functions.");
+    return;
+  }
+
+  const config = ConfigurationManager.getConfiguration(document.uri, document.languageId);
+  const functions = MetricsAnalyzerFactory.analyzeFile(
+    document.getText(),
+    document.languageId,
+    ConfigurationManager.getAnalyzerOptions(config)
+  );
+  const next = findNextHotspot(functions, config, editor.selection.active.line);
+  if (!next) {
+    vscode.window.showInformationMessage(
+      `No function in ${vscode.workspace.asRelativePath(document.uri)} is over the threshold.`
+    );
+    return;
+  }
+  await revealFunction(document.uri, next.startLine);
+}
+
+/**
+ * Puts functions picked from anywhere in the workspace side by side with their combined
+ * complexity, optionally against a reference value such as the complexity of the function
+ * they were split from. Uses the live results of the last workspace analysis.
+ */
+async function compareFunctionsSideBySide(): Promise<void> {
+  const metrics = await getWorkspaceResults();
+  if (!metrics) {
+    return;
+  }
+
+  const activeUri = vscode.window.activeTextEditor?.document.uri.toString();
+  const isActive = (file: { uri: vscode.Uri }) => Number(file.uri.toString() === activeUri);
+  const items = metrics.roots
+    .flatMap((root) => root.files)
+    .flatMap((file) =>
+      file.functions.map((func) => ({
+        file,
+        func,
+        label: func.name,
+        description: file.relativePath,
+        detail: `Complexity ${func.complexity}, line ${func.startLine + 1}`,
+      }))
+    )
+    // Functions of the active file first, where a refactoring usually just happened.
+    .sort((a, b) => isActive(b.file) - isActive(a.file));
+  const picked = await vscode.window.showQuickPick(items, {
+    canPickMany: true,
+    matchOnDescription: true,
+    placeHolder: "Pick the functions to compare",
+  });
+  if (!picked || picked.length === 0) {
+    return;
+  }
+
+  const reference = await vscode.window.showInputBox({
+    prompt:
+      "Reference complexity to compare the combined value against, e.g. of the function before the split. Leave empty to compare side by side.",
+    validateInput: (value) =>
+      value.trim() === "" || /^\d+$/.test(value.trim()) ? undefined : "Enter a whole number, or nothing.",
+  });
+  if (reference === undefined) {
+    return;
+  }
+
+  const comparison = compareFunctions(
+    picked.map(({ file, func }) => ({ relativePath: file.relativePath, func })),
+    reference.trim() === "" ? undefined : Number(reference.trim())
+  );
+  if (!detailsChannel) {
+    detailsChannel = vscode.window.createOutputChannel("Code Metrics Details");
+  }
+  detailsChannel.clear();
+  for (const line of formatFunctionComparison(comparison)) {
+    detailsChannel.appendLine(line);
+  }
+  detailsChannel.show(true /* preserveFocus */);
+}
+
+/**
+ * Lets the user pick one of the `codeMetrics.profiles` (or none) and persists the choice
+ * in workspace state so it survives reloads.
+ */
+async function selectProfile(context: vscode.ExtensionContext): Promise<void> {
+  const resource = vscode.window.activeTextEditor?.document.uri;
+  const names = Object.keys(ConfigurationManager.getProfiles(resource));
+  if (names.length === 0) {
+    vscode.window.showInformationMessage(
+      "No threshold profiles are defined. Add them under the codeMetrics.profiles setting."
+    );
+    return;
+  }
+
+  const active = ConfigurationManager.getActiveProfile();
+  const noneLabel = "No profile";
+  const picked = await vscode.window.showQuickPick(
+    [
+      { label: noneLabel, description: "Use the configured thresholds" },
+      ...names.map((name) => ({
+        label: name,
+        description: name === active ? "active" : undefined,
+      })),
+    ],
+    { placeHolder: "Select a threshold profile" }
+  );
+  if (!picked) {
+    return;
+  }
+
+  const profile = picked.label === noneLabel ? undefined : picked.label;
+  await context.workspaceState.update(ACTIVE_PROFILE_STATE_KEY, profile);
+  ConfigurationManager.setActiveProfile(profile);
+}
+
 // This method is called when your extension is activated
 // Your extension is activated the very first time the command is executed
-export function activate(context: vscode.ExtensionContext) {
+export function activate(context: vscode.ExtensionContext): CodeMetricsApi {
   console.log("Code Metrics extension is now active!");
+
+  // Restore the threshold profile chosen in a previous session before providers read config.
+  ConfigurationManager.setActiveProfile(
+    context.workspaceState.get<string>(ACTIVE_PROFILE_STATE_KEY)
+  );
   
   // Register command for CodeLens clicks — shows a formatted breakdown in the output channel
   const showFunctionDetailsCommand = vscode.commands.registerCommand(
@@ -66,10 +1347,173 @@ export function activate(context: vscode.ExtensionContext) {
     showFunctionDetails
   );
 
+  const analyzeWorkspaceCommand = vscode.commands.registerCommand(
+    "codeMetrics.analyzeWorkspace",
+    analyzeWorkspace
+  );
+
+  const analyzeFolderCommand = vscode.commands.registerCommand(
+    "codeMetrics.analyzeFolder",
+    analyzeFolder
+  );
+
+  const compareWithBaselineCommand = vscode.commands.registerCommand(
+    "codeMetrics.compareWithBaseline",
+    compareWithBaselineBranch
+  );
+
+  const generatePullRequestReportCommand = vscode.commands.registerCommand(
+    "codeMetrics.generatePullRequestReport",
+    generatePullRequestReport
+  );
+
+  const checkComplexityBudgetCommand = vscode.commands.registerCommand(
+    "codeMetrics.checkComplexityBudget",
+    checkBudget
+  );
+
+  const showComplexityDistributionCommand = vscode.commands.registerCommand(
+    "codeMetrics.showComplexityDistribution",
+    showComplexityDistribution
+  );
+
+  const goToWorstFunctionCommand = vscode.commands.registerCommand(
+    "codeMetrics.goToWorstFunction",
+    goToWorstFunction
+  );
+
+  const listAnalyzableFilesCommand = vscode.commands.registerCommand(
+    "codeMetrics.listAnalyzableFiles",
+    listAnalyzableFiles
+  );
+
+  const analyzeSnippetCommand = vscode.commands.registerCommand(
+    "codeMetrics.analyzeSnippet",
+    analyzeSnippet
+  );
+
+  const selectProfileCommand = vscode.commands.registerCommand(
+    "codeMetrics.selectProfile",
+    () => selectProfile(context)
+  );
+
+  const exportMetricsCommand = vscode.commands.registerCommand(
+    "codeMetrics.exportMetrics",
+    exportMetrics
+  );
+
+  const revealFunctionCommand = vscode.commands.registerCommand(
+    "codeMetrics.revealFunction",
+    revealFunction
+  );
+
+  const annotateComplexityCommand = vscode.commands.registerCommand(
+    "codeMetrics.annotateComplexity",
+    annotateComplexity
+  );
+
+  const stripComplexityAnnotationsCommand = vscode.commands.registerCommand(
+    "codeMetrics.stripComplexityAnnotations",
+    stripComplexityAnnotations
+  );
+
+  const analyzeNotebookCommand = vscode.commands.registerCommand(
+    "codeMetrics.analyzeNotebook",
+    analyzeNotebook
+  );
+
+  const profileAnalysisCommand = vscode.commands.registerCommand(
+    "codeMetrics.profileAnalysis",
+    profileAnalysis
+  );
+
+  const analyzeAtRefCommand = vscode.commands.registerCommand(
+    "codeMetrics.analyzeAtRef",
+    analyzeAtRef
+  );
+
+  const compareWithGocycloCommand = vscode.commands.registerCommand(
+    "codeMetrics.compareWithGocyclo",
+    showGocycloComparison
+  );
+
+  const listFileFunctionsCommand = vscode.commands.registerCommand(
+    "codeMetrics.listFileFunctions",
+    listFileFunctions
+  );
+
+  const goToNextHotspotCommand = vscode.commands.registerCommand(
+    "codeMetrics.goToNextHotspot",
+    goToNextHotspot
+  );
+
+  const showExportSchemaCommand = vscode.commands.registerCommand(
+    "codeMetrics.showExportSchema",
+    showExportSchema
+  );
+
+  const compareFunctionsCommand = vscode.commands.registerCommand(
+    "codeMetrics.compareFunctions",
+    compareFunctionsSideBySide
+  );
+
+  const explainFunctionCommand = vscode.commands.registerCommand(
+    "codeMetrics.explainFunction",
+    explainFunctionComplexity
+  );
+
+  const explainCurrentFunctionCommand = vscode.commands.registerCommand(
+    "codeMetrics.explainCurrentFunction",
+    explainCurrentFunction
+  );
+
   // Register providers
   const codeLensDisposable = registerCodeLensProvider();
+  const diagnosticsDisposable = registerDiagnosticsProvider();
+  const testComplexityDisposable = registerTestComplexityProvider();
+  const historyHoverDisposable = registerHistoryHoverProvider();
+  const overviewRulerDisposable = registerOverviewRuler();
+  const complexitySymbolDisposable = registerComplexitySymbolProvider();
+  const saveGuardrailDisposable = registerSaveGuardrail();
+  const statusBarDisposable = registerStatusBar();
+
+  context.subscriptions.push(
+    showFunctionDetailsCommand,
+    analyzeWorkspaceCommand,
+    analyzeFolderCommand,
+    goToWorstFunctionCommand,
+    showComplexityDistributionCommand,
+    compareWithBaselineCommand,
+    generatePullRequestReportCommand,
+    checkComplexityBudgetCommand,
+    listAnalyzableFilesCommand,
+    analyzeSnippetCommand,
+    selectProfileCommand,
+    exportMetricsCommand,
+    revealFunctionCommand,
+    annotateComplexityCommand,
+    stripComplexityAnnotationsCommand,
+    analyzeNotebookCommand,
+    profileAnalysisCommand,
+    compareWithGocycloCommand,
+    analyzeAtRefCommand,
+    listFileFunctionsCommand,
+    goToNextHotspotCommand,
+    compareFunctionsCommand,
+    showExportSchemaCommand,
+    explainFunctionCommand,
+    explainCurrentFunctionCommand,
+    codeLensDisposable,
+    diagnosticsDisposable,
+    testComplexityDisposable,
+    historyHoverDisposable,
+    overviewRulerDisposable,
+    complexitySymbolDisposable,
+    saveGuardrailDisposable,
+    statusBarDisposable
+  );
 
-  context.subscriptions.push(showFunctionDetailsCommand, codeLensDisposable);
+  return createApi(analyzerRegistrations);
 }
 
 // This method is called when your extension is deactivated
@@ -77,4 +1521,18 @@ export function deactivate() {
   console.log("Code Metrics extension is now deactivated");
   detailsChannel?.dispose();
   detailsChannel = undefined;
+  reportChannel?.dispose();
+  reportChannel = undefined;
+  filesChannel?.dispose();
+  filesChannel = undefined;
+  timingChannel?.dispose();
+  timingChannel = undefined;
+  snapshotChannel?.dispose();
+  snapshotChannel = undefined;
+  workspaceWatcher?.dispose();
+  workspaceWatcher = undefined;
+  distributionPanel?.dispose();
+  decisionHighlight?.dispose();
+  decisionHighlight = undefined;
+  [...analyzerRegistrations].forEach((registration) => registration.dispose());
 }
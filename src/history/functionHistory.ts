@@ -0,0 +1,185 @@
+/**
+ * @fileoverview Function Complexity History
+ *
+ * Looks up how a function's complexity evolved: the recent commits that touched the file
+ * are read with `git show`, analyzed, and the versions in which the function's code changed
+ * are reported with the complexity it had after each of them.
+ *
+ * Walking history is expensive, so analyses are cached per commit and file (a committed
+ * version never changes); only `git log` runs again on each lookup.
+ *
+ * This module does not depend on the VS Code API.
+ */
+
+import { runGit } from "../baseline/baseline";
+import { getFunctionFingerprint } from "../metricsAnalyzer/fingerprint";
+import {
+  AnalyzerOptions,
+  MetricsAnalyzerFactory,
+} from "../metricsAnalyzer/metricsAnalyzerFactory";
+
+/** The complexity of a function after a commit that changed it. */
+export interface HistoryEntry {
+  /** Abbreviated commit hash */
+  commit: string;
+  /** Author date, `YYYY-MM-DD` */
+  date: string;
+  /** First line of the commit message */
+  subject: string;
+  complexity: number;
+}
+
+/** Options for {@link getFunctionHistory}. */
+export interface HistoryOptions {
+  /** Maximum number of entries to return (default 5) */
+  limit?: number;
+  /** Maximum number of file commits to inspect (default 30) */
+  maxCommits?: number;
+  /** Options passed to the analyzer, which must match those of the current results */
+  analyzerOptions?: AnalyzerOptions;
+}
+
+/** A function in one committed version: its complexity and a hash of its code. */
+interface FunctionVersion {
+  complexity: number;
+  fingerprint: string;
+}
+
+/** Maximum number of analyzed file versions kept in the cache. */
+const VERSION_CACHE_MAX_SIZE = 500;
+
+/** Functions of committed file versions by name, keyed by directory, commit, path, and options. */
+const versionCache = new Map<string, Map<string, FunctionVersion>>();
+
+/** Analyzes a committed version of a file, or reads it from the cache. */
+async function getVersion(
+  cwd: string,
+  commit: string,
+  relativePath: string,
+  languageId: string,
+  options: AnalyzerOptions
+): Promise<Map<string, FunctionVersion>> {
+  const key = `${cwd}\0${commit}\0${relativePath}\0${languageId}\0${JSON.stringify(options)}`;
+  const cached = versionCache.get(key);
+  if (cached) {
+    return cached;
+  }
+
+  const text = await runGit(cwd, ["show", `${commit}:./${relativePath}`]);
+  const lines = text.split(/\r?\n/);
+  const functions = new Map<string, FunctionVersion>();
+  for (const func of MetricsAnalyzerFactory.analyzeFile(text, languageId, options)) {
+    if (!functions.has(func.name)) {
+      functions.set(func.name, {
+        complexity: func.complexity,
+        fingerprint: getFunctionFingerprint(lines, func),
+      });
+    }
+  }
+  if (versionCache.size >= VERSION_CACHE_MAX_SIZE) {
+    versionCache.delete(versionCache.keys().next().value!);
+  }
+  versionCache.set(key, functions);
+  return functions;
+}
+
+/**
+ * Returns the complexity of a function after each of the last commits that changed it,
+ * newest first. Commits are found through the file's log (renames are not followed), and a
+ * commit counts as changing the function when its code differs from the previous version.
+ *
+ * @param cwd - Directory the path is relative to, inside the repository
+ * @param relativePath - Path of the file relative to `cwd`
+ * @param functionName - Qualified function name as reported by the analyzer
+ * @param languageId - Language of the file
+ * @param options - History options
+ * @returns The entries; empty when the file has no history
+ * @throws {Error} If git fails, e.g. outside a repository
+ */
+export async function getFunctionHistory(
+  cwd: string,
+  relativePath: string,
+  functionName: string,
+  languageId: string,
+  options: HistoryOptions = {}
+): Promise<HistoryEntry[]> {
+  const limit = options.limit ?? 5;
+  const maxCommits = options.maxCommits ?? 30;
+  const analyzerOptions = options.analyzerOptions ?? {};
+  const log = await runGit(cwd, [
+    "log",
+    `-n${maxCommits}`,
+    "--format=%h%x09%as%x09%s",
+    "--",
+    relativePath,
+  ]);
+  const commits = log
+    .split("\n")
+    .filter((line) => line !== "")
+    .map((line) => {
+      const [commit, date, ...subject] = line.split("\t");
+      return { commit, date, subject: subject.join("\t") };
+    });
+  // With a full page of commits the oldest one's predecessor is unknown, so it is not judged.
+  const judged = commits.length < maxCommits ? commits.length : commits.length - 1;
+
+  const entries: HistoryEntry[] = [];
+  let newer: FunctionVersion | undefined;
+  for (let i = 0; i <= judged && i < commits.length && entries.length < limit; i++) {
+    const functions = await getVersion(
+      cwd,
+      commits[i].commit,
+      relativePath,
+      languageId,
+      analyzerOptions
+    );
+    const version = functions.get(functionName);
+    // The newer version changed the function if this (older) one differs or lacks it.
+    if (newer && newer.fingerprint !== version?.fingerprint) {
+      entries.push({ ...commits[i - 1], complexity: newer.complexity });
+    }
+    newer = version;
+  }
+  if (newer && judged === commits.length && entries.length < limit) {
+    // The oldest commit of the file introduced the function.
+    entries.push({ ...commits[commits.length - 1], complexity: newer.complexity });
+  }
+  return entries;
+}
+
+/**
+ * Renders a function's history as Markdown for a hover.
+ *
+ * @param functionName - The function's name
+ * @param current - Complexity of the current (possibly unsaved) version
+ * @param history - Entries from {@link getFunctionHistory}, newest first
+ * @returns The Markdown
+ */
+export function formatFunctionHistory(
+  functionName: string,
+  current: number,
+  history: readonly HistoryEntry[]
+): string {
+  const lines = [`**Complexity history of \`${functionName}\`**`, ""];
+  if (history.length === 0) {
+    lines.push(`Complexity ${current}; no committed history yet.`);
+    return lines.join("\n");
+  }
+
+  const change = (value: number, previous?: number) =>
+    previous === undefined || value === previous
+      ? ""
+      : value > previous ? `+${value - previous}` : `${value - previous}`;
+  lines.push("| Version | Date | Complexity | Change |", "| --- | --- | ---: | ---: |");
+  if (current !== history[0].complexity) {
+    lines.push(`| working copy | | ${current} | ${change(current, history[0].complexity)} |`);
+  }
+  history.forEach((entry, i) => {
+    const subject = entry.subject.replace(/\|/g, "\\|");
+    lines.push(
+      `| \`${entry.commit}\` ${subject} | ${entry.date} | ${entry.complexity} | ` +
+        `${change(entry.complexity, history[i + 1]?.complexity)} |`
+    );
+  });
+  return lines.join("\n");
+}
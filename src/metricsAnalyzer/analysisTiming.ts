@@ -0,0 +1,94 @@
+/**
+ * @fileoverview Analysis Phase Timing
+ *
+ * Measures where analysis time goes, for diagnosing slow files. While a measurement is
+ * running, the analyzers and the factory report the time they spend in each phase:
+ * - `parse`: building the syntax tree (tree-sitter)
+ * - `walk`: traversing the tree and scoring functions
+ * - `aggregate`: the factory's post-processing (line counts, generated code, recursion, filters)
+ *
+ * Phases nest (the walk includes a parse), so each phase records its own time without
+ * the phases inside it. Outside a measurement, {@link timePhase} only calls its callback.
+ *
+ * This module does not depend on the VS Code API.
+ */
+
+/** A phase of the analysis of one file. */
+export type AnalysisPhase = "parse" | "walk" | "aggregate";
+
+/** Milliseconds spent in each phase. */
+export type PhaseTimings = Record<AnalysisPhase, number>;
+
+/** The result of a measured call, with its duration and the time of its phases. */
+export interface Measured<T> {
+  result: T;
+  /** Wall-clock milliseconds of the call */
+  totalMs: number;
+  phases: PhaseTimings;
+}
+
+/** The running measurement, if any. */
+interface TimingSession {
+  phases: PhaseTimings;
+  /** Time spent in phases nested in the phase being timed */
+  nestedMs: number;
+}
+
+let session: TimingSession | undefined;
+
+/** Returns zeroed phase timings. */
+export function emptyPhaseTimings(): PhaseTimings {
+  return { parse: 0, walk: 0, aggregate: 0 };
+}
+
+/**
+ * Returns whether a measurement is running. Callers that cache results skip the cache
+ * while it is, so measured times are those of real work.
+ */
+export function isTiming(): boolean {
+  return session !== undefined;
+}
+
+/**
+ * Runs a callback as one phase of the running measurement.
+ *
+ * @param phase - The phase the callback's own time is recorded under
+ * @param fn - The work to time
+ * @returns The callback's result
+ */
+export function timePhase<T>(phase: AnalysisPhase, fn: () => T): T {
+  const current = session;
+  if (!current) {
+    return fn();
+  }
+  const outerNestedMs = current.nestedMs;
+  current.nestedMs = 0;
+  const start = performance.now();
+  try {
+    return fn();
+  } finally {
+    const elapsed = performance.now() - start;
+    current.phases[phase] += elapsed - current.nestedMs;
+    current.nestedMs = outerNestedMs + elapsed;
+  }
+}
+
+/**
+ * Runs a callback while measuring the phases of the analysis it does.
+ * Measurements do not nest: an inner one supersedes the outer one until it ends.
+ *
+ * @param fn - The work to measure, typically one call to the factory's `analyzeFile`
+ * @returns The callback's result with its duration and phase timings
+ */
+export function measureAnalysis<T>(fn: () => T): Measured<T> {
+  const outer = session;
+  const current: TimingSession = { phases: emptyPhaseTimings(), nestedMs: 0 };
+  session = current;
+  const start = performance.now();
+  try {
+    const result = fn();
+    return { result, totalMs: performance.now() - start, phases: current.phases };
+  } finally {
+    session = outer;
+  }
+}
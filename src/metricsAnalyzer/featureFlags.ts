@@ -0,0 +1,99 @@
+/**
+ * @fileoverview Feature Flag Checks
+ *
+ * Code behind feature flags (`if flags.NewCheckout { ... }`) carries both the old and the
+ * new path until the flag is removed, which inflates complexity for a while. This module
+ * finds the conditions that check a flag, matched by configurable regular expressions,
+ * and estimates the steady-state complexity: the score once the flags are gone and only
+ * one path is left.
+ *
+ * The estimate works on the reported details alone, so it is a heuristic for every
+ * language: a flag check's increment is dropped, an `else` of the check is dropped, and
+ * the increments nested in its branches lose the nesting level the check added.
+ */
+
+import { UnifiedFunctionMetrics, UnifiedMetricsDetail } from "./metricsAnalyzerFactory";
+
+/** Detail reasons of conditions that can check a flag: `if`, `else if`, and `elif`. */
+const CONDITION_REASON = /^(if|else if|else_if|elif)(\b|_)/;
+
+/** Detail reasons of a plain `else`. */
+const ELSE_REASON = /^else( clause|_clause)?$/;
+
+/**
+ * Whether a detail is a condition whose text matches one of the flag patterns. The text
+ * is taken from the detail's (1-based) position to the end of its line.
+ */
+function isFlagCheck(
+  detail: UnifiedMetricsDetail,
+  lines: readonly string[],
+  patterns: readonly RegExp[]
+): boolean {
+  if (!CONDITION_REASON.test(detail.reason)) {
+    return false;
+  }
+  const text = lines[detail.line - 1]?.substring(detail.column - 1) ?? "";
+  return patterns.some((pattern) => pattern.test(text));
+}
+
+/** The leading whitespace of a detail's line. */
+function indentOf(lines: readonly string[], detail: UnifiedMetricsDetail): string {
+  return /^\s*/.exec(lines[detail.line - 1] ?? "")![0];
+}
+
+/**
+ * Sets `featureFlagChecks` and `steadyStateComplexity` on the functions that check a
+ * feature flag. Functions without flag checks are left untouched.
+ *
+ * @param functions - The file's functions
+ * @param lines - The file's source lines; detail positions are 1-based
+ * @param patterns - Regular expressions matched against condition text, e.g. `isEnabled\(`
+ */
+export function markFeatureFlags(
+  functions: readonly UnifiedFunctionMetrics[],
+  lines: readonly string[],
+  patterns: readonly RegExp[]
+): void {
+  if (patterns.length === 0) {
+    return;
+  }
+  for (const func of functions) {
+    let checks = 0;
+    let removed = 0;
+    // The flag checks whose branches the current detail may be in, innermost last.
+    const open: UnifiedMetricsDetail[] = [];
+    for (const detail of func.details) {
+      while (open.length > 0 && detail.nesting < open[open.length - 1].nesting) {
+        open.pop();
+      }
+      // Operators of the check's own condition are on its line. A later detail indented
+      // like the check is its `else`, or ends it; analyzers differ in whether they record
+      // the `else` at the check's nesting or inside it.
+      const check = open[open.length - 1];
+      if (check && detail.line !== check.line) {
+        const aligned =
+          detail.nesting <= check.nesting + 1 && indentOf(lines, detail) === indentOf(lines, check);
+        if (aligned && ELSE_REASON.test(detail.reason)) {
+          // The other path of a flag check goes away with the flag.
+          removed += detail.increment;
+          continue;
+        }
+        if (aligned || detail.nesting === check.nesting) {
+          open.pop();
+        }
+      }
+      if (isFlagCheck(detail, lines, patterns)) {
+        checks++;
+        removed += detail.increment;
+        open.push(detail);
+      } else if (detail.increment > 1) {
+        // Increments above 1 include nesting; each enclosing flag check added one level.
+        removed += Math.min(open.length, detail.increment - 1);
+      }
+    }
+    if (checks > 0) {
+      func.featureFlagChecks = checks;
+      func.steadyStateComplexity = Math.max(0, func.complexity - removed);
+    }
+  }
+}
@@ -0,0 +1,49 @@
+/**
+ * @fileoverview Function Fingerprints
+ *
+ * A fingerprint identifies a function by its content instead of its name, so history
+ * and baseline matching can follow a function across renames and moves within a file.
+ * It hashes the function's source after normalization:
+ * - blank and comment-only lines are dropped, and whitespace runs collapse to one space
+ * - occurrences of the function's own name (e.g. recursive calls) are neutralized
+ *
+ * Any other edit to the body yields a new fingerprint, which is the trade-off against
+ * name-based identity: names survive edits but not renames, fingerprints the reverse.
+ */
+
+import { createHash } from "crypto";
+import { UnifiedFunctionMetrics } from "./metricsAnalyzerFactory";
+
+/** How a function is identified across versions: by qualified name or by content fingerprint. */
+export type IdentityStrategy = "name" | "fingerprint";
+
+/** Placeholder substituted for the function's own name before hashing. */
+const NAME_PLACEHOLDER = "\u0000";
+
+/**
+ * Computes the content fingerprint of a function.
+ *
+ * @param lines - The file's source lines
+ * @param func - The function (0-based `startLine`/`endLine`)
+ * @returns A 12-character hex fingerprint
+ */
+export function getFunctionFingerprint(
+  lines: readonly string[],
+  func: UnifiedFunctionMetrics
+): string {
+  // `Type.Method` and `Outer.func1` are declared under their last segment.
+  const shortName = func.name.substring(func.name.lastIndexOf(".") + 1);
+  const namePattern = /^[A-Za-z_$][\w$]*$/.test(shortName)
+    ? new RegExp(`(?<![\\w$])${shortName.replace(/\$/g, "\\$")}(?![\\w$])`, "g")
+    : undefined;
+
+  const normalized: string[] = [];
+  for (let i = func.startLine; i <= Math.min(func.endLine, lines.length - 1); i++) {
+    const line = lines[i].trim().replace(/\s+/g, " ");
+    if (line === "" || line.startsWith("//") || line.startsWith("#") || line.startsWith("/*")) {
+      continue;
+    }
+    normalized.push(namePattern ? line.replace(namePattern, NAME_PLACEHOLDER) : line);
+  }
+  return createHash("sha1").update(normalized.join("\n")).digest("hex").substring(0, 12);
+}
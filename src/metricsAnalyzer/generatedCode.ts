@@ -0,0 +1,84 @@
+/**
+ * @fileoverview Generated Code Detection
+ *
+ * Finds code that was produced by a tool rather than written by hand, so it can be
+ * tagged or left out of metrics:
+ * - Whole files carrying the standard Go header `// Code generated <tool>; DO NOT EDIT.`
+ *   (https://go.dev/s/generatedcode), which must appear before the first non-comment text.
+ * - Regions between `//metrics:generated-begin` and `//metrics:generated-end` markers,
+ *   for generated sections embedded in hand-written files.
+ */
+
+import { UnifiedFunctionMetrics } from "./metricsAnalyzerFactory";
+
+/** The standard generated-file header (`#` is accepted for scripting languages). */
+const GENERATED_HEADER = /^(\/\/|#)\s*Code generated .* DO NOT EDIT\.$/;
+
+/** Opens a generated region. */
+const REGION_BEGIN = /^(\/\/|#)\s*metrics:generated-begin\b/;
+
+/** Closes a generated region. */
+const REGION_END = /^(\/\/|#)\s*metrics:generated-end\b/;
+
+/** Generated code found in a file. */
+export interface GeneratedCode {
+  /** Whether the whole file is generated */
+  file: boolean;
+  /** Generated line ranges (0-based, inclusive); an unterminated region runs to the end */
+  regions: { start: number; end: number }[];
+}
+
+/**
+ * Scans a file for the generated-file header and generated-region markers.
+ *
+ * @param lines - The source split into lines
+ * @returns The generated code found
+ */
+export function findGeneratedCode(lines: readonly string[]): GeneratedCode {
+  const result: GeneratedCode = { file: false, regions: [] };
+  let inHeader = true;
+  let regionStart: number | undefined;
+
+  for (let i = 0; i < lines.length; i++) {
+    const line = lines[i].trim();
+
+    if (inHeader) {
+      if (GENERATED_HEADER.test(line)) {
+        result.file = true;
+      } else if (line !== "" && !line.startsWith("//") && !line.startsWith("#") &&
+                 !line.startsWith("/*") && !line.startsWith("*")) {
+        inHeader = false;
+      }
+    }
+
+    if (regionStart === undefined && REGION_BEGIN.test(line)) {
+      regionStart = i;
+    } else if (regionStart !== undefined && REGION_END.test(line)) {
+      result.regions.push({ start: regionStart, end: i });
+      regionStart = undefined;
+    }
+  }
+
+  if (regionStart !== undefined) {
+    result.regions.push({ start: regionStart, end: lines.length - 1 });
+  }
+  return result;
+}
+
+/**
+ * Returns whether a function lies in generated code: the whole file is generated, or
+ * the function starts inside a generated region.
+ *
+ * @param func - The function (0-based `startLine`)
+ * @param generated - The generated code found in its file
+ * @returns true if the function is generated
+ */
+export function isGeneratedFunction(
+  func: UnifiedFunctionMetrics,
+  generated: GeneratedCode
+): boolean {
+  return (
+    generated.file ||
+    generated.regions.some((r) => func.startLine >= r.start && func.startLine <= r.end)
+  );
+}
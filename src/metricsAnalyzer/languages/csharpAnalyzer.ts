@@ -11,6 +11,7 @@
 
 import Parser from "tree-sitter";
 import CSharp from "tree-sitter-c-sharp";
+import { timePhase } from "../analysisTiming";
 
 // Module-level singleton: parser initialization is expensive, so we reuse one instance per language.
 const _parser = new Parser();
@@ -157,7 +158,7 @@ export class CSharpMetricsAnalyzer {
    */
   public analyzeFunctions(sourceText: string): CSharpFunctionMetrics[] {
     this.sourceText = sourceText;
-    const tree = this.parser.parse(sourceText);
+    const tree = timePhase("parse", () => this.parser.parse(sourceText));
     const functions: CSharpFunctionMetrics[] = [];
 
     const visit = (node: Parser.SyntaxNode) => {
@@ -0,0 +1,826 @@
+/**
+ * @fileoverview Dart Complexity Analyzer
+ *
+ * This module measures the cognitive complexity of Dart (and Flutter) functions. No syntax
+ * tree grammar for Dart is available, so the analyzer tokenizes the source (skipping
+ * comments and strings, including their interpolations) and follows brackets:
+ * - `if`, `for`, `while`, and `do`, including collection `if` and `for`, add +1 plus their
+ *   nesting level; `else` and `else if` add a flat +1
+ * - each `case` of a `switch` statement, and each arm of a `switch` expression except the
+ *   `_` and `default` ones, adds a flat +1
+ * - each `catch` (or `on Type` clause) adds +1
+ * - each ternary `? :`, `assert`, and null-aware `?.`, `?..`, `?[`, `...?`, or `??=` adds +1
+ * - each sequence of `&&`, `||`, or `??` adds +1
+ * - the bodies of `if`, loops, `switch`, and `catch` add a level of nesting
+ *
+ * Functions, methods, getters, setters, operators, constructors (with their initializer
+ * lists), and closures are each reported as their own entry, for block bodies (`{ ... }`)
+ * and arrow bodies (`=> expr`) alike. A closure's branches only count toward the closure,
+ * and nesting starts again at 0 inside it. Members are named `Class.member`, local
+ * functions `outer.inner`, and closures `outer.<closure N>`, numbered per enclosing entry.
+ */
+
+/** A single complexity increment, matching the shape of the other analyzers. */
+interface DartMetricsDetail {
+  increment: number;
+  reason: string;
+  /** Line of the construct (0-based) */
+  line: number;
+  /** Column of the construct (0-based) */
+  column: number;
+  nesting: number;
+}
+
+/** Complexity results for one function, method, constructor, or closure. */
+interface DartFunctionMetrics {
+  /** Qualified name, e.g. `Cart.total`, `Point.origin`, or `build.<closure 1>` */
+  name: string;
+  complexity: number;
+  details: DartMetricsDetail[];
+  startLine: number;
+  endLine: number;
+  startColumn: number;
+  endColumn: number;
+}
+
+type TokenType = "ident" | "op" | "open" | "close" | "comma" | "semicolon" | "literal";
+
+interface Token {
+  type: TokenType;
+  /** Identifier, operator, or bracket text; empty for literals */
+  value: string;
+  start: number;
+  end: number;
+}
+
+/** Constructs whose condition is followed by a body that adds a nesting level. */
+type ControlKind = "if" | "for" | "while" | "do" | "else" | "switch" | "catch";
+
+/** An entry being read: a function, method, constructor, or closure. */
+interface Scope {
+  name: string;
+  complexity: number;
+  details: DartMetricsDetail[];
+  start: number;
+  end: number;
+}
+
+/**
+ * A bracket, or a body without brackets (an arrow body, a constructor's initializer list,
+ * or the single statement of an `if` or loop), being read.
+ */
+interface Frame {
+  kind: "class" | "function" | "initializer" | "control" | "switch" | "block";
+  /** Whether the frame has no brackets and ends with its statement or expression */
+  inline: boolean;
+  /** The bracket character of a bracket frame */
+  bracket?: string;
+  /** The condition a parenthesis holds, e.g. the `(x)` of `if (x)` */
+  condition?: ControlKind;
+  /** The construct of a control frame */
+  control?: ControlKind;
+  /** The entry of a function or initializer frame */
+  scope?: Scope;
+  /** The name of a class, mixin, enum, or extension body */
+  className?: string;
+  /** Index of the first token of the current arm of a `switch` expression */
+  armStart?: number;
+  /** Whether the expression of a `switch` expression arm is being read */
+  inArm?: boolean;
+}
+
+/** Identifiers that are followed by parentheses without declaring a function. */
+const RESERVED: ReadonlySet<string> = new Set([
+  "if", "for", "while", "switch", "catch", "assert", "super", "this", "return", "await",
+  "yield", "throw", "new", "const", "in", "is", "as", "case", "when", "else", "do",
+]);
+
+/** Identifiers after which a parenthesis starts a closure rather than a call. */
+const EXPRESSION_KEYWORDS: ReadonlySet<string> = new Set(["return", "await", "yield", "throw"]);
+
+/** Keywords that start a class-like body. */
+const TYPE_KEYWORDS: ReadonlySet<string> = new Set(["class", "mixin", "enum", "extension"]);
+
+/** Modifiers between a parameter list and its body, e.g. `() async {`. */
+const BODY_MODIFIERS: ReadonlySet<string> = new Set(["async", "sync", "*"]);
+
+const LOGICAL_OPERATORS: ReadonlySet<string> = new Set(["&&", "||", "??"]);
+
+const NULL_AWARE_OPERATORS: ReadonlySet<string> = new Set(["?.", "?..", "...?", "??="]);
+
+/** Operators that end a sequence of logical operators. */
+const SEQUENCE_BREAKS: ReadonlySet<string> = new Set([
+  "?", ":", "=>", "=", "+=", "-=", "*=", "/=", "%=", "~/=", "&=", "|=", "^=", "<<=", "??=",
+]);
+
+/** Operators, longest first. `>` is never combined, so generic types close one at a time. */
+const OPERATOR = /^(?:\.\.\.\?|\?\?=|\?\.\.|~\/=|<<=|\.\.\.|\?\?|\?\.|&&|\|\||==|!=|<=|=>|\+\+|--|\+=|-=|\*=|\/=|%=|&=|\|=|\^=|<<|~\/|\.\.|[-+*/%=<>!&|^~?:.@#])/;
+
+/**
+ * Analyzer for Dart source files.
+ */
+export class DartMetricsAnalyzer {
+  /** Start offset of every line, for offset → position conversion */
+  private lineStarts: number[] = [];
+  private source = "";
+
+  /**
+   * Analyzes every function, method, constructor, and closure of a Dart file.
+   *
+   * @param sourceText - The Dart source
+   * @returns One entry per function-like declaration or closure, in source order
+   */
+  public analyzeFunctions(sourceText: string): DartFunctionMetrics[] {
+    this.source = sourceText;
+    this.lineStarts = [0];
+    for (let i = 0; i < sourceText.length; i++) {
+      if (sourceText[i] === "\n") {
+        this.lineStarts.push(i + 1);
+      }
+    }
+
+    const tokens = this.tokenize(sourceText);
+    const matches = this.matchBrackets(tokens);
+    const frames: Frame[] = [];
+    const scopes: Scope[] = [];
+    /** Bodies found after a parameter list, by the index of their `{`, `=>`, or `:` */
+    const bodies = new Map<number, Scope>();
+    const closureCounts = new Map<string, number>();
+    let condition: ControlKind | undefined;
+    let afterControl: ControlKind | undefined;
+    let closedDo = false;
+    let elseIf = false;
+    let catchBlock = false;
+    let onClause = false;
+    let typeName: string | undefined;
+    let typePending = false;
+    let lastLogical: string | undefined;
+
+    const top = () => frames[frames.length - 1];
+    const currentScope = (): Scope | undefined =>
+      [...frames].reverse().find((f) => f.scope)?.scope;
+    const nesting = () => {
+      let level = 0;
+      for (let i = frames.length - 1; i >= 0 && !frames[i].scope; i--) {
+        if (frames[i].kind === "control" || frames[i].kind === "switch") {
+          level++;
+        }
+      }
+      return level;
+    };
+    const addIncrement = (increment: number, reason: string, token: Token, level = nesting()) => {
+      const scope = currentScope();
+      if (scope) {
+        const { line, column } = this.getPosition(token.start);
+        scope.complexity += increment;
+        scope.details.push({ increment, reason, line, column, nesting: level });
+      }
+    };
+    const closeFrame = (end: number): Frame => {
+      const frame = frames.pop()!;
+      if (frame.control === "do") {
+        closedDo = true;
+      }
+      if (frame.scope) {
+        frame.scope.end = end;
+        scopes.push(frame.scope);
+      }
+      return frame;
+    };
+    /** Ends the bodies without brackets that end with the current statement or expression. */
+    const closeInline = (end: number, atComma: boolean) => {
+      while (top()?.inline && !(atComma && top().kind === "initializer")) {
+        closeFrame(end);
+      }
+    };
+    /** Ends the bodies without brackets up to the `if` an `else` belongs to. */
+    const closeThroughIf = (end: number) => {
+      while (top()?.inline) {
+        const frame = closeFrame(end);
+        if (frame.kind === "control" && frame.control === "if") {
+          break;
+        }
+      }
+    };
+    /** The name the entries declared at the current position are qualified with. */
+    const parentName = (): string | undefined => {
+      for (let i = frames.length - 1; i >= 0; i--) {
+        if (frames[i].scope) {
+          return frames[i].scope!.name;
+        }
+        if (frames[i].className !== undefined) {
+          return frames[i].className || undefined;
+        }
+      }
+      return undefined;
+    };
+    for (let i = 0; i < tokens.length; i++) {
+      const token = tokens[i];
+      const previous = tokens[i - 1];
+      const next = tokens[i + 1];
+      const wasClosedDo = closedDo;
+      closedDo = false;
+
+      // A function body found after its parameter list begins.
+      const body = bodies.get(i);
+      if (body) {
+        bodies.delete(i);
+        if (token.type === "open") {
+          frames.push({ kind: "function", inline: false, bracket: "{", scope: body });
+        } else {
+          frames.push({
+            kind: token.value === ":" ? "initializer" : "function",
+            inline: true,
+            scope: body,
+          });
+        }
+        lastLogical = undefined;
+        continue;
+      }
+
+      // The body of an `if`, loop, `else`, or `catch` without braces is a single statement.
+      if (afterControl && !(token.type === "open" && token.value === "{")) {
+        if (afterControl !== "switch") {
+          frames.push({ kind: "control", inline: true, control: afterControl });
+        }
+        afterControl = undefined;
+      }
+
+      switch (token.type) {
+        case "open": {
+          lastLogical = undefined;
+          if (token.value === "{") {
+            let frame: Frame;
+            if (afterControl) {
+              frame = {
+                kind: afterControl === "switch" ? "switch" : "control",
+                inline: false,
+                control: afterControl,
+                armStart: i + 1,
+              };
+              afterControl = undefined;
+            } else if (catchBlock) {
+              frame = { kind: "control", inline: false, control: "catch" };
+            } else if (typePending) {
+              frame = { kind: "class", inline: false, className: typeName ?? "" };
+            } else if (
+              top()?.kind === "initializer" &&
+              previous &&
+              !["op", "comma", "open"].includes(previous.type)
+            ) {
+              // The initializer list of a constructor ends where its body begins.
+              const initializer = frames.pop()!;
+              frame = { kind: "function", inline: false, scope: initializer.scope };
+            } else {
+              frame = { kind: "block", inline: false };
+            }
+            catchBlock = false;
+            onClause = false;
+            typePending = false;
+            typeName = undefined;
+            frames.push({ ...frame, bracket: "{" });
+            break;
+          }
+
+          if (token.value === "(" && condition) {
+            frames.push({ kind: "block", inline: false, bracket: "(", condition });
+            condition = undefined;
+            break;
+          }
+          if (token.value === "(" && !typePending) {
+            this.findFunction(tokens, i, matches, frames, bodies, closureCounts, parentName);
+          }
+          frames.push({ kind: "block", inline: false, bracket: token.value });
+          break;
+        }
+        case "close": {
+          lastLogical = undefined;
+          closeInline(previous?.end ?? token.start, false);
+          const frame = top();
+          if (frame && !frame.inline) {
+            closeFrame(token.end);
+            if (frame.condition) {
+              afterControl = frame.condition;
+            }
+          }
+          break;
+        }
+        case "semicolon":
+          lastLogical = undefined;
+          if (next?.type === "ident" && next.value === "else") {
+            closeThroughIf(previous?.end ?? token.start);
+          } else {
+            closeInline(previous?.end ?? token.start, false);
+          }
+          typePending = false;
+          typeName = undefined;
+          break;
+        case "comma": {
+          lastLogical = undefined;
+          closeInline(previous?.end ?? token.start, true);
+          const frame = top();
+          if (frame?.kind === "switch") {
+            frame.armStart = i + 1;
+            frame.inArm = false;
+          }
+          break;
+        }
+        case "op":
+          this.countOperator(tokens, i, top(), addIncrement);
+          if (LOGICAL_OPERATORS.has(token.value)) {
+            if (lastLogical !== token.value) {
+              addIncrement(1, `logical ${token.value} operator`, token);
+            }
+            lastLogical = token.value;
+          } else if (SEQUENCE_BREAKS.has(token.value)) {
+            lastLogical = undefined;
+          }
+          break;
+        case "ident":
+          switch (token.value) {
+            case "if":
+              lastLogical = undefined;
+              if (!elseIf) {
+                addIncrement(1 + nesting(), "if statement", token);
+              }
+              elseIf = false;
+              condition = "if";
+              break;
+            case "for":
+              addIncrement(1 + nesting(), "for loop", token);
+              condition = "for";
+              break;
+            case "while":
+              if (wasClosedDo) {
+                // The condition of a `do ... while`, counted with the `do`.
+                break;
+              }
+              addIncrement(1 + nesting(), "while loop", token);
+              condition = "while";
+              break;
+            case "do":
+              addIncrement(1 + nesting(), "do...while loop", token);
+              afterControl = "do";
+              break;
+            case "switch":
+              condition = "switch";
+              break;
+            case "else":
+              // A collection `if` ends at its `else`, which has no `;` before it.
+              if (previous && previous.type !== "semicolon" && previous.value !== "}") {
+                closeThroughIf(previous.end);
+              }
+              if (next?.type === "ident" && next.value === "if") {
+                addIncrement(1, "else if clause", token, nesting());
+                elseIf = true;
+              } else {
+                addIncrement(1, "else clause", token, nesting());
+                afterControl = "else";
+              }
+              break;
+            case "on":
+              if (previous?.type === "close" && previous.value === "}") {
+                addIncrement(1, "catch clause", token);
+                catchBlock = true;
+                onClause = true;
+              }
+              break;
+            case "catch":
+              if (!onClause) {
+                addIncrement(1, "catch clause", token);
+              }
+              catchBlock = false;
+              onClause = false;
+              condition = "catch";
+              break;
+            case "case":
+              if (top()?.kind === "switch") {
+                addIncrement(1, "switch case", token, nesting() - 1);
+              }
+              break;
+            case "assert":
+              addIncrement(1, "assert statement", token);
+              break;
+            case "get":
+              this.findGetter(tokens, i, frames, bodies, parentName);
+              break;
+            default:
+              if (TYPE_KEYWORDS.has(token.value) && !typePending && this.startsDeclaration(previous)) {
+                typePending = true;
+                typeName = this.readTypeName(tokens, i);
+              }
+              break;
+          }
+          break;
+        default:
+          break;
+      }
+    }
+    while (frames.length > 0) {
+      closeFrame(sourceText.length);
+    }
+
+    return scopes
+      .sort((a, b) => a.start - b.start)
+      .map((scope) => {
+        const start = this.getPosition(scope.start);
+        const end = this.getPosition(scope.end);
+        return {
+          name: scope.name,
+          complexity: scope.complexity,
+          details: scope.details,
+          startLine: start.line,
+          endLine: end.line,
+          startColumn: start.column,
+          endColumn: end.column,
+        };
+      });
+  }
+
+  /**
+   * Counts the operators that are branches on their own: the ternary `?`, null-aware
+   * access and assignment, and the arms of a `switch` expression. `??`, `&&`, and `||`
+   * are counted per sequence by the caller.
+   */
+  private countOperator(
+    tokens: Token[],
+    index: number,
+    frame: Frame | undefined,
+    addIncrement: (increment: number, reason: string, token: Token) => void
+  ): void {
+    const token = tokens[index];
+    const previous = tokens[index - 1];
+    if (NULL_AWARE_OPERATORS.has(token.value)) {
+      addIncrement(1, `null-aware ${token.value} operator`, token);
+    } else if (token.value === "?") {
+      const attached = previous !== undefined && previous.end === token.start;
+      const following = this.source[token.end] ?? "";
+      if (attached && following === "[") {
+        addIncrement(1, "null-aware ?[ operator", token);
+      } else if (!attached || !/[\s>,)\]};=]/.test(following)) {
+        // Not a nullable type such as `String?` or `List<int?>`
+        addIncrement(1, "ternary expression", token);
+      }
+    } else if (token.value === "=>" && frame?.kind === "switch" && !frame.inArm) {
+      const pattern = tokens.slice(frame.armStart ?? index, index);
+      frame.inArm = true;
+      const wildcard =
+        pattern.length === 1 && (pattern[0].value === "_" || pattern[0].value === "default");
+      if (!wildcard) {
+        addIncrement(1, "switch expression case", tokens[frame.armStart ?? index]);
+      }
+    }
+  }
+
+  /**
+   * Checks whether the parenthesis at `index` opens the parameter list of a function,
+   * method, constructor, or closure, and if so registers the entry for its body.
+   */
+  private findFunction(
+    tokens: Token[],
+    index: number,
+    matches: Map<number, number>,
+    frames: Frame[],
+    bodies: Map<number, Scope>,
+    closureCounts: Map<string, number>,
+    parentName: () => string | undefined
+  ): void {
+    const top = frames[frames.length - 1];
+    // Patterns in a switch expression, such as `Point(:var x) => x`, are not functions.
+    if (top?.kind === "switch" && !top.inArm) {
+      return;
+    }
+    const close = matches.get(index);
+    if (close === undefined) {
+      return;
+    }
+    let bodyIndex = close + 1;
+    while (tokens[bodyIndex] && BODY_MODIFIERS.has(tokens[bodyIndex].value)) {
+      bodyIndex++;
+    }
+    const bodyToken = tokens[bodyIndex];
+    if (!bodyToken) {
+      return;
+    }
+    const opensBody =
+      (bodyToken.type === "open" && bodyToken.value === "{") ||
+      (bodyToken.type === "op" && bodyToken.value === "=>");
+    const previous = tokens[index - 1];
+    const declaresOperator = tokens
+      .slice(Math.max(index - 4, 0), index)
+      .some((t) => t.type === "ident" && t.value === "operator");
+
+    if (
+      !declaresOperator &&
+      (previous === undefined ||
+      previous.type === "open" ||
+      previous.type === "comma" ||
+      (previous.type === "op" && previous.value !== ">" && previous.value !== ".") ||
+      (previous.type === "ident" && EXPRESSION_KEYWORDS.has(previous.value)))
+    ) {
+      if (opensBody) {
+        const parent = parentName();
+        const key = parent ?? "";
+        const count = (closureCounts.get(key) ?? 0) + 1;
+        closureCounts.set(key, count);
+        const name = parent ? `${parent}.<closure ${count}>` : `<closure ${count}>`;
+        bodies.set(bodyIndex, this.newScope(name, tokens[index].start));
+      }
+      return;
+    }
+
+    // Calls in a constructor's initializer list, such as `super(x)`, are not declarations.
+    if (top?.kind === "initializer") {
+      return;
+    }
+    const declared = this.readDeclaredName(tokens, index);
+    if (!declared) {
+      return;
+    }
+    const inClass = top?.kind === "class";
+    const isConstructorName =
+      inClass && (declared.name === top.className || declared.name.startsWith(`${top.className}.`));
+    const hasInitializer = isConstructorName && bodyToken.type === "op" && bodyToken.value === ":";
+    if (!opensBody && !hasInitializer) {
+      return;
+    }
+    const parent = parentName();
+    const name = isConstructorName || !parent ? declared.name : `${parent}.${declared.name}`;
+    bodies.set(bodyIndex, this.newScope(name, declared.start));
+  }
+
+  /** Registers a getter, `Type get name => ...` or `Type get name { ... }`. */
+  private findGetter(
+    tokens: Token[],
+    index: number,
+    frames: Frame[],
+    bodies: Map<number, Scope>,
+    parentName: () => string | undefined
+  ): void {
+    const nameToken = tokens[index + 1];
+    if (nameToken?.type !== "ident" || frames[frames.length - 1]?.kind === "initializer") {
+      return;
+    }
+    let bodyIndex = index + 2;
+    while (tokens[bodyIndex] && BODY_MODIFIERS.has(tokens[bodyIndex].value)) {
+      bodyIndex++;
+    }
+    const bodyToken = tokens[bodyIndex];
+    if (
+      bodyToken &&
+      ((bodyToken.type === "open" && bodyToken.value === "{") ||
+        (bodyToken.type === "op" && bodyToken.value === "=>"))
+    ) {
+      const parent = parentName();
+      const name = parent ? `${parent}.${nameToken.value}` : nameToken.value;
+      bodies.set(bodyIndex, this.newScope(name, nameToken.start));
+    }
+  }
+
+  /**
+   * Reads the name declared by the identifiers before a parameter list: `name`, a named
+   * constructor `Class.name`, a setter `name=`, an operator `operator ==`, or a generic
+   * function `name<T>`.
+   */
+  private readDeclaredName(
+    tokens: Token[],
+    index: number
+  ): { name: string; start: number } | undefined {
+    for (let j = index - 1; j >= Math.max(index - 4, 0); j--) {
+      if (tokens[j].type === "ident" && tokens[j].value === "operator") {
+        const symbol = tokens.slice(j + 1, index).map((t) => t.value).join("");
+        return { name: `operator ${symbol}`, start: tokens[j].start };
+      }
+    }
+
+    let nameIndex = index - 1;
+    if (tokens[nameIndex].type === "op" && tokens[nameIndex].value === ">") {
+      // Skip the type parameters of a generic function.
+      let angle = 0;
+      for (; nameIndex >= 0; nameIndex--) {
+        const value = tokens[nameIndex].value;
+        if (value === ">") {
+          angle++;
+        } else if (value === "<" && --angle === 0) {
+          break;
+        }
+      }
+      nameIndex--;
+    }
+    const nameToken = tokens[nameIndex];
+    if (nameToken?.type !== "ident" || RESERVED.has(nameToken.value)) {
+      return undefined;
+    }
+    const before = tokens[nameIndex - 1];
+    if (before?.type === "op" && before.value === "." && tokens[nameIndex - 2]?.type === "ident") {
+      const owner = tokens[nameIndex - 2];
+      return { name: `${owner.value}.${nameToken.value}`, start: owner.start };
+    }
+    if (before?.type === "ident" && before.value === "set") {
+      return { name: `${nameToken.value}=`, start: nameToken.start };
+    }
+    return { name: nameToken.value, start: nameToken.start };
+  }
+
+  /** Returns whether a class-like keyword starts a declaration, rather than being a name. */
+  private startsDeclaration(previous: Token | undefined): boolean {
+    return (
+      previous === undefined ||
+      previous.type === "semicolon" ||
+      previous.type === "close" ||
+      (previous.type === "open" && previous.value === "{") ||
+      previous.type === "ident"
+    );
+  }
+
+  /**
+   * Reads the name after `class`, `mixin`, `enum`, or `extension`. An unnamed extension
+   * is named after the type it extends (`extension on String` → `String`).
+   */
+  private readTypeName(tokens: Token[], index: number): string | undefined {
+    let j = index + 1;
+    while (tokens[j]?.type === "ident" && (tokens[j].value === "type" || tokens[j].value === "class")) {
+      j++;
+    }
+    const nameToken = tokens[j];
+    if (nameToken?.type !== "ident") {
+      return undefined;
+    }
+    if (tokens[index].value === "extension" && nameToken.value === "on") {
+      return tokens[j + 1]?.type === "ident" ? tokens[j + 1].value : undefined;
+    }
+    return nameToken.value;
+  }
+
+  private newScope(name: string, start: number): Scope {
+    return { name, complexity: 0, details: [], start, end: start };
+  }
+
+  /** Maps the index of every opening bracket to the index of its closing bracket. */
+  private matchBrackets(tokens: Token[]): Map<number, number> {
+    const matches = new Map<number, number>();
+    const open: number[] = [];
+    tokens.forEach((token, index) => {
+      if (token.type === "open") {
+        open.push(index);
+      } else if (token.type === "close" && open.length > 0) {
+        matches.set(open.pop()!, index);
+      }
+    });
+    return matches;
+  }
+
+  /**
+   * Splits Dart source into the tokens the analyzer needs. Comments are dropped (block
+   * comments may nest); strings, raw strings, multi-line strings, and numbers become
+   * literal tokens, so keywords inside them are never counted.
+   */
+  private tokenize(source: string): Token[] {
+    const tokens: Token[] = [];
+    const push = (type: TokenType, value: string, start: number, end: number) =>
+      tokens.push({ type, value, start, end });
+    let i = 0;
+    while (i < source.length) {
+      const ch = source[i];
+      const start = i;
+      if (ch === " " || ch === "\t" || ch === "\r" || ch === "\n") {
+        i++;
+      } else if (source.startsWith("//", i)) {
+        while (i < source.length && source[i] !== "\n") {
+          i++;
+        }
+      } else if (source.startsWith("/*", i)) {
+        i = this.skipBlockComment(source, i + 2);
+      } else if (
+        ch === '"' ||
+        ch === "'" ||
+        (ch === "r" && (source[i + 1] === '"' || source[i + 1] === "'") && !/[\w$]/.test(source[i - 1] ?? ""))
+      ) {
+        i = this.skipString(source, i);
+        push("literal", "", start, i);
+      } else if (/[A-Za-z_$]/.test(ch)) {
+        while (i < source.length && /[\w$]/.test(source[i])) {
+          i++;
+        }
+        push("ident", source.substring(start, i), start, i);
+      } else if (/[0-9]/.test(ch) || (ch === "." && /[0-9]/.test(source[i + 1] ?? ""))) {
+        const number = /^(?:0[xX][0-9a-fA-F_]+|[0-9_]*\.?[0-9_]+(?:[eE][-+]?[0-9]+)?)/.exec(
+          source.substring(i, i + 64)
+        );
+        i += number ? number[0].length : 1;
+        push("literal", "", start, i);
+      } else if (ch === "(" || ch === "[" || ch === "{") {
+        push("open", ch, i, i + 1);
+        i++;
+      } else if (ch === ")" || ch === "]" || ch === "}") {
+        push("close", ch, i, i + 1);
+        i++;
+      } else if (ch === ",") {
+        push("comma", ",", i, i + 1);
+        i++;
+      } else if (ch === ";") {
+        push("semicolon", ";", i, i + 1);
+        i++;
+      } else {
+        const operator = OPERATOR.exec(source.substring(i, i + 4));
+        if (operator) {
+          i += operator[0].length;
+          push("op", operator[0], start, i);
+        } else {
+          i++;
+        }
+      }
+    }
+    return tokens;
+  }
+
+  /** Skips a block comment from after its `/*`, including nested block comments. */
+  private skipBlockComment(source: string, from: number): number {
+    let depth = 1;
+    let i = from;
+    while (i < source.length && depth > 0) {
+      if (source.startsWith("/*", i)) {
+        depth++;
+        i += 2;
+      } else if (source.startsWith("*/", i)) {
+        depth--;
+        i += 2;
+      } else {
+        i++;
+      }
+    }
+    return i;
+  }
+
+  /**
+   * Skips a string literal from its first character (the quote, or the `r` of a raw
+   * string): single- or triple-quoted, with escapes and `${...}` interpolations.
+   */
+  private skipString(source: string, from: number): number {
+    let i = from;
+    const raw = source[i] === "r";
+    if (raw) {
+      i++;
+    }
+    const quote = source.startsWith(source[i].repeat(3), i) ? source[i].repeat(3) : source[i];
+    i += quote.length;
+    while (i < source.length && !source.startsWith(quote, i)) {
+      if (quote.length === 1 && source[i] === "\n") {
+        return i; // unterminated; resume on the next line
+      }
+      if (!raw && source[i] === "\\") {
+        i += 2;
+      } else if (!raw && source[i] === "$" && source[i + 1] === "{") {
+        i = this.skipInterpolation(source, i + 2);
+      } else {
+        i++;
+      }
+    }
+    return Math.min(i + quote.length, source.length);
+  }
+
+  /** Skips the code of a `${...}` interpolation, which may contain strings and braces. */
+  private skipInterpolation(source: string, from: number): number {
+    let depth = 1;
+    let i = from;
+    while (i < source.length && depth > 0) {
+      const ch = source[i];
+      if (ch === '"' || ch === "'") {
+        i = this.skipString(source, i);
+        continue;
+      }
+      if (ch === "{") {
+        depth++;
+      } else if (ch === "}") {
+        depth--;
+      }
+      i++;
+    }
+    return i;
+  }
+
+  /** Converts a character offset to a 0-based line and column. */
+  private getPosition(offset: number): { line: number; column: number } {
+    let low = 0;
+    let high = this.lineStarts.length - 1;
+    while (low < high) {
+      const mid = (low + high + 1) >> 1;
+      if (this.lineStarts[mid] <= offset) {
+        low = mid;
+      } else {
+        high = mid - 1;
+      }
+    }
+    return { line: low, column: offset - this.lineStarts[low] };
+  }
+
+  /**
+   * Static helper that analyzes a Dart file in one call.
+   *
+   * @param sourceText - The Dart source
+   * @returns Results per function, method, constructor, and closure
+   */
+  public static analyzeFile(sourceText: string): DartFunctionMetrics[] {
+    return new DartMetricsAnalyzer().analyzeFunctions(sourceText);
+  }
+}
@@ -0,0 +1,685 @@
+/**
+ * @fileoverview Elixir Complexity Analyzer
+ *
+ * This module measures the branching logic of Elixir functions. No syntax tree grammar
+ * for Elixir is available, so the analyzer tokenizes the source (skipping comments,
+ * strings, heredocs, sigils, atoms, and charlists) and follows `do ... end` and
+ * `fn ... end` blocks, including keyword forms such as `if x, do: a, else: b`:
+ * - `if`, `unless`, and `for` add +1 plus their nesting level; an `else` adds a flat +1
+ * - each `->` clause of `case`, `cond`, `receive`, `try` (rescue/catch/else), and the
+ *   `else` of `with` adds a flat +1; clauses of `fn` count only when there are several
+ * - each `<-` clause in the head of a `with` adds a flat +1
+ * - each guard (`when`) adds +1, in function heads and in clauses
+ * - each sequence of `&&`, `||`, `and`, or `or` adds +1
+ *
+ * Functions are reported per `def`/`defp` (and `defmacro`/`defmacrop`) head, as
+ * `Module.name/arity`. Function clauses of the same name and arity are aggregated into one
+ * entry, each clause after the first adding a flat +1 like a `case` clause, since pattern
+ * matching in heads is branching too.
+ */
+
+/** A single complexity increment, matching the shape of the other analyzers. */
+interface ElixirMetricsDetail {
+  increment: number;
+  reason: string;
+  /** Line of the construct (0-based) */
+  line: number;
+  /** Column of the construct (0-based) */
+  column: number;
+  nesting: number;
+}
+
+/** Complexity results for one function (all clauses of a name and arity). */
+interface ElixirFunctionMetrics {
+  /** Qualified name, e.g. `MyApp.Accounts.get_user/1` */
+  name: string;
+  complexity: number;
+  details: ElixirMetricsDetail[];
+  startLine: number;
+  endLine: number;
+  startColumn: number;
+  endColumn: number;
+  /** The function's arity */
+  parameterCount: number;
+  /** Number of function clauses aggregated into this entry */
+  clauseCount: number;
+}
+
+type TokenType =
+  | "ident"
+  | "keyword"
+  | "alias"
+  | "op"
+  | "open"
+  | "close"
+  | "comma"
+  | "newline"
+  | "literal";
+
+interface Token {
+  type: TokenType;
+  /** Identifier or operator text; for keywords (`do:`) the name without the colon */
+  value: string;
+  start: number;
+  end: number;
+}
+
+/** Constructs that open a block and add a nesting level to the code inside them. */
+type BlockKind =
+  | "if"
+  | "unless"
+  | "case"
+  | "cond"
+  | "with"
+  | "receive"
+  | "try"
+  | "for"
+  | "fn"
+  | "def"
+  | "module"
+  | "block";
+
+const NESTING_KINDS: ReadonlySet<BlockKind> = new Set<BlockKind>([
+  "if", "unless", "case", "cond", "with", "receive", "try", "for", "fn",
+]);
+
+/** Constructs whose `->` clauses are branches. */
+const CLAUSE_KINDS: ReadonlySet<BlockKind> = new Set<BlockKind>([
+  "case", "cond", "receive", "try", "with", "fn",
+]);
+
+const CONSTRUCT_KEYWORDS: ReadonlySet<string> = new Set([
+  "if", "unless", "case", "cond", "with", "receive", "try", "for",
+]);
+
+const FUNCTION_KEYWORDS: ReadonlySet<string> = new Set(["def", "defp", "defmacro", "defmacrop"]);
+
+const MODULE_KEYWORDS: ReadonlySet<string> = new Set(["defmodule", "defprotocol", "defimpl"]);
+
+const LOGICAL_OPERATORS: ReadonlySet<string> = new Set(["&&", "||", "and", "or"]);
+
+/** Tokens at the start of a line that continue the previous line's expression. */
+const CONTINUATION_STARTS: ReadonlySet<string> = new Set([
+  "|>", "&&", "||", "and", "or", "when", "<>", "++", "|", ".",
+]);
+
+/** Words that are binary operators, so a line ending with one continues. */
+const OPERATOR_WORDS: ReadonlySet<string> = new Set(["and", "or", "not", "in", "when"]);
+
+/** Operators, longest first. */
+const OPERATOR = /^(?:===|!==|\|\|\||&&&|<<<|>>>|<~>|<<~|~>>|\|\|>|->|<-|&&|\|\||\|>|::|\\\\|==|!=|<=|>=|=~|<>|\+\+|--|\.\.|=>|<<|>>|~>|<~|[-+*/=<>!&|^.~@%])/;
+
+/** A function clause whose head or body is being read. */
+interface ClauseState {
+  name: string;
+  arity: number;
+  module: string | undefined;
+  complexity: number;
+  details: ElixirMetricsDetail[];
+  start: number;
+  end: number;
+}
+
+/** A `do ... end`, `fn ... end`, or keyword-form (`do:`) block being read. */
+interface Frame {
+  kind: BlockKind;
+  /** Whether the block is a keyword form, which ends with its expression */
+  inline: boolean;
+  /** Bracket depth at which the block was opened */
+  depth: number;
+  /** The clause of a `def` block */
+  clause?: ClauseState;
+  /** The qualified name of a module block */
+  module?: string;
+  /** Offsets of the `->` of an `fn`, counted when it has several clauses */
+  arrows?: number[];
+}
+
+/** A construct keyword whose `do` has not been read yet. */
+interface Pending {
+  kind: BlockKind;
+  depth: number;
+  /** Whether the keyword is called with parentheses, as in `if(x, do: y)` */
+  parens: boolean;
+  clause?: ClauseState;
+  module?: string;
+}
+
+/**
+ * Analyzer for Elixir source files.
+ */
+export class ElixirMetricsAnalyzer {
+  /** Start offset of every line, for offset → position conversion */
+  private lineStarts: number[] = [];
+
+  /**
+   * Analyzes every function of an Elixir file.
+   *
+   * @param sourceText - The Elixir source
+   * @returns One entry per function name and arity, in source order
+   */
+  public analyzeFunctions(sourceText: string): ElixirFunctionMetrics[] {
+    this.lineStarts = [0];
+    for (let i = 0; i < sourceText.length; i++) {
+      if (sourceText[i] === "\n") {
+        this.lineStarts.push(i + 1);
+      }
+    }
+
+    const tokens = this.tokenize(sourceText);
+    const frames: Frame[] = [];
+    let pending: Pending[] = [];
+    const clauses: ClauseState[] = [];
+    let depth = 0;
+    let lastLogical: string | undefined;
+    let previous: Token | undefined;
+
+    const currentModule = () =>
+      [...frames].reverse().find((f) => f.kind === "module")?.module;
+    const currentClause = (): ClauseState | undefined =>
+      [...pending].reverse().find((p) => p.clause)?.clause ??
+      [...frames].reverse().find((f) => f.clause)?.clause;
+    const nestingAt = (count: number) => {
+      let nesting = 0;
+      for (let i = count - 1; i >= 0 && !frames[i].clause; i--) {
+        if (NESTING_KINDS.has(frames[i].kind)) {
+          nesting++;
+        }
+      }
+      return nesting;
+    };
+    const addIncrement = (increment: number, reason: string, offset: number, nesting: number) => {
+      const clause = currentClause();
+      if (clause) {
+        const { line, column } = this.getPosition(offset);
+        clause.complexity += increment;
+        clause.details.push({ increment, reason, line, column, nesting });
+      }
+    };
+    const closeFrame = (end: number) => {
+      const frame = frames.pop()!;
+      if (frame.kind === "fn" && frame.arrows && frame.arrows.length > 1) {
+        const nesting = nestingAt(frames.length);
+        for (const arrow of frame.arrows) {
+          addIncrement(1, "fn clause", arrow, nesting);
+        }
+      }
+      if (frame.clause) {
+        frame.clause.end = end;
+        clauses.push(frame.clause);
+      }
+    };
+    const closeInlineFrames = (atDepth: number, end: number) => {
+      while (frames.length > 0 && frames[frames.length - 1].inline && frames[frames.length - 1].depth >= atDepth) {
+        closeFrame(end);
+      }
+    };
+
+    for (let i = 0; i < tokens.length; i++) {
+      const token = tokens[i];
+      const next = tokens[i + 1];
+
+      if (token.type === "newline") {
+        const continues =
+          previous === undefined ||
+          ["op", "comma", "open", "keyword"].includes(previous.type) ||
+          (previous.type === "ident" && OPERATOR_WORDS.has(previous.value)) ||
+          (next !== undefined && CONTINUATION_STARTS.has(next.value) && next.type !== "keyword");
+        if (!continues) {
+          // The statement ends: keyword-form blocks and bodyless heads end with it.
+          closeInlineFrames(depth, previous!.end);
+          pending = pending.filter((p) => p.depth < depth);
+          lastLogical = undefined;
+        }
+        continue;
+      }
+
+      switch (token.type) {
+        case "open":
+          depth++;
+          lastLogical = undefined;
+          break;
+        case "close":
+          depth = Math.max(depth - 1, 0);
+          closeInlineFrames(depth + 1, previous?.end ?? token.start);
+          pending = pending.filter((p) => p.depth <= depth);
+          lastLogical = undefined;
+          break;
+        case "comma":
+          lastLogical = undefined;
+          break;
+        case "keyword":
+          lastLogical = undefined;
+          if (token.value === "do") {
+            const index = this.findPending(pending, depth, true);
+            const construct = index === -1 ? undefined : pending.splice(index, 1)[0];
+            frames.push({ ...this.toFrame(construct), inline: true, depth });
+          } else if (token.value === "else") {
+            this.countElse(frames, token, addIncrement, nestingAt);
+          }
+          break;
+        case "op":
+          if (token.value === "->") {
+            lastLogical = undefined;
+            const frame = frames[frames.length - 1];
+            if (frame && CLAUSE_KINDS.has(frame.kind)) {
+              if (frame.kind === "fn") {
+                (frame.arrows ??= []).push(token.start);
+              } else {
+                addIncrement(1, `${frame.kind} clause`, token.start, nestingAt(frames.length - 1));
+              }
+            }
+          } else if (token.value === "<-") {
+            const construct = pending[pending.length - 1];
+            if (construct?.kind === "with") {
+              addIncrement(1, "with clause", token.start, nestingAt(frames.length));
+            }
+          } else if (LOGICAL_OPERATORS.has(token.value)) {
+            if (lastLogical !== token.value) {
+              addIncrement(1, `logical operator ${token.value}`, token.start, nestingAt(frames.length));
+            }
+            lastLogical = token.value;
+          }
+          break;
+        case "ident":
+          if (LOGICAL_OPERATORS.has(token.value)) {
+            if (lastLogical !== token.value) {
+              addIncrement(1, `logical operator ${token.value}`, token.start, nestingAt(frames.length));
+            }
+            lastLogical = token.value;
+          } else if (token.value === "do") {
+            lastLogical = undefined;
+            const index = this.findPending(pending, depth, false);
+            const construct = index === -1 ? undefined : pending.splice(index, 1)[0];
+            frames.push({ ...this.toFrame(construct), inline: false, depth });
+          } else if (token.value === "end") {
+            lastLogical = undefined;
+            closeInlineFrames(depth, previous?.end ?? token.start);
+            if (frames.length > 0) {
+              closeFrame(token.end);
+            }
+          } else if (token.value === "fn") {
+            lastLogical = undefined;
+            frames.push({ kind: "fn", inline: false, depth, arrows: [] });
+          } else if (token.value === "else") {
+            this.countElse(frames, token, addIncrement, nestingAt);
+          } else if (token.value === "when") {
+            lastLogical = undefined;
+            addIncrement(1, "guard", token.start, nestingAt(frames.length));
+          } else if (CONSTRUCT_KEYWORDS.has(token.value)) {
+            const kind = token.value as BlockKind;
+            if (kind === "if" || kind === "unless" || kind === "for") {
+              const nesting = nestingAt(frames.length);
+              addIncrement(1 + nesting, `${kind} (nesting: ${nesting})`, token.start, nesting);
+            }
+            pending.push({ kind, depth, parens: this.isCall(token, next) });
+          } else if (FUNCTION_KEYWORDS.has(token.value)) {
+            const clause = this.readHead(tokens, i, currentModule());
+            if (clause) {
+              pending.push({ kind: "def", depth, parens: false, clause });
+            }
+          } else if (MODULE_KEYWORDS.has(token.value)) {
+            const name = this.readModuleName(tokens, i);
+            const outer = currentModule();
+            pending.push({
+              kind: "module",
+              depth,
+              parens: false,
+              module: outer && name ? `${outer}.${name}` : name ?? outer,
+            });
+          }
+          break;
+        default:
+          break;
+      }
+      previous = token;
+    }
+    while (frames.length > 0) {
+      closeFrame(sourceText.length);
+    }
+
+    return this.aggregate(clauses);
+  }
+
+  /** Finds the construct a `do` (or `do:`) at the given depth belongs to. */
+  private findPending(pending: Pending[], depth: number, keyword: boolean): number {
+    for (let i = pending.length - 1; i >= 0; i--) {
+      const p = pending[i];
+      if (p.depth === depth || (keyword && p.parens && p.depth + 1 === depth)) {
+        return i;
+      }
+    }
+    return -1;
+  }
+
+  private toFrame(construct: Pending | undefined): Omit<Frame, "inline" | "depth"> {
+    if (!construct) {
+      return { kind: "block" };
+    }
+    return {
+      kind: construct.kind,
+      clause: construct.clause,
+      module: construct.module,
+      arrows: undefined,
+    };
+  }
+
+  /** Counts an `else` of an `if` or `unless` (`else` in `with` and `try` starts clauses). */
+  private countElse(
+    frames: Frame[],
+    token: Token,
+    addIncrement: (increment: number, reason: string, offset: number, nesting: number) => void,
+    nestingAt: (count: number) => number
+  ): void {
+    const frame = frames[frames.length - 1];
+    if (frame && (frame.kind === "if" || frame.kind === "unless")) {
+      addIncrement(1, "else", token.start, nestingAt(frames.length - 1));
+    }
+  }
+
+  /** Returns whether a keyword is called with parentheses directly after it. */
+  private isCall(token: Token, next: Token | undefined): boolean {
+    return next?.type === "open" && next.value === "(" && next.start === token.end;
+  }
+
+  /**
+   * Reads a function head after `def`: its name and arity. Heads whose name is not a plain
+   * identifier (e.g. `def unquote(name)(...)` in macros) are skipped.
+   */
+  private readHead(tokens: Token[], index: number, module: string | undefined): ClauseState | undefined {
+    const nameToken = tokens[index + 1];
+    if (nameToken?.type !== "ident" && nameToken?.type !== "keyword") {
+      return undefined;
+    }
+    if (nameToken.value === "unquote") {
+      return undefined;
+    }
+    let arity = 0;
+    const open = tokens[index + 2];
+    if (nameToken.type === "ident" && open?.type === "open" && open.value === "(" && open.start === nameToken.end) {
+      let depth = 0;
+      let hasArgument = false;
+      for (let j = index + 2; j < tokens.length; j++) {
+        const t = tokens[j];
+        if (t.type === "open") {
+          depth++;
+        } else if (t.type === "close") {
+          depth--;
+          if (depth === 0) {
+            break;
+          }
+        } else if (t.type === "comma" && depth === 1) {
+          arity++;
+        } else if (t.type !== "newline") {
+          hasArgument = true;
+        }
+      }
+      arity = hasArgument ? arity + 1 : 0;
+    }
+    return {
+      name: nameToken.value,
+      arity,
+      module,
+      complexity: 0,
+      details: [],
+      start: tokens[index].start,
+      end: tokens[index].end,
+    };
+  }
+
+  /** Reads the alias after `defmodule` (`A.B.C`), and the `for:` target of a `defimpl`. */
+  private readModuleName(tokens: Token[], index: number): string | undefined {
+    const readAlias = (from: number): { name: string | undefined; next: number } => {
+      const parts: string[] = [];
+      let j = from;
+      while (tokens[j]?.type === "alias") {
+        parts.push(tokens[j].value);
+        if (tokens[j + 1]?.type === "op" && tokens[j + 1].value === "." && tokens[j + 2]?.type === "alias") {
+          j += 2;
+        } else {
+          j++;
+          break;
+        }
+      }
+      return { name: parts.length > 0 ? parts.join(".") : undefined, next: j };
+    };
+    const { name, next } = readAlias(index + 1);
+    if (tokens[index].value === "defimpl" && tokens[next]?.type === "comma") {
+      const target = tokens[next + 1];
+      if (target?.type === "keyword" && target.value === "for") {
+        const implemented = readAlias(next + 2).name;
+        return name && implemented ? `${name}.${implemented}` : name;
+      }
+    }
+    return name;
+  }
+
+  /** Merges the clauses of each function into one entry per module, name, and arity. */
+  private aggregate(clauses: ClauseState[]): ElixirFunctionMetrics[] {
+    const byName = new Map<string, ElixirFunctionMetrics>();
+    const ordered = [...clauses].sort((a, b) => a.start - b.start);
+    for (const clause of ordered) {
+      const name = `${clause.module ? `${clause.module}.` : ""}${clause.name}/${clause.arity}`;
+      const start = this.getPosition(clause.start);
+      const end = this.getPosition(clause.end);
+      const existing = byName.get(name);
+      if (!existing) {
+        byName.set(name, {
+          name,
+          complexity: clause.complexity,
+          details: clause.details,
+          startLine: start.line,
+          endLine: end.line,
+          startColumn: start.column,
+          endColumn: end.column,
+          parameterCount: clause.arity,
+          clauseCount: 1,
+        });
+        continue;
+      }
+      existing.clauseCount++;
+      existing.complexity += 1 + clause.complexity;
+      existing.details.push(
+        { increment: 1, reason: `function clause ${existing.clauseCount}`, line: start.line, column: start.column, nesting: 0 },
+        ...clause.details
+      );
+      if (end.line > existing.endLine || (end.line === existing.endLine && end.column > existing.endColumn)) {
+        existing.endLine = end.line;
+        existing.endColumn = end.column;
+      }
+    }
+    return [...byName.values()];
+  }
+
+  /**
+   * Splits Elixir source into the tokens the analyzer needs. Comments are dropped;
+   * strings, heredocs, sigils, charlists, atoms, characters (`?a`), and numbers become
+   * literal tokens, so keywords inside them are never counted.
+   */
+  private tokenize(source: string): Token[] {
+    const tokens: Token[] = [];
+    const push = (type: TokenType, value: string, start: number, end: number) =>
+      tokens.push({ type, value, start, end });
+    let i = 0;
+    while (i < source.length) {
+      const ch = source[i];
+      const start = i;
+      if (ch === "\n" || ch === ";") {
+        push("newline", "\n", i, i + 1);
+        i++;
+      } else if (ch === " " || ch === "\t" || ch === "\r" || ch === "\\" && source[i + 1] === "\n") {
+        i++;
+      } else if (ch === "#") {
+        while (i < source.length && source[i] !== "\n") {
+          i++;
+        }
+      } else if (source.startsWith('"""', i) || source.startsWith("'''", i)) {
+        const close = source.indexOf(source.substring(i, i + 3), i + 3);
+        i = close === -1 ? source.length : close + 3;
+        push("literal", "", start, i);
+      } else if (ch === '"' || ch === "'") {
+        i = this.skipString(source, i + 1, ch);
+        if (source[i] === ":" && source[i + 1] !== ":") {
+          i++; // quoted keyword key, "key": value
+        }
+        push("literal", "", start, i);
+      } else if (ch === "~" && /[a-zA-Z]/.test(source[i + 1] ?? "")) {
+        i = this.skipSigil(source, i + 1);
+        push("literal", "", start, i);
+      } else if (ch === "?" && i + 1 < source.length && !/[\w?!]/.test(source[i - 1] ?? "")) {
+        i += source[i + 1] === "\\" ? 3 : 2;
+        push("literal", "", start, i);
+      } else if (ch === ":" && source[i + 1] === ":") {
+        push("op", "::", i, i + 2);
+        i += 2;
+      } else if (ch === ":" && (source[i + 1] === '"' || source[i + 1] === "'")) {
+        i = this.skipString(source, i + 2, source[i + 1]);
+        push("literal", "", start, i);
+      } else if (ch === ":" && /[a-zA-Z_]/.test(source[i + 1] ?? "")) {
+        i++;
+        while (i < source.length && /[\w@]/.test(source[i])) {
+          i++;
+        }
+        if (source[i] === "?" || source[i] === "!") {
+          i++;
+        }
+        push("literal", "", start, i);
+      } else if (/[a-z_]/.test(ch)) {
+        while (i < source.length && /\w/.test(source[i])) {
+          i++;
+        }
+        if (source[i] === "?" || source[i] === "!") {
+          i++;
+        }
+        const value = source.substring(start, i);
+        const afterDot = tokens[tokens.length - 1]?.type === "op" && tokens[tokens.length - 1].value === "." &&
+          tokens[tokens.length - 1].end === start;
+        if (source[i] === ":" && source[i + 1] !== ":") {
+          i++;
+          push("keyword", value, start, i);
+        } else {
+          // `map.field` and `Mod.fun` are not keywords, even when named like one.
+          push(afterDot ? "literal" : "ident", value, start, i);
+        }
+      } else if (/[A-Z]/.test(ch)) {
+        while (i < source.length && /\w/.test(source[i])) {
+          i++;
+        }
+        if (source[i] === ":" && source[i + 1] !== ":") {
+          i++;
+          push("keyword", source.substring(start, i - 1), start, i);
+        } else {
+          push("alias", source.substring(start, i), start, i);
+        }
+      } else if (/[0-9]/.test(ch)) {
+        const number = /^(?:0[xob][0-9a-fA-F_]+|[0-9][0-9_]*(?:\.[0-9][0-9_]*(?:[eE][-+]?[0-9]+)?)?)/.exec(
+          source.substring(i, i + 64)
+        );
+        i += number ? number[0].length : 1;
+        push("literal", "", start, i);
+      } else if (ch === "(" || ch === "[" || ch === "{") {
+        push("open", ch, i, i + 1);
+        i++;
+      } else if (ch === ")" || ch === "]" || ch === "}") {
+        push("close", ch, i, i + 1);
+        i++;
+      } else if (ch === ",") {
+        push("comma", ",", i, i + 1);
+        i++;
+      } else {
+        const operator = OPERATOR.exec(source.substring(i, i + 4));
+        if (operator) {
+          i += operator[0].length;
+          push("op", operator[0], start, i);
+        } else {
+          i++;
+        }
+      }
+    }
+    return tokens;
+  }
+
+  /** Skips a quoted string from after its opening quote, with escapes and interpolation. */
+  private skipString(source: string, from: number, quote: string): number {
+    let i = from;
+    while (i < source.length && source[i] !== quote) {
+      if (source[i] === "\\") {
+        i += 2;
+      } else if (source[i] === "#" && source[i + 1] === "{") {
+        i = this.skipInterpolation(source, i + 2);
+      } else {
+        i++;
+      }
+    }
+    return i + 1;
+  }
+
+  /** Skips the code of a `#{...}` interpolation, which may contain strings and braces. */
+  private skipInterpolation(source: string, from: number): number {
+    let depth = 1;
+    let i = from;
+    while (i < source.length && depth > 0) {
+      const ch = source[i];
+      if (ch === '"' || ch === "'") {
+        i = this.skipString(source, i + 1, ch);
+        continue;
+      }
+      if (ch === "{") {
+        depth++;
+      } else if (ch === "}") {
+        depth--;
+      }
+      i++;
+    }
+    return i;
+  }
+
+  /** Skips a sigil from after its `~`: the letters, the delimited content, and modifiers. */
+  private skipSigil(source: string, from: number): number {
+    let i = from;
+    while (i < source.length && /[a-zA-Z]/.test(source[i])) {
+      i++;
+    }
+    if (source.startsWith('"""', i) || source.startsWith("'''", i)) {
+      const close = source.indexOf(source.substring(i, i + 3), i + 3);
+      i = close === -1 ? source.length : close + 3;
+    } else {
+      const closing: Record<string, string> = { "(": ")", "[": "]", "{": "}", "<": ">" };
+      const close = closing[source[i]] ?? source[i];
+      i++;
+      while (i < source.length && source[i] !== close) {
+        i += source[i] === "\\" ? 2 : 1;
+      }
+      i++;
+    }
+    while (i < source.length && /[a-zA-Z]/.test(source[i])) {
+      i++;
+    }
+    return i;
+  }
+
+  /** Converts a character offset to a 0-based line and column. */
+  private getPosition(offset: number): { line: number; column: number } {
+    let low = 0;
+    let high = this.lineStarts.length - 1;
+    while (low < high) {
+      const mid = (low + high + 1) >> 1;
+      if (this.lineStarts[mid] <= offset) {
+        low = mid;
+      } else {
+        high = mid - 1;
+      }
+    }
+    return { line: low, column: offset - this.lineStarts[low] };
+  }
+
+  /**
+   * Static helper that analyzes an Elixir file in one call.
+   *
+   * @param sourceText - The Elixir source
+   * @returns Results per function
+   */
+  public static analyzeFile(sourceText: string): ElixirFunctionMetrics[] {
+    return new ElixirMetricsAnalyzer().analyzeFunctions(sourceText);
+  }
+}
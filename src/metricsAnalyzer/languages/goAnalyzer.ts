@@ -11,6 +11,7 @@
 
 import Parser from "tree-sitter";
 import Go from "tree-sitter-go";
+import { timePhase } from "../analysisTiming";
 
 // Module-level singleton: parser initialization is expensive, so we reuse one instance per language.
 const _parser = new Parser();
@@ -52,6 +53,551 @@ interface GoFunctionMetrics {
   startColumn: number;
   /** Column number where the function definition ends (0-based) */
   endColumn: number;
+  /**
+   * Number of distinct receiver fields accessed in the method body (methods only).
+   * A rough LCOM-style cohesion signal: methods touching many fields often mix concerns.
+   */
+  fieldAccessCount?: number;
+  /** Per-function complexity budget pinned via a `//metrics:expect cc<=N` doc comment */
+  expectedComplexity?: GoComplexityExpectation;
+  /** Largest number of boolean operands combined by `&&`/`||` in any single condition */
+  maxConditionOperands?: number;
+  /** Deepest nesting of `&&`/`||` groups within each other in one expression */
+  maxExpressionNesting?: number;
+  /** Deepest nesting of func literals in the body (0 when it has no closures) */
+  maxClosureDepth?: number;
+  /** Switch, type switch, and select statements of the body with their clause counts */
+  switches?: GoSwitchSize[];
+  /**
+   * Number of `return` statements, naked returns included, and of `panic` calls when
+   * panics count as exits; closures' returns are their own
+   */
+  returnCount?: number;
+  /**
+   * Error surface: number of `return` statements handing back a non-nil error, such as
+   * `return err` and `return nil, fmt.Errorf("load: %w", err)`; set only when the last
+   * result is of type `error`. Naked returns are not counted; closures' returns are their own
+   */
+  errorReturnCount?: number;
+  /** Number of statements in the body, init clauses and closures' statements excluded */
+  statementCount?: number;
+  /** Number of branches: `if` and `else if`, loops, and `case` clauses other than `default` */
+  branchCount?: number;
+  /** Branches per statement (`branchCount / statementCount`, 0 for an empty body) */
+  branchDensity?: number;
+  /** Operator kinds used in the body (arithmetic, comparison, logical, bitwise, channel), sorted */
+  operatorKinds?: string[];
+  /** Named syntax nodes in the body by node type; only collected when requested */
+  nodeCounts?: Record<string, number>;
+  /** Distinct functions and receiver methods called in the body, by entry name */
+  calls?: string[];
+  /** Number of parameters, the receiver excluded; `a, b int` counts two */
+  parameterCount?: number;
+  /** Whether the method's receiver is a pointer (`*T`); methods only */
+  pointerReceiver?: boolean;
+  /**
+   * Fields written through a first parameter of type `*T`, T declared in the package, e.g.
+   * `cfg.Port = p` in `func Apply(cfg *Config)`; plain functions only, unset without writes
+   */
+  pointerParamWrites?: GoPointerParamWrites;
+  /** Whether a method's named receiver is never referenced in the body; methods only */
+  receiverUnused?: boolean;
+  /**
+   * Whether the parser did not fully understand the function's syntax, e.g. a language
+   * feature newer than the bundled grammar, so its metrics are a best-effort estimate
+   */
+  approximate?: boolean;
+}
+
+/** Fields a plain function writes through its first parameter, a pointer to a package type. */
+interface GoPointerParamWrites {
+  /** The parameter, e.g. `cfg` */
+  parameter: string;
+  /** The pointed-to type, e.g. `Config` */
+  type: string;
+  /** Number of distinct fields assigned or incremented through the parameter */
+  fieldCount: number;
+}
+
+/** A switch, type switch, or select statement and its number of clauses. */
+interface GoSwitchSize {
+  /** `switch`, `type switch`, or `select` */
+  kind: string;
+  /** Number of `case` clauses, `default` included */
+  caseCount: number;
+  /** Line of the statement (0-based) */
+  line: number;
+  /** Column of the statement (0-based) */
+  column: number;
+}
+
+/**
+ * A complexity budget declared in a function's doc comment, e.g. `//metrics:expect cc<=8`.
+ * `<=` and `<` are upper bounds; `==` (written `=` or `==`) pins an exact value.
+ */
+interface GoComplexityExpectation {
+  /** Comparison operator the actual complexity must satisfy */
+  operator: "<=" | "<" | "==";
+  /** The expected complexity value */
+  value: number;
+}
+
+/**
+ * How func literals (closures) are reported:
+ * - `inline`: merged into the enclosing function at an increased nesting level (default)
+ * - `separate`: reported as their own entries and excluded from the enclosing function
+ * - `both`: merged into the enclosing function and also reported as their own entries
+ */
+type GoClosureMode = "inline" | "separate" | "both";
+
+/**
+ * How func values of composite literals (struct fields, map entries, and slice elements,
+ * as in dispatch tables) are reported besides their own entry:
+ * - `standalone`: excluded from the enclosing function
+ * - `attributed`: also merged into the enclosing function (default)
+ */
+type GoLiteralFuncMode = "standalone" | "attributed";
+
+/**
+ * How `panic(...)` calls are counted:
+ * - `statement`: like any other call, without a contribution (default)
+ * - `exit`: as an exit point in `returnCount`, without adding complexity
+ * - `branch`: as a complexity increment, like `recover()`
+ */
+type GoPanicMode = "statement" | "exit" | "branch";
+
+/**
+ * How functions and closures are scored:
+ * - `cognitive`: cognitive complexity (default)
+ * - `cyclomatic`: gocyclo's decision points, as in {@link GoMetricsAnalyzer.countCyclomatic}
+ */
+type GoScoring = "cognitive" | "cyclomatic";
+
+/** Options that change how Go functions are analyzed. */
+interface GoAnalyzerOptions {
+  /** How func literals are reported (default `inline`) */
+  closureMode?: GoClosureMode;
+  /** Whether composite literal func values also count in their function (default `attributed`) */
+  literalFuncMode?: GoLiteralFuncMode;
+  /** Whether to collect a per-function histogram of syntax node types (default false) */
+  collectNodeCounts?: boolean;
+  /**
+   * Files with more lines than this are parsed one top-level declaration at a time, so
+   * only one declaration's syntax tree is held in memory (default 0: never)
+   */
+  streamingThreshold?: number;
+  /** How `panic(...)` calls are counted (default `statement`) */
+  panicMode?: GoPanicMode;
+  /** How function declarations are scored (default `cognitive`) */
+  scoring?: GoScoring;
+  /**
+   * Lines (0-based, inclusive) whose functions are analyzed; function declarations outside
+   * them are not parsed at all (default: the whole file)
+   */
+  lineRanges?: readonly { start: number; end: number }[];
+}
+
+/** A top-level declaration's source text, with any comments that precede it. */
+interface GoDeclarationChunk {
+  text: string;
+  /** Line of the file the text starts on (0-based) */
+  row: number;
+}
+
+/** Characters that end a Go statement at the end of a line (automatic semicolon insertion). */
+const STATEMENT_END = /[\w)\]}"'`]/;
+
+/**
+ * Splits Go source text into top-level declarations without parsing it. A declaration
+ * ends at a line break outside brackets, strings, and comments, where Go would insert a
+ * semicolon. Comments and blank lines between declarations go with the next one.
+ *
+ * @param sourceText - The complete Go source code
+ * @returns The declarations in source order, each starting at the beginning of a line
+ */
+function* splitTopLevelDeclarations(sourceText: string): Generator<GoDeclarationChunk> {
+  let depth = 0;
+  let start = 0;
+  let startRow = 0;
+  let row = 0;
+  // Last significant character of the current line
+  let last = "";
+  for (let i = 0; i < sourceText.length; i++) {
+    const ch = sourceText[i];
+    if (ch === "\n") {
+      row++;
+      if (depth === 0 && STATEMENT_END.test(last)) {
+        yield { text: sourceText.substring(start, i + 1), row: startRow };
+        start = i + 1;
+        startRow = row;
+      }
+      last = "";
+    } else if (ch === "/" && sourceText[i + 1] === "/") {
+      const end = sourceText.indexOf("\n", i);
+      i = (end === -1 ? sourceText.length : end) - 1;
+    } else if (ch === "/" && sourceText[i + 1] === "*") {
+      const end = sourceText.indexOf("*/", i + 2);
+      const stop = end === -1 ? sourceText.length : end + 2;
+      for (let j = i; j < stop; j++) {
+        if (sourceText[j] === "\n") {
+          row++;
+        }
+      }
+      i = stop - 1;
+    } else if (ch === '"' || ch === "'") {
+      // Interpreted strings and runes end on the same line; skip escaped characters.
+      let j = i + 1;
+      while (j < sourceText.length && sourceText[j] !== ch && sourceText[j] !== "\n") {
+        j += sourceText[j] === "\\" && sourceText[j + 1] !== "\n" ? 2 : 1;
+      }
+      i = sourceText[j] === ch ? j : j - 1;
+      last = ch;
+    } else if (ch === "`") {
+      const end = sourceText.indexOf("`", i + 1);
+      const stop = end === -1 ? sourceText.length - 1 : end;
+      for (let j = i; j < stop; j++) {
+        if (sourceText[j] === "\n") {
+          row++;
+        }
+      }
+      i = stop;
+      last = ch;
+    } else if (ch === "(" || ch === "{" || ch === "[") {
+      depth++;
+      last = ch;
+    } else if (ch === ")" || ch === "}" || ch === "]") {
+      depth = Math.max(0, depth - 1);
+      last = ch;
+    } else if (ch !== " " && ch !== "\t" && ch !== "\r") {
+      last = ch;
+    }
+  }
+  if (start < sourceText.length) {
+    yield { text: sourceText.substring(start), row: startRow };
+  }
+}
+
+/** A declaration that starts with `func`, after any comments: a function or method. */
+const FUNCTION_DECLARATION = /^(?:\s|\/\/[^\n]*|\/\*[\s\S]*?\*\/)*func\b/;
+
+/** Counts the lines of a text without splitting it. */
+function countLines(text: string): number {
+  let lines = 1;
+  for (let i = text.indexOf("\n"); i !== -1; i = text.indexOf("\n", i + 1)) {
+    lines++;
+  }
+  return lines;
+}
+
+/** A token of the fallback scan of a declaration the parser did not understand. */
+interface GoEstimateToken {
+  /** Identifier, keyword, or operator text; strings, runes, and numbers are `"literal"` */
+  text: string;
+  row: number;
+  column: number;
+}
+
+/** Operators the fallback scan reads as one token. */
+const ESTIMATE_OPERATOR = /^(?:&&|\|\||:=|<-|\.\.\.|[^\w\s])/;
+
+/** Splits a declaration into tokens for the fallback scan, dropping comments. */
+function tokenizeDeclaration(text: string): GoEstimateToken[] {
+  const tokens: GoEstimateToken[] = [];
+  let row = 0;
+  let lineStart = 0;
+  let i = 0;
+  const advanceTo = (end: number) => {
+    for (let j = i; j < end; j++) {
+      if (text[j] === "\n") {
+        row++;
+        lineStart = j + 1;
+      }
+    }
+    i = end;
+  };
+  while (i < text.length) {
+    const ch = text[i];
+    const start = { row, column: i - lineStart };
+    if (/\s/.test(ch)) {
+      advanceTo(i + 1);
+    } else if (text.startsWith("//", i)) {
+      const end = text.indexOf("\n", i);
+      advanceTo(end === -1 ? text.length : end);
+    } else if (text.startsWith("/*", i)) {
+      const end = text.indexOf("*/", i + 2);
+      advanceTo(end === -1 ? text.length : end + 2);
+    } else if (ch === '"' || ch === "'" || ch === "`") {
+      let j = i + 1;
+      while (j < text.length && text[j] !== ch && (ch === "`" || text[j] !== "\n")) {
+        j += ch !== "`" && text[j] === "\\" ? 2 : 1;
+      }
+      advanceTo(Math.min(j + 1, text.length));
+      tokens.push({ text: "literal", ...start });
+    } else if (/\w/.test(ch)) {
+      const match = /^\w+/.exec(text.substring(i, i + 256))!;
+      advanceTo(i + match[0].length);
+      tokens.push({ text: /^\d/.test(match[0]) ? "literal" : match[0], ...start });
+    } else {
+      const operator = ESTIMATE_OPERATOR.exec(text.substring(i, i + 3))![0];
+      advanceTo(i + operator.length);
+      tokens.push({ text: operator, ...start });
+    }
+  }
+  return tokens;
+}
+
+/**
+ * Estimates the cognitive complexity of a function declaration from its tokens, for
+ * declarations the parser could not make sense of. It follows braces rather than syntax:
+ * `if`, `for`, `switch`, `select`, `goto`, labeled jumps, and `recover()` add +1 plus
+ * nesting, `else` and `else if` a flat +1, each `&&`/`||` sequence +1, and a nested func
+ * literal +1 plus nesting. The block after a control keyword, `else`, or `func` nests.
+ *
+ * @param text - One top-level declaration, possibly preceded by comments
+ * @returns The estimate with positions relative to the text, or null when it is not a function
+ */
+function estimateDeclaration(text: string): GoFunctionMetrics | null {
+  const tokens = tokenizeDeclaration(text);
+  const start = tokens[0];
+  if (start?.text !== "func") {
+    return null;
+  }
+
+  // Name: `func Name`, or `func (r *Type[T]) Name` for methods.
+  let i = 1;
+  let receiverType = "";
+  if (tokens[i]?.text === "(") {
+    let depth = 0;
+    const receiver: string[] = [];
+    for (; i < tokens.length; i++) {
+      depth += tokens[i].text === "(" ? 1 : tokens[i].text === ")" ? -1 : 0;
+      if (depth === 0) {
+        break;
+      }
+      receiver.push(tokens[i].text);
+    }
+    const parts = receiver.slice(1);
+    // An unnamed receiver (`func (T) M()`) has only its type.
+    const named = parts.length > 1 && /^\w+$/.test(parts[0]) && /^[\w*]/.test(parts[1]);
+    receiverType = formatReceiverType(
+      (named ? parts.slice(1) : parts).join("").replace(/^\*/, "")
+    );
+    i++;
+  }
+  const nameToken = tokens[i];
+  if (!nameToken || !/^\w+$/.test(nameToken.text)) {
+    return null;
+  }
+  const name = receiverType ? `${receiverType}.${nameToken.text}` : nameToken.text;
+
+  // The body is the first brace outside brackets that does not belong to a
+  // `struct{}` or `interface{}` type in the signature.
+  let depth = 0;
+  for (i++; i < tokens.length; i++) {
+    const token = tokens[i].text;
+    if (token === "(" || token === "[") {
+      depth++;
+    } else if (token === ")" || token === "]") {
+      depth--;
+    } else if (token === "{") {
+      if (tokens[i - 1]?.text !== "struct" && tokens[i - 1]?.text !== "interface") {
+        if (depth === 0) {
+          break;
+        }
+      } else {
+        // Skip the type's braces.
+        for (let braces = 0; i < tokens.length; i++) {
+          braces += tokens[i].text === "{" ? 1 : tokens[i].text === "}" ? -1 : 0;
+          if (braces === 0) {
+            break;
+          }
+        }
+      }
+    }
+  }
+  if (i >= tokens.length) {
+    return null; // a declaration without a body
+  }
+
+  const details: GoMetricsDetail[] = [];
+  // Whether each open brace adds a level of nesting
+  const braces: boolean[] = [];
+  let pendingNest = false;
+  // Last logical operator of the current expression, per open parenthesis
+  const sequences: (string | undefined)[] = [undefined];
+  let end = tokens[tokens.length - 1];
+  // The function body's brace does not nest; a statement's header (`if a && b`) is
+  // nested in its statement, as its condition is in the syntax tree.
+  const level = () => braces.filter((nests) => nests).length - 1 + (pendingNest ? 1 : 0);
+  const add = (increment: number, reason: string, token: GoEstimateToken) =>
+    details.push({ increment, reason, line: token.row, column: token.column, nesting: level() });
+
+  for (; i < tokens.length; i++) {
+    const token = tokens[i];
+    const previous = tokens[i - 1];
+    const next = tokens[i + 1];
+    if (previous && token.row !== previous.row && previous.text !== "&&" && previous.text !== "||") {
+      sequences[sequences.length - 1] = undefined; // a new statement
+    }
+    switch (token.text) {
+      case "{":
+        braces.push(braces.length === 0 || pendingNest);
+        pendingNest = false;
+        sequences[sequences.length - 1] = undefined;
+        break;
+      case "}":
+        braces.pop();
+        sequences[sequences.length - 1] = undefined;
+        if (braces.length === 0) {
+          end = token;
+          i = tokens.length;
+        }
+        break;
+      case "(":
+        sequences.push(undefined);
+        break;
+      case ")":
+        if (sequences.length > 1) {
+          sequences.pop();
+        }
+        break;
+      case ";":
+      case ",":
+        sequences[sequences.length - 1] = undefined;
+        break;
+      case "&&":
+      case "||":
+        if (sequences[sequences.length - 1] !== token.text) {
+          sequences[sequences.length - 1] = token.text;
+          add(1 + level(), `binary ${token.text} operator`, token);
+        }
+        break;
+      case "else":
+        add(1, next?.text === "if" ? "else if clause" : "else clause", token);
+        if (next?.text === "if") {
+          i++;
+        }
+        pendingNest = true;
+        break;
+      case "if":
+        add(1 + level(), "if statement", token);
+        pendingNest = true;
+        break;
+      case "for":
+      case "switch":
+      case "select":
+        add(
+          1 + level(),
+          token.text === "for" ? "for loop" : `${token.text} statement`,
+          token
+        );
+        pendingNest = true;
+        break;
+      case "func":
+        if (level() > 0) {
+          add(1 + level(), "function literal (nested)", token);
+        }
+        pendingNest = true;
+        break;
+      case "goto":
+        add(1 + level(), "goto statement", token);
+        break;
+      case "break":
+      case "continue": {
+        const labeled = next !== undefined && next.row === token.row && /^\w+$/.test(next.text);
+        if (labeled || level() > 0) {
+          add(
+            1 + level(),
+            labeled ? `labeled ${token.text} statement` : `${token.text} statement (nested)`,
+            token
+          );
+        }
+        break;
+      }
+      case "recover":
+        if (next?.text === "(" && previous?.text !== ".") {
+          add(1 + level(), "recover call", token);
+        }
+        break;
+    }
+  }
+
+  return {
+    name,
+    complexity: details.reduce((sum, detail) => sum + detail.increment, 0),
+    details,
+    startLine: start.row,
+    endLine: end.row,
+    startColumn: start.column,
+    endColumn: end.column + 1,
+    approximate: true,
+  };
+}
+
+/**
+ * Writes a receiver type the same way however it is spaced in the source, so that
+ * `Pair[K,V]` and `Pair[ K, V ]` both name their methods `Pair[K, V].M`.
+ */
+function formatReceiverType(text: string): string {
+  return text
+    .replace(/\s+/g, " ")
+    .replace(/\s*,\s*/g, ", ")
+    .replace(/\[\s*/g, "[")
+    .replace(/\s*\]/g, "]")
+    .trim();
+}
+
+/**
+ * Points receiver calls of generic methods at the methods they call. Every method of a
+ * generic type names its own type parameters, so `s.Push()` in `func (s *Stack[E]) Drain()`
+ * is collected as `Stack[E].Push` while the method is `Stack[T].Push`; both are the same
+ * method of `Stack`, and the call is renamed to the declared name.
+ *
+ * @param functions - The entries of one file, whose `calls` are updated in place
+ */
+function resolveGenericReceiverCalls(functions: GoFunctionMetrics[]): void {
+  const withoutTypeParams = (name: string) => name.replace(/\[[^\]]*\](?=\.[^.]+$)/, "");
+  const methods = new Map<string, string>();
+  for (const func of functions) {
+    if (func.pointerReceiver !== undefined && func.name.includes("[")) {
+      methods.set(withoutTypeParams(func.name), func.name);
+    }
+  }
+  if (methods.size === 0) {
+    return;
+  }
+  for (const func of functions) {
+    if (func.calls) {
+      const calls = func.calls.map((call) => methods.get(withoutTypeParams(call)) ?? call);
+      func.calls = [...new Set(calls)];
+    }
+  }
+}
+
+/** Fluent-API usage found in one Go file: methods returning their receiver, and call chains. */
+interface GoFluentUsage {
+  /** Methods whose single result is their own receiver type, e.g. `func (b *B) With() *B` */
+  fluentMethods: { type: string; name: string }[];
+  /** Method call chains (`a.X().Y().Z()`), method names in call order with the chain's line (0-based) */
+  chains: { methods: string[]; line: number }[];
+}
+
+/** A function's cyclomatic complexity counted the way gocyclo counts it. */
+interface GoCyclomaticComplexity {
+  /** Entry name, as in the cognitive complexity results (e.g. `Service.Save`) */
+  name: string;
+  /** Line number where the function definition starts (0-based) */
+  startLine: number;
+  /** 1 plus one per `if`, `for`, non-default `case`, `&&`, and `||` */
+  complexity: number;
+}
+
+/** A function or closure whose body is being analyzed, used to name nested closures. */
+interface GoClosureScope {
+  /** Entry name of the enclosing function or closure */
+  name: string;
+  /** Number of closures named so far directly inside this scope */
+  closureCount: number;
 }
 
 /**
@@ -87,131 +633,1280 @@ export class GoMetricsAnalyzer {
     "func_literal",
   ]);
 
-  /** Current nesting level during analysis */
-  private nesting = 0;
-  /** Current complexity score during analysis */
-  private complexity = 0;
-  /** Array of complexity details for the current function being analyzed */
-  private details: GoMetricsDetail[] = [];
-  /** The source code text being analyzed */
-  private sourceText: string;
-  /** Tree-sitter parser instance configured for Go */
-  private parser: Parser;
+  /** Operator kinds by operator token; unary operators are keyed `unary <op>`. */
+  private static readonly OPERATOR_KINDS: Readonly<Record<string, string>> = {
+    "+": "arithmetic", "-": "arithmetic", "*": "arithmetic", "/": "arithmetic", "%": "arithmetic",
+    "unary +": "arithmetic", "unary -": "arithmetic",
+    "==": "comparison", "!=": "comparison", "<": "comparison", "<=": "comparison",
+    ">": "comparison", ">=": "comparison",
+    "&&": "logical", "||": "logical", "unary !": "logical",
+    "&": "bitwise", "|": "bitwise", "^": "bitwise", "&^": "bitwise", "<<": "bitwise",
+    ">>": "bitwise", "unary ^": "bitwise",
+    "<-": "channel", "unary <-": "channel",
+  };
+
+  /** Statement node types that do not end in `_statement`. */
+  private static readonly DECLARATION_STATEMENTS: ReadonlySet<string> = new Set([
+    "short_var_declaration",
+    "var_declaration",
+    "const_declaration",
+    "type_declaration",
+  ]);
+
+  /** Node types that are a branch for branch density. */
+  private static readonly BRANCH_TYPES: ReadonlySet<string> = new Set([
+    "if_statement",
+    "for_statement",
+    "expression_case",
+    "type_case",
+    "communication_case",
+  ]);
+
+  /** The keyword of each switch-like statement type, as reported in `switches`. */
+  private static readonly SWITCH_KINDS: Readonly<Record<string, string>> = {
+    expression_switch_statement: "switch",
+    type_switch_statement: "type switch",
+    select_statement: "select",
+  };
+
+  /** Clause node types of switch-like statements. */
+  private static readonly CASE_CLAUSE_TYPES: ReadonlySet<string> = new Set([
+    "expression_case",
+    "type_case",
+    "communication_case",
+    "default_case",
+  ]);
+
+  /** Node types whose `initializer` field holds an init clause (`for_clause` is inside `for`). */
+  private static readonly INIT_CLAUSE_PARENTS: ReadonlySet<string> = new Set([
+    "if_statement",
+    "expression_switch_statement",
+    "type_switch_statement",
+    "for_clause",
+  ]);
+
+  /** Matches a `//metrics:expect cc<=N` directive (also `cc<N`, `cc=N`, `cc==N`). */
+  private static readonly EXPECT_DIRECTIVE =
+    /^\/\/\s*metrics:expect\s+cc\s*(<=|<|==|=)\s*(\d+)\s*$/;
+
+  /** Matches a `//metrics:disable` or `//metrics:enable` region comment; a reason may follow. */
+  private static readonly REGION_DIRECTIVE = /^\/\/\s*metrics:(disable|enable)(\s|$)/;
+
+  /** Blocks that end the `//metrics:disable` or `//metrics:enable` regions begun inside them. */
+  private static readonly REGION_SCOPES = new Set([
+    "block",
+    "expression_case",
+    "type_case",
+    "communication_case",
+    "default_case",
+    "func_literal",
+  ]);
+
+  /** Current nesting level during analysis */
+  private nesting = 0;
+  /** Current complexity score during analysis */
+  private complexity = 0;
+  /** Array of complexity details for the current function being analyzed */
+  private details: GoMetricsDetail[] = [];
+  /** The source code text being analyzed */
+  private sourceText: string;
+  /** Tree-sitter parser instance configured for Go */
+  private parser: Parser;
+  /** How func literals are reported */
+  private closureMode: GoClosureMode;
+  private literalFuncMode: GoLiteralFuncMode;
+  /** Whether node type histograms are collected */
+  private collectNodeCounts: boolean;
+  /** Line count above which files are parsed one declaration at a time (0: never) */
+  private streamingThreshold: number;
+  /** How `panic(...)` calls are counted */
+  private panicMode: GoPanicMode;
+  /** How function declarations are scored */
+  private scoring: GoScoring;
+  /** Lines whose function declarations are analyzed, or undefined for the whole file */
+  private lineRanges: readonly { start: number; end: number }[] | undefined;
+  /** Closure entries collected while analyzing the current function */
+  private closures: GoFunctionMetrics[] = [];
+  /** Enclosing function/closure scopes, innermost last */
+  private closureScopes: GoClosureScope[] = [];
+  /** Start offsets of func literals already reported as their own entry */
+  private reportedClosures = new Set<number>();
+  /** Receiver name and type of the method being analyzed, used to resolve `recv.Method()` calls */
+  private receiver: { name: string; type: string } | null = null;
+  /** Whether the walk is inside a `//metrics:disable` region, whose increments are not counted */
+  private disabled = false;
+
+  /**
+   * Creates a new instance of the Go cognitive complexity analyzer.
+   * Initializes the Tree-sitter parser with the Go language grammar.
+   *
+   * @param options - Optional analysis options
+   */
+  constructor(options: GoAnalyzerOptions = {}) {
+    this.parser = _parser;
+    this.sourceText = "";
+    this.closureMode = options.closureMode ?? "inline";
+    this.literalFuncMode = options.literalFuncMode ?? "attributed";
+    this.collectNodeCounts = options.collectNodeCounts ?? false;
+    this.streamingThreshold = options.streamingThreshold ?? 0;
+    this.panicMode = options.panicMode ?? "statement";
+    this.scoring = options.scoring ?? "cognitive";
+    this.lineRanges = options.lineRanges;
+  }
+
+  /**
+   * Analyzes all functions in the provided Go source code.
+   *
+   * This method parses the source code and identifies all function-like constructs
+   * (functions, methods) and calculates their cognitive complexity scores.
+   *
+   * @param sourceText - The complete Go source code to analyze
+   * @returns An array of complexity analysis results, one for each function found
+   *
+   * @example
+   * ```typescript
+   * const sourceCode = `
+   * package main
+   *
+   * func Add(a, b int) int {
+   *   if a < 0 || b < 0 {
+   *     return 0
+   *   }
+   *   return a + b
+   * }`;
+   * const results = analyzer.analyzeFunctions(sourceCode);
+   * // results[0].complexity would be 2 (if statement + logical OR)
+   * ```
+   */
+  public analyzeFunctions(sourceText: string): GoFunctionMetrics[] {
+    // Package-level closures are numbered across the file, as Go numbers them in `init`.
+    const packageScope: GoClosureScope = { name: "init", closureCount: 0 };
+    let functions: GoFunctionMetrics[];
+    if (
+      this.lineRanges !== undefined ||
+      (this.streamingThreshold > 0 && countLines(sourceText) > this.streamingThreshold)
+    ) {
+      functions = this.analyzeDeclarations(sourceText, packageScope);
+    } else {
+      this.sourceText = sourceText;
+      const tree = timePhase("parse", () => this.parser.parse(sourceText));
+      functions = this.analyzeTree(tree.rootNode, packageScope);
+    }
+    resolveGenericReceiverCalls(functions);
+    return functions;
+  }
+
+  /**
+   * Analyzes a large file one top-level declaration at a time: each declaration is parsed
+   * on its own and its tree released before the next, so memory use is bounded by the
+   * largest declaration rather than the file. Positions are shifted back to the file's.
+   * With line ranges, function declarations outside them are skipped unparsed; other
+   * declarations are always parsed, as their package-level closures are numbered across
+   * the file.
+   *
+   * @param sourceText - The complete Go source code to analyze
+   * @param packageScope - Numbers package-level closures across the file
+   * @returns The same results as analyzing the file at once
+   */
+  private analyzeDeclarations(
+    sourceText: string,
+    packageScope: GoClosureScope
+  ): GoFunctionMetrics[] {
+    const functions: GoFunctionMetrics[] = [];
+    for (const chunk of splitTopLevelDeclarations(sourceText)) {
+      if (this.lineRanges && FUNCTION_DECLARATION.test(chunk.text)) {
+        const end = chunk.row + countLines(chunk.text) - (chunk.text.endsWith("\n") ? 2 : 1);
+        if (!this.lineRanges.some((range) => range.start <= end && range.end >= chunk.row)) {
+          continue;
+        }
+      }
+      this.sourceText = chunk.text;
+      const tree = timePhase("parse", () => this.parser.parse(chunk.text));
+      for (const func of this.analyzeTree(tree.rootNode, packageScope)) {
+        func.startLine += chunk.row;
+        func.endLine += chunk.row;
+        for (const detail of func.details) {
+          detail.line += chunk.row;
+        }
+        for (const statement of func.switches ?? []) {
+          statement.line += chunk.row;
+        }
+        functions.push(func);
+      }
+    }
+    this.sourceText = sourceText;
+    return functions;
+  }
+
+  /**
+   * Analyzes every function and package-level closure of a parsed file or declaration.
+   *
+   * @param root - The root node of the syntax tree
+   * @param packageScope - Numbers package-level closures across the file
+   * @returns The functions found, in source order
+   */
+  private analyzeTree(root: Parser.SyntaxNode, packageScope: GoClosureScope): GoFunctionMetrics[] {
+    const functions: GoFunctionMetrics[] = [];
+
+    const visit = (node: Parser.SyntaxNode) => {
+      if (this.isFunctionDeclaration(node)) {
+        const result = this.analyzeFunction(node);
+        if (result) {
+          functions.push(result, ...this.closures);
+        }
+        // Go does not allow nested function_declaration or method_declaration
+        // inside function bodies, so there is no need to recurse further.
+        return;
+      }
+      if (node.type === "func_literal") {
+        functions.push(...this.analyzePackageClosure(node, packageScope));
+        return;
+      }
+
+      for (const child of node.children) {
+        visit(child);
+      }
+    };
+
+    visit(root);
+    if (this.scoring === "cyclomatic") {
+      this.scoreCyclomatic(root, functions);
+    }
+    return this.addEstimates(root, functions);
+  }
+
+  /**
+   * Replaces the complexity of each function and closure entry with its number of
+   * decision points, counted with the rules of {@link countCyclomatic} but without the 1
+   * it starts at, which a complexity base of 1 adds back. A func literal counts towards
+   * the entry enclosing it when the cognitive walk merges it there (see `closureMode` and
+   * `literalFuncMode`), so the default and gocyclo settings give gocyclo's values.
+   * Decisions inside `//metrics:disable` regions are not counted. The details still list
+   * the cognitive increments.
+   *
+   * @param root - The root node of the syntax tree
+   * @param functions - The functions found in the tree
+   */
+  private scoreCyclomatic(root: Parser.SyntaxNode, functions: GoFunctionMetrics[]): void {
+    // Decision points of each function and func literal, keyed by where it starts.
+    const counts = new Map<string, number>();
+    let depth = 0;
+    let disabled = false;
+
+    const count = (node: Parser.SyntaxNode): number => {
+      const isFunction = this.isFunctionDeclaration(node) || node.type === "func_literal";
+      if (node.type === "comment") {
+        const match = depth > 0 && GoMetricsAnalyzer.REGION_DIRECTIVE.exec(
+          this.sourceText.substring(node.startIndex, node.endIndex)
+        );
+        if (match) {
+          disabled = match[1] === "disable";
+        }
+        return 0;
+      }
+
+      // Functions start enabled; a closure starts as the region it is in, and region
+      // comments end with the block they are in, as in the cognitive walk.
+      const savedDisabled = disabled;
+      if (isFunction && depth === 0) {
+        disabled = false;
+      }
+      let decisions = !disabled && this.isCyclomaticDecision(node) ? 1 : 0;
+      if (isFunction) {
+        depth++;
+      }
+      for (const child of node.namedChildren) {
+        decisions += count(child);
+      }
+      if (isFunction || GoMetricsAnalyzer.REGION_SCOPES.has(node.type)) {
+        disabled = savedDisabled;
+      }
+      if (!isFunction) {
+        return decisions;
+      }
+
+      depth--;
+      counts.set(`${node.startPosition.row}:${node.startPosition.column}`, decisions);
+      return node.type === "func_literal" && this.isMergedLiteral(node) ? decisions : 0;
+    };
+
+    count(root);
+    for (const func of functions) {
+      const decisions = counts.get(`${func.startLine}:${func.startColumn}`);
+      if (decisions !== undefined) {
+        func.complexity = decisions;
+      }
+    }
+  }
+
+  /**
+   * Determines whether the cognitive walk merges a func literal into the function or
+   * closure enclosing it, besides or instead of reporting it as its own entry.
+   */
+  private isMergedLiteral(node: Parser.SyntaxNode): boolean {
+    if (this.closureMode === "both") {
+      return true;
+    }
+    return this.isCompositeLiteralValue(node)
+      ? this.literalFuncMode === "attributed"
+      : this.closureMode === "inline";
+  }
+
+  /**
+   * Handles syntax the parser did not understand, such as a language feature newer than
+   * the bundled grammar. Functions overlapping a syntax error are marked approximate, and
+   * function declarations that were not recognized at all get a token-based estimate
+   * instead of being left out.
+   *
+   * @param root - The root node of the syntax tree
+   * @param functions - The functions found in the tree, in source order
+   * @returns The functions with the estimates added, in source order
+   */
+  private addEstimates(root: Parser.SyntaxNode, functions: GoFunctionMetrics[]): GoFunctionMetrics[] {
+    const errors = root.descendantsOfType("ERROR");
+    if (errors.length === 0) {
+      return functions;
+    }
+    for (const func of functions) {
+      if (
+        errors.some(
+          (error) => error.startPosition.row <= func.endLine && error.endPosition.row >= func.startLine
+        )
+      ) {
+        func.approximate = true;
+      }
+    }
+
+    const parsedStarts = new Set(functions.map((func) => func.startLine));
+    const estimates: GoFunctionMetrics[] = [];
+    for (const chunk of splitTopLevelDeclarations(this.sourceText)) {
+      const estimate = estimateDeclaration(chunk.text);
+      if (!estimate) {
+        continue;
+      }
+      estimate.startLine += chunk.row;
+      estimate.endLine += chunk.row;
+      for (const detail of estimate.details) {
+        detail.line += chunk.row;
+      }
+      if (!parsedStarts.has(estimate.startLine)) {
+        estimates.push(estimate);
+      }
+    }
+    return estimates.length === 0
+      ? functions
+      : [...functions, ...estimates].sort((a, b) => a.startLine - b.startLine);
+  }
+
+  /**
+   * Finds the fluent-API building blocks of a file: methods that return their receiver
+   * type (builder steps) and method call chains. Chains are attributed to types later,
+   * across files, by matching method names against each type's fluent methods.
+   *
+   * @param sourceText - The complete Go source code to analyze
+   * @returns The fluent methods and call chains of the file
+   */
+  public findFluentUsage(sourceText: string): GoFluentUsage {
+    this.sourceText = sourceText;
+    const tree = this.parser.parse(sourceText);
+    const usage: GoFluentUsage = { fluentMethods: [], chains: [] };
+    const text = (node: Parser.SyntaxNode) =>
+      sourceText.substring(node.startIndex, node.endIndex);
+    // `*Builder[T]` and `Builder` name the same type for this purpose.
+    const baseType = (node: Parser.SyntaxNode) =>
+      text(node).replace(/^\*+\s*/, "").replace(/\[.*$/, "").trim();
+
+    const isChainLink = (node: Parser.SyntaxNode) =>
+      node.type === "call_expression" &&
+      node.childForFieldName("function")?.type === "selector_expression";
+
+    const visit = (node: Parser.SyntaxNode) => {
+      if (node.type === "method_declaration") {
+        const receiver = node.childForFieldName("receiver");
+        const receiverType = receiver ? this.findTypeInParameterList(receiver) : null;
+        let result = node.childForFieldName("result");
+        if (result?.type === "parameter_list") {
+          const params = result.namedChildren.filter((c) => c.type === "parameter_declaration");
+          result = params.length === 1 ? params[0].childForFieldName("type") : null;
+        }
+        const name = node.childForFieldName("name");
+        if (receiverType && result && name && baseType(result) === baseType(receiverType)) {
+          usage.fluentMethods.push({ type: baseType(receiverType), name: text(name) });
+        }
+      }
+
+      // Record a chain once, from its outermost call.
+      const parent = node.parent;
+      const isOutermost = !(
+        parent?.type === "selector_expression" && parent.parent && isChainLink(parent.parent)
+      );
+      if (isChainLink(node) && isOutermost) {
+        const methods: string[] = [];
+        let link: Parser.SyntaxNode | null = node;
+        while (link && isChainLink(link)) {
+          const selector: Parser.SyntaxNode = link.childForFieldName("function")!;
+          const field = selector.childForFieldName("field");
+          if (field) {
+            methods.unshift(text(field));
+          }
+          link = selector.childForFieldName("operand");
+        }
+        if (methods.length > 1) {
+          usage.chains.push({ methods, line: node.startPosition.row });
+        }
+      }
+
+      for (const child of node.namedChildren) {
+        visit(child);
+      }
+    };
+
+    visit(tree.rootNode);
+    return usage;
+  }
+
+  /**
+   * Counts each function's cyclomatic complexity with gocyclo's rules, for cross-checking
+   * the cognitive complexity against it. The count starts at 1 and adds one per `if`
+   * (each `else if` included), `for` or `range` loop, `case` or `select` case other than
+   * `default`, and `&&` or `||` operator. Func literals count towards the function
+   * declaring them, and package-level func literals are not reported, as in gocyclo.
+   *
+   * @param sourceText - The complete Go source code to analyze
+   * @returns One entry per function or method with a body, in source order
+   */
+  public countCyclomatic(sourceText: string): GoCyclomaticComplexity[] {
+    this.sourceText = sourceText;
+    return this.countCyclomaticTree(this.parser.parse(sourceText).rootNode);
+  }
+
+  /**
+   * Counts the cyclomatic complexity of each function declared in a parsed file or
+   * declaration, as {@link countCyclomatic} does.
+   *
+   * @param root - The root node of the syntax tree
+   * @returns One entry per function or method with a body, in source order
+   */
+  private countCyclomaticTree(root: Parser.SyntaxNode): GoCyclomaticComplexity[] {
+    const results: GoCyclomaticComplexity[] = [];
+
+    const count = (node: Parser.SyntaxNode): number => {
+      let complexity = this.isCyclomaticDecision(node) ? 1 : 0;
+      for (const child of node.namedChildren) {
+        complexity += count(child);
+      }
+      return complexity;
+    };
+
+    for (const node of root.namedChildren) {
+      const body = this.isFunctionDeclaration(node) ? this.getFunctionBody(node) : null;
+      if (body) {
+        results.push({
+          name: this.getFunctionName(node),
+          startLine: node.startPosition.row,
+          complexity: 1 + count(body),
+        });
+      }
+    }
+    return results;
+  }
+
+  /**
+   * Determines if a syntax node is a decision point for gocyclo: an `if`, a loop, a case
+   * other than `default`, or an `&&` or `||` operator.
+   */
+  private isCyclomaticDecision(node: Parser.SyntaxNode): boolean {
+    switch (node.type) {
+      case "if_statement":
+      case "for_statement":
+      case "expression_case":
+      case "type_case":
+      case "communication_case":
+        return true;
+      case "binary_expression": {
+        const operator = this.getBinaryOperator(node);
+        return operator === "&&" || operator === "||";
+      }
+      default:
+        return false;
+    }
+  }
+
+  /**
+   * Determines if a syntax node represents a function declaration.
+   *
+   * Checks for various Go function-like constructs including:
+   * - Regular functions (function_declaration)
+   * - Methods with receivers (method_declaration)
+   *
+   * Note: func_literal (closures/anonymous functions) are analyzed as part of
+   * their parent function's complexity unless `closureMode` reports them separately,
+   * or they are the value of a struct field or map entry.
+   *
+   * @param node - The syntax node to check
+   * @returns True if the node represents a function declaration
+   */
+  private isFunctionDeclaration(node: Parser.SyntaxNode): boolean {
+    return (
+      node.type === "function_declaration" || node.type === "method_declaration"
+    );
+  }
+
+  /**
+   * Analyzes the cognitive complexity of a single function.
+   *
+   * This method resets the analyzer state and processes the given function
+   * node to calculate its cognitive complexity. It extracts the function name,
+   * finds the function body, and recursively analyzes all statements within.
+   *
+   * @param node - The syntax node representing the function declaration
+   * @returns Complexity analysis result or null if the function has no body
+   */
+  private analyzeFunction(node: Parser.SyntaxNode): GoFunctionMetrics | null {
+    // Reset state for new function
+    this.nesting = 0;
+    this.complexity = 0;
+    this.details = [];
+    this.disabled = false;
+    this.closures = [];
+    this.reportedClosures.clear();
+
+    // Get function name
+    const functionName = this.getFunctionName(node);
+    this.closureScopes = [{ name: functionName, closureCount: 0 }];
+    const receiverName = this.getReceiverName(node);
+    this.receiver = receiverName && functionName.includes(".")
+      ? { name: receiverName, type: functionName.substring(0, functionName.lastIndexOf(".")) }
+      : null;
+
+    // Find the function body
+    const body = this.getFunctionBody(node);
+    if (!body) {
+      return null; // Interface method or declaration without body
+    }
+
+    // Analyze the function body
+    this.visit(body);
+
+    const metrics: GoFunctionMetrics = {
+      name: functionName,
+      complexity: this.complexity,
+      details: this.details,
+      startLine: node.startPosition.row,
+      endLine: node.endPosition.row,
+      startColumn: node.startPosition.column,
+      endColumn: node.endPosition.column,
+    };
+
+    metrics.maxConditionOperands = this.getMaxConditionOperands(body);
+    metrics.maxExpressionNesting = this.getMaxExpressionNesting(body);
+    metrics.maxClosureDepth = this.getMaxClosureDepth(body);
+    metrics.switches = this.collectSwitches(body);
+    metrics.returnCount = this.countReturns(body);
+    metrics.errorReturnCount = this.countErrorReturns(node, body);
+    Object.assign(metrics, this.measureBranchDensity(body));
+    metrics.operatorKinds = this.getOperatorKinds(body);
+    metrics.calls = this.collectCalls(body);
+    metrics.parameterCount = this.countParameters(node);
+    if (this.collectNodeCounts) {
+      metrics.nodeCounts = this.countNodeTypes(body);
+    }
+
+    if (receiverName) {
+      metrics.fieldAccessCount = this.countReceiverFieldAccesses(body, receiverName);
+      metrics.receiverUnused = !this.referencesIdentifier(body, receiverName);
+    }
+    if (node.type === "method_declaration") {
+      const receiver = node.childForFieldName("receiver");
+      metrics.pointerReceiver =
+        (receiver && this.findTypeInParameterList(receiver))?.type === "pointer_type";
+    } else {
+      const writes = this.countPointerParamWrites(node, body);
+      if (writes) {
+        metrics.pointerParamWrites = writes;
+      }
+    }
+
+    const expectation = this.getExpectation(node);
+    if (expectation) {
+      metrics.expectedComplexity = expectation;
+    }
+
+    // Closures finish (and are collected) innermost-first; report them in source order.
+    this.closures.sort((a, b) => a.startLine - b.startLine || a.startColumn - b.startColumn);
+
+    return metrics;
+  }
+
+  /**
+   * Analyzes a func literal in a package-level variable initializer, e.g. the handler of
+   * `var routes = []Route{{Path: "/", Handler: func(w http.ResponseWriter, r *http.Request) {...}}}`.
+   * Such literals have no enclosing function to be merged into, so they are always
+   * reported as their own entries, named `init.func1`, `init.func2` as Go names them.
+   *
+   * @param node - The func_literal node
+   * @param packageScope - The file's package-level closure scope
+   * @returns The literal's entry, followed by the entries of closures reported inside it
+   */
+  private analyzePackageClosure(
+    node: Parser.SyntaxNode,
+    packageScope: GoClosureScope
+  ): GoFunctionMetrics[] {
+    this.nesting = 0;
+    this.complexity = 0;
+    this.details = [];
+    this.disabled = false;
+    this.closures = [];
+    this.reportedClosures.clear();
+    this.closureScopes = [packageScope];
+    this.receiver = null;
+
+    this.analyzeClosure(node);
+    return this.closures.sort((a, b) => a.startLine - b.startLine || a.startColumn - b.startColumn);
+  }
+
+  /**
+   * Returns whether a func literal is a value of a composite literal: a struct field
+   * (`Handler: func() {...}`), a map entry (`"get": func() {...}`), or an element of a
+   * slice or array (`[]func(){func() {...}}`). Such literals define behavior, as a method
+   * would, rather than being called in place.
+   *
+   * @param node - The func_literal node
+   * @returns true when the literal is an element or a keyed element's value
+   */
+  private isCompositeLiteralValue(node: Parser.SyntaxNode): boolean {
+    let element = node;
+    if (element.parent?.type === "literal_element") {
+      element = element.parent;
+    }
+    const keyed = element.parent;
+    if (keyed?.type === "literal_value") {
+      return true;
+    }
+    if (keyed?.type !== "keyed_element") {
+      return false;
+    }
+    // The value is what follows the colon (comments may sit on either side of it).
+    const colon = keyed.children.find((child) => child.type === ":");
+    return colon !== undefined && element.startIndex > colon.startIndex;
+  }
+
+  /**
+   * Analyzes a func literal as its own entry, independently of the enclosing function.
+   *
+   * The closure's body is measured from nesting level 0, exactly as if it were a named
+   * function. Closures are named after Go's runtime convention: `Outer.func1`, `Outer.func2`
+   * for closures directly inside `Outer`, and `Outer.func1.1` for a closure nested in
+   * `Outer.func1`. The enclosing function's analysis state is restored afterwards.
+   *
+   * @param node - The func_literal node
+   */
+  private analyzeClosure(node: Parser.SyntaxNode): void {
+    this.reportedClosures.add(node.startIndex);
+
+    const scope = this.closureScopes[this.closureScopes.length - 1];
+    scope.closureCount++;
+    const name = this.closureScopes.length === 1
+      ? `${scope.name}.func${scope.closureCount}`
+      : `${scope.name}.${scope.closureCount}`;
+
+    const savedNesting = this.nesting;
+    const savedComplexity = this.complexity;
+    const savedDetails = this.details;
+    // A closure inside a disabled region starts disabled; its own region comments end with it.
+    const savedDisabled = this.disabled;
+    this.nesting = 0;
+    this.complexity = 0;
+    this.details = [];
+    this.closureScopes.push({ name, closureCount: 0 });
+
+    const body = node.childForFieldName("body");
+    if (body) {
+      this.visit(body);
+    }
+
+    this.closures.push({
+      name,
+      complexity: this.complexity,
+      details: this.details,
+      startLine: node.startPosition.row,
+      endLine: node.endPosition.row,
+      startColumn: node.startPosition.column,
+      endColumn: node.endPosition.column,
+      maxConditionOperands: body ? this.getMaxConditionOperands(body) : 0,
+      maxExpressionNesting: body ? this.getMaxExpressionNesting(body) : 0,
+      maxClosureDepth: body ? this.getMaxClosureDepth(body) : 0,
+      switches: body ? this.collectSwitches(body) : [],
+      returnCount: body ? this.countReturns(body) : 0,
+      errorReturnCount: body ? this.countErrorReturns(node, body) : undefined,
+      ...(body ? this.measureBranchDensity(body) : {}),
+      operatorKinds: body ? this.getOperatorKinds(body) : [],
+      calls: body ? this.collectCalls(body) : [],
+      parameterCount: this.countParameters(node),
+      nodeCounts: this.collectNodeCounts && body ? this.countNodeTypes(body) : undefined,
+    });
+
+    this.closureScopes.pop();
+    this.nesting = savedNesting;
+    this.complexity = savedComplexity;
+    this.details = savedDetails;
+    this.disabled = savedDisabled;
+  }
+
+  /**
+   * Finds the largest number of boolean operands in any single condition of a function body.
+   *
+   * Each maximal `&&`/`||` expression counts its leaf operands, looking through parentheses
+   * and `!`, so `a && b || !(c && d)` has four operands. An `if` condition without logical
+   * operators counts as one. Logical expressions nested inside an operand (for example in a
+   * call argument) are measured as separate conditions.
+   *
+   * @param body - The function body block
+   * @returns The maximum operand count, or 0 if the body has no conditions
+   */
+  private getMaxConditionOperands(body: Parser.SyntaxNode): number {
+    let max = 0;
+
+    const walk = (node: Parser.SyntaxNode) => {
+      if (node.type === "binary_expression" && this.getBinaryOperator(node) !== null) {
+        max = Math.max(max, countOperands(node));
+        return;
+      }
+      if (node.type === "if_statement" && node.childForFieldName("condition")) {
+        max = Math.max(max, 1);
+      }
+      for (const child of node.namedChildren) {
+        walk(child);
+      }
+    };
+
+    const countOperands = (node: Parser.SyntaxNode): number => {
+      if (node.type === "binary_expression" && this.getBinaryOperator(node) !== null) {
+        const left = node.childForFieldName("left");
+        const right = node.childForFieldName("right");
+        return (left ? countOperands(left) : 0) + (right ? countOperands(right) : 0);
+      }
+      if (node.type === "parenthesized_expression" && node.firstNamedChild) {
+        return countOperands(node.firstNamedChild);
+      }
+      if (node.type === "unary_expression" && node.child(0)?.type === "!") {
+        const operand = node.childForFieldName("operand");
+        if (operand) {
+          return countOperands(operand);
+        }
+      }
+      // A leaf operand; it may still contain unrelated conditions (e.g. `f(a && b)`).
+      for (const child of node.namedChildren) {
+        walk(child);
+      }
+      return 1;
+    };
+
+    walk(body);
+    return max;
+  }
 
   /**
-   * Creates a new instance of the Go cognitive complexity analyzer.
-   * Initializes the Tree-sitter parser with the Go language grammar.
+   * Finds the deepest nesting of logical expressions within each other, as opposed to the
+   * nesting of blocks: `a && b` is 1, `a && (b || c)` and `a && b || c` are 2 (the `&&`
+   * groups inside the `||`), and `(a || b) && (c || d)` is 2. A chain of the same operator,
+   * `a && b && c`, counts once, parenthesized or not. Statements in a func literal do not
+   * nest in the expression the literal appears in.
+   *
+   * @param body - The function body block
+   * @returns The deepest nesting, or 0 if the body has no logical operators
    */
-  constructor() {
-    this.parser = _parser;
-    this.sourceText = "";
+  private getMaxExpressionNesting(body: Parser.SyntaxNode): number {
+    let max = 0;
+
+    const depth = (node: Parser.SyntaxNode): number => {
+      if (node.type === "func_literal") {
+        return 0;
+      }
+      let inner = 0;
+      for (const child of node.namedChildren) {
+        inner = Math.max(inner, depth(child));
+      }
+      const operator = node.type === "binary_expression" ? this.getBinaryOperator(node) : null;
+      if (operator === null) {
+        return inner;
+      }
+      let parent = node.parent;
+      while (parent?.type === "parenthesized_expression") {
+        parent = parent.parent;
+      }
+      const chained =
+        parent?.type === "binary_expression" && this.getBinaryOperator(parent) === operator;
+      const nesting = chained ? inner : inner + 1;
+      max = Math.max(max, nesting);
+      return nesting;
+    };
+
+    depth(body);
+    return max;
   }
 
   /**
-   * Analyzes all functions in the provided Go source code.
+   * Finds the deepest nesting of func literals in a function body: a callback passed
+   * inside another callback counts 2. Control flow between the literals does not count.
    *
-   * This method parses the source code and identifies all function-like constructs
-   * (functions, methods) and calculates their cognitive complexity scores.
+   * @param body - The function body block
+   * @returns The maximum closure depth, or 0 if the body has no func literals
+   */
+  private getMaxClosureDepth(body: Parser.SyntaxNode): number {
+    const walk = (node: Parser.SyntaxNode, depth: number): number => {
+      const current = node.type === "func_literal" ? depth + 1 : depth;
+      let max = current;
+      for (const child of node.namedChildren) {
+        max = Math.max(max, walk(child, current));
+      }
+      return max;
+    };
+    return walk(body, 0);
+  }
+
+  /**
+   * Lists the switch, type switch, and select statements of a function body with their
+   * number of clauses, `default` included. A long switch is a maintenance concern even when
+   * each case is trivial. Statements inside func literals belong to the literal.
    *
-   * @param sourceText - The complete Go source code to analyze
-   * @returns An array of complexity analysis results, one for each function found
+   * @param body - The function body block
+   * @returns The statements in source order
+   */
+  private collectSwitches(body: Parser.SyntaxNode): GoSwitchSize[] {
+    const switches: GoSwitchSize[] = [];
+    const walk = (node: Parser.SyntaxNode) => {
+      if (node.type === "func_literal") {
+        return;
+      }
+      const kind = GoMetricsAnalyzer.SWITCH_KINDS[node.type];
+      if (kind) {
+        switches.push({
+          kind,
+          caseCount: node.namedChildren.filter((child) =>
+            GoMetricsAnalyzer.CASE_CLAUSE_TYPES.has(child.type)
+          ).length,
+          line: node.startPosition.row,
+          column: node.startPosition.column,
+        });
+      }
+      for (const child of node.namedChildren) {
+        walk(child);
+      }
+    };
+    walk(body);
+    return switches;
+  }
+
+  /**
+   * Counts the return points of a function body. Naked returns in functions with named
+   * results (`return` alone) count like any other return. Returns inside func literals
+   * exit the closure, not this function, so they are not counted.
    *
-   * @example
-   * ```typescript
-   * const sourceCode = `
-   * package main
+   * @param body - The function body block
+   * @returns The number of return statements
+   */
+  private countReturns(body: Parser.SyntaxNode): number {
+    const walk = (node: Parser.SyntaxNode): number => {
+      if (node.type === "func_literal") {
+        return 0;
+      }
+      let count =
+        node.type === "return_statement" ||
+        (this.panicMode === "exit" && this.isBuiltinCall(node, "panic"))
+          ? 1
+          : 0;
+      for (const child of node.namedChildren) {
+        count += walk(child);
+      }
+      return count;
+    };
+    return walk(body);
+  }
+
+  /**
+   * Counts the returns of a function whose last result is an `error` that hand back an
+   * error, the function's error surface. A return is counted when the expression in the
+   * error position is anything but `nil`, and when a single call forwards several results
+   * (`return load(path)`), since the callee's error passes through. Returns inside func
+   * literals belong to the closure, as with {@link countReturns}.
    *
-   * func Add(a, b int) int {
-   *   if a < 0 || b < 0 {
-   *     return 0
-   *   }
-   *   return a + b
-   * }`;
-   * const results = analyzer.analyzeFunctions(sourceCode);
-   * // results[0].complexity would be 2 (if statement + logical OR)
-   * ```
+   * @param node - The function declaration, method declaration, or func literal
+   * @param body - The function body block
+   * @returns The count, or undefined when the function does not return an error
    */
-  public analyzeFunctions(sourceText: string): GoFunctionMetrics[] {
-    this.sourceText = sourceText;
-    const tree = this.parser.parse(sourceText);
-    const functions: GoFunctionMetrics[] = [];
+  private countErrorReturns(node: Parser.SyntaxNode, body: Parser.SyntaxNode): number | undefined {
+    const resultTypes = this.getResultTypes(node);
+    const last = resultTypes[resultTypes.length - 1];
+    if (!last || this.sourceText.substring(last.startIndex, last.endIndex) !== "error") {
+      return undefined;
+    }
 
-    const visit = (node: Parser.SyntaxNode) => {
-      if (this.isFunctionDeclaration(node)) {
-        const result = this.analyzeFunction(node);
-        if (result) {
-          functions.push(result);
+    const walk = (current: Parser.SyntaxNode): number => {
+      if (current.type === "func_literal") {
+        return 0;
+      }
+      let count = 0;
+      if (current.type === "return_statement") {
+        const values =
+          current.namedChildren.find((c) => c.type === "expression_list")?.namedChildren ?? [];
+        if (values.length === resultTypes.length) {
+          const error = values[values.length - 1];
+          count = this.sourceText.substring(error.startIndex, error.endIndex) === "nil" ? 0 : 1;
+        } else if (values.length === 1 && values[0].type === "call_expression") {
+          count = 1;
         }
-        // Go does not allow nested function_declaration or method_declaration
-        // inside function bodies, so there is no need to recurse further.
+      }
+      for (const child of current.namedChildren) {
+        count += walk(child);
+      }
+      return count;
+    };
+    return walk(body);
+  }
+
+  /**
+   * Lists the result types of a function, one entry per result: `(a, b int, err error)`
+   * gives `int`, `int`, `error`, and a single unparenthesized result gives itself.
+   *
+   * @param node - The function declaration, method declaration, or func literal
+   */
+  private getResultTypes(node: Parser.SyntaxNode): Parser.SyntaxNode[] {
+    const result = node.childForFieldName("result");
+    if (!result) {
+      return [];
+    }
+    if (result.type !== "parameter_list") {
+      return [result];
+    }
+    const types: Parser.SyntaxNode[] = [];
+    for (const declaration of result.namedChildren) {
+      const type = declaration.childForFieldName("type");
+      if (declaration.type === "parameter_declaration" && type) {
+        const names = declaration.namedChildren.filter((c) => c.type === "identifier").length;
+        for (let i = 0; i < Math.max(names, 1); i++) {
+          types.push(type);
+        }
+      }
+    }
+    return types;
+  }
+
+  /**
+   * Counts the statements and branches of a function body, for branch density. A labeled
+   * statement counts once, as the statement it labels, and empty statements not at all;
+   * neither do init clauses and post statements such as `i := 0` and `i++` in `for i := 0; i < n; i++`.
+   * Statements inside func literals belong to the closure, as with returns.
+   *
+   * @param body - The function body block
+   * @returns The statement and branch counts, and branches per statement rounded to 0.01
+   */
+  private measureBranchDensity(
+    body: Parser.SyntaxNode
+  ): Pick<GoFunctionMetrics, "statementCount" | "branchCount" | "branchDensity"> {
+    let statements = 0;
+    let branches = 0;
+    const walk = (node: Parser.SyntaxNode) => {
+      if (node.type === "func_literal") {
         return;
       }
+      const isStatement =
+        (node.type.endsWith("_statement") ||
+          GoMetricsAnalyzer.DECLARATION_STATEMENTS.has(node.type)) &&
+        node.type !== "labeled_statement" &&
+        node.type !== "empty_statement" &&
+        node.parent?.type !== "for_clause" &&
+        !this.isInitClause(node);
+      if (isStatement) {
+        statements++;
+      }
+      if (GoMetricsAnalyzer.BRANCH_TYPES.has(node.type)) {
+        branches++;
+      }
+      for (const child of node.namedChildren) {
+        walk(child);
+      }
+    };
+    walk(body);
+    return {
+      statementCount: statements,
+      branchCount: branches,
+      branchDensity: statements > 0 ? Math.round((branches / statements) * 100) / 100 : 0,
+    };
+  }
 
-      for (const child of node.children) {
-        visit(child);
+  /**
+   * Finds the kinds of operators a function body uses: arithmetic (`+`, `%`, `++`, `-=`),
+   * comparison (`==`, `<`), logical (`&&`, `!`), bitwise (`&`, `<<`, `&^`, unary `^`), and
+   * channel (`<-` sends and receives). Pointer `*` and `&` are not counted. Operators inside
+   * func literals belong to the closure, as for return points.
+   *
+   * @param body - The function body block
+   * @returns The distinct kinds, sorted alphabetically
+   */
+  private getOperatorKinds(body: Parser.SyntaxNode): string[] {
+    const kinds = new Set<string>();
+    const walk = (node: Parser.SyntaxNode): void => {
+      if (node.type === "func_literal") {
+        return;
+      }
+      let operator: string | undefined;
+      if (node.type === "binary_expression") {
+        operator = node.childForFieldName("operator")?.type;
+      } else if (node.type === "unary_expression") {
+        operator = `unary ${node.childForFieldName("operator")?.type}`;
+      } else if (node.type === "assignment_statement") {
+        // Compound assignments (`+=`, `<<=`) apply their operator; plain `=` applies none.
+        operator = node.childForFieldName("operator")?.type.replace(/=$/, "");
+      } else if (node.type === "inc_statement" || node.type === "dec_statement") {
+        operator = "+";
+      } else if (node.type === "send_statement") {
+        operator = "<-";
+      }
+      const kind = operator !== undefined ? GoMetricsAnalyzer.OPERATOR_KINDS[operator] : undefined;
+      if (kind) {
+        kinds.add(kind);
+      }
+      for (const child of node.namedChildren) {
+        walk(child);
       }
     };
+    walk(body);
+    return [...kinds].sort();
+  }
 
-    visit(tree.rootNode);
-    return functions;
+  /**
+   * Counts the parameters of a function, method, or func literal. Each name of a grouped
+   * declaration (`a, b int`) counts, an unnamed parameter (`func(int)`) counts once, and
+   * a variadic parameter counts once. The method receiver is not a parameter.
+   *
+   * @param node - The function_declaration, method_declaration, or func_literal node
+   * @returns The number of parameters
+   */
+  private countParameters(node: Parser.SyntaxNode): number {
+    const parameters = node.childForFieldName("parameters");
+    let count = 0;
+    for (const child of parameters?.namedChildren ?? []) {
+      if (child.type === "parameter_declaration") {
+        const names = child.namedChildren.filter((c) => c.type === "identifier").length;
+        count += Math.max(names, 1);
+      } else if (child.type === "variadic_parameter_declaration") {
+        count++;
+      }
+    }
+    return count;
   }
 
   /**
-   * Determines if a syntax node represents a function declaration.
+   * Collects the callees of a function body that can be resolved to entries of this file:
+   * plain calls `f()` by function name, and calls on the receiver `r.M()` as `Type.M`.
+   * Calls inside func literals are included, since they run on behalf of the function.
+   * Package-qualified and other selector calls cannot be resolved and are skipped.
    *
-   * Checks for various Go function-like constructs including:
-   * - Regular functions (function_declaration)
-   * - Methods with receivers (method_declaration)
+   * @param body - The function body block
+   * @returns Distinct callee names in first-call order
+   */
+  private collectCalls(body: Parser.SyntaxNode): string[] {
+    const calls = new Set<string>();
+    const text = (node: Parser.SyntaxNode) =>
+      this.sourceText.substring(node.startIndex, node.endIndex);
+    const walk = (node: Parser.SyntaxNode) => {
+      const callee = node.type === "call_expression" ? node.childForFieldName("function") : null;
+      if (callee?.type === "identifier") {
+        calls.add(text(callee));
+      } else if (callee?.type === "selector_expression" && this.receiver) {
+        const operand = callee.childForFieldName("operand");
+        const field = callee.childForFieldName("field");
+        if (operand?.type === "identifier" && field && text(operand) === this.receiver.name) {
+          calls.add(`${this.receiver.type}.${text(field)}`);
+        }
+      }
+      for (const child of node.namedChildren) {
+        walk(child);
+      }
+    };
+    walk(body);
+    return [...calls];
+  }
+
+  /**
+   * Builds a histogram of the named syntax nodes in a function body, closures included.
+   * Anonymous tokens (punctuation, keywords) are not counted.
    *
-   * Note: func_literal (closures/anonymous functions) are analyzed
-   * as part of their parent function's complexity.
+   * @param body - The function body block
+   * @returns Node counts keyed by tree-sitter node type
+   */
+  private countNodeTypes(body: Parser.SyntaxNode): Record<string, number> {
+    const counts: Record<string, number> = {};
+    const walk = (node: Parser.SyntaxNode) => {
+      counts[node.type] = (counts[node.type] ?? 0) + 1;
+      for (const child of node.namedChildren) {
+        walk(child);
+      }
+    };
+    walk(body);
+    return counts;
+  }
+
+  /**
+   * Looks for a `//metrics:expect` directive in the doc comment directly above a declaration.
    *
-   * @param node - The syntax node to check
-   * @returns True if the node represents a function declaration
+   * The doc comment is the run of `comment` siblings ending on the line right before the
+   * declaration; a blank line breaks the run, matching how `go doc` associates comments.
+   *
+   * @param node - The function or method declaration syntax node
+   * @returns The parsed expectation, or null if none is declared
    */
-  private isFunctionDeclaration(node: Parser.SyntaxNode): boolean {
-    return (
-      node.type === "function_declaration" || node.type === "method_declaration"
-    );
+  private getExpectation(node: Parser.SyntaxNode): GoComplexityExpectation | null {
+    let expectedRow = node.startPosition.row - 1;
+    let comment = node.previousNamedSibling;
+    while (comment && comment.type === "comment" && comment.endPosition.row === expectedRow) {
+      const text = this.sourceText.substring(comment.startIndex, comment.endIndex);
+      const match = GoMetricsAnalyzer.EXPECT_DIRECTIVE.exec(text);
+      if (match) {
+        return {
+          operator: match[1] === "=" ? "==" : (match[1] as "<=" | "<" | "=="),
+          value: parseInt(match[2], 10),
+        };
+      }
+      expectedRow = comment.startPosition.row - 1;
+      comment = comment.previousNamedSibling;
+    }
+    return null;
   }
 
   /**
-   * Analyzes the cognitive complexity of a single function.
+   * Returns the receiver identifier of a method declaration (e.g. `c` in `func (c *Calc)`),
+   * or null for plain functions, unnamed receivers, and the blank identifier `_`.
    *
-   * This method resets the analyzer state and processes the given function
-   * node to calculate its cognitive complexity. It extracts the function name,
-   * finds the function body, and recursively analyzes all statements within.
+   * @param node - The function or method declaration syntax node
+   * @returns The receiver name or null
+   */
+  private getReceiverName(node: Parser.SyntaxNode): string | null {
+    if (node.type !== "method_declaration") { return null; }
+    const receiver = node.childForFieldName("receiver");
+    if (!receiver) { return null; }
+    for (const child of receiver.namedChildren) {
+      if (child.type !== "parameter_declaration") { continue; }
+      const nameNode = child.childForFieldName("name");
+      if (!nameNode) { return null; }
+      const name = this.sourceText.substring(nameNode.startIndex, nameNode.endIndex);
+      return name === "_" ? null : name;
+    }
+    return null;
+  }
+
+  /**
+   * Counts the distinct receiver fields referenced via `recv.field` selectors in a method body.
    *
-   * @param node - The syntax node representing the function declaration
-   * @returns Complexity analysis result or null if the function has no body
+   * Method calls on the receiver (`recv.Method()`) are not field accesses and are skipped.
+   * Shadowing of the receiver name is not tracked, so the result is an approximation.
+   *
+   * @param body - The method body block
+   * @param receiverName - The receiver identifier to match selector operands against
+   * @returns The number of distinct fields accessed
    */
-  private analyzeFunction(node: Parser.SyntaxNode): GoFunctionMetrics | null {
-    // Reset state for new function
-    this.nesting = 0;
-    this.complexity = 0;
-    this.details = [];
+  private countReceiverFieldAccesses(
+    body: Parser.SyntaxNode,
+    receiverName: string
+  ): number {
+    const fields = new Set<string>();
+    const walk = (node: Parser.SyntaxNode) => {
+      if (node.type === "selector_expression") {
+        const operand = node.childForFieldName("operand");
+        const field = node.childForFieldName("field");
+        const isMethodCall =
+          node.parent?.type === "call_expression" &&
+          node.parent.childForFieldName("function")?.startIndex === node.startIndex;
+        if (
+          operand?.type === "identifier" &&
+          field &&
+          !isMethodCall &&
+          this.sourceText.substring(operand.startIndex, operand.endIndex) === receiverName
+        ) {
+          fields.add(this.sourceText.substring(field.startIndex, field.endIndex));
+        }
+      }
+      for (const child of node.namedChildren) {
+        walk(child);
+      }
+    };
+    walk(body);
+    return fields.size;
+  }
 
-    // Get function name
-    const functionName = this.getFunctionName(node);
+  /**
+   * Tells whether an identifier is referenced anywhere in a body, closures included.
+   *
+   * @param body - The function body block
+   * @param name - The identifier to look for
+   */
+  private referencesIdentifier(body: Parser.SyntaxNode, name: string): boolean {
+    const walk = (node: Parser.SyntaxNode): boolean =>
+      (node.type === "identifier" &&
+        this.sourceText.substring(node.startIndex, node.endIndex) === name) ||
+      node.namedChildren.some(walk);
+    return walk(body);
+  }
 
-    // Find the function body
-    const body = this.getFunctionBody(node);
-    if (!body) {
-      return null; // Interface method or declaration without body
+  /**
+   * Counts the distinct fields a plain function writes through its first parameter when
+   * that parameter is a pointer to a type of the package (`*T`, not `*pkg.T` or `*T[K]`):
+   * the targets of assignments and `++`/`--` whose selector starts at the parameter, so
+   * `cfg.Port = 80` and `cfg.TLS.Enabled = true` write `Port` and `TLS`. Like receiver
+   * field accesses, shadowing is not tracked.
+   *
+   * @param node - The function declaration syntax node
+   * @param body - The function body block
+   * @returns The writes, or undefined when the first parameter does not qualify or is not written
+   */
+  private countPointerParamWrites(
+    node: Parser.SyntaxNode,
+    body: Parser.SyntaxNode
+  ): GoPointerParamWrites | undefined {
+    const first = node
+      .childForFieldName("parameters")
+      ?.namedChildren.find((c) => c.type === "parameter_declaration");
+    const nameNode = first?.childForFieldName("name");
+    const typeNode = first?.childForFieldName("type");
+    const pointee = typeNode?.type === "pointer_type" ? typeNode.namedChildren[0] : undefined;
+    if (!nameNode || pointee?.type !== "type_identifier") {
+      return undefined;
+    }
+    const type = this.sourceText.substring(pointee.startIndex, pointee.endIndex);
+    const parameter = this.sourceText.substring(nameNode.startIndex, nameNode.endIndex);
+    if (parameter === "_") {
+      return undefined;
     }
 
-    // Analyze the function body
-    this.visit(body);
-
-    return {
-      name: functionName,
-      complexity: this.complexity,
-      details: this.details,
-      startLine: node.startPosition.row,
-      endLine: node.endPosition.row,
-      startColumn: node.startPosition.column,
-      endColumn: node.endPosition.column,
+    const fields = new Set<string>();
+    const addTarget = (target: Parser.SyntaxNode) => {
+      let selector: Parser.SyntaxNode | null = target;
+      while (selector?.type === "selector_expression") {
+        const operand: Parser.SyntaxNode | null = selector.childForFieldName("operand");
+        if (operand?.type === "identifier") {
+          const field = selector.childForFieldName("field");
+          if (field && this.sourceText.substring(operand.startIndex, operand.endIndex) === parameter) {
+            fields.add(this.sourceText.substring(field.startIndex, field.endIndex));
+          }
+          return;
+        }
+        selector = operand;
+      }
+    };
+    const walk = (current: Parser.SyntaxNode) => {
+      if (current.type === "assignment_statement") {
+        current.childForFieldName("left")?.namedChildren.forEach(addTarget);
+      } else if (current.type === "inc_statement" || current.type === "dec_statement") {
+        const target = current.namedChildren[0];
+        if (target) {
+          addTarget(target);
+        }
+      }
+      current.namedChildren.forEach(walk);
     };
+    walk(body);
+    return fields.size > 0 ? { parameter, type, fieldCount: fields.size } : undefined;
   }
 
   /**
@@ -243,14 +1938,14 @@ export class GoMetricsAnalyzer {
             const innerType = typeNode.firstNamedChild;
             receiverType = innerType?.type === "type_identifier"
               ? this.sourceText.substring(innerType.startIndex, innerType.endIndex)
-              : this.sourceText
-                  .substring(typeNode.startIndex, typeNode.endIndex)
-                  .replace(/^\*+\s*/, "")
-                  .trim();
+              : formatReceiverType(
+                  this.sourceText
+                    .substring(typeNode.startIndex, typeNode.endIndex)
+                    .replace(/^\*+\s*/, "")
+                );
           } else {
-            receiverType = this.sourceText.substring(
-              typeNode.startIndex,
-              typeNode.endIndex
+            receiverType = formatReceiverType(
+              this.sourceText.substring(typeNode.startIndex, typeNode.endIndex)
             );
           }
         }
@@ -319,13 +2014,50 @@ export class GoMetricsAnalyzer {
    *
    * This method traverses the AST and calls checkComplexity for each node
    * to determine if it contributes to the cognitive complexity score.
-   * It skips nested function declarations to avoid double-counting.
+   * It skips nested function declarations to avoid double-counting. Comments are
+   * visited in source order, so `//metrics:disable` and `//metrics:enable` comments
+   * switch counting off and on for the code between them.
    *
    * @param node - The current syntax node being visited
    */
   private visit(node: Parser.SyntaxNode): void {
+    if (node.type === "comment") {
+      const match = GoMetricsAnalyzer.REGION_DIRECTIVE.exec(
+        this.sourceText.substring(node.startIndex, node.endIndex)
+      );
+      if (match) {
+        this.disabled = match[1] === "disable";
+      }
+      return;
+    }
+
+    if (this.isInitClause(node)) {
+      // `if v, ok := m[k]; ok {`: the init clause runs before the decision, so it is
+      // not nested inside it (only a closure in it can add complexity at all).
+      this.nesting--;
+      for (const child of node.children) {
+        this.visit(child);
+      }
+      this.nesting++;
+      return;
+    }
+
+    const literalValue = node.type === "func_literal" && this.isCompositeLiteralValue(node);
+    if (node.type === "func_literal" && (this.closureMode !== "inline" || literalValue)) {
+      // In `both` mode the literal is also merged below; the inline pass revisits it
+      // (and any closures inside it), so only report each literal once. Composite literal
+      // values are reported separately even in `inline` mode, and are merged as well
+      // when they are attributed to their function.
+      if (!this.reportedClosures.has(node.startIndex)) {
+        this.analyzeClosure(node);
+      }
+      if (this.closureMode !== "both" && !(literalValue && this.literalFuncMode === "attributed")) {
+        return;
+      }
+    }
+
     const baseIncrement = this.getComplexityIncrement(node);
-    if (baseIncrement > 0) {
+    if (baseIncrement > 0 && !this.disabled) {
       // Add nesting level to the increment for cognitive complexity
       const increment = baseIncrement + this.nesting;
       const reason = this.getComplexityReason(node);
@@ -340,9 +2072,12 @@ export class GoMetricsAnalyzer {
       });
     }
 
-    // Conditionally bump nesting, iterate children once, then restore.
+    // Conditionally bump nesting, iterate children once, then restore. Region comments
+    // end with the block they are in, so an unterminated `//metrics:disable` in an `if`
+    // body or a merged func literal does not reach the code after it.
     const nests = this.increasesNesting(node);
     if (nests) { this.nesting++; }
+    const savedDisabled = this.disabled;
 
     // In Go, if_statement carries its else/else-if branch as the "alternative" field
     // (a direct if_statement or block child, with no wrapping else_clause node).
@@ -362,6 +2097,7 @@ export class GoMetricsAnalyzer {
     }
 
     if (nests) { this.nesting--; }
+    if (GoMetricsAnalyzer.REGION_SCOPES.has(node.type)) { this.disabled = savedDisabled; }
   }
 
   /**
@@ -383,14 +2119,16 @@ export class GoMetricsAnalyzer {
     const reason = isElseIf ? "else if clause" : "else clause";
 
     // Flat +1 for else/else-if — no nesting penalty.
-    this.complexity += 1;
-    this.details.push({
-      increment: 1,
-      reason,
-      line: node.startPosition.row,
-      column: node.startPosition.column,
-      nesting: this.nesting,
-    });
+    if (!this.disabled) {
+      this.complexity += 1;
+      this.details.push({
+        increment: 1,
+        reason,
+        line: node.startPosition.row,
+        column: node.startPosition.column,
+        nesting: this.nesting,
+      });
+    }
 
     if (isElseIf) {
       // else-if: visit the inner if_statement's children at the CURRENT nesting level
@@ -472,9 +2210,12 @@ export class GoMetricsAnalyzer {
       case "goto_statement":
         return 1;
 
-      // Recover calls (similar to catch)
+      // Recover calls (similar to catch), and panics when they count as branches
       case "call_expression":
-        return this.isRecoverCall(node) ? 1 : 0;
+        return this.isBuiltinCall(node, "recover") ||
+          (this.panicMode === "branch" && this.isBuiltinCall(node, "panic"))
+          ? 1
+          : 0;
 
       default:
         return 0;
@@ -482,18 +2223,20 @@ export class GoMetricsAnalyzer {
   }
 
   /**
-   * Checks if a call expression is a recover() call.
+   * Checks if a node is a call of a builtin such as recover() or panic().
    *
    * Uses childForFieldName for O(1) field access rather than a linear
    * scan of all children.
    *
-   * @param node - The call expression node to check
-   * @returns True if this is a recover() call
+   * @param node - The node to check
+   * @param name - The builtin's name
+   * @returns True if this is a call of the builtin
    */
-  private isRecoverCall(node: Parser.SyntaxNode): boolean {
+  private isBuiltinCall(node: Parser.SyntaxNode, name: string): boolean {
+    if (node.type !== "call_expression") { return false; }
     const funcNode = node.childForFieldName("function");
     if (!funcNode || funcNode.type !== "identifier") { return false; }
-    return this.sourceText.substring(funcNode.startIndex, funcNode.endIndex) === "recover";
+    return this.sourceText.substring(funcNode.startIndex, funcNode.endIndex) === name;
   }
 
   /**
@@ -561,9 +2304,9 @@ export class GoMetricsAnalyzer {
       case "goto_statement":
         return "goto statement";
       case "call_expression":
-        // `getComplexityIncrement` returns 1 only when `isRecoverCall` is true,
-        // so this path is only reached for recover() calls.
-        return "recover call";
+        // `getComplexityIncrement` returns 1 only for recover() calls and, when panics
+        // count as branches, panic() calls.
+        return this.isBuiltinCall(node, "recover") ? "recover call" : "panic call";
       /* c8 ignore next 2 */
       default:
         return "unknown complexity source";
@@ -583,6 +2326,24 @@ export class GoMetricsAnalyzer {
     return GoMetricsAnalyzer.NESTING_TYPES.has(node.type);
   }
 
+  /**
+   * Determines if a node is the init clause of an `if`, `switch`, or type switch, or of a
+   * three-clause `for` loop, which are visited after their statement increased nesting.
+   *
+   * @param node - The syntax node to check
+   * @returns True if the node is the statement's `initializer`
+   */
+  private isInitClause(node: Parser.SyntaxNode): boolean {
+    const parent = node.parent;
+    if (!parent || !GoMetricsAnalyzer.INIT_CLAUSE_PARENTS.has(parent.type)) {
+      return false;
+    }
+    const initializer = parent.childForFieldName("initializer");
+    return initializer !== null &&
+      initializer.startIndex === node.startIndex &&
+      initializer.endIndex === node.endIndex;
+  }
+
   /**
    * Static factory method to analyze Go source code.
    *
@@ -591,6 +2352,7 @@ export class GoMetricsAnalyzer {
    * analyzer instances.
    *
    * @param sourceText - The complete Go source code to analyze
+   * @param options - Optional analysis options (e.g. how closures are reported)
    * @returns An array of complexity analysis results for all functions found
    *
    * @example
@@ -601,8 +2363,31 @@ export class GoMetricsAnalyzer {
    * });
    * ```
    */
-  public static analyzeFile(sourceText: string): GoFunctionMetrics[] {
-    const analyzer = new GoMetricsAnalyzer();
+  public static analyzeFile(
+    sourceText: string,
+    options?: GoAnalyzerOptions
+  ): GoFunctionMetrics[] {
+    const analyzer = new GoMetricsAnalyzer(options);
     return analyzer.analyzeFunctions(sourceText);
   }
+
+  /**
+   * Static helper that finds fluent methods and call chains in one call.
+   *
+   * @param sourceText - The complete Go source code to analyze
+   * @returns The fluent methods and call chains of the file
+   */
+  public static findFluentUsage(sourceText: string): GoFluentUsage {
+    return new GoMetricsAnalyzer().findFluentUsage(sourceText);
+  }
+
+  /**
+   * Static helper that counts gocyclo-style cyclomatic complexity in one call.
+   *
+   * @param sourceText - The complete Go source code to analyze
+   * @returns One entry per function or method with a body
+   */
+  public static countCyclomatic(sourceText: string): GoCyclomaticComplexity[] {
+    return new GoMetricsAnalyzer().countCyclomatic(sourceText);
+  }
 }
@@ -0,0 +1,181 @@
+/**
+ * @fileoverview Go Template Complexity Analyzer
+ *
+ * This module measures the branching logic embedded in Go `text/template` and
+ * `html/template` files. Templates have no syntax tree grammar available, so the
+ * analyzer scans `{{ ... }}` actions directly and applies the cognitive complexity
+ * rules to template control structures:
+ * - `{{if}}`, `{{range}}` and `{{with}}` add +1 plus their nesting level
+ * - `{{else}}`, `{{else if}}` and `{{else with}}` add a flat +1
+ *
+ * Each `{{define}}` or `{{block}}` is reported as its own entry, named after the
+ * template it defines; actions outside them are reported as `(template)`.
+ */
+
+/** A single complexity increment, matching the shape of the other analyzers. */
+interface GoTemplateMetricsDetail {
+  increment: number;
+  reason: string;
+  /** Line of the action (0-based) */
+  line: number;
+  /** Column of the action's opening delimiter (0-based) */
+  column: number;
+  nesting: number;
+}
+
+/** Complexity results for one template definition. */
+interface GoTemplateFunctionMetrics {
+  /** The defined template's name, or `(template)` for actions outside any definition */
+  name: string;
+  complexity: number;
+  details: GoTemplateMetricsDetail[];
+  startLine: number;
+  endLine: number;
+  startColumn: number;
+  endColumn: number;
+}
+
+/** Matches an action, including `{{-`/`-}}` trim markers. */
+const ACTION = /\{\{(?:-\s)?\s*([\s\S]*?)\s*(?:\s-)?\}\}/g;
+
+/** Name of the entry holding actions outside `{{define}}`/`{{block}}`. */
+const TOP_LEVEL_NAME = "(template)";
+
+/** Block kinds that an `{{end}}` can close. */
+type BlockKind = "if" | "range" | "with" | "define";
+
+/** A definition whose actions are being collected. */
+interface OpenDefinition {
+  metrics: GoTemplateFunctionMetrics;
+  /** Control blocks open inside this definition, innermost last */
+  blocks: BlockKind[];
+}
+
+/**
+ * Analyzer for Go template files.
+ */
+export class GoTemplateMetricsAnalyzer {
+  /** Start offset of every line, for offset → position conversion */
+  private lineStarts: number[] = [];
+
+  /**
+   * Analyzes every template definition in a template file.
+   *
+   * @param sourceText - The template source
+   * @returns One entry per `{{define}}`/`{{block}}`, plus `(template)` when actions
+   *   appear outside them; in source order
+   */
+  public analyzeFunctions(sourceText: string): GoTemplateFunctionMetrics[] {
+    this.lineStarts = [0];
+    for (let i = 0; i < sourceText.length; i++) {
+      if (sourceText[i] === "\n") {
+        this.lineStarts.push(i + 1);
+      }
+    }
+
+    const lastLine = this.lineStarts.length - 1;
+    const topLevel: OpenDefinition = {
+      metrics: this.createMetrics(TOP_LEVEL_NAME, 0, 0),
+      blocks: [],
+    };
+    topLevel.metrics.endLine = lastLine;
+    topLevel.metrics.endColumn = sourceText.length - this.lineStarts[lastLine];
+    let topLevelHasActions = false;
+
+    const definitions: GoTemplateFunctionMetrics[] = [];
+    const stack: OpenDefinition[] = [topLevel];
+
+    for (const match of sourceText.matchAll(ACTION)) {
+      const body = match[1];
+      if (body.startsWith("/*")) {
+        continue; // comment
+      }
+      const { line, column } = this.getPosition(match.index ?? 0);
+      const current = stack[stack.length - 1];
+      if (current === topLevel) {
+        topLevelHasActions = true;
+      }
+      const keyword = /^[a-z]+/.exec(body)?.[0];
+
+      if (keyword === "define" || keyword === "block") {
+        const name = /^"([^"]*)"/.exec(body.substring(keyword.length).trimStart())?.[1];
+        const definition: OpenDefinition = {
+          metrics: this.createMetrics(name ?? keyword, line, column),
+          blocks: ["define"],
+        };
+        definitions.push(definition.metrics);
+        stack.push(definition);
+      } else if (keyword === "if" || keyword === "range" || keyword === "with") {
+        const nesting = current.blocks.filter((b) => b !== "define").length;
+        const reason = `${keyword} action (nesting: ${nesting})`;
+        this.addDetail(current, 1 + nesting, reason, line, column, nesting);
+        current.blocks.push(keyword);
+      } else if (keyword === "else") {
+        const nesting = Math.max(current.blocks.filter((b) => b !== "define").length - 1, 0);
+        const chained = /^else\s+(if|with)\b/.exec(body)?.[1];
+        const reason = chained ? `else ${chained} action` : "else action";
+        this.addDetail(current, 1, reason, line, column, nesting);
+      } else if (keyword === "end") {
+        const closed = current.blocks.pop();
+        if (closed === "define" && current !== topLevel) {
+          const end = this.getPosition((match.index ?? 0) + match[0].length);
+          current.metrics.endLine = end.line;
+          current.metrics.endColumn = end.column;
+          stack.pop();
+        }
+      }
+    }
+
+    const results = topLevelHasActions ? [topLevel.metrics, ...definitions] : definitions;
+    return results.sort((a, b) => a.startLine - b.startLine || a.startColumn - b.startColumn);
+  }
+
+  private createMetrics(name: string, line: number, column: number): GoTemplateFunctionMetrics {
+    return {
+      name,
+      complexity: 0,
+      details: [],
+      startLine: line,
+      endLine: line,
+      startColumn: column,
+      endColumn: column,
+    };
+  }
+
+  private addDetail(
+    definition: OpenDefinition,
+    increment: number,
+    reason: string,
+    line: number,
+    column: number,
+    nesting: number
+  ): void {
+    definition.metrics.complexity += increment;
+    definition.metrics.details.push({ increment, reason, line, column, nesting });
+  }
+
+  /** Converts a character offset to a 0-based line and column. */
+  private getPosition(offset: number): { line: number; column: number } {
+    let low = 0;
+    let high = this.lineStarts.length - 1;
+    while (low < high) {
+      const mid = (low + high + 1) >> 1;
+      if (this.lineStarts[mid] <= offset) {
+        low = mid;
+      } else {
+        high = mid - 1;
+      }
+    }
+    return { line: low, column: offset - this.lineStarts[low] };
+  }
+
+  /**
+   * Static helper that analyzes a template file in one call.
+   *
+   * @param sourceText - The template source
+   * @returns Results per template definition
+   */
+  public static analyzeFile(sourceText: string): GoTemplateFunctionMetrics[] {
+    return new GoTemplateMetricsAnalyzer().analyzeFunctions(sourceText);
+  }
+}
@@ -11,6 +11,7 @@
 
 import Parser from "tree-sitter";
 import Java from "tree-sitter-java";
+import { timePhase } from "../analysisTiming";
 
 // Module-level singleton: parser initialization is expensive, so we reuse one instance per language.
 const _parser = new Parser();
@@ -118,7 +119,7 @@ export class JavaMetricsAnalyzer {
    */
   public analyzeFunctions(sourceText: string): JavaFunctionMetrics[] {
     this.sourceText = sourceText;
-    const tree = _parser.parse(sourceText);
+    const tree = timePhase("parse", () => _parser.parse(sourceText));
     const functions: JavaFunctionMetrics[] = [];
 
     const visit = (node: Parser.SyntaxNode) => {
@@ -0,0 +1,51 @@
+/**
+ * @fileoverview Java Rules for the Rule-Based Engine
+ *
+ * The Java grammar mapped to the declarative {@link ComplexityRules} table, analyzed by
+ * the rule-based engine when `codeMetrics.analysis.engine` is `rules`. It scores Java
+ * code exactly like the hand-written JavaMetricsAnalyzer, which proves the table covers it.
+ */
+
+import Parser from "tree-sitter";
+import Java from "tree-sitter-java";
+import { ComplexityRules, RuleBasedAnalyzer } from "../ruleBasedAnalyzer";
+import { RawFunctionMetrics } from "../metricsAnalyzerFactory";
+
+// Module-level singleton: parser initialization is expensive, so we reuse one instance per language.
+const _parser = new Parser();
+_parser.setLanguage(Java);
+
+/** How Java syntax contributes to cognitive complexity. */
+export const JAVA_RULES: ComplexityRules = {
+  functions: ["method_declaration", "constructor_declaration", "compact_constructor_declaration"],
+  containers: ["class_declaration", "interface_declaration", "enum_declaration", "record_declaration"],
+  structural: {
+    if_statement: "if statement",
+    while_statement: "while loop",
+    for_statement: "for loop",
+    enhanced_for_statement: "enhanced for loop",
+    do_statement: "do-while loop",
+    catch_clause: "catch clause",
+    switch_expression: "switch statement",
+    lambda_expression: "lambda expression",
+  },
+  flat: {
+    ternary_expression: "ternary expression",
+  },
+  conditional: { type: "if_statement", alternativeField: "alternative" },
+  binaryExpression: "binary_expression",
+  logicalOperators: ["&&", "||"],
+};
+
+/** Java analyzer backed by the rule-based engine. */
+export class JavaRulesAnalyzer {
+  /**
+   * Static factory method to analyze Java source code.
+   *
+   * @param sourceText - The complete Java source code to analyze
+   * @returns An array of complexity analysis results for all methods found
+   */
+  public static analyzeFile(sourceText: string): RawFunctionMetrics[] {
+    return new RuleBasedAnalyzer(_parser, JAVA_RULES).analyzeFunctions(sourceText);
+  }
+}
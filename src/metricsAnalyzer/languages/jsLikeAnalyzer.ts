@@ -9,6 +9,7 @@
  */
 
 import Parser from "tree-sitter";
+import { timePhase } from "../analysisTiming";
 
 /**
  * Represents a single complexity detail for a specific JS/TS code construct.
@@ -46,6 +47,8 @@ export interface JsLikeFunctionMetrics {
   startColumn: number;
   /** Column number where the function definition ends (0-based) */
   endColumn: number;
+  /** Deepest nesting of ternaries and `&&`/`||`/`??` groups within each other in one expression */
+  maxExpressionNesting: number;
 }
 
 /**
@@ -122,7 +125,7 @@ export class JsLikeMetricsAnalyzer {
    */
   public analyzeFunctions(sourceText: string): JsLikeFunctionMetrics[] {
     this.sourceText = sourceText;
-    const tree = this.parser.parse(sourceText);
+    const tree = timePhase("parse", () => this.parser.parse(sourceText));
     const functions: JsLikeFunctionMetrics[] = [];
     this.collectFunctions(tree.rootNode, functions);
     return functions;
@@ -166,6 +169,7 @@ export class JsLikeMetricsAnalyzer {
         endLine: node.endPosition.row,
         startColumn: node.startPosition.column,
         endColumn: node.endPosition.column,
+        maxExpressionNesting: this.getMaxExpressionNesting(node),
       };
       functions.push(metrics);
 
@@ -348,6 +352,54 @@ export class JsLikeMetricsAnalyzer {
     }
   }
 
+  /**
+   * Finds the deepest nesting of conditional expressions within each other, as opposed to
+   * the nesting of blocks: `a ? b : c` is 1, `a ? (b ? c : d) : e` and `a && (b || c)` are
+   * 2. A chain of the same logical operator counts once, as does a ternary continuing in
+   * the alternative of another (`a ? b : c ? d : e`, an else-if). Nested functions are
+   * measured as part of this one, but do not nest in the expression they appear in.
+   *
+   * @param func - The function node
+   * @returns The deepest nesting, or 0 if the function has no conditional expressions
+   */
+  private getMaxExpressionNesting(func: Parser.SyntaxNode): number {
+    let max = 0;
+
+    const depth = (node: Parser.SyntaxNode): number => {
+      let inner = 0;
+      for (const child of node.namedChildren) {
+        inner = Math.max(inner, depth(child));
+      }
+      if (node !== func && this.isNestedFunction(node)) {
+        return 0;
+      }
+      let parent = node.parent;
+      while (parent?.type === "parenthesized_expression") {
+        parent = parent.parent;
+      }
+      let chained: boolean;
+      if (node.type === "ternary_expression") {
+        chained =
+          parent?.type === "ternary_expression" && parent.childForFieldName("alternative") === node;
+      } else if (
+        (node.type === "binary_expression" || node.type === "logical_expression") &&
+        this.getOperator(node) !== null
+      ) {
+        chained =
+          (parent?.type === "binary_expression" || parent?.type === "logical_expression") &&
+          this.getOperator(parent) === this.getOperator(node);
+      } else {
+        return inner;
+      }
+      const nesting = chained ? inner : inner + 1;
+      max = Math.max(max, nesting);
+      return nesting;
+    };
+
+    depth(func);
+    return max;
+  }
+
   /**
    * Checks if a node is a nested function definition.
    */
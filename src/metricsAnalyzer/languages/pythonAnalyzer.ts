@@ -10,6 +10,7 @@
  */
 
 import Parser from "tree-sitter";
+import { timePhase } from "../analysisTiming";
 const Python = require("tree-sitter-python"); // noqa
 
 // Module-level singleton: parser initialization is expensive, so we reuse one instance per language.
@@ -112,7 +113,7 @@ export class PythonMetricsAnalyzer {
    */
   public analyzeFunctions(sourceText: string): PythonFunctionMetrics[] {
     this.sourceText = sourceText;
-    const tree = this.parser.parse(sourceText);
+    const tree = timePhase("parse", () => this.parser.parse(sourceText));
     const functions: PythonFunctionMetrics[] = [];
 
     const visit = (node: Parser.SyntaxNode, className?: string) => {
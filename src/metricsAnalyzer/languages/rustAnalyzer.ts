@@ -10,6 +10,7 @@
  */
 
 import Parser from "tree-sitter";
+import { timePhase } from "../analysisTiming";
 const Rust = require("tree-sitter-rust"); // noqa
 
 // Module-level singleton: parser initialization is expensive, so we reuse one instance per language.
@@ -133,7 +134,7 @@ export class RustMetricsAnalyzer {
    */
   public analyzeFunctions(sourceText: string): RustFunctionMetrics[] {
     this.sourceText = sourceText;
-    const tree = this.parser.parse(sourceText);
+    const tree = timePhase("parse", () => this.parser.parse(sourceText));
     const functions: RustFunctionMetrics[] = [];
 
     const visit = (node: Parser.SyntaxNode) => {
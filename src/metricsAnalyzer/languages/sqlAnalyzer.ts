@@ -0,0 +1,520 @@
+/**
+ * @fileoverview SQL Complexity Analyzer
+ *
+ * This module measures the cognitive complexity of SQL stored routines. It reads the
+ * `CREATE [OR REPLACE] FUNCTION` and `CREATE [OR REPLACE] PROCEDURE` statements and `DO`
+ * blocks of a file and analyzes their bodies; plain queries outside routines are not
+ * reported. No syntax tree grammar for SQL is available, so the analyzer tokenizes the
+ * source, skipping comments and strings.
+ *
+ * Bodies are read in the PL/pgSQL dialect, the PostgreSQL procedural language, which
+ * also covers `LANGUAGE sql` bodies; routines in other languages (e.g. `plpython3u` or
+ * C) are skipped. Within a body:
+ * - `IF`, `LOOP`, `WHILE ... LOOP`, `FOR ... LOOP`, and `FOREACH ... LOOP` add +1 plus
+ *   their nesting level; `ELSIF` and `ELSE` add a flat +1
+ * - each `WHEN` of a `CASE`, in statements and expressions alike, adds a flat +1
+ * - each `WHEN` handler of an `EXCEPTION` section adds +1 plus its nesting level
+ * - `EXIT WHEN` and `CONTINUE WHEN` add a flat +1
+ * - each sequence of `AND` or `OR` adds +1 (the `AND` of `BETWEEN` is not counted)
+ * - the branches of `IF`, loops, `CASE`, and exception handlers add a level of nesting
+ *
+ * Routines are named as declared, e.g. `billing.apply_discount`; `DO` blocks, which have
+ * no name, are numbered `<do block N>`. Other dialects, such as T-SQL, would read their
+ * routines and bodies differently and get their own body reader.
+ */
+
+/** A single complexity increment, matching the shape of the other analyzers. */
+interface SqlMetricsDetail {
+  increment: number;
+  reason: string;
+  /** Line of the construct (0-based) */
+  line: number;
+  /** Column of the construct (0-based) */
+  column: number;
+  nesting: number;
+}
+
+/** Complexity results for one routine or `DO` block. */
+interface SqlFunctionMetrics {
+  /** Routine name as declared, e.g. `billing.apply_discount`, or `<do block N>` */
+  name: string;
+  complexity: number;
+  details: SqlMetricsDetail[];
+  startLine: number;
+  endLine: number;
+  startColumn: number;
+  endColumn: number;
+}
+
+type TokenType = "word" | "op" | "open" | "close" | "comma" | "semicolon" | "literal";
+
+interface Token {
+  type: TokenType;
+  /** Upper-cased word, unquoted identifier, or operator text; empty for literals */
+  value: string;
+  /** Whether a word was a quoted identifier, which is never a keyword */
+  quoted?: boolean;
+  start: number;
+  end: number;
+  /** Start of a string literal's contents, after its opening quote */
+  contentStart?: number;
+  /** End of a string literal's contents, before its closing quote */
+  contentEnd?: number;
+}
+
+/** A construct of a body being read, innermost last. */
+interface Frame {
+  kind: "if" | "loop" | "case" | "block";
+  /** Whether a `WHILE`/`FOR` loop's header is being read, before its `LOOP` keyword */
+  header?: boolean;
+  /** Whether the exception handlers of a block are being read */
+  handlers?: boolean;
+}
+
+/** Languages whose bodies are read as PL/pgSQL. */
+const PLPGSQL_LANGUAGES: ReadonlySet<string> = new Set(["PLPGSQL", "SQL"]);
+
+/** Loop keywords that start a loop header ending in `LOOP`, with their detail reasons. */
+const LOOP_HEADERS: Readonly<Record<string, string>> = {
+  WHILE: "while loop",
+  FOR: "for loop",
+  FOREACH: "foreach loop",
+};
+
+/** Keywords after which a new statement starts. */
+const STATEMENT_STARTERS: ReadonlySet<string> = new Set([
+  "THEN", "ELSE", "LOOP", "BEGIN", "DECLARE",
+]);
+
+/** Operators, longest first. */
+const OPERATOR = /^(?:<<|>>|:=|::|=>|<>|!=|<=|>=|\|\||[-+*/%^=<>!|&~@#.:[\]])/;
+
+/**
+ * Analyzer for SQL files with PL/pgSQL routines.
+ */
+export class SqlMetricsAnalyzer {
+  /** Start offset of every line, for offset → position conversion */
+  private lineStarts: number[] = [];
+  private source = "";
+
+  /**
+   * Analyzes every stored function, procedure, and `DO` block of a SQL file.
+   *
+   * @param sourceText - The SQL source
+   * @returns One entry per routine with a PL/pgSQL or SQL body, in source order
+   */
+  public analyzeFunctions(sourceText: string): SqlFunctionMetrics[] {
+    this.source = sourceText;
+    this.lineStarts = [0];
+    for (let i = 0; i < sourceText.length; i++) {
+      if (sourceText[i] === "\n") {
+        this.lineStarts.push(i + 1);
+      }
+    }
+
+    const results: SqlFunctionMetrics[] = [];
+    const tokens = this.tokenize(0, sourceText.length);
+    let doBlocks = 0;
+    let start = 0;
+    while (start < tokens.length) {
+      let end = start;
+      while (end < tokens.length && tokens[end].type !== "semicolon") {
+        end++;
+      }
+      const statement = tokens.slice(start, end);
+      const terminator = tokens[end] ?? statement[statement.length - 1];
+      const routine = this.readRoutine(statement);
+      if (routine && terminator) {
+        const name = routine.name ?? `<do block ${++doBlocks}>`;
+        results.push(this.analyzeRoutine(name, statement[0], terminator, routine.body));
+      }
+      start = end + 1;
+    }
+    return results;
+  }
+
+  /**
+   * Reads the name and body of a `CREATE FUNCTION`, `CREATE PROCEDURE`, or `DO`
+   * statement whose body is PL/pgSQL or SQL.
+   *
+   * @returns The routine, with no name for a `DO` block, or undefined for other statements
+   */
+  private readRoutine(statement: Token[]): { name?: string; body: Token } | undefined {
+    const words = statement.map((token) => (token.type === "word" && !token.quoted ? token.value : ""));
+    let language: string | undefined;
+    const languageIndex = words.indexOf("LANGUAGE");
+    if (languageIndex !== -1) {
+      const next = statement[languageIndex + 1];
+      language =
+        next?.type === "literal"
+          ? this.source.substring(next.contentStart!, next.contentEnd!).toUpperCase()
+          : next?.value;
+    }
+
+    if (words[0] === "DO") {
+      const body = statement.find((token) => token.type === "literal");
+      return body && PLPGSQL_LANGUAGES.has(language ?? "PLPGSQL") ? { body } : undefined;
+    }
+    if (words[0] !== "CREATE") {
+      return undefined;
+    }
+    let index = 1;
+    if (words[1] === "OR" && words[2] === "REPLACE") {
+      index = 3;
+    }
+    if (words[index] !== "FUNCTION" && words[index] !== "PROCEDURE") {
+      return undefined;
+    }
+
+    // The name runs up to the parameter list: `schema.name(` or `"Quoted Name"(`.
+    const nameParts: string[] = [];
+    for (index++; index < statement.length && statement[index].type !== "open"; index++) {
+      const token = statement[index];
+      if (token.type === "word") {
+        nameParts.push(
+          token.quoted ? token.value : this.source.substring(token.start, token.end)
+        );
+      }
+    }
+
+    const asIndex = words.indexOf("AS", index);
+    const body = asIndex === -1 ? undefined : statement[asIndex + 1];
+    // A second string after the body names a C library symbol: `AS 'file', 'symbol'`.
+    if (
+      body?.type !== "literal" ||
+      statement[asIndex + 2]?.type === "comma" ||
+      !PLPGSQL_LANGUAGES.has(language ?? "PLPGSQL")
+    ) {
+      return undefined;
+    }
+    return { name: nameParts.join("."), body };
+  }
+
+  /**
+   * Analyzes the body of one routine as PL/pgSQL.
+   *
+   * @param name - The entry name
+   * @param first - The first token of the routine's statement
+   * @param last - The statement's terminating semicolon, or its last token
+   * @param body - The string literal holding the body
+   */
+  private analyzeRoutine(
+    name: string,
+    first: Token,
+    last: Token,
+    body: Token
+  ): SqlFunctionMetrics {
+    const details: SqlMetricsDetail[] = [];
+    const tokens = this.tokenize(body.contentStart!, body.contentEnd!);
+    const frames: Frame[] = [];
+    const nesting = () =>
+      frames.filter((frame) => frame.kind !== "block" || frame.handlers).length;
+    const addIncrement = (increment: number, reason: string, token: Token, level: number) => {
+      const { line, column } = this.getPosition(token.start);
+      details.push({ increment, reason, line, column, nesting: level });
+    };
+    const addNested = (reason: string, token: Token) => {
+      const level = nesting();
+      addIncrement(1 + level, reason, token, level);
+    };
+    const addFlat = (reason: string, token: Token) => addIncrement(1, reason, token, nesting());
+    const top = () => frames[frames.length - 1];
+    const popTo = (kind: Frame["kind"]) => {
+      const index = frames.map((frame) => frame.kind).lastIndexOf(kind);
+      if (index !== -1) {
+        frames.length = index;
+      }
+    };
+
+    // The last logical operator of each parenthesis level, and whether a `BETWEEN`
+    // is waiting for its `AND`.
+    const sequences: { operator?: string; between: boolean }[] = [{ between: false }];
+    const resetSequence = () => {
+      sequences[sequences.length - 1].operator = undefined;
+    };
+    let statementStart = true;
+    let statementWord: string | undefined;
+
+    for (let i = 0; i < tokens.length; i++) {
+      const token = tokens[i];
+      const word = token.type === "word" && !token.quoted ? token.value : "";
+      const atStart = statementStart;
+      statementStart = false;
+      if (atStart && word) {
+        statementWord = word;
+      }
+
+      if (token.type === "semicolon") {
+        statementStart = true;
+        statementWord = undefined;
+        resetSequence();
+        continue;
+      }
+      if (token.type === "open") {
+        sequences.push({ between: false });
+        continue;
+      }
+      if (token.type === "close") {
+        if (sequences.length > 1) {
+          sequences.pop();
+        }
+        continue;
+      }
+      if (token.type === "op" && token.value === ">>") {
+        statementStart = atStart || tokens[i - 2]?.value === "<<"; // after a `<<label>>`
+        continue;
+      }
+      if (!word) {
+        continue;
+      }
+
+      if (word === "AND" || word === "OR") {
+        const sequence = sequences[sequences.length - 1];
+        if (word === "AND" && sequence.between) {
+          sequence.between = false;
+        } else if (sequence.operator !== word) {
+          sequence.operator = word;
+          addFlat(`logical ${word} operator`, token);
+        }
+        continue;
+      }
+      if (word === "BETWEEN") {
+        sequences[sequences.length - 1].between = true;
+        continue;
+      }
+      // Keywords of statements and clauses start a new expression.
+      if (STATEMENT_STARTERS.has(word) || word === "WHEN" || word === "ELSIF" || word === "ELSEIF") {
+        resetSequence();
+      }
+
+      switch (word) {
+        case "IF":
+          if (atStart) {
+            addNested("if statement", token);
+            frames.push({ kind: "if" });
+          }
+          break;
+        case "ELSIF":
+        case "ELSEIF":
+          if (top()?.kind === "if") {
+            addFlat("elsif clause", token);
+          }
+          break;
+        case "ELSE":
+          if (top()?.kind === "if") {
+            addFlat("else clause", token);
+          }
+          statementStart = true;
+          break;
+        case "CASE":
+          frames.push({ kind: "case" });
+          break;
+        case "WHEN": {
+          const frame = top();
+          if (frame?.kind === "case") {
+            addFlat("when clause", token);
+          } else if (frame?.kind === "block" && frame.handlers) {
+            // The handler adds no nesting to itself, only to its statements.
+            const level = nesting() - 1;
+            addIncrement(1 + level, "exception handler", token, level);
+          } else if (statementWord === "EXIT" || statementWord === "CONTINUE") {
+            addFlat(`${statementWord.toLowerCase()} when`, token);
+          }
+          break;
+        }
+        case "LOOP": {
+          const frame = top();
+          if (frame?.kind === "loop" && frame.header) {
+            frame.header = false;
+          } else if (atStart) {
+            addNested("loop", token);
+            frames.push({ kind: "loop" });
+          }
+          statementStart = true;
+          break;
+        }
+        case "WHILE":
+        case "FOR":
+        case "FOREACH":
+          if (atStart) {
+            addNested(LOOP_HEADERS[word], token);
+            frames.push({ kind: "loop", header: true });
+          }
+          break;
+        case "BEGIN":
+          if (atStart) {
+            frames.push({ kind: "block" });
+          }
+          statementStart = true;
+          break;
+        case "EXCEPTION": {
+          const frame = top();
+          if (atStart && frame?.kind === "block") {
+            frame.handlers = true;
+          }
+          break;
+        }
+        case "END": {
+          const next = tokens[i + 1];
+          const closes = next?.type === "word" && !next.quoted ? next.value : "";
+          if (closes === "IF" || closes === "LOOP" || closes === "CASE") {
+            popTo(closes === "IF" ? "if" : closes === "LOOP" ? "loop" : "case");
+            i++;
+          } else if (top()?.kind === "case") {
+            frames.pop(); // the END of a CASE expression
+          } else {
+            popTo("block");
+          }
+          break;
+        }
+        default:
+          if (STATEMENT_STARTERS.has(word)) {
+            statementStart = true;
+          }
+      }
+    }
+
+    const startPosition = this.getPosition(first.start);
+    const endPosition = this.getPosition(last.end);
+    return {
+      name,
+      complexity: details.reduce((sum, detail) => sum + detail.increment, 0),
+      details,
+      startLine: startPosition.line,
+      endLine: endPosition.line,
+      startColumn: startPosition.column,
+      endColumn: endPosition.column,
+    };
+  }
+
+  /**
+   * Splits a range of the source into tokens. Comments are dropped, and strings
+   * (`'...'`, `E'...'`, and dollar-quoted `$tag$...$tag$`) become literal tokens that
+   * record where their contents are, so routine bodies can be tokenized in turn.
+   */
+  private tokenize(from: number, to: number): Token[] {
+    const source = this.source;
+    const tokens: Token[] = [];
+    const push = (type: TokenType, value: string, start: number, end: number) =>
+      tokens.push({ type, value, start, end });
+    let i = from;
+    while (i < to) {
+      const ch = source[i];
+      const start = i;
+      if (ch === " " || ch === "\t" || ch === "\r" || ch === "\n") {
+        i++;
+      } else if (source.startsWith("--", i)) {
+        while (i < to && source[i] !== "\n") {
+          i++;
+        }
+      } else if (source.startsWith("/*", i)) {
+        i = this.skipBlockComment(i + 2, to);
+      } else if (ch === "'" || ((ch === "E" || ch === "e") && source[i + 1] === "'")) {
+        const escapes = ch !== "'";
+        const contentStart = i + (escapes ? 2 : 1);
+        i = contentStart;
+        while (i < to) {
+          if (escapes && source[i] === "\\") {
+            i += 2;
+          } else if (source[i] === "'" && source[i + 1] === "'") {
+            i += 2;
+          } else if (source[i] === "'") {
+            break;
+          } else {
+            i++;
+          }
+        }
+        const contentEnd = Math.min(i, to);
+        i = Math.min(i + 1, to);
+        tokens.push({ type: "literal", value: "", start, end: i, contentStart, contentEnd });
+      } else if (ch === "$" && /^\$(?:[A-Za-z_][A-Za-z0-9_]*)?\$/.test(source.substring(i, i + 64))) {
+        const tag = /^\$(?:[A-Za-z_][A-Za-z0-9_]*)?\$/.exec(source.substring(i, i + 64))![0];
+        const contentStart = i + tag.length;
+        const close = source.indexOf(tag, contentStart);
+        const contentEnd = close === -1 || close > to ? to : close;
+        i = Math.min(contentEnd + tag.length, to);
+        tokens.push({ type: "literal", value: "", start, end: i, contentStart, contentEnd });
+      } else if (ch === '"') {
+        i++;
+        while (i < to && !(source[i] === '"' && source[i + 1] !== '"')) {
+          i += source[i] === '"' ? 2 : 1;
+        }
+        const name = source.substring(start + 1, i).replace(/""/g, '"');
+        i = Math.min(i + 1, to);
+        tokens.push({ type: "word", value: name, quoted: true, start, end: i });
+      } else if (/[A-Za-z_]/.test(ch)) {
+        while (i < to && /[\w$]/.test(source[i])) {
+          i++;
+        }
+        push("word", source.substring(start, i).toUpperCase(), start, i);
+      } else if (/[0-9$]/.test(ch)) {
+        // Numbers and positional parameters such as `$1`
+        i++;
+        while (i < to && /[\w.]/.test(source[i])) {
+          i++;
+        }
+        push("literal", "", start, i);
+      } else if (ch === "(") {
+        push("open", ch, i, ++i);
+      } else if (ch === ")") {
+        push("close", ch, i, ++i);
+      } else if (ch === ",") {
+        push("comma", ch, i, ++i);
+      } else if (ch === ";") {
+        push("semicolon", ch, i, ++i);
+      } else {
+        const operator = OPERATOR.exec(source.substring(i, i + 2));
+        if (operator) {
+          i += operator[0].length;
+          push("op", operator[0], start, i);
+        } else {
+          i++;
+        }
+      }
+    }
+    return tokens;
+  }
+
+  /** Skips a block comment from after its `/*`, including nested block comments. */
+  private skipBlockComment(from: number, to: number): number {
+    let depth = 1;
+    let i = from;
+    while (i < to && depth > 0) {
+      if (this.source.startsWith("/*", i)) {
+        depth++;
+        i += 2;
+      } else if (this.source.startsWith("*/", i)) {
+        depth--;
+        i += 2;
+      } else {
+        i++;
+      }
+    }
+    return Math.min(i, to);
+  }
+
+  /** Converts a character offset to a 0-based line and column. */
+  private getPosition(offset: number): { line: number; column: number } {
+    let low = 0;
+    let high = this.lineStarts.length - 1;
+    while (low < high) {
+      const mid = (low + high + 1) >> 1;
+      if (this.lineStarts[mid] <= offset) {
+        low = mid;
+      } else {
+        high = mid - 1;
+      }
+    }
+    return { line: low, column: offset - this.lineStarts[low] };
+  }
+
+  /**
+   * Static helper that analyzes a SQL file in one call.
+   *
+   * @param sourceText - The SQL source
+   * @returns Results per stored routine and `DO` block
+   */
+  public static analyzeFile(sourceText: string): SqlFunctionMetrics[] {
+    return new SqlMetricsAnalyzer().analyzeFunctions(sourceText);
+  }
+}
@@ -0,0 +1,139 @@
+/**
+ * @fileoverview Logical Lines of Code
+ *
+ * Counts the logical lines of a function: lines that contain code, excluding blank
+ * lines and lines that hold only comments. It is a language-light, line-based count
+ * that complements cognitive complexity by catching long but simple functions.
+ */
+
+import { UnifiedFunctionMetrics } from "./metricsAnalyzerFactory";
+
+/** Comment syntax used to recognise comment-only lines. */
+export interface CommentStyle {
+  /** Line comment prefix */
+  line: string;
+  /** Whether `/* ... *\/` block comments exist */
+  block: boolean;
+}
+
+const C_STYLE: CommentStyle = { line: "//", block: true };
+const HASH_STYLE: CommentStyle = { line: "#", block: false };
+const SQL_STYLE: CommentStyle = { line: "--", block: true };
+
+/** Languages whose comments do not follow C-style syntax. */
+const commentStyles: Record<string, CommentStyle> = {
+  elixir: HASH_STYLE,
+  python: HASH_STYLE,
+  sql: SQL_STYLE,
+};
+
+/** Returns the comment syntax of a language, C-style unless listed otherwise. */
+export function getCommentStyle(languageId: string): CommentStyle {
+  return commentStyles[languageId] ?? C_STYLE;
+}
+
+/** Tracks whether a block comment is still open at the end of a line. */
+interface ScanState {
+  inBlock: boolean;
+}
+
+/**
+ * Returns whether a line contains any code outside comments, updating the block-comment state.
+ * String literals are not tokenized, so comment markers inside strings are treated as comments.
+ */
+function lineHasCode(line: string, style: CommentStyle, state: ScanState): boolean {
+  let rest = line;
+  let hasCode = false;
+  while (rest.length > 0) {
+    if (state.inBlock) {
+      const end = rest.indexOf("*/");
+      if (end < 0) {
+        return hasCode;
+      }
+      state.inBlock = false;
+      rest = rest.substring(end + 2);
+      continue;
+    }
+
+    rest = rest.trimStart();
+    if (rest.length === 0 || rest.startsWith(style.line)) {
+      break;
+    }
+    if (style.block && rest.startsWith("/*")) {
+      state.inBlock = true;
+      rest = rest.substring(2);
+      continue;
+    }
+
+    // Code up to the next comment; keep scanning only if a block comment opens on this line.
+    hasCode = true;
+    const blockStart = style.block ? rest.indexOf("/*") : -1;
+    const lineStart = rest.indexOf(style.line);
+    if (blockStart < 0 || (lineStart >= 0 && lineStart < blockStart)) {
+      break;
+    }
+    rest = rest.substring(blockStart);
+  }
+  return hasCode;
+}
+
+/**
+ * Counts the logical lines of code between two lines (inclusive).
+ *
+ * @param lines - The source split into lines
+ * @param startLine - First line of the range (0-based)
+ * @param endLine - Last line of the range (0-based, inclusive)
+ * @param languageId - Language identifier, used to pick the comment syntax
+ * @returns The number of lines containing code
+ */
+export function countLogicalLines(
+  lines: readonly string[],
+  startLine: number,
+  endLine: number,
+  languageId: string
+): number {
+  const style = getCommentStyle(languageId);
+  const state: ScanState = { inBlock: false };
+  let count = 0;
+  for (let i = Math.max(startLine, 0); i <= endLine && i < lines.length; i++) {
+    if (lineHasCode(lines[i], style, state)) {
+      count++;
+    }
+  }
+  return count;
+}
+
+/**
+ * Counts the logical lines of code of a whole file. Declarations outside functions, such
+ * as a Go `const` block of `iota` values, count here although they give no function entry.
+ *
+ * @param sourceText - The file's contents
+ * @param languageId - Language identifier, used to pick the comment syntax
+ * @returns The number of lines containing code
+ */
+export function countFileLogicalLines(sourceText: string, languageId: string): number {
+  const lines = sourceText.split(/\r?\n/);
+  return countLogicalLines(lines, 0, lines.length - 1, languageId);
+}
+
+/**
+ * Finds the longest function of a file by logical lines of code.
+ * Ties go to the function that appears first.
+ *
+ * @param functions - The analyzed functions of one file
+ * @returns The longest function, or undefined when there are no measured functions
+ */
+export function getLongestFunction(
+  functions: readonly UnifiedFunctionMetrics[]
+): UnifiedFunctionMetrics | undefined {
+  let longest: UnifiedFunctionMetrics | undefined;
+  for (const func of functions) {
+    if (
+      func.logicalLines !== undefined &&
+      (!longest || func.logicalLines > (longest.logicalLines ?? 0))
+    ) {
+      longest = func;
+    }
+  }
+  return longest;
+}
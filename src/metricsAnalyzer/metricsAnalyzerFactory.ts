@@ -14,6 +14,14 @@
  *
  */
 
+import { countLogicalLines } from "./linesOfCode";
+import { countTokens } from "./tokenCount";
+import { isTiming, timePhase } from "./analysisTiming";
+import { findGeneratedCode, isGeneratedFunction } from "./generatedCode";
+import { markRecursion, RecursionKind } from "./recursion";
+import { markFeatureFlags } from "./featureFlags";
+import { getFunctionFingerprint } from "./fingerprint";
+
 /**
  * Represents a single complexity detail for a specific code construct.
  * Each detail contributes to the overall complexity of a function.
@@ -50,6 +58,307 @@ export interface UnifiedFunctionMetrics {
   startColumn: number;
   /** Column number where the function definition ends (0-based) */
   endColumn: number;
+  /**
+   * Number of distinct receiver fields the method accesses (cohesion heuristic).
+   * Only populated by analyzers that support it (currently Go methods).
+   */
+  fieldAccessCount?: number;
+  /**
+   * Complexity budget pinned in the function's doc comment (e.g. `//metrics:expect cc<=8`).
+   * Only populated by analyzers that support it (currently Go).
+   */
+  expectedComplexity?: ComplexityExpectation;
+  /**
+   * Largest number of boolean operands combined by logical operators in any single condition.
+   * Only populated by analyzers that support it (currently Go).
+   */
+  maxConditionOperands?: number;
+  /**
+   * Deepest nesting of conditional expressions (ternaries and `&&`/`||`/`??` groups) within
+   * each other in one expression, as opposed to block nesting.
+   * Only populated by analyzers that support it (currently Go, JavaScript, and TypeScript).
+   */
+  maxExpressionNesting?: number;
+  /**
+   * Deepest nesting of closures (function literals) within the function.
+   * Only populated by analyzers that support it (currently Go).
+   */
+  maxClosureDepth?: number;
+  /**
+   * Switch and select statements of the function with their number of clauses, to flag
+   * overly long ones. Only populated by analyzers that support it (currently Go).
+   */
+  switches?: SwitchSize[];
+  /**
+   * Number of return points (explicit and naked `return` statements) in the function, and
+   * of `panic` calls when `panicMode` is `exit`.
+   * Only populated by analyzers that support it (currently Go).
+   */
+  returnCount?: number;
+  /**
+   * Error surface: number of returns handing back a non-nil error (`return err`,
+   * `return nil, fmt.Errorf("...: %w", err)`), for functions whose last result is an error.
+   * Only populated by analyzers that support it (currently Go).
+   */
+  errorReturnCount?: number;
+  /**
+   * Number of statements and of branches (`if`, loops, non-default `case` clauses), and
+   * branches per statement. Only populated by analyzers that support it (currently Go).
+   */
+  statementCount?: number;
+  branchCount?: number;
+  branchDensity?: number;
+  /**
+   * Kinds of operators used in the body (`arithmetic`, `bitwise`, `channel`, `comparison`,
+   * `logical`), sorted; their number is a lightweight readability signal.
+   * Only populated by analyzers that support it (currently Go).
+   */
+  operatorKinds?: string[];
+  /**
+   * Histogram of named syntax nodes in the function body, keyed by node type.
+   * Only populated when `collectNodeCounts` is requested, by analyzers that support it (currently Go).
+   */
+  nodeCounts?: Record<string, number>;
+  /**
+   * Logical lines of code: lines within the function that contain code, excluding blank
+   * and comment-only lines. Populated by the factory for every language, unless the
+   * `linesOfCode` metric is turned off.
+   */
+  logicalLines?: number;
+  /**
+   * Whether the parser did not fully understand the function's syntax, e.g. a language
+   * feature newer than the bundled grammar, so its metrics are a best-effort estimate.
+   * Only set by analyzers that support it (currently Go).
+   */
+  approximate?: boolean;
+  /**
+   * Lexical tokens in the function's range, whitespace and comments excluded: a size measure
+   * that does not depend on formatting. Populated by the factory for every language, unless
+   * the `tokens` metric is turned off.
+   */
+  tokenCount?: number;
+  /**
+   * Number of declared parameters (receivers excluded); for Elixir, the function's arity.
+   * Only populated by analyzers that support it (currently Go and Elixir).
+   */
+  parameterCount?: number;
+  /**
+   * Number of function clauses (heads of the same name and arity) aggregated into this entry.
+   * Only populated by analyzers that support it (currently Elixir).
+   */
+  clauseCount?: number;
+  /**
+   * Whether a method has a pointer receiver (`func (c *T) M()`); unset for functions and closures.
+   * Only populated by analyzers that support it (currently Go).
+   */
+  pointerReceiver?: boolean;
+  /**
+   * Fields a plain function writes through a first parameter of type `*T`, a hint that it
+   * could be a method on T; unset without such writes.
+   * Only populated by analyzers that support it (currently Go).
+   */
+  pointerParamWrites?: { parameter: string; type: string; fieldCount: number };
+  /**
+   * Whether a method never references its named receiver, a hint that it could be a
+   * package function; unset for functions and unnamed receivers.
+   * Only populated by analyzers that support it (currently Go).
+   */
+  receiverUnused?: boolean;
+  /**
+   * Names of the functions called in the body, used to build the file's call graph.
+   * Only populated by analyzers that support it (currently Go).
+   */
+  calls?: string[];
+  /**
+   * Set when the function is recursive: it calls itself (`direct`), or it is on a call
+   * cycle with other functions of its file (`mutual`). Populated by the factory from `calls`.
+   */
+  recursion?: RecursionKind;
+  /** Members of the mutual recursion cycle, sorted by name, when `recursion` is `mutual` */
+  recursionCycle?: string[];
+  /**
+   * Content hash of the normalized function source that survives renames, for matching
+   * functions across versions. Populated by the factory when `fingerprints` is requested.
+   */
+  fingerprint?: string;
+  /**
+   * Set when the function is generated code: its file carries a `// Code generated ... DO NOT EDIT.`
+   * header or it starts inside a `//metrics:generated-begin` region. Populated by the factory.
+   */
+  generated?: boolean;
+  /**
+   * Number of conditions that check a feature flag, matched by `featureFlagPatterns`.
+   * Populated by the factory; unset for functions without flag checks.
+   */
+  featureFlagChecks?: number;
+  /**
+   * Estimated complexity once the feature flags are removed and one path is left: flag
+   * checks, their `else` branches, and the nesting they add are not counted. Populated
+   * by the factory alongside `featureFlagChecks`.
+   */
+  steadyStateComplexity?: number;
+  /**
+   * Roles of the function, e.g. `resolver` when its name matches `resolverPatterns`.
+   * Populated by the factory; unset for untagged functions.
+   */
+  tags?: string[];
+}
+
+/**
+ * A per-function complexity budget declared in source. `<=` and `<` are upper bounds;
+ * `==` pins an exact value so any drift is reported.
+ */
+export interface ComplexityExpectation {
+  /** Comparison operator the actual complexity must satisfy */
+  operator: "<=" | "<" | "==";
+  /** The expected complexity value */
+  value: number;
+}
+
+/** A switch-like statement and its number of clauses. */
+export interface SwitchSize {
+  /** The statement's keyword, e.g. `switch`, `type switch`, or `select` */
+  kind: string;
+  /** Number of `case` clauses, `default` included */
+  caseCount: number;
+  /** Line of the statement (0-based, like the function's `startLine`) */
+  line: number;
+  /** Column of the statement (0-based) */
+  column: number;
+}
+
+/**
+ * Fluent-API usage in one file: methods that return their own receiver type (builder
+ * steps) and method call chains, used to aggregate chain statistics per type.
+ */
+export interface FluentUsage {
+  /** Methods returning their receiver type, by receiver type name */
+  fluentMethods: { type: string; name: string }[];
+  /** Method call chains, method names in call order, with the chain's line (0-based) */
+  chains: { methods: string[]; line: number }[];
+}
+
+/**
+ * How closures (func literals, lambdas) are reported:
+ * - `inline`: merged into the enclosing function at an increased nesting level
+ * - `separate`: reported as their own entries and excluded from the enclosing function
+ * - `both`: merged into the enclosing function and also reported as their own entries
+ */
+export type ClosureMode = "inline" | "separate" | "both";
+
+/**
+ * How func values of composite literals, such as the handlers of a dispatch table, are
+ * reported besides their own entry:
+ * - `standalone`: excluded from the enclosing function
+ * - `attributed`: also merged into the enclosing function
+ */
+export type LiteralFuncMode = "standalone" | "attributed";
+
+/**
+ * How calls that abort the function, such as Go's `panic(...)`, are counted:
+ * - `statement`: like any other call, without a contribution
+ * - `exit`: as an exit point in `returnCount`, without adding complexity
+ * - `branch`: as a complexity increment
+ */
+export type PanicMode = "statement" | "exit" | "branch";
+
+/**
+ * How functions are scored:
+ * - `cognitive`: cognitive complexity
+ * - `cyclomatic`: one per `if`, loop, non-default `case`, `&&`, and `||`, as gocyclo counts
+ *   on top of its starting 1 (see `complexityBase`)
+ */
+export type ComplexityScoring = "cognitive" | "cyclomatic";
+
+/**
+ * Which analyzer scores a language:
+ * - `builtin`: the hand-written analyzer of each language
+ * - `rules`: the rule-based engine driven by a declarative node-type table, for languages
+ *   that have one (currently Java); other languages keep their built-in analyzer
+ */
+export type AnalysisEngine = "builtin" | "rules";
+
+/**
+ * Metrics computed by the factory for every language that can be turned off. Cognitive
+ * complexity is always computed, since it decides every band.
+ * - `linesOfCode`: `logicalLines`
+ * - `tokens`: `tokenCount`
+ * - `recursion`: `recursion` and `recursionCycle`
+ */
+export type OptionalMetric = "linesOfCode" | "tokens" | "recursion";
+
+/** Every optional metric, the default of `AnalyzerOptions.metrics`. */
+export const OPTIONAL_METRICS: readonly OptionalMetric[] = ["linesOfCode", "tokens", "recursion"];
+
+/**
+ * Options that change how source code is analyzed. Analyzers ignore options they do not
+ * support, so the same options can be passed for every language.
+ */
+export interface AnalyzerOptions {
+  /** How closures are reported (default `inline`; currently honoured by Go) */
+  closureMode?: ClosureMode;
+  /** How composite literal func values are reported (default `attributed`; currently honoured by Go) */
+  literalFuncMode?: LiteralFuncMode;
+  /** Optional metrics to compute (default: all); the others are skipped and left unset */
+  metrics?: readonly OptionalMetric[];
+  /** Whether generated functions are left out of the results (default false: they are tagged) */
+  excludeGenerated?: boolean;
+  /** Whether to collect syntax node type histograms (advanced; currently honoured by Go) */
+  collectNodeCounts?: boolean;
+  /** Whether each function gets a content `fingerprint` (default false) */
+  fingerprints?: boolean;
+  /** Which analyzer scores languages that have a rule table (default `builtin`) */
+  engine?: AnalysisEngine;
+  /**
+   * Regular expressions matched against qualified function names (e.g. `User.String`);
+   * matching functions are left out of the results. Invalid patterns are ignored.
+   */
+  excludeFunctions?: readonly string[];
+  /**
+   * Value added to every function's complexity (default 0). With 1, a function without
+   * branches scores 1, as in McCabe's definition and tools such as gocyclo; the details
+   * still list only the increments.
+   */
+  complexityBase?: number;
+  /**
+   * Files with more lines than this are parsed one top-level declaration at a time to
+   * bound memory use (default 0: never; currently honoured by Go)
+   */
+  streamingThreshold?: number;
+  /**
+   * Regular expressions matched against the text of `if` conditions; matching conditions
+   * are feature flag checks, and functions with them get a `steadyStateComplexity`.
+   * Invalid patterns are ignored.
+   */
+  featureFlagPatterns?: readonly string[];
+  /**
+   * Regular expressions matched against qualified function names (e.g. `Query.Orders`);
+   * matching functions are tagged `resolver`. Invalid patterns are ignored.
+   */
+  resolverPatterns?: readonly string[];
+  /** How `panic(...)` calls are counted (default `statement`; currently honoured by Go) */
+  panicMode?: PanicMode;
+  /** How functions are scored (default `cognitive`; currently honoured by Go) */
+  scoring?: ComplexityScoring;
+  /**
+   * Only functions overlapping these lines are returned (default: all). Languages listed by
+   * {@link MetricsAnalyzerFactory.supportsLineRanges} also skip parsing the rest of the file.
+   */
+  lineRanges?: readonly LineRange[];
+}
+
+/** Result of {@link MetricsAnalyzerFactory.analyzeSource}. */
+export interface SourceAnalysis {
+  /** The functions found, with 1-based detail positions; empty when `error` is set */
+  functions: UnifiedFunctionMetrics[];
+  /** Why the text could not be analyzed: no analyzer for the language, or the analyzer failed */
+  error?: string;
+}
+
+/** A range of lines, 0-based and inclusive. */
+export interface LineRange {
+  start: number;
+  end: number;
 }
 
 /**
@@ -87,6 +396,137 @@ export class MetricsAnalyzerFactory {
     return supportedLanguageSet.has(languageId);
   }
 
+  /**
+   * Resolves the language identifier for a file path from its extension.
+   *
+   * Used when files are discovered on disk (e.g. workspace analysis) rather than
+   * opened as documents, so no VS Code language mode is available.
+   *
+   * @param filePath - File path or name (either separator style)
+   * @returns The supported language identifier, or undefined if the extension is not supported
+   */
+  static getLanguageIdForFile(filePath: string): string | undefined {
+    const dot = filePath.lastIndexOf(".");
+    const sep = Math.max(filePath.lastIndexOf("/"), filePath.lastIndexOf("\\"));
+    if (dot <= sep + 1) {
+      return undefined;
+    }
+    return fileExtensionLanguages[filePath.substring(dot + 1).toLowerCase()];
+  }
+
+  /**
+   * Adds an analyzer for a language that has no built-in one. Its results flow through the
+   * same post-processing (logical lines, generated code, recursion) as built-in languages.
+   *
+   * @param languageId - VS Code language identifier, e.g. 'cobol'
+   * @param analyzer - The analyzer to use for documents of that language
+   * @param fileExtensions - Extensions (without the dot) mapped to the language for workspace scans
+   * @returns A function that removes the analyzer and its extensions again
+   * @throws {Error} If the language or one of the extensions is already taken
+   */
+  static registerAnalyzer(
+    languageId: string,
+    analyzer: LanguageAnalyzer,
+    fileExtensions: readonly string[] = []
+  ): () => void {
+    if (supportedLanguageSet.has(languageId)) {
+      throw new Error(`An analyzer for "${languageId}" is already registered.`);
+    }
+    const extensions = fileExtensions.map((ext) => ext.replace(/^\./, "").toLowerCase());
+    const taken = extensions.find((ext) => fileExtensionLanguages[ext] !== undefined);
+    if (taken !== undefined) {
+      throw new Error(`Files with extension ".${taken}" are already handled by "${fileExtensionLanguages[taken]}".`);
+    }
+
+    const analyze = (sourceText: string) => normalizeFunctions(analyzer.analyzeFile(sourceText));
+    languageAnalyzers[languageId] = analyze;
+    supportedLanguageSet.add(languageId);
+    for (const ext of extensions) {
+      fileExtensionLanguages[ext] = languageId;
+    }
+
+    return () => {
+      if (languageAnalyzers[languageId] !== analyze) {
+        return; // already removed
+      }
+      delete languageAnalyzers[languageId];
+      supportedLanguageSet.delete(languageId);
+      for (const ext of extensions) {
+        delete fileExtensionLanguages[ext];
+      }
+      // Drop cached results so a later analyzer for the same language starts clean.
+      analysisCache.clear();
+    };
+  }
+
+  /**
+   * Returns the file extensions (without the leading dot) that map to a supported language.
+   * @returns An array of extensions (e.g., 'go', 'ts', 'py')
+   */
+  static getSupportedFileExtensions(): string[] {
+    return Object.keys(fileExtensionLanguages);
+  }
+
+  /**
+   * Finds fluent methods and method call chains in a file.
+   *
+   * @param sourceText - The complete source code content to analyze
+   * @param languageId - VS Code language identifier
+   * @returns The file's fluent usage, or undefined for languages without support (all but Go)
+   */
+  static findFluentUsage(sourceText: string, languageId: string): FluentUsage | undefined {
+    if (languageId !== "go") {
+      return undefined;
+    }
+    const { GoMetricsAnalyzer } = require("./languages/goAnalyzer") as {
+      GoMetricsAnalyzer: { findFluentUsage(sourceText: string): FluentUsage };
+    };
+    return GoMetricsAnalyzer.findFluentUsage(sourceText);
+  }
+
+  /**
+   * Analyzes source text that does not come from a document, e.g. in unit tests or tools
+   * embedding the calculator. Unlike {@link MetricsAnalyzerFactory.analyzeFile}, failures
+   * are reported rather than thrown or hidden: an unsupported language or an analyzer that
+   * throws gives no functions and an `error`. Text with syntax errors is not a failure; it
+   * is analyzed as far as the parser recovers, and Go functions overlapping an error are
+   * marked `approximate`.
+   *
+   * @param sourceText - The complete source code to analyze
+   * @param languageId - VS Code language identifier (e.g., 'go')
+   * @param options - Optional analysis options
+   * @returns The functions found, or an empty list and the reason nothing was analyzed
+   */
+  static analyzeSource(
+    sourceText: string,
+    languageId: string,
+    options: AnalyzerOptions = {}
+  ): SourceAnalysis {
+    if (!supportedLanguageSet.has(languageId)) {
+      return { functions: [], error: `No analyzer is registered for "${languageId}".` };
+    }
+    try {
+      return { functions: this.analyzeFile(sourceText, languageId, options) };
+    } catch (error) {
+      return {
+        functions: [],
+        error: `Analysis of ${languageId} failed: ${error instanceof Error ? error.message : String(error)}`,
+      };
+    }
+  }
+
+  /**
+   * Checks whether a language's analyzer skips the parts of a file outside the
+   * `lineRanges` option, so analyzing the visible part of a large file is faster than
+   * analyzing all of it.
+   *
+   * @param languageId - VS Code language identifier
+   * @returns True for Go
+   */
+  static supportsLineRanges(languageId: string): boolean {
+    return languageId === "go";
+  }
+
   /**
    * Analyzes the complexity of functions within a source code file.
    *
@@ -96,6 +536,7 @@ export class MetricsAnalyzerFactory {
    *
    * @param sourceText - The complete source code content to analyze
    * @param languageId - VS Code language identifier (e.g., 'csharp', 'go')
+   * @param options - Optional analysis options; results are cached per distinct options
    *
    * @returns An array of complexity analysis results, one for each function found in the source code.
    *          Returns an empty array if no functions are found or if the language is not supported.
@@ -120,21 +561,72 @@ export class MetricsAnalyzerFactory {
    */
   public static analyzeFile(
     sourceText: string,
-    languageId: string
+    languageId: string,
+    options: AnalyzerOptions = {}
   ): UnifiedFunctionMetrics[] {
     // Get the analyzer function for the specified language
-    const analyzer = languageAnalyzers[languageId];
+    const analyzer =
+      (options.engine === "rules" ? ruleBasedAnalyzers[languageId] : undefined) ??
+      languageAnalyzers[languageId];
     if (analyzer) {
-      // Use cache to avoid re-analyzing identical source text
-      const cacheKey = `${languageId}:${sourceText.length}:${hashString(sourceText)}`;
+      // Use cache to avoid re-analyzing identical source text (not while timing the analysis)
+      const cacheKey =
+        `${languageId}:${getOptionsKey(options)}:${sourceText.length}:${hashString(sourceText)}`;
       const cached = analysisCache.get(cacheKey);
-      if (cached) {
+      if (cached && !isTiming()) {
         // Move to end to maintain LRU order (most recently used stays at back)
         analysisCache.delete(cacheKey);
         analysisCache.set(cacheKey, cached);
         return cached;
       }
-      const results = analyzer(sourceText);
+      const analyzed = timePhase("walk", () => analyzer(sourceText, options));
+      const results = timePhase("aggregate", () => {
+        const lines = sourceText.split(/\r?\n/);
+        const generated = findGeneratedCode(lines);
+        const metrics = new Set(options.metrics ?? OPTIONAL_METRICS);
+        let functions = analyzed;
+        for (const func of functions) {
+          if (metrics.has("linesOfCode")) {
+            func.logicalLines = countLogicalLines(lines, func.startLine, func.endLine, languageId);
+          }
+          if (metrics.has("tokens")) {
+            func.tokenCount = countTokens(lines, func, languageId);
+          }
+          if (isGeneratedFunction(func, generated)) {
+            func.generated = true;
+          }
+          if (options.fingerprints) {
+            func.fingerprint = getFunctionFingerprint(lines, func);
+          }
+          if (options.complexityBase) {
+            func.complexity += options.complexityBase;
+          }
+        }
+        if (metrics.has("recursion")) {
+          markRecursion(functions);
+        }
+        markFeatureFlags(functions, lines, compilePatterns(options.featureFlagPatterns ?? []));
+        const resolvers = compilePatterns(options.resolverPatterns ?? []);
+        for (const func of functions) {
+          if (resolvers.some((pattern) => pattern.test(func.name))) {
+            func.tags = [...(func.tags ?? []), "resolver"];
+          }
+        }
+        if (options.excludeGenerated) {
+          functions = functions.filter((func) => !func.generated);
+        }
+        const ranges = options.lineRanges;
+        if (ranges) {
+          functions = functions.filter((func) =>
+            ranges.some((range) => range.start <= func.endLine && range.end >= func.startLine)
+          );
+        }
+        const excluded = compilePatterns(options.excludeFunctions ?? []);
+        if (excluded.length > 0) {
+          functions = functions.filter((func) => !excluded.some((pattern) => pattern.test(func.name)));
+        }
+        return functions;
+      });
       if (analysisCache.size >= CACHE_MAX_SIZE) {
         analysisCache.delete(analysisCache.keys().next().value!);
       }
@@ -152,6 +644,51 @@ const CACHE_MAX_SIZE = 20;
 /** Cache of analysis results keyed by language + content hash. Evicts least-recently-used entry when full. */
 const analysisCache = new Map<string, UnifiedFunctionMetrics[]>();
 
+/** Serializes analyzer options into a cache key segment, with defaults filled in. */
+function getOptionsKey(options: AnalyzerOptions): string {
+  return [
+    [...(options.metrics ?? OPTIONAL_METRICS)].sort().join(","),
+    options.closureMode ?? "inline",
+    options.excludeGenerated ? 1 : 0,
+    options.collectNodeCounts ? 1 : 0,
+    options.fingerprints ? 1 : 0,
+    options.engine ?? "builtin",
+    JSON.stringify(options.excludeFunctions ?? []),
+    options.complexityBase ?? 0,
+    options.streamingThreshold ?? 0,
+    JSON.stringify(options.featureFlagPatterns ?? []),
+    JSON.stringify(options.resolverPatterns ?? []),
+    options.panicMode ?? "statement",
+    options.literalFuncMode ?? "attributed",
+    options.scoring ?? "cognitive",
+    JSON.stringify(options.lineRanges ?? null),
+  ].join(":");
+}
+
+/** Compiled function name, resolver and feature flag patterns by source; `null` marks an invalid pattern. */
+const patternCache = new Map<string, RegExp | null>();
+
+/** Compiles regular expression sources, skipping invalid ones. */
+function compilePatterns(sources: readonly string[]): RegExp[] {
+  const patterns: RegExp[] = [];
+  for (const source of sources) {
+    if (!patternCache.has(source)) {
+      let pattern: RegExp | null = null;
+      try {
+        pattern = new RegExp(source);
+      } catch {
+        console.warn(`Ignoring invalid pattern "${source}"`);
+      }
+      patternCache.set(source, pattern);
+    }
+    const pattern = patternCache.get(source);
+    if (pattern) {
+      patterns.push(pattern);
+    }
+  }
+  return patterns;
+}
+
 /** Fast non-cryptographic hash for cache key generation (djb2 variant). */
 function hashString(str: string): number {
   let hash = 5381;
@@ -165,7 +702,7 @@ function hashString(str: string): number {
  * Raw detail shape returned by all language-specific analyzers.
  * `line` and `column` are 0-based and are normalized to 1-based by createAnalyzer.
  */
-interface RawMetricsDetail {
+export interface RawMetricsDetail {
   increment: number;
   reason: string;
   line: number;
@@ -178,7 +715,7 @@ interface RawMetricsDetail {
  * All position fields (`startLine`, `endLine`, `startColumn`, `endColumn`) are 0-based
  * and are passed through as-is to `UnifiedFunctionMetrics` without normalization.
  */
-interface RawFunctionMetrics {
+export interface RawFunctionMetrics {
   name: string;
   complexity: number;
   details: RawMetricsDetail[];
@@ -186,11 +723,40 @@ interface RawFunctionMetrics {
   endLine: number;
   startColumn: number;
   endColumn: number;
+  fieldAccessCount?: number;
+  expectedComplexity?: ComplexityExpectation;
+  maxConditionOperands?: number;
+  maxExpressionNesting?: number;
+  maxClosureDepth?: number;
+  switches?: SwitchSize[];
+  returnCount?: number;
+  errorReturnCount?: number;
+  statementCount?: number;
+  branchCount?: number;
+  branchDensity?: number;
+  operatorKinds?: string[];
+  nodeCounts?: Record<string, number>;
+  calls?: string[];
+  parameterCount?: number;
+  clauseCount?: number;
+  pointerReceiver?: boolean;
+  pointerParamWrites?: { parameter: string; type: string; fieldCount: number };
+  receiverUnused?: boolean;
+}
+
+/**
+ * An analyzer contributed at runtime for a language without a built-in one.
+ * `analyzeFile` must be synchronous and side-effect free: it is called with the full
+ * document text on every analysis (results are cached by content) and returns one entry
+ * per function, with 0-based positions.
+ */
+export interface LanguageAnalyzer {
+  analyzeFile(sourceText: string): RawFunctionMetrics[];
 }
 
 /** Shape of a language analyzer class that must expose a static `analyzeFile` method. */
 interface AnalyzerClass {
-  analyzeFile(sourceText: string): RawFunctionMetrics[];
+  analyzeFile(sourceText: string, options?: AnalyzerOptions): RawFunctionMetrics[];
 }
 
 /**
@@ -206,17 +772,19 @@ interface AnalyzerClass {
  *
  * @param modulePath - require()-style path to the language analyzer module (relative to this file)
  * @param className  - Name of the exported analyzer class that exposes a static `analyzeFile` method
- * @returns A function that takes source text and returns an array of UnifiedFunctionMetrics
+ * @returns A function that takes source text and options and returns an array of UnifiedFunctionMetrics
  * @throws {Error} If the module does not export the expected class with an `analyzeFile` method
  */
 export function createAnalyzer(
   modulePath: string,
   className: string
-): (sourceText: string) => UnifiedFunctionMetrics[] {
+): (sourceText: string, options?: AnalyzerOptions) => UnifiedFunctionMetrics[] {
   // Cached reference to the resolved analyzeFile function — populated on first call.
-  let cachedAnalyze: ((sourceText: string) => RawFunctionMetrics[]) | null = null;
+  let cachedAnalyze:
+    | ((sourceText: string, options?: AnalyzerOptions) => RawFunctionMetrics[])
+    | null = null;
 
-  return function (sourceText: string): UnifiedFunctionMetrics[] {
+  return function (sourceText: string, options?: AnalyzerOptions): UnifiedFunctionMetrics[] {
     if (!cachedAnalyze) {
       const mod = require(modulePath) as Record<string, AnalyzerClass | undefined>;
       const analyzerClass = mod[className];
@@ -229,25 +797,26 @@ export function createAnalyzer(
       cachedAnalyze = analyzerClass.analyzeFile.bind(analyzerClass);
     }
 
-    const functions: RawFunctionMetrics[] = cachedAnalyze(sourceText);
-    return functions.map((func: RawFunctionMetrics) => ({
-      name: func.name,
-      complexity: func.complexity,
-      details: func.details.map((detail: RawMetricsDetail) => ({
-        increment: detail.increment,
-        reason: detail.reason,
-        line: detail.line + 1,     // analyzers use 0-based; normalize to 1-based
-        column: detail.column + 1, // analyzers use 0-based; normalize to 1-based
-        nesting: detail.nesting,
-      })),
-      startLine: func.startLine,
-      endLine: func.endLine,
-      startColumn: func.startColumn,
-      endColumn: func.endColumn,
-    }));
+    return normalizeFunctions(cachedAnalyze(sourceText, options));
   };
 }
 
+/** Converts raw analyzer results to the unified shape shared by every language. */
+function normalizeFunctions(functions: RawFunctionMetrics[]): UnifiedFunctionMetrics[] {
+  // Spread the raw result so optional language-specific metrics pass through untouched;
+  // only detail positions need normalizing.
+  return functions.map((func: RawFunctionMetrics) => ({
+    ...func,
+    details: func.details.map((detail: RawMetricsDetail) => ({
+      increment: detail.increment,
+      reason: detail.reason,
+      line: detail.line + 1,     // analyzers use 0-based; normalize to 1-based
+      column: detail.column + 1, // analyzers use 0-based; normalize to 1-based
+      nesting: detail.nesting,
+    })),
+  }));
+}
+
 /**
  * A record of language-specific analyzers that compute cognitive complexity metrics for source code.
  *
@@ -258,18 +827,60 @@ export function createAnalyzer(
  */
 const languageAnalyzers: Record<
   string,
-  (sourceText: string) => UnifiedFunctionMetrics[]
+  (sourceText: string, options?: AnalyzerOptions) => UnifiedFunctionMetrics[]
 > = {
   csharp:          createAnalyzer("./languages/csharpAnalyzer",     "CSharpMetricsAnalyzer"),
+  dart:            createAnalyzer("./languages/dartAnalyzer",        "DartMetricsAnalyzer"),
+  elixir:          createAnalyzer("./languages/elixirAnalyzer",      "ElixirMetricsAnalyzer"),
   go:              createAnalyzer("./languages/goAnalyzer",          "GoMetricsAnalyzer"),
+  gotmpl:          createAnalyzer("./languages/goTemplateAnalyzer",  "GoTemplateMetricsAnalyzer"),
   java:            createAnalyzer("./languages/javaAnalyzer",        "JavaMetricsAnalyzer"),
   javascript:      createAnalyzer("./languages/javascriptAnalyzer",  "JavaScriptMetricsAnalyzer"),
   javascriptreact: createAnalyzer("./languages/javascriptAnalyzer",  "JavaScriptMetricsAnalyzer"),
   python:          createAnalyzer("./languages/pythonAnalyzer",      "PythonMetricsAnalyzer"),
+  sql:             createAnalyzer("./languages/sqlAnalyzer",         "SqlMetricsAnalyzer"),
   typescript:      createAnalyzer("./languages/typescriptAnalyzer",  "TypeScriptMetricsAnalyzer"),
   typescriptreact: createAnalyzer("./languages/tsxAnalyzer",         "TsxMetricsAnalyzer"),
   rust:            createAnalyzer("./languages/rustAnalyzer",         "RustMetricsAnalyzer"),
 };
 
+/**
+ * Languages described by a rule table for the rule-based engine (`engine: "rules"`).
+ * Every entry must also have a built-in analyzer above.
+ */
+const ruleBasedAnalyzers: Record<
+  string,
+  (sourceText: string, options?: AnalyzerOptions) => UnifiedFunctionMetrics[]
+> = {
+  java: createAnalyzer("./languages/javaRules", "JavaRulesAnalyzer"),
+};
+
 /** Set of supported language IDs for O(1) membership checks via {@link MetricsAnalyzerFactory.isSupportedLanguage}. */
 const supportedLanguageSet = new Set<string>(Object.keys(languageAnalyzers));
+
+/**
+ * Maps file extensions (lower-case, without the dot) to supported language identifiers.
+ * Mirrors the default VS Code language associations for each supported language.
+ */
+const fileExtensionLanguages: Record<string, string> = {
+  cs:  "csharp",
+  dart: "dart",
+  ex:  "elixir",
+  exs: "elixir",
+  go:  "go",
+  gotmpl: "gotmpl",
+  gohtml: "gotmpl",
+  tmpl: "gotmpl",
+  java: "java",
+  js:  "javascript",
+  mjs: "javascript",
+  cjs: "javascript",
+  jsx: "javascriptreact",
+  py:  "python",
+  sql: "sql",
+  ts:  "typescript",
+  mts: "typescript",
+  cts: "typescript",
+  tsx: "typescriptreact",
+  rs:  "rust",
+};
@@ -0,0 +1,44 @@
+/**
+ * @fileoverview Metric Number Formatting
+ *
+ * Formats metric values for display. Integers (complexity, line counts) are always
+ * written plainly; non-integer values such as risk scores use the decimal separator
+ * of the display locale. Exports deliberately do not use this, so files written for
+ * other tools always use `.` regardless of locale.
+ */
+
+/** Fraction digits shown for non-integer metrics. */
+const MAX_FRACTION_DIGITS = 2;
+
+/** Formatters by locale; creating an `Intl.NumberFormat` is comparatively expensive. */
+const formatters = new Map<string, Intl.NumberFormat>();
+
+/**
+ * Formats a metric value for display.
+ *
+ * @param value - The value to format
+ * @param locale - BCP 47 locale tag, e.g. `de-DE`; an invalid tag falls back to `en`
+ * @returns The formatted value, without digit grouping
+ */
+export function formatMetricValue(value: number, locale: string): string {
+  if (Number.isInteger(value)) {
+    return String(value);
+  }
+  let formatter = formatters.get(locale);
+  if (!formatter) {
+    try {
+      formatter = new Intl.NumberFormat(locale, {
+        maximumFractionDigits: MAX_FRACTION_DIGITS,
+        useGrouping: false,
+      });
+    } catch {
+      // RangeError for malformed locale tags, e.g. a typo in the override setting.
+      formatter = new Intl.NumberFormat("en", {
+        maximumFractionDigits: MAX_FRACTION_DIGITS,
+        useGrouping: false,
+      });
+    }
+    formatters.set(locale, formatter);
+  }
+  return formatter.format(value);
+}
@@ -0,0 +1,94 @@
+/**
+ * @fileoverview Recursion Detection
+ *
+ * Recursion makes control flow harder to follow than its complexity score suggests,
+ * and mutual recursion (A calls B calls A) even more so. This module builds the call
+ * graph of a file from the callees each analyzer reports and tags:
+ * - `direct` recursion: a function that calls itself
+ * - `mutual` recursion: functions on a cycle of two or more functions
+ *
+ * Only calls between functions of the same file are resolved.
+ */
+
+import { UnifiedFunctionMetrics } from "./metricsAnalyzerFactory";
+
+/** How a function recurses. */
+export type RecursionKind = "direct" | "mutual";
+
+/**
+ * Tags the recursive functions of a file by finding the strongly connected components
+ * of its call graph (Tarjan's algorithm). Functions on a cycle with others are `mutual`,
+ * with `recursionCycle` listing the cycle's members; functions only calling themselves
+ * are `direct`. Functions without `calls` are left untouched.
+ *
+ * @param functions - The file's functions, with callee names in `calls`
+ */
+export function markRecursion(functions: UnifiedFunctionMetrics[]): void {
+  const byName = new Map<string, UnifiedFunctionMetrics>();
+  for (const func of functions) {
+    if (func.calls && !byName.has(func.name)) {
+      byName.set(func.name, func);
+    }
+  }
+
+  const index = new Map<string, number>();
+  const lowLink = new Map<string, number>();
+  const stack: string[] = [];
+  const onStack = new Set<string>();
+
+  const connect = (name: string) => {
+    index.set(name, index.size);
+    lowLink.set(name, index.get(name)!);
+    stack.push(name);
+    onStack.add(name);
+
+    for (const callee of byName.get(name)!.calls!) {
+      if (!byName.has(callee)) {
+        continue;
+      }
+      if (!index.has(callee)) {
+        connect(callee);
+        lowLink.set(name, Math.min(lowLink.get(name)!, lowLink.get(callee)!));
+      } else if (onStack.has(callee)) {
+        lowLink.set(name, Math.min(lowLink.get(name)!, index.get(callee)!));
+      }
+    }
+
+    if (lowLink.get(name) === index.get(name)) {
+      const component: string[] = [];
+      let member: string;
+      do {
+        member = stack.pop()!;
+        onStack.delete(member);
+        component.push(member);
+      } while (member !== name);
+      tagComponent(component, byName);
+    }
+  };
+
+  for (const name of byName.keys()) {
+    if (!index.has(name)) {
+      connect(name);
+    }
+  }
+}
+
+/** Tags the members of one strongly connected component of the call graph. */
+function tagComponent(
+  component: string[],
+  byName: Map<string, UnifiedFunctionMetrics>
+): void {
+  if (component.length > 1) {
+    const cycle = [...component].sort();
+    for (const name of component) {
+      const func = byName.get(name)!;
+      func.recursion = "mutual";
+      func.recursionCycle = cycle;
+    }
+    return;
+  }
+  const func = byName.get(component[0])!;
+  if (func.calls!.includes(func.name)) {
+    func.recursion = "direct";
+  }
+}
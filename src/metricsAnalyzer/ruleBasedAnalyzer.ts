@@ -0,0 +1,197 @@
+/**
+ * @fileoverview Rule-Based Cognitive Complexity Engine
+ *
+ * A language-neutral analyzer that walks a Tree-sitter syntax tree and scores functions
+ * from a declarative table of node types ({@link ComplexityRules}) instead of hand-written
+ * visitor code. Supporting a language then means mapping its grammar's node types to:
+ * - structural constructs (+1 plus the nesting level, and they nest their children)
+ * - flat constructs (+1 regardless of nesting)
+ * - else branches of conditionals (+1, with else-if chains kept at the same nesting)
+ * - logical operators (+1 per sequence of the same operator)
+ *
+ * The counting rules live here once, so every language described by a table follows the
+ * same rules. It is offered as an alternative engine (`codeMetrics.analysis.engine`) next
+ * to the hand-written analyzers, starting with Java.
+ */
+
+import Parser from "tree-sitter";
+import { RawFunctionMetrics, RawMetricsDetail } from "./metricsAnalyzerFactory";
+import { timePhase } from "./analysisTiming";
+
+/** Declarative description of how a grammar's node types contribute to complexity. */
+export interface ComplexityRules {
+  /** Node types of declarations measured as functions; those without a `body` are skipped */
+  functions: readonly string[];
+  /** Node types whose `name` field qualifies the functions inside them, e.g. `Class.method` */
+  containers: readonly string[];
+  /** Node types scored +1 plus the nesting level that also nest their children, with reasons */
+  structural: Readonly<Record<string, string>>;
+  /** Node types scored a flat +1, with reasons */
+  flat: Readonly<Record<string, string>>;
+  /** Conditional node type and the field holding its else branch (an else-if is the same type) */
+  conditional?: { type: string; alternativeField: string };
+  /** Node type of binary expressions, whose operator is read from the `operator` field */
+  binaryExpression?: string;
+  /** Operators scored +1 once per sequence of the same operator, e.g. `a && b && c` */
+  logicalOperators?: readonly string[];
+}
+
+/**
+ * Scores the functions of a source file with a {@link ComplexityRules} table.
+ *
+ * Functions nested in a function (e.g. methods of an anonymous class) are neither reported
+ * nor counted toward the enclosing function.
+ */
+export class RuleBasedAnalyzer {
+  private readonly functionTypes: ReadonlySet<string>;
+  private readonly containerTypes: ReadonlySet<string>;
+  private readonly logicalOperators: ReadonlySet<string>;
+
+  /** Current nesting level during analysis */
+  private nesting = 0;
+  /** Current complexity score during analysis */
+  private complexity = 0;
+  /** Complexity details for the function being analyzed */
+  private details: RawMetricsDetail[] = [];
+
+  /**
+   * @param parser - Parser already set to the language's grammar
+   * @param rules - The language's rule table
+   */
+  constructor(
+    private readonly parser: Parser,
+    private readonly rules: ComplexityRules
+  ) {
+    this.functionTypes = new Set(rules.functions);
+    this.containerTypes = new Set(rules.containers);
+    this.logicalOperators = new Set(rules.logicalOperators ?? []);
+  }
+
+  /**
+   * Analyzes all functions in the provided source code.
+   *
+   * @param sourceText - The complete source code to analyze
+   * @returns One result per function with a body, in source order
+   */
+  public analyzeFunctions(sourceText: string): RawFunctionMetrics[] {
+    const functions: RawFunctionMetrics[] = [];
+    const visit = (node: Parser.SyntaxNode) => {
+      if (this.functionTypes.has(node.type)) {
+        const result = this.analyzeFunction(node);
+        if (result) {
+          functions.push(result);
+        }
+        return;
+      }
+      for (const child of node.children) {
+        visit(child);
+      }
+    };
+
+    visit(timePhase("parse", () => this.parser.parse(sourceText)).rootNode);
+    return functions;
+  }
+
+  /** Scores one function, or returns null when it has no body. */
+  private analyzeFunction(node: Parser.SyntaxNode): RawFunctionMetrics | null {
+    const body = node.childForFieldName("body");
+    if (!body) {
+      return null;
+    }
+
+    this.nesting = 0;
+    this.complexity = 0;
+    this.details = [];
+    for (const child of body.children) {
+      this.visit(child, false);
+    }
+
+    return {
+      name: this.getFunctionName(node),
+      complexity: this.complexity,
+      details: this.details,
+      startLine: node.startPosition.row,
+      endLine: node.endPosition.row,
+      startColumn: node.startPosition.column,
+      endColumn: node.endPosition.column,
+    };
+  }
+
+  /** Returns the function's name, qualified with its nearest named container. */
+  private getFunctionName(node: Parser.SyntaxNode): string {
+    const name = node.childForFieldName("name")?.text ?? "<anonymous>";
+    for (let parent = node.parent; parent; parent = parent.parent) {
+      const containerName = this.containerTypes.has(parent.type)
+        ? parent.childForFieldName("name")
+        : null;
+      if (containerName) {
+        return `${containerName.text}.${name}`;
+      }
+    }
+    return name;
+  }
+
+  /**
+   * Scores a node and visits its children.
+   *
+   * @param node - The node to visit
+   * @param isElseIf - Whether the node is the conditional of an else-if branch, which the
+   *   parent's else increment already counted
+   */
+  private visit(node: Parser.SyntaxNode, isElseIf: boolean): void {
+    const structural = this.rules.structural[node.type];
+    if (structural !== undefined && !isElseIf) {
+      this.addDetail(1 + this.nesting, structural, node);
+    }
+    const flat = this.rules.flat[node.type];
+    if (flat !== undefined) {
+      this.addDetail(1, flat, node);
+    }
+    const operator = this.getLogicalOperator(node);
+    if (operator !== undefined && this.getLogicalOperator(node.parent) !== operator) {
+      this.addDetail(1, `binary ${operator} operator`, node);
+    }
+
+    const conditional = this.rules.conditional;
+    const alternative = conditional && node.type === conditional.type
+      ? node.childForFieldName(conditional.alternativeField)
+      : null;
+    if (alternative) {
+      const elseIf = alternative.type === conditional!.type;
+      const elseToken = node.children.find((c) => !c.isNamed && c.type === "else");
+      this.addDetail(1, elseIf ? "else if clause" : "else clause", elseToken ?? alternative);
+    }
+
+    if (structural !== undefined) {
+      this.nesting++;
+    }
+    for (const child of node.children) {
+      if (!this.functionTypes.has(child.type)) {
+        this.visit(child, child === alternative && alternative.type === node.type);
+      }
+    }
+    if (structural !== undefined) {
+      this.nesting--;
+    }
+  }
+
+  /** Returns the logical operator of a binary expression node, if it is one. */
+  private getLogicalOperator(node: Parser.SyntaxNode | null): string | undefined {
+    if (!node || node.type !== this.rules.binaryExpression) {
+      return undefined;
+    }
+    const operator = node.childForFieldName("operator")?.type;
+    return operator !== undefined && this.logicalOperators.has(operator) ? operator : undefined;
+  }
+
+  private addDetail(increment: number, reason: string, node: Parser.SyntaxNode): void {
+    this.complexity += increment;
+    this.details.push({
+      increment,
+      reason,
+      line: node.startPosition.row,
+      column: node.startPosition.column,
+      nesting: this.nesting,
+    });
+  }
+}
@@ -0,0 +1,101 @@
+/**
+ * @fileoverview Token Count
+ *
+ * Counts the lexical tokens of a function: identifiers, keywords, literals, operators, and
+ * punctuation, with whitespace and comments left out. Like logical lines it measures size,
+ * but it does not depend on formatting, so it sorts long one-liners and sprawling
+ * functions alike. The scan is shared by every language and approximate: it knows comment
+ * syntax and common string quotes, not each language's full lexical grammar.
+ */
+
+import { CommentStyle, getCommentStyle } from "./linesOfCode";
+
+/** Multi-character operators counted as one token, longest first. */
+const OPERATOR = /^(?:>>>=|===|!==|\*\*=|<<=|>>=|\.\.\.|&&=|\|\|=|\?\?=|&\^=|>>>|=>|->|<-|::|:=|==|!=|<=|>=|&&|\|\||\?\?|\?\.|\+\+|--|\+=|-=|\*=|\/=|%=|&=|\|=|\^=|&\^|<<|>>|\*\*|\.\.)/;
+
+/** Characters that start an identifier or keyword. */
+const WORD_START = /[\p{L}_$@]/u;
+
+/** Characters that continue an identifier or keyword. */
+const WORD_PART = /[\p{L}\p{N}_$]/u;
+
+/**
+ * Returns the end of a string literal opening at `start`. Triple-quoted and backtick
+ * strings may span lines; other strings end at their line's end when left unclosed.
+ */
+function skipString(text: string, start: number): number {
+  const quote = text[start];
+  const triple = text.startsWith(quote.repeat(3), start) ? quote.repeat(3) : undefined;
+  let i = start + (triple ? 3 : 1);
+  while (i < text.length) {
+    if (text[i] === "\\") {
+      i += 2;
+    } else if (triple ? text.startsWith(triple, i) : text[i] === quote) {
+      return i + (triple ? 3 : 1);
+    } else if (text[i] === "\n" && !triple && quote !== "`") {
+      return i;
+    } else {
+      i++;
+    }
+  }
+  return text.length;
+}
+
+/** Counts the tokens of a piece of source text. */
+export function countTextTokens(text: string, style: CommentStyle): number {
+  let count = 0;
+  let i = 0;
+  while (i < text.length) {
+    const ch = text[i];
+    if (/\s/.test(ch)) {
+      i++;
+    } else if (text.startsWith(style.line, i)) {
+      const end = text.indexOf("\n", i);
+      i = end === -1 ? text.length : end;
+    } else if (style.block && text.startsWith("/*", i)) {
+      const end = text.indexOf("*/", i + 2);
+      i = end === -1 ? text.length : end + 2;
+    } else {
+      count++;
+      if (ch === '"' || ch === "'" || ch === "`") {
+        i = skipString(text, i);
+      } else if (WORD_START.test(ch)) {
+        i++;
+        while (i < text.length && WORD_PART.test(text[i])) {
+          i++;
+        }
+      } else if (/[0-9]/.test(ch)) {
+        i++;
+        while (i < text.length && /[\w.]/.test(text[i])) {
+          i++;
+        }
+      } else {
+        i += OPERATOR.exec(text.substring(i, i + 4))?.[0].length ?? 1;
+      }
+    }
+  }
+  return count;
+}
+
+/**
+ * Counts the tokens of a function's source range, from its first line and column to its
+ * last, so the signature is included.
+ *
+ * @param lines - The source split into lines
+ * @param func - The function's range (0-based lines and columns, end exclusive)
+ * @param languageId - Language identifier, used to pick the comment syntax
+ * @returns The number of tokens
+ */
+export function countTokens(
+  lines: readonly string[],
+  func: { startLine: number; startColumn: number; endLine: number; endColumn: number },
+  languageId: string
+): number {
+  const range = lines.slice(func.startLine, func.endLine + 1);
+  if (range.length === 0) {
+    return 0;
+  }
+  range[range.length - 1] = range[range.length - 1].substring(0, func.endColumn);
+  range[0] = range[0].substring(func.startColumn);
+  return countTextTokens(range.join("\n"), getCommentStyle(languageId));
+}
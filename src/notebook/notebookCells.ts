@@ -0,0 +1,144 @@
+/**
+ * @fileoverview Notebook Cell Analysis
+ *
+ * Analyzes the Python code cells of a Jupyter notebook one by one. Each cell is reported
+ * with the functions it defines and the complexity of its top-level code, which in
+ * notebooks often holds most of the logic. Markdown cells and cells in other languages
+ * are skipped.
+ *
+ * IPython magics (`%timeit`, `%%capture`, `!pip install`) are not Python and would show
+ * up as syntax errors, so they are blanked before analysis; line numbers are unchanged.
+ */
+
+import {
+  AnalyzerOptions,
+  MetricsAnalyzerFactory,
+  UnifiedFunctionMetrics,
+} from "../metricsAnalyzer/metricsAnalyzerFactory";
+import { CodeMetricsConfig, ConfigurationManager } from "../configuration";
+
+/** URI scheme of the documents VS Code creates for notebook cells. */
+export const NOTEBOOK_CELL_SCHEME = "vscode-notebook-cell";
+
+/** A notebook cell, detached from the notebook API. */
+export interface NotebookCellSource {
+  /** 0-based position of the cell in the notebook */
+  index: number;
+  kind: "code" | "markup";
+  /** Language of the cell's document */
+  languageId: string;
+  text: string;
+}
+
+/** Analysis results for one code cell. */
+export interface CellMetrics {
+  /** 0-based position of the cell in the notebook */
+  index: number;
+  /** Complexity of the statements outside any function (loops and branches at top level) */
+  topLevelComplexity: number;
+  /** Functions and methods defined in the cell, with positions relative to the cell */
+  functions: UnifiedFunctionMetrics[];
+}
+
+/** Name of the function the cell's code is wrapped in to measure its top-level code. */
+const CELL_FUNCTION = "__cell__";
+
+/**
+ * Replaces IPython line and cell magics and shell escapes with blank lines.
+ *
+ * @param text - The cell's source
+ * @returns Python source with the same number of lines
+ */
+export function stripCellMagics(text: string): string {
+  return text
+    .split("\n")
+    .map((line) => (/^\s*(%|!)/.test(line) ? "" : line))
+    .join("\n");
+}
+
+/**
+ * Analyzes the Python code cells of a notebook.
+ *
+ * The top-level code of a cell is measured by analyzing the cell as the body of a
+ * function; the functions it defines are then nested functions, which the Python
+ * analyzer reports separately and does not count toward the enclosing one.
+ *
+ * @param cells - The notebook's cells, in order
+ * @param options - Analyzer options
+ * @returns One entry per Python code cell, in notebook order
+ */
+export function analyzeNotebookCells(
+  cells: readonly NotebookCellSource[],
+  options: AnalyzerOptions = {}
+): CellMetrics[] {
+  const results: CellMetrics[] = [];
+  for (const cell of cells) {
+    if (cell.kind !== "code" || cell.languageId !== "python") {
+      continue;
+    }
+    // The `pass` keeps empty cells valid; one space of indentation shifts every column by 1.
+    const wrapped = `def ${CELL_FUNCTION}():\n pass\n${stripCellMagics(cell.text)
+      .split("\n")
+      .map((line) => ` ${line}`)
+      .join("\n")}`;
+    const functions = MetricsAnalyzerFactory.analyzeFile(wrapped, "python", options);
+    const wrapper = functions.find((func) => func.name === CELL_FUNCTION && func.startLine === 0);
+    results.push({
+      index: cell.index,
+      topLevelComplexity: wrapper?.complexity ?? 0,
+      functions: functions
+        .filter((func) => func !== wrapper)
+        .map((func) => ({
+          ...func,
+          startLine: func.startLine - 2,
+          endLine: func.endLine - 2,
+          startColumn: Math.max(func.startColumn - 1, 0),
+          endColumn: Math.max(func.endColumn - 1, 0),
+          details: func.details.map((d) => ({
+            ...d,
+            line: d.line - 2,
+            column: Math.max(d.column - 1, 1),
+          })),
+        })),
+    });
+  }
+  return results;
+}
+
+/**
+ * Renders the per-cell metrics of a notebook as report lines. Cells are numbered from 1,
+ * as in the notebook editor.
+ *
+ * @param name - The notebook's path, as shown to the user
+ * @param cells - Results from {@link analyzeNotebookCells}
+ * @param config - The configuration used for status bands
+ * @returns Report lines ready to be written to an output channel
+ */
+export function formatNotebookReport(
+  name: string,
+  cells: readonly CellMetrics[],
+  config: CodeMetricsConfig
+): string[] {
+  const lines = [`Notebook: ${name}`];
+  if (cells.length === 0) {
+    lines.push("  No Python code cells found.");
+    return lines;
+  }
+  const functionCount = cells.reduce((n, cell) => n + cell.functions.length, 0);
+  lines.push(`  ${cells.length} code cells, ${functionCount} functions`);
+  for (const cell of cells) {
+    const status = ConfigurationManager.getComplexityStatus(cell.topLevelComplexity, config);
+    lines.push(
+      `  Cell ${cell.index + 1}  ${status.icon} top level ${cell.topLevelComplexity}, ` +
+        `${cell.functions.length} functions`
+    );
+    const sorted = [...cell.functions].sort((a, b) => b.complexity - a.complexity);
+    for (const func of sorted) {
+      const funcStatus = ConfigurationManager.getComplexityStatus(func.complexity, config);
+      lines.push(
+        `    ${funcStatus.icon} ${String(func.complexity).padStart(3)}  ${func.name} (line ${func.startLine + 1})`
+      );
+    }
+  }
+  return lines;
+}
@@ -0,0 +1,438 @@
+/**
+ * @fileoverview Project Configuration
+ *
+ * The configuration options of the extension, their defaults, and the parsing of
+ * `.codemetrics.json` project files. `configuration.ts` resolves these from VS Code
+ * settings; the command-line entry points, which run without VS Code, resolve a folder's
+ * configuration from its project file with {@link loadProjectConfig}, so CI scores
+ * files the way the editor does.
+ *
+ * This module does not depend on the VS Code API.
+ */
+
+import * as fs from "fs";
+import * as path from "path";
+import {
+  AnalysisEngine,
+  AnalyzerOptions,
+  ClosureMode,
+  ComplexityScoring,
+  LiteralFuncMode,
+  OPTIONAL_METRICS,
+  PanicMode,
+} from "./metricsAnalyzer/metricsAnalyzerFactory";
+import { IdentityStrategy } from "./metricsAnalyzer/fingerprint";
+
+/** When CodeLens analysis runs. */
+export type AnalysisTrigger = "onChange" | "onSave" | "manual";
+
+/** How the complexity CodeLens is rendered: the full label, or only the band's colored dot. */
+export type DisplayStyle = "full" | "badge";
+
+/** The order of functions within each file of the workspace report. */
+export type ReportSortBy = "complexity" | "tokens";
+
+/** What a click on the complexity CodeLens opens: the details channel or the explanation panel. */
+export type CodeLensAction = "details" | "explain";
+
+/**
+ * The metric that decides a function's band in the editor: its cognitive complexity, or
+ * the deepest nesting level of its complexity-contributing constructs.
+ */
+export type PrimaryMetric = "cognitive" | "nesting";
+
+/** The metrics `display.primaryMetric` accepts; other values fall back to `cognitive`. */
+export const PRIMARY_METRICS: readonly PrimaryMetric[] = ["cognitive", "nesting"];
+
+/** The `metrics.enabled` entry each primary metric is computed from. */
+export const PRIMARY_METRIC_SOURCES: Readonly<Record<PrimaryMetric, string>> = {
+  cognitive: "complexity",
+  nesting: "complexity",
+};
+
+/**
+ * Primary metrics of other tools that are not implemented, with what to do instead.
+ * They are rejected like any other unknown value.
+ */
+export const UNSUPPORTED_PRIMARY_METRICS: Readonly<Record<string, string>> = {
+  cyclomatic: 'set complexity.scoring to "cyclomatic" to score functions by it',
+  maintainability: "no maintainability index is computed",
+};
+
+/**
+ * A named set of counting rules matching another tool's conventions, or `custom` for the
+ * individual `complexity.scoring`, `complexity.base`, `complexity.panic`, `closureMode`, and
+ * `literalFuncs` settings.
+ */
+export type ComplexityPreset = "custom" | "sonar" | "gocyclo" | "mccabe-classic";
+
+/** The rule settings chosen by each preset; they replace the individual settings. */
+export const COMPLEXITY_PRESETS: Readonly<
+  Record<
+    Exclude<ComplexityPreset, "custom">,
+    Pick<
+      CodeMetricsConfig,
+      "complexityScoring" | "complexityBase" | "panicMode" | "closureMode" | "literalFuncMode"
+    >
+  >
+> = {
+  // SonarQube's cognitive complexity: starts at 0, nested functions add to their parent.
+  sonar: {
+    complexityScoring: "cognitive",
+    complexityBase: 0,
+    panicMode: "statement",
+    closureMode: "inline",
+    literalFuncMode: "attributed",
+  },
+  // gocyclo: decision points from 1, every func literal counts in the declaring function.
+  gocyclo: {
+    complexityScoring: "cyclomatic",
+    complexityBase: 1,
+    panicMode: "statement",
+    closureMode: "inline",
+    literalFuncMode: "attributed",
+  },
+  // McCabe's graph of one declared function: decision points from 1, panics are exits.
+  "mccabe-classic": {
+    complexityScoring: "cyclomatic",
+    complexityBase: 1,
+    panicMode: "exit",
+    closureMode: "inline",
+    literalFuncMode: "attributed",
+  },
+};
+
+/**
+ * Interface defining all configuration options for the code metrics extension.
+ * This interface ensures type safety when accessing configuration values.
+ */
+export interface CodeMetricsConfig {
+  /** Whether the extension is enabled */
+  enabled: boolean;
+  /** Whether to show CodeLens above functions */
+  showCodeLens: boolean;
+  /** Complexity threshold for warning status (yellow indicator) */
+  warningThreshold: number;
+  /** Complexity threshold for error status (red indicator) */
+  errorThreshold: number;
+  /** Nesting level for warning status when nesting is the primary metric */
+  nestingWarningThreshold: number;
+  /** Nesting level for error status when nesting is the primary metric */
+  nestingErrorThreshold: number;
+  /** Glob patterns for files to exclude from analysis */
+  excludePatterns: string[];
+  /** Maximum distinct receiver fields a method may access before it is flagged (0 disables) */
+  fieldAccessThreshold: number;
+  /** Maximum boolean operands allowed in a single condition before it is flagged (0 disables) */
+  conditionOperandThreshold: number;
+  /** Deepest conditional expression nesting allowed before a function is flagged (0 disables) */
+  expressionNestingThreshold: number;
+  /** Closure nesting depth above which a function is flagged (0 disables) */
+  closureDepthThreshold: number;
+  /** Most clauses a switch or select may have before it is flagged (0 disables) */
+  caseClauseThreshold: number;
+  /** Branches per statement above which a function is flagged (0 disables) */
+  branchDensityThreshold: number;
+  /** Percentage of a file's total complexity at which a single function is flagged (0 disables) */
+  dominantFunctionShare: number;
+  /** Metrics to compute and show: `complexity` (always computed), `linesOfCode`, `tokens`, `recursion` */
+  enabledMetrics: string[];
+  /** Whether test files are analyzed even when they match an exclude pattern */
+  includeTests: boolean;
+  /** How closures are reported: merged into their parent, as their own entries, or both */
+  closureMode: ClosureMode;
+  /** Whether to show a file summary CodeLens (e.g. the longest function) at the top of each file */
+  showFileSummary: boolean;
+  /** Path to an lcov or Go cover profile, relative to the workspace folder (empty disables) */
+  coverageFile: string;
+  /** Coverage percentage below which complex functions are flagged as risky */
+  coverageThreshold: number;
+  /** Whether generated code is left out of metrics (otherwise it is analyzed and tagged) */
+  excludeGenerated: boolean;
+  /** Whether the workspace report groups Go platform variants (`foo_linux.go`, `foo_windows.go`) */
+  groupPlatformVariants: boolean;
+  /** Whether the workspace report compares the per-platform implementations of Go functions */
+  groupPlatformFunctions: boolean;
+  /** Locale used to format non-integer metrics for display; empty uses VS Code's display language */
+  displayLocale: string;
+  /** Whether complexity lenses show the full label or only a colored badge */
+  displayStyle: DisplayStyle;
+  /** Whether clicking a complexity lens writes the breakdown or opens the explanation panel */
+  codeLensAction: CodeLensAction;
+  /** Label of complexity lenses with `{status}`, `{value}`, `{score}` and `{name}` placeholders; empty uses the built-in label */
+  codeLensTemplate: string;
+  /** Whether function headers are marked in the overview ruler with their band's color */
+  overviewRuler: boolean;
+  /** Whether the status bar shows how many functions of the active file are over the threshold */
+  statusBar: boolean;
+  /** Whether the status bar item is hidden for files without functions over the threshold */
+  statusBarHideWhenZero: boolean;
+  /** Whether function symbols named with their complexity are added for breadcrumbs and the Outline */
+  symbolComplexity: boolean;
+  /** Most functions of a file that get CodeLens; larger files show the most complex ones (0: no limit) */
+  codeLensLimit: number;
+  /** The metric that decides the band of CodeLens, overview ruler marks, and threshold diagnostics */
+  primaryMetric: PrimaryMetric;
+  /** Whether functions over the primary metric's warning threshold get a Problems-panel diagnostic */
+  thresholdDiagnostics: boolean;
+  /** When CodeLens analysis runs: on every change, on save, or only on request */
+  analysisTrigger: AnalysisTrigger;
+  /** Whether saving a file that brings a function over the warning threshold shows a modal warning */
+  saveGuardrail: boolean;
+  /** Whether workspace analysis descends into the git submodules listed in `.gitmodules` */
+  includeSubmodules: boolean;
+  /** Line count above which CodeLens analysis runs in the background (0 disables) */
+  largeFileThreshold: number;
+  /** Whether files above `largeFileThreshold` are skipped until analysis is requested */
+  skipLargeFiles: boolean;
+  /** Whether files above `largeFileThreshold` analyze only functions in and near the visible range */
+  lazyAnalysis: boolean;
+  /** Advanced: whether syntax node counts are collected and shown in function details */
+  showNodeCounts: boolean;
+  /** Whether exports give every function a unique id of root, file path, and qualified name */
+  exportQualifiedNames: boolean;
+  /** Whether the workspace report aggregates method-chain statistics per fluent type (Go) */
+  reportFluentChains: boolean;
+  /** Debt estimate: minutes for each function at or above the warning threshold */
+  debtBaseMinutes: number;
+  /** Debt estimate: minutes per complexity point over the warning threshold */
+  debtMinutesPerPoint: number;
+  /** Whether the workspace report lists Go functions no function of their package calls */
+  reportUnusedFunctions: boolean;
+  /** Whether exported Go functions, which may be called from elsewhere, are listed as unused */
+  unusedFunctionsIncludeExported: boolean;
+  /** Whether the workspace report lists per-type method counts (NOM) and summed complexity (WMC) for Go */
+  reportTypeMetrics: boolean;
+  /** Whether the workspace report lists Go functions that could be methods, and methods that could be functions */
+  reportMethodCandidates: boolean;
+  /** Whether the workspace report lists each file's functions by complexity or by token count */
+  reportSortBy: ReportSortBy;
+  /** Whether value- and pointer-receiver methods of a Go type count as one type in the type metrics */
+  typeMetricsMergeReceivers: boolean;
+  /** Whether a summary notification is shown when a workspace or folder analysis completes */
+  showCompletionSummary: boolean;
+  /** Base ref that Compare with Baseline measures changes against, e.g. `origin/main` */
+  baselineRef: string;
+  /** Largest net complexity change Check Complexity Budget allows (null: no budget) */
+  complexityBudget: number | null;
+  /** How functions are matched across versions: by name, or by content fingerprint */
+  identityStrategy: IdentityStrategy;
+  /** Minimum complexity for a function to get a `metrics: cc=N` annotation comment */
+  annotationThreshold: number;
+  /** Which analyzer scores languages that have a rule table: the built-in one, or the rule engine */
+  analysisEngine: AnalysisEngine;
+  /** Whether hovering a function's first line shows its complexity over recent commits (git) */
+  historyHover: boolean;
+  /** Regular expressions matched against qualified function names; matches are not reported */
+  excludeFunctionPatterns: string[];
+  /** Regular expressions matched against qualified function names; matches are tagged as resolvers */
+  resolverPatterns: string[];
+  /** Preset deciding the scoring, complexity base, panic, closure, and literal func rules; `custom` uses the individual settings */
+  complexityPreset: ComplexityPreset;
+  /** How functions are scored: cognitive complexity, or gocyclo's decision points */
+  complexityScoring: ComplexityScoring;
+  /** Value every function's complexity starts at: 0 (cognitive complexity) or 1 (McCabe-style) */
+  complexityBase: number;
+  /** Go files with more lines than this are parsed one declaration at a time (0: never) */
+  streamingThreshold: number;
+  /** Regular expressions matched against `if` conditions to recognize feature flag checks */
+  featureFlagPatterns: string[];
+  /** How Go `panic(...)` calls count: as a plain statement, an exit point, or a branch */
+  panicMode: PanicMode;
+  /** Whether Go composite literal func values, e.g. dispatch table handlers, also count in their function */
+  literalFuncMode: LiteralFuncMode;
+}
+
+/**
+ * Default configuration values used when user hasn't specified custom values.
+ */
+export const DEFAULT_CONFIG: CodeMetricsConfig = {
+  enabled: true,
+  showCodeLens: true,
+  warningThreshold: 10,
+  errorThreshold: 15,
+  nestingWarningThreshold: 3,
+  nestingErrorThreshold: 5,
+  excludePatterns: [
+    "**/node_modules/**",
+    "**/dist/**",
+    "**/build/**",
+    "**/out/**",
+    "**/*.min.js",
+    "**/*.spec.*",
+    "**/*.test.*",
+  ],
+  fieldAccessThreshold: 0,
+  conditionOperandThreshold: 0,
+  expressionNestingThreshold: 0,
+  closureDepthThreshold: 0,
+  caseClauseThreshold: 20,
+  branchDensityThreshold: 0,
+  dominantFunctionShare: 50,
+  enabledMetrics: ["complexity", "linesOfCode", "recursion"],
+  includeTests: false,
+  closureMode: "inline",
+  showFileSummary: false,
+  coverageFile: "",
+  coverageThreshold: 50,
+  excludeGenerated: true,
+  groupPlatformVariants: false,
+  groupPlatformFunctions: false,
+  displayLocale: "",
+  displayStyle: "full",
+  codeLensAction: "details",
+  codeLensTemplate: "",
+  overviewRuler: false,
+  statusBar: true,
+  statusBarHideWhenZero: false,
+  symbolComplexity: false,
+  codeLensLimit: 500,
+  primaryMetric: "cognitive",
+  thresholdDiagnostics: false,
+  analysisTrigger: "onChange",
+  saveGuardrail: false,
+  includeSubmodules: false,
+  largeFileThreshold: 3000,
+  skipLargeFiles: false,
+  lazyAnalysis: false,
+  showNodeCounts: false,
+  exportQualifiedNames: true,
+  reportFluentChains: false,
+  debtBaseMinutes: 5,
+  debtMinutesPerPoint: 1,
+  reportUnusedFunctions: false,
+  unusedFunctionsIncludeExported: false,
+  reportTypeMetrics: false,
+  reportMethodCandidates: false,
+  reportSortBy: "complexity",
+  typeMetricsMergeReceivers: true,
+  showCompletionSummary: true,
+  baselineRef: "origin/main",
+  complexityBudget: null,
+  identityStrategy: "name",
+  annotationThreshold: 10,
+  analysisEngine: "builtin",
+  historyHover: false,
+  excludeFunctionPatterns: [],
+  resolverPatterns: [],
+  complexityPreset: "custom",
+  complexityScoring: "cognitive",
+  complexityBase: 0,
+  streamingThreshold: 20000,
+  featureFlagPatterns: [],
+  panicMode: "statement",
+  literalFuncMode: "attributed",
+};
+
+/** Name of the optional per-root project configuration file. */
+export const PROJECT_CONFIG_FILE = ".codemetrics.json";
+
+/**
+ * Parses the contents of a `.codemetrics.json` project file into configuration overrides.
+ *
+ * Keys use the setting names without the `codeMetrics.` prefix (e.g. `warningThreshold`).
+ * Unknown keys and values whose type does not match the setting's default are ignored,
+ * so a partially invalid file still applies its valid entries.
+ *
+ * @param text - Raw file contents
+ * @returns The valid overrides (empty when the file is not a JSON object)
+ */
+export function parseProjectConfig(text: string): Partial<CodeMetricsConfig> {
+  let parsed: unknown;
+  try {
+    parsed = JSON.parse(text);
+  } catch (error) {
+    console.warn(`Ignoring invalid ${PROJECT_CONFIG_FILE}:`, error);
+    return {};
+  }
+  return toConfigOverrides(parsed);
+}
+
+/**
+ * Keeps the entries of an object that name a known setting with a value of the right type.
+ *
+ * @param candidate - Untrusted object (parsed JSON or a `codeMetrics.profiles` entry)
+ * @returns The valid overrides (empty when the value is not a plain object)
+ */
+export function toConfigOverrides(candidate: unknown): Partial<CodeMetricsConfig> {
+  if (typeof candidate !== "object" || candidate === null || Array.isArray(candidate)) {
+    return {};
+  }
+
+  const overrides: Record<string, unknown> = {};
+  for (const [key, value] of Object.entries(candidate as Record<string, unknown>)) {
+    if (!(key in DEFAULT_CONFIG)) {
+      continue;
+    }
+    const defaultValue = DEFAULT_CONFIG[key as keyof CodeMetricsConfig];
+    const matches = Array.isArray(defaultValue)
+      ? Array.isArray(value) && value.every((v) => typeof v === "string")
+      : defaultValue === null
+        ? value === null || typeof value === "number" // optional numbers, e.g. `complexityBudget`
+        : typeof value === typeof defaultValue;
+    if (matches) {
+      overrides[key] = value;
+    }
+  }
+  return overrides as Partial<CodeMetricsConfig>;
+}
+
+
+/**
+ * Applies the complexity preset of a resolved configuration: a preset other than
+ * `custom`, wherever it was chosen, decides the rule settings it covers.
+ *
+ * @param config - The resolved configuration, changed in place
+ * @returns The same configuration
+ */
+export function applyComplexityPreset(config: CodeMetricsConfig): CodeMetricsConfig {
+  if (Object.prototype.hasOwnProperty.call(COMPLEXITY_PRESETS, config.complexityPreset)) {
+    Object.assign(
+      config,
+      COMPLEXITY_PRESETS[config.complexityPreset as keyof typeof COMPLEXITY_PRESETS]
+    );
+  }
+  return config;
+}
+
+/**
+ * Derives the analyzer options from a resolved configuration.
+ *
+ * @param config - The resolved configuration
+ * @returns Options to pass to `MetricsAnalyzerFactory.analyzeFile`
+ */
+export function toAnalyzerOptions(config: CodeMetricsConfig): AnalyzerOptions {
+  return {
+    metrics: OPTIONAL_METRICS.filter((metric) => config.enabledMetrics.includes(metric)),
+    closureMode: config.closureMode,
+    excludeGenerated: config.excludeGenerated,
+    collectNodeCounts: config.showNodeCounts,
+    fingerprints: config.identityStrategy === "fingerprint",
+    engine: config.analysisEngine,
+    excludeFunctions: config.excludeFunctionPatterns,
+    // Only 0 and 1 are meaningful; anything else (e.g. from `.codemetrics.json`) counts as 0.
+    complexityBase: config.complexityBase === 1 ? 1 : 0,
+    streamingThreshold: config.streamingThreshold,
+    featureFlagPatterns: config.featureFlagPatterns,
+    resolverPatterns: config.resolverPatterns,
+    panicMode: config.panicMode,
+    literalFuncMode: config.literalFuncMode,
+    scoring: config.complexityScoring,
+  };
+}
+
+/**
+ * Resolves the configuration of a folder without VS Code: the defaults with the folder's
+ * `.codemetrics.json` and its preset applied. VS Code settings are not available, so
+ * only the project file differs from the defaults.
+ *
+ * @param folderPath - The folder whose project file applies
+ * @returns The resolved configuration
+ */
+export function loadProjectConfig(folderPath: string): CodeMetricsConfig {
+  const filePath = path.join(folderPath, PROJECT_CONFIG_FILE);
+  const overrides = fs.existsSync(filePath)
+    ? parseProjectConfig(fs.readFileSync(filePath, "utf8"))
+    : {};
+  return applyComplexityPreset({ ...DEFAULT_CONFIG, ...overrides });
+}
@@ -1,24 +1,14 @@
 import * as vscode from "vscode";
 import {
+  AnalyzerOptions,
+  LineRange,
   MetricsAnalyzerFactory,
   UnifiedFunctionMetrics,
 } from "../metricsAnalyzer/metricsAnalyzerFactory";
+import { getLongestFunction } from "../metricsAnalyzer/linesOfCode";
 import { ConfigurationManager, CodeMetricsConfig } from "../configuration";
-
-/**
- * Compiled regex cache for exclude patterns.
- * Key: joined pattern string (patterns change rarely; cache avoids per-request recompilation).
- * Value: array of compiled { regex, isFullPath } entries ready for matching.
- * Capped at EXCLUDE_CACHE_MAX_SIZE entries (LRU eviction) to prevent unbounded growth when
- * workspace settings vary across many open folders or when settings change frequently.
- */
-const excludeRegexCache = new Map<
-  string,
-  { regex: RegExp; isFullPath: boolean }[]
->();
-
-/** Maximum number of distinct pattern-list compilations to keep in the exclude regex cache. */
-const EXCLUDE_CACHE_MAX_SIZE = 32;
+import { isExcludedFile } from "../workspace/fileFilters";
+import { NOTEBOOK_CELL_SCHEME, stripCellMagics } from "../notebook/notebookCells";
 
 const CONFIG_CACHE_MAX_SIZE = 32;
 
@@ -34,55 +24,117 @@ const ANALYSIS_CACHE_MAX_SIZE = 64;
  */
 const EXCLUDE_RESULT_CACHE_MAX_SIZE = 512;
 
-/** Compiles a single glob pattern into a regex, honouring `**`, `*`, `?` wildcards. */
-function compileExcludePattern(
-  pattern: string
-): { regex: RegExp; isFullPath: boolean } {
-  const normalized = pattern.replace(/\\/g, "/");
-  const isFullPath = normalized.includes("/");
-
-  if (isFullPath) {
-    const regexPattern = normalized
-      .replace(/\*\*/g, "\x00DS\x00")
-      .replace(/\*/g, "\x00S\x00")
-      .replace(/\?/g, "\x00Q\x00")
-      .replace(/[.+^${}()|[\]\\]/g, "\\$&")
-      .replace(/\x00DS\x00/g, ".*")
-      .replace(/\x00S\x00/g, "[^/]*")
-      .replace(/\x00Q\x00/g, "[^/]");
-    return { regex: new RegExp(`^${regexPattern}$`), isFullPath: true };
-  } else {
-    const regexPattern = normalized
-      .replace(/\*/g, "\x00S\x00")
-      .replace(/\?/g, "\x00Q\x00")
-      .replace(/[.+^${}()|[\]\\]/g, "\\$&")
-      .replace(/\x00S\x00/g, ".*")
-      .replace(/\x00Q\x00/g, ".");
-    return { regex: new RegExp(`^${regexPattern}$`), isFullPath: false };
+/**
+ * Lines analyzed above and below the visible range of a lazily analyzed file, so short
+ * scrolls find their lenses ready.
+ */
+const LAZY_ANALYSIS_MARGIN = 200;
+
+/**
+ * Selects the functions of a file that get lenses. A file with more functions than
+ * `display.codeLensLimit` keeps only the most complex ones by the primary metric
+ * (ties broken by cognitive complexity), so rendering stays fast on huge files.
+ *
+ * @param functions - The analyzed functions of the file, in source order
+ * @param config - The configuration providing the limit and the primary metric
+ * @returns The functions to render, in source order
+ */
+export function getCodeLensFunctions(
+  functions: UnifiedFunctionMetrics[],
+  config: CodeMetricsConfig
+): UnifiedFunctionMetrics[] {
+  if (config.codeLensLimit <= 0 || functions.length <= config.codeLensLimit) {
+    return functions;
+  }
+  return functions
+    .map((func, index) => ({
+      func,
+      index,
+      value: ConfigurationManager.getPrimaryMetricStatus(func, config).value,
+    }))
+    .sort((a, b) => b.value - a.value || b.func.complexity - a.func.complexity || a.index - b.index)
+    .slice(0, config.codeLensLimit)
+    .sort((a, b) => a.index - b.index)
+    .map((entry) => entry.func);
+}
+
+/**
+ * Fills a complexity lens template (`display.codeLensTemplate`). Placeholders are written
+ * in braces, e.g. `Complejidad {value}`; unknown placeholders are kept as written.
+ *
+ * @param template - The template text
+ * @param fields - Placeholder values by name: `status`, `value`, `score` and `name`
+ * @returns The label
+ */
+export function formatCodeLensLabel(template: string, fields: Record<string, string>): string {
+  return template.replace(/\{(\w+)\}/g, (placeholder, key: string) =>
+    Object.hasOwn(fields, key) ? fields[key] : placeholder
+  );
+}
+
+/**
+ * Merges line ranges into the fewest sorted, non-overlapping ranges; adjacent ranges join.
+ *
+ * @param ranges - The ranges, in any order
+ * @returns The merged ranges, sorted by start
+ */
+export function mergeLineRanges(ranges: readonly LineRange[]): LineRange[] {
+  const merged: LineRange[] = [];
+  for (const range of [...ranges].sort((a, b) => a.start - b.start)) {
+    const last = merged[merged.length - 1];
+    if (last && range.start <= last.end + 1) {
+      last.end = Math.max(last.end, range.end);
+    } else {
+      merged.push({ ...range });
+    }
   }
+  return merged;
 }
 
-/** Returns compiled regex entries for the given patterns, using a cache to avoid recompilation. */
-function getCompiledPatterns(
-  patterns: string[]
-): { regex: RegExp; isFullPath: boolean }[] {
-  // Normalize separators before keying so Windows paths (backslash) and
-  // forward-slash paths for the same pattern list share a single cache entry.
-  const cacheKey = patterns.map((p) => p.replace(/\\/g, "/")).join("\x00");
-  let compiled = excludeRegexCache.get(cacheKey);
-  if (!compiled) {
-    compiled = patterns.map(compileExcludePattern);
-    if (excludeRegexCache.size >= EXCLUDE_CACHE_MAX_SIZE) {
-      // Evict the least-recently-used entry (first key in insertion order).
-      excludeRegexCache.delete(excludeRegexCache.keys().next().value!);
+/**
+ * Finds the lines of some ranges that other ranges do not cover.
+ *
+ * @param ranges - The ranges wanted, in any order
+ * @param covered - The ranges already covered, as returned by {@link mergeLineRanges}
+ * @returns The uncovered parts, sorted by start
+ */
+export function subtractLineRanges(
+  ranges: readonly LineRange[],
+  covered: readonly LineRange[]
+): LineRange[] {
+  const missing: LineRange[] = [];
+  for (const range of mergeLineRanges(ranges)) {
+    let start = range.start;
+    for (const cover of covered) {
+      if (cover.end < start || cover.start > range.end) {
+        continue;
+      }
+      if (cover.start > start) {
+        missing.push({ start, end: cover.start - 1 });
+      }
+      start = cover.end + 1;
+    }
+    if (start <= range.end) {
+      missing.push({ start, end: range.end });
     }
-    excludeRegexCache.set(cacheKey, compiled);
-  } else {
-    // Refresh LRU order: move this entry to the end.
-    excludeRegexCache.delete(cacheKey);
-    excludeRegexCache.set(cacheKey, compiled);
   }
-  return compiled;
+  return missing;
+}
+
+/**
+ * Returns the lines of a document visible in its editors, widened by
+ * {@link LAZY_ANALYSIS_MARGIN}; the top of the document when no editor shows it yet.
+ */
+function getViewportRanges(document: vscode.TextDocument): LineRange[] {
+  const uri = document.uri.toString();
+  const visible = vscode.window.visibleTextEditors
+    .filter((editor) => editor.document.uri.toString() === uri)
+    .flatMap((editor) => editor.visibleRanges);
+  const ranges = visible.map((range) => ({ start: range.start.line, end: range.end.line }));
+  return (ranges.length > 0 ? ranges : [{ start: 0, end: 0 }]).map((range) => ({
+    start: Math.max(0, range.start - LAZY_ANALYSIS_MARGIN),
+    end: Math.min(document.lineCount - 1, range.end + LAZY_ANALYSIS_MARGIN),
+  }));
 }
 
 export class MetricsCodeLensProvider implements vscode.CodeLensProvider {
@@ -95,7 +147,7 @@ export class MetricsCodeLensProvider implements vscode.CodeLensProvider {
    * the global scope when there is no workspace folder).
    *
    * `provideCodeLenses` is called on every keystroke, so avoiding repeated `getConfiguration`
-   * round-trips — which each make 5 separate VS Code API calls — measurably reduces overhead.
+   * round-trips — which make one VS Code API call per setting — measurably reduces overhead.
    * The cache is cleared by the configuration change watcher whenever settings change.
    */
   private readonly configCache = new Map<string, CodeMetricsConfig>();
@@ -136,16 +188,43 @@ export class MetricsCodeLensProvider implements vscode.CodeLensProvider {
    */
   private readonly codeLensCache = new Map<string, vscode.CodeLens[]>();
 
+  /**
+   * Analysis results pinned per document URI when `analysis.trigger` is `onSave` or `manual`.
+   * Lenses keep showing the pinned results while the document is edited; a pin is replaced
+   * on save (`onSave`) or by the Analyze Current File command, and dropped on close.
+   */
+  private readonly pinnedAnalysis = new Map<
+    string,
+    { analysisKey: string; functions: UnifiedFunctionMetrics[] }
+  >();
+
+  /**
+   * Background analyses of large files keyed by analysis key. While one is pending the
+   * document shows a placeholder lens; lenses refresh when it completes.
+   */
+  private readonly pendingAnalysis = new Map<string, Promise<void>>();
+
+  /**
+   * Partial analyses of large files with `analysis.lazy`, keyed by document URI: the lines
+   * analyzed so far at one analysis key, and the functions found in them. Lines joining
+   * the viewport are analyzed and added; an edit starts over.
+   */
+  private readonly lazyAnalysis = new Map<
+    string,
+    { analysisKey: string; covered: LineRange[]; functions: UnifiedFunctionMetrics[] }
+  >();
+
   public async provideCodeLenses(
     document: vscode.TextDocument,
     token: vscode.CancellationToken
   ): Promise<vscode.CodeLens[]> {
     // Use a per-workspace-folder config cache to avoid repeated VS Code API calls on every keystroke.
+    // Languages are cached apart, since `[language]` settings may set their own thresholds.
     const folder = vscode.workspace.getWorkspaceFolder(document.uri);
-    const configKey = folder ? folder.uri.toString() : "";
+    const configKey = `${folder ? folder.uri.toString() : ""}#${document.languageId}`;
     let config = this.configCache.get(configKey);
     if (!config) {
-      config = ConfigurationManager.getConfiguration(document.uri);
+      config = ConfigurationManager.getConfiguration(document.uri, document.languageId);
       if (this.configCache.size >= CONFIG_CACHE_MAX_SIZE) {
         // Evict the oldest entry in insertion order.
         const oldestKey = this.configCache.keys().next().value;
@@ -168,7 +247,7 @@ export class MetricsCodeLensProvider implements vscode.CodeLensProvider {
       return [];
     }
 
-    if (this.isExcluded(document.uri.fsPath, config.excludePatterns)) {
+    if (this.isExcluded(document.uri.fsPath, config)) {
       return [];
     }
 
@@ -180,26 +259,44 @@ export class MetricsCodeLensProvider implements vscode.CodeLensProvider {
     }
 
     try {
-      const analysisKey = `${document.uri.toString()}#${document.languageId}#${document.version}`;
-      let functions = this.analysisCache.get(analysisKey);
-      if (!functions) {
-        const sourceText = document.getText();
-        functions = MetricsAnalyzerFactory.analyzeFile(
-          sourceText,
-          document.languageId
-        );
-        if (this.analysisCache.size >= ANALYSIS_CACHE_MAX_SIZE) {
-          // Evict the least-recently-used entry (first key in insertion order).
-          const oldestKey = this.analysisCache.keys().next().value;
-          if (oldestKey !== undefined) {
-            this.analysisCache.delete(oldestKey);
-          }
+      const options = ConfigurationManager.getAnalyzerOptions(config);
+      const isLarge =
+        config.largeFileThreshold > 0 && document.lineCount > config.largeFileThreshold;
+      const pinned = this.pinnedAnalysis.get(document.uri.toString());
+      let analysisKey: string;
+      let functions: UnifiedFunctionMetrics[];
+      if (
+        isLarge &&
+        config.lazyAnalysis &&
+        config.analysisTrigger === "onChange" &&
+        MetricsAnalyzerFactory.supportsLineRanges(document.languageId)
+      ) {
+        // The visible part is analyzed at once; lenses are rendered fresh as it grows.
+        return this.createCodeLenses(this.analyzeViewport(document, options), document, config);
+      }
+      if (isLarge && config.skipLargeFiles && !pinned) {
+        // Large files are skipped until Analyze Current File is run for them.
+        return [this.createLargeFileCodeLens(document, "skipped")];
+      }
+      if (config.analysisTrigger === "onChange" && !(isLarge && config.skipLargeFiles)) {
+        analysisKey = this.getAnalysisKey(document, options);
+        if (isLarge && !this.analysisCache.has(analysisKey)) {
+          void this.analyzeInBackground(document, analysisKey, options, false);
+          return [this.createLargeFileCodeLens(document, "analyzing")];
         }
-        this.analysisCache.set(analysisKey, functions);
+        functions = this.analyze(document, analysisKey, options);
+      } else if (pinned) {
+        ({ analysisKey, functions } = pinned);
+      } else if (config.analysisTrigger === "manual") {
+        return [];
       } else {
-        // Refresh LRU order.
-        this.analysisCache.delete(analysisKey);
-        this.analysisCache.set(analysisKey, functions);
+        // onSave: a newly opened document is analyzed once, as it was loaded.
+        if (isLarge) {
+          const key = this.getAnalysisKey(document, options);
+          void this.analyzeInBackground(document, key, options, true);
+          return [this.createLargeFileCodeLens(document, "analyzing")];
+        }
+        ({ analysisKey, functions } = this.pin(document, options));
       }
 
       // Return a cached CodeLens array when both the document content and config are unchanged,
@@ -223,6 +320,185 @@ export class MetricsCodeLensProvider implements vscode.CodeLensProvider {
     }
   }
 
+  /**
+   * Analyzes the current text of a document and pins the result, so `onSave` and
+   * `manual` trigger modes show it until the next save or explicit analysis.
+   *
+   * @param document - The document to analyze
+   * @returns The pinned analysis
+   */
+  public analyzeNow(
+    document: vscode.TextDocument
+  ): { analysisKey: string; functions: UnifiedFunctionMetrics[] } {
+    const config = ConfigurationManager.getConfiguration(document.uri, document.languageId);
+    return this.pin(document, ConfigurationManager.getAnalyzerOptions(config));
+  }
+
+  private pin(
+    document: vscode.TextDocument,
+    options: AnalyzerOptions
+  ): { analysisKey: string; functions: UnifiedFunctionMetrics[] } {
+    const analysisKey = this.getAnalysisKey(document, options);
+    const pinned = { analysisKey, functions: this.analyze(document, analysisKey, options) };
+    this.pinnedAnalysis.set(document.uri.toString(), pinned);
+    return pinned;
+  }
+
+  /**
+   * Analyzes a large document off the current call, with a status bar progress indicator,
+   * and refreshes lenses when done. Concurrent requests for the same key share one analysis.
+   *
+   * @param pin - Whether to pin the result (trigger modes other than `onChange`)
+   */
+  private analyzeInBackground(
+    document: vscode.TextDocument,
+    analysisKey: string,
+    options: AnalyzerOptions,
+    pin: boolean
+  ): Promise<void> {
+    const pending = this.pendingAnalysis.get(analysisKey);
+    if (pending) {
+      return pending;
+    }
+    const task = Promise.resolve(
+      vscode.window.withProgress(
+        {
+          location: vscode.ProgressLocation.Window,
+          title: vscode.l10n.t("Code Metrics: Analyzing {0} lines", document.lineCount),
+        },
+        () =>
+          new Promise<void>((resolve) => {
+            // Yield first so the placeholder lens is returned before parsing starts.
+            setTimeout(() => {
+              try {
+                const functions = this.analyze(document, analysisKey, options);
+                if (pin) {
+                  this.pinnedAnalysis.set(document.uri.toString(), { analysisKey, functions });
+                }
+              } catch (error) {
+                console.error("Error analyzing large file:", error);
+              }
+              resolve();
+            }, 0);
+          })
+      )
+    ).finally(() => {
+      this.pendingAnalysis.delete(analysisKey);
+      this.refresh();
+    });
+    this.pendingAnalysis.set(analysisKey, task);
+    return task;
+  }
+
+  /** Resolves once every background analysis started so far has finished. */
+  public async waitForPendingAnalysis(): Promise<void> {
+    await Promise.all(this.pendingAnalysis.values());
+  }
+
+  /**
+   * Creates the placeholder lens shown at the top of a large file while it is analyzed
+   * in the background, or while it is skipped (clicking it analyzes the file).
+   */
+  private createLargeFileCodeLens(
+    document: vscode.TextDocument,
+    state: "analyzing" | "skipped"
+  ): vscode.CodeLens {
+    const range = new vscode.Range(0, 0, 0, 0);
+    const command: vscode.Command =
+      state === "analyzing"
+        ? {
+            title: `$(sync~spin) ${vscode.l10n.t("Analyzing {0} lines…", document.lineCount)}`,
+            command: "",
+          }
+        : {
+            title: `⏭️ ${vscode.l10n.t(
+              "Large file skipped ({0} lines) — click to analyze",
+              document.lineCount
+            )}`,
+            command: "codeMetrics.analyzeCurrentFile",
+          };
+    return new vscode.CodeLens(range, command);
+  }
+
+  /**
+   * Analyzes the lines of a document in and near its editors' viewport that earlier calls
+   * at the same version have not, and adds their functions to those found before.
+   *
+   * @returns Every function analyzed so far, in source order
+   */
+  private analyzeViewport(
+    document: vscode.TextDocument,
+    options: AnalyzerOptions
+  ): UnifiedFunctionMetrics[] {
+    const analysisKey = this.getAnalysisKey(document, options);
+    const uri = document.uri.toString();
+    let lazy = this.lazyAnalysis.get(uri);
+    if (!lazy || lazy.analysisKey !== analysisKey) {
+      lazy = { analysisKey, covered: [], functions: [] };
+      this.lazyAnalysis.set(uri, lazy);
+    }
+    const missing = subtractLineRanges(getViewportRanges(document), lazy.covered);
+    if (missing.length > 0) {
+      const known = new Set(lazy.functions.map((func) => func.startLine));
+      const found = MetricsAnalyzerFactory.analyzeFile(document.getText(), document.languageId, {
+        ...options,
+        lineRanges: missing,
+      }).filter((func) => !known.has(func.startLine));
+      lazy.functions = [...lazy.functions, ...found].sort((a, b) => a.startLine - b.startLine);
+      lazy.covered = mergeLineRanges([...lazy.covered, ...missing]);
+    }
+    return lazy.functions;
+  }
+
+  /**
+   * Refreshes lenses when the viewport of a lazily analyzed document reaches lines that
+   * have not been analyzed yet.
+   */
+  public onVisibleRangesChanged(document: vscode.TextDocument): void {
+    const lazy = this.lazyAnalysis.get(document.uri.toString());
+    if (lazy && subtractLineRanges(getViewportRanges(document), lazy.covered).length > 0) {
+      this.refresh();
+    }
+  }
+
+  /** Analyzer options (e.g. closure mode) change the result, so they are part of the key. */
+  private getAnalysisKey(document: vscode.TextDocument, options: AnalyzerOptions): string {
+    return `${document.uri.toString()}#${document.languageId}#${document.version}#${JSON.stringify(options)}`;
+  }
+
+  /** Analyzes a document's current text, reusing cached results for the same key. */
+  private analyze(
+    document: vscode.TextDocument,
+    analysisKey: string,
+    options: AnalyzerOptions
+  ): UnifiedFunctionMetrics[] {
+    let functions = this.analysisCache.get(analysisKey);
+    if (!functions) {
+      // Notebook cells get lenses like files; their IPython magics are not Python.
+      const sourceText = document.uri.scheme === NOTEBOOK_CELL_SCHEME
+        ? stripCellMagics(document.getText())
+        : document.getText();
+      functions = MetricsAnalyzerFactory.analyzeFile(
+        sourceText,
+        document.languageId,
+        options
+      );
+      if (this.analysisCache.size >= ANALYSIS_CACHE_MAX_SIZE) {
+        // Evict the least-recently-used entry (first key in insertion order).
+        const oldestKey = this.analysisCache.keys().next().value;
+        if (oldestKey !== undefined) {
+          this.analysisCache.delete(oldestKey);
+        }
+      }
+      this.analysisCache.set(analysisKey, functions);
+    } else {
+      // Refresh LRU order.
+      this.analysisCache.delete(analysisKey);
+      this.analysisCache.set(analysisKey, functions);
+    }
+    return functions;
+  }
+
   public resolveCodeLens(
     codeLens: vscode.CodeLens,
     _token: vscode.CancellationToken
@@ -238,7 +514,7 @@ export class MetricsCodeLensProvider implements vscode.CodeLensProvider {
     );
   }
 
-  private isExcluded(filePath: string, excludePatterns: string[]): boolean {
+  private isExcluded(filePath: string, config: CodeMetricsConfig): boolean {
     const normalizedPath = filePath.replace(/\\/g, "/");
 
     // Check the path-level result cache first. `provideCodeLenses` is called on every
@@ -252,21 +528,7 @@ export class MetricsCodeLensProvider implements vscode.CodeLensProvider {
       return cached;
     }
 
-    const compiled = getCompiledPatterns(excludePatterns);
-    // Lazily extract the filename the first time a basename-only pattern is encountered.
-    // Using lastIndexOf + substring avoids allocating an intermediate array for the common
-    // case where all patterns are full-path patterns (the default configuration).
-    let filename: string | undefined;
-    const result = compiled.some(({ regex, isFullPath }) => {
-      if (isFullPath) {
-        return regex.test(normalizedPath);
-      }
-      if (filename === undefined) {
-        const sep = normalizedPath.lastIndexOf("/");
-        filename = sep === -1 ? normalizedPath : normalizedPath.substring(sep + 1);
-      }
-      return regex.test(filename);
-    });
+    const result = isExcludedFile(normalizedPath, config);
 
     // Store result, evicting the oldest entry if the cache is full.
     if (this.excludeResultCache.size >= EXCLUDE_RESULT_CACHE_MAX_SIZE) {
@@ -283,9 +545,95 @@ export class MetricsCodeLensProvider implements vscode.CodeLensProvider {
     document: vscode.TextDocument,
     config: CodeMetricsConfig
   ): vscode.CodeLens[] {
-    return functions
-      .filter((func) => func.complexity > 0)
-      .map((func) => this.createCodeLens(func, document, config));
+    const lenses: vscode.CodeLens[] = [];
+    if (config.showFileSummary) {
+      const longest = getLongestFunction(functions);
+      if (longest) {
+        lenses.push(this.createFileSummaryCodeLens(longest, document));
+      }
+    }
+    const shown = getCodeLensFunctions(functions, config);
+    if (shown.length < functions.length) {
+      lenses.push(this.createLimitCodeLens(shown.length, functions.length, document));
+    }
+    const dominant = ConfigurationManager.getDominantFunction(functions, config);
+    for (const func of shown) {
+      if (func.complexity > 0) {
+        lenses.push(this.createCodeLens(func, document, config));
+      }
+      const warnings = ConfigurationManager.getMetricWarnings(func, config);
+      if (dominant?.func === func) {
+        warnings.push(
+          `Holds ${dominant.share}% of the file's complexity (threshold ${config.dominantFunctionShare}%)`
+        );
+      }
+      if (warnings.length > 0) {
+        lenses.push(this.createWarningCodeLens(func, warnings, document));
+      }
+    }
+    return lenses;
+  }
+
+  /**
+   * Creates the file summary CodeLens shown at the top of the file. It names the longest
+   * function by logical lines of code and navigates to it when clicked.
+   */
+  private createFileSummaryCodeLens(
+    longest: UnifiedFunctionMetrics,
+    document: vscode.TextDocument
+  ): vscode.CodeLens {
+    const range = new vscode.Range(0, 0, 0, 0);
+    const command: vscode.Command = {
+      title: `📏 ${vscode.l10n.t(
+        "Longest function: {0} ({1} lines)",
+        longest.name,
+        longest.logicalLines ?? 0
+      )}`,
+      command: "codeMetrics.revealFunction",
+      arguments: [document.uri, longest.startLine],
+    };
+    return new vscode.CodeLens(range, command);
+  }
+
+  /**
+   * Creates the lens shown at the top of a file with more functions than
+   * `display.codeLensLimit`; clicking it lists every function of the file.
+   */
+  private createLimitCodeLens(
+    shown: number,
+    total: number,
+    document: vscode.TextDocument
+  ): vscode.CodeLens {
+    const range = new vscode.Range(0, 0, 0, 0);
+    const command: vscode.Command = {
+      title: `📉 ${vscode.l10n.t(
+        "CodeLens shows the {0} most complex of {1} functions — click to list all",
+        shown,
+        total
+      )}`,
+      command: "codeMetrics.listFileFunctions",
+      arguments: [document.uri],
+    };
+    return new vscode.CodeLens(range, command);
+  }
+
+  /**
+   * Creates a CodeLens listing auxiliary metric warnings (e.g. receiver field access)
+   * for a function. Shown next to the complexity lens so it never alters the main score.
+   */
+  private createWarningCodeLens(
+    func: UnifiedFunctionMetrics,
+    warnings: string[],
+    document: vscode.TextDocument
+  ): vscode.CodeLens {
+    const line = func.startLine;
+    const range = new vscode.Range(line, 0, line, 0);
+    const command: vscode.Command = {
+      title: `⚠️ ${warnings.join(" · ")}`,
+      command: "cognitiveComplexity.showFunctionDetails",
+      arguments: [func, document.uri],
+    };
+    return new vscode.CodeLens(range, command);
   }
 
   private createCodeLens(
@@ -293,31 +641,79 @@ export class MetricsCodeLensProvider implements vscode.CodeLensProvider {
     document: vscode.TextDocument,
     config: CodeMetricsConfig
   ): vscode.CodeLens {
-    const complexity = func.complexity;
-
     // Create range for the code lens (above the function)
     const line = func.startLine;
     const range = new vscode.Range(line, 0, line, 0);
 
-    // Get status information using the already-resolved config
-    const status = ConfigurationManager.getComplexityStatus(
-      complexity,
-      config
-    );
-
-    // Create the code lens title
-    const title = `${status.icon} ${status.text} (${complexity})`;
-
-    // Create command to show detailed report for this function
-    const command: vscode.Command = {
-      title: title,
-      command: "cognitiveComplexity.showFunctionDetails",
-      arguments: [func, document.uri],
-    };
+    // Get the band of the primary metric using the already-resolved config
+    const { value, status } = ConfigurationManager.getPrimaryMetricStatus(func, config);
+    const score =
+      config.primaryMetric === "nesting" ? vscode.l10n.t("nesting {0}", value) : `${value}`;
+
+    // Create the code lens title from the configured template, tagging generated code that
+    // was not excluded and adding the steady-state estimate of functions with feature flag checks
+    const tags = [
+      func.steadyStateComplexity !== undefined
+        ? vscode.l10n.t("steady state {0}", func.steadyStateComplexity)
+        : "",
+      func.generated ? vscode.l10n.t("generated") : "",
+      func.approximate ? vscode.l10n.t("approximate") : "",
+    ].filter((tag) => tag);
+    const label =
+      formatCodeLensLabel(config.codeLensTemplate || "{status} ({score})", {
+        status: status.text,
+        value: `${value}`,
+        score,
+        name: func.name,
+      }) + tags.map((tag) => ` · ${tag}`).join("");
+
+    // Create command to show detailed report, or the explanation panel, for this function;
+    // a badge shows only the band's colored dot and keeps the label for the hover
+    const commandId =
+      config.codeLensAction === "explain"
+        ? "codeMetrics.explainFunction"
+        : "cognitiveComplexity.showFunctionDetails";
+    const command: vscode.Command =
+      config.displayStyle === "badge"
+        ? {
+            title: status.icon,
+            tooltip: label,
+            command: commandId,
+            arguments: [func, document.uri],
+          }
+        : {
+            title: `${status.icon} ${label}`,
+            command: commandId,
+            arguments: [func, document.uri],
+          };
 
     return new vscode.CodeLens(range, command);
   }
 
+  /**
+   * The functions the lenses of a document are based on, without analyzing it: the pinned
+   * analysis outside `onChange` mode or for a skipped large file, or else the cached result
+   * for the current text. Undefined while nothing is analyzed yet, and for lazily analyzed
+   * files, where only the viewport is.
+   */
+  public getAnalyzedFunctions(
+    document: vscode.TextDocument,
+    config: CodeMetricsConfig
+  ): UnifiedFunctionMetrics[] | undefined {
+    const isLarge =
+      config.largeFileThreshold > 0 && document.lineCount > config.largeFileThreshold;
+    if (config.analysisTrigger === "onChange") {
+      if (config.lazyAnalysis && isLarge && MetricsAnalyzerFactory.supportsLineRanges(document.languageId)) {
+        return undefined;
+      }
+      if (!(isLarge && config.skipLargeFiles)) {
+        const options = ConfigurationManager.getAnalyzerOptions(config);
+        return this.analysisCache.get(this.getAnalysisKey(document, options));
+      }
+    }
+    return this.pinnedAnalysis.get(document.uri.toString())?.functions;
+  }
+
   public refresh(): void {
     this._onDidChangeCodeLenses.fire();
   }
@@ -333,14 +729,22 @@ export class MetricsCodeLensProvider implements vscode.CodeLensProvider {
   public clearAnalysisCache(): void {
     this.analysisCache.clear();
     this.codeLensCache.clear();
+    this.lazyAnalysis.clear();
+  }
+
+  /** Drops every pinned analysis, e.g. when the analysis trigger mode changes. */
+  public clearPinnedAnalysis(): void {
+    this.pinnedAnalysis.clear();
   }
 
   /**
    * Removes all analysis-cache and codeLens-cache entries whose key starts with the given
-   * document URI. Called when a document is closed so stale per-version entries don't
-   * occupy memory until the cache fills up and LRU eviction takes over.
+   * document URI, and its pinned and lazy analyses. Called when a document is closed so stale
+   * per-version entries don't occupy memory until the cache fills up and LRU eviction takes over.
    */
   public pruneAnalysisCacheForDocument(uriString: string): void {
+    this.pinnedAnalysis.delete(uriString);
+    this.lazyAnalysis.delete(uriString);
     const prefix = `${uriString}#`;
     for (const key of this.analysisCache.keys()) {
       if (key.startsWith(prefix)) {
@@ -355,9 +759,51 @@ export class MetricsCodeLensProvider implements vscode.CodeLensProvider {
   }
 }
 
+/** The active provider, so languages added at runtime can be attached to it. */
+let activeProvider: MetricsCodeLensProvider | undefined;
+
+/**
+ * The functions the active CodeLens provider last analyzed for a document, if any.
+ * See {@link MetricsCodeLensProvider.getAnalyzedFunctions}.
+ */
+export function getAnalyzedFunctions(
+  document: vscode.TextDocument,
+  config: CodeMetricsConfig
+): UnifiedFunctionMetrics[] | undefined {
+  return activeProvider?.getAnalyzedFunctions(document, config);
+}
+
+/**
+ * Subscribes to the active CodeLens provider's refreshes, which follow a save in `onSave`
+ * mode, Analyze Current File, and the end of a background analysis.
+ *
+ * @param listener - Called on every refresh
+ */
+export function onDidChangeAnalysis(listener: () => void): vscode.Disposable {
+  return activeProvider?.onDidChangeCodeLenses(listener) ?? new vscode.Disposable(() => {});
+}
+
+/**
+ * Attaches the active CodeLens provider to a language that gained an analyzer after
+ * activation (see the extension API's `registerAnalyzer`).
+ *
+ * @param language - The VS Code language identifier
+ * @returns A disposable that detaches the provider from the language again
+ */
+export function registerCodeLensLanguage(language: string): vscode.Disposable {
+  const provider = activeProvider;
+  if (!provider) {
+    return new vscode.Disposable(() => {});
+  }
+  const registration = vscode.languages.registerCodeLensProvider({ language }, provider);
+  provider.refresh();
+  return registration;
+}
+
 // Register the code lens provider
 export function registerCodeLensProvider(): vscode.Disposable {
   const provider = new MetricsCodeLensProvider();
+  activeProvider = provider;
 
   const languages = MetricsAnalyzerFactory.getSupportedLanguages();
   const disposables: vscode.Disposable[] = [];
@@ -368,16 +814,75 @@ export function registerCodeLensProvider(): vscode.Disposable {
   });
 
   // Refresh code lenses when configuration changes
-  const configWatcher = ConfigurationManager.onConfigurationChanged((_e) => {
+  const configWatcher = ConfigurationManager.onConfigurationChanged((e) => {
+    excludeRegexCache.clear();
+    provider.clearConfigCache();
+    if (e.affectsConfiguration("codeMetrics.analysis.trigger")) {
+      provider.clearPinnedAnalysis();
+    }
+    setTimeout(() => provider.refresh(), 100);
+  });
+
+  // A `.codemetrics.json` edit changes the effective config for its workspace folder.
+  const projectConfigWatcher = ConfigurationManager.onProjectConfigChanged(() => {
+    excludeRegexCache.clear();
+    provider.clearConfigCache();
+    setTimeout(() => provider.refresh(), 100);
+  });
+
+  // Switching the threshold profile changes bands and excludes everywhere.
+  const profileWatcher = ConfigurationManager.onActiveProfileChanged(() => {
     excludeRegexCache.clear();
     provider.clearConfigCache();
     setTimeout(() => provider.refresh(), 100);
   });
 
+  // In `onSave` mode, saving is what re-analyzes a document.
+  const saveWatcher = vscode.workspace.onDidSaveTextDocument((doc) => {
+    if (
+      MetricsAnalyzerFactory.isSupportedLanguage(doc.languageId) &&
+      ConfigurationManager.getConfiguration(doc.uri, doc.languageId).analysisTrigger === "onSave"
+    ) {
+      provider.analyzeNow(doc);
+      provider.refresh();
+    }
+  });
+
+  // Explicit analysis of the active document, the only trigger in `manual` mode.
+  const analyzeCommand = vscode.commands.registerCommand(
+    "codeMetrics.analyzeCurrentFile",
+    () => {
+      const document = vscode.window.activeTextEditor?.document;
+      if (document && MetricsAnalyzerFactory.isSupportedLanguage(document.languageId)) {
+        provider.analyzeNow(document);
+        provider.refresh();
+      }
+    }
+  );
+
+  // Scrolling a lazily analyzed file analyzes the functions coming into view.
+  const scrollWatcher = vscode.window.onDidChangeTextEditorVisibleRanges((e) => {
+    provider.onVisibleRangesChanged(e.textEditor.document);
+  });
+
   // Proactively evict analysis-cache entries for closed documents to reduce memory pressure.
   const closeWatcher = vscode.workspace.onDidCloseTextDocument((doc) => {
     provider.pruneAnalysisCacheForDocument(doc.uri.toString());
   });
 
-  return vscode.Disposable.from(...disposables, configWatcher, closeWatcher);
+  return vscode.Disposable.from(
+    ...disposables,
+    configWatcher,
+    projectConfigWatcher,
+    profileWatcher,
+    saveWatcher,
+    analyzeCommand,
+    scrollWatcher,
+    closeWatcher,
+    new vscode.Disposable(() => {
+      if (activeProvider === provider) {
+        activeProvider = undefined;
+      }
+    })
+  );
 }
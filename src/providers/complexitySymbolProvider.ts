@@ -0,0 +1,94 @@
+import * as vscode from "vscode";
+import {
+  MetricsAnalyzerFactory,
+  UnifiedFunctionMetrics,
+} from "../metricsAnalyzer/metricsAnalyzerFactory";
+import { CodeMetricsConfig, ConfigurationManager } from "../configuration";
+import { isExcludedFile } from "../workspace/fileFilters";
+
+/**
+ * Builds document symbols whose names carry each function's primary metric, e.g.
+ * `Load · 🟡 12` or `Load · 🟡 nesting 3`, so breadcrumbs and the Outline show it.
+ * Closures and nested functions become children of the function containing them.
+ *
+ * @param functions - The analyzed functions of a file
+ * @param config - The configuration providing the primary metric and band thresholds
+ * @returns The top-level symbols, in source order
+ */
+export function getComplexitySymbols(
+  functions: readonly UnifiedFunctionMetrics[],
+  config: CodeMetricsConfig
+): vscode.DocumentSymbol[] {
+  const sorted = [...functions].sort(
+    (a, b) => a.startLine - b.startLine || b.endLine - a.endLine
+  );
+  const roots: vscode.DocumentSymbol[] = [];
+  // Symbols whose range may still contain the next function, outermost first
+  const open: vscode.DocumentSymbol[] = [];
+  for (const func of sorted) {
+    const { value, status } = ConfigurationManager.getPrimaryMetricStatus(func, config);
+    const score = config.primaryMetric === "nesting" ? `nesting ${value}` : `${value}`;
+    const range = new vscode.Range(func.startLine, func.startColumn, func.endLine, func.endColumn);
+    const symbol = new vscode.DocumentSymbol(
+      `${func.name} · ${status.icon} ${score}`,
+      status.text,
+      vscode.SymbolKind.Function,
+      range,
+      new vscode.Range(func.startLine, func.startColumn, func.startLine, func.startColumn)
+    );
+
+    while (open.length > 0 && !open[open.length - 1].range.contains(range)) {
+      open.pop();
+    }
+    if (open.length > 0) {
+      open[open.length - 1].children.push(symbol);
+    } else {
+      roots.push(symbol);
+    }
+    open.push(symbol);
+  }
+  return roots;
+}
+
+/**
+ * Provides a symbol per function, named with its complexity. VS Code merges these with
+ * the language's own symbols, so breadcrumbs show the number while the cursor is inside
+ * a function. Enabled with `codeMetrics.display.symbolComplexity`.
+ */
+export class ComplexitySymbolProvider implements vscode.DocumentSymbolProvider {
+  public provideDocumentSymbols(document: vscode.TextDocument): vscode.DocumentSymbol[] {
+    const config = ConfigurationManager.getConfiguration(document.uri, document.languageId);
+    if (
+      !config.enabled ||
+      !config.symbolComplexity ||
+      document.uri.scheme.startsWith("git") ||
+      isExcludedFile(document.uri.fsPath, config)
+    ) {
+      return [];
+    }
+
+    try {
+      const functions = MetricsAnalyzerFactory.analyzeFile(
+        document.getText(),
+        document.languageId,
+        ConfigurationManager.getAnalyzerOptions(config)
+      );
+      return getComplexitySymbols(functions, config);
+    } catch (error) {
+      console.error("Error creating complexity symbols:", error);
+      return [];
+    }
+  }
+}
+
+// Register the complexity symbols for every supported language
+export function registerComplexitySymbolProvider(): vscode.Disposable {
+  const provider = new ComplexitySymbolProvider();
+  return vscode.Disposable.from(
+    ...MetricsAnalyzerFactory.getSupportedLanguages().map((language) =>
+      vscode.languages.registerDocumentSymbolProvider({ language }, provider, {
+        label: "Code Metrics",
+      })
+    )
+  );
+}
@@ -0,0 +1,311 @@
+import * as vscode from "vscode";
+import {
+  ComplexityExpectation,
+  MetricsAnalyzerFactory,
+  UnifiedFunctionMetrics,
+} from "../metricsAnalyzer/metricsAnalyzerFactory";
+import { CodeMetricsConfig, ConfigurationManager } from "../configuration";
+import { getIgnoreEdit, isIgnoredFunction } from "../annotations/complexityAnnotations";
+import { isExcludedFile } from "../workspace/fileFilters";
+
+/** Source label shown next to every diagnostic in the Problems panel. */
+export const DIAGNOSTIC_SOURCE = "Code Metrics";
+
+/** What to do instead of a long switch-like statement, by its keyword. */
+const LONG_SWITCH_ADVICE: Readonly<Record<string, string>> = {
+  switch: "consider a map from values to results or handlers",
+  "type switch": "consider a method on an interface the types implement",
+  select: "consider merging channels or splitting the loop",
+};
+
+/**
+ * Returns whether a complexity value violates a per-function expectation.
+ *
+ * - `<=` and `<` are upper bounds: only exceeding them is a violation.
+ * - `==` pins an exact value: any drift (up or down) is a violation, so the
+ *   annotation is kept in sync with the code it describes.
+ *
+ * @param complexity - The actual complexity of the function
+ * @param expectation - The expectation declared in source
+ * @returns true if the expectation is not met
+ */
+export function violatesExpectation(
+  complexity: number,
+  expectation: ComplexityExpectation
+): boolean {
+  switch (expectation.operator) {
+    case "<=":
+      return complexity > expectation.value;
+    case "<":
+      return complexity >= expectation.value;
+    case "==":
+      return complexity !== expectation.value;
+  }
+}
+
+/**
+ * Publishes Problems-panel diagnostics for analyzed functions.
+ *
+ * Reports functions whose complexity drifts from a budget pinned in source
+ * (`//metrics:expect cc<=N`). These budgets apply independently of the global
+ * warning/error thresholds, so critical functions can carry a tighter limit.
+ * With `display.thresholdDiagnostics`, functions in the warning or error band of the
+ * primary metric get a warning or an error.
+ * Switch and select statements with more clauses than `caseClauseThreshold` are flagged
+ * where they start. The function dominating its file's complexity gets an informational note.
+ * Functions marked with a `//metrics:ignore` comment get no diagnostics.
+ */
+export class MetricsDiagnosticsProvider implements vscode.Disposable {
+  private readonly collection: vscode.DiagnosticCollection;
+
+  constructor() {
+    this.collection = vscode.languages.createDiagnosticCollection("codeMetrics");
+  }
+
+  /**
+   * Re-analyzes a document and replaces its diagnostics.
+   * Unsupported, excluded, or disabled documents have their diagnostics cleared.
+   *
+   * @param document - The document to analyze
+   */
+  public updateDiagnostics(document: vscode.TextDocument): void {
+    const config = ConfigurationManager.getConfiguration(document.uri, document.languageId);
+    if (
+      !config.enabled ||
+      !MetricsAnalyzerFactory.isSupportedLanguage(document.languageId) ||
+      document.uri.scheme.startsWith("git") ||
+      isExcludedFile(document.uri.fsPath, config)
+    ) {
+      this.collection.delete(document.uri);
+      return;
+    }
+
+    try {
+      const functions = MetricsAnalyzerFactory.analyzeFile(
+        document.getText(),
+        document.languageId,
+        ConfigurationManager.getAnalyzerOptions(config)
+      );
+      this.collection.set(document.uri, this.createDiagnostics(functions, document, config));
+    } catch (error) {
+      console.error("Error creating diagnostics:", error);
+      this.collection.delete(document.uri);
+    }
+  }
+
+  /** Removes the diagnostics published for a document. */
+  public clearDiagnostics(uri: vscode.Uri): void {
+    this.collection.delete(uri);
+  }
+
+  /** Returns the diagnostics currently published for a document. */
+  public getDiagnostics(uri: vscode.Uri): readonly vscode.Diagnostic[] {
+    return this.collection.get(uri) ?? [];
+  }
+
+  public dispose(): void {
+    this.collection.dispose();
+  }
+
+  private createDiagnostics(
+    functions: UnifiedFunctionMetrics[],
+    document: vscode.TextDocument,
+    config: CodeMetricsConfig
+  ): vscode.Diagnostic[] {
+    const diagnostics: vscode.Diagnostic[] = [];
+    const lines = document.getText().split(/\r?\n/);
+    const isIgnored = (func: UnifiedFunctionMetrics) =>
+      isIgnoredFunction(lines, func, document.languageId);
+    for (const func of functions) {
+      if (isIgnored(func)) {
+        continue;
+      }
+      const threshold = config.thresholdDiagnostics
+        ? this.createThresholdDiagnostic(func, document, config)
+        : undefined;
+      if (threshold) {
+        diagnostics.push(threshold);
+      }
+      const expectation = func.expectedComplexity;
+      if (expectation && violatesExpectation(func.complexity, expectation)) {
+        const diagnostic = new vscode.Diagnostic(
+          this.getFunctionRange(func, document),
+          `${func.name} has cognitive complexity ${func.complexity}, ` +
+            `expected cc${expectation.operator}${expectation.value}`,
+          vscode.DiagnosticSeverity.Warning
+        );
+        diagnostic.source = DIAGNOSTIC_SOURCE;
+        diagnostic.code = "expectation";
+        diagnostics.push(diagnostic);
+      }
+      for (const statement of ConfigurationManager.getLongSwitches(func, config)) {
+        const line = document.lineAt(Math.min(statement.line, Math.max(document.lineCount - 1, 0)));
+        const diagnostic = new vscode.Diagnostic(
+          line.range.with(line.range.start.with({ character: statement.column })),
+          `This ${statement.kind} has ${statement.caseCount} cases ` +
+            `(threshold ${config.caseClauseThreshold}); ` +
+            (LONG_SWITCH_ADVICE[statement.kind] ?? "consider splitting it"),
+          vscode.DiagnosticSeverity.Warning
+        );
+        diagnostic.source = DIAGNOSTIC_SOURCE;
+        diagnostic.code = "longSwitch";
+        diagnostics.push(diagnostic);
+      }
+    }
+
+    const dominant = ConfigurationManager.getDominantFunction(functions, config);
+    if (dominant && !isIgnored(dominant.func)) {
+      const diagnostic = new vscode.Diagnostic(
+        this.getFunctionRange(dominant.func, document),
+        `${dominant.func.name} holds ${dominant.share}% of this file's cognitive complexity; ` +
+          "consider splitting it",
+        vscode.DiagnosticSeverity.Information
+      );
+      diagnostic.source = DIAGNOSTIC_SOURCE;
+      diagnostic.code = "dominantFunction";
+      diagnostics.push(diagnostic);
+    }
+    return diagnostics;
+  }
+
+  /**
+   * Reports a function in the warning or error band of the primary metric, with the
+   * matching severity, or returns undefined for a function below the warning threshold.
+   */
+  private createThresholdDiagnostic(
+    func: UnifiedFunctionMetrics,
+    document: vscode.TextDocument,
+    config: CodeMetricsConfig
+  ): vscode.Diagnostic | undefined {
+    const { value, status } = ConfigurationManager.getPrimaryMetricStatus(func, config);
+    if (status.level === "low") {
+      return undefined;
+    }
+    const isError = status.level === "error";
+    const nesting = config.primaryMetric === "nesting";
+    const threshold = nesting
+      ? (isError ? config.nestingErrorThreshold : config.nestingWarningThreshold)
+      : (isError ? config.errorThreshold : config.warningThreshold);
+    const diagnostic = new vscode.Diagnostic(
+      this.getFunctionRange(func, document),
+      (nesting ? `${func.name} nests ${value} levels deep` : `${func.name} has complexity ${value}`) +
+        ` (${isError ? "error" : "warning"} threshold ${threshold})`,
+      isError ? vscode.DiagnosticSeverity.Error : vscode.DiagnosticSeverity.Warning
+    );
+    diagnostic.source = DIAGNOSTIC_SOURCE;
+    diagnostic.code = "threshold";
+    return diagnostic;
+  }
+
+  /** Anchors a diagnostic on the function's header line rather than its whole body. */
+  private getFunctionRange(
+    func: UnifiedFunctionMetrics,
+    document: vscode.TextDocument
+  ): vscode.Range {
+    const line = Math.min(func.startLine, Math.max(document.lineCount - 1, 0));
+    return document.lineAt(line).range;
+  }
+}
+
+/**
+ * Offers a quick fix on Code Metrics diagnostics that mutes the function they report by
+ * inserting a `//metrics:ignore` comment above it.
+ */
+export class IgnoreComplexityCodeActionProvider implements vscode.CodeActionProvider {
+  public static readonly providedCodeActionKinds = [vscode.CodeActionKind.QuickFix];
+
+  public provideCodeActions(
+    document: vscode.TextDocument,
+    _range: vscode.Range | vscode.Selection,
+    context: vscode.CodeActionContext
+  ): vscode.CodeAction[] {
+    const diagnostics = context.diagnostics.filter((d) => d.source === DIAGNOSTIC_SOURCE);
+    if (diagnostics.length === 0) {
+      return [];
+    }
+
+    const config = ConfigurationManager.getConfiguration(document.uri, document.languageId);
+    const functions = MetricsAnalyzerFactory.analyzeFile(
+      document.getText(),
+      document.languageId,
+      ConfigurationManager.getAnalyzerOptions(config)
+    );
+    const lines = document.getText().split(/\r?\n/);
+    const eol = document.eol === vscode.EndOfLine.CRLF ? "\r\n" : "\n";
+    const actions = new Map<UnifiedFunctionMetrics, vscode.CodeAction>();
+    for (const diagnostic of diagnostics) {
+      // Diagnostics are anchored on the header line of the function they report, or on a
+      // statement inside it.
+      const line = diagnostic.range.start.line;
+      const func =
+        functions.find((f) => f.startLine === line) ??
+        functions.find((f) => f.startLine <= line && line <= f.endLine);
+      const existing = func && actions.get(func);
+      if (existing) {
+        existing.diagnostics!.push(diagnostic);
+        continue;
+      }
+      const edit = func && getIgnoreEdit(lines, func, document.languageId);
+      if (!func || !edit) {
+        continue;
+      }
+      const action = new vscode.CodeAction(
+        "Ignore complexity for this function",
+        vscode.CodeActionKind.QuickFix
+      );
+      action.edit = new vscode.WorkspaceEdit();
+      action.edit.insert(document.uri, new vscode.Position(edit.line, 0), `${edit.text}${eol}`);
+      action.diagnostics = [diagnostic];
+      actions.set(func, action);
+    }
+    return [...actions.values()];
+  }
+}
+
+// Register the diagnostics provider
+export function registerDiagnosticsProvider(): vscode.Disposable {
+  const provider = new MetricsDiagnosticsProvider();
+  // Diagnostics are published for every document scheme, so the quick fix is offered for all.
+  const codeActionProvider = vscode.languages.registerCodeActionsProvider(
+    "*",
+    new IgnoreComplexityCodeActionProvider(),
+    { providedCodeActionKinds: IgnoreComplexityCodeActionProvider.providedCodeActionKinds }
+  );
+
+  // Analyze documents that were already open before activation.
+  vscode.workspace.textDocuments.forEach((doc) => provider.updateDiagnostics(doc));
+
+  const openWatcher = vscode.workspace.onDidOpenTextDocument((doc) =>
+    provider.updateDiagnostics(doc)
+  );
+  const changeWatcher = vscode.workspace.onDidChangeTextDocument((e) =>
+    provider.updateDiagnostics(e.document)
+  );
+  const closeWatcher = vscode.workspace.onDidCloseTextDocument((doc) =>
+    provider.clearDiagnostics(doc.uri)
+  );
+
+  // Thresholds or excludes may have changed — re-evaluate every open document.
+  const configWatcher = ConfigurationManager.onConfigurationChanged((_e) => {
+    vscode.workspace.textDocuments.forEach((doc) => provider.updateDiagnostics(doc));
+  });
+
+  const projectConfigWatcher = ConfigurationManager.onProjectConfigChanged(() => {
+    vscode.workspace.textDocuments.forEach((doc) => provider.updateDiagnostics(doc));
+  });
+
+  const profileWatcher = ConfigurationManager.onActiveProfileChanged(() => {
+    vscode.workspace.textDocuments.forEach((doc) => provider.updateDiagnostics(doc));
+  });
+
+  return vscode.Disposable.from(
+    provider,
+    codeActionProvider,
+    openWatcher,
+    changeWatcher,
+    closeWatcher,
+    configWatcher,
+    projectConfigWatcher,
+    profileWatcher
+  );
+}
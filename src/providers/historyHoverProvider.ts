@@ -0,0 +1,69 @@
+import * as path from "path";
+import * as vscode from "vscode";
+import { MetricsAnalyzerFactory } from "../metricsAnalyzer/metricsAnalyzerFactory";
+import { ConfigurationManager } from "../configuration";
+import { formatFunctionHistory, getFunctionHistory } from "../history/functionHistory";
+
+/**
+ * Shows a function's complexity over its last few commits when hovering its first line.
+ * Enabled with `codeMetrics.history.hover`; files outside a git repository get no hover.
+ */
+export class HistoryHoverProvider implements vscode.HoverProvider {
+  public async provideHover(
+    document: vscode.TextDocument,
+    position: vscode.Position,
+    token: vscode.CancellationToken
+  ): Promise<vscode.Hover | undefined> {
+    if (
+      document.uri.scheme !== "file" ||
+      !MetricsAnalyzerFactory.isSupportedLanguage(document.languageId)
+    ) {
+      return undefined;
+    }
+    const config = ConfigurationManager.getConfiguration(document.uri, document.languageId);
+    if (!config.enabled || !config.historyHover) {
+      return undefined;
+    }
+
+    const analyzerOptions = ConfigurationManager.getAnalyzerOptions(config);
+    const func = MetricsAnalyzerFactory.analyzeFile(
+      document.getText(),
+      document.languageId,
+      analyzerOptions
+    ).find((f) => f.startLine === position.line);
+    if (!func) {
+      return undefined;
+    }
+
+    let history;
+    try {
+      history = await getFunctionHistory(
+        path.dirname(document.uri.fsPath),
+        path.basename(document.uri.fsPath),
+        func.name,
+        document.languageId,
+        { analyzerOptions }
+      );
+    } catch {
+      return undefined; // not in a git repository, or the file is untracked
+    }
+    if (token.isCancellationRequested) {
+      return undefined;
+    }
+    return new vscode.Hover(
+      new vscode.MarkdownString(formatFunctionHistory(func.name, func.complexity, history)),
+      document.lineAt(position.line).range
+    );
+  }
+}
+
+/**
+ * Registers the history hover for every file; the provider itself checks the language and
+ * the setting, so languages added through the API and setting changes apply immediately.
+ */
+export function registerHistoryHoverProvider(): vscode.Disposable {
+  return vscode.languages.registerHoverProvider(
+    { scheme: "file" },
+    new HistoryHoverProvider()
+  );
+}
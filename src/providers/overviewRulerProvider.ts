@@ -0,0 +1,140 @@
+import * as vscode from "vscode";
+import {
+  MetricsAnalyzerFactory,
+  UnifiedFunctionMetrics,
+} from "../metricsAnalyzer/metricsAnalyzerFactory";
+import { CodeMetricsConfig, ConfigurationManager } from "../configuration";
+import { isExcludedFile } from "../workspace/fileFilters";
+
+/** Complexity band, as returned by {@link ConfigurationManager.getPrimaryMetricStatus}. */
+type ComplexityLevel = "low" | "warning" | "error";
+
+/** Theme colors contributed in package.json, one per band. */
+const LEVEL_COLORS: Record<ComplexityLevel, string> = {
+  low: "codeMetrics.overviewRuler.lowComplexity",
+  warning: "codeMetrics.overviewRuler.moderateComplexity",
+  error: "codeMetrics.overviewRuler.highComplexity",
+};
+
+/**
+ * Groups the header lines of functions by the band of their primary metric.
+ *
+ * @param functions - The analyzed functions of a file
+ * @param config - The configuration providing the band thresholds
+ * @returns The header line ranges of the functions in each band
+ */
+export function getOverviewRulerRanges(
+  functions: readonly UnifiedFunctionMetrics[],
+  config: CodeMetricsConfig
+): Record<ComplexityLevel, vscode.Range[]> {
+  const ranges: Record<ComplexityLevel, vscode.Range[]> = { low: [], warning: [], error: [] };
+  for (const func of functions) {
+    const { level } = ConfigurationManager.getPrimaryMetricStatus(func, config).status;
+    ranges[level].push(new vscode.Range(func.startLine, 0, func.startLine, 0));
+  }
+  return ranges;
+}
+
+/**
+ * Marks function headers in the editor's overview ruler with the color of their
+ * complexity band, giving a heatmap of a file's hotspots next to the scrollbar.
+ * Enabled with `codeMetrics.display.overviewRuler`.
+ */
+export class ComplexityOverviewRuler implements vscode.Disposable {
+  private readonly decorationTypes: Record<ComplexityLevel, vscode.TextEditorDecorationType>;
+
+  constructor() {
+    const create = (level: ComplexityLevel) =>
+      vscode.window.createTextEditorDecorationType({
+        isWholeLine: true,
+        overviewRulerColor: new vscode.ThemeColor(LEVEL_COLORS[level]),
+        overviewRulerLane: vscode.OverviewRulerLane.Right,
+      });
+    this.decorationTypes = {
+      low: create("low"),
+      warning: create("warning"),
+      error: create("error"),
+    };
+  }
+
+  /**
+   * Re-analyzes an editor's document and replaces its overview ruler marks.
+   * Unsupported, excluded, or disabled documents have their marks cleared.
+   *
+   * @param editor - The editor to decorate
+   */
+  public updateEditor(editor: vscode.TextEditor): void {
+    const document = editor.document;
+    const config = ConfigurationManager.getConfiguration(document.uri, document.languageId);
+    if (
+      !config.enabled ||
+      !config.overviewRuler ||
+      !MetricsAnalyzerFactory.isSupportedLanguage(document.languageId) ||
+      document.uri.scheme.startsWith("git") ||
+      isExcludedFile(document.uri.fsPath, config)
+    ) {
+      this.clearEditor(editor);
+      return;
+    }
+
+    try {
+      const functions = MetricsAnalyzerFactory.analyzeFile(
+        document.getText(),
+        document.languageId,
+        ConfigurationManager.getAnalyzerOptions(config)
+      );
+      const ranges = getOverviewRulerRanges(functions, config);
+      for (const level of Object.keys(this.decorationTypes) as ComplexityLevel[]) {
+        editor.setDecorations(this.decorationTypes[level], ranges[level]);
+      }
+    } catch (error) {
+      console.error("Error creating overview ruler decorations:", error);
+      this.clearEditor(editor);
+    }
+  }
+
+  /** Removes the overview ruler marks of an editor. */
+  public clearEditor(editor: vscode.TextEditor): void {
+    for (const type of Object.values(this.decorationTypes)) {
+      editor.setDecorations(type, []);
+    }
+  }
+
+  public dispose(): void {
+    for (const type of Object.values(this.decorationTypes)) {
+      type.dispose();
+    }
+  }
+}
+
+// Register the overview ruler decorations
+export function registerOverviewRuler(): vscode.Disposable {
+  const ruler = new ComplexityOverviewRuler();
+  const updateVisible = () => vscode.window.visibleTextEditors.forEach((e) => ruler.updateEditor(e));
+
+  // Decorate editors that were already open before activation.
+  updateVisible();
+
+  const visibleWatcher = vscode.window.onDidChangeVisibleTextEditors((editors) =>
+    editors.forEach((e) => ruler.updateEditor(e))
+  );
+  const changeWatcher = vscode.workspace.onDidChangeTextDocument((e) => {
+    vscode.window.visibleTextEditors
+      .filter((editor) => editor.document === e.document)
+      .forEach((editor) => ruler.updateEditor(editor));
+  });
+
+  // The toggle, thresholds or excludes may have changed — redecorate every visible editor.
+  const configWatcher = ConfigurationManager.onConfigurationChanged((_e) => updateVisible());
+  const projectConfigWatcher = ConfigurationManager.onProjectConfigChanged(updateVisible);
+  const profileWatcher = ConfigurationManager.onActiveProfileChanged(updateVisible);
+
+  return vscode.Disposable.from(
+    ruler,
+    visibleWatcher,
+    changeWatcher,
+    configWatcher,
+    projectConfigWatcher,
+    profileWatcher
+  );
+}
@@ -0,0 +1,164 @@
+import * as path from "path";
+import * as vscode from "vscode";
+import {
+  MetricsAnalyzerFactory,
+  UnifiedFunctionMetrics,
+} from "../metricsAnalyzer/metricsAnalyzerFactory";
+import { CodeMetricsConfig, ConfigurationManager } from "../configuration";
+import { isExcludedFile } from "../workspace/fileFilters";
+
+/**
+ * Finds the functions that crossed the warning threshold since an earlier version of a
+ * file: over-threshold functions whose name was not over the threshold before, new
+ * functions included. Functions matched by name, so a renamed complex function is new.
+ *
+ * @param previous - The functions of the earlier version
+ * @param current - The functions of the saved version
+ * @param config - The configuration providing the warning threshold
+ * @returns The new violations, most complex first
+ */
+export function findNewViolations(
+  previous: readonly UnifiedFunctionMetrics[],
+  current: readonly UnifiedFunctionMetrics[],
+  config: CodeMetricsConfig
+): UnifiedFunctionMetrics[] {
+  const over = (func: UnifiedFunctionMetrics) => func.complexity >= config.warningThreshold;
+  const known = new Set(previous.filter(over).map((func) => func.name));
+  return current
+    .filter((func) => over(func) && !known.has(func.name))
+    .sort((a, b) => b.complexity - a.complexity);
+}
+
+/**
+ * Summarizes new violations for the guardrail warning, e.g. "Saving orders.go added a
+ * function over the complexity threshold of 15: Checkout (complexity 18, line 42)."
+ *
+ * @param fileName - The saved file's base name
+ * @param violations - Results from {@link findNewViolations}
+ * @param config - The configuration providing the warning threshold
+ */
+export function formatGuardrailMessage(
+  fileName: string,
+  violations: readonly UnifiedFunctionMetrics[],
+  config: CodeMetricsConfig
+): string {
+  const what = violations.length === 1 ? "a function" : `${violations.length} functions`;
+  const list = violations
+    .map((func) => `${func.name} (complexity ${func.complexity}, line ${func.startLine + 1})`)
+    .join(", ");
+  return (
+    `Saving ${fileName} added ${what} over the complexity threshold of ` +
+    `${config.warningThreshold}: ${list}.`
+  );
+}
+
+/**
+ * Warns when a save brings a function over the complexity threshold, with a modal that
+ * names the new violations. The file is saved regardless: the warning only reports.
+ * Each document is compared with its functions when it was last saved or opened.
+ * Enabled with `codeMetrics.analysis.saveGuardrail`.
+ */
+export class SaveGuardrail {
+  private readonly savedFunctions = new Map<string, UnifiedFunctionMetrics[]>();
+
+  /**
+   * Remembers a document's functions as the version later saves are compared with.
+   *
+   * @param document - An opened or saved document
+   */
+  public record(document: vscode.TextDocument): void {
+    const functions = this.analyze(document);
+    if (functions) {
+      this.savedFunctions.set(document.uri.toString(), functions);
+    }
+  }
+
+  /**
+   * Compares a saved document with its previous version and warns about new violations.
+   *
+   * @param document - The saved document
+   */
+  public async onSaved(document: vscode.TextDocument): Promise<void> {
+    const key = document.uri.toString();
+    const previous = this.savedFunctions.get(key);
+    const current = this.analyze(document);
+    if (!current) {
+      return;
+    }
+    this.savedFunctions.set(key, current);
+
+    const config = ConfigurationManager.getConfiguration(document.uri, document.languageId);
+    const violations = findNewViolations(previous ?? [], current, config);
+    if (!config.saveGuardrail || violations.length === 0) {
+      return;
+    }
+    const reveal = "Show Function";
+    const choice = await vscode.window.showWarningMessage(
+      formatGuardrailMessage(path.basename(document.uri.fsPath), violations, config),
+      { modal: true },
+      reveal
+    );
+    if (choice === reveal) {
+      const line = violations[0].startLine;
+      const editor = await vscode.window.showTextDocument(document);
+      editor.selection = new vscode.Selection(line, 0, line, 0);
+      editor.revealRange(new vscode.Range(line, 0, line, 0), vscode.TextEditorRevealType.InCenter);
+    }
+  }
+
+  /** Forgets a closed document. */
+  public forget(document: vscode.TextDocument): void {
+    this.savedFunctions.delete(document.uri.toString());
+  }
+
+  /**
+   * Analyzes a document the guardrail applies to; undefined for unsupported, excluded,
+   * or disabled documents, and when the guardrail is off.
+   */
+  private analyze(document: vscode.TextDocument): UnifiedFunctionMetrics[] | undefined {
+    const config = ConfigurationManager.getConfiguration(document.uri, document.languageId);
+    if (
+      !config.enabled ||
+      !config.saveGuardrail ||
+      document.uri.scheme !== "file" ||
+      !MetricsAnalyzerFactory.isSupportedLanguage(document.languageId) ||
+      isExcludedFile(document.uri.fsPath, config)
+    ) {
+      return undefined;
+    }
+
+    try {
+      return MetricsAnalyzerFactory.analyzeFile(
+        document.getText(),
+        document.languageId,
+        ConfigurationManager.getAnalyzerOptions(config)
+      );
+    } catch (error) {
+      console.error("Error analyzing document for the save guardrail:", error);
+      return undefined;
+    }
+  }
+}
+
+// Register the save guardrail
+export function registerSaveGuardrail(): vscode.Disposable {
+  const guardrail = new SaveGuardrail();
+
+  // Documents open before activation compare their first save with their current text.
+  vscode.workspace.textDocuments.forEach((doc) => guardrail.record(doc));
+
+  const openWatcher = vscode.workspace.onDidOpenTextDocument((doc) => guardrail.record(doc));
+  const saveWatcher = vscode.workspace.onDidSaveTextDocument((doc) => {
+    void guardrail.onSaved(doc);
+  });
+  const closeWatcher = vscode.workspace.onDidCloseTextDocument((doc) => guardrail.forget(doc));
+
+  // Turning the guardrail on starts tracking the documents already open.
+  const configWatcher = ConfigurationManager.onConfigurationChanged((e) => {
+    if (e.affectsConfiguration("codeMetrics.analysis.saveGuardrail")) {
+      vscode.workspace.textDocuments.forEach((doc) => guardrail.record(doc));
+    }
+  });
+
+  return vscode.Disposable.from(openWatcher, saveWatcher, closeWatcher, configWatcher);
+}
@@ -0,0 +1,216 @@
+import * as path from "path";
+import * as vscode from "vscode";
+import {
+  MetricsAnalyzerFactory,
+  UnifiedFunctionMetrics,
+} from "../metricsAnalyzer/metricsAnalyzerFactory";
+import { CodeMetricsConfig, ConfigurationManager } from "../configuration";
+import { isExcludedFile } from "../workspace/fileFilters";
+import { getAnalyzedFunctions, onDidChangeAnalysis } from "./codeLensProvider";
+
+/** Delay after the last edit before the badge is recomputed. */
+const UPDATE_DELAY_MS = 300;
+
+/** What the status bar badge shows for a file. */
+export interface StatusBadge {
+  /** Functions at or over the warning threshold of the primary metric */
+  count: number;
+  /** Functions among them at or over the error threshold */
+  errorCount: number;
+  /** Status bar text, e.g. `$(warning) 3` */
+  text: string;
+  tooltip: string;
+}
+
+/**
+ * Counts a file's functions over the threshold of the primary metric for the status bar.
+ *
+ * @param functions - The analyzed functions of the file
+ * @param config - The configuration providing the primary metric and its thresholds
+ * @param fileName - The file's base name, for the tooltip
+ * @returns The badge
+ */
+export function getStatusBadge(
+  functions: readonly UnifiedFunctionMetrics[],
+  config: CodeMetricsConfig,
+  fileName: string
+): StatusBadge {
+  let count = 0;
+  let errorCount = 0;
+  for (const func of functions) {
+    const { level } = ConfigurationManager.getPrimaryMetricStatus(func, config).status;
+    if (level !== "low") {
+      count++;
+      if (level === "error") {
+        errorCount++;
+      }
+    }
+  }
+
+  const metric = config.primaryMetric === "nesting" ? "nesting" : "complexity";
+  if (count === 0) {
+    return {
+      count,
+      errorCount,
+      text: "$(check) 0",
+      tooltip: `No function in ${fileName} is over the ${metric} threshold`,
+    };
+  }
+  const functionsOver = count === 1 ? "1 function" : `${count} functions`;
+  const errors = errorCount > 0 ? ` (${errorCount} over the error threshold)` : "";
+  return {
+    count,
+    errorCount,
+    text: `$(warning) ${count}`,
+    tooltip: `${functionsOver} in ${fileName} over the ${metric} threshold${errors}. Click to go to the next one.`,
+  };
+}
+
+/**
+ * Finds the next function over the warning threshold of the primary metric after a line,
+ * wrapping around to the first one of the file.
+ *
+ * @param functions - The analyzed functions of the file
+ * @param config - The configuration providing the primary metric and its thresholds
+ * @param line - The zero-based line to search from, usually the cursor's
+ * @returns The function, or undefined when none is over the threshold
+ */
+export function findNextHotspot(
+  functions: readonly UnifiedFunctionMetrics[],
+  config: CodeMetricsConfig,
+  line: number
+): UnifiedFunctionMetrics | undefined {
+  const hotspots = functions
+    .filter((func) => ConfigurationManager.getPrimaryMetricStatus(func, config).status.level !== "low")
+    .sort((a, b) => a.startLine - b.startLine);
+  return hotspots.find((func) => func.startLine > line) ?? hotspots[0];
+}
+
+/**
+ * Shows how many functions of the active file are over the threshold in the status bar,
+ * updated shortly after the file is edited. Clicking the item moves to the next of them.
+ * Enabled with `codeMetrics.display.statusBar`; with
+ * `codeMetrics.display.statusBarHideWhenZero` a file without violations hides it.
+ *
+ * Only small files in the `onChange` trigger mode are analyzed here. Otherwise the item
+ * shows what the CodeLens provider analyzed, so large, lazily analyzed, skipped, and
+ * `onSave` or `manual` documents are not parsed on every edit.
+ */
+export class ComplexityStatusBar implements vscode.Disposable {
+  private readonly item: vscode.StatusBarItem;
+
+  constructor() {
+    this.item = vscode.window.createStatusBarItem(
+      "codeMetrics.functionsOverThreshold",
+      vscode.StatusBarAlignment.Right,
+      100
+    );
+    this.item.name = "Code Metrics: Functions Over Threshold";
+  }
+
+  /**
+   * Re-analyzes the active editor's document and updates the item.
+   * Unsupported, excluded, or disabled documents hide it, as do documents without an
+   * analysis to show yet.
+   *
+   * @param editor - The active editor, if any
+   */
+  public update(editor: vscode.TextEditor | undefined): void {
+    const document = editor?.document;
+    if (!document) {
+      this.item.hide();
+      return;
+    }
+    const config = ConfigurationManager.getConfiguration(document.uri, document.languageId);
+    if (
+      !config.enabled ||
+      !config.statusBar ||
+      !MetricsAnalyzerFactory.isSupportedLanguage(document.languageId) ||
+      document.uri.scheme.startsWith("git") ||
+      isExcludedFile(document.uri.fsPath, config)
+    ) {
+      this.item.hide();
+      return;
+    }
+
+    try {
+      const isLarge =
+        config.largeFileThreshold > 0 && document.lineCount > config.largeFileThreshold;
+      const functions =
+        config.analysisTrigger === "onChange" && !isLarge
+          ? MetricsAnalyzerFactory.analyzeFile(
+              document.getText(),
+              document.languageId,
+              ConfigurationManager.getAnalyzerOptions(config)
+            )
+          : getAnalyzedFunctions(document, config);
+      if (!functions) {
+        this.item.hide();
+        return;
+      }
+      const badge = getStatusBadge(functions, config, path.basename(document.uri.fsPath));
+      if (badge.count === 0 && config.statusBarHideWhenZero) {
+        this.item.hide();
+        return;
+      }
+      this.item.text = badge.text;
+      this.item.tooltip = badge.tooltip;
+      this.item.backgroundColor =
+        badge.errorCount > 0
+          ? new vscode.ThemeColor("statusBarItem.errorBackground")
+          : badge.count > 0
+            ? new vscode.ThemeColor("statusBarItem.warningBackground")
+            : undefined;
+      this.item.command = {
+        title: "Go To Next Function Over Threshold",
+        command: "codeMetrics.goToNextHotspot",
+      };
+      this.item.show();
+    } catch (error) {
+      console.error("Error updating the complexity status bar item:", error);
+      this.item.hide();
+    }
+  }
+
+  public dispose(): void {
+    this.item.dispose();
+  }
+}
+
+// Register the functions-over-threshold status bar item
+export function registerStatusBar(): vscode.Disposable {
+  const statusBar = new ComplexityStatusBar();
+  const updateActive = () => statusBar.update(vscode.window.activeTextEditor);
+
+  updateActive();
+
+  const editorWatcher = vscode.window.onDidChangeActiveTextEditor((editor) =>
+    statusBar.update(editor)
+  );
+  // Typing only recomputes the badge once it pauses.
+  let pendingUpdate: ReturnType<typeof setTimeout> | undefined;
+  const changeWatcher = vscode.workspace.onDidChangeTextDocument((e) => {
+    if (e.document === vscode.window.activeTextEditor?.document) {
+      clearTimeout(pendingUpdate);
+      pendingUpdate = setTimeout(updateActive, UPDATE_DELAY_MS);
+    }
+  });
+  // Saves in `onSave` mode, Analyze Current File, and background analyses of large files.
+  const analysisWatcher = onDidChangeAnalysis(updateActive);
+
+  // The toggle, thresholds or excludes may have changed.
+  const configWatcher = ConfigurationManager.onConfigurationChanged((_e) => updateActive());
+  const projectConfigWatcher = ConfigurationManager.onProjectConfigChanged(updateActive);
+  const profileWatcher = ConfigurationManager.onActiveProfileChanged(updateActive);
+
+  return vscode.Disposable.from(
+    statusBar,
+    editorWatcher,
+    changeWatcher,
+    analysisWatcher,
+    configWatcher,
+    projectConfigWatcher,
+    profileWatcher,
+    new vscode.Disposable(() => clearTimeout(pendingUpdate))
+  );
+}
@@ -0,0 +1,160 @@
+import * as vscode from "vscode";
+import {
+  MetricsAnalyzerFactory,
+  UnifiedFunctionMetrics,
+} from "../metricsAnalyzer/metricsAnalyzerFactory";
+import { ConfigurationManager } from "../configuration";
+import { isTestFile } from "../workspace/fileFilters";
+
+/**
+ * Go test entry points recognised by `go test`: the prefix alone, or followed by a
+ * character that is not a lower-case letter (`TestParse`, `Test_parse`, not `Testify`).
+ */
+const GO_TEST_FUNCTION = /^(Test|Benchmark|Fuzz|Example)(?:$|[^a-z])/;
+
+/** Python test functions and methods as discovered by pytest / unittest. */
+const PYTHON_TEST_FUNCTION = /(^|\.)test/;
+
+/**
+ * Selects the functions of a test file that are test entry points.
+ *
+ * Go and Python use name prefixes; for other languages every function in a test
+ * file (test methods, describe/it callbacks) is treated as test code.
+ *
+ * @param functions - All analyzed functions in the test file
+ * @param languageId - The file's language identifier
+ * @returns The test functions
+ */
+export function getTestFunctions(
+  functions: UnifiedFunctionMetrics[],
+  languageId: string
+): UnifiedFunctionMetrics[] {
+  switch (languageId) {
+    case "go":
+      return functions.filter((f) => GO_TEST_FUNCTION.test(f.name));
+    case "python":
+      return functions.filter((f) => PYTHON_TEST_FUNCTION.test(f.name));
+    default:
+      return functions;
+  }
+}
+
+/**
+ * Surfaces the complexity of test functions in the Test Explorer.
+ *
+ * Other extensions' test items cannot be annotated, so this owns a separate, read-only
+ * test controller whose items mirror the test functions of open test files with their
+ * complexity as the item description.
+ */
+export class TestComplexityProvider implements vscode.Disposable {
+  private readonly controller: vscode.TestController;
+
+  constructor() {
+    this.controller = vscode.tests.createTestController(
+      "codeMetrics.testComplexity",
+      "Test Complexity"
+    );
+  }
+
+  /** Top-level (per-file) test items, exposed for tests. */
+  public get items(): vscode.TestItemCollection {
+    return this.controller.items;
+  }
+
+  /**
+   * Re-analyzes a test document and replaces its items. Non-test documents are ignored.
+   *
+   * @param document - The document to analyze
+   */
+  public updateDocument(document: vscode.TextDocument): void {
+    if (
+      !MetricsAnalyzerFactory.isSupportedLanguage(document.languageId) ||
+      !isTestFile(document.uri.fsPath)
+    ) {
+      return;
+    }
+
+    const config = ConfigurationManager.getConfiguration(document.uri, document.languageId);
+    const functions = getTestFunctions(
+      MetricsAnalyzerFactory.analyzeFile(
+        document.getText(),
+        document.languageId,
+        ConfigurationManager.getAnalyzerOptions(config)
+      ),
+      document.languageId
+    );
+
+    const fileId = document.uri.toString();
+    const fileItem = this.controller.createTestItem(
+      fileId,
+      vscode.workspace.asRelativePath(document.uri, false),
+      document.uri
+    );
+    fileItem.children.replace(
+      functions.map((func) => {
+        const item = this.controller.createTestItem(
+          `${fileId}#${func.name}#${func.startLine}`,
+          func.name,
+          document.uri
+        );
+        const status = ConfigurationManager.getComplexityStatus(func.complexity, config);
+        item.description = `${status.icon} complexity ${func.complexity}`;
+        item.range = new vscode.Range(func.startLine, 0, func.endLine, 0);
+        return item;
+      })
+    );
+    this.controller.items.add(fileItem);
+  }
+
+  /** Removes the items for a document. */
+  public removeDocument(uri: vscode.Uri): void {
+    this.controller.items.delete(uri.toString());
+  }
+
+  public dispose(): void {
+    this.controller.dispose();
+  }
+}
+
+// Register the test complexity provider; it is only active while `includeTests` is enabled.
+export function registerTestComplexityProvider(): vscode.Disposable {
+  let provider: TestComplexityProvider | undefined;
+
+  const sync = () => {
+    const enabled =
+      ConfigurationManager.isEnabled() && ConfigurationManager.get("includeTests");
+    if (enabled && !provider) {
+      provider = new TestComplexityProvider();
+      vscode.workspace.textDocuments.forEach((doc) => provider!.updateDocument(doc));
+    } else if (!enabled && provider) {
+      provider.dispose();
+      provider = undefined;
+    }
+  };
+  sync();
+
+  const configWatcher = ConfigurationManager.onConfigurationChanged((_e) => sync());
+  // A profile may toggle `includeTests` or change the bands shown in item descriptions.
+  const profileWatcher = ConfigurationManager.onActiveProfileChanged(() => {
+    sync();
+    vscode.workspace.textDocuments.forEach((doc) => provider?.updateDocument(doc));
+  });
+  const openWatcher = vscode.workspace.onDidOpenTextDocument((doc) =>
+    provider?.updateDocument(doc)
+  );
+  const changeWatcher = vscode.workspace.onDidChangeTextDocument((e) =>
+    provider?.updateDocument(e.document)
+  );
+  const closeWatcher = vscode.workspace.onDidCloseTextDocument((doc) =>
+    provider?.removeDocument(doc.uri)
+  );
+
+  return vscode.Disposable.from(
+    configWatcher,
+    profileWatcher,
+    openWatcher,
+    changeWatcher,
+    closeWatcher,
+    { dispose: () => provider?.dispose() }
+  );
+}
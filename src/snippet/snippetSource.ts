@@ -0,0 +1,127 @@
+/**
+ * @fileoverview Ad-hoc Snippet Sources
+ *
+ * Helpers for analyzing throwaway code that does not live in the workspace: source
+ * fetched from a URL (raw files, GitHub blobs, gists) or pasted from the clipboard.
+ */
+
+import {
+  MetricsAnalyzerFactory,
+  UnifiedFunctionMetrics,
+} from "../metricsAnalyzer/metricsAnalyzerFactory";
+import { CodeMetricsConfig, ConfigurationManager } from "../configuration";
+
+/** Largest snippet that will be fetched and analyzed, in characters. */
+export const MAX_SNIPPET_LENGTH = 1_000_000;
+
+/** Time allowed for a snippet download before it is abandoned. */
+const FETCH_TIMEOUT_MS = 10_000;
+
+/**
+ * Rewrites well-known HTML page URLs to the raw file they display:
+ * GitHub `blob` pages to `raw.githubusercontent.com`, and gist pages to their `/raw` form.
+ * Other URLs are returned unchanged.
+ *
+ * @param url - The URL entered by the user
+ * @returns The URL to download
+ */
+export function toRawSourceUrl(url: URL): URL {
+  if (url.hostname === "github.com") {
+    // /owner/repo/blob/ref/path → /owner/repo/ref/path
+    const match = /^\/([^/]+)\/([^/]+)\/blob\/(.+)$/.exec(url.pathname);
+    if (match) {
+      return new URL(`https://raw.githubusercontent.com/${match[1]}/${match[2]}/${match[3]}`);
+    }
+  }
+  if (url.hostname === "gist.github.com" && !url.pathname.includes("/raw")) {
+    return new URL(`${url.origin}${url.pathname.replace(/\/$/, "")}/raw`);
+  }
+  return url;
+}
+
+/**
+ * Downloads source text from an http(s) URL.
+ *
+ * @param input - The URL entered by the user
+ * @param fetchImpl - Fetch implementation (injectable for tests)
+ * @returns The downloaded text
+ * @throws {Error} With a user-facing message for invalid URLs, HTTP errors, timeouts,
+ *   network failures, and oversized responses
+ */
+export async function fetchSnippet(
+  input: string,
+  fetchImpl: typeof fetch = fetch
+): Promise<string> {
+  let url: URL;
+  try {
+    url = new URL(input.trim());
+  } catch {
+    throw new Error(`"${input}" is not a valid URL`);
+  }
+  if (url.protocol !== "https:" && url.protocol !== "http:") {
+    throw new Error("Only http and https URLs are supported");
+  }
+
+  let response: Response;
+  try {
+    response = await fetchImpl(toRawSourceUrl(url), {
+      signal: AbortSignal.timeout(FETCH_TIMEOUT_MS),
+    });
+  } catch (error) {
+    throw new Error(`Could not download ${url.href}: ${(error as Error).message}`);
+  }
+  if (!response.ok) {
+    throw new Error(`Could not download ${url.href}: HTTP ${response.status}`);
+  }
+
+  const text = await response.text();
+  if (text.length > MAX_SNIPPET_LENGTH) {
+    throw new Error(`${url.href} is too large to analyze`);
+  }
+  return text;
+}
+
+/**
+ * Guesses the language of a snippet from the file name at the end of a URL.
+ *
+ * @param input - The URL entered by the user
+ * @returns The supported language identifier, or undefined when it cannot be inferred
+ */
+export function inferSnippetLanguage(input: string): string | undefined {
+  try {
+    return MetricsAnalyzerFactory.getLanguageIdForFile(new URL(input.trim()).pathname);
+  } catch {
+    return undefined;
+  }
+}
+
+/**
+ * Renders the metrics of an analyzed snippet as report lines.
+ *
+ * @param source - Where the snippet came from (URL or "clipboard")
+ * @param languageId - The language it was analyzed as
+ * @param functions - The analysis results
+ * @param config - The configuration used for status bands
+ * @returns Report lines ready to be written to an output channel
+ */
+export function formatSnippetReport(
+  source: string,
+  languageId: string,
+  functions: UnifiedFunctionMetrics[],
+  config: CodeMetricsConfig
+): string[] {
+  const lines = [`Snippet: ${source} (${languageId})`];
+  if (functions.length === 0) {
+    lines.push("  No functions found.");
+    return lines;
+  }
+  lines.push(`  ${functions.length} functions`);
+  const sorted = [...functions].sort((a, b) => b.complexity - a.complexity);
+  for (const func of sorted) {
+    const status = ConfigurationManager.getComplexityStatus(func.complexity, config);
+    lines.push(
+      `    ${status.icon} ${String(func.complexity).padStart(3)}  ${func.name} (line ${func.startLine + 1})`
+    );
+  }
+  return lines;
+}
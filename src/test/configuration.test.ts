@@ -11,7 +11,9 @@ import * as vscode from "vscode";
 import {
   ConfigurationManager,
   DEFAULT_CONFIG,
+  parseProjectConfig,
 } from "../configuration";
+import { MetricsAnalyzerFactory } from "../metricsAnalyzer/metricsAnalyzerFactory";
 
 suite("ConfigurationManager Tests", () => {
   teardown(async function () {
@@ -189,6 +191,151 @@ suite("ConfigurationManager Tests", () => {
     assert.ok(validationResult.warnings[0].includes("Warning threshold"));
   });
 
+  test("should pass enabled metrics to the analyzer and flag unknown ones", async () => {
+    const config = vscode.workspace.getConfiguration("codeMetrics");
+    await config.update(
+      "metrics.enabled",
+      ["complexity", "tokens", "halstead"],
+      vscode.ConfigurationTarget.Global
+    );
+    try {
+      const settings = ConfigurationManager.getConfiguration();
+      assert.deepStrictEqual(ConfigurationManager.getAnalyzerOptions(settings).metrics, ["tokens"]);
+
+      assert.deepStrictEqual(ConfigurationManager.validateConfiguration().warnings, [
+        'Metric "halstead" in metrics.enabled is not computed; use complexity, linesOfCode, tokens, recursion',
+      ]);
+    } finally {
+      await config.update("metrics.enabled", undefined, vscode.ConfigurationTarget.Global);
+    }
+  });
+
+  test("should reject primary metrics that are not implemented or not enabled", async () => {
+    const config = vscode.workspace.getConfiguration("codeMetrics");
+    await config.update("display.primaryMetric", "cyclomatic", vscode.ConfigurationTarget.Global);
+    try {
+      assert.strictEqual(ConfigurationManager.getConfiguration().primaryMetric, "cognitive");
+      assert.deepStrictEqual(ConfigurationManager.validateConfiguration().warnings, [
+        'Primary metric "cyclomatic" is not implemented; ' +
+          'set complexity.scoring to "cyclomatic" to score functions by it; using cognitive',
+      ]);
+
+      await config.update("display.primaryMetric", "nesting", vscode.ConfigurationTarget.Global);
+      await config.update("metrics.enabled", ["linesOfCode"], vscode.ConfigurationTarget.Global);
+      assert.deepStrictEqual(ConfigurationManager.validateConfiguration().warnings, [
+        'Primary metric "nesting" needs "complexity" in metrics.enabled',
+      ]);
+    } finally {
+      await config.update("display.primaryMetric", undefined, vscode.ConfigurationTarget.Global);
+      await config.update("metrics.enabled", undefined, vscode.ConfigurationTarget.Global);
+    }
+  });
+
+  test("should warn when sorting reports by tokens without counting them", async () => {
+    const config = vscode.workspace.getConfiguration("codeMetrics");
+    await config.update("reportSortBy", "tokens", vscode.ConfigurationTarget.Global);
+    try {
+      assert.deepStrictEqual(ConfigurationManager.validateConfiguration().warnings, [
+        'Report sort order "tokens" needs "tokens" in metrics.enabled; functions are not counted',
+      ]);
+
+      await config.update(
+        "metrics.enabled",
+        ["complexity", "tokens"],
+        vscode.ConfigurationTarget.Global
+      );
+      assert.deepStrictEqual(ConfigurationManager.validateConfiguration().warnings, []);
+    } finally {
+      await config.update("reportSortBy", undefined, vscode.ConfigurationTarget.Global);
+      await config.update("metrics.enabled", undefined, vscode.ConfigurationTarget.Global);
+    }
+  });
+
+  test("should pass excluded function patterns to the analyzer and flag invalid ones", async () => {
+    const config = vscode.workspace.getConfiguration("codeMetrics");
+    await config.update(
+      "analysis.excludeFunctionPatterns",
+      ["\\.String$", "("],
+      vscode.ConfigurationTarget.Global
+    );
+    try {
+      const settings = ConfigurationManager.getConfiguration();
+      assert.deepStrictEqual(
+        ConfigurationManager.getAnalyzerOptions(settings).excludeFunctions,
+        ["\\.String$", "("]
+      );
+
+      const validationResult = ConfigurationManager.validateConfiguration();
+      assert.deepStrictEqual(validationResult.warnings, [
+        'Function name pattern "(" is not a valid regular expression',
+      ]);
+    } finally {
+      await config.update(
+        "analysis.excludeFunctionPatterns",
+        undefined,
+        vscode.ConfigurationTarget.Global
+      );
+    }
+  });
+
+  test("should let a complexity preset replace the rule settings and flag unknown ones", async () => {
+    const config = vscode.workspace.getConfiguration("codeMetrics");
+    await config.update("complexity.base", 0, vscode.ConfigurationTarget.Global);
+    await config.update("complexity.preset", "mccabe-classic", vscode.ConfigurationTarget.Global);
+    try {
+      const settings = ConfigurationManager.getConfiguration();
+      assert.strictEqual(settings.complexityScoring, "cyclomatic");
+      assert.strictEqual(settings.complexityBase, 1);
+      assert.strictEqual(settings.panicMode, "exit");
+      assert.strictEqual(settings.closureMode, "inline");
+      assert.strictEqual(settings.literalFuncMode, "attributed");
+      assert.deepStrictEqual(ConfigurationManager.validateConfiguration().warnings, []);
+
+      await config.update("complexity.preset", "eslint", vscode.ConfigurationTarget.Global);
+      assert.strictEqual(ConfigurationManager.getConfiguration().complexityBase, 0);
+      assert.deepStrictEqual(ConfigurationManager.validateConfiguration().warnings, [
+        'Complexity preset "eslint" is not known; use custom, sonar, gocyclo, mccabe-classic',
+      ]);
+    } finally {
+      await config.update("complexity.preset", undefined, vscode.ConfigurationTarget.Global);
+      await config.update("complexity.base", undefined, vscode.ConfigurationTarget.Global);
+    }
+  });
+
+  test("should score Go functions as gocyclo does with the gocyclo preset", async () => {
+    const sourceCode = [
+      "package main",
+      "",
+      "func Grade(n int, strict bool) string {",
+      "\tswitch {",
+      "\tcase n > 90 && strict:",
+      "\t\treturn \"A\"",
+      "\tcase n > 75:",
+      "\t\treturn \"B\"",
+      "\tdefault:",
+      "\t\treturn \"C\"",
+      "\t}",
+      "}",
+    ].join("\n");
+    const score = () =>
+      MetricsAnalyzerFactory.analyzeFile(
+        sourceCode,
+        "go",
+        ConfigurationManager.getAnalyzerOptions(ConfigurationManager.getConfiguration())
+      )[0].complexity;
+
+    // switch(1) + &&(1)
+    assert.strictEqual(score(), 2);
+    const config = vscode.workspace.getConfiguration("codeMetrics");
+    await config.update("complexity.preset", "gocyclo", vscode.ConfigurationTarget.Global);
+    try {
+      // gocyclo reports 4: 1 + two non-default cases + &&
+      assert.strictEqual(score(), 4);
+    } finally {
+      await config.update("complexity.preset", undefined, vscode.ConfigurationTarget.Global);
+    }
+  });
+
   test("should create configuration change watcher", () => {
     const watcher = ConfigurationManager.onConfigurationChanged(
       (_e: vscode.ConfigurationChangeEvent) => { /* no-op */ }
@@ -234,4 +381,374 @@ suite("ConfigurationManager Tests", () => {
     assert.ok(config.errorThreshold !== undefined);
     assert.ok(config.excludePatterns !== undefined);
   });
+
+  test("should parse valid .codemetrics.json overrides", () => {
+    const overrides = parseProjectConfig(
+      JSON.stringify({
+        warningThreshold: 4,
+        errorThreshold: 8,
+        excludePatterns: ["**/gen/**"],
+      })
+    );
+
+    assert.deepStrictEqual(overrides, {
+      warningThreshold: 4,
+      errorThreshold: 8,
+      excludePatterns: ["**/gen/**"],
+    });
+  });
+
+  test("should ignore unknown keys and mistyped values in .codemetrics.json", () => {
+    const overrides = parseProjectConfig(
+      JSON.stringify({
+        warningThreshold: "4",
+        excludePatterns: [1, 2],
+        unknownSetting: true,
+        enabled: false,
+      })
+    );
+
+    assert.deepStrictEqual(overrides, { enabled: false });
+  });
+
+  test("should read a complexity budget from .codemetrics.json", () => {
+    assert.deepStrictEqual(parseProjectConfig('{ "complexityBudget": 20 }'), { complexityBudget: 20 });
+    assert.deepStrictEqual(parseProjectConfig('{ "complexityBudget": null }'), { complexityBudget: null });
+    assert.deepStrictEqual(parseProjectConfig('{ "complexityBudget": "20" }'), {});
+  });
+
+  test("should ignore malformed .codemetrics.json content", () => {
+    assert.deepStrictEqual(parseProjectConfig("{ not json"), {});
+    assert.deepStrictEqual(parseProjectConfig("[1, 2]"), {});
+  });
+
+  test("should return no project overrides outside a workspace folder", () => {
+    assert.deepStrictEqual(ConfigurationManager.getProjectConfig(), {});
+    assert.deepStrictEqual(
+      ConfigurationManager.getProjectConfig(vscode.Uri.file("/not/in/workspace.go")),
+      {}
+    );
+  });
+
+  test("should apply the active threshold profile over settings", async () => {
+    const config = vscode.workspace.getConfiguration("codeMetrics");
+    await config.update(
+      "profiles",
+      {
+        strict: { warningThreshold: 3, errorThreshold: 6, bogus: true },
+        legacy: { warningThreshold: "20" },
+      },
+      vscode.ConfigurationTarget.Global
+    );
+    const changes: (string | undefined)[] = [];
+    const listener = ConfigurationManager.onActiveProfileChanged((name) => changes.push(name));
+    try {
+      assert.deepStrictEqual(ConfigurationManager.getProfiles(), {
+        strict: { warningThreshold: 3, errorThreshold: 6 },
+        legacy: {},
+      });
+
+      ConfigurationManager.setActiveProfile("strict");
+      assert.strictEqual(ConfigurationManager.getConfiguration().warningThreshold, 3);
+      assert.strictEqual(ConfigurationManager.get("errorThreshold"), 6);
+      assert.strictEqual(ConfigurationManager.getComplexityStatus(6).level, "error");
+
+      // Unknown profiles fall back to the configured values
+      ConfigurationManager.setActiveProfile("missing");
+      assert.strictEqual(
+        ConfigurationManager.getConfiguration().warningThreshold,
+        DEFAULT_CONFIG.warningThreshold
+      );
+
+      ConfigurationManager.setActiveProfile(undefined);
+      assert.deepStrictEqual(changes, ["strict", "missing", undefined]);
+    } finally {
+      listener.dispose();
+      ConfigurationManager.setActiveProfile(undefined);
+      await config.update("profiles", undefined, vscode.ConfigurationTarget.Global);
+    }
+  });
+
+  test("should report field access warnings only above an enabled threshold", () => {
+    const func = {
+      name: "Service.Handle",
+      complexity: 1,
+      details: [],
+      startLine: 0,
+      endLine: 10,
+      startColumn: 0,
+      endColumn: 1,
+      fieldAccessCount: 9,
+    };
+
+    // Default threshold (0) disables the check
+    assert.deepStrictEqual(
+      ConfigurationManager.getMetricWarnings(func, DEFAULT_CONFIG),
+      []
+    );
+
+    const over = ConfigurationManager.getMetricWarnings(func, {
+      ...DEFAULT_CONFIG,
+      fieldAccessThreshold: 8,
+    });
+    assert.strictEqual(over.length, 1);
+    assert.ok(over[0].includes("9 receiver fields"));
+
+    const atLimit = ConfigurationManager.getMetricWarnings(func, {
+      ...DEFAULT_CONFIG,
+      fieldAccessThreshold: 9,
+    });
+    assert.strictEqual(atLimit.length, 0);
+  });
+
+  test("should report condition operand warnings only above an enabled threshold", () => {
+    const func = {
+      name: "Check",
+      complexity: 3,
+      details: [],
+      startLine: 0,
+      endLine: 4,
+      startColumn: 0,
+      endColumn: 1,
+      maxConditionOperands: 5,
+    };
+
+    assert.deepStrictEqual(
+      ConfigurationManager.getMetricWarnings(func, DEFAULT_CONFIG),
+      []
+    );
+
+    const warnings = ConfigurationManager.getMetricWarnings(func, {
+      ...DEFAULT_CONFIG,
+      conditionOperandThreshold: 4,
+    });
+    assert.strictEqual(warnings.length, 1);
+    assert.ok(warnings[0].includes("5 boolean operands"));
+  });
+
+  test("should report the longest switch above the case clause threshold", () => {
+    const func = {
+      name: "Route",
+      complexity: 2,
+      details: [],
+      startLine: 0,
+      endLine: 60,
+      startColumn: 0,
+      endColumn: 1,
+      switches: [
+        { kind: "switch", caseCount: 4, line: 2, column: 1 },
+        { kind: "type switch", caseCount: 25, line: 10, column: 1 },
+        { kind: "switch", caseCount: 22, line: 40, column: 1 },
+      ],
+    };
+
+    assert.deepStrictEqual(ConfigurationManager.getMetricWarnings(func, DEFAULT_CONFIG), [
+      "Type switch with 25 cases (threshold 20)",
+    ]);
+    assert.deepStrictEqual(
+      ConfigurationManager.getLongSwitches(func, DEFAULT_CONFIG).map((s) => s.line),
+      [10, 40]
+    );
+    assert.deepStrictEqual(
+      ConfigurationManager.getMetricWarnings(func, { ...DEFAULT_CONFIG, caseClauseThreshold: 0 }),
+      []
+    );
+  });
+
+  test("should report expression nesting warnings only above an enabled threshold", () => {
+    const func = {
+      name: "Eligible",
+      complexity: 4,
+      details: [],
+      startLine: 0,
+      endLine: 3,
+      startColumn: 0,
+      endColumn: 1,
+      maxExpressionNesting: 3,
+    };
+
+    assert.deepStrictEqual(
+      ConfigurationManager.getMetricWarnings(func, DEFAULT_CONFIG),
+      []
+    );
+
+    const warnings = ConfigurationManager.getMetricWarnings(func, {
+      ...DEFAULT_CONFIG,
+      expressionNestingThreshold: 2,
+    });
+    assert.deepStrictEqual(warnings, [
+      "Conditional expressions nested 3 deep (threshold 2)",
+    ]);
+  });
+
+  test("should report closure depth warnings only above an enabled threshold", () => {
+    const func = {
+      name: "Serve",
+      complexity: 2,
+      details: [],
+      startLine: 0,
+      endLine: 12,
+      startColumn: 0,
+      endColumn: 1,
+      maxClosureDepth: 3,
+    };
+
+    assert.deepStrictEqual(
+      ConfigurationManager.getMetricWarnings(func, DEFAULT_CONFIG),
+      []
+    );
+    assert.deepStrictEqual(
+      ConfigurationManager.getMetricWarnings(func, { ...DEFAULT_CONFIG, closureDepthThreshold: 3 }),
+      []
+    );
+
+    const warnings = ConfigurationManager.getMetricWarnings(func, {
+      ...DEFAULT_CONFIG,
+      closureDepthThreshold: 2,
+    });
+    assert.strictEqual(warnings.length, 1);
+    assert.ok(warnings[0].includes("nested 3 deep"));
+  });
+
+  test("should report branch density warnings only above an enabled threshold", () => {
+    const func = {
+      name: "Load",
+      complexity: 2,
+      details: [],
+      startLine: 0,
+      endLine: 10,
+      startColumn: 0,
+      endColumn: 1,
+      statementCount: 4,
+      branchCount: 2,
+      branchDensity: 0.5,
+    };
+
+    assert.deepStrictEqual(ConfigurationManager.getMetricWarnings(func, DEFAULT_CONFIG), []);
+    assert.deepStrictEqual(
+      ConfigurationManager.getMetricWarnings(func, { ...DEFAULT_CONFIG, branchDensityThreshold: 0.5 }),
+      []
+    );
+    assert.deepStrictEqual(
+      ConfigurationManager.getMetricWarnings(func, { ...DEFAULT_CONFIG, branchDensityThreshold: 0.4 }),
+      ["2 branches in 4 statements, density 0.5 (threshold 0.4)"]
+    );
+  });
+
+  test("should find the function dominating its file's complexity", () => {
+    const func = (name: string, complexity: number) => ({
+      name,
+      complexity,
+      details: [],
+      startLine: 0,
+      endLine: 0,
+      startColumn: 0,
+      endColumn: 0,
+    });
+    const giant = func("Giant", 12);
+    const functions = [giant, func("Small", 3), func("Tiny", 1)];
+
+    assert.deepStrictEqual(
+      ConfigurationManager.getDominantFunction(functions, DEFAULT_CONFIG),
+      { func: giant, share: 75 }
+    );
+    assert.strictEqual(
+      ConfigurationManager.getDominantFunction(functions, { ...DEFAULT_CONFIG, dominantFunctionShare: 80 }),
+      undefined
+    );
+    assert.strictEqual(
+      ConfigurationManager.getDominantFunction(functions, { ...DEFAULT_CONFIG, dominantFunctionShare: 0 }),
+      undefined
+    );
+    // A single function, or a file below the warning threshold, is never flagged.
+    assert.strictEqual(ConfigurationManager.getDominantFunction([giant], DEFAULT_CONFIG), undefined);
+    assert.strictEqual(
+      ConfigurationManager.getDominantFunction([func("A", 6), func("B", 1)], DEFAULT_CONFIG),
+      undefined
+    );
+  });
+
+  test("should band functions by their primary metric", () => {
+    const func = {
+      name: "Walk",
+      complexity: 6,
+      details: [
+        { increment: 1, reason: "for", line: 2, column: 4, nesting: 0 },
+        { increment: 2, reason: "for", line: 3, column: 8, nesting: 1 },
+        { increment: 3, reason: "if", line: 4, column: 12, nesting: 2 },
+      ],
+      startLine: 0,
+      endLine: 8,
+      startColumn: 0,
+      endColumn: 1,
+    };
+    const nesting = { ...DEFAULT_CONFIG, primaryMetric: "nesting" as const };
+
+    assert.strictEqual(ConfigurationManager.getPrimaryMetricValue(func, DEFAULT_CONFIG), 6);
+    assert.strictEqual(ConfigurationManager.getPrimaryMetricValue(func, nesting), 2);
+    assert.strictEqual(
+      ConfigurationManager.getPrimaryMetricValue({ ...func, details: [] }, nesting),
+      0
+    );
+
+    assert.strictEqual(ConfigurationManager.getPrimaryMetricStatus(func, DEFAULT_CONFIG).status.level, "low");
+    assert.deepStrictEqual(
+      ConfigurationManager.getPrimaryMetricStatus(func, { ...nesting, nestingWarningThreshold: 2 }),
+      { value: 2, status: { level: "warning", icon: "🟡", text: "Moderate Complexity" } }
+    );
+    assert.strictEqual(
+      ConfigurationManager.getPrimaryMetricStatus(func, { ...nesting, nestingErrorThreshold: 2 }).status.level,
+      "error"
+    );
+  });
+
+  test("should fall back to cognitive complexity for an unsupported primary metric", async () => {
+    const config = vscode.workspace.getConfiguration("codeMetrics");
+    await config.update("display.primaryMetric", "nesting", vscode.ConfigurationTarget.Global);
+    await config.update(
+      "profiles",
+      { legacy: { primaryMetric: "maintainability" } },
+      vscode.ConfigurationTarget.Global
+    );
+    try {
+      assert.strictEqual(ConfigurationManager.getConfiguration().primaryMetric, "nesting");
+
+      ConfigurationManager.setActiveProfile("legacy");
+      assert.strictEqual(ConfigurationManager.getConfiguration().primaryMetric, "cognitive");
+    } finally {
+      ConfigurationManager.setActiveProfile(undefined);
+      await config.update("display.primaryMetric", undefined, vscode.ConfigurationTarget.Global);
+      await config.update("profiles", undefined, vscode.ConfigurationTarget.Global);
+    }
+  });
+
+  test("should derive analyzer options from the closure mode", async () => {
+    assert.strictEqual(ConfigurationManager.getConfiguration().closureMode, "inline");
+
+    const config = vscode.workspace.getConfiguration("codeMetrics");
+    await config.update("closureMode", "separate", vscode.ConfigurationTarget.Global);
+    try {
+      assert.deepStrictEqual(
+        ConfigurationManager.getAnalyzerOptions(ConfigurationManager.getConfiguration()),
+        {
+          metrics: ["linesOfCode", "recursion"],
+          closureMode: "separate",
+          excludeGenerated: true,
+          collectNodeCounts: false,
+          fingerprints: false,
+          engine: "builtin",
+          excludeFunctions: [],
+          complexityBase: 0,
+          streamingThreshold: 20000,
+          featureFlagPatterns: [],
+          resolverPatterns: [],
+          panicMode: "statement",
+          literalFuncMode: "attributed",
+          scoring: "cognitive",
+        }
+      );
+    } finally {
+      await config.update("closureMode", undefined, vscode.ConfigurationTarget.Global);
+    }
+  });
 });
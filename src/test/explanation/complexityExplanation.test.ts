@@ -0,0 +1,102 @@
+import * as assert from "assert";
+import { UnifiedFunctionMetrics } from "../../metricsAnalyzer/metricsAnalyzerFactory";
+import {
+  explainFunction,
+  formatExplanationSummary,
+  getConstruct,
+  renderExplanationHtml,
+} from "../../explanation/complexityExplanation";
+
+suite("Complexity Explanation Tests", () => {
+  const lines = [
+    "func Check(items []int, strict bool) int {",
+    "    for _, item := range items {",
+    "        if item < 0 && strict {",
+    "            return -1",
+    "        } else {",
+    "            continue",
+    "        }",
+    "    }",
+    "    return 0",
+    "}",
+  ];
+  const func: UnifiedFunctionMetrics = {
+    name: "Check",
+    complexity: 6,
+    details: [
+      { increment: 1, reason: "for loop", line: 2, column: 5, nesting: 0 },
+      { increment: 1, reason: "binary && operator", line: 3, column: 12, nesting: 1 },
+      { increment: 2, reason: "if statement", line: 3, column: 9, nesting: 1 },
+      { increment: 1, reason: "else clause", line: 5, column: 11, nesting: 1 },
+      { increment: 1, reason: "continue statement (nested)", line: 6, column: 13, nesting: 2 },
+    ],
+    startLine: 0,
+    endLine: 9,
+    startColumn: 0,
+    endColumn: 1,
+  };
+
+  test("should name the construct of each detail reason", () => {
+    assert.deepStrictEqual(
+      [
+        "if statement",
+        "binary && operator",
+        "logical AND operator",
+        "else if clause",
+        "for loop",
+        "loop",
+        "function literal (nested)",
+      ].map(getConstruct),
+      ["if", "&&", "AND", "else if", "for", "loop", "function literal"]
+    );
+  });
+
+  test("should explain each decision in source order", () => {
+    const decisions = explainFunction(func, lines);
+
+    assert.deepStrictEqual(
+      decisions.map((d) => [d.summary, d.source]),
+      [
+        ["+1 for `for` at line 2", "for _, item := range items {"],
+        ["+2 for `if` at line 3", "if item < 0 && strict {"],
+        ["+1 for `&&` at line 3", "if item < 0 && strict {"],
+        ["+1 for `else` at line 5", "} else {"],
+        ["+1 for `continue` at line 6", "continue"],
+      ]
+    );
+    assert.ok(decisions[1].explanation.endsWith("plus 1 because it is nested one level deep."));
+    assert.ok(decisions[2].explanation.startsWith("A sequence of logical operators"));
+    assert.ok(decisions[3].explanation.endsWith("Counted flat, whatever its nesting."));
+    assert.ok(!decisions[0].explanation.includes("nested"));
+  });
+
+  test("should sum up a function in one line", () => {
+    assert.strictEqual(
+      formatExplanationSummary({ ...func, logicalLines: 8 }, explainFunction(func, lines)),
+      "Check: complexity 6 from 5 decisions, 8 lines of code. Largest: +2 for `if` at line 3."
+    );
+    assert.strictEqual(
+      formatExplanationSummary({ ...func, complexity: 0, details: [] }, []),
+      "Check: complexity 0. Nothing adds to it."
+    );
+  });
+
+  test("should render linked lines and escape source text", () => {
+    const html = renderExplanationHtml(
+      func,
+      explainFunction(func, lines),
+      (line) => `command:reveal?${line}`
+    );
+
+    assert.ok(html.includes('href="command:reveal?3"'));
+    assert.ok(html.includes("+2 for <code>if</code> at line 3"));
+    assert.ok(html.includes("<code>if item &lt; 0 &amp;&amp; strict {</code>"));
+    assert.ok(html.includes("Cognitive complexity 6, from 5 counted decisions."));
+  });
+
+  test("should say when nothing adds complexity", () => {
+    const html = renderExplanationHtml({ ...func, complexity: 0, details: [] }, [], () => "");
+
+    assert.ok(html.includes("Nothing in this function adds to its complexity"));
+  });
+});
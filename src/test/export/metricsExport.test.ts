@@ -0,0 +1,304 @@
+import * as assert from "assert";
+import * as fs from "fs";
+import * as path from "path";
+import * as vscode from "vscode";
+import {
+  collectExportRows,
+  formatExport,
+  formatExportSchema,
+} from "../../export/metricsExport";
+import { EXPORT_SCHEMA, EXPORT_SCHEMA_VERSION } from "../../export/exportSchema";
+import { RootMetrics, WorkspaceMetrics } from "../../workspace/workspaceAnalyzer";
+import { DEFAULT_CONFIG } from "../../configuration";
+import { MetricsAnalyzerFactory } from "../../metricsAnalyzer/metricsAnalyzerFactory";
+
+suite("Metrics Export Tests", () => {
+  // Flat has complexity 0, Branch 1, and Nested 3.
+  const sourceCode = `
+package main
+
+func Flat() int {
+    return 0
+}
+
+func Branch(a bool) int {
+    if a {
+        return 1
+    }
+    return 0
+}
+
+func Nested(a, b bool) int {
+    if a {
+        if b {
+            return 2
+        }
+        return 1
+    }
+    return 0
+}
+`;
+
+  function createRoot(name: string, warningThreshold: number, errorThreshold: number): RootMetrics {
+    return {
+      name,
+      folder: undefined,
+      config: { ...DEFAULT_CONFIG, warningThreshold, errorThreshold },
+      files: [
+        {
+          uri: vscode.Uri.file(`/${name}/main.go`),
+          relativePath: "main.go",
+          languageId: "go",
+          functions: MetricsAnalyzerFactory.analyzeFile(sourceCode, "go"),
+        },
+      ],
+    };
+  }
+
+  const metrics: WorkspaceMetrics = { roots: [createRoot("app", 1, 3)] };
+
+  suite("Violation Filter", () => {
+    test("should export every function by default", () => {
+      const rows = collectExportRows(metrics, { format: "json" });
+
+      assert.deepStrictEqual(
+        rows.map((r) => [r.function, r.status]),
+        [["Flat", "low"], ["Branch", "warning"], ["Nested", "error"]]
+      );
+    });
+
+    test("should keep the warning band and above for warning violations", () => {
+      const rows = collectExportRows(metrics, { format: "json", onlyViolations: "warning" });
+
+      assert.deepStrictEqual(rows.map((r) => r.function), ["Branch", "Nested"]);
+    });
+
+    test("should keep only the error band for error violations", () => {
+      const rows = collectExportRows(metrics, { format: "csv", onlyViolations: "error" });
+
+      assert.deepStrictEqual(rows.map((r) => r.function), ["Nested"]);
+    });
+
+    test("should band and filter by the thresholds of the file's language", () => {
+      const root = {
+        ...createRoot("app", 10, 20),
+        languageThresholds: { go: { warningThreshold: 1, errorThreshold: 3 } },
+      };
+
+      const rows = collectExportRows({ roots: [root] }, { format: "json", onlyViolations: "warning" });
+
+      assert.deepStrictEqual(
+        rows.map((r) => [r.function, r.status]),
+        [["Branch", "warning"], ["Nested", "error"]]
+      );
+    });
+
+    test("should apply each root's own thresholds", () => {
+      const rows = collectExportRows(
+        { roots: [createRoot("strict", 1, 3), createRoot("lenient", 10, 15)] },
+        { format: "json", onlyViolations: "warning" }
+      );
+
+      assert.deepStrictEqual(rows.map((r) => r.root), ["strict", "strict"]);
+    });
+  });
+
+  suite("Qualified Names", () => {
+    test("should give every row a unique id when enabled", () => {
+      const rows = collectExportRows(
+        { roots: [createRoot("api", 1, 3), createRoot("web", 1, 3)] },
+        { format: "json", qualifiedNames: true }
+      );
+
+      assert.strictEqual(rows[0].id, "api/main.go#Flat");
+      assert.strictEqual(new Set(rows.map((r) => r.id)).size, rows.length);
+    });
+
+    test("should suffix names repeated within a file with their line", () => {
+      const root = createRoot("app", 1, 3);
+      const [flat] = root.files[0].functions;
+      root.files[0].functions = [flat, { ...flat, startLine: 20, endLine: 22 }];
+
+      const rows = collectExportRows({ roots: [root] }, { format: "csv", qualifiedNames: true });
+
+      assert.deepStrictEqual(rows.map((r) => r.id), ["app/main.go#Flat@4", "app/main.go#Flat@21"]);
+    });
+
+    test("should lead CSV rows with the id column only when ids are present", () => {
+      const withIds = formatExport(
+        collectExportRows(metrics, { format: "csv", onlyViolations: "error", qualifiedNames: true }),
+        "csv"
+      ).split("\n");
+      const withoutIds = formatExport(collectExportRows(metrics, { format: "csv" }), "csv");
+
+      assert.ok(withIds[0].startsWith("id,root,file,"));
+      assert.ok(withIds[1].startsWith("app/main.go#Nested,app,main.go,"));
+      assert.ok(withoutIds.startsWith("root,file,"));
+    });
+
+    test("should append a fingerprint column when functions carry fingerprints", () => {
+      const root = createRoot("app", 1, 3);
+      root.files[0].functions = root.files[0].functions.map((func) => ({
+        ...func,
+        fingerprint: "0123456789ab",
+      }));
+
+      const lines = formatExport(collectExportRows({ roots: [root] }, { format: "csv" }), "csv")
+        .trimEnd()
+        .split("\n");
+
+      assert.ok(lines[0].endsWith(",logicalLines,fingerprint"));
+      assert.ok(lines[1].endsWith(",0123456789ab"));
+    });
+  });
+
+  suite("Formatting", () => {
+    test("should write 1-based lines and a header row in CSV", () => {
+      const rows = collectExportRows(metrics, { format: "csv", onlyViolations: "error" });
+      const lines = formatExport(rows, "csv").trimEnd().split("\n");
+
+      assert.strictEqual(
+        lines[0],
+        "root,file,language,function,startLine,endLine,complexity,status,logicalLines"
+      );
+      assert.strictEqual(lines[1], "app,main.go,go,Nested,15,23,3,error,9");
+    });
+
+    test("should write the kept rows as OpenMetrics gauges", () => {
+      const rows = collectExportRows(metrics, { format: "openmetrics", onlyViolations: "warning" });
+      const lines = formatExport(rows, "openmetrics").trimEnd().split("\n");
+
+      assert.deepStrictEqual(lines.slice(2, 4), [
+        'code_complexity{file="main.go",func="Branch",language="go"} 1',
+        'code_complexity{file="main.go",func="Nested",language="go"} 3',
+      ]);
+      assert.ok(lines.includes('code_logical_lines{file="main.go",func="Nested",language="go"} 9'));
+      assert.strictEqual(lines[lines.length - 1], "# EOF");
+    });
+
+    test("should leave out the logicalLines column when lines of code are not measured", () => {
+      const rows = collectExportRows(metrics, { format: "csv", onlyViolations: "error" }).map(
+        (row) => ({ ...row, logicalLines: undefined })
+      );
+      const lines = formatExport(rows, "csv").trimEnd().split("\n");
+
+      assert.deepStrictEqual(lines, [
+        "root,file,language,function,startLine,endLine,complexity,status",
+        "app,main.go,go,Nested,15,23,3,error",
+      ]);
+    });
+
+    test("should quote CSV fields containing separators or quotes", () => {
+      const [row] = collectExportRows(metrics, { format: "csv", onlyViolations: "error" });
+      const csv = formatExport([{ ...row, function: 'Outer, "inner"' }], "csv");
+
+      assert.ok(csv.includes(',"Outer, ""inner""",'));
+    });
+
+    test("should write JSON that parses back to the rows", () => {
+      const rows = collectExportRows(metrics, { format: "json" });
+      const exported = JSON.parse(formatExport(rows, "json"));
+
+      assert.strictEqual(exported.schemaVersion, EXPORT_SCHEMA_VERSION);
+      assert.deepStrictEqual(exported.functions, rows);
+    });
+  });
+
+  suite("Schema", () => {
+    type Schema = {
+      type?: string;
+      const?: unknown;
+      enum?: readonly unknown[];
+      minimum?: number;
+      required?: readonly string[];
+      additionalProperties?: boolean;
+      properties?: Record<string, Schema>;
+      items?: Schema;
+    };
+
+    /** Checks a value against the keywords the export schema uses; returns the violations. */
+    function validate(value: unknown, schema: Schema, at = "$"): string[] {
+      const errors: string[] = [];
+      const actualType = Array.isArray(value)
+        ? "array"
+        : Number.isInteger(value)
+          ? "integer"
+          : value === null
+            ? "null"
+            : typeof value;
+      if (schema.type && schema.type !== actualType) {
+        return [`${at}: expected ${schema.type}, got ${actualType}`];
+      }
+      if ("const" in schema && value !== schema.const) {
+        errors.push(`${at}: expected ${JSON.stringify(schema.const)}`);
+      }
+      if (schema.enum && !schema.enum.includes(value)) {
+        errors.push(`${at}: ${JSON.stringify(value)} is not one of ${JSON.stringify(schema.enum)}`);
+      }
+      if (schema.minimum !== undefined && typeof value === "number" && value < schema.minimum) {
+        errors.push(`${at}: ${value} is below ${schema.minimum}`);
+      }
+      if (actualType === "object") {
+        const object = value as Record<string, unknown>;
+        for (const key of schema.required ?? []) {
+          if (!(key in object)) {
+            errors.push(`${at}: missing ${key}`);
+          }
+        }
+        for (const [key, child] of Object.entries(object)) {
+          const childSchema = schema.properties?.[key];
+          if (childSchema) {
+            errors.push(...validate(child, childSchema, `${at}.${key}`));
+          } else if (schema.additionalProperties === false) {
+            errors.push(`${at}: unexpected ${key}`);
+          }
+        }
+      }
+      if (actualType === "array" && schema.items) {
+        (value as unknown[]).forEach((item, i) => {
+          errors.push(...validate(item, schema.items!, `${at}[${i}]`));
+        });
+      }
+      return errors;
+    }
+
+    test("should write JSON exports that match the schema, optional fields included", () => {
+      const root = createRoot("app", 1, 3);
+      root.files[0].functions = MetricsAnalyzerFactory.analyzeFile(sourceCode, "go", {
+        fingerprints: true,
+      });
+      const rows = collectExportRows({ roots: [root] }, { format: "json", qualifiedNames: true });
+      const exported = JSON.parse(formatExport(rows, "json"));
+
+      assert.ok(exported.functions[0].id && exported.functions[0].fingerprint);
+      assert.deepStrictEqual(validate(exported, EXPORT_SCHEMA), []);
+      assert.deepStrictEqual(
+        validate(JSON.parse(formatExport([], "json")), EXPORT_SCHEMA),
+        []
+      );
+    });
+
+    test("should reject rows that break the contract", () => {
+      const [row] = collectExportRows(metrics, { format: "json" });
+      const exported = {
+        schemaVersion: EXPORT_SCHEMA_VERSION,
+        functions: [{ ...row, status: "critical", extra: 1 }],
+      };
+
+      assert.deepStrictEqual(validate(exported, EXPORT_SCHEMA), [
+        `$.functions[0].status: "critical" is not one of ["low","warning","error"]`,
+        "$.functions[0]: unexpected extra",
+      ]);
+    });
+
+    test("should publish the schema the exporter is written against", () => {
+      const published = fs.readFileSync(
+        path.resolve(__dirname, "../../../schemas/metrics-export.schema.json"),
+        "utf-8"
+      );
+
+      assert.strictEqual(published, formatExportSchema());
+      assert.strictEqual(JSON.parse(published).properties.schemaVersion.const, EXPORT_SCHEMA_VERSION);
+    });
+  });
+});
@@ -6,8 +6,11 @@
  */
 
 import * as assert from "assert";
+import * as path from "path";
 import * as vscode from "vscode";
 import * as extensionModule from "../extension";
+import { CodeMetricsApi } from "../api";
+import { MetricsAnalyzerFactory } from "../metricsAnalyzer/metricsAnalyzerFactory";
 
 suite("Extension Activation Tests", () => {
   // Ensure extension is activated before running tests
@@ -34,6 +37,132 @@ suite("Extension Activation Tests", () => {
     );
   });
 
+  test("should register codeMetrics.analyzeWorkspace command", async () => {
+    const commands = await vscode.commands.getCommands(true);
+
+    assert.ok(
+      commands.includes("codeMetrics.analyzeWorkspace"),
+      "Command codeMetrics.analyzeWorkspace should be registered"
+    );
+  });
+
+  test("should register codeMetrics.listAnalyzableFiles command", async () => {
+    const commands = await vscode.commands.getCommands(true);
+
+    assert.ok(
+      commands.includes("codeMetrics.listAnalyzableFiles"),
+      "Command codeMetrics.listAnalyzableFiles should be registered"
+    );
+  });
+
+  test("should register codeMetrics.analyzeSnippet command", async () => {
+    const commands = await vscode.commands.getCommands(true);
+
+    assert.ok(
+      commands.includes("codeMetrics.analyzeSnippet"),
+      "Command codeMetrics.analyzeSnippet should be registered"
+    );
+  });
+
+  test("should register codeMetrics.selectProfile command", async () => {
+    const commands = await vscode.commands.getCommands(true);
+
+    assert.ok(
+      commands.includes("codeMetrics.selectProfile"),
+      "Command codeMetrics.selectProfile should be registered"
+    );
+  });
+
+  test("should register codeMetrics.analyzeFolder command", async () => {
+    const commands = await vscode.commands.getCommands(true);
+
+    assert.ok(
+      commands.includes("codeMetrics.analyzeFolder"),
+      "Command codeMetrics.analyzeFolder should be registered"
+    );
+  });
+
+  test("should register codeMetrics.compareWithBaseline command", async () => {
+    const commands = await vscode.commands.getCommands(true);
+
+    assert.ok(
+      commands.includes("codeMetrics.compareWithBaseline"),
+      "Command codeMetrics.compareWithBaseline should be registered"
+    );
+  });
+
+  test("should register codeMetrics.annotateComplexity command", async () => {
+    const commands = await vscode.commands.getCommands(true);
+
+    assert.ok(
+      commands.includes("codeMetrics.annotateComplexity"),
+      "Command codeMetrics.annotateComplexity should be registered"
+    );
+  });
+
+  test("should register codeMetrics.stripComplexityAnnotations command", async () => {
+    const commands = await vscode.commands.getCommands(true);
+
+    assert.ok(
+      commands.includes("codeMetrics.stripComplexityAnnotations"),
+      "Command codeMetrics.stripComplexityAnnotations should be registered"
+    );
+  });
+
+  test("should register codeMetrics.analyzeNotebook command", async () => {
+    const commands = await vscode.commands.getCommands(true);
+
+    assert.ok(
+      commands.includes("codeMetrics.analyzeNotebook"),
+      "Command codeMetrics.analyzeNotebook should be registered"
+    );
+  });
+
+  test("should register codeMetrics.showComplexityDistribution command", async () => {
+    const commands = await vscode.commands.getCommands(true);
+
+    assert.ok(
+      commands.includes("codeMetrics.showComplexityDistribution"),
+      "Command codeMetrics.showComplexityDistribution should be registered"
+    );
+  });
+
+  test("should register codeMetrics.goToWorstFunction command", async () => {
+    const commands = await vscode.commands.getCommands(true);
+
+    assert.ok(
+      commands.includes("codeMetrics.goToWorstFunction"),
+      "Command codeMetrics.goToWorstFunction should be registered"
+    );
+  });
+
+  test("should register codeMetrics.analyzeCurrentFile command", async () => {
+    const commands = await vscode.commands.getCommands(true);
+
+    assert.ok(
+      commands.includes("codeMetrics.analyzeCurrentFile"),
+      "Command codeMetrics.analyzeCurrentFile should be registered"
+    );
+  });
+
+  test("should register codeMetrics.exportMetrics command", async () => {
+    const commands = await vscode.commands.getCommands(true);
+
+    assert.ok(
+      commands.includes("codeMetrics.exportMetrics"),
+      "Command codeMetrics.exportMetrics should be registered"
+    );
+  });
+
+  test("should register codeMetrics.explainCurrentFunction command", async () => {
+    const commands = await vscode.commands.getCommands(true);
+
+    assert.ok(
+      commands.includes("codeMetrics.explainCurrentFunction"),
+      "Command codeMetrics.explainCurrentFunction should be registered"
+    );
+  });
+
   test("should execute cognitiveComplexity.showFunctionDetails command without errors", async () => {
     // This should not throw an error
     try {
@@ -141,6 +270,52 @@ suite("Extension Activation Tests", () => {
     }
   });
 
+  test("should expose an API for registering custom analyzers", async () => {
+    const extension = vscode.extensions.getExtension<CodeMetricsApi>("dev-asilva.code-metrics");
+    const api = await extension?.activate();
+    assert.ok(api, "activate() should return the extension API");
+
+    const registration = api.registerAnalyzer("toylang-api", { analyzeFile: () => [] });
+    const languages = MetricsAnalyzerFactory.getSupportedLanguages();
+    registration.dispose();
+
+    assert.ok(languages.includes("toylang-api"));
+    assert.ok(!MetricsAnalyzerFactory.isSupportedLanguage("toylang-api"));
+  });
+
+  test("should look up a single function's metrics through the API", async () => {
+    const extension = vscode.extensions.getExtension<CodeMetricsApi>("dev-asilva.code-metrics");
+    const api = await extension?.activate();
+    assert.ok(api, "activate() should return the extension API");
+    const uri = vscode.Uri.file(path.resolve(__dirname, "../../samples/Test.go"));
+
+    const max = await api.getFunctionMetrics(uri, "Max");
+    assert.ok(max, "Max should be found in the Go sample");
+    assert.strictEqual(max.name, "Max");
+    assert.strictEqual(max.startLine, 27);
+    assert.strictEqual(max.complexity, 1);
+
+    assert.strictEqual(await api.getFunctionMetrics(uri, "Missing"), null);
+    assert.strictEqual(
+      await api.getFunctionMetrics(vscode.Uri.file(path.resolve(__dirname, "../../samples/missing.go")), "Max"),
+      null
+    );
+  });
+
+  test("should analyze source text without a document through the API", async () => {
+    const extension = vscode.extensions.getExtension<CodeMetricsApi>("dev-asilva.code-metrics");
+    const api = await extension?.activate();
+    assert.ok(api, "activate() should return the extension API");
+
+    const analysis = api.analyzeSource("package main\n\nfunc Check(a bool) {\n\tif a {\n\t}\n}\n", "go");
+    assert.strictEqual(analysis.error, undefined);
+    assert.deepStrictEqual(analysis.functions.map((f) => f.name), ["Check"]);
+
+    const unsupported = api.analyzeSource("whatever", "toylang-missing");
+    assert.deepStrictEqual(unsupported.functions, []);
+    assert.ok(unsupported.error);
+  });
+
   test("should deactivate extension without errors", () => {
     // Directly invoke deactivate to cover the disposal path
     assert.doesNotThrow(() => {
@@ -0,0 +1,280 @@
+import * as assert from "assert";
+import { DartMetricsAnalyzer } from "../../../metricsAnalyzer/languages/dartAnalyzer";
+
+suite("Dart Metrics Analyzer Tests", () => {
+  let analyzer: DartMetricsAnalyzer;
+
+  setup(() => {
+    analyzer = new DartMetricsAnalyzer();
+  });
+
+  suite("Functions", () => {
+    test("should report functions, members, and constructors with their range", () => {
+      const sourceCode = `void main() {
+  runApp(const App());
+}
+
+class Point {
+  final int x;
+  final int y;
+
+  Point(this.x, this.y) : assert(x >= 0);
+  const Point.zero() : this(0, 0);
+  factory Point.parse(String s) {
+    return Point(int.parse(s), 0);
+  }
+
+  int get sum => x + y;
+  set both(int v) {}
+  bool operator ==(Object other) => other is Point && other.x == x;
+}
+
+extension on String {
+  bool get blank => trim().isEmpty;
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(
+        results.map((r) => [r.name, r.startLine, r.endLine]),
+        [
+          ["main", 0, 2],
+          ["Point", 8, 8],
+          ["Point.zero", 9, 9],
+          ["Point.parse", 10, 12],
+          ["Point.sum", 14, 14],
+          ["Point.both=", 15, 15],
+          ["Point.operator ==", 16, 16],
+          ["String.blank", 20, 20],
+        ]
+      );
+      assert.deepStrictEqual(results[1].details.map((d) => d.reason), ["assert statement"]);
+      assert.strictEqual(results[6].complexity, 1);
+    });
+
+    test("should give closures and local functions their own scope", () => {
+      const sourceCode = `Widget build(BuildContext context) {
+  final items = values.where((v) => v > 0 && v < 10).toList();
+  items.forEach((v) {
+    if (v > 5) {
+      print(v);
+    }
+  });
+  int helper(int x) {
+    return x > 0 ? x : -x;
+  }
+  return ListView(children: items.map(Text.new).toList());
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(
+        results.map((r) => [r.name, r.complexity, r.startLine, r.endLine]),
+        [
+          ["build", 0, 0, 11],
+          ["build.<closure 1>", 1, 1, 1],
+          ["build.<closure 2>", 1, 2, 6],
+          ["build.helper", 1, 7, 9],
+        ]
+      );
+      // Nesting starts again at 0 inside a closure.
+      assert.strictEqual(results[2].details[0].nesting, 0);
+    });
+  });
+
+  suite("Control Flow", () => {
+    test("should add nesting to if and loops, and a flat +1 to else", () => {
+      const sourceCode = `int classify(int? n) {
+  if (n == null || n < 0) {
+    return -1;
+  } else if (n == 0) {
+    return 0;
+  } else {
+    for (var i = 0; i < n; i++) {
+      while (i > 2) i--;
+    }
+  }
+  do {
+    n--;
+  } while (n > 0);
+  return n;
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      // if(1) + ||(1) + else if(1) + else(1) + for(2) + while(3) + do(1)
+      assert.strictEqual(results[0].complexity, 10);
+      assert.deepStrictEqual(
+        results[0].details.map((d) => d.reason),
+        [
+          "if statement",
+          "logical || operator",
+          "else if clause",
+          "else clause",
+          "for loop",
+          "while loop",
+          "do...while loop",
+        ]
+      );
+    });
+
+    test("should nest the bodies of statements without braces", () => {
+      const sourceCode = `void visit(List<String> keys) {
+  for (final k in keys)
+    if (k.isEmpty) print('empty');
+    else if (k.length > 3) print(k);
+    else print('short');
+  if (keys.isEmpty) return;
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(
+        results[0].details.map((d) => [d.reason, d.increment]),
+        [
+          ["for loop", 1],
+          ["if statement", 2],
+          ["else if clause", 1],
+          ["else clause", 1],
+          ["if statement", 1],
+        ]
+      );
+    });
+
+    test("should count collection if and for elements", () => {
+      const sourceCode = `Widget build(BuildContext context) {
+  return Column(children: [
+    if (loading) const Spinner() else Text(title),
+    for (final item in items) ListTile(title: Text(item)),
+  ]);
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(
+        results[0].details.map((d) => d.reason),
+        ["if statement", "else clause", "for loop"]
+      );
+    });
+
+    test("should count each switch case and switch expression arm", () => {
+      const sourceCode = `void handle(int v) {
+  switch (v) {
+    case 1:
+      print('one');
+    case 2:
+    case 3:
+      if (v > 2) print('three');
+    default:
+      print('other');
+  }
+}
+
+String label(Shape shape) => switch (shape) {
+      Circle(:var radius) => 'circle $radius',
+      Square() when shape.side > 1 => 'square',
+      _ => 'unknown',
+    };
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      // three cases + if nested in the switch (2)
+      assert.strictEqual(results[0].complexity, 5);
+      assert.strictEqual(results[1].name, "label");
+      assert.deepStrictEqual(
+        results[1].details.map((d) => d.reason),
+        ["switch expression case", "switch expression case"]
+      );
+    });
+
+    test("should count catch and on clauses once each", () => {
+      const sourceCode = `Future<void> load() async {
+  try {
+    await fetch();
+  } on FormatException catch (e) {
+    print(e);
+  } on TimeoutException {
+    retry();
+  } catch (e) {
+    rethrow;
+  } finally {
+    close();
+  }
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results[0].complexity, 3);
+      assert.ok(results[0].details.every((d) => d.reason === "catch clause"));
+    });
+  });
+
+  suite("Operators", () => {
+    test("should count ternaries and null-aware operators, but not nullable types", () => {
+      const sourceCode = `String? describe(Map<String, List<int?>> m, String? key) {
+  String? value = key != null ? key : null;
+  value ??= 'x';
+  final first = m[key]?.first ?? m['default']?[0];
+  final all = [...?m[key]];
+  return value;
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(
+        results[0].details.map((d) => d.reason),
+        [
+          "ternary expression",
+          "null-aware ??= operator",
+          "null-aware ?. operator",
+          "logical ?? operator",
+          "null-aware ?[ operator",
+          "null-aware ...? operator",
+        ]
+      );
+    });
+
+    test("should count each sequence of the same logical operator once", () => {
+      const sourceCode = `bool valid(int a, int b, int? c) =>
+    a > 0 && b > 0 && a != b || c != null && (c > a || c > b);
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(
+        results[0].details.map((d) => d.reason),
+        ["logical && operator", "logical || operator", "logical && operator", "logical || operator"]
+      );
+    });
+  });
+
+  suite("Tokenizing", () => {
+    test("should ignore keywords in comments and strings", () => {
+      const sourceCode = `/* if (a) { /* nested */ while (b) {} } */
+String describe(int x) {
+  // if (x > 0)
+  final raw = r'if (x) $x';
+  final text = '''
+    for (final y in ys) y ?? 0
+  ''';
+  return "case \${x > 0 ? 'a' : "b"} if";
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results.length, 1);
+      assert.strictEqual(results[0].name, "describe");
+      assert.strictEqual(results[0].complexity, 0);
+      assert.strictEqual(results[0].endLine, 8);
+    });
+  });
+});
@@ -0,0 +1,209 @@
+import * as assert from "assert";
+import { ElixirMetricsAnalyzer } from "../../../metricsAnalyzer/languages/elixirAnalyzer";
+
+suite("Elixir Metrics Analyzer Tests", () => {
+  let analyzer: ElixirMetricsAnalyzer;
+
+  setup(() => {
+    analyzer = new ElixirMetricsAnalyzer();
+  });
+
+  suite("Functions", () => {
+    test("should report functions as Module.name/arity with their range", () => {
+      const sourceCode = `defmodule MyApp.Accounts do
+  def list_users, do: Repo.all(User)
+
+  defp normalize(email, opts \\\\ []) do
+    String.downcase(email)
+  end
+end
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(
+        results.map((r) => [r.name, r.startLine, r.endLine, r.parameterCount]),
+        [
+          ["MyApp.Accounts.list_users/0", 1, 1, 0],
+          ["MyApp.Accounts.normalize/2", 3, 5, 2],
+        ]
+      );
+      assert.ok(results.every((r) => r.complexity === 0));
+    });
+
+    test("should aggregate function clauses of the same name and arity", () => {
+      const sourceCode = `defmodule Size do
+  def classify(0), do: :zero
+  def classify(n) when n > 0 and n < 10, do: :small
+  def classify(_n), do: :large
+
+  def classify(n, unit), do: {n, unit}
+end
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results.length, 2);
+      const classify = results[0];
+      assert.strictEqual(classify.name, "Size.classify/1");
+      assert.strictEqual(classify.clauseCount, 3);
+      assert.strictEqual(classify.startLine, 1);
+      assert.strictEqual(classify.endLine, 3);
+      // two extra clauses + guard + and
+      assert.strictEqual(classify.complexity, 4);
+      assert.deepStrictEqual(
+        classify.details.map((d) => d.reason),
+        ["function clause 2", "guard", "logical operator and", "function clause 3"]
+      );
+      assert.strictEqual(results[1].name, "Size.classify/2");
+      assert.strictEqual(results[1].clauseCount, 1);
+    });
+  });
+
+  suite("Control Flow", () => {
+    test("should add nesting to if, unless, and for, and a flat +1 to else", () => {
+      const sourceCode = `defmodule Roles do
+  defp check(user, opts) do
+    if user.admin? && opts[:strict] do
+      for role <- user.roles do
+        unless role.valid, do: raise("bad role")
+      end
+    else
+      :ok
+    end
+  end
+end
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results.length, 1);
+      // if(1) + &&(1) + for(2) + unless(3) + else(1)
+      assert.strictEqual(results[0].complexity, 8);
+      assert.deepStrictEqual(
+        results[0].details.map((d) => d.reason),
+        [
+          "if (nesting: 0)",
+          "logical operator &&",
+          "for (nesting: 1)",
+          "unless (nesting: 2)",
+          "else",
+        ]
+      );
+    });
+
+    test("should count each case, cond, and receive clause", () => {
+      const sourceCode = `defmodule Worker do
+  def get_user(id) when is_integer(id) do
+    case Repo.get(User, id) do
+      nil -> {:error, :not_found}
+      %User{active: true} = user -> {:ok, user}
+      _ -> {:error, :inactive}
+    end
+  end
+
+  def loop do
+    receive do
+      {:msg, m} ->
+        cond do
+          m > 1 -> :a
+          true -> :b
+        end
+    after
+      1000 -> :timeout
+    end
+  end
+end
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      // guard + three case clauses
+      assert.strictEqual(results[0].complexity, 4);
+      // two receive clauses (including after) + two cond clauses
+      assert.strictEqual(results[1].complexity, 4);
+    });
+
+    test("should count with clauses and the clauses of its else", () => {
+      const sourceCode = `defmodule Signup do
+  def create(attrs) do
+    with {:ok, a} <- validate(attrs),
+         {:ok, b} <- insert(a) do
+      {:ok, b}
+    else
+      {:error, _} = err -> err
+      _ -> :unknown
+    end
+  end
+end
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results[0].complexity, 4);
+      assert.ok(results[0].details.every((d) => d.reason === "with clause" && d.increment === 1));
+    });
+
+    test("should count fn clauses only for multi-clause anonymous functions", () => {
+      const sourceCode = `defmodule Handlers do
+  def handler do
+    fn
+      {:ok, x} -> x
+      :error -> nil
+    end
+  end
+
+  def double(list) do
+    list
+    |> Enum.map(fn x -> x * 2 end)
+    |> Enum.filter(&(&1 > 2 || &1 < 0))
+  end
+end
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results[0].complexity, 2);
+      assert.deepStrictEqual(
+        results[1].details.map((d) => d.reason),
+        ["logical operator ||"]
+      );
+    });
+  });
+
+  suite("Tokenizing", () => {
+    test("should ignore keywords in comments, strings, heredocs, sigils, and atoms", () => {
+      const sourceCode = `defmodule Docs do
+  @moduledoc """
+  if case cond do end
+  """
+
+  # if x do
+  def describe(x) do
+    label = ~s(if #{x} or not)
+    msg = "case #{if x, do: "a", else: "b"} end"
+    {:if, :case, label, msg, if: 1}
+  end
+end
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results.length, 1);
+      assert.strictEqual(results[0].name, "Docs.describe/1");
+      assert.strictEqual(results[0].complexity, 0);
+      assert.strictEqual(results[0].endLine, 10);
+    });
+
+    test("should name implementations after the protocol and the implementing module", () => {
+      const sourceCode = `defimpl String.Chars, for: MyApp.User do
+  def to_string(user), do: user.name
+end
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(results.map((r) => r.name), ["String.Chars.MyApp.User.to_string/1"]);
+    });
+  });
+});
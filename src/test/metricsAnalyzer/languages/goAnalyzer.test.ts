@@ -1,5 +1,9 @@
 import * as assert from "assert";
+import * as fs from "fs";
+import * as path from "path";
 import { GoMetricsAnalyzer } from "../../../metricsAnalyzer/languages/goAnalyzer";
+import { countFileLogicalLines } from "../../../metricsAnalyzer/linesOfCode";
+import { MetricsAnalyzerFactory } from "../../../metricsAnalyzer/metricsAnalyzerFactory";
 
 suite("Go Metrics Analyzer Tests", () => {
   let analyzer: GoMetricsAnalyzer;
@@ -364,6 +368,46 @@ func RecoverExample() (err error) {
     });
   });
 
+  suite("Panic Mode", () => {
+    const fixture = fs.readFileSync(path.resolve(__dirname, "../../../../samples/Test.go"), "utf-8");
+    const analyzeSafeOperation = (panicMode?: "statement" | "exit" | "branch") =>
+      new GoMetricsAnalyzer({ panicMode })
+        .analyzeFunctions(fixture)
+        .find((r) => r.name === "SafeOperation")!;
+
+    test("should treat panic as a plain statement by default", () => {
+      const result = analyzeSafeOperation();
+
+      assert.deepStrictEqual(analyzeSafeOperation("statement"), result);
+      assert.strictEqual(result.returnCount, 0);
+      assert.ok(!result.details.some((d) => d.reason === "panic call"));
+    });
+
+    test("should count panic as an exit point without adding complexity", () => {
+      const statement = analyzeSafeOperation("statement");
+      const result = analyzeSafeOperation("exit");
+
+      assert.strictEqual(result.complexity, statement.complexity);
+      assert.strictEqual(result.returnCount, 1);
+    });
+
+    test("should count panic as a branch", () => {
+      const statement = analyzeSafeOperation("statement");
+      const result = analyzeSafeOperation("branch");
+
+      // panic(1) at nesting 0, after the deferred closure
+      assert.strictEqual(result.complexity, statement.complexity + 1);
+      assert.strictEqual(result.returnCount, 0);
+      assert.deepStrictEqual(result.details[result.details.length - 1], {
+        increment: 1,
+        reason: "panic call",
+        line: 146,
+        column: 1,
+        nesting: 0,
+      });
+    });
+  });
+
   suite("Function Literals (Closures)", () => {
     test("should handle closure at top level (no extra complexity)", () => {
       const sourceCode = `
@@ -426,6 +470,276 @@ func DeeplyNestedClosure() {
     });
   });
 
+  suite("Closure Modes", () => {
+    const genericSource = `
+package main
+
+func Filter[T any](items []T, keep func(T) bool) []T {
+    result := make([]T, 0, len(items))
+    for _, item := range items {
+        if keep(item) {
+            result = append(result, item)
+        }
+    }
+    return result
+}
+
+func PositiveEvens(numbers []int) []int {
+    return Filter(numbers, func(n int) bool {
+        if n <= 0 {
+            return false
+        } else if n%2 != 0 {
+            return false
+        }
+        return n < 1000 || n%10 == 0
+    })
+}
+`;
+
+    test("should merge closures into the enclosing function by default", () => {
+      const results = analyzer.analyzeFunctions(genericSource);
+
+      assert.deepStrictEqual(
+        results.map((r) => r.name),
+        ["Filter", "PositiveEvens"]
+      );
+      // if(1+1 nesting) + else if(1) + ||(1) = 4
+      assert.strictEqual(results[1].complexity, 4);
+    });
+
+    test("should report a predicate passed to a generic function as its own entry", () => {
+      const results = new GoMetricsAnalyzer({ closureMode: "separate" })
+        .analyzeFunctions(genericSource);
+
+      assert.deepStrictEqual(
+        results.map((r) => r.name),
+        ["Filter", "PositiveEvens", "PositiveEvens.func1"]
+      );
+      // Generic function itself: for(1) + if(2) = 3
+      assert.strictEqual(results[0].complexity, 3);
+      // The predicate is excluded from its caller
+      assert.strictEqual(results[1].complexity, 0);
+
+      const predicate = results[2];
+      // if(1) + else if(1) + ||(1) = 3, measured from nesting level 0
+      assert.strictEqual(predicate.complexity, 3);
+      assert.strictEqual(predicate.startLine, 14);
+      assert.strictEqual(predicate.endLine, 21);
+      assert.ok(predicate.details.every((d) => d.line >= 14 && d.line <= 21));
+    });
+
+    test("should keep the closure in its parent and report it in both mode", () => {
+      const results = new GoMetricsAnalyzer({ closureMode: "both" })
+        .analyzeFunctions(genericSource);
+
+      const parent = results.find((r) => r.name === "PositiveEvens");
+      const predicate = results.find((r) => r.name === "PositiveEvens.func1");
+      assert.strictEqual(parent?.complexity, 4);
+      assert.strictEqual(predicate?.complexity, 3);
+    });
+
+    test("should name sibling and nested closures like the Go runtime", () => {
+      const sourceCode = `
+package main
+
+func Pipeline(items []int) {
+    double := func(x int) int { return x * 2 }
+    each := func(f func(int)) {
+        for _, item := range items {
+            f(item)
+        }
+        sink := func(v int) {
+            if v > 0 {
+                println(v)
+            }
+        }
+        sink(0)
+    }
+    each(func(v int) { println(double(v)) })
+}
+`;
+
+      const results = new GoMetricsAnalyzer({ closureMode: "both" })
+        .analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(
+        results.map((r) => r.name),
+        ["Pipeline", "Pipeline.func1", "Pipeline.func2", "Pipeline.func2.1", "Pipeline.func3"]
+      );
+      const nested = results.find((r) => r.name === "Pipeline.func2.1");
+      // if(1) at nesting 0 within its own entry
+      assert.strictEqual(nested?.complexity, 1);
+    });
+
+    test("should accept closure mode through the static analyzeFile helper", () => {
+      const results = GoMetricsAnalyzer.analyzeFile(genericSource, { closureMode: "separate" });
+
+      assert.strictEqual(results.length, 3);
+    });
+  });
+
+  suite("Callback Closures", () => {
+    const fixture = fs.readFileSync(
+      path.resolve(__dirname, "../../../../samples/callbacks/callbacks.go"),
+      "utf-8"
+    );
+    const complexityOf = (results: { name: string; complexity: number }[]) =>
+      Object.fromEntries(results.map((r) => [r.name, r.complexity]));
+
+    test("should count standard-library callbacks toward their caller by default", () => {
+      assert.deepStrictEqual(complexityOf(analyzer.analyzeFunctions(fixture)), {
+        // if(1+1 nesting)
+        SortPeople: 2,
+        // for(1) + comparator nested in the loop(1+1)
+        SortGroups: 3,
+        // if(2) + &&(1) + if(2) + ||(1) + &&(1) + &&(1)
+        Normalize: 8,
+        // if(2) + if(2) + &&(1) + if(2)
+        ListGoFiles: 7,
+        // for(2) + if(3)
+        "Registry.Entries": 5,
+      });
+    });
+
+    test("should report each callback as its own entry in separate mode", () => {
+      const results = new GoMetricsAnalyzer({ closureMode: "separate" })
+        .analyzeFunctions(fixture);
+
+      assert.deepStrictEqual(complexityOf(results), {
+        SortPeople: 0,
+        "SortPeople.func1": 1,
+        SortGroups: 1,
+        "SortGroups.func1": 0,
+        Normalize: 0,
+        "Normalize.func1": 6,
+        ListGoFiles: 0,
+        "ListGoFiles.func1": 4,
+        "Registry.Entries": 0,
+        "Registry.Entries.func1": 3,
+      });
+      const comparator = results.find((r) => r.name === "SortPeople.func1");
+      assert.strictEqual(comparator?.startLine, 19);
+      assert.strictEqual(comparator?.endLine, 24);
+    });
+
+    test("should keep callbacks in their caller and report them in both mode", () => {
+      const results = complexityOf(
+        new GoMetricsAnalyzer({ closureMode: "both" }).analyzeFunctions(fixture)
+      );
+
+      assert.strictEqual(results["SortGroups"], 3);
+      assert.strictEqual(results["SortGroups.func1"], 0);
+      assert.strictEqual(results["ListGoFiles"], 7);
+      assert.strictEqual(results["ListGoFiles.func1"], 4);
+    });
+  });
+
+  suite("Func Literal Fields", () => {
+    const fixture = fs.readFileSync(
+      path.resolve(__dirname, "../../../../samples/funcfields/funcfields.go"),
+      "utf-8"
+    );
+    const summarize = (results: { name: string; complexity: number; startLine: number; endLine: number }[]) =>
+      results.map((r) => [r.name, r.complexity, r.startLine, r.endLine]);
+
+    test("should report struct field and map entry funcs as their own entries by default", () => {
+      assert.deepStrictEqual(summarize(analyzer.analyzeFunctions(fixture)), [
+        ["init.func1", 0, 18, 20],
+        // if(1) + &&(1)
+        ["init.func2", 2, 24, 30],
+        // if(1) + GetFn's if(2) + DeleteFn's for(2) and if(3)
+        ["NewFakeStore", 8, 46, 66],
+        ["NewFakeStore.func1", 1, 51, 56],
+        // for(1) + if(2)
+        ["NewFakeStore.func2", 3, 57, 64],
+        ["init.func3", 1, 70, 75],
+      ]);
+    });
+
+    test("should leave field funcs out of their function when standalone", () => {
+      const results = new GoMetricsAnalyzer({ literalFuncMode: "standalone" }).analyzeFunctions(fixture);
+
+      assert.deepStrictEqual(summarize(results), [
+        ["init.func1", 0, 18, 20],
+        ["init.func2", 2, 24, 30],
+        // if(1); the field funcs are not merged into the constructor
+        ["NewFakeStore", 1, 46, 66],
+        ["NewFakeStore.func1", 1, 51, 56],
+        // for(1) + if(2)
+        ["NewFakeStore.func2", 3, 57, 64],
+        ["init.func3", 1, 70, 75],
+      ]);
+    });
+
+    test("should also merge field funcs into their function in both mode", () => {
+      const results = new GoMetricsAnalyzer({ closureMode: "both" }).analyzeFunctions(fixture);
+      const complexityOf = Object.fromEntries(results.map((r) => [r.name, r.complexity]));
+
+      // if(1) + GetFn's if(2) + DeleteFn's for(2) and if(3)
+      assert.strictEqual(complexityOf["NewFakeStore"], 8);
+      assert.strictEqual(complexityOf["NewFakeStore.func2"], 3);
+      assert.strictEqual(complexityOf["init.func2"], 2);
+    });
+
+    test("should keep merging func literals that are not field values", () => {
+      const sourceCode = `
+package main
+
+func Run(items []int) {
+	apply := func(x int) int {
+		if x > 0 {
+			return x
+		}
+		return 0
+	}
+	_ = apply
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(results.map((r) => [r.name, r.complexity]), [["Run", 2]]);
+    });
+  });
+
+  suite("Dispatch Tables", () => {
+    const fixture = fs.readFileSync(
+      path.resolve(__dirname, "../../../../samples/dispatch/dispatch.go"),
+      "utf-8"
+    );
+
+    test("should report each map and slice element func as its own entry when standalone", () => {
+      const results = new GoMetricsAnalyzer({ literalFuncMode: "standalone" }).analyzeFunctions(fixture);
+
+      assert.deepStrictEqual(
+        results.map((r) => [r.name, r.complexity, r.startLine, r.endLine]),
+        [
+          // if(1); the handlers are not merged into the function building the table
+          ["NewRouter", 1, 15, 38],
+          ["NewRouter.func1", 1, 20, 25],
+          // for(1) + if(2)
+          ["NewRouter.func2", 3, 26, 33],
+          ["NewRouter.func3", 0, 34, 36],
+          ["Middleware", 0, 41, 50],
+          ["Middleware.func1", 1, 43, 48],
+        ]
+      );
+    });
+
+    test("should also attribute the handlers to their function by default", () => {
+      const results = analyzer.analyzeFunctions(fixture);
+      const complexityOf = Object.fromEntries(results.map((r) => [r.name, r.complexity]));
+
+      // if(1) + add's if(2) + list's for(2) and if(3)
+      assert.strictEqual(complexityOf["NewRouter"], 8);
+      assert.strictEqual(complexityOf["Middleware"], 2);
+      // The handlers' own entries are unchanged.
+      assert.strictEqual(complexityOf["NewRouter.func2"], 3);
+      assert.strictEqual(complexityOf["Middleware.func1"], 1);
+      assert.strictEqual(results.length, 6);
+    });
+  });
+
   suite("Jump Statements", () => {
     test("should handle goto statements", () => {
       const sourceCode = `
@@ -604,234 +918,1271 @@ func (m Math) Max(a, b int) int {
     });
   });
 
-  suite("Goroutines", () => {
-    test("should not add complexity for go statement itself", () => {
+  suite("Receiver Field Access", () => {
+    test("should count distinct receiver fields accessed by a method", () => {
       const sourceCode = `
 package main
 
-func SpawnGoroutine() {
-    go func() {
-        // concurrent work
-    }()
+type Service struct {
+    name  string
+    count int
+    cache map[string]int
+}
+
+func (s *Service) Handle(key string) int {
+    s.count++
+    if v, ok := s.cache[key]; ok {
+        return v + s.count
+    }
+    s.cache[key] = len(s.name)
+    return s.cache[key]
 }
 `;
 
       const results = analyzer.analyzeFunctions(sourceCode);
 
-      // go statement doesn't add complexity, closure at top level = 0
-      assert.strictEqual(results[0].complexity, 0);
+      assert.strictEqual(results.length, 1);
+      assert.strictEqual(results[0].fieldAccessCount, 3);
     });
 
-    test("should add complexity for nested goroutine with closure", () => {
+    test("should not count method calls on the receiver as field accesses", () => {
       const sourceCode = `
 package main
 
-func NestedGoroutine() {
-    if true {
-        go func() {
-            // concurrent work
-        }()
-    }
+type Service struct {
+    count int
+}
+
+func (s *Service) Tick() {
+    s.reset()
+    s.count++
 }
 `;
 
       const results = analyzer.analyzeFunctions(sourceCode);
 
-      // if(1) + nested closure(2) = 3
-      assert.strictEqual(results[0].complexity, 3);
+      assert.strictEqual(results[0].fieldAccessCount, 1);
     });
-  });
 
-  suite("Edge Cases", () => {
-    test("should handle empty function", () => {
+    test("should leave field access undefined for plain functions and blank receivers", () => {
       const sourceCode = `
 package main
 
-func Empty() {
+type Service struct{}
+
+func Helper(s *Service) int {
+    return s.count
+}
+
+func (_ Service) Name() string {
+    return "service"
 }
 `;
 
       const results = analyzer.analyzeFunctions(sourceCode);
 
-      assert.strictEqual(results.length, 1);
-      assert.strictEqual(results[0].name, "Empty");
-      assert.strictEqual(results[0].complexity, 0);
+      assert.strictEqual(results.length, 2);
+      assert.strictEqual(results[0].fieldAccessCount, undefined);
+      assert.strictEqual(results[1].fieldAccessCount, undefined);
     });
+  });
 
-    test("should handle function with only return", () => {
+  suite("Method Candidates", () => {
+    test("should count distinct fields written through a pointer first parameter", () => {
       const sourceCode = `
 package main
 
-func ReturnOnly() int {
-    return 42
+type Config struct {
+    Host    string
+    Port    int
+    Retries int
+    TLS     struct{ Enabled bool }
 }
-`;
 
-      const results = analyzer.analyzeFunctions(sourceCode);
+func Apply(cfg *Config, host string) {
+    cfg.Host = host
+    cfg.Port = 443
+    cfg.TLS.Enabled = true
+    cfg.Retries++
+    cfg.Port = cfg.Port + 1
+}
 
-      assert.strictEqual(results.length, 1);
-      assert.strictEqual(results[0].complexity, 0);
-    });
+func Describe(cfg *Config) string {
+    return cfg.Host
+}
 
-    test("should handle empty file", () => {
-      const sourceCode = `package main
+func Reset(cfg *http.Request, c Config) {
+    cfg.Host = ""
+    c.Port = 0
+}
 `;
 
       const results = analyzer.analyzeFunctions(sourceCode);
 
-      assert.strictEqual(results.length, 0);
+      assert.deepStrictEqual(results[0].pointerParamWrites, {
+        parameter: "cfg",
+        type: "Config",
+        fieldCount: 4,
+      });
+      assert.strictEqual(results[1].pointerParamWrites, undefined);
+      assert.strictEqual(results[2].pointerParamWrites, undefined);
     });
 
-    test("should handle file with only imports", () => {
+    test("should flag methods that never reference their receiver", () => {
       const sourceCode = `
 package main
 
-import "fmt"
+type Server struct {
+    addr string
+}
+
+func (s *Server) normalize(path string) string {
+    return strings.TrimSuffix(path, "/")
+}
+
+func (s *Server) Addr() string {
+    return s.addr
+}
+
+func (*Server) Close() {}
+
+func (s *Server) later() func() string {
+    return func() string { return s.addr }
+}
 `;
 
       const results = analyzer.analyzeFunctions(sourceCode);
+      const byName = new Map(results.map((r) => [r.name, r]));
 
-      assert.strictEqual(results.length, 0);
+      assert.strictEqual(byName.get("Server.normalize")?.receiverUnused, true);
+      assert.strictEqual(byName.get("Server.Addr")?.receiverUnused, false);
+      assert.strictEqual(byName.get("Server.Close")?.receiverUnused, undefined);
+      assert.strictEqual(byName.get("Server.later")?.receiverUnused, false);
     });
+  });
 
-    test("should handle complex real-world function", () => {
+  suite("Complexity Expectations", () => {
+    test("should parse expectation directives from doc comments", () => {
       const sourceCode = `
 package main
 
-func ProcessData(items []int, includeNegatives bool) []int {
-    result := make([]int, 0)
+// Bounded is a critical path.
+//metrics:expect cc<=8
+func Bounded(x int) int {
+    return x
+}
 
-    for _, item := range items {
-        if item > 0 {
-            result = append(result, item)
-        } else if includeNegatives && item < 0 {
-            result = append(result, -item)
-        } else {
-            continue
-        }
-    }
+//metrics:expect cc<3
+func Strict(x int) int {
+    return x
+}
 
-    if len(result) > 10 {
-        return result[:10]
-    } else if len(result) == 0 {
-        return nil
-    }
+// metrics:expect cc=2
+func Exact(x int) int {
+    return x
+}
 
-    return result
+//metrics:expect cc==4
+func DoubleEquals(x int) int {
+    return x
 }
 `;
 
       const results = analyzer.analyzeFunctions(sourceCode);
 
-      assert.strictEqual(results.length, 1);
-      // for(1) + if(2) + else if(1) + &&(3) + else(1) + continue(3) + if(1) + else if(1) = 13
-      assert.strictEqual(results[0].complexity, 13);
+      assert.strictEqual(results.length, 4);
+      assert.deepStrictEqual(results[0].expectedComplexity, { operator: "<=", value: 8 });
+      assert.deepStrictEqual(results[1].expectedComplexity, { operator: "<", value: 3 });
+      assert.deepStrictEqual(results[2].expectedComplexity, { operator: "==", value: 2 });
+      assert.deepStrictEqual(results[3].expectedComplexity, { operator: "==", value: 4 });
     });
-  });
-
-  suite("Position Information", () => {
-    test("should return correct line numbers", () => {
-      const sourceCode = `package main
 
-func First() {
-}
-
-func Second() {
-    if true {
-    }
-}`;
-
-      const results = analyzer.analyzeFunctions(sourceCode);
+    test("should ignore directives separated from the function by a blank line", () => {
+      const sourceCode = `
+package main
 
-      assert.strictEqual(results.length, 2);
-      // First function starts at line 2 (0-based)
-      assert.strictEqual(results[0].startLine, 2);
-      // Second function starts at line 5 (0-based)
-      assert.strictEqual(results[1].startLine, 5);
-    });
+//metrics:expect cc<=1
 
-    test("should return correct complexity detail positions", () => {
-      const sourceCode = `package main
+func Detached(x int) int {
+    return x
+}
 
-func Test() {
-    if true {
-    }
-}`;
+func NoDoc(x int) int {
+    return x
+}
+`;
 
       const results = analyzer.analyzeFunctions(sourceCode);
 
-      assert.strictEqual(results.length, 1);
-      assert.strictEqual(results[0].details.length, 1);
-      // The if statement is on line 3 (0-based)
-      assert.strictEqual(results[0].details[0].line, 3);
+      assert.strictEqual(results[0].expectedComplexity, undefined);
+      assert.strictEqual(results[1].expectedComplexity, undefined);
     });
   });
 
-  suite("Static Factory Method", () => {
-    test("should analyze file using static method", () => {
+  suite("Disabled Regions", () => {
+    test("should leave out the decisions between disable and enable comments", () => {
       const sourceCode = `
 package main
 
-func Add(a, b int) int {
-    if a < 0 || b < 0 {
-        return 0
+func Reconcile(items []Item, strict bool) error {
+    for _, item := range items { // +1
+        if item.Stale { // +2
+            continue // +3
+        }
+        //metrics:disable legacy retry rules, tracked in #412
+        if item.Retries > 3 && !strict {
+            if item.Kind == "batch" {
+                return errRetry
+            } else if item.Kind == "stream" {
+                return errStream
+            }
+        }
+        //metrics:enable
+        if item.Err != nil { // +2
+            return item.Err
+        }
     }
-    return a + b
+    return nil
 }
 `;
 
-      const results = GoMetricsAnalyzer.analyzeFile(sourceCode);
+      const [result] = analyzer.analyzeFunctions(sourceCode);
 
-      assert.strictEqual(results.length, 1);
-      assert.strictEqual(results[0].name, "Add");
-      // if(1) + ||(2 nested in if) = 3
-      assert.strictEqual(results[0].complexity, 3);
+      assert.deepStrictEqual(
+        result.details.map((d) => [d.reason, d.line]),
+        [
+          ["for loop", 4],
+          ["if statement", 5],
+          ["continue statement (nested)", 6],
+          ["if statement", 17],
+        ]
+      );
+      assert.strictEqual(result.complexity, 8);
     });
-  });
 
-  suite("If-Else Chains", () => {
-    test("should handle if-else chains", () => {
+    test("should keep the nesting of disabled code for the code after it", () => {
       const sourceCode = `
 package main
 
-func IfElseChain(value int) string {
-    if value > 100 {
-        return "large"
-    } else if value > 50 {
-        return "medium"
-    } else if value > 0 {
-        return "small"
-    } else {
-        return "zero or negative"
+func Apply(ok bool, n int) int {
+    //metrics:disable
+    if ok {
+        //metrics:enable
+        if n > 0 { // +2, still nested in the disabled if
+            return n
+        } else { // +1
+            return -n
+        }
     }
+    return 0
 }
 `;
 
-      const results = analyzer.analyzeFunctions(sourceCode);
+      const [result] = analyzer.analyzeFunctions(sourceCode);
 
-      // Each if statement adds complexity
-      assert.ok(
-        results[0].complexity >= 3,
-        "Should count multiple if statements"
+      assert.deepStrictEqual(
+        result.details.map((d) => [d.reason, d.increment]),
+        [
+          ["if statement", 2],
+          ["else clause", 1],
+        ]
       );
     });
-  });
 
-  suite("Defer Statements", () => {
-    test("should not add complexity for simple defer", () => {
+    test("should end a disabled region with its function or func literal", () => {
       const sourceCode = `
 package main
 
-func DeferTest() {
-    defer close()
+func Unterminated(ok bool) {
+    //metrics:disable
+    if ok {
+        return
+    }
 }
 
-func close() {}
+func Next(ok bool) {
+    if ok { // +1
+        return
+    }
+    run(func() {
+        //metrics:disable
+        if ok {
+            return
+        }
+    })
+    if !ok { // +1
+        return
+    }
+}
 `;
 
       const results = analyzer.analyzeFunctions(sourceCode);
 
-      // defer itself doesn't add complexity
+      assert.strictEqual(results[0].complexity, 0);
+      assert.strictEqual(results[1].complexity, 2);
+    });
+
+    test("should end a disabled region with the block it is in", () => {
+      const sourceCode = `
+package main
+
+func Route(kind string, ok bool) int {
+    if ok { // +1
+        //metrics:disable
+        for kind != "" {
+            return 1
+        }
+    }
+    switch kind { // +1
+    case "a":
+        //metrics:disable
+        if ok {
+            return 2
+        }
+    case "b":
+        if !ok { // +2
+            return 3
+        }
+    }
+    for ok { // +1
+        return 4
+    }
+    return 0
+}
+`;
+
+      const [result] = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(
+        result.details.map((d) => [d.reason, d.line]),
+        [
+          ["if statement", 4],
+          ["switch statement", 10],
+          ["if statement", 17],
+          ["for loop", 21],
+        ]
+      );
+      assert.strictEqual(
+        new GoMetricsAnalyzer({ scoring: "cyclomatic" }).analyzeFunctions(sourceCode)[0].complexity,
+        // if + both cases + case "b"'s if + for; the disabled loop and if are not counted
+        5
+      );
+    });
+
+    test("should not treat similar comments as region directives", () => {
+      const sourceCode = `
+package main
+
+func Noted(ok bool) {
+    //metrics:disabled is not a directive
+    // see metrics:disable in the docs
+    if ok {
+        return
+    }
+}
+`;
+
+      const [result] = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(result.complexity, 1);
+    });
+  });
+
+  suite("Condition Operand Count", () => {
+    test("should count every operand of a mixed logical chain", () => {
+      const sourceCode = `
+package main
+
+func Chain(a, b, c, d bool) bool {
+    return a && b || c && d
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results[0].maxConditionOperands, 4);
+    });
+
+    test("should look through parentheses and negation", () => {
+      const sourceCode = `
+package main
+
+func Check(a, b, c int) {
+    if (a > 0 && b > 0) || !(c > 0 && a < b) {
+        return
+    }
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results[0].maxConditionOperands, 4);
+    });
+
+    test("should report the maximum across conditions", () => {
+      const sourceCode = `
+package main
+
+func Many(a, b, c bool) {
+    if a {
+        return
+    }
+    if a && b {
+        return
+    }
+    for a || b || c {
+        break
+    }
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results[0].maxConditionOperands, 3);
+    });
+
+    test("should measure conditions nested in call arguments separately", () => {
+      const sourceCode = `
+package main
+
+func Nested(a, b, c bool) bool {
+    return check(a && b) || c
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results[0].maxConditionOperands, 2);
+    });
+
+    test("should report one operand for plain conditions and zero without conditions", () => {
+      const sourceCode = `
+package main
+
+func Plain(a int) int {
+    if a > 0 {
+        return a
+    }
+    return 0
+}
+
+func Linear(a int) int {
+    return a + 1
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results[0].maxConditionOperands, 1);
+      assert.strictEqual(results[1].maxConditionOperands, 0);
+    });
+  });
+
+  suite("Switch Size", () => {
+    test("should count the clauses of switches, type switches, and selects, default included", () => {
+      const sourceCode = `
+package main
+
+func Dispatch(v interface{}, code int, a, b chan int) {
+    switch code {
+    case 1, 2:
+    case 3:
+    default:
+    }
+    switch v.(type) {
+    case int:
+    case string:
+    }
+    select {
+    case <-a:
+    case x := <-b:
+        _ = x
+    default:
+    }
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(results[0].switches, [
+        { kind: "switch", caseCount: 3, line: 4, column: 4 },
+        { kind: "type switch", caseCount: 2, line: 9, column: 4 },
+        { kind: "select", caseCount: 3, line: 13, column: 4 },
+      ]);
+    });
+
+    test("should leave switches in func literals to the literal", () => {
+      const sourceCode = `
+package main
+
+func Outer(code int) func() {
+    return func() {
+        switch code {
+        case 1:
+        }
+    }
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+      const outer = results.find((r) => r.name === "Outer");
+
+      assert.deepStrictEqual(outer?.switches, []);
+    });
+  });
+
+  suite("Expression Nesting", () => {
+    test("should count a chain of one operator as one level", () => {
+      const sourceCode = `
+package main
+
+func Chain(a, b, c bool) bool {
+    return a && b && c
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results[0].maxExpressionNesting, 1);
+    });
+
+    test("should count groups of another operator as nested, with or without parentheses", () => {
+      const sourceCode = `
+package main
+
+func Grouped(a, b, c bool) bool {
+    return a && (b || c)
+}
+
+func Mixed(a, b, c bool) bool {
+    return a && b || c
+}
+
+func Deep(a, b, c, d bool) bool {
+    return a || (b && (c || d))
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(
+        results.map((r) => r.maxExpressionNesting),
+        [2, 2, 3]
+      );
+    });
+
+    test("should measure conditions in function literals separately", () => {
+      const sourceCode = `
+package main
+
+func Outer(a, b, c bool) bool {
+    check := func() bool {
+        return a || (b && c)
+    }
+    return check() && a
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+      const outer = results.find((r) => r.name === "Outer");
+
+      assert.strictEqual(outer?.maxExpressionNesting, 1);
+    });
+  });
+
+  suite("Fluent Usage", () => {
+    test("should find methods returning their receiver and method call chains", () => {
+      const sourceCode = `
+package main
+
+type Builder struct{ parts []string }
+
+func (b *Builder) With(p string) *Builder { b.parts = append(b.parts, p); return b }
+func (b Builder) Named(name string) (out Builder) { return b }
+func (b *Builder) Build() string { return "" }
+func (b *Builder) Clone() (*Builder, error) { return b, nil }
+
+func main() {
+    s := NewBuilder().With("a").With("b").Named("x").Build()
+    fmt.Println(s)
+}
+`;
+
+      const usage = GoMetricsAnalyzer.findFluentUsage(sourceCode);
+
+      assert.deepStrictEqual(usage.fluentMethods, [
+        { type: "Builder", name: "With" },
+        { type: "Builder", name: "Named" },
+      ]);
+      assert.deepStrictEqual(usage.chains, [
+        { methods: ["With", "With", "Named", "Build"], line: 11 },
+      ]);
+    });
+  });
+
+  suite("Node Counts", () => {
+    const sourceCode = `
+package main
+
+func Sum(items []int) int {
+    total := 0
+    for _, item := range items {
+        total += item
+    }
+    return total
+}
+`;
+
+    test("should not collect node counts by default", () => {
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results[0].nodeCounts, undefined);
+    });
+
+    test("should collect a node type histogram when requested", () => {
+      const results = new GoMetricsAnalyzer({ collectNodeCounts: true })
+        .analyzeFunctions(sourceCode);
+      const counts = results[0].nodeCounts ?? {};
+
+      assert.strictEqual(counts.block, 2);
+      assert.strictEqual(counts.for_statement, 1);
+      assert.strictEqual(counts.return_statement, 1);
+      assert.strictEqual(counts.if_statement, undefined);
+    });
+  });
+
+  suite("Return Points", () => {
+    test("should count a naked return of named results", () => {
+      const sourceCode = `
+package main
+
+func Divide(a, b int) (q int, err error) {
+    q = a / b
+    return
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results[0].returnCount, 1);
+    });
+
+    test("should count every naked and explicit return", () => {
+      const sourceCode = `
+package main
+
+func Parse(s string) (n int, ok bool) {
+    if s == "" {
+        return
+    }
+    if s == "0" {
+        return 0, true
+    }
+    n, ok = 1, true
+    return
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results[0].returnCount, 3);
+    });
+
+    test("should not count returns of deferred closures assigning named results", () => {
+      const sourceCode = `
+package main
+
+func SafeOperation() (err error) {
+    defer func() {
+        if r := recover(); r != nil {
+            err = fmt.Errorf("recovered: %v", r)
+            return
+        }
+    }()
+    panic("boom")
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results[0].returnCount, 0);
+    });
+
+    test("should not change complexity between named and unnamed results", () => {
+      const named = `
+package main
+
+func Check(a int) (ok bool) {
+    if a > 0 {
+        ok = true
+        return
+    }
+    return
+}
+`;
+      const unnamed = `
+package main
+
+func Check(a int) bool {
+    if a > 0 {
+        return true
+    }
+    return false
+}
+`;
+
+      const namedResult = analyzer.analyzeFunctions(named)[0];
+      const unnamedResult = analyzer.analyzeFunctions(unnamed)[0];
+
+      assert.strictEqual(namedResult.complexity, unnamedResult.complexity);
+      assert.strictEqual(namedResult.returnCount, unnamedResult.returnCount);
+    });
+  });
+
+  suite("Error Surface", () => {
+    test("should count returns handing back a non-nil error", () => {
+      const sourceCode = `
+package main
+
+func Load(path string) (*Config, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, fmt.Errorf("load %s: %w", path, err)
+    }
+    cfg, err := parse(data)
+    if err != nil {
+        return nil, err
+    }
+    if cfg.Version == 0 {
+        return nil, errVersion
+    }
+    return cfg, nil
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results[0].returnCount, 4);
+      assert.strictEqual(results[0].errorReturnCount, 3);
+    });
+
+    test("should count forwarded calls but not naked returns", () => {
+      const sourceCode = `
+package main
+
+func Save(cfg *Config) error {
+    if cfg == nil {
+        return errNoConfig
+    }
+    return write(cfg)
+}
+
+func Reload(path string) (cfg *Config, err error) {
+    if path == "" {
+        return
+    }
+    return Load(path)
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results[0].errorReturnCount, 2);
+      assert.strictEqual(results[1].errorReturnCount, 1);
+    });
+
+    test("should leave the error surface undefined for functions not returning an error", () => {
+      const sourceCode = `
+package main
+
+func Run() int {
+    handler := func() error {
+        return errFailed
+    }
+    if handler() != nil {
+        return 1
+    }
+    return 0
+}
+`;
+
+      const results = new GoMetricsAnalyzer({ closureMode: "both" }).analyzeFunctions(sourceCode);
+      const run = results.find((r) => r.name === "Run");
+      const closure = results.find((r) => r.name === "Run.func1");
+
+      assert.strictEqual(run?.errorReturnCount, undefined);
+      assert.strictEqual(closure?.errorReturnCount, 1);
+    });
+  });
+
+  suite("Operator Kinds", () => {
+    test("should report each kind of operator once", () => {
+      const sourceCode = `
+package main
+
+func Mix(values []int, mask uint, ch chan int) bool {
+    total := 0
+    for i := 0; i < len(values); i++ {
+        total += values[i] * 2
+    }
+    mask &^= 1 << 3
+    ch <- total
+    return total > 10 && !(mask == 0)
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(results[0].operatorKinds, [
+        "arithmetic",
+        "bitwise",
+        "channel",
+        "comparison",
+        "logical",
+      ]);
+    });
+
+    test("should not count plain assignment, pointer operators, or closures' operators", () => {
+      const sourceCode = `
+package main
+
+func Wire(p *int, ready chan bool) func() bool {
+    q := &p
+    *p = **q
+    return func() bool {
+        return <-ready || *p > 0
+    }
+}
+`;
+
+      const results = new GoMetricsAnalyzer({ closureMode: "separate" })
+        .analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(results.find((r) => r.name === "Wire")?.operatorKinds, []);
+      assert.deepStrictEqual(
+        results.find((r) => r.name === "Wire.func1")?.operatorKinds,
+        ["channel", "comparison", "logical"]
+      );
+    });
+  });
+
+  suite("Parameter Count", () => {
+    test("should count grouped, unnamed, and variadic parameters", () => {
+      const sourceCode = `
+package main
+
+type S struct{}
+
+func (s *S) Log(format string, args ...any) {}
+
+func Add(a, b int, c float64) {}
+
+func Handle(func(int) error) {}
+
+func None() {}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(results.map((r) => r.parameterCount), [2, 3, 1, 0]);
+    });
+  });
+
+  suite("Calls", () => {
+    test("should collect plain calls and calls on the receiver", () => {
+      const sourceCode = `
+package main
+
+import "fmt"
+
+type Tree struct{ left, right *Tree }
+
+func (t *Tree) Size() int {
+    if t == nil {
+        return 0
+    }
+    fmt.Println(helper())
+    return 1 + t.left.Size() + t.Size()
+}
+
+func helper() int {
+    return helper()
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(results[0].calls, ["helper", "Tree.Size"]);
+      assert.deepStrictEqual(results[1].calls, ["helper"]);
+    });
+  });
+
+  suite("Closure Depth", () => {
+    test("should measure the deepest func literal nesting", () => {
+      const sourceCode = `
+package main
+
+func Serve(items []int) {
+    for _, item := range items {
+        go func() {
+            process(item, func(err error) {
+                retry(func() {
+                    log(err)
+                })
+            })
+        }()
+    }
+    defer func() {}()
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results[0].maxClosureDepth, 3);
+    });
+
+    test("should report zero for functions without closures", () => {
+      const sourceCode = `
+package main
+
+func Plain(a int) int {
+    if a > 0 {
+        return a
+    }
+    return 0
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results[0].maxClosureDepth, 0);
+    });
+
+    test("should measure separately reported closures from their own body", () => {
+      const sourceCode = `
+package main
+
+func Outer() {
+    run(func() {
+        run(func() {})
+    })
+}
+`;
+
+      const results = new GoMetricsAnalyzer({ closureMode: "both" })
+        .analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(
+        results.map((r) => [r.name, r.maxClosureDepth]),
+        [["Outer", 2], ["Outer.func1", 1], ["Outer.func1.1", 0]]
+      );
+    });
+  });
+
+  suite("Goroutines", () => {
+    test("should not add complexity for go statement itself", () => {
+      const sourceCode = `
+package main
+
+func SpawnGoroutine() {
+    go func() {
+        // concurrent work
+    }()
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      // go statement doesn't add complexity, closure at top level = 0
+      assert.strictEqual(results[0].complexity, 0);
+    });
+
+    test("should add complexity for nested goroutine with closure", () => {
+      const sourceCode = `
+package main
+
+func NestedGoroutine() {
+    if true {
+        go func() {
+            // concurrent work
+        }()
+    }
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      // if(1) + nested closure(2) = 3
+      assert.strictEqual(results[0].complexity, 3);
+    });
+  });
+
+  suite("Edge Cases", () => {
+    test("should handle empty function", () => {
+      const sourceCode = `
+package main
+
+func Empty() {
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results.length, 1);
+      assert.strictEqual(results[0].name, "Empty");
+      assert.strictEqual(results[0].complexity, 0);
+    });
+
+    test("should handle function with only return", () => {
+      const sourceCode = `
+package main
+
+func ReturnOnly() int {
+    return 42
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results.length, 1);
+      assert.strictEqual(results[0].complexity, 0);
+    });
+
+    test("should handle empty file", () => {
+      const sourceCode = `package main
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results.length, 0);
+    });
+
+    test("should handle file with only imports", () => {
+      const sourceCode = `
+package main
+
+import "fmt"
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results.length, 0);
+    });
+
+    test("should handle complex real-world function", () => {
+      const sourceCode = `
+package main
+
+func ProcessData(items []int, includeNegatives bool) []int {
+    result := make([]int, 0)
+
+    for _, item := range items {
+        if item > 0 {
+            result = append(result, item)
+        } else if includeNegatives && item < 0 {
+            result = append(result, -item)
+        } else {
+            continue
+        }
+    }
+
+    if len(result) > 10 {
+        return result[:10]
+    } else if len(result) == 0 {
+        return nil
+    }
+
+    return result
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results.length, 1);
+      // for(1) + if(2) + else if(1) + &&(3) + else(1) + continue(3) + if(1) + else if(1) = 13
+      assert.strictEqual(results[0].complexity, 13);
+    });
+  });
+
+  suite("Position Information", () => {
+    test("should return correct line numbers", () => {
+      const sourceCode = `package main
+
+func First() {
+}
+
+func Second() {
+    if true {
+    }
+}`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results.length, 2);
+      // First function starts at line 2 (0-based)
+      assert.strictEqual(results[0].startLine, 2);
+      // Second function starts at line 5 (0-based)
+      assert.strictEqual(results[1].startLine, 5);
+    });
+
+    test("should return correct complexity detail positions", () => {
+      const sourceCode = `package main
+
+func Test() {
+    if true {
+    }
+}`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results.length, 1);
+      assert.strictEqual(results[0].details.length, 1);
+      // The if statement is on line 3 (0-based)
+      assert.strictEqual(results[0].details[0].line, 3);
+    });
+  });
+
+  suite("Static Factory Method", () => {
+    test("should analyze file using static method", () => {
+      const sourceCode = `
+package main
+
+func Add(a, b int) int {
+    if a < 0 || b < 0 {
+        return 0
+    }
+    return a + b
+}
+`;
+
+      const results = GoMetricsAnalyzer.analyzeFile(sourceCode);
+
+      assert.strictEqual(results.length, 1);
+      assert.strictEqual(results[0].name, "Add");
+      // if(1) + ||(2 nested in if) = 3
+      assert.strictEqual(results[0].complexity, 3);
+    });
+  });
+
+  suite("If-Else Chains", () => {
+    test("should handle if-else chains", () => {
+      const sourceCode = `
+package main
+
+func IfElseChain(value int) string {
+    if value > 100 {
+        return "large"
+    } else if value > 50 {
+        return "medium"
+    } else if value > 0 {
+        return "small"
+    } else {
+        return "zero or negative"
+    }
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      // Each if statement adds complexity
+      assert.ok(
+        results[0].complexity >= 3,
+        "Should count multiple if statements"
+      );
+      // if(1) + else if(1) + else if(1) + else(1) = 4
+      assert.strictEqual(results[0].complexity, 4);
+    });
+
+    test("should add exactly one per else-if in a long chain", () => {
+      const branches = Array.from(
+        { length: 8 },
+        (_, i) => ` else if code == ${i + 1} {\n        return ${i + 1}\n    }`
+      ).join("");
+      const sourceCode = `
+package main
+
+func Dispatch(code int) int {
+    if code == 0 {
+        return 0
+    }${branches}
+    return -1
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      // if(1) + 8 × else if(1) = 9
+      assert.strictEqual(results[0].complexity, 9);
+      const elseIfs = results[0].details.filter((d) => d.reason === "else if clause");
+      assert.strictEqual(elseIfs.length, 8);
+      assert.ok(elseIfs.every((d) => d.increment === 1 && d.nesting === 0));
+    });
+
+    test("should not apply a nesting penalty to nested else-if chains", () => {
+      const sourceCode = `
+package main
+
+func Grade(scores [][]int) int {
+    total := 0
+    for _, row := range scores {
+        for _, s := range row {
+            if s > 90 {
+                total += 3
+            } else if s > 75 {
+                total += 2
+            } else if s > 50 {
+                total += 1
+            }
+        }
+    }
+    return total
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      // for(1) + for(2) + if(3) + else if(1) + else if(1) = 8
+      assert.strictEqual(results[0].complexity, 8);
+    });
+
+    test("should count else-if bodies at the chain's nesting level", () => {
+      const sourceCode = `
+package main
+
+func Route(a, b bool) int {
+    if a {
+        return 1
+    } else if b {
+        if a == b {
+            return 2
+        }
+    }
+    return 0
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      // if(1) + else if(1) + nested if(2) — the else-if does not nest a second time
+      assert.strictEqual(results[0].complexity, 4);
+    });
+  });
+
+  suite("Defer Statements", () => {
+    test("should not add complexity for simple defer", () => {
+      const sourceCode = `
+package main
+
+func DeferTest() {
+    defer close()
+}
+
+func close() {}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      // defer itself doesn't add complexity
       assert.strictEqual(results[0].complexity, 0);
     });
 
@@ -839,41 +2190,732 @@ func close() {}
       const sourceCode = `
 package main
 
-func DeferredClosure() {
-    if true {
-        defer func() {
-            // cleanup
-        }()
+func DeferredClosure() {
+    if true {
+        defer func() {
+            // cleanup
+        }()
+    }
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      // if(1) + nested func_literal(2) = 3
+      assert.strictEqual(results[0].complexity, 3);
+    });
+  });
+
+  suite("Channel Operations", () => {
+    test("should handle channel receive in select", () => {
+      const sourceCode = `
+package main
+
+func ChannelReceive(ch1, ch2 chan int) int {
+    select {
+    case v := <-ch1:
+        return v
+    case v := <-ch2:
+        return v
+    }
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results[0].complexity, 1);
+      assert.strictEqual(results[0].details[0].reason, "select statement");
+    });
+
+    test("should count a range over a channel as a loop", () => {
+      const sourceCode = `
+package main
+
+func Drain(ch <-chan int) (sum int) {
+    for v := range ch {
+        sum += v
+    }
+    for range ch {
+    }
+    return sum
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(
+        results[0].details.map((d) => [d.reason, d.increment, d.nesting]),
+        [
+          ["for loop", 1, 0],
+          ["for loop", 1, 0],
+        ]
+      );
+      assert.strictEqual(results[0].complexity, 2);
+    });
+
+    test("should score ConcurrentWorker as a loop around a nested select", () => {
+      const fixture = fs.readFileSync(path.resolve(__dirname, "../../../../samples/Test.go"), "utf-8");
+
+      const result = analyzer.analyzeFunctions(fixture).find((r) => r.name === "ConcurrentWorker")!;
+
+      // for(1) + select(2, nesting = 1); the returning case adds nothing
+      assert.deepStrictEqual(
+        result.details.map((d) => [d.reason, d.increment, d.nesting]),
+        [
+          ["for loop", 1, 0],
+          ["select statement", 2, 1],
+        ]
+      );
+      assert.strictEqual(result.complexity, 3);
+    });
+
+    test("should count a select once whatever its number of cases", () => {
+      const worker = (cases: string) => `
+package main
+
+func Worker(jobs <-chan int, results chan<- int, done chan bool) {
+    for {
+        select {
+        case job := <-jobs:
+            results <- job * 2
+${cases}
+        }
+    }
+}
+`;
+
+      const two = analyzer.analyzeFunctions(worker("        case <-done:\n            return"));
+      const four = analyzer.analyzeFunctions(
+        worker(
+          "        case <-done:\n            return\n" +
+            "        case results <- 0:\n        default:\n            results <- -1"
+        )
+      );
+
+      assert.strictEqual(two[0].complexity, 3);
+      assert.strictEqual(four[0].complexity, 3);
+      assert.deepStrictEqual(four[0].details, two[0].details);
+    });
+
+    test("should count a range-over-channel loop with a select in its body", () => {
+      const sourceCode = `
+package main
+
+func Forward(in <-chan int, out chan<- int, quit chan struct{}) {
+    for v := range in {
+        select {
+        case out <- v:
+        case <-quit:
+            return
+        }
     }
 }
 `;
 
       const results = analyzer.analyzeFunctions(sourceCode);
 
-      // if(1) + nested func_literal(2) = 3
+      // range(1) + select(2, nesting = 1)
       assert.strictEqual(results[0].complexity, 3);
+      // one per communication case, plus one for the loop, from 1
+      assert.strictEqual(GoMetricsAnalyzer.countCyclomatic(sourceCode)[0].complexity, 4);
     });
   });
 
-  suite("Channel Operations", () => {
-    test("should handle channel receive in select", () => {
+  suite("Statements With Init Clauses", () => {
+    const complexityOf = (sourceCode: string) =>
+      analyzer.analyzeFunctions(sourceCode)[0].complexity;
+
+    test("should not count the init clause of an if statement", () => {
       const sourceCode = `
 package main
 
-func ChannelReceive(ch1, ch2 chan int) int {
-    select {
-    case v := <-ch1:
+func Lookup(m map[string]int, k string) int {
+    if v, ok := m[k]; ok {
         return v
-    case v := <-ch2:
+    }
+    return 0
+}
+`;
+      // if(1); the comma-ok assignment is not a decision
+      assert.strictEqual(complexityOf(sourceCode), 1);
+    });
+
+    test("should still count logical operators in the condition after the init clause", () => {
+      const sourceCode = `
+package main
+
+func LookupPositive(m map[string]int, k string) int {
+    if v, ok := m[k]; ok && v > 0 {
+        return v
+    }
+    return 0
+}
+`;
+      // if(1) + &&(1)
+      assert.strictEqual(complexityOf(sourceCode), 2);
+    });
+
+    test("should not count the init clause of an else-if", () => {
+      const sourceCode = `
+package main
+
+func Resolve(m map[string]int, k string, fallback int) int {
+    if k == "" {
+        return fallback
+    } else if v, ok := m[k]; ok {
         return v
     }
+    return 0
+}
+`;
+      // if(1) + else if(1)
+      assert.strictEqual(complexityOf(sourceCode), 2);
+    });
+
+    test("should not count the init and post statements of a for loop", () => {
+      const sourceCode = `
+package main
+
+func Sum(values []int) int {
+    total := 0
+    for i := 0; i < len(values); i++ {
+        total += values[i]
+    }
+    return total
+}
+`;
+      assert.strictEqual(complexityOf(sourceCode), 1);
+    });
+
+    test("should not count the init clause of a switch", () => {
+      const sourceCode = `
+package main
+
+func Classify(s string) string {
+    switch n := len(s); n {
+    case 0:
+        return "empty"
+    default:
+        return "text"
+    }
+}
+`;
+      assert.strictEqual(complexityOf(sourceCode), 1);
+    });
+
+    test("should not count the init clause of a type switch", () => {
+      const sourceCode = `
+package main
+
+func Describe(x interface{}) string {
+    switch prefix := "value: "; v := x.(type) {
+    case int:
+        return prefix + "int"
+    default:
+        _ = v
+        return prefix + "other"
+    }
 }
 `;
+      assert.strictEqual(complexityOf(sourceCode), 1);
+    });
+
+    test("should not nest a closure in an init clause inside its statement", () => {
+      const sourceCode = `
+package main
 
+func Validate(items []int) error {
+    if err := each(items, func(i int) error {
+        if i < 0 {
+            return errNegative
+        }
+        return nil
+    }); err != nil {
+        return err
+    }
+    return nil
+}
+`;
       const results = analyzer.analyzeFunctions(sourceCode);
 
-      assert.strictEqual(results[0].complexity, 1);
-      assert.strictEqual(results[0].details[0].reason, "select statement");
+      // if(1) + closure's if(1+1): the closure runs before the decision, at nesting 0
+      assert.strictEqual(results[0].complexity, 3);
+      const inner = results[0].details.find((d) => d.line === 5);
+      assert.strictEqual(inner?.nesting, 1);
+    });
+  });
+
+  suite("Type Constraints", () => {
+    const fixture = fs.readFileSync(
+      path.resolve(__dirname, "../../../../samples/generics/constraints.go"),
+      "utf-8"
+    );
+
+    test("should report only functions, not constraint interfaces or their type sets", () => {
+      const results = analyzer.analyzeFunctions(fixture);
+
+      assert.deepStrictEqual(
+        Object.fromEntries(results.map((r) => [r.name, r.complexity])),
+        {
+          // for(1)
+          Sum: 1,
+          // if(1) + if(1)
+          Clamp: 2,
+          "Set[T].Add": 0,
+          // if(1) + &&(1)
+          Flags: 2,
+          // for(1)
+          Print: 1,
+        }
+      );
+    });
+
+    test("should keep positions and parameters of generic functions", () => {
+      const clamp = analyzer.analyzeFunctions(fixture).find((r) => r.name === "Clamp");
+
+      assert.strictEqual(clamp?.startLine, 33);
+      assert.strictEqual(clamp?.endLine, 41);
+      // Type parameters are not parameters
+      assert.strictEqual(clamp?.parameterCount, 3);
+      assert.deepStrictEqual(clamp?.details.map((d) => d.line), [34, 37]);
+    });
+
+    test("should count | in expressions but not in type sets", () => {
+      const results = analyzer.analyzeFunctions(fixture);
+
+      assert.deepStrictEqual(results.find((r) => r.name === "Flags")?.operatorKinds, [
+        "bitwise",
+        "logical",
+      ]);
+      assert.deepStrictEqual(results.find((r) => r.name === "Clamp")?.operatorKinds, [
+        "comparison",
+      ]);
+    });
+  });
+
+  suite("Generic Receivers", () => {
+    const fixture = fs.readFileSync(
+      path.resolve(__dirname, "../../../../samples/generics/containers.go"),
+      "utf-8"
+    );
+
+    test("should name methods after their generic type, however it is spaced", () => {
+      const results = analyzer.analyzeFunctions(fixture);
+
+      assert.deepStrictEqual(
+        results.map((r) => [r.name, r.pointerReceiver]),
+        [
+          ["Stack[T].Push", true],
+          ["Stack[T].Pop", true],
+          ["Stack[E].Peek", false],
+          ["Stack[_].Len", false],
+          ["Stack[V].Drain", true],
+          ["Pair[K, V].Swap", false],
+          ["Pair[K, V].Matches", true],
+        ]
+      );
+    });
+
+    test("should resolve receiver calls across type parameter names", () => {
+      const results = analyzer.analyzeFunctions(fixture);
+      const calls = (name: string) => results.find((r) => r.name === name)?.calls;
+
+      assert.deepStrictEqual(calls("Stack[E].Peek"), ["Stack[_].Len"]);
+      assert.deepStrictEqual(calls("Stack[V].Drain"), ["Stack[T].Pop", "visit", "Stack[V].Drain"]);
+      assert.deepStrictEqual(calls("Pair[K, V].Matches"), ["present"]);
+    });
+  });
+
+  suite("gocyclo Counting", () => {
+    const sourceCode = `package main
+
+var handler = func() {
+    if ready {
+        start()
+    }
+}
+
+func Classify(n int, ok bool) string {
+    if n < 0 && ok || n > 100 {
+        return "out"
+    } else if n == 0 {
+        return "zero"
+    }
+    switch {
+    case n < 10:
+        return "small"
+    case n < 50:
+        return "medium"
+    default:
+        return "large"
+    }
+}
+
+func (s *Server) Run(jobs []int, done chan bool) {
+    for _, j := range jobs {
+        go func() {
+            if j > 0 {
+                process(j)
+            }
+        }()
+    }
+    select {
+    case <-done:
+    default:
+    }
+}
+`;
+
+    test("should count ifs, loops, non-default cases, and each logical operator from 1", () => {
+      assert.deepStrictEqual(GoMetricsAnalyzer.countCyclomatic(sourceCode), [
+        // if(1) + &&(1) + ||(1) + else if(1) + two cases(2)
+        { name: "Classify", startLine: 8, complexity: 7 },
+        // range(1) + if in the closure(1) + select case(1)
+        { name: "Server.Run", startLine: 24, complexity: 4 },
+      ]);
+    });
+
+    test("should score declared functions with the decision points when cyclomatic", () => {
+      for (const cyclomatic of [
+        new GoMetricsAnalyzer({ scoring: "cyclomatic" }),
+        new GoMetricsAnalyzer({ scoring: "cyclomatic", streamingThreshold: 1 }),
+      ]) {
+        const scores = cyclomatic.analyzeFunctions(sourceCode).map((r) => [r.name, r.complexity]);
+
+        // The count less its starting 1, closures included
+        assert.deepStrictEqual(scores, [
+          ["init.func1", 1],
+          ["Classify", 6],
+          ["Server.Run", 3],
+        ]);
+      }
+    });
+
+    test("should score separately reported closures with their own decision points", () => {
+      const scores = (closureMode: "separate" | "both") =>
+        new GoMetricsAnalyzer({ scoring: "cyclomatic", closureMode })
+          .analyzeFunctions(sourceCode)
+          .map((r) => [r.name, r.complexity]);
+
+      // range(1) + select case(1); the closure's if is only its own
+      assert.deepStrictEqual(scores("separate"), [
+        ["init.func1", 1],
+        ["Classify", 6],
+        ["Server.Run", 2],
+        ["Server.Run.func1", 1],
+      ]);
+      assert.deepStrictEqual(scores("both"), [
+        ["init.func1", 1],
+        ["Classify", 6],
+        ["Server.Run", 3],
+        ["Server.Run.func1", 1],
+      ]);
+    });
+
+    test("should leave out the decisions of disabled regions when cyclomatic", () => {
+      const results = new GoMetricsAnalyzer({ scoring: "cyclomatic" }).analyzeFunctions(`package main
+
+func Parse(s string, ok bool) int {
+    //metrics:disable generated table
+    if s == "a" && ok {
+        return 1
+    }
+    //metrics:enable
+    for range s {
+    }
+    return 0
+}
+`);
+
+      // Only the loop counts.
+      assert.deepStrictEqual(results.map((r) => [r.name, r.complexity]), [["Parse", 1]]);
+    });
+
+    test("should differ from cognitive complexity where the rules differ", () => {
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      // if(1) + &&(1) + ||(1) + else if(1) + switch(1)
+      assert.strictEqual(results.find((r) => r.name === "Classify")?.complexity, 5);
+      // for(1) + nested closure(2) + if(3) + select(1)
+      assert.strictEqual(results.find((r) => r.name === "Server.Run")?.complexity, 7);
+    });
+  });
+
+  suite("Streaming Parse", () => {
+    const streaming = new GoMetricsAnalyzer({ streamingThreshold: 1 });
+
+    test("should give the same results one declaration at a time", () => {
+      for (const sample of [
+        "Test.go",
+        "funcfields/funcfields.go",
+        "generics/constraints.go",
+        "generics/containers.go",
+      ]) {
+        const fixture = fs.readFileSync(
+          path.resolve(__dirname, "../../../../samples", sample),
+          "utf-8"
+        );
+
+        assert.deepStrictEqual(streaming.analyzeFunctions(fixture), analyzer.analyzeFunctions(fixture), sample);
+      }
+    });
+
+    test("should not split declarations at brackets in strings, runes, and comments", () => {
+      const sourceCode = `package main
+
+const usage = \`
+}
+func fake() {
+\`
+
+/* }
+func alsoFake() { */
+var brace, quote = '}', "\\"}"
+
+//metrics:expect cc<=1
+func Real(x int) int {
+    if x > 0 {
+        return x
+    }
+    return 0
+}
+`;
+
+      const results = streaming.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(results, analyzer.analyzeFunctions(sourceCode));
+      assert.deepStrictEqual(results.map((r) => [r.name, r.startLine, r.endLine]), [["Real", 12, 17]]);
+      assert.strictEqual(results[0].details[0].line, 13);
+      assert.deepStrictEqual(results[0].expectedComplexity, { operator: "<=", value: 1 });
+    });
+
+    test("should shift switch positions back to the file's lines", () => {
+      const sourceCode = `package main
+
+func First(a bool) int {
+    if a {
+        return 1
+    }
+    return 0
+}
+
+func Second(code int, ch chan int) string {
+    switch code {
+    case 1:
+        return "one"
+    default:
+        select {
+        case <-ch:
+        }
+    }
+    return ""
+}
+`;
+
+      const results = streaming.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(results, analyzer.analyzeFunctions(sourceCode));
+      assert.deepStrictEqual(
+        results[1].switches?.map((s) => [s.kind, s.line, s.column]),
+        [
+          ["switch", 10, 4],
+          ["select", 14, 8],
+        ]
+      );
+    });
+
+    test("should only stream files above the threshold", () => {
+      const sourceCode = "package main\n\nfunc A() {}\n\nvar f = func() {}\n";
+
+      assert.deepStrictEqual(
+        new GoMetricsAnalyzer({ streamingThreshold: 100 }).analyzeFunctions(sourceCode),
+        streaming.analyzeFunctions(sourceCode)
+      );
+    });
+
+    test("should analyze only function declarations overlapping the line ranges", () => {
+      const sourceCode = `package main
+
+// Early is above the range.
+func Early(a bool) int {
+    if a {
+        return 1
+    }
+    return 0
+}
+
+var handler = func() {}
+
+// Visible is in it.
+func Visible(a bool) int {
+    if a {
+        return 1
+    }
+    return 0
+}
+
+func Late() {}
+`;
+
+      const results = new GoMetricsAnalyzer({ lineRanges: [{ start: 14, end: 16 }] }).analyzeFunctions(
+        sourceCode
+      );
+      const all = analyzer.analyzeFunctions(sourceCode);
+
+      // Package-level closures are always analyzed, so their numbering stays that of the file.
+      assert.deepStrictEqual(
+        results.map((r) => r.name),
+        ["init.func1", "Visible"]
+      );
+      assert.deepStrictEqual(
+        results.find((r) => r.name === "Visible"),
+        all.find((r) => r.name === "Visible")
+      );
+    });
+  });
+
+  suite("Branch Density", () => {
+    const density = (r: { statementCount?: number; branchCount?: number; branchDensity?: number }) => [
+      r.statementCount,
+      r.branchCount,
+      r.branchDensity,
+    ];
+
+    test("should measure branches per statement of error-checking code", () => {
+      const sourceCode = `
+package main
+
+func Load(path string) (*Config, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    var c Config
+    if err := json.Unmarshal(data, &c); err != nil {
+        return nil, err
+    }
+    return &c, nil
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      // The init clause of the second if is not a statement of its own
+      assert.deepStrictEqual(density(results[0]), [7, 2, 0.29]);
+    });
+
+    test("should count loops and non-default cases but not for clauses or closures", () => {
+      const sourceCode = `
+package main
+
+func Sum(xs []int) (total int) {
+    for i := 0; i < len(xs); i++ {
+        switch {
+        case xs[i] > 0:
+            total += xs[i]
+        default:
+            continue
+        }
+    }
+    run(func() {
+        if total > 0 {
+            total = 0
+        }
+    })
+    return
+}
+`;
+
+      const results = new GoMetricsAnalyzer({ closureMode: "separate" }).analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(
+        results.map((r) => [r.name, ...density(r)]),
+        [
+          // for, switch, assignment, continue, run(...), return; for and one case
+          ["Sum", 6, 2, 0.33],
+          ["Sum.func1", 2, 1, 0.5],
+        ]
+      );
+    });
+
+    test("should report zero density for an empty body", () => {
+      const results = analyzer.analyzeFunctions("package main\n\nfunc Noop() {}\n");
+
+      assert.deepStrictEqual(density(results[0]), [0, 0, 0]);
+    });
+  });
+
+  suite("Unrecognized Syntax", () => {
+    test("should estimate functions the parser does not understand and mark them approximate", () => {
+      const sourceCode = `
+package main
+
+func Clamp(x int) int {
+    if x < 0 {
+        return 0
+    }
+    return x ?? 0
+}
+
+func Valid(ok bool) bool {
+    return ok
+}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+      const clamp = results.find((r) => r.name === "Clamp");
+      const valid = results.find((r) => r.name === "Valid");
+
+      assert.ok(clamp, "the function should still be reported");
+      assert.strictEqual(clamp.approximate, true);
+      assert.strictEqual(clamp.complexity, 1);
+      assert.strictEqual(clamp.startLine, 3);
+      assert.ok(valid);
+      assert.strictEqual(valid.approximate, undefined);
+    });
+
+    test("should not mark functions of well-formed code approximate", () => {
+      const sourceCode = `
+package main
+
+func Sign(x int) int {
+    if x < 0 {
+        return -1
+    }
+    return 1
+}
+`;
+
+      const [result] = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(result.approximate, undefined);
+    });
+  });
+
+  suite("Declaration Blocks", () => {
+    const fixture = fs.readFileSync(path.resolve(__dirname, "../../../../samples/enums/enums.go"), "utf-8");
+
+    test("should report only the functions of a file full of iota const blocks", () => {
+      const results = analyzer.analyzeFunctions(fixture);
+
+      assert.deepStrictEqual(
+        results.map((r) => [r.name, r.startLine + 1, r.endLine + 1]),
+        [
+          ["Code.String", 119, 124],
+          ["ParseCode", 127, 134],
+          ["itoa", 136, 144],
+        ]
+      );
+    });
+
+    test("should count the declaration blocks toward the file's lines of code", () => {
+      const functionLines = MetricsAnalyzerFactory.analyzeFile(fixture, "go").reduce(
+        (n, f) => n + (f.logicalLines ?? 0),
+        0
+      );
+
+      assert.strictEqual(countFileLogicalLines(fixture, "go"), 118);
+      assert.ok(functionLines < 30, `functions hold ${functionLines} lines`);
     });
   });
 });
@@ -0,0 +1,97 @@
+import * as assert from "assert";
+import { GoTemplateMetricsAnalyzer } from "../../../metricsAnalyzer/languages/goTemplateAnalyzer";
+
+suite("Go Template Metrics Analyzer Tests", () => {
+  let analyzer: GoTemplateMetricsAnalyzer;
+
+  setup(() => {
+    analyzer = new GoTemplateMetricsAnalyzer();
+  });
+
+  suite("Control Actions", () => {
+    test("should add nesting to if, range, and with, and a flat +1 to else", () => {
+      const sourceCode = `
+{{define "list"}}
+  {{if .Items}}
+    {{range .Items}}
+      {{if .Active}}<b>{{.Name}}</b>{{else}}{{.Name}}{{end}}
+    {{end}}
+  {{else if .Loading}}
+    loading
+  {{else}}
+    empty
+  {{end}}
+{{end}}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results.length, 1);
+      assert.strictEqual(results[0].name, "list");
+      // if(1) + range(2) + nested if(3) + else(1) + else if(1) + else(1)
+      assert.strictEqual(results[0].complexity, 9);
+      assert.deepStrictEqual(
+        results[0].details.map((d) => d.reason),
+        [
+          "if action (nesting: 0)",
+          "range action (nesting: 1)",
+          "if action (nesting: 2)",
+          "else action",
+          "else if action",
+          "else action",
+        ]
+      );
+    });
+
+    test("should honour trim markers and ignore comments", () => {
+      const sourceCode = `{{/* {{if .Skipped}} */}}{{- if .Ready -}}yes{{- end -}}`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(results.length, 1);
+      assert.strictEqual(results[0].complexity, 1);
+    });
+  });
+
+  suite("Template Definitions", () => {
+    test("should report each define and block with its own range", () => {
+      const sourceCode = `{{define "header"}}
+  {{with .Title}}<h1>{{.}}</h1>{{end}}
+{{end}}
+{{block "footer" .}}
+  {{range .Links}}<a>{{.}}</a>{{end}}
+{{end}}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(
+        results.map((r) => [r.name, r.complexity, r.startLine, r.endLine]),
+        [
+          ["header", 1, 0, 2],
+          ["footer", 1, 3, 5],
+        ]
+      );
+    });
+
+    test("should report actions outside definitions as the top-level template", () => {
+      const sourceCode = `<p>{{with .User}}{{.Name}}{{end}}</p>
+{{define "empty"}}{{end}}
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(
+        results.map((r) => [r.name, r.complexity]),
+        [
+          ["(template)", 1],
+          ["empty", 0],
+        ]
+      );
+    });
+
+    test("should return no entries for plain text", () => {
+      assert.deepStrictEqual(analyzer.analyzeFunctions("<p>static</p>\n"), []);
+    });
+  });
+});
@@ -0,0 +1,217 @@
+import * as assert from "assert";
+import { SqlMetricsAnalyzer } from "../../../metricsAnalyzer/languages/sqlAnalyzer";
+
+suite("SQL Metrics Analyzer Tests", () => {
+  let analyzer: SqlMetricsAnalyzer;
+
+  setup(() => {
+    analyzer = new SqlMetricsAnalyzer();
+  });
+
+  suite("Routines", () => {
+    test("should report functions, procedures, and DO blocks with their range", () => {
+      const sourceCode = `-- Billing routines
+CREATE OR REPLACE FUNCTION billing.apply_discount(total numeric)
+RETURNS numeric AS $$
+BEGIN
+  RETURN total * 0.9;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT billing.apply_discount(10);
+
+CREATE PROCEDURE "Archive Orders"() LANGUAGE plpgsql AS $body$
+BEGIN
+  DELETE FROM orders WHERE archived;
+END;
+$body$;
+
+DO $$
+BEGIN
+  PERFORM 1;
+END
+$$;
+
+CREATE FUNCTION add(a int, b int) RETURNS int
+  AS 'SELECT a + b;'
+  LANGUAGE sql IMMUTABLE;
+`;
+
+      const results = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(
+        results.map((r) => [r.name, r.startLine, r.endLine, r.complexity]),
+        [
+          ["billing.apply_discount", 1, 6, 0],
+          ["Archive Orders", 10, 14, 0],
+          ["<do block 1>", 16, 20, 0],
+          ["add", 22, 24, 0],
+        ]
+      );
+    });
+
+    test("should skip routines in other languages", () => {
+      const sourceCode = `CREATE FUNCTION py_max(a integer, b integer) RETURNS integer AS $$
+  if a > b:
+    return a
+  return b
+$$ LANGUAGE plpython3u;
+
+CREATE FUNCTION c_add(integer, integer) RETURNS integer
+  AS 'funcs', 'c_add'
+  LANGUAGE C STRICT;
+
+CREATE TABLE IF NOT EXISTS audit (id int);
+`;
+
+      assert.deepStrictEqual(analyzer.analyzeFunctions(sourceCode), []);
+    });
+  });
+
+  suite("Control Flow", () => {
+    test("should score IF, ELSIF, and ELSE with nesting", () => {
+      const sourceCode = `CREATE FUNCTION grade(score int) RETURNS text AS $$
+BEGIN
+  IF score >= 90 THEN
+    RETURN 'A';
+  ELSIF score >= 80 THEN
+    IF score >= 85 THEN
+      RETURN 'B+';
+    END IF;
+    RETURN 'B';
+  ELSE
+    RETURN 'C';
+  END IF;
+END;
+$$ LANGUAGE plpgsql;`;
+
+      const [result] = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(
+        result.details.map((d) => [d.reason, d.increment, d.line, d.column]),
+        [
+          ["if statement", 1, 2, 2],
+          ["elsif clause", 1, 4, 2],
+          ["if statement", 2, 5, 4],
+          ["else clause", 1, 9, 2],
+        ]
+      );
+      assert.strictEqual(result.complexity, 5);
+    });
+
+    test("should count each loop once, including its header's LOOP", () => {
+      const sourceCode = `CREATE PROCEDURE process() AS $$
+DECLARE
+  r record;
+  i int := 0;
+BEGIN
+  <<outer>>
+  FOR r IN SELECT * FROM jobs LOOP
+    WHILE i < r.retries LOOP
+      i := i + 1;
+      EXIT outer WHEN i > 10;
+    END LOOP;
+  END LOOP outer;
+  LOOP
+    CONTINUE WHEN i % 2 = 0;
+    EXIT;
+  END LOOP;
+  FOREACH i IN ARRAY ARRAY[1, 2] LOOP
+    PERFORM i;
+  END LOOP;
+END;
+$$ LANGUAGE plpgsql;`;
+
+      const [result] = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(
+        result.details.map((d) => [d.reason, d.increment, d.nesting]),
+        [
+          ["for loop", 1, 0],
+          ["while loop", 2, 1],
+          ["exit when", 1, 2],
+          ["loop", 1, 0],
+          ["continue when", 1, 1],
+          ["foreach loop", 1, 0],
+        ]
+      );
+    });
+
+    test("should score CASE branches and exception handlers", () => {
+      const sourceCode = `CREATE FUNCTION safe_label(code int) RETURNS text AS $$
+DECLARE
+  label text;
+BEGIN
+  label := CASE code WHEN 1 THEN 'one' WHEN 2 THEN 'two' ELSE 'many' END;
+  CASE
+    WHEN code < 0 THEN
+      RAISE EXCEPTION 'negative code %', code;
+    ELSE
+      NULL;
+  END CASE;
+  RETURN label;
+EXCEPTION
+  WHEN division_by_zero THEN
+    RETURN NULL;
+  WHEN OTHERS THEN
+    IF code IS NULL THEN
+      RETURN 'unknown';
+    END IF;
+    RAISE;
+END;
+$$ LANGUAGE plpgsql;`;
+
+      const [result] = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(
+        result.details.map((d) => [d.reason, d.increment, d.line]),
+        [
+          ["when clause", 1, 4],
+          ["when clause", 1, 4],
+          ["when clause", 1, 6],
+          ["exception handler", 1, 13],
+          ["exception handler", 1, 15],
+          ["if statement", 2, 16],
+        ]
+      );
+      assert.strictEqual(result.complexity, 7);
+    });
+
+    test("should count sequences of AND and OR but not BETWEEN", () => {
+      const sourceCode = `DO $$
+BEGIN
+  IF a AND b AND (c OR d) OR x BETWEEN 1 AND 5 THEN
+    PERFORM 1;
+  END IF;
+END
+$$;`;
+
+      const [result] = analyzer.analyzeFunctions(sourceCode);
+
+      assert.deepStrictEqual(
+        result.details.map((d) => d.reason),
+        ["if statement", "logical AND operator", "logical OR operator", "logical OR operator"]
+      );
+      assert.strictEqual(result.complexity, 4);
+    });
+  });
+
+  suite("Lexing", () => {
+    test("should ignore keywords in comments, strings, and SQL statements", () => {
+      const sourceCode = `CREATE FUNCTION cleanup() RETURNS void AS $$
+BEGIN
+  -- IF this were code it would count
+  /* LOOP /* nested */ WHILE */
+  DROP TABLE IF EXISTS scratch;
+  RAISE NOTICE 'IF % LOOP', E'it\\'s WHEN';
+  EXECUTE $q$ SELECT CASE WHEN true THEN 1 END $q$;
+END;
+$$ LANGUAGE plpgsql;`;
+
+      const [result] = analyzer.analyzeFunctions(sourceCode);
+
+      assert.strictEqual(result.name, "cleanup");
+      assert.deepStrictEqual(result.details, []);
+    });
+  });
+});
@@ -311,6 +311,52 @@ function abs(n: number): number {
     });
   });
 
+  suite("Expression Nesting", () => {
+    test("should count a ternary inside a ternary's branch as nested", () => {
+      const sourceCode = `
+function label(a: boolean, b: boolean): string {
+  return a ? (b ? "both" : "a") : "none";
+}
+`;
+      const results = TypeScriptMetricsAnalyzer.analyzeFile(sourceCode);
+
+      assert.strictEqual(results[0].maxExpressionNesting, 2);
+    });
+
+    test("should count a chain of ternaries in the alternative once", () => {
+      const sourceCode = `
+function grade(score: number): string {
+  return score > 90 ? "A" : score > 80 ? "B" : score > 70 ? "C" : "D";
+}
+`;
+      const results = TypeScriptMetricsAnalyzer.analyzeFile(sourceCode);
+
+      assert.strictEqual(results[0].maxExpressionNesting, 1);
+    });
+
+    test("should count a logical group inside another operator", () => {
+      const sourceCode = `
+function check(a: boolean, b: boolean, c: boolean): boolean {
+  return a && b && c || b;
+}
+`;
+      const results = TypeScriptMetricsAnalyzer.analyzeFile(sourceCode);
+
+      assert.strictEqual(results[0].maxExpressionNesting, 2);
+    });
+
+    test("should not nest a callback's conditions in the expression around it", () => {
+      const sourceCode = `
+function ready(items: { a: boolean; b: boolean }[], started: boolean): boolean {
+  return items.some((x) => x.a || x.b) && started;
+}
+`;
+      const results = TypeScriptMetricsAnalyzer.analyzeFile(sourceCode);
+
+      assert.strictEqual(results[0].maxExpressionNesting, 1);
+    });
+  });
+
   suite("Labeled Statements", () => {
     test("should count labeled break statement", () => {
       const sourceCode = `
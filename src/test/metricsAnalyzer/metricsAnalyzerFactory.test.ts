@@ -1,4 +1,6 @@
 import * as assert from "assert";
+import * as fs from "fs";
+import * as path from "path";
 import {
   MetricsAnalyzerFactory,
   UnifiedMetricsDetail,
@@ -207,6 +209,52 @@ suite("Metrics Analyzer Factory Tests", () => {
     });
   });
 
+  suite("Custom Analyzers", () => {
+    const analyzer = {
+      analyzeFile: (sourceText: string) => [
+        {
+          name: "main",
+          complexity: sourceText.split("IF").length - 1,
+          details: [{ increment: 1, reason: "IF", line: 0, column: 4, nesting: 0 }],
+          startLine: 0,
+          endLine: 1,
+          startColumn: 0,
+          endColumn: 3,
+        },
+      ],
+    };
+
+    test("should analyze a registered language until it is unregistered", () => {
+      const unregister = MetricsAnalyzerFactory.registerAnalyzer("toylang", analyzer, ["toy"]);
+      try {
+        assert.ok(MetricsAnalyzerFactory.isSupportedLanguage("toylang"));
+        assert.strictEqual(MetricsAnalyzerFactory.getLanguageIdForFile("src/a.TOY"), "toylang");
+
+        const results = MetricsAnalyzerFactory.analyzeFile("IF a IF b\nEND", "toylang");
+        assert.strictEqual(results[0].complexity, 2);
+        // Positions are normalized like built-in languages, and post-processing applies.
+        assert.strictEqual(results[0].details[0].line, 1);
+        assert.strictEqual(results[0].details[0].column, 5);
+        assert.strictEqual(results[0].logicalLines, 2);
+      } finally {
+        unregister();
+      }
+
+      assert.ok(!MetricsAnalyzerFactory.isSupportedLanguage("toylang"));
+      assert.strictEqual(MetricsAnalyzerFactory.getLanguageIdForFile("a.toy"), undefined);
+      assert.deepStrictEqual(MetricsAnalyzerFactory.analyzeFile("IF", "toylang"), []);
+    });
+
+    test("should not replace built-in languages or extensions", () => {
+      assert.throws(() => MetricsAnalyzerFactory.registerAnalyzer("go", analyzer), /already registered/);
+      assert.throws(
+        () => MetricsAnalyzerFactory.registerAnalyzer("toylang", analyzer, [".py"]),
+        /already handled by "python"/
+      );
+      assert.ok(!MetricsAnalyzerFactory.isSupportedLanguage("toylang"));
+    });
+  });
+
   suite("Line and Column Normalization", () => {
     test("should normalize line numbers to 1-based indexing", () => {
       const sourceCode = `public class Test {
@@ -534,6 +582,33 @@ func Add(a, b int) int {
       assert.strictEqual(results[0].details.length, 0);
     });
 
+    test("should add the complexity base to every function", () => {
+      const sourceCode = `
+package main
+
+func Add(a, b int) int {
+    return a + b
+}
+
+func Max(a, b int) int {
+    if a > b {
+        return a
+    }
+    return b
+}
+`;
+
+      const results = MetricsAnalyzerFactory.analyzeFile(sourceCode, "go", { complexityBase: 1 });
+
+      assert.deepStrictEqual(
+        results.map((r) => [r.name, r.complexity]),
+        [["Add", 1], ["Max", 2]]
+      );
+      // The details still list only the increments
+      assert.deepStrictEqual(results[1].details.map((d) => d.increment), [1]);
+      assert.strictEqual(MetricsAnalyzerFactory.analyzeFile(sourceCode, "go")[0].complexity, 0);
+    });
+
     test("should analyze Go function with complexity", () => {
       const sourceCode = `
 package main
@@ -768,6 +843,49 @@ func SafeOperation() {
       );
       assert.ok(hasRecover, "Should detect recover call");
     });
+
+    test("should pass closure mode through and cache results per options", () => {
+      const sourceCode = `
+package main
+
+func Run(items []int) {
+    apply(items, func(v int) {
+        if v > 0 {
+            println(v)
+        }
+    })
+}
+`;
+
+      const inline = MetricsAnalyzerFactory.analyzeFile(sourceCode, "go");
+      const separate = MetricsAnalyzerFactory.analyzeFile(sourceCode, "go", {
+        closureMode: "separate",
+      });
+
+      assert.deepStrictEqual(inline.map((f) => f.name), ["Run"]);
+      assert.deepStrictEqual(separate.map((f) => f.name), ["Run", "Run.func1"]);
+      assert.strictEqual(separate[1].complexity, 1);
+      // Closure details are normalized to 1-based lines like any other entry
+      assert.strictEqual(separate[1].details[0].line, 6);
+    });
+
+    test("should only collect node counts when requested, caching each variant", () => {
+      const sourceCode = `
+package main
+
+func Flat() int {
+    return 1
+}
+`;
+
+      const plain = MetricsAnalyzerFactory.analyzeFile(sourceCode, "go");
+      const counted = MetricsAnalyzerFactory.analyzeFile(sourceCode, "go", {
+        collectNodeCounts: true,
+      });
+
+      assert.strictEqual(plain[0].nodeCounts, undefined);
+      assert.strictEqual(counted[0].nodeCounts?.return_statement, 1);
+    });
   });
 
   suite("Integration with Real-World Go Code", () => {
@@ -852,6 +970,93 @@ func IsComplexCondition(value int, flag1, flag2 bool) bool {
     });
   });
 
+  suite("Go Compiler Directives", () => {
+    const samples = path.resolve(__dirname, "../../../samples/directives");
+    const analyzeSample = (relativePath: string) =>
+      MetricsAnalyzerFactory.analyzeFile(
+        fs.readFileSync(path.join(samples, relativePath), "utf-8"),
+        "go"
+      );
+
+    test("should measure files with directives like their directive-free copy", () => {
+      // Positions differ by the removed lines, so compare everything else.
+      const summarize = (results: ReturnType<typeof analyzeSample>) =>
+        results.map((func) => ({
+          name: func.name,
+          complexity: func.complexity,
+          logicalLines: func.logicalLines,
+          returnCount: func.returnCount,
+          parameterCount: func.parameterCount,
+          generated: func.generated,
+          details: func.details.map((d) => `${d.reason}:${d.increment}:${d.nesting}`),
+        }));
+
+      const withDirectives = analyzeSample("directives.go");
+      assert.deepStrictEqual(
+        summarize(withDirectives),
+        summarize(analyzeSample("plain/directives.go"))
+      );
+      assert.deepStrictEqual(
+        withDirectives.map((func) => func.name),
+        ["Clamp", "Process", "Level.Valid"]
+      );
+    });
+
+    test("should count directive lines as comments, not code or decision points", () => {
+      const clamp = analyzeSample("directives.go").find((func) => func.name === "Clamp");
+
+      // if(1) + if(1) + &&(1); the //line and //nolint lines in the body are not code
+      assert.strictEqual(clamp?.complexity, 3);
+      assert.strictEqual(clamp?.logicalLines, 9);
+      assert.strictEqual(clamp?.generated, undefined);
+    });
+
+    test("should keep physical positions in files with //line directives", () => {
+      const results = analyzeSample("linedirective.go");
+
+      // The directives remap yyLex to calc.y:120 and so on; positions stay in this file.
+      assert.deepStrictEqual(
+        results.map((func) => [func.name, func.startLine, func.endLine]),
+        [
+          ["yyLex", 24, 32],
+          ["yyTokname", 35, 41],
+          ["yyParse", 44, 57],
+        ]
+      );
+      // Details are 1-based: the for (and its &&) and the if of yyLex, the if of yyTokname,
+      // and the for and switch of yyParse, whose cases carry a directive.
+      const detailLines = (func: (typeof results)[number]) => [
+        ...new Set(func.details.map((d) => d.line)),
+      ];
+      assert.deepStrictEqual(detailLines(results[0]), [26, 29]);
+      assert.deepStrictEqual(detailLines(results[1]), [37]);
+      assert.deepStrictEqual(detailLines(results[2]), [46, 47]);
+      assert.ok(results.every((func) => func.generated));
+    });
+
+    test("should measure files with go:embed variables like their directive-free copy", () => {
+      const summarize = (results: ReturnType<typeof analyzeSample>) =>
+        results.map((func) => ({
+          name: func.name,
+          complexity: func.complexity,
+          logicalLines: func.logicalLines,
+          details: func.details.map((d) => `${d.reason}:${d.increment}:${d.nesting}`),
+        }));
+
+      const withEmbeds = analyzeSample("embed.go");
+      assert.deepStrictEqual(summarize(withEmbeds), summarize(analyzeSample("plain/embed.go")));
+      assert.deepStrictEqual(
+        withEmbeds.map((func) => [func.name, func.complexity, func.logicalLines, func.startLine]),
+        [
+          ["Version", 1, 7, 25],
+          // if(1) + ||(1)
+          ["Banner", 2, 6, 36],
+          ["Template", 1, 7, 44],
+        ]
+      );
+    });
+  });
+
   suite("createAnalyzer Runtime Guard", () => {
     test("should throw a descriptive error when module does not export the expected class", () => {
       // Use a valid module path but a class name that does not exist in it
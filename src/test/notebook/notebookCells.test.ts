@@ -0,0 +1,107 @@
+import * as assert from "assert";
+import {
+  NotebookCellSource,
+  analyzeNotebookCells,
+  formatNotebookReport,
+  stripCellMagics,
+} from "../../notebook/notebookCells";
+import { DEFAULT_CONFIG } from "../../configuration";
+
+suite("Notebook Cell Tests", () => {
+  const cells: NotebookCellSource[] = [
+    { index: 0, kind: "markup", languageId: "markdown", text: "# Cleaning\n\nif this were code" },
+    {
+      index: 1,
+      kind: "code",
+      languageId: "python",
+      text: [
+        "%matplotlib inline",
+        "import pandas as pd",
+        "",
+        "def clean(df):",
+        "    if df is None:",
+        "        return None",
+        "    return df.dropna()",
+        "",
+        'for name in ["a", "b"]:',
+        "    if name:",
+        "        print(name)",
+      ].join("\n"),
+    },
+    { index: 2, kind: "code", languageId: "r", text: "if (x) print(x)" },
+    { index: 3, kind: "code", languageId: "python", text: "" },
+    {
+      index: 4,
+      kind: "code",
+      languageId: "python",
+      text: [
+        "!pip install numpy",
+        "class Model:",
+        "    def fit(self, x):",
+        "        while x:",
+        "            x -= 1",
+      ].join("\n"),
+    },
+  ];
+
+  suite("Cell Analysis", () => {
+    test("should analyze Python code cells and skip markdown and other languages", () => {
+      const results = analyzeNotebookCells(cells);
+
+      assert.deepStrictEqual(
+        results.map((cell) => cell.index),
+        [1, 3, 4]
+      );
+    });
+
+    test("should attribute top-level code and functions to their cell", () => {
+      const [first, empty, model] = analyzeNotebookCells(cells);
+
+      // for(1) + nested if(2); clean's if belongs to clean
+      assert.strictEqual(first.topLevelComplexity, 3);
+      assert.deepStrictEqual(
+        first.functions.map((f) => [f.name, f.complexity, f.startLine, f.endLine, f.startColumn]),
+        [["clean", 1, 3, 6, 0]]
+      );
+      assert.strictEqual(first.functions[0].details[0].line, 5);
+
+      assert.strictEqual(empty.topLevelComplexity, 0);
+      assert.deepStrictEqual(empty.functions, []);
+
+      assert.deepStrictEqual(
+        model.functions.map((f) => [f.name, f.complexity, f.startLine]),
+        [["Model.fit", 1, 2]]
+      );
+    });
+
+    test("should blank IPython magics without moving lines", () => {
+      assert.strictEqual(
+        stripCellMagics("%%time\nx = 1\n  !ls\nprint(x % 2)"),
+        "\nx = 1\n\nprint(x % 2)"
+      );
+    });
+  });
+
+  suite("Report Formatting", () => {
+    test("should list each cell with its functions, numbered from 1", () => {
+      const lines = formatNotebookReport("analysis.ipynb", analyzeNotebookCells(cells), DEFAULT_CONFIG);
+
+      assert.deepStrictEqual(lines, [
+        "Notebook: analysis.ipynb",
+        "  3 code cells, 2 functions",
+        "  Cell 2  🟢 top level 3, 1 functions",
+        "    🟢   1  clean (line 4)",
+        "  Cell 4  🟢 top level 0, 0 functions",
+        "  Cell 5  🟢 top level 0, 1 functions",
+        "    🟢   1  Model.fit (line 3)",
+      ]);
+    });
+
+    test("should report notebooks without Python code cells", () => {
+      assert.deepStrictEqual(formatNotebookReport("notes.ipynb", [], DEFAULT_CONFIG), [
+        "Notebook: notes.ipynb",
+        "  No Python code cells found.",
+      ]);
+    });
+  });
+});
@@ -1,7 +1,14 @@
 import * as assert from "assert";
+import * as fs from "fs";
+import * as path from "path";
 import * as vscode from "vscode";
-import { MetricsCodeLensProvider } from "../../providers/codeLensProvider";
-import { ConfigurationManager } from "../../configuration";
+import {
+  MetricsCodeLensProvider,
+  formatCodeLensLabel,
+  mergeLineRanges,
+  subtractLineRanges,
+} from "../../providers/codeLensProvider";
+import { ConfigurationManager, DEFAULT_CONFIG } from "../../configuration";
 import {
   MetricsAnalyzerFactory,
   UnifiedFunctionMetrics,
@@ -33,6 +40,7 @@ suite("Metrics Code Lens Provider Tests", () => {
       // Test that the provider respects the configuration
       const originalGetConfiguration = ConfigurationManager.getConfiguration;
       ConfigurationManager.getConfiguration = () => ({
+        ...DEFAULT_CONFIG,
         enabled: false,
         showCodeLens: true,
         warningThreshold: 10,
@@ -73,6 +81,7 @@ suite("Metrics Code Lens Provider Tests", () => {
         ConfigurationManager.getComplexityStatus;
 
       ConfigurationManager.getConfiguration = () => ({
+        ...DEFAULT_CONFIG,
         enabled: true,
         showCodeLens: true,
         warningThreshold: 1,
@@ -795,6 +804,7 @@ suite("Metrics Code Lens Provider Tests", () => {
       const originalGetConfiguration = ConfigurationManager.getConfiguration;
       const originalAnalyzeFile = MetricsAnalyzerFactory.analyzeFile;
       ConfigurationManager.getConfiguration = () => ({
+        ...DEFAULT_CONFIG,
         enabled: true,
         showCodeLens: true,
         warningThreshold: 10,
@@ -826,6 +836,598 @@ suite("Metrics Code Lens Provider Tests", () => {
     });
   });
 
+  suite("Analysis Trigger", () => {
+    const original = `
+package main
+
+func Check(a bool) int {
+    if a {
+        return 1
+    }
+    return 0
+}
+`;
+    const edited = `
+package main
+
+func Check(a, b bool) int {
+    if a {
+        if b {
+            return 2
+        }
+        return 1
+    }
+    return 0
+}
+`;
+
+    function withTrigger(trigger: "onChange" | "onSave" | "manual"): () => void {
+      const originalGetConfiguration = ConfigurationManager.getConfiguration;
+      ConfigurationManager.getConfiguration = () => ({
+        ...DEFAULT_CONFIG,
+        excludePatterns: [],
+        analysisTrigger: trigger,
+      });
+      return () => {
+        ConfigurationManager.getConfiguration = originalGetConfiguration;
+      };
+    }
+
+    function editDocument(path: string): vscode.TextDocument {
+      return { ...createMockDocument("go", edited, path), version: 2 } as vscode.TextDocument;
+    }
+
+    test("should re-analyze every change in onChange mode", async () => {
+      const restore = withTrigger("onChange");
+      try {
+        await provider.provideCodeLenses(
+          createMockDocument("go", original, "/test/change.go"),
+          mockToken
+        );
+        const result = await provider.provideCodeLenses(editDocument("/test/change.go"), mockToken);
+
+        assert.ok(result[0].command?.title.includes("(3)"));
+      } finally {
+        restore();
+      }
+    });
+
+    test("should keep the last results while editing in onSave mode", async () => {
+      const restore = withTrigger("onSave");
+      try {
+        const first = await provider.provideCodeLenses(
+          createMockDocument("go", original, "/test/save.go"),
+          mockToken
+        );
+        const whileEditing = await provider.provideCodeLenses(editDocument("/test/save.go"), mockToken);
+
+        assert.ok(first[0].command?.title.includes("(1)"));
+        assert.ok(whileEditing[0].command?.title.includes("(1)"));
+
+        provider.analyzeNow(editDocument("/test/save.go"));
+        const afterSave = await provider.provideCodeLenses(editDocument("/test/save.go"), mockToken);
+        assert.ok(afterSave[0].command?.title.includes("(3)"));
+      } finally {
+        restore();
+      }
+    });
+
+    test("should show nothing until analysis is requested in manual mode", async () => {
+      const restore = withTrigger("manual");
+      try {
+        const document = createMockDocument("go", original, "/test/manual.go");
+
+        assert.deepStrictEqual(await provider.provideCodeLenses(document, mockToken), []);
+
+        provider.analyzeNow(document);
+        const result = await provider.provideCodeLenses(document, mockToken);
+        assert.ok(result[0].command?.title.includes("(1)"));
+      } finally {
+        restore();
+      }
+    });
+  });
+
+  suite("Large Files", () => {
+    const sourceCode = `
+package main
+
+func Check(a bool) int {
+    if a {
+        return 1
+    }
+    return 0
+}
+`;
+
+    test("should analyze large files in the background", async () => {
+      const document = createMockDocument("go", sourceCode, "/test/large.go");
+      const originalGetConfiguration = ConfigurationManager.getConfiguration;
+      ConfigurationManager.getConfiguration = () => ({
+        ...DEFAULT_CONFIG,
+        excludePatterns: [],
+        largeFileThreshold: 5,
+      });
+      try {
+        const placeholder = await provider.provideCodeLenses(document, mockToken);
+        assert.strictEqual(placeholder.length, 1);
+        assert.ok(placeholder[0].command?.title.includes("Analyzing"));
+
+        await provider.waitForPendingAnalysis();
+        const result = await provider.provideCodeLenses(document, mockToken);
+        assert.strictEqual(result.length, 1);
+        assert.ok(result[0].command?.title.includes("(1)"));
+      } finally {
+        ConfigurationManager.getConfiguration = originalGetConfiguration;
+      }
+    });
+
+    test("should skip large files until analysis is requested", async () => {
+      const document = createMockDocument("go", sourceCode, "/test/skipped.go");
+      const originalGetConfiguration = ConfigurationManager.getConfiguration;
+      ConfigurationManager.getConfiguration = () => ({
+        ...DEFAULT_CONFIG,
+        excludePatterns: [],
+        largeFileThreshold: 5,
+        skipLargeFiles: true,
+      });
+      try {
+        const skipped = await provider.provideCodeLenses(document, mockToken);
+        assert.strictEqual(skipped.length, 1);
+        assert.strictEqual(skipped[0].command?.command, "codeMetrics.analyzeCurrentFile");
+
+        provider.analyzeNow(document);
+        const result = await provider.provideCodeLenses(document, mockToken);
+        assert.ok(result[0].command?.title.includes("(1)"));
+      } finally {
+        ConfigurationManager.getConfiguration = originalGetConfiguration;
+      }
+    });
+
+    test("should analyze only the top of a large file with lazy analysis", async () => {
+      const lazySource =
+        sourceCode + "\n".repeat(600) + "func Far(a bool) int {\n    if a {\n        return 1\n    }\n    return 0\n}\n";
+      const document = createMockDocument("go", lazySource, "/test/lazy.go");
+      const originalGetConfiguration = ConfigurationManager.getConfiguration;
+      ConfigurationManager.getConfiguration = () => ({
+        ...DEFAULT_CONFIG,
+        excludePatterns: [],
+        largeFileThreshold: 5,
+        lazyAnalysis: true,
+      });
+      try {
+        // No editor shows the mock document, so only lines near the top are analyzed.
+        const result = await provider.provideCodeLenses(document, mockToken);
+
+        assert.deepStrictEqual(
+          result.map((lens) => lens.command?.arguments?.[0].name),
+          ["Check"]
+        );
+      } finally {
+        ConfigurationManager.getConfiguration = originalGetConfiguration;
+      }
+    });
+
+    test("should merge line ranges and find the uncovered lines", () => {
+      const covered = mergeLineRanges([
+        { start: 10, end: 20 },
+        { start: 0, end: 5 },
+        { start: 6, end: 8 },
+      ]);
+
+      assert.deepStrictEqual(covered, [
+        { start: 0, end: 8 },
+        { start: 10, end: 20 },
+      ]);
+      assert.deepStrictEqual(subtractLineRanges([{ start: 0, end: 30 }], covered), [
+        { start: 9, end: 9 },
+        { start: 21, end: 30 },
+      ]);
+      assert.deepStrictEqual(subtractLineRanges([{ start: 12, end: 18 }], covered), []);
+    });
+
+    test("should analyze files at the threshold inline", async () => {
+      const document = createMockDocument("go", sourceCode, "/test/small.go");
+      const originalGetConfiguration = ConfigurationManager.getConfiguration;
+      ConfigurationManager.getConfiguration = () => ({
+        ...DEFAULT_CONFIG,
+        excludePatterns: [],
+        largeFileThreshold: document.lineCount,
+      });
+      try {
+        const result = await provider.provideCodeLenses(document, mockToken);
+
+        assert.ok(result[0].command?.title.includes("(1)"));
+      } finally {
+        ConfigurationManager.getConfiguration = originalGetConfiguration;
+      }
+    });
+  });
+
+  suite("Metric Warnings", () => {
+    test("should add a warning lens when a method accesses too many receiver fields", async () => {
+      const sourceCode = `
+package main
+
+type Service struct {
+    a, b, c int
+}
+
+func (s *Service) Sum() int {
+    return s.a + s.b + s.c
+}
+`;
+      const document = createMockDocument("go", sourceCode, "/test/fields.go");
+
+      const originalGetConfiguration = ConfigurationManager.getConfiguration;
+      ConfigurationManager.getConfiguration = () => ({
+        ...DEFAULT_CONFIG,
+        excludePatterns: [],
+        fieldAccessThreshold: 2,
+      });
+      try {
+        const result = await provider.provideCodeLenses(document, mockToken);
+
+        // Sum has zero complexity, so the only lens is the field access warning.
+        assert.strictEqual(result.length, 1);
+        assert.ok(result[0].command?.title.includes("3 receiver fields"));
+      } finally {
+        ConfigurationManager.getConfiguration = originalGetConfiguration;
+      }
+    });
+
+    test("should suppress functions matching an excluded name pattern", async () => {
+      const sourceCode = `
+package main
+
+type User struct {
+    Name string
+}
+
+func (u User) String() string {
+    if u.Name == "" {
+        return "?"
+    }
+    return u.Name
+}
+
+func (u User) MarshalJSON() ([]byte, error) {
+    if u.Name == "" {
+        return []byte("null"), nil
+    }
+    return []byte(u.Name), nil
+}
+
+func Validate(u User) bool {
+    if u.Name == "" {
+        return false
+    }
+    return true
+}
+`;
+      const document = createMockDocument("go", sourceCode, "/test/user.go");
+
+      const originalGetConfiguration = ConfigurationManager.getConfiguration;
+      ConfigurationManager.getConfiguration = () => ({
+        ...DEFAULT_CONFIG,
+        excludePatterns: [],
+        excludeFunctionPatterns: ["\\.(String|MarshalJSON)$"],
+      });
+      try {
+        const result = await provider.provideCodeLenses(document, mockToken);
+
+        assert.strictEqual(result.length, 1);
+        assert.strictEqual(result[0].range.start.line, 21);
+      } finally {
+        ConfigurationManager.getConfiguration = originalGetConfiguration;
+      }
+    });
+
+    test("should anchor lenses on the physical lines of files with //line directives", async () => {
+      const sourceCode = fs.readFileSync(
+        path.resolve(__dirname, "../../../samples/directives/linedirective.go"),
+        "utf-8"
+      );
+      const document = createMockDocument("go", sourceCode, "/test/calc.go");
+      const funcLines = sourceCode
+        .split("\n")
+        .flatMap((line, index) => (line.startsWith("func ") ? [index] : []));
+
+      const originalGetConfiguration = ConfigurationManager.getConfiguration;
+      ConfigurationManager.getConfiguration = () => ({
+        ...DEFAULT_CONFIG,
+        excludePatterns: [],
+        excludeGenerated: false,
+      });
+      try {
+        const result = await provider.provideCodeLenses(document, mockToken);
+
+        assert.deepStrictEqual(funcLines, [24, 35, 44]);
+        assert.deepStrictEqual(
+          [...new Set(result.map((lens) => lens.range.start.line))],
+          funcLines
+        );
+      } finally {
+        ConfigurationManager.getConfiguration = originalGetConfiguration;
+      }
+    });
+
+    test("should show only the band's dot in badge style, with the label on hover", async () => {
+      const sourceCode = `
+package main
+
+func Check(a, b bool) int {
+    if a {
+        if b {
+            return 1
+        }
+    }
+    return 0
+}
+`;
+      const document = createMockDocument("go", sourceCode, "/test/badge.go");
+
+      const originalGetConfiguration = ConfigurationManager.getConfiguration;
+      ConfigurationManager.getConfiguration = () => ({
+        ...DEFAULT_CONFIG,
+        excludePatterns: [],
+        displayStyle: "badge",
+      });
+      try {
+        const result = await provider.provideCodeLenses(document, mockToken);
+
+        assert.strictEqual(result.length, 1);
+        // if(1) + nested if(2) = 3, in the low band
+        assert.strictEqual(result[0].command?.title, "🟢");
+        assert.strictEqual(result[0].command?.tooltip, "Low Complexity (3)");
+        assert.strictEqual(result[0].command?.command, "cognitiveComplexity.showFunctionDetails");
+      } finally {
+        ConfigurationManager.getConfiguration = originalGetConfiguration;
+      }
+    });
+
+    test("should open the explanation panel on click when configured", async () => {
+      const sourceCode = `package main
+
+func Check(a bool) int {
+    if a {
+        return 1
+    }
+    return 0
+}
+`;
+      const document = createMockDocument("go", sourceCode, "/test/explain.go");
+
+      const originalGetConfiguration = ConfigurationManager.getConfiguration;
+      ConfigurationManager.getConfiguration = () => ({
+        ...DEFAULT_CONFIG,
+        excludePatterns: [],
+        codeLensAction: "explain",
+      });
+      try {
+        const result = await provider.provideCodeLenses(document, mockToken);
+
+        assert.strictEqual(result.length, 1);
+        assert.strictEqual(result[0].command?.command, "codeMetrics.explainFunction");
+        assert.strictEqual(result[0].command?.arguments?.[0].name, "Check");
+      } finally {
+        ConfigurationManager.getConfiguration = originalGetConfiguration;
+      }
+    });
+
+    test("should color the lens by nesting when it is the primary metric", async () => {
+      const sourceCode = `
+package main
+
+func Walk(items [][]int) int {
+    total := 0
+    for _, row := range items {
+        for _, v := range row {
+            if v > 0 {
+                total += v
+            }
+        }
+    }
+    return total
+}
+`;
+      const document = createMockDocument("go", sourceCode, "/test/nesting.go");
+
+      const originalGetConfiguration = ConfigurationManager.getConfiguration;
+      ConfigurationManager.getConfiguration = () => ({
+        ...DEFAULT_CONFIG,
+        excludePatterns: [],
+        primaryMetric: "nesting",
+        nestingWarningThreshold: 2,
+        nestingErrorThreshold: 4,
+      });
+      try {
+        const result = await provider.provideCodeLenses(document, mockToken);
+
+        assert.strictEqual(result.length, 1);
+        // for(1) + nested for(2) + nested if(3) = 6 is low, but the if sits at nesting 2
+        assert.strictEqual(result[0].command?.title, "🟡 Moderate Complexity (nesting 2)");
+      } finally {
+        ConfigurationManager.getConfiguration = originalGetConfiguration;
+      }
+    });
+
+    test("should render the label from a custom template", async () => {
+      const sourceCode = `package main
+
+func Check(a bool) int {
+    if a {
+        return 1
+    }
+    return 0
+}
+`;
+      const document = createMockDocument("go", sourceCode, "/test/template.go");
+
+      const originalGetConfiguration = ConfigurationManager.getConfiguration;
+      ConfigurationManager.getConfiguration = () => ({
+        ...DEFAULT_CONFIG,
+        excludePatterns: [],
+        codeLensTemplate: "Complejidad {value} · {name}",
+      });
+      try {
+        const result = await provider.provideCodeLenses(document, mockToken);
+
+        assert.strictEqual(result.length, 1);
+        assert.strictEqual(result[0].command?.title, "🟢 Complejidad 1 · Check");
+      } finally {
+        ConfigurationManager.getConfiguration = originalGetConfiguration;
+      }
+    });
+
+    test("should fill known template placeholders and keep unknown ones", () => {
+      assert.strictEqual(
+        formatCodeLensLabel("{status} ({score}) {unknown} {constructor}", {
+          status: "Low Complexity",
+          score: "nesting 2",
+        }),
+        "Low Complexity (nesting 2) {unknown} {constructor}"
+      );
+    });
+  });
+
+  suite("File Summary", () => {
+    const sourceCode = `
+package main
+
+func Short() int {
+    return 1
+}
+
+// Long has more logical lines, even though it is simple.
+func Long() int {
+    a := 1
+    // a comment-only line
+
+    b := 2
+    return a + b
+}
+`;
+
+    test("should add a lens navigating to the longest function when enabled", async () => {
+      const document = createMockDocument("go", sourceCode, "/test/summary.go");
+
+      const originalGetConfiguration = ConfigurationManager.getConfiguration;
+      ConfigurationManager.getConfiguration = () => ({
+        ...DEFAULT_CONFIG,
+        excludePatterns: [],
+        showFileSummary: true,
+      });
+      try {
+        const result = await provider.provideCodeLenses(document, mockToken);
+
+        // Both functions have zero complexity, so only the summary lens is shown.
+        assert.strictEqual(result.length, 1);
+        assert.strictEqual(result[0].range.start.line, 0);
+        assert.strictEqual(result[0].command?.title, "📏 Longest function: Long (5 lines)");
+        assert.strictEqual(result[0].command?.command, "codeMetrics.revealFunction");
+        assert.deepStrictEqual(result[0].command?.arguments, [document.uri, 8]);
+      } finally {
+        ConfigurationManager.getConfiguration = originalGetConfiguration;
+      }
+    });
+
+    test("should not add a summary lens by default", async () => {
+      const document = createMockDocument("go", sourceCode, "/test/summary-off.go");
+
+      const originalGetConfiguration = ConfigurationManager.getConfiguration;
+      ConfigurationManager.getConfiguration = () => ({
+        ...DEFAULT_CONFIG,
+        excludePatterns: [],
+      });
+      try {
+        const result = await provider.provideCodeLenses(document, mockToken);
+
+        assert.strictEqual(result.length, 0);
+      } finally {
+        ConfigurationManager.getConfiguration = originalGetConfiguration;
+      }
+    });
+  });
+
+  suite("CodeLens Limit", () => {
+    const sourceCode = `
+package main
+
+func One(a int) int {
+    if a > 0 {
+        return 1
+    }
+    return 0
+}
+
+func Three(a, b int) int {
+    if a > 0 {
+        if b > 0 {
+            return 1
+        }
+    }
+    return 0
+}
+
+func Two(a, b int) int {
+    if a > 0 && b > 0 {
+        return 1
+    }
+    return 0
+}
+`;
+
+    test("should show only the most complex functions above the limit", async () => {
+      const document = createMockDocument("go", sourceCode, "/test/limit.go");
+
+      const originalGetConfiguration = ConfigurationManager.getConfiguration;
+      ConfigurationManager.getConfiguration = () => ({
+        ...DEFAULT_CONFIG,
+        excludePatterns: [],
+        codeLensLimit: 2,
+      });
+      try {
+        const result = await provider.provideCodeLenses(document, mockToken);
+
+        assert.strictEqual(result.length, 3);
+        assert.strictEqual(result[0].range.start.line, 0);
+        assert.strictEqual(
+          result[0].command?.title,
+          "📉 CodeLens shows the 2 most complex of 3 functions — click to list all"
+        );
+        assert.strictEqual(result[0].command?.command, "codeMetrics.listFileFunctions");
+        assert.deepStrictEqual(result[0].command?.arguments, [document.uri]);
+        // Three (3) and Two (2) keep their lenses, in source order; One (1) is dropped.
+        assert.deepStrictEqual(
+          result.slice(1).map((lens) => lens.range.start.line),
+          [10, 19]
+        );
+      } finally {
+        ConfigurationManager.getConfiguration = originalGetConfiguration;
+      }
+    });
+
+    test("should show every function when the limit is 0", async () => {
+      const document = createMockDocument("go", sourceCode, "/test/no-limit.go");
+
+      const originalGetConfiguration = ConfigurationManager.getConfiguration;
+      ConfigurationManager.getConfiguration = () => ({
+        ...DEFAULT_CONFIG,
+        excludePatterns: [],
+        codeLensLimit: 0,
+      });
+      try {
+        const result = await provider.provideCodeLenses(document, mockToken);
+
+        assert.strictEqual(result.length, 3);
+        assert.ok(result.every((lens) => lens.command?.command === "cognitiveComplexity.showFunctionDetails"));
+      } finally {
+        ConfigurationManager.getConfiguration = originalGetConfiguration;
+      }
+    });
+  });
+
   suite("Code Lens Resolution", () => {
     test("should return code lens as-is in resolveCodeLens", async () => {
       const mockCodeLens = new vscode.CodeLens(new vscode.Range(0, 0, 0, 0));
@@ -0,0 +1,51 @@
+import * as assert from "assert";
+import { DEFAULT_CONFIG } from "../../configuration";
+import { UnifiedFunctionMetrics } from "../../metricsAnalyzer/metricsAnalyzerFactory";
+import { getComplexitySymbols } from "../../providers/complexitySymbolProvider";
+
+suite("Complexity Symbol Provider Tests", () => {
+  function fn(name: string, complexity: number, startLine: number, endLine: number): UnifiedFunctionMetrics {
+    return {
+      name,
+      complexity,
+      details: [],
+      startLine,
+      endLine,
+      startColumn: 0,
+      endColumn: 1,
+    };
+  }
+
+  test("should name symbols with the function's complexity and band", () => {
+    const symbols = getComplexitySymbols([fn("Save", 2, 0, 5), fn("Load", 12, 7, 30)], DEFAULT_CONFIG);
+
+    assert.deepStrictEqual(
+      symbols.map((s) => [s.name, s.detail, s.range.start.line, s.range.end.line]),
+      [
+        ["Save · 🟢 2", "Low Complexity", 0, 5],
+        ["Load · 🟡 12", "Moderate Complexity", 7, 30],
+      ]
+    );
+  });
+
+  test("should nest closures under the function containing them", () => {
+    const outer = fn("Outer", 3, 0, 20);
+    const inner = { ...fn("Outer.func1", 1, 4, 8), startColumn: 8, endColumn: 5 };
+
+    const symbols = getComplexitySymbols([inner, outer, fn("Next", 0, 22, 24)], DEFAULT_CONFIG);
+
+    assert.deepStrictEqual(symbols.map((s) => s.name), ["Outer · 🟢 3", "Next · 🟢 0"]);
+    assert.deepStrictEqual(symbols[0].children.map((s) => s.name), ["Outer.func1 · 🟢 1"]);
+  });
+
+  test("should show the nesting depth when it is the primary metric", () => {
+    const func = {
+      ...fn("Walk", 9, 0, 12),
+      details: [{ increment: 4, reason: "if statement", line: 5, column: 12, nesting: 3 }],
+    };
+
+    const [symbol] = getComplexitySymbols([func], { ...DEFAULT_CONFIG, primaryMetric: "nesting" });
+
+    assert.strictEqual(symbol.name, "Walk · 🟡 nesting 3");
+  });
+});
@@ -0,0 +1,366 @@
+import * as assert from "assert";
+import * as vscode from "vscode";
+import {
+  IgnoreComplexityCodeActionProvider,
+  MetricsDiagnosticsProvider,
+  violatesExpectation,
+} from "../../providers/diagnosticsProvider";
+
+suite("Metrics Diagnostics Provider Tests", () => {
+  let provider: MetricsDiagnosticsProvider;
+
+  setup(() => {
+    provider = new MetricsDiagnosticsProvider();
+  });
+
+  teardown(() => {
+    provider.dispose();
+  });
+
+  suite("Expectation Comparison", () => {
+    test("should treat <= as an inclusive upper bound", () => {
+      assert.strictEqual(violatesExpectation(8, { operator: "<=", value: 8 }), false);
+      assert.strictEqual(violatesExpectation(9, { operator: "<=", value: 8 }), true);
+    });
+
+    test("should treat < as an exclusive upper bound", () => {
+      assert.strictEqual(violatesExpectation(7, { operator: "<", value: 8 }), false);
+      assert.strictEqual(violatesExpectation(8, { operator: "<", value: 8 }), true);
+    });
+
+    test("should flag any drift from an exact expectation", () => {
+      assert.strictEqual(violatesExpectation(3, { operator: "==", value: 3 }), false);
+      assert.strictEqual(violatesExpectation(2, { operator: "==", value: 3 }), true);
+      assert.strictEqual(violatesExpectation(4, { operator: "==", value: 3 }), true);
+    });
+  });
+
+  suite("Document Diagnostics", () => {
+    test("should report a function exceeding its pinned expectation", async () => {
+      const document = await vscode.workspace.openTextDocument({
+        language: "go",
+        content: `package main
+
+//metrics:expect cc<=1
+func Tight(a, b bool) int {
+	if a {
+		if b {
+			return 2
+		}
+	}
+	return 0
+}
+
+//metrics:expect cc<=5
+func Loose(a bool) int {
+	if a {
+		return 1
+	}
+	return 0
+}
+`,
+      });
+
+      provider.updateDiagnostics(document);
+      const diagnostics = provider.getDiagnostics(document.uri);
+
+      assert.strictEqual(diagnostics.length, 1);
+      assert.ok(diagnostics[0].message.includes("Tight"));
+      assert.ok(diagnostics[0].message.includes("cc<=1"));
+      assert.strictEqual(diagnostics[0].range.start.line, 3);
+      assert.strictEqual(diagnostics[0].severity, vscode.DiagnosticSeverity.Warning);
+    });
+
+    test("should note the function dominating its file", async () => {
+      const document = await vscode.workspace.openTextDocument({
+        language: "go",
+        content: `package main
+
+func Small(a bool) int {
+	if a {
+		return 1
+	}
+	return 0
+}
+
+func Giant(a, b, c bool) int {
+	if a {
+		if b {
+			if c {
+				return 3
+			}
+		}
+	}
+	for a {
+		if b {
+			return 2
+		}
+	}
+	return 0
+}
+`,
+      });
+
+      provider.updateDiagnostics(document);
+      const diagnostics = provider.getDiagnostics(document.uri);
+
+      // Giant: 1 + 2 + 3 (nested ifs) + 1 + 2 (for, nested if) = 9 of 10.
+      assert.strictEqual(diagnostics.length, 1);
+      assert.strictEqual(diagnostics[0].code, "dominantFunction");
+      assert.ok(diagnostics[0].message.startsWith("Giant holds 90%"));
+      assert.strictEqual(diagnostics[0].range.start.line, 9);
+      assert.strictEqual(diagnostics[0].severity, vscode.DiagnosticSeverity.Information);
+    });
+
+    test("should report functions over the primary metric's thresholds when enabled", async () => {
+      const document = await vscode.workspace.openTextDocument({
+        language: "go",
+        content: `package main
+
+func Small(a bool) int {
+	if a {
+		return 1
+	}
+	return 0
+}
+
+func Giant(a, b, c bool) int {
+	if a {
+		if b {
+			if c {
+				return 3
+			}
+		}
+	}
+	return 0
+}
+`,
+      });
+      const thresholds = () => {
+        provider.updateDiagnostics(document);
+        return provider
+          .getDiagnostics(document.uri)
+          .filter((d) => d.code === "threshold")
+          .map((d) => [d.message, d.severity, d.range.start.line]);
+      };
+
+      const config = vscode.workspace.getConfiguration("codeMetrics");
+      assert.deepStrictEqual(thresholds(), []);
+      await config.update("display.thresholdDiagnostics", true, vscode.ConfigurationTarget.Global);
+      await config.update("warningThreshold", 1, vscode.ConfigurationTarget.Global);
+      await config.update("errorThreshold", 5, vscode.ConfigurationTarget.Global);
+      try {
+        assert.deepStrictEqual(thresholds(), [
+          ["Small has complexity 1 (warning threshold 1)", vscode.DiagnosticSeverity.Warning, 2],
+          ["Giant has complexity 6 (error threshold 5)", vscode.DiagnosticSeverity.Error, 9],
+        ]);
+
+        // The deepest if is at nesting level 2.
+        await config.update("display.primaryMetric", "nesting", vscode.ConfigurationTarget.Global);
+        await config.update("nestingWarningThreshold", 2, vscode.ConfigurationTarget.Global);
+        assert.deepStrictEqual(thresholds(), [
+          ["Giant nests 2 levels deep (warning threshold 2)", vscode.DiagnosticSeverity.Warning, 9],
+        ]);
+      } finally {
+        for (const key of [
+          "display.thresholdDiagnostics",
+          "warningThreshold",
+          "errorThreshold",
+          "display.primaryMetric",
+          "nestingWarningThreshold",
+        ]) {
+          await config.update(key, undefined, vscode.ConfigurationTarget.Global);
+        }
+      }
+    });
+
+    test("should flag a switch with more cases than the threshold where it starts", async () => {
+      const cases = Array.from({ length: 21 }, (_, i) => `\tcase ${i}:\n\t\treturn "${i}"\n`).join("");
+      const document = await vscode.workspace.openTextDocument({
+        language: "go",
+        content: `package main
+
+func Name(code int) string {
+	switch code {
+${cases}	default:
+		return "unknown"
+	}
+}
+`,
+      });
+
+      provider.updateDiagnostics(document);
+      const diagnostics = provider.getDiagnostics(document.uri);
+
+      // 21 cases and default are 22 clauses, above the default threshold of 20.
+      assert.strictEqual(diagnostics.length, 1);
+      assert.strictEqual(diagnostics[0].code, "longSwitch");
+      assert.ok(diagnostics[0].message.startsWith("This switch has 22 cases (threshold 20)"));
+      assert.ok(diagnostics[0].message.includes("map"));
+      assert.strictEqual(diagnostics[0].range.start.line, 3);
+      assert.strictEqual(diagnostics[0].range.start.character, 1);
+    });
+
+    test("should flag a long switch on the same line when the file is streamed", async () => {
+      const cases = Array.from({ length: 21 }, (_, i) => `\tcase ${i}:\n\t\treturn "${i}"\n`).join("");
+      const content = `package main
+
+func Other(a bool) int {
+	if a {
+		return 1
+	}
+	return 0
+}
+
+func Name(code int) string {
+	switch code {
+${cases}	default:
+		return "unknown"
+	}
+}
+`;
+      const lines = async () => {
+        const document = await vscode.workspace.openTextDocument({ language: "go", content });
+        provider.updateDiagnostics(document);
+        return provider
+          .getDiagnostics(document.uri)
+          .filter((d) => d.code === "longSwitch")
+          .map((d) => [d.range.start.line, d.range.start.character]);
+      };
+
+      const unstreamed = await lines();
+      const config = vscode.workspace.getConfiguration("codeMetrics");
+      await config.update("analysis.streamingThreshold", 1, vscode.ConfigurationTarget.Global);
+      try {
+        assert.deepStrictEqual(unstreamed, [[10, 1]]);
+        assert.deepStrictEqual(await lines(), unstreamed);
+      } finally {
+        await config.update("analysis.streamingThreshold", undefined, vscode.ConfigurationTarget.Global);
+      }
+    });
+
+    test("should not report functions marked metrics:ignore", async () => {
+      const document = await vscode.workspace.openTextDocument({
+        language: "go",
+        content: `package main
+
+//metrics:ignore
+//metrics:expect cc<=1
+func Tight(a, b bool) int {
+	if a {
+		if b {
+			return 2
+		}
+	}
+	return 0
+}
+`,
+      });
+
+      provider.updateDiagnostics(document);
+
+      assert.strictEqual(provider.getDiagnostics(document.uri).length, 0);
+    });
+
+    test("should publish no diagnostics for unsupported languages", async () => {
+      const document = await vscode.workspace.openTextDocument({
+        language: "plaintext",
+        content: "//metrics:expect cc<=0",
+      });
+
+      provider.updateDiagnostics(document);
+
+      assert.strictEqual(provider.getDiagnostics(document.uri).length, 0);
+    });
+
+    test("should clear diagnostics for a document", async () => {
+      const document = await vscode.workspace.openTextDocument({
+        language: "go",
+        content: `package main
+
+//metrics:expect cc==0
+func Branchy(a bool) int {
+	if a {
+		return 1
+	}
+	return 0
+}
+`,
+      });
+
+      provider.updateDiagnostics(document);
+      assert.strictEqual(provider.getDiagnostics(document.uri).length, 1);
+
+      provider.clearDiagnostics(document.uri);
+      assert.strictEqual(provider.getDiagnostics(document.uri).length, 0);
+    });
+  });
+
+  suite("Ignore Quick Fix", () => {
+    const content = `package main
+
+// Tight must stay flat.
+//metrics:expect cc<=1
+func Tight(a, b bool) int {
+	if a {
+		if b {
+			return 2
+		}
+	}
+	return 0
+}
+`;
+
+    test("should offer to ignore the reported function", async () => {
+      const document = await vscode.workspace.openTextDocument({ language: "go", content });
+      provider.updateDiagnostics(document);
+      const diagnostics = provider.getDiagnostics(document.uri);
+      assert.strictEqual(diagnostics.length, 1);
+
+      const actions = new IgnoreComplexityCodeActionProvider().provideCodeActions(
+        document,
+        diagnostics[0].range,
+        { diagnostics, only: undefined, triggerKind: vscode.CodeActionTriggerKind.Invoke }
+      );
+
+      assert.strictEqual(actions.length, 1);
+      assert.strictEqual(actions[0].title, "Ignore complexity for this function");
+      assert.deepStrictEqual(actions[0].diagnostics, diagnostics);
+      const [edit] = actions[0].edit!.get(document.uri);
+      // Below the doc comment, directly above the declaration.
+      assert.strictEqual(edit.range.start.line, 4);
+      assert.strictEqual(edit.newText, "//metrics:ignore\n");
+    });
+
+    test("should mute the diagnostic once applied", async () => {
+      const document = await vscode.workspace.openTextDocument({ language: "go", content });
+      provider.updateDiagnostics(document);
+      const diagnostics = provider.getDiagnostics(document.uri);
+
+      const [action] = new IgnoreComplexityCodeActionProvider().provideCodeActions(
+        document,
+        diagnostics[0].range,
+        { diagnostics, only: undefined, triggerKind: vscode.CodeActionTriggerKind.Invoke }
+      );
+      assert.ok(await vscode.workspace.applyEdit(action.edit!));
+      provider.updateDiagnostics(document);
+
+      assert.strictEqual(provider.getDiagnostics(document.uri).length, 0);
+      assert.strictEqual(document.lineAt(4).text, "//metrics:ignore");
+    });
+
+    test("should ignore diagnostics from other sources", async () => {
+      const document = await vscode.workspace.openTextDocument({ language: "go", content });
+      const foreign = new vscode.Diagnostic(document.lineAt(4).range, "unused parameter b");
+      foreign.source = "gopls";
+
+      const actions = new IgnoreComplexityCodeActionProvider().provideCodeActions(
+        document,
+        foreign.range,
+        { diagnostics: [foreign], only: undefined, triggerKind: vscode.CodeActionTriggerKind.Invoke }
+      );
+
+      assert.deepStrictEqual(actions, []);
+    });
+  });
+});
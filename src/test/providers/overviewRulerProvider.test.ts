@@ -0,0 +1,43 @@
+import * as assert from "assert";
+import { DEFAULT_CONFIG } from "../../configuration";
+import { UnifiedFunctionMetrics } from "../../metricsAnalyzer/metricsAnalyzerFactory";
+import { getOverviewRulerRanges } from "../../providers/overviewRulerProvider";
+
+suite("Overview Ruler Provider Tests", () => {
+  function fn(name: string, complexity: number, startLine: number): UnifiedFunctionMetrics {
+    return {
+      name,
+      complexity,
+      details: [],
+      startLine,
+      endLine: startLine + 5,
+      startColumn: 4,
+      endColumn: 1,
+    };
+  }
+
+  test("should group function headers by complexity band", () => {
+    const config = { ...DEFAULT_CONFIG, warningThreshold: 10, errorThreshold: 20 };
+    const functions = [fn("small", 2, 0), fn("medium", 12, 10), fn("large", 25, 30), fn("tiny", 0, 40)];
+
+    const ranges = getOverviewRulerRanges(functions, config);
+
+    assert.deepStrictEqual(ranges.low.map((r) => r.start.line), [0, 40]);
+    assert.deepStrictEqual(ranges.warning.map((r) => r.start.line), [10]);
+    assert.deepStrictEqual(ranges.error.map((r) => r.start.line), [30]);
+  });
+
+  test("should mark only the function's first line", () => {
+    const ranges = getOverviewRulerRanges([fn("large", 25, 7)], DEFAULT_CONFIG);
+
+    assert.strictEqual(ranges.error.length, 1);
+    assert.strictEqual(ranges.error[0].start.line, 7);
+    assert.strictEqual(ranges.error[0].end.line, 7);
+  });
+
+  test("should return no marks for a file without functions", () => {
+    const ranges = getOverviewRulerRanges([], DEFAULT_CONFIG);
+
+    assert.deepStrictEqual(ranges, { low: [], warning: [], error: [] });
+  });
+});
@@ -0,0 +1,48 @@
+import * as assert from "assert";
+import { DEFAULT_CONFIG } from "../../configuration";
+import { UnifiedFunctionMetrics } from "../../metricsAnalyzer/metricsAnalyzerFactory";
+import { findNewViolations, formatGuardrailMessage } from "../../providers/saveGuardrail";
+
+suite("Save Guardrail Tests", () => {
+  const config = { ...DEFAULT_CONFIG, warningThreshold: 15, errorThreshold: 25 };
+
+  function fn(name: string, complexity: number, startLine = 0): UnifiedFunctionMetrics {
+    return {
+      name,
+      complexity,
+      details: [],
+      startLine,
+      endLine: startLine + 5,
+      startColumn: 0,
+      endColumn: 1,
+    };
+  }
+
+  test("should report functions that are new or grew to the threshold", () => {
+    const previous = [fn("Checkout", 12), fn("Refund", 20), fn("Ship", 3)];
+    const current = [fn("Checkout", 15), fn("Refund", 22), fn("Ship", 3), fn("Retry", 18)];
+
+    assert.deepStrictEqual(
+      findNewViolations(previous, current, config).map((f) => f.name),
+      ["Retry", "Checkout"]
+    );
+  });
+
+  test("should report nothing when the violations were already there", () => {
+    const previous = [fn("Refund", 20)];
+
+    assert.deepStrictEqual(findNewViolations(previous, [fn("Refund", 30), fn("Ship", 14)], config), []);
+  });
+
+  test("should name each violation with its complexity and line", () => {
+    assert.strictEqual(
+      formatGuardrailMessage("orders.go", [fn("Checkout", 18, 41)], config),
+      "Saving orders.go added a function over the complexity threshold of 15: Checkout (complexity 18, line 42)."
+    );
+    assert.strictEqual(
+      formatGuardrailMessage("orders.go", [fn("Retry", 18, 9), fn("Checkout", 15, 41)], config),
+      "Saving orders.go added 2 functions over the complexity threshold of 15: " +
+        "Retry (complexity 18, line 10), Checkout (complexity 15, line 42)."
+    );
+  });
+});
@@ -0,0 +1,76 @@
+import * as assert from "assert";
+import { DEFAULT_CONFIG } from "../../configuration";
+import { UnifiedFunctionMetrics } from "../../metricsAnalyzer/metricsAnalyzerFactory";
+import { findNextHotspot, getStatusBadge } from "../../providers/statusBarProvider";
+
+suite("Status Bar Provider Tests", () => {
+  const config = { ...DEFAULT_CONFIG, warningThreshold: 10, errorThreshold: 20 };
+
+  function fn(name: string, complexity: number, startLine: number): UnifiedFunctionMetrics {
+    return {
+      name,
+      complexity,
+      details: [],
+      startLine,
+      endLine: startLine + 5,
+      startColumn: 0,
+      endColumn: 1,
+    };
+  }
+
+  test("should count functions at or over the warning threshold", () => {
+    const badge = getStatusBadge(
+      [fn("small", 2, 0), fn("medium", 10, 10), fn("large", 25, 30)],
+      config,
+      "orders.go"
+    );
+
+    assert.strictEqual(badge.count, 2);
+    assert.strictEqual(badge.errorCount, 1);
+    assert.strictEqual(badge.text, "$(warning) 2");
+    assert.strictEqual(
+      badge.tooltip,
+      "2 functions in orders.go over the complexity threshold (1 over the error threshold). " +
+        "Click to go to the next one."
+    );
+  });
+
+  test("should show a check mark for a file without violations", () => {
+    const badge = getStatusBadge([fn("small", 2, 0)], config, "orders.go");
+
+    assert.strictEqual(badge.count, 0);
+    assert.strictEqual(badge.text, "$(check) 0");
+    assert.strictEqual(badge.tooltip, "No function in orders.go is over the complexity threshold");
+  });
+
+  test("should count by the nesting thresholds when nesting is the primary metric", () => {
+    const deep = {
+      ...fn("deep", 4, 0),
+      details: [{ increment: 4, reason: "if statement", line: 5, column: 12, nesting: 3 }],
+    };
+    const nestingConfig = {
+      ...config,
+      primaryMetric: "nesting" as const,
+      nestingWarningThreshold: 3,
+      nestingErrorThreshold: 5,
+    };
+
+    const badge = getStatusBadge([deep, fn("flat", 15, 10)], nestingConfig, "deep.go");
+
+    assert.strictEqual(badge.text, "$(warning) 1");
+    assert.strictEqual(
+      badge.tooltip,
+      "1 function in deep.go over the nesting threshold. Click to go to the next one."
+    );
+  });
+
+  test("should go to the next function over the threshold after the cursor", () => {
+    const functions = [fn("late", 12, 40), fn("small", 2, 0), fn("early", 15, 10)];
+
+    assert.strictEqual(findNextHotspot(functions, config, 0)?.name, "early");
+    assert.strictEqual(findNextHotspot(functions, config, 10)?.name, "late");
+    // Past the last one it wraps around to the first.
+    assert.strictEqual(findNextHotspot(functions, config, 45)?.name, "early");
+    assert.strictEqual(findNextHotspot([fn("small", 2, 0)], config, 0), undefined);
+  });
+});
@@ -0,0 +1,147 @@
+import * as assert from "assert";
+import * as vscode from "vscode";
+import { TestComplexityProvider, getTestFunctions } from "../../providers/testComplexityProvider";
+import { isTestFile } from "../../workspace/fileFilters";
+import { UnifiedFunctionMetrics } from "../../metricsAnalyzer/metricsAnalyzerFactory";
+
+suite("Test Complexity Provider Tests", () => {
+  suite("Test File Detection", () => {
+    test("should recognise common test file conventions", () => {
+      const testFiles = [
+        "/repo/pkg/calc_test.go",
+        "/repo/src/app.test.ts",
+        "/repo/src/app.spec.jsx",
+        "C:\\repo\\tests\\test_calc.py",
+        "/repo/calc_test.py",
+        "/repo/src/CalculatorTest.java",
+        "/repo/src/CalculatorTests.cs",
+      ];
+      for (const file of testFiles) {
+        assert.strictEqual(isTestFile(file), true, `${file} should be a test file`);
+      }
+    });
+
+    test("should not treat regular source files as tests", () => {
+      const sourceFiles = [
+        "/repo/pkg/calc.go",
+        "/repo/src/testing.ts",
+        "/repo/src/contest.py",
+        "/repo/src/Calculator.java",
+        "/repo/test/helpers.go",
+      ];
+      for (const file of sourceFiles) {
+        assert.strictEqual(isTestFile(file), false, `${file} should not be a test file`);
+      }
+    });
+  });
+
+  suite("Test Function Selection", () => {
+    function fn(name: string): UnifiedFunctionMetrics {
+      return {
+        name,
+        complexity: 0,
+        details: [],
+        startLine: 0,
+        endLine: 0,
+        startColumn: 0,
+        endColumn: 0,
+      };
+    }
+
+    test("should select go test entry points by prefix", () => {
+      const functions = [fn("TestAdd"), fn("BenchmarkAdd"), fn("FuzzParse"), fn("Example"), fn("helper")];
+
+      const names = getTestFunctions(functions, "go").map((f) => f.name);
+
+      assert.deepStrictEqual(names, ["TestAdd", "BenchmarkAdd", "FuzzParse", "Example"]);
+    });
+
+    test("should not select go functions that only start with a test prefix", () => {
+      const functions = [fn("Testify"), fn("Examples"), fn("Benchmarks"), fn("Test_parse"), fn("Test2")];
+
+      const names = getTestFunctions(functions, "go").map((f) => f.name);
+
+      assert.deepStrictEqual(names, ["Test_parse", "Test2"]);
+    });
+
+    test("should select python test functions and methods", () => {
+      const functions = [fn("test_add"), fn("TestCalc.test_sub"), fn("setup_module")];
+
+      const names = getTestFunctions(functions, "python").map((f) => f.name);
+
+      assert.deepStrictEqual(names, ["test_add", "TestCalc.test_sub"]);
+    });
+
+    test("should keep every function for other languages", () => {
+      const functions = [fn("CalculatorTest.testAdd"), fn("CalculatorTest.setUp")];
+
+      assert.strictEqual(getTestFunctions(functions, "java").length, 2);
+    });
+  });
+
+  suite("Test Items", () => {
+    const sourceCode = `
+package calc
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+    for _, tc := range cases {
+        if got := Classify(tc.in); got != tc.want {
+            t.Errorf("got %v", got)
+        }
+    }
+}
+
+func newCase() int {
+    return 1
+}
+`;
+
+    function createTestDocument(fsPath: string): vscode.TextDocument {
+      return {
+        uri: vscode.Uri.file(fsPath),
+        languageId: "go",
+        getText: () => sourceCode,
+      } as unknown as vscode.TextDocument;
+    }
+
+    test("should annotate test functions with their complexity", () => {
+      const provider = new TestComplexityProvider();
+      try {
+        const document = createTestDocument("/repo/calc/calc_test.go");
+        provider.updateDocument(document);
+
+        const fileItem = provider.items.get(document.uri.toString());
+        assert.ok(fileItem, "file item should be created");
+        assert.strictEqual(fileItem!.children.size, 1, "only TestClassify is a test entry point");
+
+        const testItems: vscode.TestItem[] = [];
+        fileItem!.children.forEach((item) => testItems.push(item));
+        assert.strictEqual(testItems[0].label, "TestClassify");
+        // for (+1) + nested if (+2) = 3
+        assert.ok(testItems[0].description?.includes("complexity 3"));
+      } finally {
+        provider.dispose();
+      }
+    });
+
+    test("should ignore non-test files and remove closed documents", () => {
+      const provider = new TestComplexityProvider();
+      try {
+        const source = createTestDocument("/repo/calc/calc.go");
+        provider.updateDocument(source);
+        assert.strictEqual(provider.items.size, 0);
+
+        const testDoc = createTestDocument("/repo/calc/calc_test.go");
+        provider.updateDocument(testDoc);
+        assert.strictEqual(provider.items.size, 1);
+
+        provider.removeDocument(testDoc.uri);
+        assert.strictEqual(provider.items.size, 0);
+      } finally {
+        provider.dispose();
+      }
+    });
+  });
+});
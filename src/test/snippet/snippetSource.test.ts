@@ -0,0 +1,98 @@
+import * as assert from "assert";
+import {
+  fetchSnippet,
+  formatSnippetReport,
+  inferSnippetLanguage,
+  toRawSourceUrl,
+} from "../../snippet/snippetSource";
+import { DEFAULT_CONFIG } from "../../configuration";
+import { MetricsAnalyzerFactory } from "../../metricsAnalyzer/metricsAnalyzerFactory";
+
+suite("Snippet Source Tests", () => {
+  suite("URL Handling", () => {
+    test("should rewrite GitHub blob and gist pages to raw files", () => {
+      assert.strictEqual(
+        toRawSourceUrl(new URL("https://github.com/acme/tool/blob/main/cmd/run.go")).href,
+        "https://raw.githubusercontent.com/acme/tool/main/cmd/run.go"
+      );
+      assert.strictEqual(
+        toRawSourceUrl(new URL("https://gist.github.com/someone/abc123/")).href,
+        "https://gist.github.com/someone/abc123/raw"
+      );
+      assert.strictEqual(
+        toRawSourceUrl(new URL("https://example.com/a.go")).href,
+        "https://example.com/a.go"
+      );
+    });
+
+    test("should infer the language from the URL's file name", () => {
+      assert.strictEqual(inferSnippetLanguage("https://example.com/src/main.go?x=1"), "go");
+      assert.strictEqual(inferSnippetLanguage("https://gist.github.com/someone/abc123"), undefined);
+      assert.strictEqual(inferSnippetLanguage("not a url"), undefined);
+    });
+  });
+
+  suite("Fetching", () => {
+    function fakeFetch(response: Response | Error): typeof fetch {
+      return (async () => {
+        if (response instanceof Error) {
+          throw response;
+        }
+        return response;
+      }) as typeof fetch;
+    }
+
+    test("should return the downloaded text", async () => {
+      const text = await fetchSnippet(
+        "https://example.com/a.go",
+        fakeFetch(new Response("package main\n"))
+      );
+
+      assert.strictEqual(text, "package main\n");
+    });
+
+    test("should report invalid URLs and unsupported schemes", async () => {
+      await assert.rejects(fetchSnippet("not a url", fakeFetch(new Response(""))), /not a valid URL/);
+      await assert.rejects(
+        fetchSnippet("file:///etc/passwd", fakeFetch(new Response(""))),
+        /Only http and https/
+      );
+    });
+
+    test("should report HTTP and network failures", async () => {
+      await assert.rejects(
+        fetchSnippet("https://example.com/missing.go", fakeFetch(new Response("", { status: 404 }))),
+        /HTTP 404/
+      );
+      await assert.rejects(
+        fetchSnippet("https://example.com/a.go", fakeFetch(new Error("getaddrinfo ENOTFOUND"))),
+        /Could not download https:\/\/example\.com\/a\.go: getaddrinfo ENOTFOUND/
+      );
+    });
+  });
+
+  suite("Report", () => {
+    test("should list functions by descending complexity", () => {
+      const functions = MetricsAnalyzerFactory.analyzeFile(
+        "package main\n\nfunc A() {}\n\nfunc B(x bool) {\n    if x {\n    }\n}\n",
+        "go"
+      );
+
+      const lines = formatSnippetReport("clipboard", "go", functions, DEFAULT_CONFIG);
+
+      assert.deepStrictEqual(lines, [
+        "Snippet: clipboard (go)",
+        "  2 functions",
+        "    🟢   1  B (line 5)",
+        "    🟢   0  A (line 3)",
+      ]);
+    });
+
+    test("should say when no functions were found", () => {
+      assert.deepStrictEqual(formatSnippetReport("clipboard", "go", [], DEFAULT_CONFIG), [
+        "Snippet: clipboard (go)",
+        "  No functions found.",
+      ]);
+    });
+  });
+});
@@ -8,9 +8,15 @@ export function run(): Promise<void> {
       // These files use VS Code's suite() and test() globals
       const testFiles = [
         "../configuration.test",
+        "../explanation/complexityExplanation.test",
+        "../export/metricsExport.test",
         "../metricsAnalyzer/metricsAnalyzerFactory.test",
         "../metricsAnalyzer/languages/csharpAnalyzer.test",
+        "../metricsAnalyzer/languages/dartAnalyzer.test",
         "../metricsAnalyzer/languages/goAnalyzer.test",
+        "../metricsAnalyzer/languages/goTemplateAnalyzer.test",
+        "../metricsAnalyzer/languages/sqlAnalyzer.test",
+        "../metricsAnalyzer/languages/elixirAnalyzer.test",
         "../metricsAnalyzer/languages/javaAnalyzer.test",
         "../metricsAnalyzer/languages/javascriptAnalyzer.test",
         "../metricsAnalyzer/languages/typescriptAnalyzer.test",
@@ -19,6 +25,21 @@ export function run(): Promise<void> {
         "../metricsAnalyzer/languages/tsxAnalyzer.test",
         "../metricsAnalyzer/languages/rustAnalyzer.test",
         "../providers/codeLensProvider.test",
+        "../providers/diagnosticsProvider.test",
+        "../providers/overviewRulerProvider.test",
+        "../providers/complexitySymbolProvider.test",
+        "../providers/testComplexityProvider.test",
+        "../providers/saveGuardrail.test",
+        "../providers/statusBarProvider.test",
+        "../notebook/notebookCells.test",
+        "../snippet/snippetSource.test",
+        "../workspace/analysisProfile.test",
+        "../workspace/gocycloComparison.test",
+        "../workspace/functionComparison.test",
+        "../workspace/snapshotAnalyzer.test",
+        "../workspace/complexityDistribution.test",
+        "../workspace/workspaceAnalyzer.test",
+        "../workspace/workspaceWatcher.test",
       ];
 
       testFiles.forEach((testFile) => {
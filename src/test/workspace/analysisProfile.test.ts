@@ -0,0 +1,57 @@
+import * as assert from "assert";
+import {
+  AnalysisProfile,
+  FileTiming,
+  formatAnalysisProfile,
+  profileSource,
+} from "../../workspace/analysisProfile";
+import { DEFAULT_CONFIG } from "../../configuration";
+
+suite("Analysis Profile Tests", () => {
+  function timing(relativePath: string, parse: number, walk: number, aggregate: number): FileTiming {
+    return {
+      relativePath,
+      languageId: "go",
+      functionCount: 2,
+      totalMs: parse + walk + aggregate,
+      phases: { parse, walk, aggregate },
+    };
+  }
+
+  test("should time the phases of one source text", () => {
+    const source = "package main\n\nfunc f(x int) int {\n\tif x > 0 {\n\t\treturn x\n\t}\n\treturn 0\n}\n";
+
+    const file = profileSource("main.go", source, "go", DEFAULT_CONFIG);
+
+    assert.strictEqual(file.relativePath, "main.go");
+    assert.strictEqual(file.functionCount, 1);
+    assert.ok(file.phases.parse > 0);
+    assert.ok(file.totalMs >= file.phases.parse + file.phases.walk);
+  });
+
+  test("should summarize totals, phases, and the slowest file", () => {
+    const profile: AnalysisProfile = {
+      target: "workspace",
+      files: [timing("fast.go", 1, 2, 1), timing("slow.go", 10, 25, 5)],
+    };
+
+    const lines = formatAnalysisProfile(profile);
+
+    assert.strictEqual(lines[0], "Analysis timing: workspace (2 files, 4 functions)");
+    assert.strictEqual(lines[1], "  Total: 44.0 ms");
+    assert.strictEqual(lines[2], "  Phases: parse 11.0 ms (25%), walk 27.0 ms (61%), aggregate 6.0 ms (14%)");
+    assert.strictEqual(lines[3], "  Slowest file: slow.go 40.0 ms (parse 10.0, walk 25.0, aggregate 5.0)");
+    const perFile = lines.slice(lines.indexOf("  Per file (slowest first):") + 1);
+    assert.deepStrictEqual(
+      perFile.map((line) => line.trim().split("  ")[1]),
+      ["slow.go", "fast.go"]
+    );
+  });
+
+  test("should report when no files were analyzed", () => {
+    assert.deepStrictEqual(formatAnalysisProfile({ target: "workspace", files: [] }), [
+      "Analysis timing: workspace (0 files, 0 functions)",
+      "  No files were analyzed.",
+    ]);
+  });
+});
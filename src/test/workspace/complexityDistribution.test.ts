@@ -0,0 +1,76 @@
+import * as assert from "assert";
+import * as vscode from "vscode";
+import {
+  computeDistribution,
+  getPercentile,
+  renderDistributionHtml,
+} from "../../workspace/complexityDistribution";
+import { WorkspaceMetrics } from "../../workspace/workspaceAnalyzer";
+import { DEFAULT_CONFIG } from "../../configuration";
+
+suite("Complexity Distribution Tests", () => {
+  function createMetrics(...complexities: number[]): WorkspaceMetrics {
+    return {
+      roots: [
+        {
+          name: "root",
+          folder: undefined,
+          config: DEFAULT_CONFIG,
+          files: [
+            {
+              uri: vscode.Uri.file("/root/a.go"),
+              relativePath: "a.go",
+              languageId: "go",
+              functions: complexities.map((complexity, i) => ({
+                name: `F${i}`,
+                complexity,
+                details: [],
+                startLine: i,
+                endLine: i,
+                startColumn: 0,
+                endColumn: 0,
+              })),
+            },
+          ],
+        },
+      ],
+    };
+  }
+
+  suite("Percentiles", () => {
+    test("should use the nearest rank", () => {
+      const sorted = [1, 2, 3, 4, 5, 6, 7, 8, 9, 10];
+
+      assert.strictEqual(getPercentile(sorted, 50), 5);
+      assert.strictEqual(getPercentile(sorted, 90), 9);
+      assert.strictEqual(getPercentile(sorted, 99), 10);
+      assert.strictEqual(getPercentile([7], 50), 7);
+    });
+
+    test("should return 0 without values", () => {
+      assert.strictEqual(getPercentile([], 90), 0);
+    });
+  });
+
+  suite("Histogram", () => {
+    test("should bucket every function", () => {
+      const distribution = computeDistribution(createMetrics(0, 0, 1, 4, 12, 80));
+
+      assert.deepStrictEqual(
+        distribution.buckets.map((b) => `${b.label}:${b.count}`),
+        ["0:2", "1–2:1", "3–5:1", "6–10:0", "11–15:1", "16–25:0", "26–50:0", "51+:1"]
+      );
+      assert.strictEqual(distribution.functionCount, 6);
+      assert.strictEqual(distribution.median, 1);
+      assert.strictEqual(distribution.max, 80);
+    });
+
+    test("should render percentiles and one bar per bucket", () => {
+      const html = renderDistributionHtml(computeDistribution(createMetrics(3, 8)));
+
+      assert.ok(html.includes("Cognitive complexity of 2 functions"));
+      assert.strictEqual(html.match(/class="bar"/g)?.length, 8);
+      assert.ok(!html.includes("<script"));
+    });
+  });
+});
@@ -0,0 +1,79 @@
+import * as assert from "assert";
+import { UnifiedFunctionMetrics } from "../../metricsAnalyzer/metricsAnalyzerFactory";
+import {
+  compareFunctions,
+  formatFunctionComparison,
+} from "../../workspace/functionComparison";
+
+suite("Function Comparison Tests", () => {
+  function createFunction(
+    name: string,
+    complexity: number,
+    startLine: number,
+    endLine: number,
+    nesting = 0
+  ): UnifiedFunctionMetrics {
+    return {
+      name,
+      complexity,
+      details: [{ increment: 1, reason: "if statement", line: startLine + 1, column: 4, nesting }],
+      startLine,
+      endLine,
+      startColumn: 0,
+      endColumn: 1,
+    };
+  }
+
+  const picked = [
+    { relativePath: "svc/order.go", func: createFunction("Validate", 6, 9, 28, 2) },
+    { relativePath: "svc/price.go", func: createFunction("Total", 2, 0, 11) },
+  ];
+
+  test("should add up the picked functions", () => {
+    const comparison = compareFunctions(picked, 11);
+
+    assert.deepStrictEqual(comparison.functions[0], {
+      name: "Validate",
+      relativePath: "svc/order.go",
+      line: 10,
+      complexity: 6,
+      maxNesting: 2,
+      lines: 20,
+    });
+    assert.strictEqual(comparison.combinedComplexity, 8);
+    assert.strictEqual(comparison.combinedLines, 32);
+    assert.strictEqual(comparison.reference, 11);
+  });
+
+  test("should compare the combined complexity with the reference", () => {
+    const lines = formatFunctionComparison(compareFunctions(picked, 11));
+
+    assert.deepStrictEqual(lines, [
+      "Function comparison (2 functions)",
+      "",
+      "  Complexity  Nesting  Lines  Function",
+      "           6        2     20  Validate (svc/order.go:10)",
+      "           2        0     12  Total (svc/price.go:1)",
+      "           8        2     32  Combined (nesting is the deepest)",
+      "",
+      "  Combined complexity 8 is lower than the reference 11: -3 (-27%).",
+      "  Validate carries 75% of the combined complexity.",
+    ]);
+  });
+
+  test("should show the functions side by side without a reference", () => {
+    const lines = formatFunctionComparison(compareFunctions(picked));
+
+    assert.ok(!lines.some((line) => line.includes("reference")));
+    assert.strictEqual(lines[lines.length - 1], "  Validate carries 75% of the combined complexity.");
+  });
+
+  test("should report a higher combined value and skip the share of a single function", () => {
+    const lines = formatFunctionComparison(compareFunctions(picked.slice(0, 1), 4));
+
+    assert.deepStrictEqual(lines.slice(-2), [
+      "",
+      "  Combined complexity 6 is higher than the reference 4: +2 (+50%).",
+    ]);
+  });
+});
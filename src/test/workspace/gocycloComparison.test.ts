@@ -0,0 +1,68 @@
+import * as assert from "assert";
+import {
+  GOCYCLO_RULE_DIFFERENCES,
+  compareWithGocyclo,
+  formatGocycloComparison,
+} from "../../workspace/gocycloComparison";
+import { DEFAULT_CONFIG } from "../../configuration";
+
+suite("gocyclo Comparison Tests", () => {
+  const source = `package main
+
+var handler = func() {
+    if ready {
+        start()
+    }
+}
+
+func Add(a, b int) int {
+    return a + b
+}
+
+func Check(a, b, c bool) bool {
+    if a && b && c {
+        return true
+    }
+    return false
+}
+`;
+
+  test("should pair each function's complexity with gocyclo's value", () => {
+    assert.deepStrictEqual(compareWithGocyclo(source, DEFAULT_CONFIG), [
+      { name: "init.func1", line: 3, complexity: 1 },
+      { name: "Add", line: 9, complexity: 0, gocyclo: 1 },
+      { name: "Check", line: 13, complexity: 2, gocyclo: 4 },
+    ]);
+  });
+
+  test("should apply the complexity base and mark excluded functions", () => {
+    const rows = compareWithGocyclo(source, {
+      ...DEFAULT_CONFIG,
+      complexityBase: 1,
+      excludeFunctionPatterns: ["^Check$"],
+    });
+
+    assert.deepStrictEqual(
+      rows.map((row) => [row.name, row.complexity, row.gocyclo]),
+      [
+        ["init.func1", 2, undefined],
+        ["Add", 1, 1],
+        ["Check", undefined, 4],
+      ]
+    );
+  });
+
+  test("should format both values, their difference, and the rules that differ", () => {
+    const lines = formatGocycloComparison("main.go", compareWithGocyclo(source, DEFAULT_CONFIG));
+
+    assert.strictEqual(lines[0], "gocyclo comparison: main.go (3 functions)");
+    assert.strictEqual(lines[1], "  0 of 2 functions have the same value under both tools.");
+    assert.deepStrictEqual(lines.slice(3, 7), [
+      "     Ours  gocyclo   Diff  Function",
+      "        1        —      —  init.func1 (line 3) (not reported by gocyclo)",
+      "        0        1     +1  Add (line 9)",
+      "        2        4     +2  Check (line 13)",
+    ]);
+    assert.strictEqual(lines.length, 9 + GOCYCLO_RULE_DIFFERENCES.length);
+  });
+});
@@ -0,0 +1,74 @@
+import * as assert from "assert";
+import { execFileSync } from "child_process";
+import * as fs from "fs";
+import * as os from "os";
+import * as path from "path";
+import * as vscode from "vscode";
+import { analyzeFolderAtRef } from "../../workspace/snapshotAnalyzer";
+
+suite("Snapshot Analyzer Tests", function () {
+  let repo: string;
+  let folder: vscode.WorkspaceFolder;
+  const git = (...args: string[]) =>
+    execFileSync("git", args, {
+      cwd: repo,
+      env: {
+        ...process.env,
+        GIT_AUTHOR_NAME: "test",
+        GIT_AUTHOR_EMAIL: "test@example.com",
+        GIT_COMMITTER_NAME: "test",
+        GIT_COMMITTER_EMAIL: "test@example.com",
+      },
+    });
+  const source = (ifs: number) =>
+    `package a\n\nfunc F(x bool) int {\n${"\tif x {\n\t\treturn 1\n\t}\n".repeat(ifs)}\treturn 0\n}\n`;
+  const complexityOf = async (ref: string) =>
+    (await analyzeFolderAtRef(folder, ref)).files.map((f) => [
+      f.relativePath,
+      f.functions.map((func) => func.complexity),
+    ]);
+
+  suiteSetup(function () {
+    repo = fs.mkdtempSync(path.join(os.tmpdir(), "code-metrics-snapshot-"));
+    try {
+      git("init", "-q");
+    } catch {
+      this.skip(); // git is not installed
+    }
+    folder = { uri: vscode.Uri.file(repo), name: "repo", index: 0 };
+    fs.writeFileSync(path.join(repo, "a.go"), source(0));
+    fs.writeFileSync(path.join(repo, "notes.md"), "# not code\n");
+    git("add", ".");
+    git("commit", "-q", "-m", "base");
+    git("tag", "v1");
+    fs.writeFileSync(path.join(repo, "a.go"), source(1));
+    fs.writeFileSync(path.join(repo, "b.go"), source(0));
+    git("add", ".");
+    git("commit", "-q", "-m", "grow");
+    fs.writeFileSync(path.join(repo, "a.go"), source(2));
+    git("stash", "-q");
+  });
+
+  suiteTeardown(() => {
+    fs.rmSync(repo, { recursive: true, force: true });
+  });
+
+  test("should analyze the files of a tag, a commit, and a stash", async () => {
+    assert.deepStrictEqual(await complexityOf("v1"), [["a.go", [0]]]);
+    assert.deepStrictEqual(await complexityOf("HEAD"), [["a.go", [1]], ["b.go", [0]]]);
+    assert.deepStrictEqual(await complexityOf("stash@{0}"), [["a.go", [2]], ["b.go", [0]]]);
+  });
+
+  test("should name the root after the ref and its commit", async () => {
+    const commit = git("rev-parse", "--short=7", "v1").toString().trim();
+
+    assert.strictEqual((await analyzeFolderAtRef(folder, "v1")).name, `repo @ v1 (${commit})`);
+  });
+
+  test("should reject refs that do not exist", async () => {
+    await assert.rejects(
+      analyzeFolderAtRef(folder, "no-such-branch"),
+      /Git ref "no-such-branch" does not name a commit in this repository\./
+    );
+  });
+});
@@ -0,0 +1,1061 @@
+import * as assert from "assert";
+import * as fs from "fs";
+import * as path from "path";
+import * as vscode from "vscode";
+import {
+  FileMetrics,
+  RootMetrics,
+  WorkspaceAnalyzer,
+  findWorstFunction,
+  formatFileListing,
+  getBuildConstraint,
+  getPlatformVariantBase,
+  groupPlatformFunctions,
+  summarizeFluentTypes,
+  findMethodCandidates,
+  findUnusedFunctions,
+  formatWorkspaceReport,
+  summarizeParameters,
+  summarizeTypes,
+  summarizeWorkspace,
+} from "../../workspace/workspaceAnalyzer";
+import {
+  GoModuleLayout,
+  isModuleCachePath,
+  parseModulePath,
+  parseWorkspaceUses,
+  resolveGoPackage,
+} from "../../workspace/goModules";
+import { parseGitmodules } from "../../workspace/gitSubmodules";
+import { DEFAULT_CONFIG } from "../../configuration";
+import { MetricsAnalyzerFactory } from "../../metricsAnalyzer/metricsAnalyzerFactory";
+
+suite("Workspace Analyzer Tests", () => {
+  const sourceCode = `
+package main
+
+func Classify(a, b bool) int {
+    if a {
+        if b {
+            return 2
+        }
+        return 1
+    }
+    return 0
+}
+`;
+
+  function createRoot(
+    name: string,
+    warningThreshold: number,
+    errorThreshold: number
+  ): RootMetrics {
+    return {
+      name,
+      folder: undefined,
+      config: { ...DEFAULT_CONFIG, warningThreshold, errorThreshold },
+      files: [
+        {
+          uri: vscode.Uri.file(`/${name}/classify.go`),
+          relativePath: "classify.go",
+          languageId: "go",
+          functions: MetricsAnalyzerFactory.analyzeFile(sourceCode, "go"),
+        },
+      ],
+    };
+  }
+
+  suite("Per-root Configuration", () => {
+    test("should band identical code differently under roots with different thresholds", () => {
+      // Classify has complexity 3: if (+1) and a nested if (+2).
+      const strict = createRoot("strict", 1, 2);
+      const lenient = createRoot("lenient", 10, 15);
+
+      const lines = formatWorkspaceReport({ roots: [strict, lenient] });
+      const lenientStart = lines.findIndex((l) => l.startsWith("lenient"));
+      const strictLines = lines.slice(0, lenientStart);
+      const lenientLines = lines.slice(lenientStart);
+
+      assert.ok(strictLines.some((l) => l.includes("🔴") && l.includes("Classify")));
+      assert.ok(lenientLines.some((l) => l.includes("🟢") && l.includes("Classify")));
+    });
+
+    test("should include each root's thresholds in its section header", () => {
+      const lines = formatWorkspaceReport({
+        roots: [createRoot("strict", 1, 2), createRoot("lenient", 10, 15)],
+      });
+
+      assert.ok(lines.includes("strict  (warning ≥ 1, error ≥ 2)"));
+      assert.ok(lines.includes("lenient  (warning ≥ 10, error ≥ 15)"));
+    });
+  });
+
+  suite("Language Sections", () => {
+    function createPolyglotRoot(): RootMetrics {
+      const root = createRoot("mono", 10, 15);
+      root.files.push({
+        uri: vscode.Uri.file("/mono/classify.py"),
+        relativePath: "classify.py",
+        languageId: "python",
+        functions: MetricsAnalyzerFactory.analyzeFile(
+          "def classify(a, b):\n    if a:\n        if b:\n            return 2\n        return 1\n    return 0\n",
+          "python"
+        ),
+      });
+      root.languageThresholds = { python: { warningThreshold: 1, errorThreshold: 2 } };
+      return root;
+    }
+
+    test("should give each language a section banded with its own thresholds", () => {
+      const lines = formatWorkspaceReport({ roots: [createPolyglotRoot()] });
+      const goStart = lines.indexOf("  ── Go  (warning ≥ 10, error ≥ 15): 1 files, 1 functions");
+      const pythonStart = lines.indexOf("  ── Python  (warning ≥ 1, error ≥ 2): 1 files, 1 functions");
+
+      assert.ok(goStart !== -1 && pythonStart > goStart, "sections should follow in name order");
+      // Both functions have complexity 3.
+      assert.ok(lines.slice(goStart, pythonStart).some((l) => l.includes("🟢") && l.includes("Classify")));
+      assert.ok(lines.slice(pythonStart).some((l) => l.includes("🔴") && l.includes("classify")));
+    });
+
+    test("should summarize the languages together at the top of the root", () => {
+      const lines = formatWorkspaceReport({ roots: [createPolyglotRoot()] });
+
+      assert.deepStrictEqual(lines.slice(0, 3), [
+        "mono  (warning ≥ 10, error ≥ 15)",
+        "  2 files, 2 functions",
+        "  Functions by language: Go 1 (0 over threshold), Python 1 (1 over threshold)",
+      ]);
+    });
+
+    test("should add the lines of code of measured files to the root summary", () => {
+      const root = createRoot("root", 10, 15);
+      root.files[0].logicalLines = 10;
+      root.files.push({
+        uri: vscode.Uri.file("/root/codes.go"),
+        relativePath: "codes.go",
+        languageId: "go",
+        functions: [],
+        logicalLines: 40,
+      });
+
+      const lines = formatWorkspaceReport({ roots: [root] });
+
+      assert.strictEqual(lines[1], "  2 files, 1 functions, 50 lines of code");
+      assert.ok(!lines.some((l) => l.includes("codes.go")), "files without functions are not listed");
+    });
+
+    test("should not add sections to single-language roots", () => {
+      const lines = formatWorkspaceReport({ roots: [createRoot("root", 10, 15)] });
+
+      assert.ok(!lines.some((l) => l.includes("──") || l.includes("Functions by language")));
+    });
+  });
+
+  suite("Report Formatting", () => {
+    test("should report when there are no workspace folders", () => {
+      const lines = formatWorkspaceReport({ roots: [] });
+
+      assert.deepStrictEqual(lines, ["No workspace folders to analyze."]);
+    });
+
+    test("should list functions by descending complexity", () => {
+      const root = createRoot("root", 10, 15);
+      root.files[0].functions = MetricsAnalyzerFactory.analyzeFile(
+        `
+package main
+
+func Simple() {}
+
+func Branchy(a bool) {
+    if a {
+        return
+    }
+}
+`,
+        "go"
+      );
+
+      const lines = formatWorkspaceReport({ roots: [root] });
+      const branchy = lines.findIndex((l) => l.includes("Branchy"));
+      const simple = lines.findIndex((l) => l.includes("Simple"));
+
+      assert.ok(branchy !== -1 && simple !== -1);
+      assert.ok(branchy < simple, "higher complexity should be listed first");
+    });
+
+    test("should list functions by descending token count when configured", () => {
+      const root = createRoot("root", 10, 15);
+      root.config.reportSortBy = "tokens";
+      root.files[0].functions = MetricsAnalyzerFactory.analyzeFile(
+        `
+package main
+
+func Branchy(a bool) {
+    if a {
+        return
+    }
+}
+
+func Long(items []int) (sum int) {
+    sum = items[0] + items[1] + items[2]
+    return sum * 2
+}
+`,
+        "go"
+      );
+
+      const lines = formatWorkspaceReport({ roots: [root] });
+      const long = lines.find((l) => l.includes("Long (line"));
+      const branchy = lines.find((l) => l.includes("Branchy (line"));
+
+      assert.ok(lines.indexOf(long!) < lines.indexOf(branchy!), "more tokens should be listed first");
+      assert.ok(long!.endsWith("  34 tokens"), long);
+      assert.ok(branchy!.endsWith("  13 tokens"), branchy);
+    });
+
+    test("should estimate debt per file, root and workspace", () => {
+      // Classify has complexity 3: with a warning threshold of 1, 5 + 1 × 2 = 7 minutes.
+      const strict = createRoot("strict", 1, 2);
+      const lenient = createRoot("lenient", 10, 15);
+      lenient.config.debtBaseMinutes = 60;
+
+      const lines = formatWorkspaceReport({ roots: [strict, lenient] });
+
+      assert.ok(lines.includes("  ⏱ Estimated debt: 7m across 1 functions over threshold"));
+      assert.ok(
+        lines.includes("  classify.go  (longest: Classify, 9 lines; params avg 2, max 2; debt 7m)")
+      );
+      // Below the lenient threshold the code carries no debt.
+      assert.strictEqual(lines.filter((l) => l.includes("Estimated debt")).length, 1);
+      assert.strictEqual(lines[lines.length - 1], "Workspace estimated debt: 7m");
+    });
+
+    test("should name the longest function in each file header", () => {
+      const lines = formatWorkspaceReport({ roots: [createRoot("root", 10, 15)] });
+
+      // Classify spans 9 lines, all of which hold code.
+      assert.ok(lines.includes("  classify.go  (longest: Classify, 9 lines; params avg 2, max 2)"));
+    });
+  });
+
+  suite("Parameter Statistics", () => {
+    const withParameters = (...counts: (number | undefined)[]) =>
+      counts.map((parameterCount, i) => ({
+        name: `F${i}`,
+        complexity: 0,
+        details: [],
+        startLine: i,
+        endLine: i,
+        startColumn: 0,
+        endColumn: 0,
+        parameterCount,
+      }));
+
+    test("should average and maximize parameter counts", () => {
+      assert.deepStrictEqual(summarizeParameters(withParameters(1, 2, 4)), {
+        average: 2.3,
+        max: 4,
+      });
+    });
+
+    test("should skip functions without a parameter count", () => {
+      assert.deepStrictEqual(summarizeParameters(withParameters(3, undefined)), {
+        average: 3,
+        max: 3,
+      });
+      assert.strictEqual(summarizeParameters(withParameters(undefined)), undefined);
+    });
+
+    test("should list packages when a root has several", () => {
+      const root = createRoot("root", 10, 15);
+      root.files.push({ ...root.files[0], relativePath: "internal/util.go" });
+
+      const lines = formatWorkspaceReport({ roots: [root] });
+      const header = lines.indexOf("  Parameters per package:");
+
+      assert.ok(header !== -1);
+      assert.strictEqual(lines[header + 1], "    .  params avg 2, max 2");
+      assert.strictEqual(lines[header + 2], "    internal  params avg 2, max 2");
+    });
+  });
+
+  suite("Coverage Overlay", () => {
+    test("should show coverage and flag complex, under-covered functions", () => {
+      const root = createRoot("root", 2, 10);
+      // Classify (complexity 3) spans lines 4–12 (1-based); one of four instrumented lines ran.
+      root.coverage = new Map([
+        ["example.com/m/classify.go", new Map([[4, 1], [5, 0], [6, 0], [7, 0]])],
+      ]);
+
+      const lines = formatWorkspaceReport({ roots: [root] });
+
+      assert.ok(lines.includes("  ‼️ 1 complex functions below 50% coverage"));
+      assert.ok(
+        lines.some((l) => l.includes("Classify") && l.endsWith("coverage 25%, risk 2.3 ‼️"))
+      );
+    });
+
+    test("should not flag functions at or above the coverage threshold", () => {
+      const root = createRoot("root", 2, 10);
+      root.coverage = new Map([
+        ["classify.go", new Map([[4, 1], [5, 1], [6, 0], [7, 0]])],
+      ]);
+
+      const lines = formatWorkspaceReport({ roots: [root] });
+
+      assert.ok(lines.some((l) => l.endsWith("coverage 50%, risk 1.5")));
+      assert.ok(lines.every((l) => !l.includes("‼️")));
+    });
+
+    test("should format risk scores with the display locale override", () => {
+      const root = createRoot("root", 2, 10);
+      root.config.displayLocale = "de-DE";
+      root.coverage = new Map([["classify.go", new Map([[4, 1], [5, 0], [6, 0], [7, 0]])]]);
+
+      const lines = formatWorkspaceReport({ roots: [root] });
+
+      assert.ok(lines.some((l) => l.endsWith("coverage 25%, risk 2,3 ‼️")));
+    });
+
+    test("should omit coverage for files the coverage file does not mention", () => {
+      const root = createRoot("root", 2, 10);
+      root.coverage = new Map([["other.go", new Map([[1, 1]])]]);
+
+      const lines = formatWorkspaceReport({ roots: [root] });
+
+      assert.ok(lines.every((l) => !l.includes("coverage")));
+    });
+  });
+
+  suite("Platform Variants", () => {
+    test("should strip GOOS and GOARCH suffixes like go/build", () => {
+      assert.strictEqual(getPlatformVariantBase("pkg/fd_linux.go"), "pkg/fd.go");
+      assert.strictEqual(getPlatformVariantBase("pkg/fd_windows_amd64.go"), "pkg/fd.go");
+      assert.strictEqual(getPlatformVariantBase("fd_arm64.go"), "fd.go");
+      assert.strictEqual(getPlatformVariantBase("fd_linux_test.go"), "fd_test.go");
+      assert.strictEqual(getPlatformVariantBase("linux.go"), undefined);
+      assert.strictEqual(getPlatformVariantBase("fd_common.go"), undefined);
+      assert.strictEqual(getPlatformVariantBase("fd_linux.ts"), undefined);
+    });
+
+    test("should group variants under their base name when enabled", () => {
+      const linux = createRoot("root", 2, 15);
+      linux.config.groupPlatformVariants = true;
+      linux.files[0].relativePath = "fd_linux.go";
+      linux.files.push({
+        uri: vscode.Uri.file("/root/fd_windows.go"),
+        relativePath: "fd_windows.go",
+        languageId: "go",
+        functions: MetricsAnalyzerFactory.analyzeFile("package main\n\nfunc Flat() {}\n", "go"),
+      });
+
+      const lines = formatWorkspaceReport({ roots: [linux] });
+      const header = lines.indexOf("  fd.go  (2 platform variants)");
+
+      assert.ok(lines.includes("  Platform variants (highest complexity per file):"));
+      assert.ok(header !== -1);
+      assert.strictEqual(lines[header + 1], "    🟡   3  fd_linux.go");
+      assert.strictEqual(lines[header + 2], "    🟢   0  fd_windows.go");
+    });
+
+    test("should read //go:build lines and legacy +build lines", () => {
+      assert.strictEqual(
+        getBuildConstraint("// Copyright\n\n//go:build linux && !android\n\npackage fd\n"),
+        "linux && !android"
+      );
+      assert.strictEqual(
+        getBuildConstraint("// +build linux darwin\n// +build amd64,cgo\n\npackage fd\n"),
+        "(linux || darwin) && amd64 && cgo"
+      );
+      assert.strictEqual(getBuildConstraint("package fd\n\n//go:build linux\n"), undefined);
+    });
+
+    test("should compare the implementations of a function across constrained files", () => {
+      const root = createRoot("root", 2, 15);
+      root.config.groupPlatformFunctions = true;
+      root.files[0].relativePath = "fd_linux.go";
+      const flat = (relativePath: string, buildConstraint?: string) => ({
+        uri: vscode.Uri.file(`/root/${relativePath}`),
+        relativePath,
+        languageId: "go",
+        functions: MetricsAnalyzerFactory.analyzeFile("package main\n\nfunc Classify() {}\n", "go"),
+        ...(buildConstraint ? { buildConstraint } : {}),
+      });
+      root.files.push(flat("fd_other.go", "!linux"), flat("classify.go"), flat("sub/fd_windows.go"));
+
+      const groups = groupPlatformFunctions(root);
+      const lines = formatWorkspaceReport({ roots: [root] });
+      const header = lines.indexOf("  Classify  (., 2 variants)");
+
+      assert.deepStrictEqual(
+        groups.map((g) => [g.goPackage, g.implementations.map((i) => i.variant)]),
+        [[".", ["linux", "!linux"]]]
+      );
+      assert.ok(lines.includes("  Platform implementations (complexity per variant):"));
+      assert.strictEqual(lines[header + 1], "    🟡   3  fd_linux.go  (linux)");
+      assert.strictEqual(lines[header + 2], "    🟢   0  fd_other.go  (!linux)");
+    });
+
+    test("should band the implementations with the thresholds of their language", () => {
+      const root = createRoot("root", 10, 15);
+      root.config.groupPlatformFunctions = true;
+      root.languageThresholds = { go: { warningThreshold: 2, errorThreshold: 3 } };
+      root.files[0].relativePath = "fd_linux.go";
+      root.files.push({
+        ...root.files[0],
+        uri: vscode.Uri.file("/root/fd_other.go"),
+        relativePath: "fd_other.go",
+        buildConstraint: "!linux",
+      });
+
+      const lines = formatWorkspaceReport({ roots: [root] });
+      const header = lines.indexOf("  Classify  (., 2 variants)");
+
+      assert.strictEqual(lines[header + 1], "    🔴   3  fd_linux.go  (linux)");
+      assert.strictEqual(lines[header + 2], "    🔴   3  fd_other.go  (!linux)");
+    });
+
+    test("should not group variants by default", () => {
+      const root = createRoot("root", 10, 15);
+      root.files[0].relativePath = "fd_linux.go";
+      root.files.push({ ...root.files[0], relativePath: "fd_darwin.go" });
+
+      const lines = formatWorkspaceReport({ roots: [root] });
+
+      assert.ok(!lines.some((l) => l.includes("platform variants") || l.includes("Platform implementations")));
+    });
+  });
+
+  suite("Resolvers", () => {
+    test("should list tagged resolvers most complex first", () => {
+      const root = createRoot("root", 2, 15);
+      root.files[0].functions = root.files[0].functions.map((f) => ({ ...f, tags: ["resolver"] }));
+      root.files.push({
+        uri: vscode.Uri.file("/root/schema.resolvers.go"),
+        relativePath: "schema.resolvers.go",
+        languageId: "go",
+        functions: MetricsAnalyzerFactory.analyzeFile(
+          "package graph\n\nfunc (r *queryResolver) Me() {}\n",
+          "go",
+          { resolverPatterns: ["Resolver\\."] }
+        ),
+      });
+
+      const lines = formatWorkspaceReport({ roots: [root] });
+      const header = lines.indexOf("  Resolvers: 2 functions, total complexity 3, 1 over threshold");
+
+      assert.ok(header !== -1);
+      assert.strictEqual(lines[header + 1], "    🟡   3  Classify  (classify.go:4)");
+      assert.strictEqual(lines[header + 2], "    🟢   0  queryResolver.Me  (schema.resolvers.go:3)");
+    });
+
+    test("should leave the section out without tagged functions", () => {
+      const lines = formatWorkspaceReport({ roots: [createRoot("root", 10, 15)] });
+
+      assert.ok(!lines.some((l) => l.includes("Resolvers")));
+    });
+  });
+
+  suite("Fluent Types", () => {
+    test("should aggregate chains across files by the type's fluent methods", () => {
+      const root = createRoot("root", 10, 15);
+      root.config.reportFluentChains = true;
+      root.files[0].fluent = {
+        fluentMethods: [
+          { type: "Query", name: "Where" },
+          { type: "Query", name: "Limit" },
+        ],
+        chains: [{ methods: ["Where", "Where", "Limit", "Run"], line: 3 }],
+      };
+      root.files.push({
+        uri: vscode.Uri.file("/root/use.go"),
+        relativePath: "use.go",
+        languageId: "go",
+        functions: [],
+        fluent: {
+          fluentMethods: [],
+          chains: [
+            { methods: ["Where", "Limit"], line: 1 },
+            { methods: ["Where", "Run"], line: 2 },
+          ],
+        },
+      });
+
+      const [stats] = summarizeFluentTypes(root);
+
+      assert.deepStrictEqual(stats, {
+        type: "Query",
+        methods: 2,
+        complexity: 0,
+        chains: 2,
+        longestChain: 3,
+        averageChain: 2.5,
+      });
+      assert.ok(
+        formatWorkspaceReport({ roots: [root] }).includes(
+          "    Query  2 methods (complexity 0), 2 chains, longest 3, average 2.5"
+        )
+      );
+    });
+  });
+
+  suite("Type Metrics", () => {
+    function createSampleRoot(mergeReceivers: boolean): RootMetrics {
+      const root = createRoot("root", 10, 15);
+      root.config = { ...root.config, reportTypeMetrics: true, typeMetricsMergeReceivers: mergeReceivers };
+      const source = fs.readFileSync(path.resolve(__dirname, "../../../samples/Test.go"), "utf-8");
+      root.files = [
+        {
+          uri: vscode.Uri.file("/root/samples/Test.go"),
+          relativePath: "samples/Test.go",
+          languageId: "go",
+          functions: MetricsAnalyzerFactory.analyzeFile(source, "go"),
+        },
+      ];
+      return root;
+    }
+
+    test("should roll value and pointer receiver methods up into one type by default", () => {
+      const root = createSampleRoot(true);
+
+      // Add (value, 0), Increment (pointer, 1), and Reset (pointer, 0)
+      assert.deepStrictEqual(summarizeTypes(root, true), [
+        { type: "Calculator", goPackage: "samples", methodCount: 3, weightedComplexity: 1 },
+      ]);
+      assert.ok(
+        formatWorkspaceReport({ roots: [root] }).includes(
+          "    WMC   1  NOM  3  Calculator (samples)"
+        )
+      );
+    });
+
+    test("should list the pointer method set separately when receivers are not merged", () => {
+      const root = createSampleRoot(false);
+
+      assert.deepStrictEqual(
+        summarizeTypes(root, false).map((t) => [t.type, t.methodCount, t.weightedComplexity]),
+        [
+          ["*Calculator", 2, 1],
+          ["Calculator", 1, 0],
+        ]
+      );
+      const lines = formatWorkspaceReport({ roots: [root] });
+      assert.ok(lines.includes("    WMC   1  NOM  2  *Calculator (samples)"));
+    });
+
+    test("should group the methods of a generic type under the type", () => {
+      const root = createRoot("root", 10, 15);
+      const source = fs.readFileSync(
+        path.resolve(__dirname, "../../../samples/generics/containers.go"),
+        "utf-8"
+      );
+      root.files = [
+        {
+          uri: vscode.Uri.file("/root/samples/generics/containers.go"),
+          relativePath: "samples/generics/containers.go",
+          languageId: "go",
+          functions: MetricsAnalyzerFactory.analyzeFile(source, "go"),
+        },
+      ];
+
+      // Stack: Pop (1), Peek (1), Drain (1); Pair: Matches (&&, 1)
+      assert.deepStrictEqual(
+        summarizeTypes(root, true).map((t) => [t.type, t.methodCount, t.weightedComplexity]),
+        [
+          ["Stack", 5, 3],
+          ["Pair", 2, 1],
+        ]
+      );
+      assert.deepStrictEqual(
+        summarizeTypes(root, false).map((t) => [t.type, t.methodCount, t.weightedComplexity]),
+        [
+          ["*Stack", 3, 2],
+          ["*Pair", 1, 1],
+          ["Stack", 2, 1],
+          ["Pair", 1, 0],
+        ]
+      );
+    });
+
+    test("should leave the section out unless enabled", () => {
+      const root = createSampleRoot(true);
+      root.config = { ...root.config, reportTypeMetrics: false };
+
+      assert.ok(!formatWorkspaceReport({ roots: [root] }).some((l) => l.includes("WMC")));
+    });
+  });
+
+  suite("Unused Functions", () => {
+    function goFile(relativePath: string, source: string): FileMetrics {
+      return {
+        uri: vscode.Uri.file(`/root/${relativePath}`),
+        relativePath,
+        languageId: "go",
+        functions: MetricsAnalyzerFactory.analyzeFile(source, "go"),
+      };
+    }
+
+    function createUnusedRoot(): RootMetrics {
+      const root = createRoot("root", 10, 15);
+      root.files = [
+        goFile(
+          "cmd/main.go",
+          `
+package main
+
+func main() {
+    run()
+}
+
+func run() {
+    if ready() {
+        return
+    }
+}
+
+func ready() bool { return true }
+
+func leftover(n int) int {
+    if n > 0 {
+        return leftover(n - 1)
+    }
+    return 0
+}
+`
+        ),
+        goFile(
+          "cmd/extra.go",
+          `
+package main
+
+func Exported() {}
+
+type server struct{}
+
+func (s *server) start() {}
+`
+        ),
+        goFile(
+          "lib/lib.go",
+          `
+package lib
+
+func ready() bool { return false }
+`
+        ),
+        goFile(
+          "lib/lib_test.go",
+          `
+package lib
+
+func TestReady(t *testing.T) {
+    ready()
+}
+`
+        ),
+      ];
+      return root;
+    }
+
+    test("should report unexported functions no function of their package calls", () => {
+      const unused = findUnusedFunctions(createUnusedRoot(), false);
+
+      // Self-recursion does not count as a use; ready() in lib is called by its test.
+      assert.deepStrictEqual(
+        unused.map(({ file, func }) => `${file.relativePath}#${func.name}`),
+        ["cmd/main.go#leftover"]
+      );
+    });
+
+    test("should report exported functions only when asked", () => {
+      const unused = findUnusedFunctions(createUnusedRoot(), true);
+
+      assert.deepStrictEqual(
+        unused.map(({ func, exported }) => `${func.name}${exported ? " (exported)" : ""}`),
+        ["leftover", "Exported (exported)"]
+      );
+    });
+
+    test("should list candidates in the report only when enabled", () => {
+      const root = createUnusedRoot();
+      assert.ok(
+        !formatWorkspaceReport({ roots: [root] }).some((line) => line.includes("unused"))
+      );
+
+      root.config.reportUnusedFunctions = true;
+      const report = formatWorkspaceReport({ roots: [root] });
+
+      assert.ok(
+        report.includes("  Potentially unused functions (not called in their package; 1 complexity):"),
+        report.join("\n")
+      );
+      assert.ok(report.includes("      1  leftover (cmd/main.go:16)"), report.join("\n"));
+    });
+  });
+
+  suite("Method Candidates", () => {
+    function createCandidateRoot(): RootMetrics {
+      const root = createRoot("root", 10, 15);
+      const source = `
+package config
+
+type Config struct {
+    Host string
+    Port int
+}
+
+func Apply(cfg *Config) {
+    cfg.Host = "localhost"
+    cfg.Port = 8080
+}
+
+func SetPort(cfg *Config, port int) {
+    cfg.Port = port
+}
+
+func (c *Config) clean(s string) string {
+    return strings.TrimSpace(s)
+}
+
+func (c *Config) String() string {
+    return "config"
+}
+`;
+      root.files = [
+        {
+          uri: vscode.Uri.file("/root/config.go"),
+          relativePath: "config.go",
+          languageId: "go",
+          functions: MetricsAnalyzerFactory.analyzeFile(source, "go"),
+        },
+      ];
+      return root;
+    }
+
+    test("should find functions writing several fields and methods ignoring their receiver", () => {
+      const candidates = findMethodCandidates(createCandidateRoot());
+
+      // SetPort writes a single field; the exported String may implement fmt.Stringer.
+      assert.deepStrictEqual(
+        candidates.map(({ func, kind, type }) => `${func.name} ${kind} ${type}`),
+        ["Apply method Config", "Config.clean function Config"]
+      );
+    });
+
+    test("should list candidates in the report only when enabled", () => {
+      const root = createCandidateRoot();
+      assert.ok(
+        !formatWorkspaceReport({ roots: [root] }).some((line) => line.includes("Method candidates"))
+      );
+
+      root.config.reportMethodCandidates = true;
+      const report = formatWorkspaceReport({ roots: [root] });
+
+      assert.ok(report.includes("  Method candidates (design hints):"), report.join("\n"));
+      assert.ok(
+        report.includes(
+          "    Apply writes 2 fields of *Config: consider a method on Config  (config.go:9)"
+        ),
+        report.join("\n")
+      );
+      assert.ok(
+        report.includes(
+          "    Config.clean never uses its receiver: consider a package function  (config.go:18)"
+        ),
+        report.join("\n")
+      );
+    });
+  });
+
+  suite("Worst Function", () => {
+    test("should find the most complex function across roots", () => {
+      const simple = createRoot("simple", 10, 15);
+      simple.files[0].functions = MetricsAnalyzerFactory.analyzeFile(
+        "package main\n\nfunc Flat() int {\n    return 0\n}\n",
+        "go"
+      );
+      const complex = createRoot("complex", 10, 15);
+
+      const worst = findWorstFunction({ roots: [simple, complex] });
+
+      assert.strictEqual(worst?.func.name, "Classify");
+      assert.strictEqual(worst?.func.complexity, 3);
+      assert.strictEqual(worst?.file, complex.files[0]);
+    });
+
+    test("should return undefined when nothing was analyzed", () => {
+      assert.strictEqual(findWorstFunction({ roots: [] }), undefined);
+    });
+  });
+
+  suite("Mixed Packages", () => {
+    const dir = path.resolve(__dirname, "../../../samples/asm");
+    const folder: vscode.WorkspaceFolder = { uri: vscode.Uri.file(dir), name: "asm", index: 0 };
+
+    test("should skip assembly files and analyze the Go files beside them", async () => {
+      const files: FileMetrics[] = [];
+      for (const name of fs.readdirSync(dir).sort()) {
+        const file = await WorkspaceAnalyzer.analyzeUri(
+          vscode.Uri.file(path.join(dir, name)),
+          folder,
+          DEFAULT_CONFIG
+        );
+        if (file) {
+          files.push(file);
+        }
+      }
+
+      assert.deepStrictEqual(
+        files.map((f) => f.relativePath),
+        ["add.go", "add_amd64.go", "add_generic.go", "sum.go"]
+      );
+      // The assembly-backed declaration in add_amd64.go has no body and is not reported.
+      assert.deepStrictEqual(
+        files.flatMap((f) => f.functions.map((func) => func.name)),
+        ["Sum", "sumAsm", "sumGeneric"]
+      );
+    });
+
+    test("should keep assembly files out of the scan", () => {
+      const extensions = MetricsAnalyzerFactory.getSupportedFileExtensions();
+      assert.ok(!extensions.includes("s") && !extensions.includes("syso"));
+      assert.strictEqual(
+        WorkspaceAnalyzer.getSkipReason(vscode.Uri.file(path.join(dir, "add_amd64.s")), DEFAULT_CONFIG),
+        "unsupported file type"
+      );
+    });
+  });
+
+  suite("Go Modules", () => {
+    const dir = path.resolve(__dirname, "../../../samples/gowork");
+    const folder: vscode.WorkspaceFolder = { uri: vscode.Uri.file(dir), name: "gowork", index: 0 };
+    const fileUri = (relativePath: string) => vscode.Uri.joinPath(folder.uri, relativePath);
+    const layout: GoModuleLayout = {
+      modules: ["app", "lib", "tools"].map((moduleDir) => ({
+        dir: fileUri(moduleDir).path,
+        path: parseModulePath(fs.readFileSync(path.join(dir, moduleDir, "go.mod"), "utf-8"))!,
+      })),
+      workspaceDirs: parseWorkspaceUses(fs.readFileSync(path.join(dir, "go.work"), "utf-8")).map(
+        (use) => path.posix.resolve(folder.uri.path, use)
+      ),
+    };
+
+    test("should read module paths and go.work use directives", () => {
+      assert.deepStrictEqual(
+        layout.modules.map((module) => module.path),
+        ["example.com/app", "example.com/lib", "example.com/tools"]
+      );
+      assert.strictEqual(parseModulePath('module "example.com/quoted" // legacy\n'), "example.com/quoted");
+      assert.strictEqual(parseModulePath("go 1.22\n"), undefined);
+      assert.deepStrictEqual(
+        parseWorkspaceUses(fs.readFileSync(path.join(dir, "go.work"), "utf-8")),
+        ["./app", "./lib"]
+      );
+      assert.deepStrictEqual(parseWorkspaceUses("go 1.22\nuse ./one\nuse (\n\t./two\n)\n"), [
+        "./one",
+        "./two",
+      ]);
+    });
+
+    test("should skip dependencies and modules go.work does not use", () => {
+      const reason = (relativePath: string) =>
+        WorkspaceAnalyzer.getSkipReason(fileUri(relativePath), DEFAULT_CONFIG, layout);
+
+      assert.strictEqual(reason("app/main.go"), undefined);
+      assert.strictEqual(reason("lib/lib.go"), undefined);
+      assert.strictEqual(reason("app/vendor/github.com/acme/retry/retry.go"), "vendored Go dependency");
+      assert.strictEqual(
+        reason("tools/gen.go"),
+        "in Go module example.com/tools, which go.work does not use"
+      );
+      // Files outside every module are analyzed with their directory as the package.
+      assert.strictEqual(reason("gen.go"), undefined);
+      assert.strictEqual(reason("samples/loose.go"), undefined);
+      assert.strictEqual(
+        reason("pkg/mod/golang.org/x/text@v0.14.0/cases/map.go"),
+        "in the Go module cache"
+      );
+      // Without modules, Go files are not scoped.
+      assert.strictEqual(WorkspaceAnalyzer.getSkipReason(fileUri("tools/gen.go"), DEFAULT_CONFIG), undefined);
+    });
+
+    test("should resolve import paths from the deepest module", () => {
+      const nested: GoModuleLayout = {
+        modules: [
+          { dir: "/ws", path: "example.com/root" },
+          { dir: "/ws/sub", path: "example.com/sub" },
+        ],
+      };
+
+      assert.strictEqual(resolveGoPackage(nested, "/ws/cmd/tool/main.go")?.importPath, "example.com/root/cmd/tool");
+      assert.strictEqual(resolveGoPackage(nested, "/ws/sub/a/b.go")?.importPath, "example.com/sub/a");
+      assert.strictEqual(resolveGoPackage(nested, "/ws/sub/doc.go")?.importPath, "example.com/sub");
+      assert.strictEqual(resolveGoPackage(nested, "/ws/pkg/mod/golang.org/x/text@v0.14.0/cases/map.go"), undefined);
+      assert.ok(isModuleCachePath("pkg/mod/golang.org/x/text@v0.14.0/cases/map.go"));
+      assert.ok(!isModuleCachePath("internal/email@domain/parse.go"));
+    });
+
+    test("should label Go files with their package import path in the report", async () => {
+      const files: FileMetrics[] = [];
+      for (const relativePath of ["app/internal/store/store.go", "app/main.go", "lib/lib.go"]) {
+        const file = await WorkspaceAnalyzer.analyzeUri(fileUri(relativePath), folder, DEFAULT_CONFIG, layout);
+        assert.ok(file, relativePath);
+        files.push(file);
+      }
+      assert.deepStrictEqual(
+        files.map((f) => f.goPackage),
+        ["example.com/app/internal/store", "example.com/app", "example.com/lib"]
+      );
+
+      const lines = formatWorkspaceReport({
+        roots: [{ name: "gowork", folder, config: DEFAULT_CONFIG, files, goModules: layout }],
+      });
+      assert.ok(lines.includes("  Go modules: example.com/app, example.com/lib"), lines.join("\n"));
+      assert.ok(
+        lines.includes("  app/internal/store/store.go  (package example.com/app/internal/store; longest: Get, 6 lines; params avg 3, max 3)"),
+        lines.join("\n")
+      );
+    });
+  });
+
+  suite("Git Submodules", () => {
+    const dir = path.resolve(__dirname, "../../../samples/submodules");
+    const folder: vscode.WorkspaceFolder = { uri: vscode.Uri.file(dir), name: "submodules", index: 0 };
+    const fileUri = (relativePath: string) => vscode.Uri.joinPath(folder.uri, relativePath);
+
+    test("should parse submodule paths from .gitmodules", () => {
+      assert.deepStrictEqual(
+        parseGitmodules(fs.readFileSync(path.join(dir, ".gitmodules"), "utf-8")),
+        ["third_party/retry", "api/proto"]
+      );
+      assert.deepStrictEqual(
+        parseGitmodules('[core]\n\tpath = ignored\n[submodule "x"]\n\tpath = ./lib/x/ # pinned\n'),
+        ["lib/x"]
+      );
+    });
+
+    test("should skip submodule contents by default", async () => {
+      const submodules = await WorkspaceAnalyzer.loadSubmodules(folder, DEFAULT_CONFIG);
+      const reason = (relativePath: string) =>
+        WorkspaceAnalyzer.getSkipReason(fileUri(relativePath), DEFAULT_CONFIG, undefined, submodules);
+
+      assert.strictEqual(
+        reason("third_party/retry/retry.go"),
+        "in git submodule retry (enable analysis.includeSubmodules to analyze)"
+      );
+      assert.ok(reason("api/proto/gen.go")?.startsWith("in git submodule proto"));
+      assert.strictEqual(reason("third_party/retry.go"), undefined);
+      assert.strictEqual(reason("main.go"), undefined);
+    });
+
+    test("should analyze submodules when enabled", async () => {
+      assert.strictEqual(
+        await WorkspaceAnalyzer.loadSubmodules(folder, { ...DEFAULT_CONFIG, includeSubmodules: true }),
+        undefined
+      );
+    });
+  });
+
+  suite("Completion Summary", () => {
+    test("should count functions over each root's warning threshold", () => {
+      const summary = summarizeWorkspace({ roots: [createRoot("a", 3, 5), createRoot("b", 10, 20)] });
+
+      assert.strictEqual(
+        summary,
+        "Analyzed 2 files, 2 functions; 1 over threshold. Worst: Classify (3) in classify.go."
+      );
+    });
+
+    test("should omit the worst function when nothing was analyzed", () => {
+      assert.strictEqual(
+        summarizeWorkspace({ roots: [] }),
+        "Analyzed 0 files, 0 functions; 0 over threshold."
+      );
+    });
+  });
+
+  suite("File Listing", () => {
+    test("should explain why files are skipped", () => {
+      const config = { ...DEFAULT_CONFIG, excludePatterns: ["**/gen/**", "**/*.test.*"] };
+
+      assert.strictEqual(
+        WorkspaceAnalyzer.getSkipReason(vscode.Uri.file("/repo/main.go"), config),
+        undefined
+      );
+      assert.strictEqual(
+        WorkspaceAnalyzer.getSkipReason(vscode.Uri.file("/repo/gen/api.go"), config),
+        "matches exclude pattern **/gen/**"
+      );
+      assert.strictEqual(
+        WorkspaceAnalyzer.getSkipReason(vscode.Uri.file("/repo/notes.md"), config),
+        "unsupported file type"
+      );
+      assert.ok(
+        WorkspaceAnalyzer.getSkipReason(vscode.Uri.file("/repo/app.test.ts"), config)
+          ?.includes("enable includeTests")
+      );
+      assert.strictEqual(
+        WorkspaceAnalyzer.getSkipReason(vscode.Uri.file("/repo/app.test.ts"), {
+          ...config,
+          includeTests: true,
+        }),
+        undefined
+      );
+    });
+
+    test("should keep test files in excluded directories skipped with includeTests", () => {
+      const config = { ...DEFAULT_CONFIG, includeTests: true };
+
+      assert.strictEqual(
+        WorkspaceAnalyzer.getSkipReason(vscode.Uri.file("/repo/node_modules/lib/lib.test.ts"), config),
+        "matches exclude pattern **/node_modules/**"
+      );
+      assert.strictEqual(
+        WorkspaceAnalyzer.getSkipReason(vscode.Uri.file("/repo/src/lib.test.ts"), config),
+        undefined
+      );
+    });
+
+    test("should render included files before skipped ones", () => {
+      const lines = formatFileListing([
+        {
+          name: "root",
+          included: ["main.go"],
+          skipped: [{ relativePath: "gen/api.go", reason: "matches exclude pattern **/gen/**" }],
+        },
+      ]);
+
+      assert.deepStrictEqual(lines, [
+        "root  (1 included, 1 skipped)",
+        "  ✓ main.go",
+        "  ✗ gen/api.go — matches exclude pattern **/gen/**",
+        "",
+      ]);
+    });
+
+    test("should list one entry per open workspace folder", async () => {
+      const listings = await WorkspaceAnalyzer.listFiles();
+
+      assert.strictEqual(listings.length, vscode.workspace.workspaceFolders?.length ?? 0);
+    });
+  });
+
+  suite("Workspace Scan", () => {
+    test("should return one entry per open workspace folder", async () => {
+      const metrics = await WorkspaceAnalyzer.analyzeWorkspace();
+
+      assert.strictEqual(
+        metrics.roots.length,
+        vscode.workspace.workspaceFolders?.length ?? 0
+      );
+    });
+
+    test("should not analyze a folder outside the workspace", async () => {
+      const metrics = await WorkspaceAnalyzer.analyzeSubtree(
+        vscode.Uri.file("/code-metrics-outside-workspace")
+      );
+
+      assert.strictEqual(metrics, undefined);
+    });
+  });
+});
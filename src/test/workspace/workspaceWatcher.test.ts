@@ -0,0 +1,110 @@
+import * as assert from "assert";
+import * as fs from "fs";
+import * as os from "os";
+import * as path from "path";
+import * as vscode from "vscode";
+import { RootMetrics, WorkspaceMetrics } from "../../workspace/workspaceAnalyzer";
+import { WorkspaceMetricsWatcher } from "../../workspace/workspaceWatcher";
+import { DEFAULT_CONFIG } from "../../configuration";
+
+suite("Workspace Watcher Tests", () => {
+  let tempDir: string;
+  let root: RootMetrics;
+  let watcher: WorkspaceMetricsWatcher;
+
+  setup(() => {
+    tempDir = fs.mkdtempSync(path.join(os.tmpdir(), "code-metrics-watch-"));
+    const folder: vscode.WorkspaceFolder = {
+      uri: vscode.Uri.file(tempDir),
+      name: "watched",
+      index: 0,
+    };
+    root = {
+      name: folder.name,
+      folder,
+      config: { ...DEFAULT_CONFIG, excludePatterns: ["**/vendor/**"] },
+      files: [],
+    };
+    const metrics: WorkspaceMetrics = { roots: [root] };
+    // A long debounce keeps the timer from firing on its own; tests flush explicitly.
+    watcher = new WorkspaceMetricsWatcher(metrics, 60000);
+  });
+
+  teardown(() => {
+    watcher.dispose();
+    fs.rmSync(tempDir, { recursive: true, force: true });
+  });
+
+  function writeFile(relativePath: string, content: string): vscode.Uri {
+    const filePath = path.join(tempDir, relativePath);
+    fs.mkdirSync(path.dirname(filePath), { recursive: true });
+    fs.writeFileSync(filePath, content);
+    return vscode.Uri.file(filePath);
+  }
+
+  test("should analyze created files and re-analyze changed ones", async () => {
+    const uri = writeFile("pkg/a.go", "package pkg\n\nfunc A(x bool) {\n    if x {\n    }\n}\n");
+    watcher.enqueue(uri, "changed");
+    await watcher.flush();
+
+    assert.strictEqual(root.files.length, 1);
+    assert.strictEqual(root.files[0].relativePath, "pkg/a.go");
+    assert.strictEqual(root.files[0].functions[0].complexity, 1);
+
+    writeFile("pkg/a.go", "package pkg\n\nfunc A() {}\n");
+    watcher.enqueue(uri, "changed");
+    await watcher.flush();
+
+    assert.strictEqual(root.files.length, 1);
+    assert.strictEqual(root.files[0].functions[0].complexity, 0);
+  });
+
+  test("should remove deleted files and keep files sorted", async () => {
+    const b = writeFile("b.go", "package main\n\nfunc B() {}\n");
+    const a = writeFile("a.go", "package main\n\nfunc A() {}\n");
+    watcher.enqueue(b, "changed");
+    watcher.enqueue(a, "changed");
+    await watcher.flush();
+
+    assert.deepStrictEqual(root.files.map((f) => f.relativePath), ["a.go", "b.go"]);
+
+    fs.rmSync(b.fsPath);
+    watcher.enqueue(b, "deleted");
+    await watcher.flush();
+
+    assert.deepStrictEqual(root.files.map((f) => f.relativePath), ["a.go"]);
+  });
+
+  test("should coalesce a burst of events into one update", async () => {
+    const uri = writeFile("c.go", "package main\n\nfunc C() {}\n");
+    let updates = 0;
+    const listener = watcher.onDidUpdate(() => updates++);
+    try {
+      watcher.enqueue(uri, "changed");
+      watcher.enqueue(uri, "changed");
+      watcher.enqueue(uri, "changed");
+      await watcher.flush();
+
+      assert.strictEqual(updates, 1);
+      assert.strictEqual(root.files.length, 1);
+    } finally {
+      listener.dispose();
+    }
+  });
+
+  test("should ignore excluded, unsupported, and out-of-root files", async () => {
+    let updates = 0;
+    const listener = watcher.onDidUpdate(() => updates++);
+    try {
+      watcher.enqueue(writeFile("vendor/lib.go", "package lib\n\nfunc L() {}\n"), "changed");
+      watcher.enqueue(writeFile("notes.md", "# notes\n"), "changed");
+      watcher.enqueue(vscode.Uri.file(path.join(os.tmpdir(), "elsewhere.go")), "changed");
+      await watcher.flush();
+
+      assert.strictEqual(root.files.length, 0);
+      assert.strictEqual(updates, 0);
+    } finally {
+      listener.dispose();
+    }
+  });
+});
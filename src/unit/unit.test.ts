@@ -19,6 +19,53 @@ import {
   UnifiedFunctionMetrics,
   UnifiedMetricsDetail,
 } from "../metricsAnalyzer/metricsAnalyzerFactory";
+import { countFileLogicalLines, countLogicalLines, getLongestFunction } from "../metricsAnalyzer/linesOfCode";
+import { countTextTokens, countTokens } from "../metricsAnalyzer/tokenCount";
+import { findGeneratedCode } from "../metricsAnalyzer/generatedCode";
+import { markRecursion } from "../metricsAnalyzer/recursion";
+import { markFeatureFlags } from "../metricsAnalyzer/featureFlags";
+import { getFunctionFingerprint } from "../metricsAnalyzer/fingerprint";
+import { RuleBasedAnalyzer } from "../metricsAnalyzer/ruleBasedAnalyzer";
+import { JavaRulesAnalyzer } from "../metricsAnalyzer/languages/javaRules";
+import Parser from "tree-sitter";
+import Java from "tree-sitter-java";
+import {
+  checkComplexityBudget,
+  collectComplexityChanges,
+  compareFunctionMetrics,
+  compareWithBaseline,
+  diffFunctionMetrics,
+  formatBaselineComment,
+  formatBudgetCheck,
+  formatChangeReport,
+  parseRemoteRef,
+} from "../baseline/baseline";
+import { getBaselineOptions, parseArguments } from "../cli/compareBaseline";
+import { loadProjectConfig } from "../projectConfig";
+import { collectSamples, parseArguments as parseExportArguments } from "../cli/exportOpenMetrics";
+import { formatOpenMetrics } from "../export/openMetrics";
+import { findExcludingPattern } from "../workspace/fileFilters";
+import { formatFunctionHistory, getFunctionHistory } from "../history/functionHistory";
+import {
+  AnnotationEdit,
+  getAnnotationEdits,
+  getIgnoreEdit,
+  getStripEdits,
+  isIgnoredFunction,
+} from "../annotations/complexityAnnotations";
+import { execFileSync } from "child_process";
+import * as fs from "fs";
+import * as os from "os";
+import * as path from "path";
+import { formatMetricValue } from "../metricsAnalyzer/numberFormat";
+import { estimateDebt, formatDebt, getFunctionDebt } from "../workspace/technicalDebt";
+import { isTiming, measureAnalysis, timePhase } from "../metricsAnalyzer/analysisTiming";
+import {
+  findFileCoverage,
+  getFunctionCoverage,
+  getRiskScore,
+  parseCoverage,
+} from "../coverage/coverage";
 import { SampleCSharpCode } from "../test/testUtils";
 
 describe("Core Logic Unit Tests (Node.js)", () => {
@@ -449,6 +496,52 @@ func Complex(x, y int) int {
         assert.ok(detail.column >= 1);
       });
     });
+
+    it("should leave out functions whose qualified name matches an excluded pattern", () => {
+      const source = [
+        "package main",
+        "type User struct{ Name string }",
+        "func (u User) String() string { return u.Name }",
+        "func (u *User) MarshalJSON() ([]byte, error) { return nil, nil }",
+        "func Stringify(u User) string { return u.String() }",
+      ].join("\n");
+      const names = (excludeFunctions?: string[]) =>
+        MetricsAnalyzerFactory.analyzeFile(source, "go", { excludeFunctions }).map((f) => f.name);
+
+      assert.deepStrictEqual(names(), ["User.String", "User.MarshalJSON", "Stringify"]);
+      assert.deepStrictEqual(names(["^User\\.(String|MarshalJSON)$"]), ["Stringify"]);
+      // Invalid patterns are ignored rather than failing the analysis.
+      assert.deepStrictEqual(names(["(", "^Stringify$"]), ["User.String", "User.MarshalJSON"]);
+    });
+
+    it("should analyze source text and report failures instead of throwing", () => {
+      const analysis = MetricsAnalyzerFactory.analyzeSource(
+        "package main\n\nfunc Max(a, b int) int {\n\tif a > b {\n\t\treturn a\n\t}\n\treturn b\n}\n",
+        "go"
+      );
+      assert.strictEqual(analysis.error, undefined);
+      assert.deepStrictEqual(
+        analysis.functions.map((f) => [f.name, f.complexity]),
+        [["Max", 1]]
+      );
+
+      const unsupported = MetricsAnalyzerFactory.analyzeSource("IDENTIFICATION DIVISION.", "cobol");
+      assert.deepStrictEqual(unsupported.functions, []);
+      assert.ok(unsupported.error?.includes("cobol"));
+
+      const unregister = MetricsAnalyzerFactory.registerAnalyzer("toylang-failing", {
+        analyzeFile: () => {
+          throw new Error("unexpected token");
+        },
+      });
+      try {
+        const failed = MetricsAnalyzerFactory.analyzeSource("fn", "toylang-failing");
+        assert.deepStrictEqual(failed.functions, []);
+        assert.strictEqual(failed.error, "Analysis of toylang-failing failed: unexpected token");
+      } finally {
+        unregister();
+      }
+    });
   });
 
   describe("Edge Cases", () => {
@@ -1142,12 +1235,15 @@ def greet(name):
       const languages = MetricsAnalyzerFactory.getSupportedLanguages();
       const expected = [
         "csharp",
+        "dart",
+        "elixir",
         "go",
         "java",
         "javascript",
         "javascriptreact",
         "python",
         "rust",
+        "sql",
         "typescript",
         "typescriptreact",
       ];
@@ -1173,6 +1269,1261 @@ def greet(name):
     });
   });
 
+  describe("MetricsAnalyzerFactory.getLanguageIdForFile()", () => {
+    it("should map supported file extensions to language identifiers", () => {
+      assert.strictEqual(MetricsAnalyzerFactory.getLanguageIdForFile("/src/main.go"), "go");
+      assert.strictEqual(MetricsAnalyzerFactory.getLanguageIdForFile("C:\\src\\App.tsx"), "typescriptreact");
+      assert.strictEqual(MetricsAnalyzerFactory.getLanguageIdForFile("lib/index.MJS"), "javascript");
+      assert.strictEqual(MetricsAnalyzerFactory.getLanguageIdForFile("Program.cs"), "csharp");
+      assert.strictEqual(MetricsAnalyzerFactory.getLanguageIdForFile("views/page.gohtml"), "gotmpl");
+      assert.strictEqual(MetricsAnalyzerFactory.getLanguageIdForFile("lib/my_app/accounts.ex"), "elixir");
+      assert.strictEqual(MetricsAnalyzerFactory.getLanguageIdForFile("test/test_helper.exs"), "elixir");
+      assert.strictEqual(MetricsAnalyzerFactory.getLanguageIdForFile("lib/widgets/cart_view.dart"), "dart");
+      assert.strictEqual(MetricsAnalyzerFactory.getLanguageIdForFile("db/functions/apply_discount.sql"), "sql");
+    });
+
+    it("should return undefined for unsupported or extension-less files", () => {
+      assert.strictEqual(MetricsAnalyzerFactory.getLanguageIdForFile("README.md"), undefined);
+      assert.strictEqual(MetricsAnalyzerFactory.getLanguageIdForFile("Makefile"), undefined);
+      assert.strictEqual(MetricsAnalyzerFactory.getLanguageIdForFile("/repo/.codemetrics.json"), undefined);
+      assert.strictEqual(MetricsAnalyzerFactory.getLanguageIdForFile("/a.go/Makefile"), undefined);
+    });
+
+    it("should only map extensions to supported languages", () => {
+      for (const ext of MetricsAnalyzerFactory.getSupportedFileExtensions()) {
+        const languageId = MetricsAnalyzerFactory.getLanguageIdForFile(`file.${ext}`);
+        assert.ok(languageId && MetricsAnalyzerFactory.isSupportedLanguage(languageId));
+      }
+    });
+  });
+
+  describe("Logical lines of code", () => {
+    it("should skip blank and comment-only lines in C-style languages", () => {
+      const lines = [
+        "func Run() {",
+        "    // setup",
+        "",
+        "    a := 1 // trailing comment",
+        "    /* block",
+        "       still a comment */",
+        "    b := /* inline */ 2",
+        "    /* one-liner */ c := 3",
+        "    return a + b + c",
+        "}",
+      ];
+
+      assert.strictEqual(countLogicalLines(lines, 0, lines.length - 1, "go"), 6);
+    });
+
+    it("should use hash comments for Python", () => {
+      const lines = ["def run():", "    # setup", "    a = 1  # trailing", "", "    return a // 2"];
+
+      assert.strictEqual(countLogicalLines(lines, 0, lines.length - 1, "python"), 3);
+    });
+
+    it("should only count lines inside the range", () => {
+      const lines = ["a", "b", "c", "d"];
+
+      assert.strictEqual(countLogicalLines(lines, 1, 2, "go"), 2);
+      assert.strictEqual(countLogicalLines(lines, 2, 10, "go"), 2);
+    });
+
+    it("should count declarations outside functions toward the file", () => {
+      const sourceText = "package codes\r\n\r\n// Code is a status.\r\nconst (\r\n\tOK = iota\r\n\tFailed\r\n)\r\n";
+
+      assert.strictEqual(countFileLogicalLines(sourceText, "go"), 5);
+    });
+
+    it("should populate logicalLines for every analyzed function", () => {
+      const results = MetricsAnalyzerFactory.analyzeFile(
+        "def short():\n    return 1\n\ndef long():\n    # note\n    a = 1\n    return a\n",
+        "python"
+      );
+
+      assert.deepStrictEqual(results.map((f) => f.logicalLines), [2, 3]);
+    });
+
+    it("should pick the first function with the most logical lines", () => {
+      const fn = (name: string, logicalLines?: number): UnifiedFunctionMetrics => ({
+        name,
+        complexity: 0,
+        details: [],
+        startLine: 0,
+        endLine: 0,
+        startColumn: 0,
+        endColumn: 0,
+        logicalLines,
+      });
+
+      assert.strictEqual(getLongestFunction([fn("a", 3), fn("b", 7), fn("c", 7)])?.name, "b");
+      assert.strictEqual(getLongestFunction([fn("unmeasured")]), undefined);
+      assert.strictEqual(getLongestFunction([]), undefined);
+    });
+  });
+
+  describe("Token count", () => {
+    const cStyle = { line: "//", block: true };
+
+    it("should count operators and strings as single tokens and skip comments", () => {
+      // x := a && "b // c" != y ++
+      assert.strictEqual(countTextTokens('x := a && "b // c" /* note */ != y++', cStyle), 8);
+    });
+
+    it("should use the language's comment syntax", () => {
+      const text = 'def f():\n    """doc # not\ncomment"""\n    return a ** 2  # square';
+
+      // def f ( ) : """…""" return a ** 2
+      assert.strictEqual(countTextTokens(text, { line: "#", block: false }), 10);
+      assert.strictEqual(countTextTokens("SELECT a -- note\nFROM t", { line: "--", block: true }), 4);
+    });
+
+    it("should only count tokens inside the function's columns", () => {
+      const lines = ["let a = 1; fn(b) {", "  b } // end", "next"];
+
+      // fn ( b ) { b }
+      assert.strictEqual(
+        countTokens(lines, { startLine: 0, startColumn: 11, endLine: 1, endColumn: 5 }, "go"),
+        7
+      );
+    });
+
+    it("should populate tokenCount for every analyzed function", () => {
+      const results = MetricsAnalyzerFactory.analyzeFile(
+        "def short():\n    return 1\n\ndef long(a):\n    # note\n    return a + 1\n",
+        "python"
+      );
+
+      assert.deepStrictEqual(results.map((f) => f.tokenCount), [7, 10]);
+    });
+  });
+
+  describe("Generated code", () => {
+    it("should detect the Go generated-file header before the package clause", () => {
+      const lines = ["// Copyright 2024 Example", "", "// Code generated by stringer; DO NOT EDIT.", "", "package main"];
+
+      assert.strictEqual(findGeneratedCode(lines).file, true);
+    });
+
+    it("should ignore the header after the first line of code", () => {
+      const lines = ["package main", "", "// Code generated by stringer; DO NOT EDIT."];
+
+      assert.strictEqual(findGeneratedCode(lines).file, false);
+    });
+
+    it("should find marked regions, running an unterminated one to the end", () => {
+      const lines = [
+        "package main",
+        "//metrics:generated-begin",
+        "func a() {}",
+        "//metrics:generated-end",
+        "func b() {}",
+        "  //metrics:generated-begin",
+        "func c() {}",
+      ];
+
+      assert.deepStrictEqual(findGeneratedCode(lines).regions, [
+        { start: 1, end: 3 },
+        { start: 5, end: 6 },
+      ]);
+    });
+
+    it("should tag generated functions and leave them out when excluded", () => {
+      const source = [
+        "package main",
+        "",
+        "func Handwritten(x int) int {",
+        "\tif x > 0 {",
+        "\t\treturn x",
+        "\t}",
+        "\treturn 0",
+        "}",
+        "",
+        "//metrics:generated-begin",
+        "func Generated(x int) int {",
+        "\tif x > 0 {",
+        "\t\treturn x",
+        "\t}",
+        "\treturn 0",
+        "}",
+        "//metrics:generated-end",
+      ].join("\n");
+
+      const tagged = MetricsAnalyzerFactory.analyzeFile(source, "go");
+      assert.deepStrictEqual(
+        tagged.map((f) => [f.name, f.generated === true]),
+        [["Handwritten", false], ["Generated", true]]
+      );
+
+      const excluded = MetricsAnalyzerFactory.analyzeFile(source, "go", { excludeGenerated: true });
+      assert.deepStrictEqual(excluded.map((f) => f.name), ["Handwritten"]);
+    });
+
+    it("should exclude every function of a generated file", () => {
+      const source = "// Code generated by mockgen. DO NOT EDIT.\n\npackage mocks\n\nfunc New() int {\n\treturn 1\n}\n";
+
+      assert.strictEqual(MetricsAnalyzerFactory.analyzeFile(source, "go", { excludeGenerated: true }).length, 0);
+      assert.strictEqual(MetricsAnalyzerFactory.analyzeFile(source, "go")[0].generated, true);
+    });
+  });
+
+  describe("Metric number formatting", () => {
+    it("should write integers plainly in every locale", () => {
+      assert.strictEqual(formatMetricValue(12345, "de-DE"), "12345");
+      assert.strictEqual(formatMetricValue(0, "fr-FR"), "0");
+    });
+
+    it("should use the locale's decimal separator for fractions", () => {
+      assert.strictEqual(formatMetricValue(2.3, "en-US"), "2.3");
+      assert.strictEqual(formatMetricValue(2.3, "de-DE"), "2,3");
+      assert.strictEqual(formatMetricValue(1234.567, "de-DE"), "1234,57");
+    });
+
+    it("should fall back to English for malformed locales", () => {
+      assert.strictEqual(formatMetricValue(2.5, "not a locale!"), "2.5");
+    });
+  });
+
+  describe("Recursion detection", () => {
+    const func = (name: string, calls: string[]): UnifiedFunctionMetrics => ({
+      name,
+      complexity: 0,
+      details: [],
+      startLine: 0,
+      endLine: 0,
+      startColumn: 0,
+      endColumn: 0,
+      calls,
+    });
+
+    it("should tag functions that call themselves", () => {
+      const functions = [func("fact", ["fact"]), func("main", ["fact"])];
+      markRecursion(functions);
+
+      assert.strictEqual(functions[0].recursion, "direct");
+      assert.strictEqual(functions[1].recursion, undefined);
+    });
+
+    it("should tag every member of a mutual recursion cycle", () => {
+      const functions = [
+        func("isEven", ["isOdd"]),
+        func("isOdd", ["isEven"]),
+        func("main", ["isEven"]),
+      ];
+      markRecursion(functions);
+
+      assert.strictEqual(functions[0].recursion, "mutual");
+      assert.strictEqual(functions[1].recursion, "mutual");
+      assert.deepStrictEqual(functions[0].recursionCycle, ["isEven", "isOdd"]);
+      assert.strictEqual(functions[2].recursion, undefined);
+    });
+
+    it("should ignore calls to functions outside the file", () => {
+      const functions = [func("run", ["fmt", "run2"])];
+      markRecursion(functions);
+
+      assert.strictEqual(functions[0].recursion, undefined);
+    });
+
+    it("should tag Go recursion end to end", () => {
+      const source = [
+        "package main",
+        "",
+        "func ping(n int) int {",
+        "    if n == 0 {",
+        "        return 0",
+        "    }",
+        "    return pong(n - 1)",
+        "}",
+        "",
+        "func pong(n int) int {",
+        "    return ping(n)",
+        "}",
+      ].join("\n");
+      const results = MetricsAnalyzerFactory.analyzeFile(source, "go");
+
+      assert.deepStrictEqual(results.map((r) => r.recursion), ["mutual", "mutual"]);
+    });
+  });
+
+  describe("Optional metrics", () => {
+    const source = [
+      "package main",
+      "",
+      "func count(n int) int {",
+      "    if n == 0 {",
+      "        return 0",
+      "    }",
+      "    return count(n - 1)",
+      "}",
+    ].join("\n");
+
+    it("should compute every optional metric by default", () => {
+      const [result] = MetricsAnalyzerFactory.analyzeFile(source, "go");
+
+      assert.strictEqual(result.logicalLines, 6);
+      assert.ok(result.tokenCount! > 0);
+      assert.strictEqual(result.recursion, "direct");
+    });
+
+    it("should skip the metrics that are not enabled", () => {
+      const [result] = MetricsAnalyzerFactory.analyzeFile(source, "go", { metrics: ["linesOfCode"] });
+
+      assert.strictEqual(result.complexity, 1);
+      assert.strictEqual(result.logicalLines, 6);
+      assert.strictEqual(result.tokenCount, undefined);
+      assert.strictEqual(result.recursion, undefined);
+    });
+  });
+
+  describe("Feature flag checks", () => {
+    const lines = [
+      "function render(user) {",
+      "  if (isEnabled('beta') && user) {",
+      "    if (user.admin) {",
+      "    }",
+      "  } else if (user.guest) {",
+      "  }",
+      "}",
+    ];
+    const detail = (reason: string, line: number, column: number, nesting: number) => ({
+      increment: reason.startsWith("if") ? 1 + nesting : 1,
+      reason,
+      line,
+      column,
+      nesting,
+    });
+    const render = (): UnifiedFunctionMetrics => ({
+      name: "render",
+      complexity: 5,
+      details: [
+        detail("if statement", 2, 3, 0),
+        detail("logical && operator", 2, 25, 0),
+        detail("if statement", 3, 5, 1),
+        detail("else if clause", 5, 5, 0),
+      ],
+      startLine: 0,
+      endLine: 6,
+      startColumn: 0,
+      endColumn: 1,
+    });
+
+    it("should drop flag checks and the nesting they add", () => {
+      const functions = [render()];
+      markFeatureFlags(functions, lines, [/isEnabled\(/]);
+
+      // The check (1) and the nesting it adds to the inner if (1); the else if stays.
+      assert.strictEqual(functions[0].featureFlagChecks, 1);
+      assert.strictEqual(functions[0].steadyStateComplexity, 3);
+    });
+
+    it("should leave functions without flag checks untouched", () => {
+      const functions = [render()];
+      markFeatureFlags(functions, lines, [/flags\./]);
+      markFeatureFlags(functions, lines, []);
+
+      assert.strictEqual(functions[0].featureFlagChecks, undefined);
+      assert.strictEqual(functions[0].steadyStateComplexity, undefined);
+    });
+
+    it("should estimate Go steady-state complexity end to end", () => {
+      const source = [
+        "package main",
+        "",
+        "func checkout(cart Cart) int {",
+        "    total := 0",
+        "    if flags.IsEnabled(\"new-checkout\") {",
+        "        for _, item := range cart.Items {",
+        "            if !item.Free {",
+        "                total += item.Price",
+        "            }",
+        "        }",
+        "    } else {",
+        "        total = legacyTotal(cart)",
+        "    }",
+        "    if total < 0 {",
+        "        return 0",
+        "    }",
+        "    return total",
+        "}",
+      ].join("\n");
+      const [result] = MetricsAnalyzerFactory.analyzeFile(source, "go", {
+        featureFlagPatterns: ["flags\\.IsEnabled"],
+      });
+
+      // if(1) + for(2) + if(3) + else(1) + if(1); without the flag: for(1) + if(2) + if(1)
+      assert.strictEqual(result.complexity, 8);
+      assert.strictEqual(result.steadyStateComplexity, 4);
+    });
+  });
+
+  describe("Resolver tags", () => {
+    it("should tag functions whose names match a resolver pattern", () => {
+      const source = [
+        "package graph",
+        "",
+        "func (r *queryResolver) Orders(ctx context.Context) ([]*Order, error) {",
+        "    if r.store == nil {",
+        "        return nil, errNoStore",
+        "    }",
+        "    return r.store.Orders(ctx)",
+        "}",
+        "",
+        "func loadOrders() {}",
+      ].join("\n");
+      const results = MetricsAnalyzerFactory.analyzeFile(source, "go", {
+        resolverPatterns: ["Resolver\\.", "("],
+      });
+
+      assert.deepStrictEqual(
+        results.map((f) => [f.name, f.complexity, f.tags]),
+        [
+          ["queryResolver.Orders", 1, ["resolver"]],
+          ["loadOrders", 0, undefined],
+        ]
+      );
+    });
+  });
+
+  describe("Function fingerprints", () => {
+    const fingerprint = (source: string) => {
+      const lines = source.split("\n");
+      const [func] = MetricsAnalyzerFactory.analyzeFile(source, "go");
+      return getFunctionFingerprint(lines, func);
+    };
+
+    const original = [
+      "package main",
+      "",
+      "func Count(n int) int {",
+      "    if n == 0 {",
+      "        return 0",
+      "    }",
+      "    return 1 + Count(n-1)",
+      "}",
+    ].join("\n");
+
+    it("should survive renames, moves, comments, and whitespace", () => {
+      const moved = [
+        "package main",
+        "",
+        "",
+        "// Total counts down.",
+        "func Total(n int) int {",
+        "\tif n == 0 {",
+        "",
+        "\t\treturn 0",
+        "\t}",
+        "\treturn 1 + Total(n-1)",
+        "}",
+      ].join("\n");
+
+      assert.match(fingerprint(original), /^[0-9a-f]{12}$/);
+      assert.strictEqual(fingerprint(moved), fingerprint(original));
+    });
+
+    it("should change when the body changes", () => {
+      assert.notStrictEqual(fingerprint(original.replace("1 +", "2 +")), fingerprint(original));
+    });
+
+    it("should only be computed on request", () => {
+      assert.strictEqual(MetricsAnalyzerFactory.analyzeFile(original, "go")[0].fingerprint, undefined);
+      assert.strictEqual(
+        MetricsAnalyzerFactory.analyzeFile(original, "go", { fingerprints: true })[0].fingerprint,
+        fingerprint(original)
+      );
+    });
+  });
+
+  describe("Baseline comparison", () => {
+    const func = (name: string, complexity: number, startLine = 0): UnifiedFunctionMetrics => ({
+      name,
+      complexity,
+      details: [],
+      startLine,
+      endLine: startLine,
+      startColumn: 0,
+      endColumn: 0,
+    });
+
+    it("should report increased and new functions, largest change first", () => {
+      const increases = compareFunctionMetrics(
+        [func("Same", 4), func("Grew", 2), func("Shrank", 9), func("Removed", 5)],
+        [func("Same", 4, 1), func("Grew", 7, 5), func("Shrank", 3, 9), func("Added", 1, 12)]
+      );
+
+      assert.deepStrictEqual(increases, [
+        { name: "Grew", line: 6, before: 2, after: 7 },
+        { name: "Added", line: 13, before: undefined, after: 1 },
+      ]);
+    });
+
+    it("should match repeated names in source order", () => {
+      const increases = compareFunctionMetrics(
+        [func("init", 1), func("init", 5)],
+        [func("init", 2), func("init", 5)]
+      );
+
+      assert.deepStrictEqual(increases.map((i) => [i.before, i.after]), [[1, 2]]);
+    });
+
+    it("should follow renamed functions by fingerprint", () => {
+      const renamed = { ...func("NewName", 6), fingerprint: "f1" };
+      const before = [{ ...func("OldName", 6), fingerprint: "f1" }];
+
+      assert.strictEqual(compareFunctionMetrics(before, [renamed]).length, 1);
+      assert.deepStrictEqual(compareFunctionMetrics(before, [renamed], "fingerprint"), []);
+    });
+
+    it("should render a Markdown table for CI comments", () => {
+      const comment = formatBaselineComment({
+        baseRef: "origin/main",
+        baseCommit: "abc",
+        analyzedFiles: 2,
+        files: [{ path: "svc/a.go", increases: [{ name: "Run", line: 3, before: 2, after: 6 }] }],
+      });
+
+      assert.ok(comment.startsWith("### Cognitive complexity compared with `origin/main`"));
+      assert.ok(comment.includes("| `svc/a.go:3` | `Run` | 2 | 6 | +4 |"));
+    });
+
+    it("should report every change in source order, removed functions last", () => {
+      const changes = diffFunctionMetrics(
+        [func("Same", 4), func("Grew", 2), func("Shrank", 9), func("Removed", 5, 20), func("Gone", 0)],
+        [func("Same", 4, 1), func("Grew", 7, 5), func("Shrank", 3, 9), func("Added", 1, 12), func("Flat", 0)]
+      );
+
+      assert.deepStrictEqual(changes, [
+        { name: "Grew", line: 6, before: 2, after: 7 },
+        { name: "Shrank", line: 10, before: 9, after: 3 },
+        { name: "Added", line: 13, before: undefined, after: 1 },
+        { name: "Removed", line: 21, before: 5 },
+      ]);
+    });
+
+    it("should render a pull request report with a verdict", () => {
+      const report = formatChangeReport({
+        baseRef: "origin/main",
+        baseCommit: "abc",
+        analyzedFiles: 2,
+        files: [
+          {
+            path: "svc/a.go",
+            changes: [
+              { name: "Run", line: 3, before: 2, after: 6 },
+              { name: "Stop", line: 9, before: 4, after: 3 },
+              { name: "Old", line: 20, before: 2 },
+            ],
+          },
+        ],
+      });
+
+      assert.deepStrictEqual(report.split("\n"), [
+        "### Complexity changes since `origin/main`",
+        "",
+        "⚠️ Complexity +1 overall: 1 more complex, 1 simpler, 1 removed.",
+        "",
+        "| Function | File | Before | After | Change |",
+        "| --- | --- | ---: | ---: | ---: |",
+        "| `Run` | `svc/a.go:3` | 2 | 6 | +4 |",
+        "| `Stop` | `svc/a.go:9` | 4 | 3 | −1 |",
+        "| `Old` | `svc/a.go:20` | 2 | – | removed |",
+        "",
+      ]);
+      assert.strictEqual(
+        formatChangeReport({ baseRef: "main", baseCommit: "abc", analyzedFiles: 3, files: [] }),
+        "### Complexity changes since `main`\n\n✅ No function changed in complexity across 3 changed files.\n"
+      );
+    });
+
+    it("should check the net change against a budget", () => {
+      const report = {
+        baseRef: "origin/main",
+        baseCommit: "abc",
+        analyzedFiles: 2,
+        files: [
+          {
+            path: "svc/a.go",
+            changes: [
+              { name: "Run", line: 3, before: 2, after: 9 },
+              { name: "New", line: 9, after: 4 },
+            ],
+          },
+          { path: "svc/b.go", changes: [{ name: "Old", line: 1, before: 3 }] },
+        ],
+      };
+
+      assert.deepStrictEqual(checkComplexityBudget(report, 5), { net: 8, budget: 5, exceeded: true });
+      assert.deepStrictEqual(checkComplexityBudget(report, 8), { net: 8, budget: 8, exceeded: false });
+      assert.strictEqual(
+        formatBudgetCheck(checkComplexityBudget(report, 5)),
+        "❌ Net complexity +8 exceeds the budget of +5."
+      );
+      assert.strictEqual(
+        formatBudgetCheck({ net: -2, budget: 0, exceeded: false }),
+        "✅ Net complexity −2 is within the budget of 0."
+      );
+      assert.ok(
+        formatChangeReport(report, 20).includes("\n\n✅ Net complexity +8 is within the budget of +20.\n")
+      );
+    });
+
+    it("should split remote refs for fetching", () => {
+      assert.deepStrictEqual(parseRemoteRef("origin/release/1.x"), { remote: "origin", branch: "release/1.x" });
+      assert.strictEqual(parseRemoteRef("main"), undefined);
+    });
+
+    it("should parse command-line arguments", () => {
+      assert.deepStrictEqual(parseArguments(["--base", "upstream/dev", "--cwd", "/repo", "--fail-on-increase"]), {
+        base: "upstream/dev",
+        cwd: "/repo",
+        failOnIncrease: true,
+        identity: "name",
+      });
+      assert.strictEqual(parseArguments(["--identity", "fingerprint"]).identity, "fingerprint");
+      assert.strictEqual(parseArguments(["--budget", "20"]).budget, 20);
+      assert.strictEqual(parseArguments(["--budget", "-5"]).budget, -5);
+      assert.throws(() => parseArguments(["--budget", "lots"]), /whole number/);
+      assert.throws(() => parseArguments(["--identity", "hash"]), /name or fingerprint/);
+      assert.throws(() => parseArguments(["--base"]), /needs a value/);
+      assert.throws(() => parseArguments(["--verbose"]), /Unknown argument/);
+    });
+
+    describe("in a git repository", function () {
+      let repo: string;
+      const git = (...args: string[]) =>
+        execFileSync("git", args, {
+          cwd: repo,
+          env: {
+            ...process.env,
+            GIT_AUTHOR_NAME: "test",
+            GIT_AUTHOR_EMAIL: "test@example.com",
+            GIT_COMMITTER_NAME: "test",
+            GIT_COMMITTER_EMAIL: "test@example.com",
+          },
+        });
+
+      before(function () {
+        repo = fs.mkdtempSync(path.join(os.tmpdir(), "code-metrics-baseline-"));
+        try {
+          git("init", "-q");
+        } catch {
+          this.skip(); // git is not installed
+        }
+        fs.writeFileSync(path.join(repo, "a.go"), "package a\n\nfunc F(x bool) int {\n\treturn 0\n}\n");
+        fs.writeFileSync(
+          path.join(repo, "gone.go"),
+          "package a\n\nfunc G(x, y bool) int {\n\tif x {\n\t\tif y {\n\t\t\treturn 2\n\t\t}\n\t}\n\treturn 0\n}\n"
+        );
+        git("add", ".");
+        git("commit", "-q", "-m", "base");
+        git("tag", "base");
+        fs.writeFileSync(
+          path.join(repo, "a.go"),
+          "package a\n\nfunc F(x bool) int {\n\tif x {\n\t\treturn 1\n\t}\n\treturn 0\n}\n"
+        );
+        fs.writeFileSync(path.join(repo, "notes.md"), "# not code\n");
+        fs.rmSync(path.join(repo, "gone.go"));
+      });
+
+      after(() => {
+        fs.rmSync(repo, { recursive: true, force: true });
+      });
+
+      it("should compare working tree changes with the base", async () => {
+        const comparison = await compareWithBaseline(repo, "base");
+
+        assert.strictEqual(comparison.analyzedFiles, 2);
+        assert.deepStrictEqual(comparison.files, [
+          { path: "a.go", increases: [{ name: "F", line: 3, before: 0, after: 1 }] },
+        ]);
+      });
+
+      it("should collect the changes for a pull request report", async () => {
+        const report = await collectComplexityChanges(repo, "base");
+
+        assert.strictEqual(report.analyzedFiles, 2);
+        assert.deepStrictEqual(report.files, [
+          { path: "a.go", changes: [{ name: "F", line: 3, before: 0, after: 1 }] },
+          { path: "gone.go", changes: [{ name: "G", line: 3, before: 3 }] },
+        ]);
+      });
+
+      it("should credit the complexity of deleted files to the budget", async () => {
+        const report = await collectComplexityChanges(repo, "base");
+
+        // F +1, G -3 (if(1) + nested if(2)) with gone.go deleted
+        assert.deepStrictEqual(checkComplexityBudget(report, 0), { net: -2, budget: 0, exceeded: false });
+      });
+
+      it("should list the functions of deleted files as removed in the report", async () => {
+        const report = formatChangeReport(await collectComplexityChanges(repo, "base"));
+
+        assert.deepStrictEqual(report.split("\n"), [
+          "### Complexity changes since `base`",
+          "",
+          "✅ Complexity −2 overall: 1 more complex, 1 removed.",
+          "",
+          "| Function | File | Before | After | Change |",
+          "| --- | --- | ---: | ---: | ---: |",
+          "| `F` | `a.go:3` | 0 | 1 | +1 |",
+          "| `G` | `gone.go:3` | 3 | – | removed |",
+          "",
+        ]);
+      });
+
+      it("should compare with the settings of the directory's .codemetrics.json from the CLI", async () => {
+        const projectFile = path.join(repo, ".codemetrics.json");
+        fs.writeFileSync(
+          projectFile,
+          JSON.stringify({ excludePatterns: ["gone.go"], complexityBase: 1, complexityBudget: 5 })
+        );
+        try {
+          const config = loadProjectConfig(repo);
+          const report = await collectComplexityChanges(
+            repo,
+            "base",
+            getBaselineOptions(parseArguments(["--cwd", repo]), config)
+          );
+
+          assert.strictEqual(config.complexityBudget, 5);
+          assert.deepStrictEqual(report.files, [
+            { path: "a.go", changes: [{ name: "F", line: 3, before: 1, after: 2 }] },
+          ]);
+        } finally {
+          fs.rmSync(projectFile);
+        }
+      });
+
+      it("should analyze both versions with the given analyzer options", async () => {
+        const report = await collectComplexityChanges(repo, "base", {
+          analyzerOptions: { complexityBase: 1 },
+        });
+
+        assert.deepStrictEqual(report.files, [
+          { path: "a.go", changes: [{ name: "F", line: 3, before: 1, after: 2 }] },
+          { path: "gone.go", changes: [{ name: "G", line: 3, before: 4 }] },
+        ]);
+      });
+
+      it("should explain how to fetch a missing base", async () => {
+        await assert.rejects(
+          compareWithBaseline(repo, "origin/main"),
+          /not available in this repository\. Fetch it first: git fetch origin main/
+        );
+      });
+    });
+  });
+
+  describe("File filters", () => {
+    const excludePatterns = ["**/node_modules/**", "vendor/**", "**/*.test.*", "*_test.go"];
+
+    it("should report the first exclude pattern matching a file", () => {
+      const config = { excludePatterns, includeTests: false };
+
+      assert.strictEqual(findExcludingPattern("/repo/src/main.go", config), undefined);
+      assert.strictEqual(findExcludingPattern("C:\\repo\\src\\app.test.ts", config), "**/*.test.*");
+      assert.strictEqual(findExcludingPattern("pkg/calc_test.go", config), "*_test.go");
+      assert.strictEqual(findExcludingPattern("vendor/lib/lib_test.go", config), "vendor/**");
+    });
+
+    it("should waive only the patterns matching test files by name with includeTests", () => {
+      const config = { excludePatterns, includeTests: true };
+
+      assert.strictEqual(findExcludingPattern("/repo/src/app.test.ts", config), undefined);
+      assert.strictEqual(findExcludingPattern("pkg/calc_test.go", config), undefined);
+      assert.strictEqual(findExcludingPattern("vendor/lib/lib_test.go", config), "vendor/**");
+      assert.strictEqual(
+        findExcludingPattern("/repo/node_modules/lib/lib.test.ts", config),
+        "**/node_modules/**"
+      );
+    });
+  });
+
+  describe("OpenMetrics export", () => {
+    it("should write one gauge series per function with escaped labels", () => {
+      const text = formatOpenMetrics([
+        { file: "a.go", function: "F", language: "go", startLine: 3, complexity: 2, logicalLines: 5 },
+        { file: 'dir\\"b".ts', function: "G", language: "typescript", startLine: 1, complexity: 0 },
+      ]);
+
+      assert.deepStrictEqual(text.split("\n"), [
+        "# HELP code_complexity Complexity score of a function, as configured for the analysis.",
+        "# TYPE code_complexity gauge",
+        'code_complexity{file="a.go",func="F",language="go"} 2',
+        'code_complexity{file="dir\\\\\\"b\\".ts",func="G",language="typescript"} 0',
+        "# HELP code_logical_lines Lines with code in a function, blank and comment-only lines excluded.",
+        "# TYPE code_logical_lines gauge",
+        'code_logical_lines{file="a.go",func="F",language="go"} 5',
+        "# EOF",
+        "",
+      ]);
+    });
+
+    it("should keep series unique across roots and repeated names", () => {
+      const sample = { file: "a.ts", function: "run", language: "typescript", complexity: 1 };
+      const text = formatOpenMetrics(
+        [
+          { ...sample, root: "web", startLine: 1 },
+          { ...sample, root: "web", startLine: 9 },
+          { ...sample, root: "api", startLine: 1 },
+        ],
+        { prefix: "repo" }
+      );
+
+      assert.ok(text.includes('repo_complexity{root="web",file="a.ts",func="run@1",language="typescript"} 1'));
+      assert.ok(text.includes('repo_complexity{root="web",file="a.ts",func="run@9",language="typescript"} 1'));
+      assert.ok(text.includes('repo_complexity{root="api",file="a.ts",func="run",language="typescript"} 1'));
+      assert.ok(!text.includes("logical_lines"));
+    });
+
+    it("should parse command-line arguments", () => {
+      const options = parseExportArguments([
+        "--cwd",
+        "/repo",
+        "--prefix",
+        "svc_code",
+        "--min-complexity",
+        "10",
+        "--exclude",
+        "^vendor/",
+      ]);
+
+      assert.strictEqual(options.cwd, "/repo");
+      assert.strictEqual(options.prefix, "svc_code");
+      assert.strictEqual(options.minComplexity, 10);
+      assert.deepStrictEqual(options.exclude.map((pattern) => pattern.source), ["^vendor\\/"]);
+      assert.throws(() => parseExportArguments(["--prefix", "9lives"]), /letters, digits/);
+      assert.throws(() => parseExportArguments(["--min-complexity", "-1"]), /whole number/);
+      assert.throws(() => parseExportArguments(["--exclude", "("]), /not a valid regular expression/);
+      assert.throws(() => parseExportArguments(["--cwd"]), /needs a value/);
+      assert.throws(() => parseExportArguments(["--verbose"]), /Unknown argument/);
+    });
+
+    describe("in a git repository", function () {
+      let repo: string;
+
+      before(function () {
+        repo = fs.mkdtempSync(path.join(os.tmpdir(), "code-metrics-openmetrics-"));
+        try {
+          execFileSync("git", ["init", "-q"], { cwd: repo });
+        } catch {
+          this.skip(); // git is not installed
+        }
+        fs.mkdirSync(path.join(repo, "vendor"));
+        fs.writeFileSync(path.join(repo, "a.go"), "package a\n\nfunc F(x bool) int {\n\tif x {\n\t\treturn 1\n\t}\n\treturn 0\n}\n\nfunc G() {}\n");
+        fs.writeFileSync(path.join(repo, "vendor", "v.go"), "package v\n\nfunc V() {}\n");
+        fs.writeFileSync(path.join(repo, "notes.md"), "# not code\n");
+        execFileSync("git", ["add", "."], { cwd: repo });
+      });
+
+      after(() => {
+        fs.rmSync(repo, { recursive: true, force: true });
+      });
+
+      it("should analyze the tracked files that are kept", async () => {
+        const samples = await collectSamples(
+          parseExportArguments(["--cwd", repo, "--exclude", "^vendor/"])
+        );
+
+        assert.deepStrictEqual(
+          samples.map((s) => [s.file, s.function, s.startLine, s.complexity]),
+          [
+            ["a.go", "F", 3, 1],
+            ["a.go", "G", 10, 0],
+          ]
+        );
+        assert.deepStrictEqual(
+          (await collectSamples(parseExportArguments(["--cwd", repo, "--min-complexity", "1"]))).map(
+            (s) => s.function
+          ),
+          ["F"]
+        );
+      });
+
+      it("should analyze and exclude as the directory's .codemetrics.json configures", async () => {
+        const projectFile = path.join(repo, ".codemetrics.json");
+        fs.writeFileSync(
+          projectFile,
+          JSON.stringify({ excludePatterns: ["**/vendor/**"], complexityBase: 1 })
+        );
+        try {
+          const samples = await collectSamples(parseExportArguments(["--cwd", repo]));
+
+          assert.deepStrictEqual(
+            samples.map((s) => [s.file, s.function, s.complexity]),
+            [
+              ["a.go", "F", 2],
+              ["a.go", "G", 1],
+            ]
+          );
+
+          fs.writeFileSync(projectFile, JSON.stringify({ enabled: false }));
+          assert.deepStrictEqual(await collectSamples(parseExportArguments(["--cwd", repo])), []);
+        } finally {
+          fs.rmSync(projectFile);
+        }
+      });
+    });
+  });
+
+  describe("Function history", () => {
+    it("should tabulate changes newest first with the uncommitted version on top", () => {
+      const markdown = formatFunctionHistory("F", 4, [
+        { commit: "ccc", date: "2026-03-01", subject: "nest | guard", complexity: 3 },
+        { commit: "bbb", date: "2026-02-01", subject: "add check", complexity: 1 },
+        { commit: "aaa", date: "2026-01-01", subject: "init", complexity: 1 },
+      ]);
+
+      assert.deepStrictEqual(markdown.split("\n").slice(2), [
+        "| Version | Date | Complexity | Change |",
+        "| --- | --- | ---: | ---: |",
+        "| working copy | | 4 | +1 |",
+        "| `ccc` nest \\| guard | 2026-03-01 | 3 | +2 |",
+        "| `bbb` add check | 2026-02-01 | 1 |  |",
+        "| `aaa` init | 2026-01-01 | 1 |  |",
+      ]);
+      assert.ok(formatFunctionHistory("F", 2, []).endsWith("Complexity 2; no committed history yet."));
+    });
+
+    describe("in a git repository", function () {
+      let repo: string;
+      const git = (...args: string[]) =>
+        execFileSync("git", args, {
+          cwd: repo,
+          env: {
+            ...process.env,
+            GIT_AUTHOR_NAME: "test",
+            GIT_AUTHOR_EMAIL: "test@example.com",
+            GIT_COMMITTER_NAME: "test",
+            GIT_COMMITTER_EMAIL: "test@example.com",
+          },
+        }).toString().trim();
+      const commit = (source: string, message: string) => {
+        fs.writeFileSync(path.join(repo, "a.go"), source);
+        git("commit", "-q", "-am", message);
+        return git("rev-parse", "--short", "HEAD");
+      };
+      const hashes: string[] = [];
+
+      before(function () {
+        repo = fs.mkdtempSync(path.join(os.tmpdir(), "code-metrics-history-"));
+        try {
+          git("init", "-q");
+        } catch {
+          this.skip(); // git is not installed
+        }
+        const g = (body: string) => `func G() {\n${body}}\n`;
+        const f = (body: string) => `func F(a, b bool) {\n${body}}\n`;
+        fs.writeFileSync(path.join(repo, "a.go"), "");
+        git("add", "a.go");
+        hashes.push(commit(`package a\n\n${f("")}\n${g("")}`, "init"));
+        hashes.push(commit(`package a\n\n${f("\tif a {\n\t}\n")}\n${g("")}`, "check a"));
+        hashes.push(commit(`package a\n\n${f("\tif a {\n\t}\n")}\n${g("\tprintln()\n")}`, "touch G"));
+        hashes.push(
+          commit(`package a\n\n${f("\tif a {\n\t\tif b {\n\t\t}\n\t}\n")}\n${g("\tprintln()\n")}`, "nest b")
+        );
+      });
+
+      after(() => {
+        fs.rmSync(repo, { recursive: true, force: true });
+      });
+
+      it("should list only the commits that changed the function", async () => {
+        const history = await getFunctionHistory(repo, "a.go", "F", "go");
+
+        assert.deepStrictEqual(
+          history.map((e) => [e.commit, e.subject, e.complexity]),
+          [[hashes[3], "nest b", 3], [hashes[1], "check a", 1], [hashes[0], "init", 0]]
+        );
+        assert.deepStrictEqual(
+          (await getFunctionHistory(repo, "a.go", "G", "go")).map((e) => e.subject),
+          ["touch G", "init"]
+        );
+      });
+
+      it("should stop at the entry limit and not judge the oldest inspected commit", async () => {
+        const limited = await getFunctionHistory(repo, "a.go", "F", "go", { limit: 2 });
+        assert.deepStrictEqual(limited.map((e) => e.subject), ["nest b", "check a"]);
+
+        const shallow = await getFunctionHistory(repo, "a.go", "F", "go", { maxCommits: 2 });
+        assert.deepStrictEqual(shallow.map((e) => e.subject), ["nest b"]);
+      });
+
+      it("should fail outside a repository", async () => {
+        await assert.rejects(getFunctionHistory(os.tmpdir(), "missing.go", "F", "go"));
+      });
+    });
+  });
+
+  describe("Rule-based engine", () => {
+    const javaSource = `
+public class Orders {
+  public int total(List<Order> orders, boolean strict) {
+    int sum = 0;
+    for (Order order : orders) {
+      if (order == null) {
+        continue;
+      } else if (strict && order.amount() < 0 && order.isFinal()) {
+        throw new IllegalStateException();
+      } else {
+        sum += order.amount() > 100 ? 100 : order.amount();
+      }
+    }
+    try {
+      orders.forEach(o -> { if (o.isOpen() || o.isHeld()) { sum(o); } });
+    } catch (RuntimeException e) {
+      do { sum--; } while (sum > 0 && strict);
+    }
+    return sum;
+  }
+
+  record Line(int qty) {
+    Line {
+      if (qty < 0) { throw new IllegalArgumentException(); }
+    }
+  }
+
+  interface Priced { int price(); }
+}
+`;
+
+    it("should score Java exactly like the built-in analyzer", () => {
+      const samplePath = path.resolve(__dirname, "../../samples/Test.java");
+      for (const source of [javaSource, fs.readFileSync(samplePath, "utf-8")]) {
+        assert.deepStrictEqual(
+          JavaRulesAnalyzer.analyzeFile(source),
+          JavaMetricsAnalyzer.analyzeFile(source)
+        );
+      }
+    });
+
+    it("should apply only the constructs listed in the rule table", () => {
+      const parser = new Parser();
+      parser.setLanguage(Java);
+      const results = new RuleBasedAnalyzer(parser, {
+        functions: ["method_declaration"],
+        containers: [],
+        structural: { if_statement: "if" },
+        flat: {},
+      }).analyzeFunctions(javaSource);
+
+      // Loops, else branches, and operators are not listed, so only ifs count and only
+      // ifs nest: the else-if is scored as an if nested in the first one.
+      assert.deepStrictEqual(results.map((r) => r.name), ["total"]);
+      assert.deepStrictEqual(
+        results[0].details.map((d) => [d.reason, d.increment]),
+        [["if", 1], ["if", 2], ["if", 1]]
+      );
+    });
+
+    it("should be selected per language through the analyzer options", () => {
+      const rules = MetricsAnalyzerFactory.analyzeFile(javaSource, "java", { engine: "rules" });
+      const builtin = MetricsAnalyzerFactory.analyzeFile(javaSource, "java");
+      assert.notStrictEqual(rules, builtin, "results are cached per engine");
+      assert.deepStrictEqual(rules, builtin);
+
+      // Languages without a rule table keep their built-in analyzer.
+      const go = "package main\nfunc F(a bool) {\n\tif a {\n\t}\n}\n";
+      assert.deepStrictEqual(
+        MetricsAnalyzerFactory.analyzeFile(go, "go", { engine: "rules" }),
+        MetricsAnalyzerFactory.analyzeFile(go, "go")
+      );
+    });
+  });
+
+  describe("Complexity annotations", () => {
+    const goSource = [
+      "package main",
+      "",
+      "// Classify sorts a value into a bucket.",
+      "func Classify(n int) string {",
+      "\tif n > 0 {",
+      "\t\tif n > 10 {",
+      '\t\t\treturn "big"',
+      "\t\t}",
+      '\t\treturn "small"',
+      "\t}",
+      '\treturn "none"',
+      "}",
+      "",
+      "func Simple() {}",
+    ].join("\n");
+
+    const applyEdits = (source: string, edits: AnnotationEdit[]) => {
+      const lines = source.split("\n");
+      for (const edit of [...edits].reverse()) {
+        if (edit.kind === "insert") {
+          lines.splice(edit.line, 0, edit.text);
+        } else if (edit.kind === "replace") {
+          lines[edit.line] = edit.text;
+        } else {
+          lines.splice(edit.line, 1);
+        }
+      }
+      return lines.join("\n");
+    };
+    const annotate = (source: string, languageId: string, threshold: number) =>
+      applyEdits(
+        source,
+        getAnnotationEdits(
+          source.split("\n"),
+          MetricsAnalyzerFactory.analyzeFile(source, languageId),
+          languageId,
+          threshold
+        )
+      );
+
+    it("should insert comments above functions at or above the threshold", () => {
+      const lines = annotate(goSource, "go", 3).split("\n");
+
+      // The comment sits between the doc comment and the declaration; Simple (0) is skipped.
+      assert.strictEqual(lines[3], "//metrics: cc=3");
+      assert.strictEqual(lines[4], "func Classify(n int) string {");
+      assert.strictEqual(lines.filter((line) => line.includes("metrics:")).length, 1);
+      assert.strictEqual(annotate(goSource, "go", 4), goSource);
+    });
+
+    it("should update existing comments instead of duplicating them", () => {
+      const annotated = annotate(goSource, "go", 1);
+      const lines = annotated.split("\n");
+      assert.deepStrictEqual(
+        getAnnotationEdits(lines, MetricsAnalyzerFactory.analyzeFile(annotated, "go"), "go", 1),
+        []
+      );
+
+      // Dropping the nested if lowers the complexity to 1: the comment is rewritten in place.
+      const simpler = annotated.replace("\t\tif n > 10 {", "\t\t{");
+      const updated = annotate(simpler, "go", 1);
+      assert.ok(updated.includes("//metrics: cc=1\nfunc Classify"));
+      assert.strictEqual(updated.split("metrics:").length - 1, 1);
+
+      // Below the threshold, the comment is removed.
+      assert.strictEqual(annotate(annotated, "go", 5), goSource);
+    });
+
+    it("should use the language's comment prefix and skip nested functions", () => {
+      const python = [
+        "def grade(score):",
+        "    def bonus(s):",
+        "        if s > 100:",
+        "            return 1",
+        "        return 0",
+        "    if score > 90:",
+        '        return "A"',
+        "    elif score > 80:",
+        '        return "B"',
+        '    return "C"',
+      ].join("\n");
+
+      const lines = annotate(python, "python", 1).split("\n");
+
+      // if(1) + elif(1); bonus is its own entry but would be annotated inside grade's body
+      assert.strictEqual(lines[0], "#metrics: cc=2");
+      assert.strictEqual(lines[1], "def grade(score):");
+      assert.strictEqual(lines.filter((line) => line.includes("metrics:")).length, 1);
+    });
+
+    it("should strip every annotation and leave other comments alone", () => {
+      const annotated = annotate(goSource, "go", 0);
+      assert.ok(annotated.includes("//metrics: cc=0\nfunc Simple"));
+
+      assert.strictEqual(applyEdits(annotated, getStripEdits(annotated.split("\n"), "go")), goSource);
+      assert.deepStrictEqual(getStripEdits(goSource.split("\n"), "go"), []);
+    });
+
+    it("should produce no edits for languages without line comments", () => {
+      assert.deepStrictEqual(getAnnotationEdits(["{{ if .X }}"], [], "gotmpl", 0), []);
+      assert.deepStrictEqual(getStripEdits(["//metrics: cc=1"], "gotmpl"), []);
+    });
+
+    it("should place an ignore directive below the doc comment and detect it", () => {
+      const [classify] = MetricsAnalyzerFactory.analyzeFile(goSource, "go");
+      const edit = getIgnoreEdit(goSource.split("\n"), classify, "go");
+      assert.deepStrictEqual(edit, { kind: "insert", line: 3, text: "//metrics:ignore" });
+
+      const ignored = applyEdits(goSource, [edit!]).split("\n");
+      const [moved] = MetricsAnalyzerFactory.analyzeFile(ignored.join("\n"), "go");
+      assert.ok(isIgnoredFunction(ignored, moved, "go"));
+      assert.strictEqual(getIgnoreEdit(ignored, moved, "go"), undefined);
+      assert.ok(!isIgnoredFunction(goSource.split("\n"), classify, "go"));
+    });
+
+    it("should place an ignore directive above decorators at the declaration's indentation", () => {
+      const python = [
+        "class Grader:",
+        "    @staticmethod",
+        "    def grade(score):",
+        "        if score > 90:",
+        '            return "A"',
+        '        return "B"',
+      ].join("\n");
+      const [grade] = MetricsAnalyzerFactory.analyzeFile(python, "python");
+
+      const edit = getIgnoreEdit(python.split("\n"), grade, "python");
+      assert.deepStrictEqual(edit, { kind: "insert", line: 1, text: "    #metrics:ignore" });
+      assert.ok(isIgnoredFunction(applyEdits(python, [edit!]).split("\n"), { ...grade, startLine: 3 }, "python"));
+      // A reason may follow the directive; a longer word is not the directive.
+      const lines = (directive: string) => ["//" + directive, "func F() {}"];
+      const f = { ...grade, startLine: 1 };
+      assert.ok(isIgnoredFunction(lines("metrics:ignore generated parser"), f, "go"));
+      assert.ok(!isIgnoredFunction(lines("metrics:ignored"), f, "go"));
+    });
+  });
+
+  describe("Coverage overlay", () => {
+    const func = (startLine: number, endLine: number): UnifiedFunctionMetrics => ({
+      name: "f",
+      complexity: 10,
+      details: [],
+      startLine,
+      endLine,
+      startColumn: 0,
+      endColumn: 0,
+    });
+
+    it("should parse lcov line hits per source file", () => {
+      const report = parseCoverage(
+        ["TN:", "SF:/repo/src/a.ts", "DA:1,1", "DA:2,0", "end_of_record", "SF:src\\b.ts", "DA:5,3", "end_of_record"].join("\n")
+      );
+
+      assert.deepStrictEqual([...report.keys()], ["/repo/src/a.ts", "src/b.ts"]);
+      assert.strictEqual(report.get("/repo/src/a.ts")?.get(2), 0);
+      assert.strictEqual(report.get("src/b.ts")?.get(5), 3);
+    });
+
+    it("should parse Go cover profiles and spread block counts over their lines", () => {
+      const report = parseCoverage(
+        ["mode: set", "example.com/m/pkg/a.go:3.20,5.2 2 1", "example.com/m/pkg/a.go:6.2,7.3 1 0"].join("\n")
+      );
+      const file = findFileCoverage(report, "pkg/a.go");
+
+      assert.ok(file);
+      assert.deepStrictEqual([...file!.entries()], [[3, 1], [4, 1], [5, 1], [6, 0], [7, 0]]);
+    });
+
+    it("should match coverage paths by exact path or path suffix", () => {
+      const report = parseCoverage("SF:/abs/repo/pkg/a.go\nDA:1,1\nend_of_record\n");
+
+      assert.ok(findFileCoverage(report, "pkg/a.go"));
+      assert.strictEqual(findFileCoverage(report, "a.go/x.go"), undefined);
+      assert.strictEqual(findFileCoverage(report, "kg/a.go"), undefined);
+    });
+
+    it("should compute function coverage over instrumented lines only", () => {
+      // Lines are 1-based in coverage and 0-based in function metrics.
+      const coverage = new Map([[2, 1], [3, 0], [4, 2], [5, 0]]);
+
+      assert.strictEqual(getFunctionCoverage(func(1, 4), coverage), 0.5);
+      assert.strictEqual(getFunctionCoverage(func(10, 12), coverage), undefined);
+    });
+
+    it("should weight complexity by the uncovered share", () => {
+      assert.strictEqual(getRiskScore(10, 0), 10);
+      assert.strictEqual(getRiskScore(10, 1), 0);
+      assert.strictEqual(getRiskScore(7, 0.25), 5.3);
+    });
+  });
+
   describe("Java Analyzer Additional Coverage", () => {
     it("should count for loop", () => {
       const sourceCode = `
@@ -3679,4 +5030,73 @@ public enum Operation {
       assert.strictEqual(results[0].complexity, 2, "Rust a&&b||c should count as 2");
     });
   });
+
+  describe("Technical debt estimate", () => {
+    const factors = { baseMinutes: 5, minutesPerPoint: 1 };
+
+    it("charges functions from the warning threshold on", () => {
+      assert.strictEqual(getFunctionDebt(9, 10, factors), 0);
+      assert.strictEqual(getFunctionDebt(10, 10, factors), 5);
+      assert.strictEqual(getFunctionDebt(17, 10, factors), 12);
+      assert.strictEqual(getFunctionDebt(17, 10, { baseMinutes: 0, minutesPerPoint: 10 }), 70);
+    });
+
+    it("sums the debt of a group of functions", () => {
+      const functions = [{ complexity: 3 }, { complexity: 12 }, { complexity: 20 }];
+
+      // (5 + 2) + (5 + 10)
+      assert.strictEqual(estimateDebt(functions, 10, factors), 22);
+      assert.strictEqual(estimateDebt([], 10, factors), 0);
+    });
+
+    it("formats durations as hours and minutes", () => {
+      assert.strictEqual(formatDebt(0), "0m");
+      assert.strictEqual(formatDebt(45), "45m");
+      assert.strictEqual(formatDebt(120), "2h");
+      assert.strictEqual(formatDebt(800), "13h 20m");
+      assert.strictEqual(formatDebt(59.6), "1h");
+    });
+  });
+
+  describe("Analysis phase timing", () => {
+    it("records each phase's own time, excluding nested phases", () => {
+      const busy = (ms: number) => {
+        const end = performance.now() + ms;
+        while (performance.now() < end) {
+          // spin
+        }
+      };
+
+      const { result, totalMs, phases } = measureAnalysis(() =>
+        timePhase("walk", () => {
+          busy(5);
+          timePhase("parse", () => busy(10));
+          return 42;
+        })
+      );
+
+      assert.strictEqual(result, 42);
+      assert.ok(phases.parse >= 10);
+      assert.ok(phases.walk >= 5 && phases.walk < phases.parse);
+      assert.ok(totalMs >= phases.parse + phases.walk);
+      assert.strictEqual(phases.aggregate, 0);
+      assert.strictEqual(isTiming(), false);
+    });
+
+    it("times every phase of an uncached factory analysis", () => {
+      const source = "package main\n\nfunc f(x int) int {\n\tif x > 0 {\n\t\treturn x\n\t}\n\treturn 0\n}\n";
+      MetricsAnalyzerFactory.analyzeFile(source, "go");
+
+      const { result, phases } = measureAnalysis(() => MetricsAnalyzerFactory.analyzeFile(source, "go"));
+
+      assert.strictEqual(result.length, 1);
+      assert.ok(phases.parse > 0, "a cached result would skip parsing");
+      assert.ok(phases.walk > 0);
+      assert.ok(phases.aggregate > 0);
+    });
+
+    it("only runs the callback outside a measurement", () => {
+      assert.strictEqual(timePhase("parse", () => "done"), "done");
+    });
+  });
 });
@@ -0,0 +1,164 @@
+/**
+ * @fileoverview Analysis Profiling
+ *
+ * Re-runs the analysis of the active file or the workspace while timing it, for
+ * diagnosing slow analysis. Every file is analyzed uncached, with the time of each
+ * phase (parse, walk, aggregate; see {@link measureAnalysis}) and its total duration
+ * recorded. Reading files from disk is not included in the timings.
+ */
+
+import * as vscode from "vscode";
+import * as path from "path";
+import { MetricsAnalyzerFactory } from "../metricsAnalyzer/metricsAnalyzerFactory";
+import {
+  AnalysisPhase,
+  PhaseTimings,
+  emptyPhaseTimings,
+  measureAnalysis,
+} from "../metricsAnalyzer/analysisTiming";
+import { CodeMetricsConfig, ConfigurationManager } from "../configuration";
+import { WorkspaceAnalyzer } from "./workspaceAnalyzer";
+
+/** Timings of the analysis of one file. */
+export interface FileTiming {
+  /** Path shown in the report, relative to its workspace folder */
+  relativePath: string;
+  languageId: string;
+  functionCount: number;
+  /** Milliseconds the analysis of the file took */
+  totalMs: number;
+  phases: PhaseTimings;
+}
+
+/** Timings of a profiled analysis run. */
+export interface AnalysisProfile {
+  /** What was analyzed, e.g. a file path or "workspace" */
+  target: string;
+  files: FileTiming[];
+}
+
+const PHASES: readonly AnalysisPhase[] = ["parse", "walk", "aggregate"];
+
+/** Decodes file contents read through `vscode.workspace.fs`. */
+const decoder = new TextDecoder("utf-8");
+
+/**
+ * Analyzes one source text while timing it.
+ *
+ * @param relativePath - Path shown in the report
+ * @param sourceText - The file's contents
+ * @param languageId - VS Code language identifier
+ * @param config - The configuration the analyzer options are taken from
+ * @returns The file's timings
+ */
+export function profileSource(
+  relativePath: string,
+  sourceText: string,
+  languageId: string,
+  config: CodeMetricsConfig
+): FileTiming {
+  const { result, totalMs, phases } = measureAnalysis(() =>
+    MetricsAnalyzerFactory.analyzeFile(
+      sourceText,
+      languageId,
+      ConfigurationManager.getAnalyzerOptions(config)
+    )
+  );
+  return { relativePath, languageId, functionCount: result.length, totalMs, phases };
+}
+
+/**
+ * Analyzes every file a workspace scan would analyze, timing each one.
+ *
+ * @param token - Optional cancellation token; files timed so far are returned on cancel
+ * @returns The timings of every analyzed file
+ */
+export async function profileWorkspace(token?: vscode.CancellationToken): Promise<AnalysisProfile> {
+  const files: FileTiming[] = [];
+  for (const folder of vscode.workspace.workspaceFolders ?? []) {
+    const config = ConfigurationManager.getConfiguration(folder.uri);
+    if (!config.enabled) {
+      continue;
+    }
+    const goModules = await WorkspaceAnalyzer.loadGoModules(folder);
+    const submodules = await WorkspaceAnalyzer.loadSubmodules(folder, config);
+    for (const uri of await WorkspaceAnalyzer.findSourceFiles(
+      folder,
+      config,
+      undefined,
+      goModules,
+      submodules
+    )) {
+      if (token?.isCancellationRequested) {
+        return { target: "workspace", files };
+      }
+      const languageId = MetricsAnalyzerFactory.getLanguageIdForFile(uri.fsPath);
+      if (!languageId) {
+        continue;
+      }
+      const relativePath = path.posix.relative(folder.uri.path, uri.path);
+      try {
+        const sourceText = decoder.decode(await vscode.workspace.fs.readFile(uri));
+        files.push(profileSource(relativePath, sourceText, languageId, config));
+      } catch (error) {
+        console.error(`Error profiling ${uri.fsPath} in ${folder.name}:`, error);
+      }
+    }
+  }
+  return { target: "workspace", files };
+}
+
+/** Formats milliseconds with one decimal, e.g. `12.3 ms`. */
+function formatMs(ms: number): string {
+  return `${ms.toFixed(1)} ms`;
+}
+
+/** Formats the phases of a timing as `parse 1.2, walk 3.4, aggregate 0.5`. */
+function formatPhases(phases: PhaseTimings): string {
+  return PHASES.map((phase) => `${phase} ${phases[phase].toFixed(1)}`).join(", ");
+}
+
+/**
+ * Renders a profiled run as report lines: the total, the time per phase, the slowest
+ * file, and every file from slowest to fastest.
+ *
+ * @param profile - Results from {@link profileSource} or {@link profileWorkspace}
+ * @returns Report lines ready to be written to an output channel
+ */
+export function formatAnalysisProfile(profile: AnalysisProfile): string[] {
+  const files = [...profile.files].sort((a, b) => b.totalMs - a.totalMs);
+  const functionCount = files.reduce((n, file) => n + file.functionCount, 0);
+  const lines = [
+    `Analysis timing: ${profile.target} (${files.length} files, ${functionCount} functions)`,
+  ];
+  if (files.length === 0) {
+    lines.push("  No files were analyzed.");
+    return lines;
+  }
+
+  const totalMs = files.reduce((sum, file) => sum + file.totalMs, 0);
+  const phases = emptyPhaseTimings();
+  for (const file of files) {
+    for (const phase of PHASES) {
+      phases[phase] += file.phases[phase];
+    }
+  }
+  const share = (ms: number) => (totalMs > 0 ? Math.round((ms / totalMs) * 100) : 0);
+  lines.push(`  Total: ${formatMs(totalMs)}`);
+  lines.push(
+    `  Phases: ${PHASES.map((phase) => `${phase} ${formatMs(phases[phase])} (${share(phases[phase])}%)`).join(", ")}`
+  );
+  const slowest = files[0];
+  lines.push(`  Slowest file: ${slowest.relativePath} ${formatMs(slowest.totalMs)} (${formatPhases(slowest.phases)})`);
+  lines.push("  The first file of each language includes loading its analyzer.");
+
+  lines.push("");
+  lines.push("  Per file (slowest first):");
+  for (const file of files) {
+    lines.push(
+      `    ${formatMs(file.totalMs).padStart(10)}  ${file.relativePath}  ` +
+        `(${formatPhases(file.phases)}; ${file.functionCount} functions)`
+    );
+  }
+  return lines;
+}
@@ -0,0 +1,131 @@
+/**
+ * @fileoverview Complexity Distribution
+ *
+ * This module summarizes how cognitive complexity is spread across the functions of a
+ * workspace analysis: a histogram over fixed complexity buckets and the median, p90, and
+ * p99 values. A sorted list shows the worst offenders; the distribution shows whether
+ * they are outliers or the norm, which is easier to communicate as overall health.
+ */
+
+import { WorkspaceMetrics } from "./workspaceAnalyzer";
+
+/** Upper bounds (inclusive) of the histogram buckets; a final open bucket follows. */
+const BUCKET_BOUNDS = [0, 2, 5, 10, 15, 25, 50];
+
+/** One histogram bar. */
+export interface DistributionBucket {
+  /** Range label, e.g. `3–5` or `51+` */
+  label: string;
+  /** Number of functions whose complexity falls in the range */
+  count: number;
+}
+
+/** Distribution of function complexity across a workspace. */
+export interface ComplexityDistribution {
+  functionCount: number;
+  buckets: DistributionBucket[];
+  median: number;
+  p90: number;
+  p99: number;
+  max: number;
+}
+
+/**
+ * Returns the nearest-rank percentile of ascending values.
+ *
+ * @param sorted - Values in ascending order
+ * @param percent - The percentile (0–100)
+ * @returns The percentile value, or 0 when there are no values
+ */
+export function getPercentile(sorted: readonly number[], percent: number): number {
+  if (sorted.length === 0) {
+    return 0;
+  }
+  const rank = Math.ceil((percent / 100) * sorted.length);
+  return sorted[Math.min(Math.max(rank, 1), sorted.length) - 1];
+}
+
+/**
+ * Buckets the complexity of every analyzed function and computes key percentiles.
+ *
+ * @param metrics - The workspace results
+ * @returns The distribution; all values are 0 when no function was analyzed
+ */
+export function computeDistribution(metrics: WorkspaceMetrics): ComplexityDistribution {
+  const values = metrics.roots
+    .flatMap((root) => root.files.flatMap((file) => file.functions.map((f) => f.complexity)))
+    .sort((a, b) => a - b);
+
+  const buckets: DistributionBucket[] = BUCKET_BOUNDS.map((max, i) => {
+    const min = i === 0 ? 0 : BUCKET_BOUNDS[i - 1] + 1;
+    return { label: min === max ? `${max}` : `${min}–${max}`, count: 0 };
+  });
+  buckets.push({ label: `${BUCKET_BOUNDS[BUCKET_BOUNDS.length - 1] + 1}+`, count: 0 });
+  for (const value of values) {
+    const index = BUCKET_BOUNDS.findIndex((max) => value <= max);
+    buckets[index === -1 ? buckets.length - 1 : index].count++;
+  }
+
+  return {
+    functionCount: values.length,
+    buckets,
+    median: getPercentile(values, 50),
+    p90: getPercentile(values, 90),
+    p99: getPercentile(values, 99),
+    max: values.length > 0 ? values[values.length - 1] : 0,
+  };
+}
+
+/**
+ * Renders a distribution as a self-contained webview page: percentile tiles and a bar
+ * histogram drawn with CSS, styled with the VS Code theme colors. The page runs no scripts.
+ *
+ * @param distribution - The distribution to show
+ * @returns The HTML document
+ */
+export function renderDistributionHtml(distribution: ComplexityDistribution): string {
+  const largest = Math.max(...distribution.buckets.map((b) => b.count), 1);
+  const rows = distribution.buckets
+    .map((bucket) => {
+      const width = Math.round((bucket.count / largest) * 100);
+      return `<tr><th>${bucket.label}</th>` +
+        `<td><div class="bar" style="width: ${width}%"></div></td>` +
+        `<td class="count">${bucket.count}</td></tr>`;
+    })
+    .join("\n");
+  const tile = (label: string, value: number) =>
+    `<div class="tile"><div class="value">${value}</div><div class="label">${label}</div></div>`;
+
+  return `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta http-equiv="Content-Security-Policy" content="default-src 'none'; style-src 'unsafe-inline';">
+<title>Complexity Distribution</title>
+<style>
+  body { font-family: var(--vscode-font-family); color: var(--vscode-foreground); padding: 1em 2em; }
+  .tiles { display: flex; gap: 1em; margin-bottom: 2em; }
+  .tile { border: 1px solid var(--vscode-panel-border); padding: 0.75em 1.25em; min-width: 6em; }
+  .value { font-size: 1.8em; font-weight: bold; }
+  .label { opacity: 0.8; }
+  table { border-collapse: collapse; width: 100%; max-width: 48em; }
+  th { text-align: right; padding-right: 1em; font-weight: normal; white-space: nowrap; width: 5em; }
+  td { padding: 0.2em 0; }
+  .bar { background: var(--vscode-charts-blue); height: 1.2em; min-width: 1px; }
+  .count { text-align: right; padding-left: 1em; width: 4em; }
+</style>
+</head>
+<body>
+<h2>Cognitive complexity of ${distribution.functionCount} functions</h2>
+<div class="tiles">
+${tile("median", distribution.median)}
+${tile("p90", distribution.p90)}
+${tile("p99", distribution.p99)}
+${tile("max", distribution.max)}
+</div>
+<table>
+${rows}
+</table>
+</body>
+</html>`;
+}
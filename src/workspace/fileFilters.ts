@@ -0,0 +1,143 @@
+/**
+ * @fileoverview File Filters
+ *
+ * Decides which files are left out of analysis: the `codeMetrics.excludePatterns` globs,
+ * and the naming conventions that mark test files. Every consumer that honours the
+ * patterns (CodeLens, diagnostics, the workspace report, the command-line entry points)
+ * goes through {@link findExcludingPattern}, so they agree on which files are skipped.
+ *
+ * This module does not depend on the VS Code API.
+ */
+
+import { CodeMetricsConfig } from "../projectConfig";
+
+/**
+ * Compiled regex cache for exclude patterns.
+ * Key: joined pattern string (patterns change rarely; cache avoids per-request recompilation).
+ * Value: array of compiled { regex, isFullPath } entries ready for matching.
+ * Capped at EXCLUDE_CACHE_MAX_SIZE entries (LRU eviction) to prevent unbounded growth when
+ * workspace settings vary across many open folders or when settings change frequently.
+ */
+const excludeRegexCache = new Map<
+  string,
+  { regex: RegExp; isFullPath: boolean }[]
+>();
+
+/** Maximum number of distinct pattern-list compilations to keep in the exclude regex cache. */
+const EXCLUDE_CACHE_MAX_SIZE = 32;
+
+/** Compiles a single glob pattern into a regex, honouring `**`, `*`, `?` wildcards. */
+function compileExcludePattern(
+  pattern: string
+): { regex: RegExp; isFullPath: boolean } {
+  const normalized = pattern.replace(/\\/g, "/");
+  const isFullPath = normalized.includes("/");
+
+  if (isFullPath) {
+    const regexPattern = normalized
+      .replace(/\*\*/g, "\x00DS\x00")
+      .replace(/\*/g, "\x00S\x00")
+      .replace(/\?/g, "\x00Q\x00")
+      .replace(/[.+^${}()|[\]\\]/g, "\\$&")
+      .replace(/\x00DS\x00/g, ".*")
+      .replace(/\x00S\x00/g, "[^/]*")
+      .replace(/\x00Q\x00/g, "[^/]");
+    return { regex: new RegExp(`^${regexPattern}$`), isFullPath: true };
+  } else {
+    const regexPattern = normalized
+      .replace(/\*/g, "\x00S\x00")
+      .replace(/\?/g, "\x00Q\x00")
+      .replace(/[.+^${}()|[\]\\]/g, "\\$&")
+      .replace(/\x00S\x00/g, ".*")
+      .replace(/\x00Q\x00/g, ".");
+    return { regex: new RegExp(`^${regexPattern}$`), isFullPath: false };
+  }
+}
+
+/** Returns compiled regex entries for the given patterns, using a cache to avoid recompilation. */
+function getCompiledPatterns(
+  patterns: string[]
+): { regex: RegExp; isFullPath: boolean }[] {
+  // Normalize separators before keying so Windows paths (backslash) and
+  // forward-slash paths for the same pattern list share a single cache entry.
+  const cacheKey = patterns.map((p) => p.replace(/\\/g, "/")).join("\x00");
+  let compiled = excludeRegexCache.get(cacheKey);
+  if (!compiled) {
+    compiled = patterns.map(compileExcludePattern);
+    if (excludeRegexCache.size >= EXCLUDE_CACHE_MAX_SIZE) {
+      // Evict the least-recently-used entry (first key in insertion order).
+      excludeRegexCache.delete(excludeRegexCache.keys().next().value!);
+    }
+    excludeRegexCache.set(cacheKey, compiled);
+  } else {
+    // Refresh LRU order: move this entry to the end.
+    excludeRegexCache.delete(cacheKey);
+    excludeRegexCache.set(cacheKey, compiled);
+  }
+  return compiled;
+}
+
+/**
+ * File name conventions that mark a file as containing tests, across supported languages.
+ * Matched against the basename only.
+ */
+const TEST_FILE_PATTERNS: readonly RegExp[] = [
+  /_test\.go$/,                          // Go
+  /\.(test|spec)\.[cm]?[jt]sx?$/,        // JavaScript / TypeScript
+  /^test_.*\.py$/, /_test\.py$/,         // Python (pytest / unittest)
+  /Tests?\.java$/,                       // Java (JUnit)
+  /Tests?\.cs$/,                         // C# (xUnit / NUnit / MSTest)
+];
+
+/**
+ * Returns whether a path looks like a test file by common naming conventions.
+ *
+ * @param filePath - File path (either separator style)
+ * @returns true if the basename matches a known test file convention
+ */
+export function isTestFile(filePath: string): boolean {
+  const normalized = filePath.replace(/\\/g, "/");
+  const basename = normalized.substring(normalized.lastIndexOf("/") + 1);
+  return TEST_FILE_PATTERNS.some((pattern) => pattern.test(basename));
+}
+
+/**
+ * Returns the exclude glob that keeps a file out of analysis. Globs containing a `/` are
+ * matched against the full path; others against the basename. With `includeTests`, test
+ * files are let through the globs that match them by name alone, wherever they are (the
+ * default `**\/*.test.*`, or `*_test.go`); globs for directories such as
+ * `**\/node_modules/**` or `vendor/**` still exclude them.
+ *
+ * @param filePath - File path (either separator style)
+ * @param config - The exclude patterns, and whether tests are included
+ * @returns The first glob excluding the file, or undefined when the file is analyzed
+ */
+export function findExcludingPattern(
+  filePath: string,
+  config: Pick<CodeMetricsConfig, "excludePatterns" | "includeTests">
+): string | undefined {
+  const normalizedPath = filePath.replace(/\\/g, "/");
+  const filename = normalizedPath.substring(normalizedPath.lastIndexOf("/") + 1);
+  const waiveTestNames = config.includeTests && isTestFile(filename);
+  const index = getCompiledPatterns(config.excludePatterns).findIndex(
+    ({ regex, isFullPath }) =>
+      regex.test(isFullPath ? normalizedPath : filename) &&
+      // A glob matching the bare name anchored at any directory matches by name alone.
+      !(waiveTestNames && regex.test(isFullPath ? `/${filename}` : filename))
+  );
+  return index === -1 ? undefined : config.excludePatterns[index];
+}
+
+/**
+ * Returns whether a file is left out of analysis by the exclude patterns.
+ *
+ * @param filePath - File path (either separator style)
+ * @param config - The exclude patterns, and whether tests are included
+ * @returns true if {@link findExcludingPattern} finds a glob excluding the file
+ */
+export function isExcludedFile(
+  filePath: string,
+  config: Pick<CodeMetricsConfig, "excludePatterns" | "includeTests">
+): boolean {
+  return findExcludingPattern(filePath, config) !== undefined;
+}
@@ -0,0 +1,108 @@
+/**
+ * @fileoverview Function Comparison
+ *
+ * Puts a handful of functions, possibly from different files, side by side with their
+ * combined complexity, to check a refactoring right after it is done: when a function
+ * is split in two, the parts should add up to less than the function they replace. The
+ * combined value can be compared against a reference, such as the original function's
+ * complexity before the split.
+ */
+
+import { UnifiedFunctionMetrics } from "../metricsAnalyzer/metricsAnalyzerFactory";
+
+/** One of the compared functions. */
+export interface ComparedFunction {
+  /** Entry name, e.g. `Service.Save` */
+  name: string;
+  /** The file's path relative to its root */
+  relativePath: string;
+  /** Line the function starts on (1-based) */
+  line: number;
+  /** Cognitive complexity */
+  complexity: number;
+  /** Deepest nesting level of any complexity increment */
+  maxNesting: number;
+  /** Number of lines the function spans */
+  lines: number;
+}
+
+/** The compared functions with their totals. */
+export interface FunctionComparison {
+  /** The functions, in the order they were picked */
+  functions: ComparedFunction[];
+  /** Summed complexity of the functions */
+  combinedComplexity: number;
+  /** Summed length of the functions in lines */
+  combinedLines: number;
+  /** The value the combined complexity is compared against, when one was given */
+  reference?: number;
+}
+
+/**
+ * Collects the values of the picked functions and their totals.
+ *
+ * @param picked - The functions with the path of the file they are in
+ * @param reference - Optional complexity to compare the combined value against
+ * @returns The comparison, ready for {@link formatFunctionComparison}
+ */
+export function compareFunctions(
+  picked: readonly { relativePath: string; func: UnifiedFunctionMetrics }[],
+  reference?: number
+): FunctionComparison {
+  const functions = picked.map(({ relativePath, func }) => ({
+    name: func.name,
+    relativePath,
+    line: func.startLine + 1,
+    complexity: func.complexity,
+    maxNesting: func.details.reduce((max, detail) => Math.max(max, detail.nesting), 0),
+    lines: func.endLine - func.startLine + 1,
+  }));
+  return {
+    functions,
+    combinedComplexity: functions.reduce((sum, f) => sum + f.complexity, 0),
+    combinedLines: functions.reduce((sum, f) => sum + f.lines, 0),
+    reference,
+  };
+}
+
+/**
+ * Renders a comparison as report lines: one line per function, the combined values,
+ * and how the combined complexity compares with the reference.
+ *
+ * @param comparison - Results from {@link compareFunctions}
+ * @returns Report lines ready to be written to an output channel
+ */
+export function formatFunctionComparison(comparison: FunctionComparison): string[] {
+  const { functions, combinedComplexity, combinedLines, reference } = comparison;
+  const lines = [`Function comparison (${functions.length} functions)`, ""];
+  lines.push("  Complexity  Nesting  Lines  Function");
+  for (const f of functions) {
+    lines.push(
+      `  ${String(f.complexity).padStart(10)}  ${String(f.maxNesting).padStart(7)}  ` +
+        `${String(f.lines).padStart(5)}  ${f.name} (${f.relativePath}:${f.line})`
+    );
+  }
+  const maxNesting = Math.max(0, ...functions.map((f) => f.maxNesting));
+  lines.push(
+    `  ${String(combinedComplexity).padStart(10)}  ${String(maxNesting).padStart(7)}  ` +
+      `${String(combinedLines).padStart(5)}  Combined (nesting is the deepest)`
+  );
+
+  const summary: string[] = [];
+  if (reference !== undefined) {
+    const diff = combinedComplexity - reference;
+    const signed = (value: number) => (value > 0 ? `+${value}` : String(value));
+    const percent = reference === 0 ? "" : ` (${signed(Math.round((diff / reference) * 100))}%)`;
+    const verdict = diff < 0 ? "lower than" : diff > 0 ? "higher than" : "the same as";
+    summary.push(
+      `  Combined complexity ${combinedComplexity} is ${verdict} the reference ${reference}: ` +
+        `${signed(diff)}${percent}.`
+    );
+  }
+  if (functions.length > 1 && combinedComplexity > 0) {
+    const highest = functions.reduce((a, b) => (b.complexity > a.complexity ? b : a));
+    const share = Math.round((highest.complexity / combinedComplexity) * 100);
+    summary.push(`  ${highest.name} carries ${share}% of the combined complexity.`);
+  }
+  return summary.length > 0 ? [...lines, "", ...summary] : lines;
+}
@@ -0,0 +1,73 @@
+/**
+ * @fileoverview Git Submodules
+ *
+ * Reads the `.gitmodules` file of a workspace folder so workspace analysis can skip the
+ * contents of git submodules: code vendored that way belongs to another repository, and
+ * its complexity is rarely this one's concern. Paths are URI paths (forward slashes), so
+ * submodule directories and files compare as plain strings.
+ *
+ * This module does not depend on the VS Code API.
+ */
+
+import * as path from "path";
+
+/**
+ * Extracts the submodule paths declared in a `.gitmodules` file, e.g. `vendor/lib` from
+ * `path = vendor/lib` in a `[submodule "lib"]` section. Comments (`#`, `;`) and keys of
+ * other sections are ignored, and quoted values are unquoted.
+ *
+ * @param text - Contents of the `.gitmodules` file
+ * @returns The paths, relative to the repository root, without trailing slashes
+ */
+export function parseGitmodules(text: string): string[] {
+  const paths: string[] = [];
+  let inSubmodule = false;
+  for (const rawLine of text.split(/\r?\n/)) {
+    const line = rawLine.trim();
+    if (line.startsWith("[")) {
+      inSubmodule = /^\[\s*submodule\b/.test(line);
+      continue;
+    }
+    const match = /^path\s*=\s*(.*)$/.exec(line);
+    if (!inSubmodule || !match) {
+      continue;
+    }
+    let value = match[1].replace(/\s+[#;].*$/, "").trim();
+    if (value.length >= 2 && value.startsWith('"') && value.endsWith('"')) {
+      value = value.slice(1, -1);
+    }
+    value = value.replace(/\\/g, "/").replace(/^\.\//, "").replace(/\/+$/, "");
+    if (value) {
+      paths.push(value);
+    }
+  }
+  return paths;
+}
+
+/**
+ * Resolves the submodule paths of a `.gitmodules` file against its folder.
+ *
+ * @param folderPath - Forward-slash path of the folder holding `.gitmodules`
+ * @param text - Contents of the `.gitmodules` file
+ * @returns Forward-slash paths of the submodule directories
+ */
+export function resolveSubmoduleDirs(folderPath: string, text: string): string[] {
+  return parseGitmodules(text).map((dir) => path.posix.join(folderPath, dir));
+}
+
+/**
+ * Explains why a file is skipped as part of a git submodule.
+ *
+ * @param submoduleDirs - Forward-slash paths of the folder's submodule directories
+ * @param filePath - Forward-slash path of the file
+ * @returns A human-readable reason, or undefined when the file is in none of the submodules
+ */
+export function getSubmoduleSkipReason(
+  submoduleDirs: readonly string[],
+  filePath: string
+): string | undefined {
+  const dir = submoduleDirs.find((candidate) => filePath.startsWith(`${candidate}/`));
+  return dir === undefined
+    ? undefined
+    : `in git submodule ${path.posix.basename(dir)} (enable analysis.includeSubmodules to analyze)`;
+}
@@ -0,0 +1,172 @@
+/**
+ * @fileoverview Go Module Layout
+ *
+ * Reads the `go.mod` and `go.work` files of a workspace folder so workspace analysis can
+ * follow Go's module boundaries: the module cache and `vendor` copies of dependencies are
+ * not analyzed, and each file of a module is labeled with the import path of its package,
+ * e.g. `example.com/app/internal/store`.
+ *
+ * A file belongs to the module with the deepest directory containing it, as in the go
+ * command. With a `go.work` file at the folder root, only the modules it lists in `use`
+ * directives are analyzed; otherwise every module found in the folder is. Go files outside
+ * every module, such as loose samples next to a module, are analyzed as before, with their
+ * directory as the package. Paths are URI paths (forward slashes), so module directories
+ * and files compare as plain strings.
+ *
+ * This module does not depend on the VS Code API.
+ */
+
+import * as path from "path";
+
+/** A Go module of a workspace folder. */
+export interface GoModule {
+  /** Forward-slash path of the directory holding the module's `go.mod` */
+  dir: string;
+  /** Module path declared by the `module` directive */
+  path: string;
+}
+
+/** The Go modules of a workspace folder. */
+export interface GoModuleLayout {
+  /** Every module found in the folder, outside the module cache */
+  modules: GoModule[];
+  /** Module directories listed in the folder's `go.work`, when it has one */
+  workspaceDirs?: string[];
+}
+
+/** Where a Go file belongs: its module and the import path of its package. */
+export interface GoPackage {
+  module: GoModule;
+  importPath: string;
+}
+
+/** Strips `//` comments from a line of a `go.mod` or `go.work` file. */
+function stripComment(line: string): string {
+  const comment = line.indexOf("//");
+  return (comment === -1 ? line : line.substring(0, comment)).trim();
+}
+
+/** Removes the quotes of a quoted module path or directory. */
+function unquote(token: string): string {
+  return /^".*"$|^`.*`$/.test(token) ? token.slice(1, -1) : token;
+}
+
+/**
+ * Reads the module path from the `module` directive of a `go.mod` file.
+ *
+ * @param text - Contents of the `go.mod` file
+ * @returns The module path, or undefined when the file declares none
+ */
+export function parseModulePath(text: string): string | undefined {
+  for (const line of text.split(/\r?\n/)) {
+    const match = /^module\s+(\S+)$/.exec(stripComment(line));
+    if (match) {
+      return unquote(match[1]);
+    }
+  }
+  return undefined;
+}
+
+/**
+ * Reads the module directories of the `use` directives of a `go.work` file, both single
+ * (`use ./app`) and in blocks (`use ( ... )`).
+ *
+ * @param text - Contents of the `go.work` file
+ * @returns Directories as written, relative to the `go.work` file unless absolute
+ */
+export function parseWorkspaceUses(text: string): string[] {
+  const dirs: string[] = [];
+  let inBlock = false;
+  for (const line of text.split(/\r?\n/)) {
+    const stripped = stripComment(line);
+    if (inBlock) {
+      if (stripped === ")") {
+        inBlock = false;
+      } else if (stripped !== "") {
+        dirs.push(unquote(stripped));
+      }
+      continue;
+    }
+    const match = /^use\s+(.+)$/.exec(stripped);
+    if (match?.[1] === "(") {
+      inBlock = true;
+    } else if (match) {
+      dirs.push(unquote(match[1]));
+    }
+  }
+  return dirs;
+}
+
+/**
+ * Returns whether a path lies in a Go module cache, whose module directories are named
+ * `module@version` (e.g. `pkg/mod/golang.org/x/text@v0.14.0/...`).
+ *
+ * @param relativePath - Forward-slash path relative to a directory that is not itself cached
+ * @returns true for paths inside a versioned module directory
+ */
+export function isModuleCachePath(relativePath: string): boolean {
+  return relativePath.split("/").some((segment) => /@v\d/.test(segment));
+}
+
+/** Returns the module with the deepest directory containing a file, as the go command does. */
+function findOwningModule(layout: GoModuleLayout, filePath: string): GoModule | undefined {
+  let owner: GoModule | undefined;
+  for (const module of layout.modules) {
+    if (filePath.startsWith(`${module.dir}/`) && (!owner || module.dir.length > owner.dir.length)) {
+      owner = module;
+    }
+  }
+  return owner;
+}
+
+/**
+ * Explains why a Go file is outside the modules analyzed for its folder. Files that are
+ * in no module are only skipped when they lie in the module cache.
+ *
+ * @param layout - The folder's modules
+ * @param filePath - Forward-slash path of the file
+ * @returns A human-readable reason, or undefined when the file is analyzed
+ */
+export function getGoModuleSkipReason(
+  layout: GoModuleLayout,
+  filePath: string
+): string | undefined {
+  const owner = findOwningModule(layout, filePath);
+  if (!owner) {
+    return isModuleCachePath(filePath) ? "in the Go module cache" : undefined;
+  }
+  if (layout.workspaceDirs && !layout.workspaceDirs.includes(owner.dir)) {
+    return `in Go module ${owner.path}, which go.work does not use`;
+  }
+  const inModule = filePath.substring(owner.dir.length + 1);
+  if (isModuleCachePath(inModule)) {
+    return "in the Go module cache";
+  }
+  if (inModule.startsWith("vendor/")) {
+    return "vendored Go dependency";
+  }
+  return undefined;
+}
+
+/**
+ * Finds the module and package import path of a Go file.
+ *
+ * @param layout - The folder's modules
+ * @param filePath - Forward-slash path of the file
+ * @returns The file's package, or undefined when it is in no module or
+ *   {@link getGoModuleSkipReason} skips it
+ */
+export function resolveGoPackage(
+  layout: GoModuleLayout,
+  filePath: string
+): GoPackage | undefined {
+  const owner = findOwningModule(layout, filePath);
+  if (!owner || getGoModuleSkipReason(layout, filePath) !== undefined) {
+    return undefined;
+  }
+  const packageDir = path.posix.dirname(filePath.substring(owner.dir.length + 1));
+  return {
+    module: owner,
+    importPath: packageDir === "." ? owner.path : `${owner.path}/${packageDir}`,
+  };
+}
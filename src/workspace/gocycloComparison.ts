@@ -0,0 +1,128 @@
+/**
+ * @fileoverview gocyclo Comparison
+ *
+ * Puts the complexity reported for each function of a Go file next to the cyclomatic
+ * complexity gocyclo would report, so users can see where and why the two tools differ.
+ * gocyclo is not run: its counting rules are reimplemented on the same syntax tree
+ * (see {@link GoMetricsAnalyzer.countCyclomatic}).
+ */
+
+import {
+  MetricsAnalyzerFactory,
+  UnifiedFunctionMetrics,
+} from "../metricsAnalyzer/metricsAnalyzerFactory";
+import { GoMetricsAnalyzer } from "../metricsAnalyzer/languages/goAnalyzer";
+import { CodeMetricsConfig, ConfigurationManager } from "../configuration";
+
+/** One function's complexity under both tools. */
+export interface GocycloComparisonRow {
+  /** Entry name, e.g. `Service.Save` or `Filter.func1` */
+  name: string;
+  /** Line the function starts on (1-based) */
+  line: number;
+  /** The reported complexity; undefined when the function is excluded from the results */
+  complexity?: number;
+  /** gocyclo's value; undefined for entries gocyclo does not report, such as separate closures */
+  gocyclo?: number;
+}
+
+/** The counting rules in which the reported complexity and gocyclo differ. */
+export const GOCYCLO_RULE_DIFFERENCES: readonly string[] = [
+  "Base: gocyclo starts every function at 1; Code Metrics starts at codeMetrics.complexity.base (0 by default).",
+  "Nesting: Code Metrics adds the nesting level to each if, for, switch, and select; gocyclo does not.",
+  "switch and select: Code Metrics counts the statement once; gocyclo counts each case except default.",
+  "else: Code Metrics adds 1 for each else and else if; gocyclo counts an else if as an if and ignores else.",
+  "&& and ||: Code Metrics counts each sequence of the same operator once (a && b && c is 1); gocyclo counts each operator.",
+  "Jumps: Code Metrics counts goto, labeled break and continue, break and continue in nested code, and recover(); gocyclo does not.",
+  "Closures: gocyclo counts a func literal's branches in the declaring function without nesting; Code Metrics merges them at a deeper nesting or reports them separately, following codeMetrics.closureMode.",
+];
+
+/**
+ * Analyzes a Go source text and pairs each function's complexity with gocyclo's value.
+ *
+ * @param sourceText - The file's contents
+ * @param config - The configuration the analyzer options are taken from
+ * @returns One row per function, in source order
+ */
+export function compareWithGocyclo(
+  sourceText: string,
+  config: CodeMetricsConfig
+): GocycloComparisonRow[] {
+  const functions = MetricsAnalyzerFactory.analyzeFile(
+    sourceText,
+    "go",
+    ConfigurationManager.getAnalyzerOptions(config)
+  );
+  const rows: GocycloComparisonRow[] = [];
+  const matched = new Set<UnifiedFunctionMetrics>();
+  for (const entry of GoMetricsAnalyzer.countCyclomatic(sourceText)) {
+    const func = functions.find(
+      (f) => f.name === entry.name && f.startLine === entry.startLine
+    );
+    if (func) {
+      matched.add(func);
+    }
+    rows.push({
+      name: entry.name,
+      line: entry.startLine + 1,
+      complexity: func?.complexity,
+      gocyclo: entry.complexity,
+    });
+  }
+  for (const func of functions) {
+    if (!matched.has(func)) {
+      rows.push({ name: func.name, line: func.startLine + 1, complexity: func.complexity });
+    }
+  }
+  return rows.sort((a, b) => a.line - b.line);
+}
+
+/**
+ * Renders a comparison as report lines: one line per function with both values and
+ * their difference, followed by the rules that differ.
+ *
+ * @param target - What was compared, e.g. the file's relative path
+ * @param rows - Results from {@link compareWithGocyclo}
+ * @returns Report lines ready to be written to an output channel
+ */
+export function formatGocycloComparison(
+  target: string,
+  rows: readonly GocycloComparisonRow[]
+): string[] {
+  const lines = [`gocyclo comparison: ${target} (${rows.length} functions)`];
+  if (rows.length === 0) {
+    lines.push("  No functions were found.");
+    return lines;
+  }
+
+  const both = rows.filter((row) => row.complexity !== undefined && row.gocyclo !== undefined);
+  const same = both.filter((row) => row.complexity === row.gocyclo).length;
+  lines.push(`  ${same} of ${both.length} functions have the same value under both tools.`);
+  lines.push("");
+  lines.push("     Ours  gocyclo   Diff  Function");
+  const cell = (value: number | undefined) => (value === undefined ? "—" : String(value));
+  for (const row of rows) {
+    const diff =
+      row.complexity !== undefined && row.gocyclo !== undefined
+        ? row.gocyclo - row.complexity
+        : undefined;
+    const note =
+      row.complexity === undefined
+        ? " (excluded)"
+        : row.gocyclo === undefined
+          ? " (not reported by gocyclo)"
+          : "";
+    lines.push(
+      `  ${cell(row.complexity).padStart(7)}  ${cell(row.gocyclo).padStart(7)}  ` +
+        `${(diff === undefined ? "—" : diff > 0 ? `+${diff}` : String(diff)).padStart(5)}  ` +
+        `${row.name} (line ${row.line})${note}`
+    );
+  }
+
+  lines.push("");
+  lines.push("  Diff is gocyclo minus ours. Rules that differ:");
+  for (const rule of GOCYCLO_RULE_DIFFERENCES) {
+    lines.push(`    - ${rule}`);
+  }
+  return lines;
+}
@@ -0,0 +1,138 @@
+/**
+ * @fileoverview Snapshot Analysis
+ *
+ * Analyzes a workspace folder as it was at a git ref (a commit, tag, branch, or stash such
+ * as `stash@{0}`) without checking it out, for finding out when complexity crept in. The
+ * files at the ref are listed with `git ls-tree` and read with `git show`; the folder's
+ * current configuration decides which of them are analyzed, and its Go modules are read
+ * from the ref as well. Coverage is not loaded, since it describes the working tree.
+ */
+
+import * as path from "path";
+import * as vscode from "vscode";
+import { MetricsAnalyzerFactory } from "../metricsAnalyzer/metricsAnalyzerFactory";
+import { runGit } from "../baseline/baseline";
+import { ConfigurationManager } from "../configuration";
+import { RootMetrics, WorkspaceAnalyzer } from "./workspaceAnalyzer";
+import {
+  GoModuleLayout,
+  isModuleCachePath,
+  parseModulePath,
+  parseWorkspaceUses,
+} from "./goModules";
+
+/**
+ * Resolves a git ref to the commit it names.
+ *
+ * @param cwd - A directory inside the repository
+ * @param ref - The ref, e.g. `v1.2.0`, `HEAD~10`, or `stash@{0}`
+ * @returns The commit hash
+ * @throws {Error} With a readable message when the ref does not name a commit
+ */
+export async function resolveSnapshotCommit(cwd: string, ref: string): Promise<string> {
+  try {
+    return (await runGit(cwd, ["rev-parse", "--verify", "--quiet", `${ref}^{commit}`])).trim();
+  } catch {
+    throw new Error(`Git ref "${ref}" does not name a commit in this repository.`);
+  }
+}
+
+/**
+ * Reads the Go modules of a folder at a commit, as {@link WorkspaceAnalyzer.loadGoModules}
+ * does for the working tree.
+ *
+ * @param folder - The workspace folder
+ * @param commit - The commit to read from
+ * @param paths - The files of the folder at the commit, relative to it
+ * @returns The modules, or undefined when the folder had no `go.mod`
+ */
+async function loadGoModulesAt(
+  folder: vscode.WorkspaceFolder,
+  commit: string,
+  paths: readonly string[]
+): Promise<GoModuleLayout | undefined> {
+  const cwd = folder.uri.fsPath;
+  const modules: GoModuleLayout["modules"] = [];
+  for (const file of paths) {
+    const dir = path.posix.dirname(file);
+    if (path.posix.basename(file) !== "go.mod" || isModuleCachePath(dir === "." ? "" : dir)) {
+      continue;
+    }
+    const modulePath = parseModulePath(await runGit(cwd, ["show", `${commit}:./${file}`]));
+    if (modulePath) {
+      modules.push({
+        dir: path.posix.dirname(vscode.Uri.joinPath(folder.uri, file).path),
+        path: modulePath,
+      });
+    }
+  }
+  if (modules.length === 0) {
+    return undefined;
+  }
+
+  const layout: GoModuleLayout = { modules };
+  if (paths.includes("go.work")) {
+    layout.workspaceDirs = parseWorkspaceUses(
+      await runGit(cwd, ["show", `${commit}:./go.work`])
+    ).map((dir) => path.posix.resolve(folder.uri.path, dir.replace(/\\/g, "/")));
+  }
+  return layout;
+}
+
+/**
+ * Analyzes a workspace folder as it was at a git ref, with the folder's configuration.
+ * The results can be rendered with `formatWorkspaceReport` like a regular scan.
+ *
+ * @param folder - The workspace folder, which must be inside a git repository
+ * @param ref - The ref to analyze, e.g. a commit hash, tag, branch, or `stash@{0}`
+ * @param token - Optional cancellation token; files analyzed so far are returned on cancel
+ * @returns The folder's results at the ref, named `<folder> @ <ref>`
+ * @throws {Error} If the ref does not exist or git fails
+ */
+export async function analyzeFolderAtRef(
+  folder: vscode.WorkspaceFolder,
+  ref: string,
+  token?: vscode.CancellationToken
+): Promise<RootMetrics> {
+  const cwd = folder.uri.fsPath;
+  const commit = await resolveSnapshotCommit(cwd, ref);
+  const config = ConfigurationManager.getConfiguration(folder.uri);
+  const root: RootMetrics = {
+    name: `${folder.name} @ ${ref} (${commit.substring(0, 7)})`,
+    folder,
+    config,
+    files: [],
+  };
+  if (!config.enabled) {
+    return root;
+  }
+
+  // Paths are relative to the folder, which may be a subdirectory of the repository.
+  const paths = (await runGit(cwd, ["ls-tree", "-r", "-z", "--name-only", commit, "."]))
+    .split("\0")
+    .filter((file) => file.length > 0);
+  root.goModules = await loadGoModulesAt(folder, commit, paths);
+
+  for (const relativePath of paths) {
+    if (token?.isCancellationRequested) {
+      break;
+    }
+    // The URI names where the file would be in the working tree; it is not read.
+    const uri = vscode.Uri.joinPath(folder.uri, relativePath);
+    const languageId = MetricsAnalyzerFactory.getLanguageIdForFile(relativePath);
+    if (!languageId || !WorkspaceAnalyzer.isIncluded(uri, config, root.goModules)) {
+      continue;
+    }
+    try {
+      const sourceText = await runGit(cwd, ["show", `${commit}:./${relativePath}`]);
+      root.files.push(
+        WorkspaceAnalyzer.analyzeSource(sourceText, uri, folder, languageId, config, root.goModules)
+      );
+    } catch (error) {
+      console.error(`Error analyzing ${relativePath} at ${ref} in ${folder.name}:`, error);
+    }
+  }
+  root.files.sort((a, b) => a.relativePath.localeCompare(b.relativePath));
+  root.languageThresholds = WorkspaceAnalyzer.loadLanguageThresholds(folder, config, root.files);
+  return root;
+}
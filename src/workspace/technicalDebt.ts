@@ -0,0 +1,77 @@
+/**
+ * @fileoverview Technical Debt Estimate
+ *
+ * Turns complexity above the warning threshold into an estimated remediation time, in the
+ * way of SonarQube's cognitive complexity rule: each function at or above the threshold
+ * costs a fixed number of minutes, plus some minutes per point over the threshold.
+ *
+ *   debt = baseMinutes + minutesPerPoint × (complexity − warningThreshold)
+ *
+ * Functions below the threshold carry no debt. File, root, and workspace figures are sums.
+ * The estimate is meant for communicating trends to stakeholders, not for planning work.
+ *
+ * This module does not depend on the VS Code API.
+ */
+
+/** The factors of the debt formula. */
+export interface DebtFactors {
+  /** Minutes charged for each function at or above the warning threshold */
+  baseMinutes: number;
+  /** Minutes charged per complexity point over the warning threshold */
+  minutesPerPoint: number;
+}
+
+/**
+ * Estimates the remediation time of one function.
+ *
+ * @param complexity - The function's complexity
+ * @param warningThreshold - Complexity from which a function carries debt
+ * @param factors - The formula's factors
+ * @returns Minutes; 0 below the threshold
+ */
+export function getFunctionDebt(
+  complexity: number,
+  warningThreshold: number,
+  factors: DebtFactors
+): number {
+  if (complexity < warningThreshold) {
+    return 0;
+  }
+  return factors.baseMinutes + factors.minutesPerPoint * (complexity - warningThreshold);
+}
+
+/**
+ * Estimates the remediation time of a group of functions, such as a file.
+ *
+ * @param functions - The functions
+ * @param warningThreshold - Complexity from which a function carries debt
+ * @param factors - The formula's factors
+ * @returns Summed minutes
+ */
+export function estimateDebt(
+  functions: readonly { complexity: number }[],
+  warningThreshold: number,
+  factors: DebtFactors
+): number {
+  return functions.reduce(
+    (sum, func) => sum + getFunctionDebt(func.complexity, warningThreshold, factors),
+    0
+  );
+}
+
+/**
+ * Formats minutes as hours and minutes, e.g. `45m`, `2h`, `13h 20m`. Fractions of a
+ * minute from fractional factors are rounded.
+ *
+ * @param minutes - The duration
+ * @returns The formatted duration
+ */
+export function formatDebt(minutes: number): string {
+  const rounded = Math.round(minutes);
+  const hours = Math.floor(rounded / 60);
+  const rest = rounded % 60;
+  if (hours === 0) {
+    return `${rest}m`;
+  }
+  return rest === 0 ? `${hours}h` : `${hours}h ${rest}m`;
+}
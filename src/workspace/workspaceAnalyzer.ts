@@ -0,0 +1,1499 @@
+/**
+ * @fileoverview Workspace Analysis
+ *
+ * This module scans every workspace folder for supported source files, analyzes them,
+ * and renders a plain-text report grouped by workspace root.
+ *
+ * Each root is analyzed with its own resolved configuration (VS Code settings scoped to
+ * the folder plus its optional `.codemetrics.json`), so thresholds and excludes in a
+ * multi-root workspace apply only to the root that declares them. Within a root, the
+ * thresholds of `[language]` settings apply to that language's functions, and roots that
+ * mix languages are reported in one section per language.
+ *
+ * Folders with `go.mod` files are scoped to their Go modules (see ./goModules): dependency
+ * copies are skipped and Go files are labeled with their package import path.
+ */
+
+import * as path from "path";
+import * as vscode from "vscode";
+import {
+  FluentUsage,
+  MetricsAnalyzerFactory,
+  UnifiedFunctionMetrics,
+} from "../metricsAnalyzer/metricsAnalyzerFactory";
+import { countFileLogicalLines, getLongestFunction } from "../metricsAnalyzer/linesOfCode";
+import { formatMetricValue } from "../metricsAnalyzer/numberFormat";
+import {
+  CoverageReport,
+  findFileCoverage,
+  getFunctionCoverage,
+  getRiskScore,
+  parseCoverage,
+} from "../coverage/coverage";
+import { CodeMetricsConfig, ConfigurationManager } from "../configuration";
+import { getTestFunctions } from "../providers/testComplexityProvider";
+import { findExcludingPattern, isTestFile } from "./fileFilters";
+import {
+  GoModuleLayout,
+  getGoModuleSkipReason,
+  isModuleCachePath,
+  parseModulePath,
+  parseWorkspaceUses,
+  resolveGoPackage,
+} from "./goModules";
+import { getSubmoduleSkipReason, resolveSubmoduleDirs } from "./gitSubmodules";
+import { DebtFactors, estimateDebt, formatDebt } from "./technicalDebt";
+
+/** Analysis results for a single source file. */
+export interface FileMetrics {
+  /** URI of the analyzed file */
+  uri: vscode.Uri;
+  /** Path relative to the workspace root (forward-slash separated) */
+  relativePath: string;
+  /** Language identifier the file was analyzed as */
+  languageId: string;
+  /** Metrics for every function found in the file */
+  functions: UnifiedFunctionMetrics[];
+  /** Lines with code in the whole file, declarations outside functions included */
+  logicalLines?: number;
+  /** Fluent methods and call chains, when `reportFluentChains` is enabled (Go only) */
+  fluent?: FluentUsage;
+  /** Import path of the file's package, for Go files in a module */
+  goPackage?: string;
+  /** Build constraint of a Go file from its `//go:build` line, e.g. `linux && amd64` */
+  buildConstraint?: string;
+}
+
+/** One implementation of a function declared in several build-constrained Go files. */
+export interface PlatformImplementation {
+  /** The file declaring this implementation */
+  file: FileMetrics;
+  func: UnifiedFunctionMetrics;
+  /** The file's build constraint, or its GOOS/GOARCH file name suffix, e.g. `windows` */
+  variant: string;
+}
+
+/** A function of a Go package with one implementation per platform. */
+export interface PlatformFunctionGroup {
+  /** Qualified function name, e.g. `FD.Close` */
+  name: string;
+  /** Package import path, or the directory of the files outside a Go module */
+  goPackage: string;
+  /** The implementations, most complex first */
+  implementations: PlatformImplementation[];
+}
+
+/** A function that no analyzed function of its package calls: a dead code candidate. */
+export interface UnusedFunction {
+  /** The file declaring the function */
+  file: FileMetrics;
+  func: UnifiedFunctionMetrics;
+  /** Whether the function is exported, so callers may live in other packages or modules */
+  exported: boolean;
+}
+
+/** A Go function whose shape suggests moving it between methods and package functions. */
+export interface MethodCandidate {
+  /** The file declaring the function */
+  file: FileMetrics;
+  func: UnifiedFunctionMetrics;
+  /**
+   * `method`: a package function writing several fields through its `*T` first parameter;
+   * `function`: an unexported method that never uses its receiver
+   */
+  kind: "method" | "function";
+  /** The type the function would become a method of, or the method's receiver type */
+  type: string;
+}
+
+/** Method statistics of one Go receiver type. */
+export interface TypeMetrics {
+  /** Receiver type name without type parameters; `*T` for a separately listed pointer method set */
+  type: string;
+  /** Package import path, or the directory of the type's files outside a Go module */
+  goPackage: string;
+  /** Number of methods (NOM) */
+  methodCount: number;
+  /** Summed cognitive complexity of the methods (WMC, weighted methods per class) */
+  weightedComplexity: number;
+}
+
+/** Aggregated method-chain statistics for one fluent (builder-style) type. */
+export interface FluentTypeStats {
+  /** Receiver type name */
+  type: string;
+  /** Number of methods returning the receiver type */
+  methods: number;
+  /** Summed cognitive complexity of those methods */
+  complexity: number;
+  /** Number of call chains with at least two consecutive fluent calls of this type */
+  chains: number;
+  /** Fluent calls in the longest chain */
+  longestChain: number;
+  /** Average fluent calls per chain (0 without chains) */
+  averageChain: number;
+}
+
+/** Complexity thresholds that a language's `[language]` settings set for a root. */
+export type LanguageThresholds = Pick<CodeMetricsConfig, "warningThreshold" | "errorThreshold">;
+
+/** Analysis results for one workspace root, evaluated against that root's configuration. */
+export interface RootMetrics {
+  /** Display name of the workspace folder */
+  name: string;
+  /** The workspace folder, or undefined for results not tied to an open folder */
+  folder: vscode.WorkspaceFolder | undefined;
+  /** The configuration resolved for this root */
+  config: CodeMetricsConfig;
+  /** Per-file results, sorted by relative path */
+  files: FileMetrics[];
+  /** Line coverage loaded from the root's `coverageFile`, if configured and readable */
+  coverage?: CoverageReport;
+  /** Subfolder the analysis was limited to, when narrower than the workspace folder */
+  scope?: vscode.Uri;
+  /** The folder's Go modules, when it has any */
+  goModules?: GoModuleLayout;
+  /** Forward-slash paths of the folder's git submodules, when their contents are skipped */
+  submodules?: string[];
+  /** Thresholds by language identifier, for languages whose settings differ from the root's */
+  languageThresholds?: Record<string, LanguageThresholds>;
+}
+
+/** Analysis results for the whole workspace, one entry per root. */
+export interface WorkspaceMetrics {
+  roots: RootMetrics[];
+}
+
+/** The files a workspace scan would analyze in one root, and why the others are skipped. */
+export interface FileListing {
+  /** Display name of the workspace folder */
+  name: string;
+  /** Relative paths of the files that would be analyzed */
+  included: string[];
+  /** Relative paths of skipped files with the reason each was skipped */
+  skipped: { relativePath: string; reason: string }[];
+}
+
+/** Operating systems recognised in Go file-name build constraints (`go tool dist list`). */
+const GOOS = new Set([
+  "aix", "android", "darwin", "dragonfly", "freebsd", "hurd", "illumos", "ios", "js",
+  "linux", "nacl", "netbsd", "openbsd", "plan9", "solaris", "wasip1", "windows", "zos",
+]);
+
+/** Architectures recognised in Go file-name build constraints. */
+const GOARCH = new Set([
+  "386", "amd64", "amd64p32", "arm", "armbe", "arm64", "arm64be", "loong64", "mips",
+  "mipsle", "mips64", "mips64le", "mips64p32", "mips64p32le", "ppc", "ppc64", "ppc64le",
+  "riscv", "riscv64", "s390", "s390x", "sparc", "sparc64", "wasm",
+]);
+
+/**
+ * Glob matching every file with a supported extension, e.g. `**\/*.{go,ts}`.
+ *
+ * @returns The glob pattern
+ */
+export function getSourceFileGlob(): string {
+  return `**/*.{${MetricsAnalyzerFactory.getSupportedFileExtensions().join(",")}}`;
+}
+
+/** Decodes file contents read through `vscode.workspace.fs`. */
+const decoder = new TextDecoder("utf-8");
+
+/**
+ * Scans workspace folders and analyzes every supported, non-excluded source file.
+ */
+export class WorkspaceAnalyzer {
+  /**
+   * Analyzes all workspace folders.
+   *
+   * @param token - Optional cancellation token; partially collected results are returned on cancel
+   * @returns Results grouped by workspace root
+   */
+  public static async analyzeWorkspace(
+    token?: vscode.CancellationToken
+  ): Promise<WorkspaceMetrics> {
+    const roots: RootMetrics[] = [];
+    for (const folder of vscode.workspace.workspaceFolders ?? []) {
+      if (token?.isCancellationRequested) {
+        break;
+      }
+      roots.push(await this.analyzeFolder(folder, token));
+    }
+    return { roots };
+  }
+
+  /**
+   * Analyzes one directory subtree of a workspace folder, with the folder's configuration.
+   * File paths in the results stay relative to the workspace folder.
+   *
+   * @param scope - The directory to analyze
+   * @param token - Optional cancellation token
+   * @returns Results with a single root, or undefined when the directory is not in the workspace
+   */
+  public static async analyzeSubtree(
+    scope: vscode.Uri,
+    token?: vscode.CancellationToken
+  ): Promise<WorkspaceMetrics | undefined> {
+    const folder = vscode.workspace.getWorkspaceFolder(scope);
+    if (!folder) {
+      return undefined;
+    }
+    const isWholeFolder = scope.path.replace(/\/$/, "") === folder.uri.path.replace(/\/$/, "");
+    return { roots: [await this.analyzeFolder(folder, token, isWholeFolder ? undefined : scope)] };
+  }
+
+  /**
+   * Analyzes a single workspace folder using the configuration resolved for it.
+   *
+   * @param folder - The workspace folder to analyze
+   * @param token - Optional cancellation token
+   * @param scope - Optional subfolder to limit the analysis to
+   * @returns The folder's results
+   */
+  public static async analyzeFolder(
+    folder: vscode.WorkspaceFolder,
+    token?: vscode.CancellationToken,
+    scope?: vscode.Uri
+  ): Promise<RootMetrics> {
+    const config = ConfigurationManager.getConfiguration(folder.uri);
+    const name = scope
+      ? `${folder.name}/${path.posix.relative(folder.uri.path, scope.path)}`
+      : folder.name;
+    const root: RootMetrics = { name, folder, config, files: [], scope };
+    if (!config.enabled) {
+      return root;
+    }
+
+    root.coverage = await this.loadCoverage(folder, config);
+    root.goModules = await this.loadGoModules(folder);
+    root.submodules = await this.loadSubmodules(folder, config);
+
+    for (const uri of await this.findSourceFiles(
+      folder,
+      config,
+      scope,
+      root.goModules,
+      root.submodules
+    )) {
+      if (token?.isCancellationRequested) {
+        break;
+      }
+      const file = await this.analyzeUri(uri, folder, config, root.goModules);
+      if (file) {
+        root.files.push(file);
+      }
+    }
+    root.files.sort((a, b) => a.relativePath.localeCompare(b.relativePath));
+    root.languageThresholds = this.loadLanguageThresholds(folder, config, root.files);
+    return root;
+  }
+
+  /**
+   * Reads the `[language]` threshold settings of the languages analyzed in a folder.
+   *
+   * @param folder - The workspace folder
+   * @param config - The folder's resolved configuration
+   * @param files - The folder's analyzed files
+   * @returns Thresholds of the languages that differ from the folder's, or undefined if none do
+   */
+  public static loadLanguageThresholds(
+    folder: vscode.WorkspaceFolder,
+    config: CodeMetricsConfig,
+    files: readonly FileMetrics[]
+  ): Record<string, LanguageThresholds> | undefined {
+    let thresholds: Record<string, LanguageThresholds> | undefined;
+    for (const languageId of new Set(files.map((file) => file.languageId))) {
+      const { warningThreshold, errorThreshold } = ConfigurationManager.getConfiguration(
+        folder.uri,
+        languageId
+      );
+      if (warningThreshold !== config.warningThreshold || errorThreshold !== config.errorThreshold) {
+        thresholds = { ...thresholds, [languageId]: { warningThreshold, errorThreshold } };
+      }
+    }
+    return thresholds;
+  }
+
+  /**
+   * Loads the coverage file configured for a folder (lcov or Go cover profile).
+   * A missing or unreadable file is logged and skipped, so the scan still completes.
+   *
+   * @param folder - The workspace folder; relative coverage paths resolve against it
+   * @param config - The folder's resolved configuration
+   * @returns The parsed coverage, or undefined when none is configured or it cannot be read
+   */
+  public static async loadCoverage(
+    folder: vscode.WorkspaceFolder,
+    config: CodeMetricsConfig
+  ): Promise<CoverageReport | undefined> {
+    if (!config.coverageFile) {
+      return undefined;
+    }
+    const uri = path.isAbsolute(config.coverageFile)
+      ? vscode.Uri.file(config.coverageFile)
+      : vscode.Uri.joinPath(folder.uri, config.coverageFile);
+    try {
+      return parseCoverage(decoder.decode(await vscode.workspace.fs.readFile(uri)));
+    } catch (error) {
+      console.warn(`Could not read coverage file ${uri.fsPath} for ${folder.name}:`, error);
+      return undefined;
+    }
+  }
+
+  /**
+   * Reads the Go modules of a folder: every `go.mod` outside the module cache, and the
+   * modules used by a `go.work` at the folder root. Unreadable files are skipped.
+   *
+   * @param folder - The workspace folder
+   * @returns The modules, or undefined when the folder has no `go.mod`
+   */
+  public static async loadGoModules(
+    folder: vscode.WorkspaceFolder
+  ): Promise<GoModuleLayout | undefined> {
+    const modules: GoModuleLayout["modules"] = [];
+    for (const uri of await vscode.workspace.findFiles(
+      new vscode.RelativePattern(folder, "**/go.mod")
+    )) {
+      const dir = path.posix.dirname(uri.path);
+      if (isModuleCachePath(path.posix.relative(folder.uri.path, dir))) {
+        continue;
+      }
+      try {
+        const modulePath = parseModulePath(decoder.decode(await vscode.workspace.fs.readFile(uri)));
+        if (modulePath) {
+          modules.push({ dir, path: modulePath });
+        }
+      } catch (error) {
+        console.warn(`Could not read ${uri.fsPath}:`, error);
+      }
+    }
+    if (modules.length === 0) {
+      return undefined;
+    }
+
+    const layout: GoModuleLayout = { modules };
+    try {
+      const goWork = decoder.decode(
+        await vscode.workspace.fs.readFile(vscode.Uri.joinPath(folder.uri, "go.work"))
+      );
+      layout.workspaceDirs = parseWorkspaceUses(goWork).map((dir) =>
+        path.posix.resolve(folder.uri.path, dir.replace(/\\/g, "/"))
+      );
+    } catch {
+      // No go.work: every module in the folder is analyzed.
+    }
+    return layout;
+  }
+
+  /**
+   * Reads the git submodules declared in the `.gitmodules` file at a folder's root, whose
+   * contents are skipped unless `analysis.includeSubmodules` is on.
+   *
+   * @param folder - The workspace folder
+   * @param config - The folder's resolved configuration
+   * @returns Forward-slash paths of the submodule directories, or undefined when submodules
+   *   are analyzed or the folder has none
+   */
+  public static async loadSubmodules(
+    folder: vscode.WorkspaceFolder,
+    config: CodeMetricsConfig
+  ): Promise<string[] | undefined> {
+    if (config.includeSubmodules) {
+      return undefined;
+    }
+    try {
+      const text = decoder.decode(
+        await vscode.workspace.fs.readFile(vscode.Uri.joinPath(folder.uri, ".gitmodules"))
+      );
+      const dirs = resolveSubmoduleDirs(folder.uri.path, text);
+      return dirs.length > 0 ? dirs : undefined;
+    } catch {
+      // No .gitmodules: the folder has no submodules.
+      return undefined;
+    }
+  }
+
+  /**
+   * Finds supported source files in a folder, honouring the folder's exclude patterns.
+   *
+   * @param folder - The workspace folder to search
+   * @param config - The folder's resolved configuration
+   * @param scope - Optional subfolder to limit the search to
+   * @param goModules - The folder's Go modules, to which Go files are limited
+   * @param submodules - The folder's skipped git submodule directories
+   * @returns URIs of the files to analyze
+   */
+  public static async findSourceFiles(
+    folder: vscode.WorkspaceFolder,
+    config: CodeMetricsConfig,
+    scope?: vscode.Uri,
+    goModules?: GoModuleLayout,
+    submodules?: readonly string[]
+  ): Promise<vscode.Uri[]> {
+    const uris = await vscode.workspace.findFiles(
+      new vscode.RelativePattern(scope ?? folder, getSourceFileGlob())
+    );
+    return uris.filter((uri) => this.isIncluded(uri, config, goModules, submodules));
+  }
+
+  /**
+   * Returns whether a file is a supported source file that the configuration does not exclude.
+   *
+   * @param uri - The file to check
+   * @param config - The resolved configuration of the file's workspace root
+   * @param goModules - The root's Go modules, when it has any
+   * @param submodules - The root's skipped git submodule directories, when it has any
+   * @returns true if the file should be analyzed
+   */
+  public static isIncluded(
+    uri: vscode.Uri,
+    config: CodeMetricsConfig,
+    goModules?: GoModuleLayout,
+    submodules?: readonly string[]
+  ): boolean {
+    return this.getSkipReason(uri, config, goModules, submodules) === undefined;
+  }
+
+  /**
+   * Explains why a file would not be analyzed.
+   *
+   * @param uri - The file to check
+   * @param config - The resolved configuration of the file's workspace root
+   * @param goModules - The root's Go modules; Go files outside them are skipped
+   * @param submodules - The root's skipped git submodule directories, from {@link loadSubmodules}
+   * @returns A human-readable reason, or undefined if the file would be analyzed
+   */
+  public static getSkipReason(
+    uri: vscode.Uri,
+    config: CodeMetricsConfig,
+    goModules?: GoModuleLayout,
+    submodules?: readonly string[]
+  ): string | undefined {
+    const languageId = MetricsAnalyzerFactory.getLanguageIdForFile(uri.fsPath);
+    if (!languageId) {
+      return "unsupported file type";
+    }
+    const submoduleReason = submodules ? getSubmoduleSkipReason(submodules, uri.path) : undefined;
+    if (submoduleReason) {
+      return submoduleReason;
+    }
+    const moduleReason =
+      goModules && languageId === "go" ? getGoModuleSkipReason(goModules, uri.path) : undefined;
+    if (moduleReason) {
+      return moduleReason;
+    }
+    const pattern = findExcludingPattern(uri.fsPath, config);
+    if (pattern === undefined) {
+      return undefined;
+    }
+    return findExcludingPattern(uri.fsPath, { ...config, includeTests: true }) === undefined
+      ? `test file matching exclude pattern ${pattern} (enable includeTests to analyze)`
+      : `matches exclude pattern ${pattern}`;
+  }
+
+  /**
+   * Resolves which files a workspace scan would analyze, without analyzing them.
+   *
+   * Files hidden by VS Code's `files.exclude` are never returned by the file search and
+   * therefore not listed; everything else with a supported extension is listed as either
+   * included or skipped with a reason.
+   *
+   * @returns One listing per workspace folder
+   */
+  public static async listFiles(): Promise<FileListing[]> {
+    const listings: FileListing[] = [];
+    for (const folder of vscode.workspace.workspaceFolders ?? []) {
+      const config = ConfigurationManager.getConfiguration(folder.uri);
+      const goModules = await this.loadGoModules(folder);
+      const submodules = await this.loadSubmodules(folder, config);
+      const listing: FileListing = { name: folder.name, included: [], skipped: [] };
+      const uris = await vscode.workspace.findFiles(
+        new vscode.RelativePattern(folder, getSourceFileGlob())
+      );
+      for (const uri of uris) {
+        const relativePath = path.posix.relative(folder.uri.path, uri.path);
+        const reason = config.enabled
+          ? this.getSkipReason(uri, config, goModules, submodules)
+          : "analysis is disabled for this folder";
+        if (reason === undefined) {
+          listing.included.push(relativePath);
+        } else {
+          listing.skipped.push({ relativePath, reason });
+        }
+      }
+      listing.included.sort((a, b) => a.localeCompare(b));
+      listing.skipped.sort((a, b) => a.relativePath.localeCompare(b.relativePath));
+      listings.push(listing);
+    }
+    return listings;
+  }
+
+  /**
+   * Reads and analyzes one file. Returns undefined for unsupported or unreadable files.
+   *
+   * @param uri - The file to analyze
+   * @param folder - The workspace folder the file belongs to
+   * @param config - The folder's resolved configuration
+   * @param goModules - The folder's Go modules, used to label Go files with their package
+   * @returns The file's results
+   */
+  public static async analyzeUri(
+    uri: vscode.Uri,
+    folder: vscode.WorkspaceFolder,
+    config: CodeMetricsConfig,
+    goModules?: GoModuleLayout
+  ): Promise<FileMetrics | undefined> {
+    const languageId = MetricsAnalyzerFactory.getLanguageIdForFile(uri.fsPath);
+    if (!languageId) {
+      return undefined;
+    }
+    try {
+      const sourceText = decoder.decode(await vscode.workspace.fs.readFile(uri));
+      return this.analyzeSource(sourceText, uri, folder, languageId, config, goModules);
+    } catch (error) {
+      console.error(`Error analyzing ${uri.fsPath} in ${folder.name}:`, error);
+      return undefined;
+    }
+  }
+
+  /**
+   * Analyzes the contents of one file, for sources not read from disk such as a file at
+   * a git ref.
+   *
+   * @param sourceText - The file's contents
+   * @param uri - The file the contents belong to
+   * @param folder - The workspace folder the file belongs to
+   * @param languageId - Language identifier to analyze the file as
+   * @param config - The folder's resolved configuration
+   * @param goModules - The folder's Go modules, used to label Go files with their package
+   * @returns The file's results
+   */
+  public static analyzeSource(
+    sourceText: string,
+    uri: vscode.Uri,
+    folder: vscode.WorkspaceFolder,
+    languageId: string,
+    config: CodeMetricsConfig,
+    goModules?: GoModuleLayout
+  ): FileMetrics {
+    const file: FileMetrics = {
+      uri,
+      relativePath: path.posix.relative(folder.uri.path, uri.path),
+      languageId,
+      functions: MetricsAnalyzerFactory.analyzeFile(
+        sourceText,
+        languageId,
+        ConfigurationManager.getAnalyzerOptions(config)
+      ),
+    };
+    if (config.enabledMetrics.includes("linesOfCode")) {
+      file.logicalLines = countFileLogicalLines(sourceText, languageId);
+    }
+    if (config.reportFluentChains) {
+      file.fluent = MetricsAnalyzerFactory.findFluentUsage(sourceText, languageId);
+    }
+    const goPackage =
+      goModules && languageId === "go" ? resolveGoPackage(goModules, uri.path) : undefined;
+    if (goPackage) {
+      file.goPackage = goPackage.importPath;
+    }
+    const buildConstraint = languageId === "go" ? getBuildConstraint(sourceText) : undefined;
+    if (buildConstraint) {
+      file.buildConstraint = buildConstraint;
+    }
+    return file;
+  }
+}
+
+/**
+ * Strips the GOOS/GOARCH build-constraint suffix from a Go file name, following the
+ * `name_GOOS_GOARCH.go` rules of `go/build` (an optional `_test` stays in place).
+ *
+ * @param relativePath - Path of the file (forward slashes)
+ * @returns The path without the suffix, e.g. `net/fd.go` for `net/fd_linux_amd64.go`,
+ *   or undefined when the file is not a platform variant
+ */
+export function getPlatformVariantBase(relativePath: string): string | undefined {
+  return splitPlatformVariant(relativePath)?.base;
+}
+
+/** Splits a Go file name into its base name and GOOS/GOARCH suffix, e.g. `linux_amd64`. */
+function splitPlatformVariant(
+  relativePath: string
+): { base: string; suffix: string } | undefined {
+  if (!relativePath.endsWith(".go")) {
+    return undefined;
+  }
+  const slash = relativePath.lastIndexOf("/");
+  const dir = relativePath.substring(0, slash + 1);
+  let stem = relativePath.substring(slash + 1, relativePath.length - ".go".length);
+  const isTest = stem.endsWith("_test");
+  if (isTest) {
+    stem = stem.substring(0, stem.length - "_test".length);
+  }
+
+  const parts = stem.split("_");
+  const n = parts.length;
+  let keep: number;
+  if (n >= 3 && GOOS.has(parts[n - 2]) && GOARCH.has(parts[n - 1])) {
+    keep = n - 2;
+  } else if (n >= 2 && (GOOS.has(parts[n - 1]) || GOARCH.has(parts[n - 1]))) {
+    keep = n - 1;
+  } else {
+    return undefined;
+  }
+  return {
+    base: `${dir}${parts.slice(0, keep).join("_")}${isTest ? "_test" : ""}.go`,
+    suffix: parts.slice(keep).join("_"),
+  };
+}
+
+/**
+ * Reads the build constraint of a Go file: the `//go:build` line of its header, before
+ * the `package` clause. Files with only legacy `// +build` lines get the equivalent
+ * expression, e.g. `(linux || darwin) && amd64` for `+build linux darwin` and `+build amd64`.
+ *
+ * @param sourceText - The file's contents
+ * @returns The constraint expression, or undefined for unconstrained files
+ */
+export function getBuildConstraint(sourceText: string): string | undefined {
+  const legacy: string[] = [];
+  for (const line of sourceText.split(/\r?\n/)) {
+    const trimmed = line.trim();
+    const goBuild = /^\/\/go:build\s+(.+)$/.exec(trimmed);
+    if (goBuild) {
+      return goBuild[1].trim();
+    }
+    const plusBuild = /^\/\/\s*\+build\s+(.+)$/.exec(trimmed);
+    if (plusBuild) {
+      const options = plusBuild[1].trim().split(/\s+/).map((option) => option.split(",").join(" && "));
+      legacy.push(options.length > 1 ? `(${options.join(" || ")})` : options[0]);
+    } else if (trimmed !== "" && !trimmed.startsWith("//")) {
+      break; // the package clause, or a block comment, ends the header
+    }
+  }
+  return legacy.length > 0 ? legacy.join(" && ") : undefined;
+}
+
+/**
+ * Groups the Go functions of a root that are implemented in several build-constrained
+ * files of one package, such as `Poll` in `fd_linux.go` and `fd_windows.go`, or in files
+ * with different `//go:build` lines. A file's variant is its build constraint, or else
+ * its GOOS/GOARCH file name suffix; files with neither are not considered.
+ *
+ * @param root - The analyzed root
+ * @returns Groups with at least two implementations, the most complex implementation first
+ */
+export function groupPlatformFunctions(root: RootMetrics): PlatformFunctionGroup[] {
+  const groups = new Map<string, PlatformFunctionGroup>();
+  for (const file of root.files) {
+    if (file.languageId !== "go") {
+      continue;
+    }
+    const variant = file.buildConstraint ?? splitPlatformVariant(file.relativePath)?.suffix;
+    if (!variant) {
+      continue;
+    }
+    const goPackage = file.goPackage ?? path.posix.dirname(file.relativePath);
+    for (const func of file.functions) {
+      const key = `${goPackage}\0${func.name}`;
+      const group = groups.get(key) ?? { name: func.name, goPackage, implementations: [] };
+      group.implementations.push({ file, func, variant });
+      groups.set(key, group);
+    }
+  }
+  const maxComplexity = (group: PlatformFunctionGroup) => group.implementations[0].func.complexity;
+  return [...groups.values()]
+    .filter((group) => new Set(group.implementations.map((i) => i.file)).size > 1)
+    .map((group) => ({
+      ...group,
+      implementations: [...group.implementations].sort(
+        (a, b) => b.func.complexity - a.func.complexity
+      ),
+    }))
+    .sort(
+      (a, b) =>
+        maxComplexity(b) - maxComplexity(a) ||
+        a.goPackage.localeCompare(b.goPackage) ||
+        a.name.localeCompare(b.name)
+    );
+}
+
+/**
+ * Renders the functions with one implementation per platform, each implementation with
+ * its complexity, file, and variant, banded by the thresholds of the file's language.
+ */
+function formatPlatformFunctions(root: RootMetrics): string[] {
+  const groups = groupPlatformFunctions(root);
+  if (groups.length === 0) {
+    return [];
+  }
+  const lines = ["  Platform implementations (complexity per variant):"];
+  for (const group of groups) {
+    lines.push(`  ${group.name}  (${group.goPackage}, ${group.implementations.length} variants)`);
+    for (const { file, func, variant } of group.implementations) {
+      const status = ConfigurationManager.getComplexityStatus(
+        func.complexity,
+        getLanguageConfig(root, file.languageId)
+      );
+      lines.push(
+        `    ${status.icon} ${String(func.complexity).padStart(3)}  ${file.relativePath}  (${variant})`
+      );
+    }
+  }
+  return lines;
+}
+
+/**
+ * Renders the platform-variant groups of a root: files sharing a base name after the
+ * GOOS/GOARCH suffix is stripped, each with its highest function complexity.
+ */
+function formatPlatformVariants(root: RootMetrics): string[] {
+  const groups = new Map<string, FileMetrics[]>();
+  for (const file of root.files) {
+    const base = getPlatformVariantBase(file.relativePath);
+    if (base) {
+      groups.set(base, [...(groups.get(base) ?? []), file]);
+    }
+  }
+
+  const lines: string[] = [];
+  for (const [base, files] of groups) {
+    if (files.length < 2) {
+      continue;
+    }
+    lines.push(`  ${base}  (${files.length} platform variants)`);
+    const maxComplexity = (file: FileMetrics) =>
+      file.functions.reduce((max, f) => Math.max(max, f.complexity), 0);
+    const sorted = [...files].sort((a, b) => maxComplexity(b) - maxComplexity(a));
+    for (const file of sorted) {
+      const max = maxComplexity(file);
+      const status = ConfigurationManager.getComplexityStatus(
+        max,
+        getLanguageConfig(root, file.languageId)
+      );
+      lines.push(`    ${status.icon} ${String(max).padStart(3)}  ${file.relativePath}`);
+    }
+  }
+  if (lines.length > 0) {
+    lines.unshift("  Platform variants (highest complexity per file):");
+  }
+  return lines;
+}
+
+/**
+ * Renders the functions tagged as resolvers by `analysis.resolverPatterns`, most complex
+ * first, each with the band of its language's thresholds.
+ *
+ * @param root - The analyzed root
+ * @returns Report lines, or none when no function is tagged
+ */
+function formatResolvers(root: RootMetrics): string[] {
+  const resolvers = root.files.flatMap((file) =>
+    file.functions
+      .filter((func) => func.tags?.includes("resolver"))
+      .map((func) => ({ file, func }))
+  );
+  if (resolvers.length === 0) {
+    return [];
+  }
+
+  const total = resolvers.reduce((n, { func }) => n + func.complexity, 0);
+  const over = resolvers.filter(
+    ({ file, func }) => func.complexity >= getLanguageConfig(root, file.languageId).warningThreshold
+  ).length;
+  const lines = [
+    `  Resolvers: ${resolvers.length} functions, total complexity ${total}, ${over} over threshold`,
+  ];
+  const sorted = [...resolvers].sort((a, b) => b.func.complexity - a.func.complexity);
+  for (const { file, func } of sorted) {
+    const status = ConfigurationManager.getComplexityStatus(
+      func.complexity,
+      getLanguageConfig(root, file.languageId)
+    );
+    lines.push(
+      `    ${status.icon} ${String(func.complexity).padStart(3)}  ${func.name}  (${file.relativePath}:${func.startLine + 1})`
+    );
+  }
+  return lines;
+}
+
+/**
+ * Aggregates fluent usage across a root's files into per-type chain statistics.
+ *
+ * A chain counts for a type when it contains at least two consecutive calls to that
+ * type's fluent methods; its length is the longest such run, so a terminal call like
+ * `Build()` does not count.
+ *
+ * @param root - The root whose files carry fluent usage
+ * @returns Statistics per type with fluent methods, longest chains first
+ */
+export function summarizeFluentTypes(root: RootMetrics): FluentTypeStats[] {
+  const methodsByType = new Map<string, Set<string>>();
+  for (const file of root.files) {
+    for (const { type, name } of file.fluent?.fluentMethods ?? []) {
+      methodsByType.set(type, (methodsByType.get(type) ?? new Set()).add(name));
+    }
+  }
+
+  const stats: FluentTypeStats[] = [];
+  for (const [type, methods] of methodsByType) {
+    const lengths: number[] = [];
+    for (const file of root.files) {
+      for (const chain of file.fluent?.chains ?? []) {
+        let run = 0;
+        let longest = 0;
+        for (const method of chain.methods) {
+          run = methods.has(method) ? run + 1 : 0;
+          longest = Math.max(longest, run);
+        }
+        if (longest >= 2) {
+          lengths.push(longest);
+        }
+      }
+    }
+
+    let complexity = 0;
+    for (const file of root.files) {
+      for (const func of file.functions) {
+        const [receiver, method] = func.name.replace(/\[.*?\]/, "").split(".");
+        if (receiver === type && methods.has(method)) {
+          complexity += func.complexity;
+        }
+      }
+    }
+
+    stats.push({
+      type,
+      methods: methods.size,
+      complexity,
+      chains: lengths.length,
+      longestChain: Math.max(0, ...lengths),
+      averageChain: lengths.length === 0 ? 0 : lengths.reduce((a, b) => a + b, 0) / lengths.length,
+    });
+  }
+  return stats.sort((a, b) => b.longestChain - a.longestChain || a.type.localeCompare(b.type));
+}
+
+/** Renders the fluent type section of a root's report. */
+function formatFluentTypes(root: RootMetrics, locale: string): string[] {
+  const stats = summarizeFluentTypes(root);
+  if (stats.length === 0) {
+    return [];
+  }
+  const lines = ["  Fluent types (methods returning their receiver):"];
+  for (const s of stats) {
+    const average = formatMetricValue(Math.round(s.averageChain * 10) / 10, locale);
+    lines.push(
+      `    ${s.type}  ${s.methods} methods (complexity ${s.complexity}), ${s.chains} chains, ` +
+        `longest ${s.longestChain}, average ${average}`
+    );
+  }
+  return lines;
+}
+
+/**
+ * Aggregates the Go methods of a root per receiver type and package. With
+ * `mergeReceivers`, methods on `T` and `*T` roll up into `T`; otherwise the pointer
+ * method set is listed as its own `*T` entry. Closures inside methods are not methods
+ * and are not counted.
+ *
+ * @param root - The analyzed root
+ * @param mergeReceivers - Whether value and pointer receivers count as one type
+ * @returns Statistics per type, highest summed complexity first
+ */
+export function summarizeTypes(root: RootMetrics, mergeReceivers: boolean): TypeMetrics[] {
+  const types = new Map<string, TypeMetrics>();
+  for (const file of root.files) {
+    if (file.languageId !== "go") {
+      continue;
+    }
+    const goPackage = file.goPackage ?? path.posix.dirname(file.relativePath);
+    for (const func of file.functions) {
+      if (func.pointerReceiver === undefined) {
+        continue; // functions and closures
+      }
+      const baseType = func.name.substring(0, func.name.lastIndexOf(".")).replace(/\[.*\]$/, "");
+      const type = func.pointerReceiver && !mergeReceivers ? `*${baseType}` : baseType;
+      const key = `${goPackage}\0${type}`;
+      const stats = types.get(key) ?? { type, goPackage, methodCount: 0, weightedComplexity: 0 };
+      stats.methodCount++;
+      stats.weightedComplexity += func.complexity;
+      types.set(key, stats);
+    }
+  }
+  return [...types.values()].sort(
+    (a, b) =>
+      b.weightedComplexity - a.weightedComplexity ||
+      a.goPackage.localeCompare(b.goPackage) ||
+      a.type.localeCompare(b.type)
+  );
+}
+
+/** Renders the type metrics section of a root's report. */
+function formatTypeMetrics(root: RootMetrics): string[] {
+  const types = summarizeTypes(root, root.config.typeMetricsMergeReceivers);
+  if (types.length === 0) {
+    return [];
+  }
+  const lines = ["  Types (WMC: summed method complexity, NOM: number of methods):"];
+  for (const t of types) {
+    lines.push(
+      `    WMC ${String(t.weightedComplexity).padStart(3)}  NOM ${String(t.methodCount).padStart(2)}  ${t.type} (${t.goPackage})`
+    );
+  }
+  return lines;
+}
+
+/** Go functions run by the toolchain rather than called: program and package entry points. */
+const GO_ENTRY_POINTS = new Set(["main", "init"]);
+
+/**
+ * Finds the Go functions of a root that no other analyzed function of their package calls,
+ * using the call graph of each function (see `UnifiedFunctionMetrics.calls`).
+ *
+ * The heuristic is conservative where the call graph is blind. Methods are never reported,
+ * since calls through other values and interfaces cannot be resolved, and neither are
+ * `main`, `init`, test entry points, and closures. Exported functions are reported only
+ * with `includeExported`, because calls from other packages are not resolved either.
+ * Functions passed as values rather than called still show up, so results are candidates.
+ *
+ * @param root - The analyzed root
+ * @param includeExported - Whether exported functions are reported too
+ * @returns The candidates, by file path and then line
+ */
+export function findUnusedFunctions(
+  root: RootMetrics,
+  includeExported: boolean
+): UnusedFunction[] {
+  const goFiles = root.files.filter((file) => file.languageId === "go");
+  const packageOf = (file: FileMetrics) =>
+    file.goPackage ?? path.posix.dirname(file.relativePath);
+
+  // Functions called by another function of the same package, keyed by package
+  const called = new Map<string, Set<string>>();
+  for (const file of goFiles) {
+    const callees = called.get(packageOf(file)) ?? new Set<string>();
+    for (const func of file.functions) {
+      for (const callee of func.calls ?? []) {
+        if (callee !== func.name) {
+          callees.add(callee);
+        }
+      }
+    }
+    called.set(packageOf(file), callees);
+  }
+
+  const unused: UnusedFunction[] = [];
+  for (const file of goFiles) {
+    const callees = called.get(packageOf(file))!;
+    const tests = new Set(isTestFile(file.relativePath) ? getTestFunctions(file.functions, "go") : []);
+    for (const func of file.functions) {
+      if (
+        func.name.includes(".") ||
+        GO_ENTRY_POINTS.has(func.name) ||
+        tests.has(func) ||
+        callees.has(func.name)
+      ) {
+        continue;
+      }
+      const exported = /^\p{Lu}/u.test(func.name);
+      if (!exported || includeExported) {
+        unused.push({ file, func, exported });
+      }
+    }
+  }
+  return unused;
+}
+
+/** Fields a package function must write through its `*T` parameter to be a method candidate. */
+const MIN_METHOD_CANDIDATE_WRITES = 2;
+
+/**
+ * Finds Go functions that look like they are on the wrong side of the method/function
+ * line: package functions writing at least {@link MIN_METHOD_CANDIDATE_WRITES} fields
+ * through their `*T` first parameter, and unexported methods that never use their
+ * receiver. Exported methods are left out because they often satisfy an interface, which
+ * the receiver cannot be dropped from. These are design hints, not defects.
+ *
+ * @param root - The analyzed root
+ * @returns The candidates, by file path and then line
+ */
+export function findMethodCandidates(root: RootMetrics): MethodCandidate[] {
+  const candidates: MethodCandidate[] = [];
+  for (const file of root.files) {
+    if (file.languageId !== "go") {
+      continue;
+    }
+    for (const func of file.functions) {
+      const writes = func.pointerParamWrites;
+      if (writes && writes.fieldCount >= MIN_METHOD_CANDIDATE_WRITES) {
+        candidates.push({ file, func, kind: "method", type: writes.type });
+      } else if (func.receiverUnused) {
+        const [type, method] = func.name.split(".");
+        if (method && !/^\p{Lu}/u.test(method)) {
+          candidates.push({ file, func, kind: "function", type });
+        }
+      }
+    }
+  }
+  return candidates;
+}
+
+/** Renders the method candidate section of a root's report. */
+function formatMethodCandidates(root: RootMetrics): string[] {
+  const candidates = findMethodCandidates(root);
+  if (candidates.length === 0) {
+    return [];
+  }
+  const lines = ["  Method candidates (design hints):"];
+  for (const { file, func, kind, type } of candidates) {
+    const hint =
+      kind === "method"
+        ? `${func.name} writes ${func.pointerParamWrites!.fieldCount} fields of *${type}: consider a method on ${type}`
+        : `${func.name} never uses its receiver: consider a package function`;
+    lines.push(`    ${hint}  (${file.relativePath}:${func.startLine + 1})`);
+  }
+  return lines;
+}
+
+/** Renders the unused function section of a root's report. */
+function formatUnusedFunctions(root: RootMetrics): string[] {
+  const unused = findUnusedFunctions(root, root.config.unusedFunctionsIncludeExported);
+  if (unused.length === 0) {
+    return [];
+  }
+  const complexity = unused.reduce((sum, { func }) => sum + func.complexity, 0);
+  const lines = [
+    `  Potentially unused functions (not called in their package; ${complexity} complexity):`,
+  ];
+  for (const { file, func, exported } of unused) {
+    lines.push(
+      `    ${String(func.complexity).padStart(3)}  ${func.name} (${file.relativePath}:${func.startLine + 1})` +
+        (exported ? "  exported" : "")
+    );
+  }
+  return lines;
+}
+
+/** The debt formula factors configured for a root. */
+function getDebtFactors(config: CodeMetricsConfig): DebtFactors {
+  return { baseMinutes: config.debtBaseMinutes, minutesPerPoint: config.debtMinutesPerPoint };
+}
+
+/**
+ * Estimates the remediation time of a root's functions at or above its warning threshold
+ * (see ./technicalDebt).
+ *
+ * @param root - The analyzed root
+ * @returns Minutes
+ */
+export function estimateRootDebt(root: RootMetrics): number {
+  const factors = getDebtFactors(root.config);
+  return root.files.reduce(
+    (sum, file) =>
+      sum +
+      estimateDebt(file.functions, getLanguageConfig(root, file.languageId).warningThreshold, factors),
+    0
+  );
+}
+
+/** Display names of language identifiers, for report sections. */
+const LANGUAGE_NAMES: Record<string, string> = {
+  csharp: "C#",
+  dart: "Dart",
+  elixir: "Elixir",
+  go: "Go",
+  gotmpl: "Go templates",
+  java: "Java",
+  javascript: "JavaScript",
+  javascriptreact: "JavaScript React",
+  python: "Python",
+  rust: "Rust",
+  sql: "SQL",
+  typescript: "TypeScript",
+  typescriptreact: "TypeScript React",
+};
+
+/**
+ * Returns the configuration that a root's functions in a language are evaluated with:
+ * the root's, with the thresholds of the language's `[language]` settings.
+ *
+ * @param root - The analyzed root
+ * @param languageId - The language identifier
+ * @returns The configuration
+ */
+export function getLanguageConfig(root: RootMetrics, languageId: string): CodeMetricsConfig {
+  const thresholds = root.languageThresholds?.[languageId];
+  return thresholds ? { ...root.config, ...thresholds } : root.config;
+}
+
+/** Counts the functions of files at or above their language's warning threshold. */
+function countOverThreshold(root: RootMetrics, files: readonly FileMetrics[]): number {
+  return files.reduce((n, file) => {
+    const threshold = getLanguageConfig(root, file.languageId).warningThreshold;
+    return n + file.functions.filter((func) => func.complexity >= threshold).length;
+  }, 0);
+}
+
+/**
+ * Renders workspace results as report lines, grouped by root.
+ *
+ * Each function's status icon is computed with its own root's thresholds, so identical
+ * code can appear with different bands under differently configured roots. Roots with
+ * files in several languages get a summary per language and one section per language,
+ * whose bands use that language's thresholds; functions stay sorted within each file.
+ * Debt is estimated per file and root, with a workspace total when there are several roots.
+ *
+ * @param metrics - The workspace results to render
+ * @returns Report lines ready to be written to an output channel
+ */
+export function formatWorkspaceReport(metrics: WorkspaceMetrics): string[] {
+  const lines: string[] = [];
+  if (metrics.roots.length === 0) {
+    lines.push("No workspace folders to analyze.");
+    return lines;
+  }
+
+  let workspaceDebt = 0;
+  for (const root of metrics.roots) {
+    const functionCount = root.files.reduce((n, f) => n + f.functions.length, 0);
+    lines.push(
+      `${root.name}  (warning ≥ ${root.config.warningThreshold}, error ≥ ${root.config.errorThreshold})`
+    );
+    const measured = root.files.filter((file) => file.logicalLines !== undefined);
+    const linesOfCode = measured.reduce((n, f) => n + (f.logicalLines ?? 0), 0);
+    lines.push(
+      `  ${root.files.length} files, ${functionCount} functions` +
+        (measured.length > 0 ? `, ${linesOfCode} lines of code` : "")
+    );
+    const reported = root.files.filter((file) => file.functions.length > 0);
+    const languages = [...new Set(reported.map((file) => file.languageId))].sort((a, b) =>
+      getLanguageName(a).localeCompare(getLanguageName(b))
+    );
+    if (languages.length > 1) {
+      const summaries = languages.map((languageId) => {
+        const files = reported.filter((file) => file.languageId === languageId);
+        const count = files.reduce((n, f) => n + f.functions.length, 0);
+        return `${getLanguageName(languageId)} ${count} (${countOverThreshold(root, files)} over threshold)`;
+      });
+      lines.push(`  Functions by language: ${summaries.join(", ")}`);
+    }
+    const debtFactors = getDebtFactors(root.config);
+    const rootDebt = estimateRootDebt(root);
+    workspaceDebt += rootDebt;
+    if (rootDebt > 0) {
+      const indebted = countOverThreshold(root, root.files);
+      lines.push(
+        `  ⏱ Estimated debt: ${formatDebt(rootDebt)} across ${indebted} functions over threshold`
+      );
+    }
+    if (root.goModules) {
+      const { modules, workspaceDirs } = root.goModules;
+      const used = modules
+        .filter((module) => !workspaceDirs || workspaceDirs.includes(module.dir))
+        .map((module) => module.path)
+        .sort();
+      if (used.length > 0) {
+        lines.push(`  Go modules: ${used.join(", ")}`);
+      }
+    }
+    const riskyCount = root.coverage ? countRiskyFunctions(root) : 0;
+    if (riskyCount > 0) {
+      lines.push(
+        `  ‼️ ${riskyCount} complex functions below ${root.config.coverageThreshold}% coverage`
+      );
+    }
+    const recursive = root.files.flatMap((f) => f.functions.filter((func) => func.recursion));
+    if (recursive.length > 0) {
+      const mutualCount = recursive.filter((func) => func.recursion === "mutual").length;
+      lines.push(
+        `  🔁 ${recursive.length} recursive functions (${mutualCount} in mutual recursion cycles)`
+      );
+    }
+    const flagged = root.files.flatMap((f) =>
+      f.functions.filter((func) => func.steadyStateComplexity !== undefined)
+    );
+    if (flagged.length > 0) {
+      const added = flagged.reduce(
+        (n, func) => n + func.complexity - (func.steadyStateComplexity ?? func.complexity),
+        0
+      );
+      lines.push(
+        `  🚩 ${flagged.length} functions with feature flag checks, adding ${added} complexity until the flags are removed`
+      );
+    }
+
+    const locale = ConfigurationManager.getDisplayLocale(root.config);
+    for (const languageId of languages) {
+      const config = getLanguageConfig(root, languageId);
+      const files = reported.filter((file) => file.languageId === languageId);
+      if (languages.length > 1) {
+        const count = files.reduce((n, f) => n + f.functions.length, 0);
+        lines.push(
+          `  ── ${getLanguageName(languageId)}  (warning ≥ ${config.warningThreshold}, ` +
+            `error ≥ ${config.errorThreshold}): ${files.length} files, ${count} functions`
+        );
+      }
+      lines.push(...formatFiles(root, files, config, debtFactors, locale));
+    }
+    lines.push(...formatPackageParameters(root, locale));
+    lines.push(...formatResolvers(root));
+    if (root.config.groupPlatformVariants) {
+      lines.push(...formatPlatformVariants(root));
+    }
+    if (root.config.groupPlatformFunctions) {
+      lines.push(...formatPlatformFunctions(root));
+    }
+    if (root.config.reportFluentChains) {
+      lines.push(...formatFluentTypes(root, locale));
+    }
+    if (root.config.reportUnusedFunctions) {
+      lines.push(...formatUnusedFunctions(root));
+    }
+    if (root.config.reportTypeMetrics) {
+      lines.push(...formatTypeMetrics(root));
+    }
+    if (root.config.reportMethodCandidates) {
+      lines.push(...formatMethodCandidates(root));
+    }
+    lines.push("");
+  }
+  if (metrics.roots.length > 1 && workspaceDebt > 0) {
+    lines.push(`Workspace estimated debt: ${formatDebt(workspaceDebt)}`);
+  }
+  return lines;
+}
+
+/**
+ * Renders the files of one language of a root, each function evaluated with the
+ * language's configuration.
+ *
+ * @param root - The analyzed root
+ * @param files - The root's files in the language that have functions
+ * @param config - The language's configuration, from {@link getLanguageConfig}
+ * @param debtFactors - How debt is estimated
+ * @param locale - Locale for metric values
+ * @returns Report lines
+ */
+function formatFiles(
+  root: RootMetrics,
+  files: readonly FileMetrics[],
+  config: CodeMetricsConfig,
+  debtFactors: DebtFactors,
+  locale: string
+): string[] {
+  const lines: string[] = [];
+  for (const file of files) {
+    const longest = getLongestFunction(file.functions);
+    const parameters = summarizeParameters(file.functions);
+    const fileDebt = estimateDebt(file.functions, config.warningThreshold, debtFactors);
+    const annotations = [
+      ...(file.goPackage ? [`package ${file.goPackage}`] : []),
+      ...(longest ? [`longest: ${longest.name}, ${longest.logicalLines} lines`] : []),
+      ...(parameters ? [formatParameterStats(parameters, locale)] : []),
+      ...(fileDebt > 0 ? [`debt ${formatDebt(fileDebt)}`] : []),
+    ];
+    lines.push(
+      annotations.length > 0
+        ? `  ${file.relativePath}  (${annotations.join("; ")})`
+        : `  ${file.relativePath}`
+    );
+    const fileCoverage = root.coverage
+      ? findFileCoverage(root.coverage, file.relativePath)
+      : undefined;
+    const byTokens = root.config.reportSortBy === "tokens";
+    const sorted = [...file.functions].sort((a, b) =>
+      byTokens
+        ? (b.tokenCount ?? 0) - (a.tokenCount ?? 0) || b.complexity - a.complexity
+        : b.complexity - a.complexity
+    );
+    for (const func of sorted) {
+      const status = ConfigurationManager.getComplexityStatus(func.complexity, config);
+      const tokensText =
+        byTokens && func.tokenCount !== undefined ? `  ${func.tokenCount} tokens` : "";
+      const coverage = fileCoverage ? getFunctionCoverage(func, fileCoverage) : undefined;
+      const steadyStateText =
+        func.steadyStateComplexity === undefined
+          ? ""
+          : `  steady state ${func.steadyStateComplexity}`;
+      const coverageText =
+        coverage === undefined
+          ? ""
+          : `  coverage ${Math.round(coverage * 100)}%, risk ${formatMetricValue(getRiskScore(func.complexity, coverage), locale)}` +
+            (isRisky(status.level, coverage, config) ? " ‼️" : "");
+      lines.push(
+        `    ${status.icon} ${String(func.complexity).padStart(3)}  ${func.name} (line ${func.startLine + 1})${func.recursion ? " 🔁" : ""}${func.approximate ? " ≈ approximate" : ""}${tokensText}${steadyStateText}${coverageText}`
+      );
+    }
+  }
+  return lines;
+}
+
+/** Returns the display name of a language identifier, e.g. `Go` for `go`. */
+function getLanguageName(languageId: string): string {
+  return LANGUAGE_NAMES[languageId] ?? languageId;
+}
+
+/** Average and maximum parameter count of a group of functions. */
+export interface ParameterStats {
+  average: number;
+  max: number;
+}
+
+/**
+ * Aggregates the parameter counts of a file's or package's functions. Many parameters on
+ * average hint at functions that would read better with an options struct.
+ *
+ * @param functions - The functions to aggregate
+ * @returns The statistics, or undefined when no function has a parameter count
+ */
+export function summarizeParameters(
+  functions: readonly UnifiedFunctionMetrics[]
+): ParameterStats | undefined {
+  const counts = functions
+    .map((func) => func.parameterCount)
+    .filter((count): count is number => count !== undefined);
+  if (counts.length === 0) {
+    return undefined;
+  }
+  return {
+    average: Math.round((counts.reduce((sum, n) => sum + n, 0) / counts.length) * 10) / 10,
+    max: Math.max(...counts),
+  };
+}
+
+function formatParameterStats(stats: ParameterStats, locale: string): string {
+  return `params avg ${formatMetricValue(stats.average, locale)}, max ${stats.max}`;
+}
+
+/**
+ * Renders parameter statistics per package (import path for Go files in a module, else
+ * directory) of a root, when more than one package has functions with parameter counts.
+ */
+function formatPackageParameters(root: RootMetrics, locale: string): string[] {
+  const packages = new Map<string, UnifiedFunctionMetrics[]>();
+  for (const file of root.files) {
+    const dir = file.goPackage ?? path.posix.dirname(file.relativePath);
+    packages.set(dir, [...(packages.get(dir) ?? []), ...file.functions]);
+  }
+  const lines: string[] = [];
+  for (const [dir, functions] of [...packages].sort((a, b) => a[0].localeCompare(b[0]))) {
+    const stats = summarizeParameters(functions);
+    if (stats) {
+      lines.push(`    ${dir}  ${formatParameterStats(stats, locale)}`);
+    }
+  }
+  return lines.length > 1 ? ["  Parameters per package:", ...lines] : [];
+}
+
+/**
+ * Returns whether a function is both complex (warning band or above) and covered below
+ * the root's `coverageThreshold`.
+ */
+function isRisky(
+  level: "low" | "warning" | "error",
+  coverage: number,
+  config: CodeMetricsConfig
+): boolean {
+  return level !== "low" && coverage * 100 < config.coverageThreshold;
+}
+
+/** Counts the functions of a root that are both complex and under-covered. */
+function countRiskyFunctions(root: RootMetrics): number {
+  let count = 0;
+  for (const file of root.files) {
+    const fileCoverage = root.coverage && findFileCoverage(root.coverage, file.relativePath);
+    if (!fileCoverage) {
+      continue;
+    }
+    const config = getLanguageConfig(root, file.languageId);
+    for (const func of file.functions) {
+      const coverage = getFunctionCoverage(func, fileCoverage);
+      const level = ConfigurationManager.getComplexityStatus(func.complexity, config).level;
+      if (coverage !== undefined && isRisky(level, coverage, config)) {
+        count++;
+      }
+    }
+  }
+  return count;
+}
+
+/**
+ * Finds the single most complex function across all roots. Ties go to the function
+ * that appears first (root, then file path, then source order).
+ *
+ * @param metrics - The workspace results to search
+ * @returns The function and its file, or undefined when no function was analyzed
+ */
+export function findWorstFunction(
+  metrics: WorkspaceMetrics
+): { file: FileMetrics; func: UnifiedFunctionMetrics } | undefined {
+  let worst: { file: FileMetrics; func: UnifiedFunctionMetrics } | undefined;
+  for (const root of metrics.roots) {
+    for (const file of root.files) {
+      for (const func of file.functions) {
+        if (!worst || func.complexity > worst.func.complexity) {
+          worst = { file, func };
+        }
+      }
+    }
+  }
+  return worst;
+}
+
+/**
+ * Builds the one-line verdict shown when an analysis completes: files and functions
+ * analyzed, functions at or above their root's warning threshold, and the worst function.
+ *
+ * @param metrics - The workspace results to summarize
+ * @returns The summary sentence
+ */
+export function summarizeWorkspace(metrics: WorkspaceMetrics): string {
+  let fileCount = 0;
+  let functionCount = 0;
+  let overThreshold = 0;
+  for (const root of metrics.roots) {
+    fileCount += root.files.length;
+    for (const file of root.files) {
+      functionCount += file.functions.length;
+    }
+    overThreshold += countOverThreshold(root, root.files);
+  }
+
+  const summary =
+    `Analyzed ${fileCount} files, ${functionCount} functions; ${overThreshold} over threshold.`;
+  const worst = findWorstFunction(metrics);
+  return worst
+    ? `${summary} Worst: ${worst.func.name} (${worst.func.complexity}) in ${worst.file.relativePath}.`
+    : summary;
+}
+
+/**
+ * Renders a dry-run file listing, grouped by root: included files first, then skipped
+ * files with the reason each was skipped.
+ *
+ * @param listings - The listings to render
+ * @returns Report lines ready to be written to an output channel
+ */
+export function formatFileListing(listings: FileListing[]): string[] {
+  const lines: string[] = [];
+  if (listings.length === 0) {
+    lines.push("No workspace folders to analyze.");
+    return lines;
+  }
+
+  for (const listing of listings) {
+    lines.push(
+      `${listing.name}  (${listing.included.length} included, ${listing.skipped.length} skipped)`
+    );
+    for (const relativePath of listing.included) {
+      lines.push(`  ✓ ${relativePath}`);
+    }
+    for (const { relativePath, reason } of listing.skipped) {
+      lines.push(`  ✗ ${relativePath} — ${reason}`);
+    }
+    lines.push("");
+  }
+  return lines;
+}
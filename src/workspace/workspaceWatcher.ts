@@ -0,0 +1,162 @@
+/**
+ * @fileoverview Incremental Workspace Analysis
+ *
+ * This module keeps workspace analysis results current after an initial scan. File system
+ * events are collected and debounced; each batch re-analyzes only the created or changed
+ * files and removes deleted ones, then notifies listeners with the updated results.
+ */
+
+import * as vscode from "vscode";
+import {
+  RootMetrics,
+  WorkspaceAnalyzer,
+  WorkspaceMetrics,
+  getSourceFileGlob,
+} from "./workspaceAnalyzer";
+
+/** Delay after the last file event before a batch of changes is processed. */
+const DEFAULT_DEBOUNCE_MS = 500;
+
+/** Kind of pending update for a file. */
+type PendingChange = "changed" | "deleted";
+
+/**
+ * Watches source files and applies changes to previously computed workspace results.
+ */
+export class WorkspaceMetricsWatcher implements vscode.Disposable {
+  private readonly metrics: WorkspaceMetrics;
+  private readonly debounceMs: number;
+  /** Pending updates keyed by URI string; a later event for the same file replaces an earlier one. */
+  private readonly pending = new Map<string, { uri: vscode.Uri; change: PendingChange }>();
+  private timer: ReturnType<typeof setTimeout> | undefined;
+  private readonly emitter = new vscode.EventEmitter<WorkspaceMetrics>();
+  private readonly watcher: vscode.FileSystemWatcher;
+  private readonly subscriptions: vscode.Disposable[];
+
+  /** Fires after a batch of file changes has been applied. */
+  public readonly onDidUpdate = this.emitter.event;
+
+  /**
+   * @param metrics - Results of the initial workspace scan; updated in place
+   * @param debounceMs - Delay after the last file event before changes are processed
+   */
+  constructor(metrics: WorkspaceMetrics, debounceMs: number = DEFAULT_DEBOUNCE_MS) {
+    this.metrics = metrics;
+    this.debounceMs = debounceMs;
+    this.watcher = vscode.workspace.createFileSystemWatcher(getSourceFileGlob());
+    this.subscriptions = [
+      this.watcher.onDidCreate((uri) => this.enqueue(uri, "changed")),
+      this.watcher.onDidChange((uri) => this.enqueue(uri, "changed")),
+      this.watcher.onDidDelete((uri) => this.enqueue(uri, "deleted")),
+    ];
+  }
+
+  /** The current workspace results. */
+  public get current(): WorkspaceMetrics {
+    return this.metrics;
+  }
+
+  /**
+   * Records a file change and (re)starts the debounce timer.
+   *
+   * @param uri - The file that changed
+   * @param change - Whether the file was created/changed or deleted
+   */
+  public enqueue(uri: vscode.Uri, change: PendingChange): void {
+    this.pending.set(uri.toString(), { uri, change });
+    if (this.timer) {
+      clearTimeout(this.timer);
+    }
+    this.timer = setTimeout(() => {
+      this.timer = undefined;
+      void this.flush();
+    }, this.debounceMs);
+  }
+
+  /**
+   * Applies all pending changes immediately and notifies listeners if anything changed.
+   */
+  public async flush(): Promise<void> {
+    if (this.timer) {
+      clearTimeout(this.timer);
+      this.timer = undefined;
+    }
+    const batch = [...this.pending.values()];
+    this.pending.clear();
+
+    const touched = new Set<RootMetrics>();
+    for (const { uri, change } of batch) {
+      const root = this.findRoot(uri);
+      if (root && (await this.apply(root, uri, change))) {
+        touched.add(root);
+      }
+    }
+
+    for (const root of touched) {
+      root.files.sort((a, b) => a.relativePath.localeCompare(b.relativePath));
+    }
+    if (touched.size > 0) {
+      this.emitter.fire(this.metrics);
+    }
+  }
+
+  public dispose(): void {
+    if (this.timer) {
+      clearTimeout(this.timer);
+      this.timer = undefined;
+    }
+    this.pending.clear();
+    this.subscriptions.forEach((d) => d.dispose());
+    this.watcher.dispose();
+    this.emitter.dispose();
+  }
+
+  /** Finds the analyzed root whose folder (or analyzed subfolder) contains a file. */
+  private findRoot(uri: vscode.Uri): RootMetrics | undefined {
+    return this.metrics.roots.find((root) => {
+      const folderUri = root.scope ?? root.folder?.uri;
+      return (
+        folderUri !== undefined &&
+        folderUri.scheme === uri.scheme &&
+        uri.path.startsWith(folderUri.path.endsWith("/") ? folderUri.path : `${folderUri.path}/`)
+      );
+    });
+  }
+
+  /**
+   * Re-analyzes or removes one file in a root.
+   *
+   * @returns true if the root's results changed
+   */
+  private async apply(
+    root: RootMetrics,
+    uri: vscode.Uri,
+    change: PendingChange
+  ): Promise<boolean> {
+    const key = uri.toString();
+    const index = root.files.findIndex((f) => f.uri.toString() === key);
+
+    const file =
+      change === "changed" &&
+      root.folder &&
+      root.config.enabled &&
+      WorkspaceAnalyzer.isIncluded(uri, root.config, root.goModules, root.submodules)
+        ? await WorkspaceAnalyzer.analyzeUri(uri, root.folder, root.config, root.goModules)
+        : undefined;
+
+    if (file) {
+      if (index === -1) {
+        root.files.push(file);
+      } else {
+        root.files[index] = file;
+      }
+      return true;
+    }
+    if (index !== -1) {
+      // Deleted, now excluded, or no longer readable.
+      root.files.splice(index, 1);
+      return true;
+    }
+    return false;
+  }
+}